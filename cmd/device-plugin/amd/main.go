@@ -0,0 +1,178 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	cli "github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/amddevice"
+	"github.com/Project-HAMi/HAMi/pkg/device/amd"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	flagutil "github.com/Project-HAMi/HAMi/pkg/util/flag"
+)
+
+func main() {
+	c := cli.NewApp()
+	c.Name = "AMD Device Plugin"
+	c.Usage = "Device plugin sharing AMD GPUs by slices of their memory and cores"
+	c.Action = func(ctx *cli.Context) error {
+		flagutil.PrintCliFlags(ctx)
+		return start(ctx)
+	}
+
+	flagset := flag.NewFlagSet("klog", flag.ExitOnError)
+	klog.InitFlags(flagset)
+
+	c.Before = func(ctx *cli.Context) error {
+		logLevel := ctx.Int("v")
+		if err := flagset.Set("v", fmt.Sprintf("%d", logLevel)); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config-file",
+			Value:   "/device-config.yaml",
+			Usage:   "the path of the device config file, the GPUs are sliced by its amd section",
+			EnvVars: []string{"CONFIG_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "rocm-smi",
+			Value:   "/opt/rocm/bin/rocm-smi",
+			Usage:   "the path of the rocm-smi discovering the GPUs of the node",
+			EnvVars: []string{"ROCM_SMI"},
+		},
+		&cli.StringFlag{
+			Name:  "sysfs",
+			Value: "/sys",
+			Usage: "the path sysfs is mounted at, the render nodes of the GPUs are read from",
+		},
+		&cli.IntFlag{
+			Name:  "v",
+			Value: 0,
+			Usage: "number for the log level verbosity",
+		},
+	}
+
+	if err := c.Run(os.Args); err != nil {
+		klog.Error(err)
+		os.Exit(1)
+	}
+}
+
+func loadConfig(path string) (amd.AMDConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return amd.AMDConfig{}, err
+	}
+	var config struct {
+		AMDConfig amd.AMDConfig `yaml:"amd"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return amd.AMDConfig{}, err
+	}
+	if config.AMDConfig.DeviceSplitCount == 0 {
+		config.AMDConfig.DeviceSplitCount = amd.DefaultDeviceSplitCount
+	}
+	return config.AMDConfig, nil
+}
+
+func start(c *cli.Context) error {
+	util.NodeName = os.Getenv(util.NodeNameEnvName)
+	client.InitGlobalClient()
+	config, err := loadConfig(c.String("config-file"))
+	if err != nil {
+		return fmt.Errorf("unable to load config: %v", err)
+	}
+	klog.Infof("Start working on node %s with config %+v", util.NodeName, config)
+	devices, err := amddevice.DiscoverDevices(c.String("rocm-smi"), config.DeviceSplitCount)
+	if err != nil {
+		return fmt.Errorf("unable to discover GPUs: %v", err)
+	}
+	renderNodes, err := amddevice.RenderNodes(c.String("sysfs"), devices)
+	if err != nil {
+		return fmt.Errorf("unable to find the render nodes of GPUs: %v", err)
+	}
+	for _, dev := range devices {
+		klog.Infof("Discovered GPU %s %s with %d MiB at %s", dev.ID, dev.Type, dev.Devmem, renderNodes[dev.ID])
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create FS watcher: %v", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(kubeletdevicepluginv1beta1.DevicePluginPath); err != nil {
+		return fmt.Errorf("failed to watch %s: %v", kubeletdevicepluginv1beta1.DevicePluginPath, err)
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+	plugin := amddevice.NewAMDDevicePlugin(config, util.NodeName, devices, renderNodes)
+	stop := make(chan struct{})
+	defer close(stop)
+	go plugin.WatchAndRegister(stop)
+
+	var restartTimeout <-chan time.Time
+restart:
+	restartTimeout = nil
+	plugin.Stop()
+	if err := plugin.Start(); err != nil {
+		klog.Info("Failed to start the plugin. Retrying in 30s...")
+		restartTimeout = time.After(30 * time.Second)
+	}
+
+	for {
+		select {
+		case <-restartTimeout:
+			goto restart
+
+		// Detect a kubelet restart by watching for a newly created
+		// 'kubeletdevicepluginv1beta1.KubeletSocket' file. When this occurs, restart the plugin.
+		case event := <-watcher.Events:
+			if event.Name == kubeletdevicepluginv1beta1.KubeletSocket && event.Op&fsnotify.Create == fsnotify.Create {
+				klog.Infof("inotify: %s created, restarting.", kubeletdevicepluginv1beta1.KubeletSocket)
+				goto restart
+			}
+
+		case err := <-watcher.Errors:
+			klog.Errorf("inotify: %s", err)
+
+		case s := <-sigs:
+			if s == syscall.SIGHUP {
+				klog.Info("Received SIGHUP, restarting.")
+				goto restart
+			}
+			klog.Infof("Received signal \"%v\", shutting down.", s)
+			return plugin.Stop()
+		}
+	}
+}
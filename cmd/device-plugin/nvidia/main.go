@@ -17,15 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"syscall"
 	"time"
 
 	spec "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
 	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	cli "github.com/urfave/cli/v2"
 	errorsutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
@@ -37,6 +41,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 	flagutil "github.com/Project-HAMi/HAMi/pkg/util/flag"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 func main() {
@@ -185,6 +190,24 @@ func start(c *cli.Context, flags []cli.Flag) error {
 	klog.Info("Starting FS watcher.")
 	util.NodeName = os.Getenv(util.NodeNameEnvName)
 	client.InitGlobalClient()
+	shutdownTracing, err := tracing.Init(context.Background(), "hami-device-plugin")
+	if err != nil {
+		return fmt.Errorf("init tracing error, %v", err)
+	}
+	defer shutdownTracing(context.Background())
+	if addr := c.String("metrics-bind-address"); addr != "" {
+		reg := prometheus.NewRegistry()
+		plugin.RegisterMetrics(reg)
+		reg.MustRegister(util.NewAnnotationPatchConflictsCounter("hami_device_plugin_annotation_patch_conflicts_total"))
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+		go func() {
+			klog.Infof("Serving metrics on %s", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				klog.Errorf("Failed to serve metrics: %v", err)
+			}
+		}()
+	}
 	watcher, err := newFSWatcher(kubeletdevicepluginv1beta1.DevicePluginPath)
 	if err != nil {
 		return fmt.Errorf("failed to create FS watcher: %v", err)
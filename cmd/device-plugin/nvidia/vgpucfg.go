@@ -63,6 +63,12 @@ func addFlags() []cli.Flag {
 			Usage:   "If set, the core utilization limit will be ignored",
 			EnvVars: []string{"DISABLE_CORE_LIMIT"},
 		},
+		&cli.StringFlag{
+			Name:    "metrics-bind-address",
+			Value:   "",
+			Usage:   "the address the metrics of the device plugin are served on, e.g. :9395; disabled if empty",
+			EnvVars: []string{"METRICS_BIND_ADDRESS"},
+		},
 		&cli.StringFlag{
 			Name:  "resource-name",
 			Value: "nvidia.com/gpu",
@@ -0,0 +1,112 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// schedulerAPI reaches the HTTP API of the scheduler.
+type schedulerAPI interface {
+	get(ctx context.Context, path string) ([]byte, error)
+	post(ctx context.Context, path string, body []byte) ([]byte, error)
+}
+
+// newSchedulerAPI returns the API at schedulerURL if set, otherwise proxied by kube-apiserver to the http port of
+// schedulerService, so no port needs to be exposed or forwarded.
+func newSchedulerAPI(kube kubernetes.Interface) (schedulerAPI, error) {
+	if schedulerURL != "" {
+		return &httpAPI{
+			url: strings.TrimSuffix(schedulerURL, "/"),
+			client: &http.Client{
+				Timeout:   30 * time.Second,
+				Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipTLSVerify}},
+			},
+		}, nil
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(schedulerService)
+	if err != nil || namespace == "" {
+		return nil, fmt.Errorf("invalid scheduler service %q, must be namespace/name", schedulerService)
+	}
+	return &proxyAPI{kube: kube, namespace: namespace, name: name}, nil
+}
+
+type httpAPI struct {
+	url    string
+	client *http.Client
+}
+
+func (a *httpAPI) get(ctx context.Context, path string) ([]byte, error) {
+	return a.do(ctx, http.MethodGet, path, nil)
+}
+
+func (a *httpAPI) post(ctx context.Context, path string, body []byte) ([]byte, error) {
+	return a.do(ctx, http.MethodPost, path, body)
+}
+
+func (a *httpAPI) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// proxyAPI reaches the scheduler through the service proxy of kube-apiserver, it needs the get and create verbs
+// of services/proxy.
+type proxyAPI struct {
+	kube      kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func (a *proxyAPI) get(ctx context.Context, path string) ([]byte, error) {
+	return a.request(a.kube.CoreV1().RESTClient().Get(), path).DoRaw(ctx)
+}
+
+func (a *proxyAPI) post(ctx context.Context, path string, body []byte) ([]byte, error) {
+	return a.request(a.kube.CoreV1().RESTClient().Post(), path).SetHeader("Content-Type", "application/json").Body(body).DoRaw(ctx)
+}
+
+func (a *proxyAPI) request(r *rest.Request, path string) *rest.Request {
+	// The scheduler serves TLS on the port named http of its service.
+	return r.Namespace(a.namespace).Resource("services").Name("https:" + a.name + ":http").SubResource("proxy").Suffix(path)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// allocatedAnnotationSuffix is the suffix of the annotations the devices allocated to a pod are recorded in by
+// every vendor, e.g. hami.io/vgpu-devices-allocated.
+const allocatedAnnotationSuffix = "-devices-allocated"
+
+// describeCmd prints the devices assigned to a pod and the limits the device plugin enforces on them.
+var describeCmd = &cobra.Command{
+	Use:   "describe pod NAMESPACE/NAME",
+	Short: "print the devices assigned to a pod and their enforced limits",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] != "pod" && args[0] != "pods" && args[0] != "po" {
+			return fmt.Errorf("unsupported resource %q, only pods can be described", args[0])
+		}
+		namespace, name, err := cache.SplitMetaNamespaceKey(args[1])
+		if err != nil {
+			return err
+		}
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		kube, _, err := newClients()
+		if err != nil {
+			return err
+		}
+		pod, err := kube.CoreV1().Pods(namespace).Get(cmd.Context(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		desc := describePod(pod)
+		if output == "json" {
+			return printJSON(os.Stdout, desc)
+		}
+		return printPodDescription(os.Stdout, desc)
+	},
+}
+
+// podDescription is the scheduling state of a pod recorded in its annotations by HAMi.
+type podDescription struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Node is the node the pod is bound to, AssignedNode the node its devices are assigned on.
+	Node         string       `json:"node,omitempty"`
+	AssignedNode string       `json:"assignedNode,omitempty"`
+	AssignedTime *metav1.Time `json:"assignedTime,omitempty"`
+	BindPhase    string       `json:"bindPhase,omitempty"`
+	BindTime     *metav1.Time `json:"bindTime,omitempty"`
+	GPUModel     string       `json:"gpuModel,omitempty"`
+	// OverriddenEnvs are the environment variables owned by the device plugin the webhook removed by container.
+	OverriddenEnvs map[string][]string `json:"overriddenEnvs,omitempty"`
+	Devices        []assignedDevice    `json:"devices"`
+}
+
+// assignedDevice is a device assigned to a container, with the limits HAMi-core enforces in the container.
+type assignedDevice struct {
+	Container string `json:"container"`
+	UUID      string `json:"uuid"`
+	Type      string `json:"type"`
+	// MemoryLimit is in MB, CoreLimit in percent of the device, 0 if not limited.
+	MemoryLimit int32 `json:"memoryLimit"`
+	CoreLimit   int32 `json:"coreLimit"`
+}
+
+func describePod(pod *corev1.Pod) *podDescription {
+	desc := &podDescription{
+		Namespace:    pod.Namespace,
+		Name:         pod.Name,
+		Node:         pod.Spec.NodeName,
+		AssignedNode: pod.Annotations[util.AssignedNodeAnnotations],
+		AssignedTime: unixTime(pod.Annotations[util.AssignedTimeAnnotations]),
+		BindPhase:    pod.Annotations[util.DeviceBindPhase],
+		BindTime:     unixTime(pod.Annotations[util.BindTimeAnnotations]),
+		GPUModel:     pod.Annotations[util.GPUModelSelectedAnnotationKey],
+		Devices:      make([]assignedDevice, 0),
+	}
+	if v := pod.Annotations[util.OverriddenEnvsAnnotationKey]; v != "" {
+		if err := json.Unmarshal([]byte(v), &desc.OverriddenEnvs); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: invalid %s annotation: %v\n", util.OverriddenEnvsAnnotationKey, err)
+		}
+	}
+	keys := make([]string, 0)
+	for key := range pod.Annotations {
+		if strings.HasSuffix(key, allocatedAnnotationSuffix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// Devices are recorded for every container in the order of the spec, separated by ";".
+		for i, s := range strings.Split(pod.Annotations[key], device.OnePodMultiContainerSplitSymbol) {
+			devs, err := device.DecodeContainerDevices(s)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: invalid %s annotation: %v\n", key, err)
+				break
+			}
+			ctr := fmt.Sprintf("#%d", i)
+			if i < len(pod.Spec.Containers) {
+				ctr = pod.Spec.Containers[i].Name
+			}
+			for _, d := range devs {
+				desc.Devices = append(desc.Devices, assignedDevice{
+					Container:   ctr,
+					UUID:        d.UUID,
+					Type:        d.Type,
+					MemoryLimit: d.Usedmem,
+					CoreLimit:   d.Usedcores,
+				})
+			}
+		}
+	}
+	return desc
+}
+
+// unixTime returns the time of the annotation in unix seconds, nil if it isn't set.
+func unixTime(v string) *metav1.Time {
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := metav1.NewTime(time.Unix(sec, 0))
+	return &t
+}
+
+func printPodDescription(out io.Writer, desc *podDescription) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Pod:\t%s/%s\n", desc.Namespace, desc.Name)
+	fmt.Fprintf(w, "Node:\t%s\n", orNone(desc.Node))
+	fmt.Fprintf(w, "Assigned node:\t%s\n", orNone(desc.AssignedNode))
+	if desc.AssignedTime != nil {
+		fmt.Fprintf(w, "Assigned at:\t%s\n", desc.AssignedTime.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Bind phase:\t%s\n", orNone(desc.BindPhase))
+	if desc.BindTime != nil {
+		fmt.Fprintf(w, "Bound at:\t%s\n", desc.BindTime.UTC().Format(time.RFC3339))
+	}
+	if desc.GPUModel != "" {
+		fmt.Fprintf(w, "GPU model:\t%s\n", desc.GPUModel)
+	}
+	ctrs := make([]string, 0, len(desc.OverriddenEnvs))
+	for ctr := range desc.OverriddenEnvs {
+		ctrs = append(ctrs, ctr)
+	}
+	sort.Strings(ctrs)
+	for _, ctr := range ctrs {
+		fmt.Fprintf(w, "Overridden envs of %s:\t%s\n", ctr, strings.Join(desc.OverriddenEnvs[ctr], ","))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(desc.Devices) == 0 {
+		_, err := fmt.Fprintln(out, "Devices: <none>")
+		return err
+	}
+	fmt.Fprintln(out, "Devices:")
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  CONTAINER\tDEVICE\tTYPE\tMEMORY LIMIT(MB)\tCORE LIMIT(%)")
+	for _, d := range desc.Devices {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%s\n", d.Container, d.UUID, d.Type, d.MemoryLimit, coreLimit(d.CoreLimit))
+	}
+	return w.Flush()
+}
+
+func coreLimit(cores int32) string {
+	if cores == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(int(cores))
+}
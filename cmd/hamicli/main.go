@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/version"
+)
+
+var (
+	schedulerURL          string
+	schedulerService      string
+	insecureSkipTLSVerify bool
+	output                string
+
+	rootCmd = &cobra.Command{
+		Use:           "hami-cli",
+		Short:         "inspect device usage and simulate placements of the HAMi scheduler",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if output != "" && output != "json" {
+				return fmt.Errorf("unsupported output %q, must be json or empty for a table", output)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&schedulerURL, "scheduler-url", "", "URL of the scheduler HTTP API, e.g. https://127.0.0.1:8443 with a port-forward; the scheduler service is reached through the kube-apiserver proxy if empty")
+	rootCmd.PersistentFlags().StringVar(&schedulerService, "scheduler-service", "kube-system/hami-scheduler", "namespace/name of the Service of the scheduler reached through the kube-apiserver proxy")
+	rootCmd.PersistentFlags().BoolVar(&insecureSkipTLSVerify, "insecure-skip-tls-verify", false, "don't verify the serving certificate of --scheduler-url, e.g. the self-signed one of the webhook")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "output format, json or empty for a table")
+	rootCmd.AddCommand(nodesCmd)
+	rootCmd.AddCommand(describeCmd)
+	rootCmd.AddCommand(simulateCmd)
+	rootCmd.AddCommand(version.VersionCmd)
+}
+
+// newClients returns the clients of the cluster and of the scheduler API, from the kubeconfig of KUBECONFIG or
+// ~/.kube/config.
+func newClients() (*client.Client, schedulerAPI, error) {
+	kube, err := client.NewClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	api, err := newSchedulerAPI(kube)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kube, api, nil
+}
+
+// printJSON prints v as indented JSON for -o json.
+func printJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	apiv1 "github.com/Project-HAMi/HAMi/pkg/scheduler/api/v1"
+)
+
+// nodesCmd prints the usage of every device registered to the scheduler.
+var nodesCmd = &cobra.Command{
+	Use:   "nodes",
+	Short: "print the usage of the devices of every node",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, api, err := newClients()
+		if err != nil {
+			return err
+		}
+		data, err := api.get(cmd.Context(), apiv1.PathPrefix+"/nodes")
+		if err != nil {
+			return err
+		}
+		var list apiv1.NodeList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("decode nodes: %v", err)
+		}
+		if output == "json" {
+			return printJSON(os.Stdout, list)
+		}
+		return printNodes(os.Stdout, &list)
+	},
+}
+
+func printNodes(out io.Writer, list *apiv1.NodeList) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tDEVICE\tTYPE\tHEALTH\tSHARES\tMEMORY(MB)\tCORES(%)\tPODS")
+	for _, node := range list.Items {
+		for _, d := range node.Devices {
+			pods := make([]string, 0, len(d.Pods))
+			for _, p := range d.Pods {
+				pods = append(pods, p.Namespace+"/"+p.Name)
+			}
+			health := "healthy"
+			if !d.Health {
+				health = "unhealthy"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%d\t%d/%d\t%d/%d\t%s\n", node.Name, d.ID, d.Type, health,
+				d.Used, d.Count, d.UsedMemory, d.TotalMemory, d.UsedCores, d.TotalCores, orNone(strings.Join(pods, ",")))
+		}
+		for _, o := range node.Orphaned {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t-\t%d/-\t%d/-\t%s\n", node.Name, o.ID, "-", "orphaned",
+				o.UsedMemory, o.UsedCores, o.Pod.Namespace+"/"+o.Pod.Name)
+		}
+	}
+	return w.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler"
+)
+
+var (
+	podFile       string
+	simulateNodes []string
+)
+
+// simulateCmd fits a pod to the nodes with the filter logic of the scheduler, without allocating or binding anything.
+var simulateCmd = &cobra.Command{
+	Use:   "simulate -f POD_FILE",
+	Short: "print where the scheduler would place a pod and why other nodes don't fit, without scheduling it",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pod, err := readPod(podFile)
+		if err != nil {
+			return err
+		}
+		_, api, err := newClients()
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(scheduler.SimulateRequest{Pod: pod, NodeNames: simulateNodes})
+		if err != nil {
+			return err
+		}
+		data, err := api.post(cmd.Context(), "/scheduler/simulate", body)
+		if err != nil {
+			return err
+		}
+		var res scheduler.SimulateResponse
+		if err := json.Unmarshal(data, &res); err != nil {
+			return fmt.Errorf("decode simulation: %v", err)
+		}
+		if res.ErrorMessage != "" {
+			return errors.New(res.ErrorMessage)
+		}
+		if output == "json" {
+			return printJSON(os.Stdout, res)
+		}
+		return printSimulation(os.Stdout, &res)
+	},
+}
+
+func init() {
+	simulateCmd.Flags().StringVarP(&podFile, "filename", "f", "", "YAML or JSON file of the pod to simulate, - for stdin")
+	simulateCmd.Flags().StringSliceVar(&simulateNodes, "nodes", nil, "candidate nodes, all the nodes with devices registered if empty")
+	_ = simulateCmd.MarkFlagRequired("filename")
+}
+
+func readPod(file string) (*corev1.Pod, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	pod := &corev1.Pod{}
+	if err := yaml.NewYAMLOrJSONDecoder(r, 4096).Decode(pod); err != nil {
+		return nil, fmt.Errorf("decode pod from %s: %v", file, err)
+	}
+	if pod.Kind != "" && pod.Kind != "Pod" {
+		return nil, fmt.Errorf("%s is a %s, not a Pod", file, pod.Kind)
+	}
+	return pod, nil
+}
+
+func printSimulation(out io.Writer, res *scheduler.SimulateResponse) error {
+	if res.Node == "" {
+		fmt.Fprintln(out, "Selected node: <none>, the pod fits no node")
+	} else {
+		fmt.Fprintf(out, "Selected node: %s\n", res.Node)
+	}
+	if res.GPUModel != "" {
+		fmt.Fprintf(out, "GPU model: %s\n", res.GPUModel)
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tFITS\tSCORE\tDEVICES\tREASON")
+	for _, n := range res.Nodes {
+		if !n.Fits {
+			fmt.Fprintf(w, "%s\tfalse\t-\t-\t%s\n", n.Name, n.Reason)
+			continue
+		}
+		devs := make([]string, 0)
+		for _, podSingle := range n.Devices {
+			for _, ctrDevices := range podSingle {
+				for _, d := range ctrDevices {
+					devs = append(devs, fmt.Sprintf("%s(%dMB,%d%%)", d.UUID, d.Usedmem, d.Usedcores))
+				}
+			}
+		}
+		sort.Strings(devs)
+		fmt.Fprintf(w, "%s\ttrue\t%.2f\t%s\t-\n", n.Name, n.Score, orNone(strings.Join(devs, ",")))
+	}
+	return w.Flush()
+}
@@ -17,23 +17,38 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"k8s.io/client-go/tools/cache"
 	klog "k8s.io/klog/v2"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler"
+	apiv1 "github.com/Project-HAMi/HAMi/pkg/scheduler/api/v1"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/routes"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/webhookcert"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/faultinject"
 	"github.com/Project-HAMi/HAMi/pkg/util/flag"
 	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 	"github.com/Project-HAMi/HAMi/pkg/version"
 )
 
@@ -49,7 +64,7 @@ var (
 		Short: "kubernetes vgpu scheduler",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			flag.PrintPFlags(cmd.Flags())
-			return start()
+			return start(cmd.Flags())
 		},
 	}
 )
@@ -59,6 +74,7 @@ func init() {
 	rootCmd.PersistentFlags().SortFlags = false
 
 	rootCmd.Flags().StringVar(&config.HTTPBind, "http_bind", "127.0.0.1:8080", "http server bind address")
+	rootCmd.Flags().StringVar(&config.GRPCBind, "grpc-bind", "", "gRPC extender server bind address, e.g. 127.0.0.1:9090; disabled if empty")
 	rootCmd.Flags().StringVar(&tlsCertFile, "cert_file", "", "tls cert file")
 	rootCmd.Flags().StringVar(&tlsKeyFile, "key_file", "", "tls key file")
 	rootCmd.Flags().StringVar(&config.SchedulerName, "scheduler-name", "", "the name to be added to pod.spec.schedulerName if not empty")
@@ -67,21 +83,138 @@ func init() {
 	rootCmd.Flags().Int32Var(&config.DefaultResourceNum, "default-gpu", 1, "default gpu to allocate")
 	rootCmd.Flags().StringVar(&config.NodeSchedulerPolicy, "node-scheduler-policy", util.NodeSchedulerPolicyBinpack.String(), "node scheduler policy")
 	rootCmd.Flags().StringVar(&device.GPUSchedulerPolicy, "gpu-scheduler-policy", util.GPUSchedulerPolicySpread.String(), "GPU scheduler policy")
+	rootCmd.Flags().StringVar(&device.DefaultDevicePool, "default-device-pool", "", "device pool of pods without the hami.io/device-pool annotation; devices in no pool if empty")
+	rootCmd.Flags().Int32Var(&device.MaxTemperature, "gpu-max-temperature", 0, "exclude GPUs hotter than this temperature in Celsius; disabled if 0")
+	rootCmd.Flags().Int32Var(&device.StrictMaxTemperature, "gpu-strict-max-temperature", 80, "exclude GPUs hotter than this temperature in Celsius for pods annotated with hami.io/gpu-health-policy=strict")
+	rootCmd.Flags().DurationVar(&device.ECCErrorWindow, "gpu-ecc-error-window", time.Hour*24, "exclude GPUs with uncorrectable ECC errors seen within this window, pods annotated with hami.io/gpu-health-policy=strict exclude GPUs with any")
+	rootCmd.Flags().DurationVar(&device.HandshakeClockTolerance, "handshake-clock-tolerance", 30*time.Second, "how far the clock of the writer of a device plugin handshake request may lag behind the scheduler, for requests the scheduler didn't see written, e.g. after it restarted")
 	rootCmd.Flags().StringVar(&config.MetricsBindAddress, "metrics-bind-address", ":9395", "The TCP address that the scheduler should bind to for serving prometheus metrics(e.g. 127.0.0.1:9395, :9395)")
 	rootCmd.Flags().StringToStringVar(&config.NodeLabelSelector, "node-label-selector", nil, "key=value pairs separated by commas")
+	rootCmd.Flags().DurationVar(&config.ReservationTTL, "reservation-ttl", 5*time.Minute, "release the devices reserved for a pod by filter if it isn't bound within this duration, they're reserved again when the pod is retried; disabled if 0")
+	rootCmd.Flags().DurationVar(&config.BindFailureCooldown, "bind-failure-cooldown", 30*time.Second, "deprioritize the node a pod failed to bind to for its retries within this duration, so they try other nodes first; disabled if 0")
+	rootCmd.Flags().StringVar(&config.ContainerRuntime, "container-runtime", "", "default container runtime, e.g. containerd, of the nodes to place pods requesting devices on, read from the hami.io/container-runtime label or the status of nodes; pods can override it with the hami.io/container-runtime annotation; any runtime if empty")
+	rootCmd.Flags().StringSliceVar(&config.AllowedRuntimeClasses, "allowed-runtime-classes", nil, "runtime classes pods requesting devices may use, applied after the default runtime class of the device config; pods setting no runtime class or another one are denied; every runtime class if empty")
+	rootCmd.Flags().StringVar(&config.ContainerRuntimeAffinity, "container-runtime-affinity", string(util.ContainerRuntimeAffinityStrict), "default affinity of pods to the nodes running their container runtime: strict or preferred; pods can override it with the hami.io/container-runtime-affinity annotation")
+	rootCmd.Flags().Int64Var(&config.MaxMemoryPerAllocation, "max-memory-per-allocation", 0, "most device memory in MiB a container may be allocated on a single device, pods requesting more are denied at admission; namespaces can override it with the maxMemoryPerAllocation of their budget in the device config; unlimited if 0")
+	rootCmd.Flags().StringVar(&config.SuggestedPlacementMode, "suggested-placement-mode", string(util.SuggestedPlacementPreferred), "default mode of the placements suggested for pods by external optimizers with the hami.io/suggested-node-uuids annotation: preferred or strict; pods can override it with the hami.io/suggested-placement-mode annotation")
+	rootCmd.Flags().StringVar(&config.VolumeLocality, "volume-locality", string(util.VolumeLocalityNone), "default locality of pods to the node their node-local persistent volumes are on: none, preferred or strict; pods can override it with the hami.io/volume-locality annotation")
+	rootCmd.Flags().BoolVar(&config.EvictOrphanedAssignments, "evict-orphaned-assignments", false, "delete pods assigned devices no longer registered on their node, e.g. after the GPU was replaced, so their controller recreates them")
+	rootCmd.Flags().DurationVar(&config.OrphanedAssignmentGracePeriod, "orphaned-assignment-grace-period", 10*time.Minute, "how long a pod must be assigned devices no longer registered on its node before it's deleted by --evict-orphaned-assignments")
+	rootCmd.Flags().DurationVar(&config.MissingDeviceExclusion, "missing-device-exclusion", 10*time.Minute, "exclude devices the device plugin found gone from their node on allocation, e.g. fallen off the bus, for this duration while their pod is rescheduled; disabled if 0")
+	rootCmd.Flags().BoolVar(&config.AllowUnmanagedWholeDevices, "allow-unmanaged-whole-devices", false, "place pods only requesting a count of whole devices on nodes advertising the device resources without HAMi registration, e.g. running the upstream device plugin, when no HAMi-managed node fits; sliced requests never go there")
+	rootCmd.Flags().DurationVar(&config.AllocationLeaseDuration, "allocation-lease-duration", 0, "keep other scheduler replicas from allocating to a node within this duration after an allocation to it until they have seen the allocated pod, required when running several replicas active/active; disabled if 0")
+	rootCmd.Flags().StringVar(&config.AdmissionDecisionLog, "admission-decision-log", "", "log every admission decision of the webhook as a JSON line, e.g. for a SIEM, to stdout, stderr or appended to a file path, regardless of the log verbosity; disabled if empty")
+	rootCmd.Flags().StringSliceVar(&config.WebhookIncludeOwnerKinds, "webhook-include-owner-kinds", nil, "kinds of the owners of the pods the webhook mutates, e.g. Deployment,Job, None for pods without owner; every kind if empty")
+	rootCmd.Flags().StringSliceVar(&config.WebhookExcludeOwnerKinds, "webhook-exclude-owner-kinds", nil, "kinds of the owners of the pods the webhook never mutates, e.g. DaemonSet, None for pods without owner; takes precedence over --webhook-include-owner-kinds")
+	rootCmd.Flags().StringVar(&config.OwnedEnvPolicy, "owned-env-policy", string(util.OwnedEnvPolicyAllow), "what to do with containers setting environment variables owned by the device plugin of the devices they request, e.g. CUDA_DEVICE_MEMORY_LIMIT: allow them, deny the pod, or override them by removing them and recording them in the hami.io/overridden-envs annotation")
+	rootCmd.Flags().BoolVar(&config.EnforceLimitRanges, "enforce-limit-ranges", false, "apply the defaults of the LimitRanges of the namespace to the device resources containers requesting devices omit, and deny pods whose device resources are out of their min and max")
+	rootCmd.Flags().StringToStringVar(&config.DefaultGPURequestLabels, "default-gpu-request-labels", nil, "key=value pairs separated by commas, pods carrying all these labels without requesting any device, e.g. legacy CI jobs labeled needs-gpu=true, are given a request of a single NVIDIA GPU; disabled if empty")
+	rootCmd.Flags().Int32Var(&config.DefaultGPURequestMemory, "default-gpu-request-memory", 0, "GPU memory in MB requested for pods matching --default-gpu-request-labels, the default of the device config if 0")
+	rootCmd.Flags().Int32Var(&config.DefaultGPURequestCores, "default-gpu-request-cores", 0, "GPU cores in percent requested for pods matching --default-gpu-request-labels, the default of the device config if 0")
+	rootCmd.Flags().BoolVar(&config.CleanupStaleAnnotations, "cleanup-stale-annotations", false, "periodically remove the registration annotations of device vendors whose handshake on a node is older than --stale-registration-age, and the assignment annotations of pods finished for --finished-pod-annotation-retention")
+	rootCmd.Flags().DurationVar(&config.StaleRegistrationAge, "stale-registration-age", 24*time.Hour, "age of the handshake of a device vendor on a node past which its registration is removed by --cleanup-stale-annotations")
+	rootCmd.Flags().DurationVar(&config.FinishedPodAnnotationRetention, "finished-pod-annotation-retention", 24*time.Hour, "duration succeeded and failed pods keep their assignment annotations with --cleanup-stale-annotations")
+	rootCmd.Flags().BoolVar(&config.InPlaceGPUResize, "in-place-gpu-resize", false, "resize the NVIDIA GPUs assigned to running pods whose containers change the GPU memory or cores they request in place; shrinks always succeed, grows only if the devices have room and are marked Infeasible otherwise")
+	rootCmd.Flags().DurationVar(&config.DeviceResidencyWarning, "device-residency-warning", 24*time.Hour, "how long before the maxDeviceResidency of the device config pods are annotated with hami.io/device-residency-deadline and warned by an event")
+	rootCmd.Flags().BoolVar(&config.EvictExpiredDeviceResidency, "evict-expired-device-residency", false, "evict the pods owned by a controller past the maxDeviceResidency of the device config, within their pod disruption budgets, so they're rescheduled to other devices")
+	rootCmd.Flags().BoolVar(&config.Defragment, "defragment", false, "periodically evict the pods labeled hami.io/rebalance=true, owned by a controller and of a priority up to --defragment-max-priority, whose rescheduling consolidates partially used devices, within their pod disruption budgets")
+	rootCmd.Flags().DurationVar(&config.DefragmentInterval, "defragment-interval", 30*time.Minute, "interval of the defragmentation by --defragment")
+	rootCmd.Flags().Int32Var(&config.DefragmentMaxPriority, "defragment-max-priority", 0, "highest priority of the pods evicted by --defragment")
+	rootCmd.Flags().IntVar(&config.DefragmentMaxEvictions, "defragment-max-evictions", 5, "most pods evicted by each defragmentation of --defragment, unlimited if 0")
+	rootCmd.Flags().BoolVar(&config.ExportDeviceAllocations, "export-device-allocations", false, "mirror the device allocation of each node into a cluster scoped DeviceAllocation object of hami.io/v1alpha1 named after the node, whose CRD must be installed")
+	rootCmd.Flags().BoolVar(&config.SetRequestsToLimits, "set-requests-to-limits", false, "set the cpu and memory requests of containers of pods requesting devices to their limits")
+	rootCmd.Flags().BoolVar(&config.ManageNodeLabels, "manage-node-labels", true, "label nodes with the types (hami.io/devicetype.<type>) and mode (hami.io/vgpu-mode) of their registered devices, removing stale labels")
 
 	rootCmd.Flags().Float32Var(&config.QPS, "kube-qps", client.DefaultQPS, "QPS to use while talking with kube-apiserver.")
 	rootCmd.Flags().IntVar(&config.Burst, "kube-burst", client.DefaultBurst, "Burst to use while talking with kube-apiserver.")
 	rootCmd.Flags().IntVar(&config.Timeout, "kube-timeout", client.DefaultTimeout, "Timeout to use while talking with kube-apiserver.")
 	rootCmd.Flags().BoolVar(&enableProfiling, "profiling", false, "Enable pprof profiling via HTTP server")
 	rootCmd.Flags().DurationVar(&config.NodeLockTimeout, "node-lock-timeout", time.Minute*5, "timeout for node locks")
+	rootCmd.Flags().DurationVar(&config.ShutdownGracePeriod, "shutdown-grace-period", time.Second*20, "how long to wait for in-flight binds to finish on SIGTERM before releasing their node locks and restoring their pod annotations, must be shorter than the terminationGracePeriodSeconds of the pod")
+	rootCmd.Flags().DurationVar(&config.ExtenderDeadline, "extender-deadline", time.Second*25, "how long filter and bind requests may take before filter returns the nodes fit so far and bind is rolled back, must be shorter than the httpTimeout of the extender in the kube-scheduler config; disabled if 0")
+	rootCmd.Flags().BoolVar(&config.EnableFaultInjection, "enable-fault-injection", false, "inject the delays and errors set on the /fault-injection endpoint at named points, e.g. bind-patch; for testing only, never enable it in production")
+	rootCmd.Flags().BoolVar(&config.ConfigReload, "config-reload", false, "reload the scheduler section and the image allowlist of the device config file when it changes or on POST /reload")
 	rootCmd.Flags().BoolVar(&config.ForceOverwriteDefaultScheduler, "force-overwrite-default-scheduler", true, "Overwrite schedulerName in Pod Spec when set to the const DefaultSchedulerName in https://k8s.io/api/core/v1 package")
+	rootCmd.Flags().StringVar(&config.VolcanoSchedulerName, "volcano-scheduler-name", "", "act as device provider of volcano for pods with this schedulerName, e.g. volcano; disabled if empty")
+	rootCmd.Flags().StringVar(&config.UtilizationProfileConfigMap, "utilization-profile-configmap", "", "namespace/name of the ConfigMap of utilization profiles referenced by the hami.io/utilization-profile annotation of pods; disabled if empty")
+	rootCmd.Flags().StringVar(&config.KueueCapacityConfigMap, "kueue-capacity-configmap", "", "namespace/name of the ConfigMap to publish schedulable device capacity of the cluster to, e.g. for Kueue quota; disabled if empty")
+	rootCmd.Flags().StringSliceVar(&config.FreeMemoryBands, "free-memory-bands", nil, "bounds of the bands of free device memory to label nodes with in hami.io/free-gpumem-band, as quantities in ascending order, e.g. 10Gi,20Gi,40Gi for the bands 0-10Gi, 10Gi-20Gi, 20Gi-40Gi and over-40Gi; disabled if empty")
+	rootCmd.Flags().StringVar(&config.FreeMemoryBandHysteresis, "free-memory-band-hysteresis", "1Gi", "how far the free device memory of a node must leave its band before its hami.io/free-gpumem-band label changes")
+	rootCmd.Flags().StringVar(&config.FreeCapacityConfigMap, "free-capacity-configmap", "", "namespace/name of the ConfigMap to publish the number of nodes in each band of --free-memory-bands to, e.g. for multi-cluster placement; disabled if empty")
+	rootCmd.Flags().DurationVar(&config.NodeResyncPeriod, "node-resync-period", time.Minute*5, "period of full reconciles of node devices, node devices are synced on node events in between")
+	rootCmd.Flags().StringVar(&config.UtilizationSourceURL, "utilization-source-url", "", "URL of the Prometheus server or HAMi monitor metrics endpoint to scrape device utilization from for the utilization GPU scheduler policy; disabled if empty")
+	rootCmd.Flags().StringVar(&config.UtilizationQuery, "utilization-query", "", "Prometheus query of device utilization in percent labeled by deviceuuid, e.g. avg by (deviceuuid) (avg_over_time(HostCoreUtilization[5m])); the HAMi monitor metrics endpoint is read if empty")
+	rootCmd.Flags().DurationVar(&config.UtilizationScrapeInterval, "utilization-scrape-interval", time.Second*30, "interval of scraping device utilization, measurements older than three intervals are ignored")
+	rootCmd.Flags().StringVar(&config.RightSizingSourceURL, "right-sizing-source-url", "", "URL of the Prometheus server or HAMi monitor metrics endpoint to scrape the device memory usage of containers from, to annotate Deployments and StatefulSets whose peak usage is far from their request with hami.io/recommended-gpumem; disabled if empty")
+	rootCmd.Flags().StringVar(&config.RightSizingQuery, "right-sizing-query", "", "Prometheus query of the device memory usage of containers in bytes labeled by podnamespace, podname, ctrname and deviceuuid, e.g. max by (podnamespace, podname, ctrname, deviceuuid) (max_over_time(vGPU_device_memory_usage_in_bytes[5m])); the HAMi monitor metrics endpoint is read if empty")
+	rootCmd.Flags().DurationVar(&config.RightSizingInterval, "right-sizing-interval", time.Minute, "interval of scraping the device memory usage of containers for --right-sizing-source-url")
+	rootCmd.Flags().DurationVar(&config.RightSizingWindow, "right-sizing-window", 24*time.Hour, "how long the peak device memory usage of workloads is measured over, workloads are only recommended once observed that long")
+	rootCmd.Flags().Float64Var(&config.RightSizingThreshold, "right-sizing-threshold", 0.5, "share of the request the recommended device memory must differ from it by to be annotated, e.g. 0.5 for half")
+	rootCmd.Flags().BoolVar(&policy.ScoreJitter, "score-jitter", false, "break ties of node and device scores randomly to spread pods, instead of by node name and device UUID for reproducible placements")
+	rootCmd.Flags().Float32Var(&policy.UtilizationWeight, "utilization-weight", 0.5, "weight of the measured utilization between 0 and 1 in device scores of the utilization GPU scheduler policy")
+	rootCmd.Flags().BoolVar(&config.ManageWebhookConfig, "manage-webhook-config", false, "issue and rotate a self-signed serving certificate and write its CA bundle into the MutatingWebhookConfiguration, instead of using cert_file and key_file")
+	rootCmd.Flags().StringVar(&config.WebhookConfigName, "webhook-config-name", "hami-webhook", "name of the MutatingWebhookConfiguration to write the CA bundle into")
+	rootCmd.Flags().StringVar(&config.WebhookService, "webhook-service", "kube-system/hami-scheduler", "namespace/name of the Service the webhook is reached through")
+	rootCmd.Flags().StringVar(&config.WebhookCertSecret, "webhook-cert-secret", "hami-scheduler-tls", "name of the Secret in the namespace of the webhook Service keeping the certificates")
+	rootCmd.Flags().DurationVar(&config.WebhookCertValidity, "webhook-cert-validity", time.Hour*24*365, "validity of the serving certificate, it is rotated once a third of it is left")
+	rootCmd.Flags().BoolVar(&config.EnableDRA, "enable-dra", false, "allocate ResourceClaims of the DRA driver with HAMi device sharing as a DRA control plane controller, requires the resource.k8s.io/v1alpha3 API")
+	rootCmd.Flags().StringVar(&config.DRADriverName, "dra-driver-name", "gpu.hami.io", "name of the HAMi DRA driver, ResourceClaims with this controller are allocated")
 
 	rootCmd.PersistentFlags().AddGoFlagSet(config.GlobalFlagSet())
 	rootCmd.AddCommand(version.VersionCmd)
+	rootCmd.AddCommand(nodeTemplatesCmd)
 	rootCmd.Flags().AddGoFlagSet(util.InitKlogFlags())
 }
 
+// startWebhookCertManager provisions the serving certificate and keeps rotating it in the background.
+func startWebhookCertManager(ctx context.Context) (*webhookcert.Manager, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(config.WebhookService)
+	if err != nil || namespace == "" {
+		return nil, fmt.Errorf("invalid webhook service %q, must be namespace/name", config.WebhookService)
+	}
+	if config.WebhookCertValidity <= 0 {
+		return nil, fmt.Errorf("webhook cert validity must be positive")
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("get hostname error, %v", err)
+	}
+	m := webhookcert.NewManager(client.GetClient(), webhookcert.Config{
+		Namespace:         namespace,
+		ServiceName:       name,
+		SecretName:        config.WebhookCertSecret,
+		WebhookConfigName: config.WebhookConfigName,
+		Validity:          config.WebhookCertValidity,
+		Identity:          identity,
+	})
+	if err := m.Start(ctx, time.Minute); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// serveGRPC serves the gRPC extender, with TLS if the http server serves TLS.
+func serveGRPC(certManager *webhookcert.Manager) error {
+	var opts []grpc.ServerOption
+	if certManager != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{GetCertificate: certManager.GetCertificate})))
+	} else if len(tlsCertFile) > 0 && len(tlsKeyFile) > 0 {
+		creds, err := credentials.NewServerTLSFromFile(tlsCertFile, tlsKeyFile)
+		if err != nil {
+			return fmt.Errorf("load grpc tls credentials error, %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	lis, err := net.Listen("tcp", config.GRPCBind)
+	if err != nil {
+		return fmt.Errorf("grpc listen error, %v", err)
+	}
+	server := grpc.NewServer(opts...)
+	sher.RegisterExtenderServer(server)
+	klog.Info("grpc listen on ", config.GRPCBind)
+	return server.Serve(lis)
+}
+
 // injectProfilingRoute injects pprof routes into the router.
 func injectProfilingRoute(router *httprouter.Router) {
 	router.GET("/debug/pprof/*suffix", func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
@@ -101,7 +234,7 @@ func injectProfilingRoute(router *httprouter.Router) {
 	})
 }
 
-func start() error {
+func start(flags *pflag.FlagSet) error {
 	// Initialize node lock timeout from config
 	nodelock.NodeLockTimeout = config.NodeLockTimeout
 	klog.InfoS("Set node lock timeout", "timeout", nodelock.NodeLockTimeout)
@@ -111,21 +244,146 @@ func start() error {
 		client.WithTimeout(config.Timeout),
 	)
 
+	shutdownTracing, err := tracing.Init(context.Background(), "hami-scheduler")
+	if err != nil {
+		return fmt.Errorf("init tracing error, %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	switch util.VolumeLocality(config.VolumeLocality) {
+	case util.VolumeLocalityNone, util.VolumeLocalityPreferred, util.VolumeLocalityStrict:
+	default:
+		return fmt.Errorf("volume locality %q is not one of none, preferred and strict", config.VolumeLocality)
+	}
+	switch util.ContainerRuntimeAffinity(config.ContainerRuntimeAffinity) {
+	case util.ContainerRuntimeAffinityStrict, util.ContainerRuntimeAffinityPreferred:
+	default:
+		return fmt.Errorf("container runtime affinity %q is not one of strict and preferred", config.ContainerRuntimeAffinity)
+	}
+	switch util.SuggestedPlacementMode(config.SuggestedPlacementMode) {
+	case util.SuggestedPlacementPreferred, util.SuggestedPlacementStrict:
+	default:
+		return fmt.Errorf("suggested placement mode %q is not one of preferred and strict", config.SuggestedPlacementMode)
+	}
+	if config.MaxMemoryPerAllocation < 0 {
+		return fmt.Errorf("max memory per allocation %d must not be negative", config.MaxMemoryPerAllocation)
+	}
+	switch util.OwnedEnvPolicy(config.OwnedEnvPolicy) {
+	case util.OwnedEnvPolicyAllow, util.OwnedEnvPolicyDeny, util.OwnedEnvPolicyOverride:
+	default:
+		return fmt.Errorf("owned env policy %q is not one of allow, deny and override", config.OwnedEnvPolicy)
+	}
+
 	config.InitDevices()
+	if config.ConfigReload {
+		watchCtx, cancelWatch := context.WithCancel(context.Background())
+		defer cancelWatch()
+		go func() {
+			if err := config.WatchConfigFile(watchCtx); err != nil {
+				klog.ErrorS(err, "Failed to watch device config file, only reloading on requests")
+			}
+		}()
+	}
 	sher = scheduler.NewScheduler()
+	if len(config.FreeMemoryBands) > 0 {
+		bands, err := scheduler.ParseFreeMemoryBands(config.FreeMemoryBands, config.FreeMemoryBandHysteresis)
+		if err != nil {
+			return fmt.Errorf("invalid free memory bands, %v", err)
+		}
+		sher.SetFreeMemoryBands(bands)
+	}
+	if config.ExportDeviceAllocations {
+		dynamicClient, err := client.NewDynamicClient(
+			client.WithBurst(config.Burst),
+			client.WithQPS(config.QPS),
+			client.WithTimeout(config.Timeout),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create the client of device allocations, %v", err)
+		}
+		sher.SetDeviceAllocationClient(dynamicClient)
+	}
 	sher.Start()
 	defer sher.Stop()
 
 	// start monitor metrics
 	go sher.RegisterFromNodeAnnotations()
+	if len(config.UtilizationSourceURL) > 0 {
+		if policy.UtilizationWeight < 0 || policy.UtilizationWeight > 1 {
+			return fmt.Errorf("utilization weight %v is not between 0 and 1", policy.UtilizationWeight)
+		}
+		if config.UtilizationScrapeInterval <= 0 {
+			return fmt.Errorf("utilization scrape interval must be positive")
+		}
+		policy.DeviceUtilization = policy.NewUtilizationStore(3 * config.UtilizationScrapeInterval)
+		source := scheduler.NewUtilizationSource(http.DefaultClient, config.UtilizationSourceURL, config.UtilizationQuery)
+		go sher.ScrapeUtilization(policy.DeviceUtilization, source, config.UtilizationScrapeInterval)
+	}
+	if len(config.RightSizingSourceURL) > 0 {
+		if config.RightSizingInterval <= 0 || config.RightSizingWindow < config.RightSizingInterval {
+			return fmt.Errorf("right-sizing interval must be positive and not longer than the window")
+		}
+		if config.RightSizingThreshold < 0 {
+			return fmt.Errorf("right-sizing threshold %v is negative", config.RightSizingThreshold)
+		}
+		source := scheduler.NewMemoryUsageSource(http.DefaultClient, config.RightSizingSourceURL, config.RightSizingQuery)
+		go sher.RecommendRightSizing(source, config.RightSizingInterval)
+	}
 	go initMetrics(config.MetricsBindAddress)
 
 	// start http server
 	router := httprouter.New()
-	router.POST("/filter", routes.PredicateRoute(sher))
-	router.POST("/bind", routes.Bind(sher))
-	router.POST("/webhook", routes.WebHookRoute())
+	router.POST(apiv1.PathPrefix+"/filter", routes.DrainingRoute(sher, routes.V1FilterRoute(sher)))
+	router.POST(apiv1.PathPrefix+"/bind", routes.DrainingRoute(sher, routes.V1BindRoute(sher)))
+	router.GET(apiv1.PathPrefix+"/nodes", routes.V1NodesRoute(sher))
+	router.GET(apiv1.PathPrefix+"/nodes/serials", routes.V1NodeSerialsRoute(sher))
+	router.GET(apiv1.PathPrefix+"/decisions", routes.V1DecisionsRoute(sher))
+	router.GET(apiv1.OpenAPIPath, routes.OpenAPIRoute())
+	// Deprecated unversioned endpoints, to be removed two releases after hami.io/v1.
+	router.POST("/filter", routes.DrainingRoute(sher, routes.DeprecatedRoute(routes.PredicateRoute(sher), "/filter", apiv1.PathPrefix+"/filter")))
+	router.POST("/bind", routes.DrainingRoute(sher, routes.DeprecatedRoute(routes.Bind(sher), "/bind", apiv1.PathPrefix+"/bind")))
+	webhookRoute, err := routes.WebHookRoute(sher)
+	if err != nil {
+		return fmt.Errorf("create webhook error, %v", err)
+	}
+	router.POST("/webhook", webhookRoute)
 	router.GET("/healthz", routes.HealthzRoute())
+	if config.ConfigReload {
+		router.POST("/reload", routes.ReloadRoute())
+	}
+	router.GET("/scheduler/rebalance-candidates", routes.RebalanceCandidatesRoute(sher))
+	router.GET("/scheduler/summary", routes.SummaryRoute(sher))
+	router.POST("/scheduler/simulate", routes.SimulateRoute(sher))
+	router.POST("/scheduler/capacity", routes.CapacityRoute(sher))
+	router.GET("/diagnostics", routes.DiagnosticsRoute(sher, flags))
+	if config.EnableFaultInjection {
+		faultinject.Enable()
+		router.GET("/fault-injection", routes.FaultInjectionRoute())
+		router.PUT("/fault-injection/:point", routes.SetFaultRoute())
+		router.DELETE("/fault-injection/:point", routes.ClearFaultRoute())
+		klog.Warning("Fault injection enabled, for testing only")
+	}
+	if len(config.VolcanoSchedulerName) > 0 {
+		router.POST("/volcano/predicate", routes.VolcanoPredicateRoute(sher))
+		router.POST("/volcano/prioritize", routes.VolcanoPrioritizeRoute(sher))
+		router.POST("/volcano/allocate", routes.VolcanoAllocateRoute(sher))
+		router.POST("/volcano/deallocate", routes.VolcanoDeallocateRoute(sher))
+		klog.InfoS("Volcano extender routes enabled", "schedulerName", config.VolcanoSchedulerName)
+	}
+	var certManager *webhookcert.Manager
+	if config.ManageWebhookConfig {
+		var err error
+		if certManager, err = startWebhookCertManager(context.Background()); err != nil {
+			return fmt.Errorf("start webhook cert manager error, %v", err)
+		}
+	}
+	if len(config.GRPCBind) > 0 {
+		go func() {
+			if err := serveGRPC(certManager); err != nil {
+				klog.Fatalf("grpc serve error, %v", err)
+			}
+		}()
+	}
 	klog.Info("listen on ", config.HTTPBind)
 
 	if enableProfiling {
@@ -133,14 +391,36 @@ func start() error {
 		klog.Infof("Profiling enabled, visit %s/debug/pprof/ to view profiles", config.HTTPBind)
 	}
 
-	if len(tlsCertFile) == 0 || len(tlsKeyFile) == 0 {
-		if err := http.ListenAndServe(config.HTTPBind, router); err != nil {
-			return fmt.Errorf("listen and Serve error, %v", err)
-		}
-	} else {
-		if err := http.ListenAndServeTLS(config.HTTPBind, tlsCertFile, tlsKeyFile, router); err != nil {
-			return fmt.Errorf("listen and Serve error, %v", err)
+	server := &http.Server{Addr: config.HTTPBind, Handler: router}
+	if certManager != nil {
+		// The certificate is looked up per handshake, rotations apply to new connections only.
+		server.TLSConfig = &tls.Config{GetCertificate: certManager.GetCertificate}
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		if certManager != nil {
+			serveErr <- server.ListenAndServeTLS("", "")
+		} else if len(tlsCertFile) == 0 || len(tlsKeyFile) == 0 {
+			serveErr <- server.ListenAndServe()
+		} else {
+			serveErr <- server.ListenAndServeTLS(tlsCertFile, tlsKeyFile)
 		}
+	}()
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("listen and Serve error, %v", err)
+	case <-signalCtx.Done():
+	}
+	klog.InfoS("Received termination signal, shutting down", "gracePeriod", config.ShutdownGracePeriod)
+	// Keep serving while draining so that new filter and bind requests are answered 503.
+	sher.Drain(config.ShutdownGracePeriod)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("http server shutdown error, %v", err)
 	}
 	return nil
 }
@@ -20,12 +20,16 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	klog "k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
 )
 
 // ClusterManager is an example for a system that might have been built without
@@ -66,46 +70,64 @@ func (cc ClusterManagerCollector) Collect(ch chan<- prometheus.Metric) {
 	nodevGPUMemoryLimitDesc := prometheus.NewDesc(
 		"GPUDeviceMemoryLimit",
 		"Device memory limit for a certain GPU",
-		[]string{"nodeid", "deviceuuid", "deviceidx"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "deviceserial"}, nil,
 	)
 	nodevGPUCoreLimitDesc := prometheus.NewDesc(
 		"GPUDeviceCoreLimit",
 		"Device memory core limit for a certain GPU",
-		[]string{"nodeid", "deviceuuid", "deviceidx"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "deviceserial"}, nil,
 	)
 	nodevGPUMemoryAllocatedDesc := prometheus.NewDesc(
 		"GPUDeviceMemoryAllocated",
 		"Device memory allocated for a certain GPU",
-		[]string{"nodeid", "deviceuuid", "deviceidx", "devicecores"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "devicecores", "deviceserial"}, nil,
+	)
+	nodevGPUMemoryOversubscribedDesc := prometheus.NewDesc(
+		"GPUDeviceMemoryOversubscribed",
+		"Device memory allocated beyond the physical memory of a certain GPU",
+		[]string{"nodeid", "deviceuuid", "deviceidx", "deviceserial"}, nil,
 	)
 	nodevGPUSharedNumDesc := prometheus.NewDesc(
 		"GPUDeviceSharedNum",
 		"Number of containers sharing this GPU",
-		[]string{"nodeid", "deviceuuid", "deviceidx"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "deviceserial"}, nil,
 	)
 
 	nodeGPUCoreAllocatedDesc := prometheus.NewDesc(
 		"GPUDeviceCoreAllocated",
 		"Device core allocated for a certain GPU",
-		[]string{"nodeid", "deviceuuid", "deviceidx"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "deviceserial"}, nil,
 	)
 	nodeGPUOverview := prometheus.NewDesc(
 		"nodeGPUOverview",
 		"GPU overview on a certain node",
-		[]string{"nodeid", "deviceuuid", "deviceidx", "devicecores", "sharedcontainers", "devicememorylimit", "devicetype"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "devicecores", "sharedcontainers", "devicememorylimit", "devicetype", "deviceserial"}, nil,
 	)
 	nodeGPUMemoryPercentage := prometheus.NewDesc(
 		"nodeGPUMemoryPercentage",
 		"GPU Memory Allocated Percentage on a certain GPU",
-		[]string{"nodeid", "deviceuuid", "deviceidx"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "deviceserial"}, nil,
 	)
 	nodeGPUMigInstance := prometheus.NewDesc(
 		"nodeGPUMigInstance",
 		"GPU Sharing mode. 0 for hami-core, 1 for mig, 2 for mps",
-		[]string{"nodeid", "deviceuuid", "deviceidx", "migname"}, nil,
+		[]string{"nodeid", "deviceuuid", "deviceidx", "migname", "deviceserial"}, nil,
+	)
+	nodeOrphanedDeviceDesc := prometheus.NewDesc(
+		"nodeOrphanedDeviceAllocated",
+		"Device memory allocated to a pod on a device no longer registered on its node, e.g. after the GPU was replaced",
+		[]string{"nodeid", "deviceuuid", "podnamespace", "podname"}, nil,
 	)
 	nu := sher.InspectAllNodesUsage()
 	for nodeID, val := range *nu {
+		for _, o := range val.Orphaned {
+			ch <- prometheus.MustNewConstMetric(
+				nodeOrphanedDeviceDesc,
+				prometheus.GaugeValue,
+				float64(o.Device.Usedmem)*float64(1024)*float64(1024),
+				nodeID, o.Device.UUID, o.Pod.Namespace, o.Pod.Name,
+			)
+		}
 		for _, devs := range val.Devices.DeviceLists {
 			if devs.Device.Mode == "mig" {
 				for idx, migs := range devs.Device.MigUsage.UsageList {
@@ -118,7 +140,7 @@ func (cc ClusterManagerCollector) Collect(ch chan<- prometheus.Metric) {
 						nodeGPUMigInstance,
 						prometheus.GaugeValue,
 						float64(inuse),
-						nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), migs.Name+"-"+fmt.Sprint(idx),
+						nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), migs.Name+"-"+fmt.Sprint(idx), devs.Device.Serial,
 					)
 				}
 			}
@@ -127,44 +149,50 @@ func (cc ClusterManagerCollector) Collect(ch chan<- prometheus.Metric) {
 				nodevGPUMemoryLimitDesc,
 				prometheus.GaugeValue,
 				float64(devs.Device.Totalmem)*float64(1024)*float64(1024),
-				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index),
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), devs.Device.Serial,
 			)
 			ch <- prometheus.MustNewConstMetric(
 				nodevGPUCoreLimitDesc,
 				prometheus.GaugeValue,
 				float64(devs.Device.Totalcore),
-				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index),
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), devs.Device.Serial,
 			)
 			ch <- prometheus.MustNewConstMetric(
 				nodevGPUMemoryAllocatedDesc,
 				prometheus.GaugeValue,
 				float64(devs.Device.Usedmem)*float64(1024)*float64(1024),
-				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), fmt.Sprint(devs.Device.Usedcores),
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), fmt.Sprint(devs.Device.Usedcores), devs.Device.Serial,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				nodevGPUMemoryOversubscribedDesc,
+				prometheus.GaugeValue,
+				float64(devs.Device.Oversubscribedmem)*float64(1024)*float64(1024),
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), devs.Device.Serial,
 			)
 			ch <- prometheus.MustNewConstMetric(
 				nodevGPUSharedNumDesc,
 				prometheus.GaugeValue,
 				float64(devs.Device.Used),
-				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index),
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), devs.Device.Serial,
 			)
 
 			ch <- prometheus.MustNewConstMetric(
 				nodeGPUCoreAllocatedDesc,
 				prometheus.GaugeValue,
 				float64(devs.Device.Usedcores),
-				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index),
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), devs.Device.Serial,
 			)
 			ch <- prometheus.MustNewConstMetric(
 				nodeGPUOverview,
 				prometheus.GaugeValue,
 				float64(devs.Device.Usedmem)*float64(1024)*float64(1024),
-				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), fmt.Sprint(devs.Device.Usedcores), fmt.Sprint(devs.Device.Used), fmt.Sprint(devs.Device.Totalmem), devs.Device.Type,
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), fmt.Sprint(devs.Device.Usedcores), fmt.Sprint(devs.Device.Used), fmt.Sprint(devs.Device.Totalmem), devs.Device.Type, devs.Device.Serial,
 			)
 			ch <- prometheus.MustNewConstMetric(
 				nodeGPUMemoryPercentage,
 				prometheus.GaugeValue,
 				float64(devs.Device.Usedmem)/float64(devs.Device.Totalmem),
-				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index),
+				nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index), devs.Device.Serial,
 			)
 		}
 	}
@@ -209,6 +237,68 @@ func (cc ClusterManagerCollector) Collect(ch chan<- prometheus.Metric) {
 			)
 		}
 	}
+	expiredReservationsDesc := prometheus.NewDesc(
+		"ExpiredReservations",
+		"Number of device reservations released because their pod wasn't bound within the reservation ttl",
+		nil, nil,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		expiredReservationsDesc,
+		prometheus.CounterValue,
+		float64(sher.ExpiredReservations()),
+	)
+	cleanedDesc := prometheus.NewDesc(
+		"CleanedStaleAnnotations",
+		"Number of stale vendor registrations removed from nodes and of finished pods assignment annotations were removed from",
+		[]string{"kind"}, nil,
+	)
+	registrations, pods := sher.CleanedStaleAnnotations()
+	ch <- prometheus.MustNewConstMetric(cleanedDesc, prometheus.CounterValue, float64(registrations), "registration")
+	ch <- prometheus.MustNewConstMetric(cleanedDesc, prometheus.CounterValue, float64(pods), "pod")
+	annotationConflictsDesc := prometheus.NewDesc(
+		"AnnotationPatchConflicts",
+		"Number of conflicts encountered patching annotations of nodes, pods and workloads, retried up to a bound",
+		nil, nil,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		annotationConflictsDesc,
+		prometheus.CounterValue,
+		float64(util.AnnotationPatchConflicts()),
+	)
+	timeToScheduleDesc := prometheus.NewDesc(
+		"PodTimeToScheduleSeconds",
+		"Time from the creation of pods to their bind to a node by the scheduler",
+		[]string{"namespace", "preempted"}, nil,
+	)
+	for key, h := range sher.ScheduleLatencies() {
+		ch <- prometheus.MustNewConstHistogram(
+			timeToScheduleDesc,
+			h.Count,
+			h.Sum,
+			h.Buckets,
+			key.Namespace, strconv.FormatBool(key.Preempted),
+		)
+	}
+	nearTimeoutDesc := prometheus.NewDesc(
+		"ExtenderNearTimeoutResponses",
+		"Number of filter and bind responses taking over 80% of the extender deadline, exceeded if past it",
+		[]string{"verb", "exceeded"}, nil,
+	)
+	for key, count := range sher.NearTimeoutResponses() {
+		ch <- prometheus.MustNewConstMetric(nearTimeoutDesc, prometheus.CounterValue, float64(count), key.Verb, strconv.FormatBool(key.Exceeded))
+	}
+	if config.RightSizingSourceURL != "" {
+		reclaimableDesc := prometheus.NewDesc(
+			"RecommendedReclaimableGPUMemory",
+			"Device memory in bytes reclaimed if the workloads were sized by their hami.io/recommended-gpumem recommendation",
+			nil, nil,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			reclaimableDesc,
+			prometheus.GaugeValue,
+			float64(sher.ReclaimableGPUMemory())*float64(1024)*float64(1024),
+		)
+	}
 	schedpods, _ := sher.GetPodManager().GetScheduledPods()
 	for _, val := range schedpods {
 		for _, podSingleDevice := range val.Devices {
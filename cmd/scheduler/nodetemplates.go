@@ -0,0 +1,45 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// nodeTemplatesCmd prints the cluster autoscaler tags of every node group in nodeGroupTemplates of the device config.
+var nodeTemplatesCmd = &cobra.Command{
+	Use:   "node-templates",
+	Short: "print cluster autoscaler node template tags of node groups in device config",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.InitDevices()
+		tags := make(map[string]map[string]string, len(config.NodeGroupTemplates))
+		for _, t := range config.NodeGroupTemplates {
+			tags[t.Name] = t.AutoscalerTags()
+		}
+		data, err := json.MarshalIndent(tags, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
@@ -209,6 +209,8 @@ func watchAndFeedback(ctx context.Context, lister *nvidia.ContainerLister, migLo
 				klog.Errorf("Failed to update container list: %v", err)
 				continue
 			}
+			applyResizedLimits(lister)
+			checkSoftLimits(lister)
 			Observe(lister)
 		}
 	}
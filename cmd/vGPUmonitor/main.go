@@ -52,6 +52,7 @@ var (
 func init() {
 	rootCmd.Flags().SortFlags = false
 	rootCmd.PersistentFlags().SortFlags = false
+	rootCmd.Flags().BoolVar(&evictSoftOOM, "evict-soft-oom", false, "evict the pods whose containers in the soft OOM mode exceed their GPU memory limit")
 	rootCmd.Flags().AddGoFlagSet(util.InitKlogFlags())
 }
 
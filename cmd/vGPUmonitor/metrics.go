@@ -104,6 +104,11 @@ var (
 		"vGPU device limit",
 		[]string{"podnamespace", "podname", "ctrname", "vdeviceid", "deviceuuid"}, nil,
 	)
+	ctrvGPUOverLimitdesc = prometheus.NewDesc(
+		"vGPU_device_memory_over_limit_in_bytes",
+		"vGPU device memory used beyond the limit, by containers in the soft OOM mode",
+		[]string{"podnamespace", "podname", "ctrname", "vdeviceid", "deviceuuid"}, nil,
+	)
 	ctrDeviceMemorydesc = prometheus.NewDesc(
 		"Device_memory_desc_of_container",
 		"Container device memory description",
@@ -133,6 +138,7 @@ func (cc ClusterManagerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- hostGPUdesc
 	ch <- ctrvGPUdesc
 	ch <- ctrvGPUlimitdesc
+	ch <- ctrvGPUOverLimitdesc
 	ch <- hostGPUUtilizationdesc
 	//prometheus.DescribeByCollect(cc, ch)
 }
@@ -413,6 +419,14 @@ func (cc ClusterManagerCollector) collectContainerMetrics(ch chan<- prometheus.M
 			return err
 		}
 
+		// Only containers in the soft OOM mode can use more than their limit.
+		if memoryLimit > 0 && memoryTotal > memoryLimit {
+			if err := sendMetric(ch, ctrvGPUOverLimitdesc, prometheus.GaugeValue, float64(memoryTotal-memoryLimit), labels...); err != nil {
+				klog.Errorf("Failed to send memory over limit metric for device %d in Pod %s/%s, Container %s: %v", i, pod.Namespace, pod.Name, ctr.Name, err)
+				return err
+			}
+		}
+
 		// Send memory-related metrics with additional labels
 		memoryLabels := append(labels, fmt.Sprint(memoryContextSize), fmt.Sprint(memoryModuleSize), fmt.Sprint(memoryBufferSize), fmt.Sprint(memoryOffset))
 		if err := sendMetric(ch, ctrDeviceMemorydesc, prometheus.CounterValue, float64(memoryTotal), memoryLabels...); err != nil {
@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	nv "github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// evictSoftOOM evicts the pods whose containers in the soft OOM mode exceed their GPU memory limit.
+var evictSoftOOM bool
+
+// softLimitExceeded is the set of containers in the soft OOM mode exceeding their GPU memory limit, by pod UID
+// and container name, so each excess is logged and evicted once.
+var softLimitExceeded = map[string]bool{}
+
+// checkSoftLimits flags the containers in the soft OOM mode using more GPU memory than their limit, which HAMi-core
+// let them allocate while the GPU had memory free, and evicts their pod if evictSoftOOM is set. The memory used
+// beyond the limit is exported as the vGPU_device_memory_over_limit_in_bytes metric.
+func checkSoftLimits(lister *nvidia.ContainerLister) {
+	pods, err := lister.PodLister().List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to list pods to check soft GPU memory limits: %v", err)
+		return
+	}
+	lister.Lock()
+	defer lister.UnLock()
+	containers := lister.ListContainers()
+	exceeded := make(map[string]bool)
+	for _, pod := range pods {
+		if util.IsPodInTerminatedState(pod) {
+			continue
+		}
+		pd, err := device.DecodePodDevices(device.SupportDevices, pod.Annotations)
+		if err != nil {
+			continue
+		}
+		for idx, ctrdevs := range pd[nv.NvidiaGPUDevice] {
+			if len(ctrdevs) == 0 || idx >= len(pod.Spec.Containers) || ctrdevs[0].OOMMode != util.OOMModeSoft {
+				continue
+			}
+			key := fmt.Sprintf("%s_%s", pod.UID, pod.Spec.Containers[idx].Name)
+			c, ok := containers[key]
+			if !ok {
+				continue
+			}
+			for i := range c.Info.DeviceNum() {
+				used, limit := c.Info.DeviceMemoryTotal(i), c.Info.DeviceMemoryLimit(i)
+				if limit == 0 || used <= limit {
+					continue
+				}
+				if softLimitExceeded[key] {
+					exceeded[key] = true
+					break
+				}
+				klog.Warningf("Pod %s/%s container %s uses %dMiB of GPU %s beyond its soft limit of %dMiB", pod.Namespace, pod.Name,
+					c.ContainerName, (used-limit)/1024/1024, c.Info.DeviceUUID(i), limit/1024/1024)
+				// Failed evictions, e.g. blocked by a disruption budget, are retried on the next check.
+				if !evictSoftOOM || evictPod(lister, pod.Namespace, pod.Name) {
+					exceeded[key] = true
+				}
+				break
+			}
+		}
+	}
+	softLimitExceeded = exceeded
+}
+
+// evictPod evicts the pod through the eviction API, so its disruption budget is respected, and returns whether it
+// succeeded.
+func evictPod(lister *nvidia.ContainerLister, namespace, name string) bool {
+	err := lister.Clientset().PolicyV1().Evictions(namespace).Evict(context.Background(), &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Failed to evict pod %s/%s exceeding its soft GPU memory limit: %v", namespace, name, err)
+		return false
+	}
+	klog.Infof("Evicted pod %s/%s exceeding its soft GPU memory limit", namespace, name)
+	return true
+}
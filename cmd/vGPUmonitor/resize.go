@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	nv "github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// applyResizedLimits applies the GPU memory and cores of the allocation of the pods the scheduler resized in place
+// to the shared regions of their running containers. The shared region holds a single limit for all the GPUs of a
+// container, the smallest of its GPUs is applied.
+func applyResizedLimits(lister *nvidia.ContainerLister) {
+	pods, err := lister.PodLister().List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to list pods to apply resized GPU limits: %v", err)
+		return
+	}
+	lister.Lock()
+	defer lister.UnLock()
+	containers := lister.ListContainers()
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[util.GPUResizedAnnotationKey]; !ok {
+			continue
+		}
+		pd, err := device.DecodePodDevices(device.SupportDevices, pod.Annotations)
+		if err != nil {
+			klog.Errorf("Failed to decode the devices of resized pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		for idx, ctrdevs := range pd[nv.NvidiaGPUDevice] {
+			if len(ctrdevs) == 0 || idx >= len(pod.Spec.Containers) || strings.Contains(ctrdevs[0].UUID, "[") {
+				continue
+			}
+			c, ok := containers[fmt.Sprintf("%s_%s", pod.UID, pod.Spec.Containers[idx].Name)]
+			if !ok || c.Info.DeviceNum() == 0 {
+				continue
+			}
+			mem, cores := ctrdevs[0].Usedmem, ctrdevs[0].Usedcores
+			for _, d := range ctrdevs[1:] {
+				mem = min(mem, d.Usedmem)
+			}
+			limit := uint64(mem) * 1024 * 1024
+			// The SM limit can't be read back, both limits are written as long as the pod runs.
+			if c.Info.DeviceMemoryLimit(0) != limit {
+				klog.Infof("Applying resized GPU limits to pod %s/%s container %s: %dMiB, %d cores", pod.Namespace, pod.Name, c.ContainerName, mem, cores)
+			}
+			c.Info.SetDeviceMemoryLimit(limit)
+			c.Info.SetDeviceSmLimit(uint64(cores))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+#!/usr/bin/env bash
+# Copyright 2025 The HAMi Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Runs the e2e tests of the mock vendor on a kind cluster, the webhook, scheduler and mock device plugin run the
+# image built from the repo, so HAMi is tested end to end without GPU.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+set -x
+
+CLUSTER_NAME=${1:-"hami-mock"}
+IMAGE_TAG=${2:-"mock-e2e"}
+TARGET_NS=${3:-"hami-system"}
+
+REPO_ROOT=$(dirname "${BASH_SOURCE[0]}")/..
+cd "${REPO_ROOT}"
+
+source "${REPO_ROOT}"/hack/util.sh
+
+for cmd in docker kind kubectl; do
+  if ! util::cmd_exist "${cmd}"; then
+    echo "Error: ${cmd} is required."
+    exit 1
+  fi
+done
+if ! util::cmd_exist helm; then
+  util::install_helm
+fi
+
+KUBE_CONF="$(mktemp)"
+export KUBE_CONF
+export KUBECONFIG="${KUBE_CONF}"
+
+if ! kind get clusters | grep -qx "${CLUSTER_NAME}"; then
+  kind create cluster --name "${CLUSTER_NAME}" --kubeconfig "${KUBE_CONF}"
+else
+  kind export kubeconfig --name "${CLUSTER_NAME}" --kubeconfig "${KUBE_CONF}"
+fi
+
+make docker IMG_TAG="projecthami/hami:${IMAGE_TAG}"
+kind load docker-image "projecthami/hami:${IMAGE_TAG}" --name "${CLUSTER_NAME}"
+
+if ! helm upgrade --install --create-namespace --wait --timeout 10m hami charts/hami -n "${TARGET_NS}" \
+  --kubeconfig "${KUBE_CONF}" \
+  --set global.imageTag="${IMAGE_TAG}" \
+  --set devicePlugin.image.pullPolicy=IfNotPresent \
+  --set scheduler.extender.image.pullPolicy=IfNotPresent \
+  --set devices.mock.enabled=true; then
+  echo "Error: Failed to deploy HAMi with the mock vendor."
+  exit 1
+fi
+
+if ! util::check_pods_status "${KUBE_CONF}" "${TARGET_NS}"; then
+  echo "Error: Pods are not running correctly."
+  exit 1
+fi
+
+GINKGO_VERSION=$(go list -m -f '{{.Version}}' github.com/onsi/ginkgo/v2)
+go run "github.com/onsi/ginkgo/v2/ginkgo@${GINKGO_VERSION}" \
+  run -v --fail-fast ./test/e2e/mock/ -- --kubeconfig="${KUBE_CONF}"
@@ -16,4 +16,5 @@
 #
 
 ROOT_DIR=$(dirname "${BASH_SOURCE[0]}")/..
-protoc -I${ROOT_DIR} --gofast_out=plugins=grpc:${ROOT_DIR} ${ROOT_DIR}/pkg/api/*.proto
\ No newline at end of file
+protoc -I${ROOT_DIR} --gofast_out=plugins=grpc:${ROOT_DIR} ${ROOT_DIR}/pkg/api/*.proto
+protoc -I${ROOT_DIR} --go_out=paths=source_relative:${ROOT_DIR} --go-grpc_out=paths=source_relative:${ROOT_DIR} ${ROOT_DIR}/pkg/scheduler/api/extenderpb/extender.proto
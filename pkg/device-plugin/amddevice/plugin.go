@@ -0,0 +1,288 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package amddevice implements the device plugin of AMD GPUs sliced by memory and cores. It registers the GPUs
+// rocm-smi reports on the node, and allocates the slices the scheduler assigned to containers by the envs of the
+// ROCm limiter, only exposing them the GPUs they were allocated.
+package amddevice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/amd"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+)
+
+// kfdDevice is the device of the ROCm kernel driver every container using AMD GPUs needs.
+const kfdDevice = "/dev/kfd"
+
+// AMDDevicePlugin serves the GPU slices of a node to kubelet.
+type AMDDevicePlugin struct {
+	config   amd.AMDConfig
+	nodeName string
+	devices  []*device.DeviceInfo
+	// The render node of each GPU by its ID
+	renderNodes map[string]string
+	socket      string
+	server      *grpc.Server
+	stop        chan struct{}
+}
+
+// NewAMDDevicePlugin returns the device plugin of the GPUs of the node nodeName, with their render nodes.
+func NewAMDDevicePlugin(config amd.AMDConfig, nodeName string, devices []*device.DeviceInfo, renderNodes map[string]string) *AMDDevicePlugin {
+	return &AMDDevicePlugin{
+		config:      config,
+		nodeName:    nodeName,
+		devices:     devices,
+		renderNodes: renderNodes,
+		socket:      kubeletdevicepluginv1beta1.DevicePluginPath + "hami-amd.sock",
+	}
+}
+
+// Start serves the device plugin and registers it with kubelet.
+func (plugin *AMDDevicePlugin) Start() error {
+	plugin.server = grpc.NewServer()
+	plugin.stop = make(chan struct{})
+	if err := plugin.serve(); err != nil {
+		klog.Errorf("Could not start AMD device plugin: %v", err)
+		plugin.Stop()
+		return err
+	}
+	klog.Infof("Starting to serve '%s' on %s", plugin.config.ResourceCountName, plugin.socket)
+	if err := plugin.register(); err != nil {
+		klog.Errorf("Could not register AMD device plugin: %v", err)
+		plugin.Stop()
+		return err
+	}
+	klog.Infof("Registered AMD device plugin for '%s' with Kubelet", plugin.config.ResourceCountName)
+	return nil
+}
+
+// Stop stops serving the device plugin.
+func (plugin *AMDDevicePlugin) Stop() error {
+	if plugin == nil || plugin.server == nil {
+		return nil
+	}
+	klog.Infof("Stopping to serve '%s' on %s", plugin.config.ResourceCountName, plugin.socket)
+	plugin.server.Stop()
+	close(plugin.stop)
+	plugin.server = nil
+	if err := os.Remove(plugin.socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (plugin *AMDDevicePlugin) serve() error {
+	os.Remove(plugin.socket)
+	sock, err := net.Listen("unix", plugin.socket)
+	if err != nil {
+		return err
+	}
+	kubeletdevicepluginv1beta1.RegisterDevicePluginServer(plugin.server, plugin)
+	server := plugin.server
+	go func() {
+		if err := server.Serve(sock); err != nil {
+			klog.Errorf("GRPC server for '%s' stopped with error: %v", plugin.config.ResourceCountName, err)
+		}
+	}()
+	// Wait for server to start by launching a blocking connexion
+	conn, err := dial(plugin.socket, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+func (plugin *AMDDevicePlugin) register() error {
+	conn, err := dial(kubeletdevicepluginv1beta1.KubeletSocket, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := kubeletdevicepluginv1beta1.NewRegistrationClient(conn)
+	_, err = client.Register(context.Background(), &kubeletdevicepluginv1beta1.RegisterRequest{
+		Version:      kubeletdevicepluginv1beta1.Version,
+		Endpoint:     path.Base(plugin.socket),
+		ResourceName: plugin.config.ResourceCountName,
+		Options:      &kubeletdevicepluginv1beta1.DevicePluginOptions{},
+	})
+	return err
+}
+
+// GetDevicePluginOptions returns the values of the optional settings for this plugin
+func (plugin *AMDDevicePlugin) GetDevicePluginOptions(context.Context, *kubeletdevicepluginv1beta1.Empty) (*kubeletdevicepluginv1beta1.DevicePluginOptions, error) {
+	return &kubeletdevicepluginv1beta1.DevicePluginOptions{}, nil
+}
+
+// ListAndWatch lists the slices of the GPUs.
+func (plugin *AMDDevicePlugin) ListAndWatch(e *kubeletdevicepluginv1beta1.Empty, s kubeletdevicepluginv1beta1.DevicePlugin_ListAndWatchServer) error {
+	if err := s.Send(&kubeletdevicepluginv1beta1.ListAndWatchResponse{Devices: plugin.apiDevices()}); err != nil {
+		return err
+	}
+	<-plugin.stop
+	return nil
+}
+
+// GetPreferredAllocation returns no preference, the scheduler already chose the devices.
+func (plugin *AMDDevicePlugin) GetPreferredAllocation(context.Context, *kubeletdevicepluginv1beta1.PreferredAllocationRequest) (*kubeletdevicepluginv1beta1.PreferredAllocationResponse, error) {
+	return &kubeletdevicepluginv1beta1.PreferredAllocationResponse{}, nil
+}
+
+// Allocate returns the envs and devices of the GPU slices the scheduler assigned to the containers of the pending
+// pod of the node.
+func (plugin *AMDDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdevicepluginv1beta1.AllocateRequest) (*kubeletdevicepluginv1beta1.AllocateResponse, error) {
+	klog.InfoS("Allocate", "request", reqs)
+	current, err := util.GetPendingPod(ctx, plugin.nodeName)
+	if err != nil {
+		return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
+	}
+	responses := kubeletdevicepluginv1beta1.AllocateResponse{}
+	for _, req := range reqs.ContainerRequests {
+		devreq, remaining, err := nextDeviceRequest(current)
+		if err != nil {
+			plugin.allocationFailed(current)
+			return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
+		}
+		if len(devreq) != len(req.DevicesIDs) {
+			plugin.allocationFailed(current)
+			return &kubeletdevicepluginv1beta1.AllocateResponse{}, errors.New("device number not matched")
+		}
+		response, err := plugin.allocateResponse(devreq)
+		if err != nil {
+			plugin.allocationFailed(current)
+			return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
+		}
+		err = util.PatchPodAnnotations(current, map[string]string{
+			device.InRequestDevices[amd.AMDDevice]: device.EncodePodSingleDevice(remaining),
+		})
+		if err != nil {
+			plugin.allocationFailed(current)
+			return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
+		}
+		current.Annotations[device.InRequestDevices[amd.AMDDevice]] = device.EncodePodSingleDevice(remaining)
+		responses.ContainerResponses = append(responses.ContainerResponses, response)
+	}
+	if devreq, _, _ := nextDeviceRequest(current); len(devreq) == 0 {
+		plugin.updateBindPhase(current, util.DeviceBindSuccess)
+	}
+	klog.Infof("Allocate response: %v", responses.ContainerResponses)
+	return &responses, nil
+}
+
+// PreStartContainer is unimplemented for this plugin
+func (plugin *AMDDevicePlugin) PreStartContainer(context.Context, *kubeletdevicepluginv1beta1.PreStartContainerRequest) (*kubeletdevicepluginv1beta1.PreStartContainerResponse, error) {
+	return &kubeletdevicepluginv1beta1.PreStartContainerResponse{}, nil
+}
+
+// apiDevices returns the devices advertised to kubelet, each GPU being split Count times.
+func (plugin *AMDDevicePlugin) apiDevices() []*kubeletdevicepluginv1beta1.Device {
+	var res []*kubeletdevicepluginv1beta1.Device
+	for _, dev := range plugin.devices {
+		for i := range dev.Count {
+			res = append(res, &kubeletdevicepluginv1beta1.Device{
+				ID:     fmt.Sprintf("%s-%d", dev.ID, i),
+				Health: kubeletdevicepluginv1beta1.Healthy,
+			})
+		}
+	}
+	return res
+}
+
+func (plugin *AMDDevicePlugin) allocationFailed(pod *corev1.Pod) {
+	klog.Infof("Pod allocation failed for pod %s/%s on node %s", pod.Namespace, pod.Name, plugin.nodeName)
+	plugin.updateBindPhase(pod, util.DeviceBindFailed)
+}
+
+func (plugin *AMDDevicePlugin) updateBindPhase(pod *corev1.Pod, deviceBindPhase string) {
+	if err := util.PatchPodAnnotations(pod, map[string]string{util.DeviceBindPhase: deviceBindPhase}); err != nil {
+		klog.Errorf("Failed to patch pod annotations for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	if err := nodelock.ReleaseNodeLock(plugin.nodeName, amd.NodeLockAMD, pod, false); err != nil {
+		klog.Errorf("Failed to release node lock for node %s: %v", plugin.nodeName, err)
+	}
+}
+
+// nextDeviceRequest returns the GPUs assigned to the next container of the pod to allocate, and the GPUs left to
+// allocate to the next containers.
+func nextDeviceRequest(pod *corev1.Pod) (device.ContainerDevices, device.PodSingleDevice, error) {
+	pdevices, err := device.DecodePodDevices(device.InRequestDevices, pod.Annotations)
+	if err != nil {
+		return nil, nil, err
+	}
+	pd, ok := pdevices[amd.AMDDevice]
+	if !ok {
+		return nil, nil, errors.New("device request not found")
+	}
+	remaining := make(device.PodSingleDevice, len(pd))
+	copy(remaining, pd)
+	for idx, ctrDevices := range pd {
+		if len(ctrDevices) > 0 {
+			remaining[idx] = device.ContainerDevices{}
+			return ctrDevices, remaining, nil
+		}
+	}
+	return nil, nil, errors.New("device request not found")
+}
+
+// allocateResponse returns the envs limiting the GPUs devreq as HAMi-core does for NVIDIA GPUs, and mounts
+// /dev/kfd and the render nodes of these GPUs only.
+func (plugin *AMDDevicePlugin) allocateResponse(devreq device.ContainerDevices) (*kubeletdevicepluginv1beta1.ContainerAllocateResponse, error) {
+	uuids := make([]string, 0, len(devreq))
+	envs := make(map[string]string)
+	specs := []*kubeletdevicepluginv1beta1.DeviceSpec{{ContainerPath: kfdDevice, HostPath: kfdDevice, Permissions: "rw"}}
+	for i, dev := range devreq {
+		renderNode, ok := plugin.renderNodes[dev.UUID]
+		if !ok {
+			return nil, fmt.Errorf("GPU %s not found on node %s", dev.UUID, plugin.nodeName)
+		}
+		uuids = append(uuids, dev.UUID)
+		envs[fmt.Sprintf("%s_%d", amd.DeviceMemoryLimitEnv, i)] = fmt.Sprintf("%dm", dev.Usedmem)
+		specs = append(specs, &kubeletdevicepluginv1beta1.DeviceSpec{ContainerPath: renderNode, HostPath: renderNode, Permissions: "rw"})
+	}
+	envs[amd.VisibleDevicesEnv] = strings.Join(uuids, ",")
+	if len(devreq) > 0 {
+		envs[amd.DeviceCoreLimitEnv] = fmt.Sprint(devreq[0].Usedcores)
+	}
+	return &kubeletdevicepluginv1beta1.ContainerAllocateResponse{Envs: envs, Devices: specs}, nil
+}
+
+// dial establishes the gRPC communication with the registered device plugin.
+func dial(unixSocketPath string, timeout time.Duration) (*grpc.ClientConn, error) {
+	return grpc.Dial(unixSocketPath, grpc.WithInsecure(), grpc.WithBlock(),
+		grpc.WithTimeout(timeout),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	)
+}
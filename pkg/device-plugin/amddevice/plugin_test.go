@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amddevice
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/amd"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// rocmSMIOutput is the output of rocm-smi --showuniqueid --showproductname --showmeminfo vram --json on a node
+// of two MI210.
+const rocmSMIOutput = `{
+  "card0": {"VRAM Total Memory (B)": "68702699520", "VRAM Total Used Memory (B)": "10960896", "Card series": "AMD Instinct MI210", "Card model": "0x0c34", "Card vendor": "Advanced Micro Devices, Inc. [AMD/ATI]", "Card SKU": "D67301", "Unique ID": "0x8A1B2C3D4E5F6071"},
+  "card1": {"VRAM Total Memory (B)": "68702699520", "VRAM Total Used Memory (B)": "10960896", "Card Series": "AMD Instinct MI210", "Card Model": "0x0c34", "Card Vendor": "Advanced Micro Devices, Inc. [AMD/ATI]", "Card SKU": "D67301", "Unique ID": "0x1f6b4b2c1a2e3d4f"},
+  "system": {"Driver version": "6.7.0"}
+}`
+
+func TestParseROCmSMI(t *testing.T) {
+	devices, err := parseROCmSMI([]byte(rocmSMIOutput), 4)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, devices, []*device.DeviceInfo{
+		{ID: "GPU-8a1b2c3d4e5f6071", Index: 0, Count: 4, Devmem: 65520, Devcore: 100, Type: "AMDGPU-Instinct MI210", Health: true, DeviceVendor: amd.AMDCommonWord},
+		{ID: "GPU-1f6b4b2c1a2e3d4f", Index: 1, Count: 4, Devmem: 65520, Devcore: 100, Type: "AMDGPU-Instinct MI210", Health: true, DeviceVendor: amd.AMDCommonWord},
+	})
+
+	_, err = parseROCmSMI([]byte(`{"card0": {"VRAM Total Memory (B)": "68702699520", "Unique ID": "N/A"}}`), 4)
+	assert.ErrorContains(t, err, "unique ID of card0")
+}
+
+func TestRenderNodes(t *testing.T) {
+	sysfs := t.TempDir()
+	for card, node := range map[string]string{"card0": "renderD128", "card1": "renderD129"} {
+		assert.NilError(t, os.MkdirAll(filepath.Join(sysfs, "class", "drm", card, "device", "drm", card), 0o755))
+		assert.NilError(t, os.MkdirAll(filepath.Join(sysfs, "class", "drm", card, "device", "drm", node), 0o755))
+	}
+	devices := []*device.DeviceInfo{{ID: "GPU-0", Index: 0}, {ID: "GPU-1", Index: 1}}
+	nodes, err := RenderNodes(sysfs, devices)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, nodes, map[string]string{"GPU-0": "/dev/dri/renderD128", "GPU-1": "/dev/dri/renderD129"})
+
+	_, err = RenderNodes(sysfs, []*device.DeviceInfo{{ID: "GPU-2", Index: 2}})
+	assert.Assert(t, err != nil)
+}
+
+func TestRegisterAnnotations(t *testing.T) {
+	devices, err := parseROCmSMI([]byte(rocmSMIOutput), 4)
+	assert.NilError(t, err)
+	plugin := NewAMDDevicePlugin(amd.AMDConfig{ResourceCountName: "amd.com/gpu"}, "node1", devices, nil)
+	assert.Equal(t, len(plugin.apiDevices()), 8)
+
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	annos := plugin.registerAnnotations(now)
+	assert.Equal(t, annos[amd.HandshakeAnnos], "Reported_"+util.FormatHandshakeTime(now))
+	registered, err := amd.InitAMDGPUDevice(amd.AMDConfig{ResourceCountName: "amd.com/gpu", ResourceMemoryName: "amd.com/gpumem"}).
+		GetNodeDevices(corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: annos}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(registered), 2)
+	assert.Equal(t, registered[1].ID, "GPU-1f6b4b2c1a2e3d4f")
+	assert.Equal(t, registered[1].Devmem, int32(65520))
+	assert.Equal(t, registered[1].Count, int32(4))
+}
+
+func TestNextDeviceRequest(t *testing.T) {
+	amd.InitAMDGPUDevice(amd.AMDConfig{})
+	pd := device.PodSingleDevice{
+		{{UUID: "GPU-0", Type: amd.AMDDevice, Usedmem: 16384, Usedcores: 30}},
+		{{UUID: "GPU-1", Type: amd.AMDDevice, Usedmem: 32768, Usedcores: 50}},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		device.InRequestDevices[amd.AMDDevice]: device.EncodePodSingleDevice(pd),
+	}}}
+
+	devreq, remaining, err := nextDeviceRequest(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, len(devreq), 1)
+	assert.Equal(t, devreq[0].UUID, "GPU-0")
+
+	pod.Annotations[device.InRequestDevices[amd.AMDDevice]] = device.EncodePodSingleDevice(remaining)
+	devreq, remaining, err = nextDeviceRequest(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, len(devreq), 1)
+	assert.Equal(t, devreq[0].UUID, "GPU-1")
+
+	pod.Annotations[device.InRequestDevices[amd.AMDDevice]] = device.EncodePodSingleDevice(remaining)
+	_, _, err = nextDeviceRequest(pod)
+	assert.ErrorContains(t, err, "device request not found")
+}
+
+func TestAllocateResponse(t *testing.T) {
+	plugin := NewAMDDevicePlugin(amd.AMDConfig{}, "node1", nil, map[string]string{
+		"GPU-0": "/dev/dri/renderD128",
+		"GPU-1": "/dev/dri/renderD129",
+		"GPU-2": "/dev/dri/renderD130",
+	})
+	response, err := plugin.allocateResponse(device.ContainerDevices{
+		{UUID: "GPU-0", Usedmem: 16384, Usedcores: 30},
+		{UUID: "GPU-2", Usedmem: 32768, Usedcores: 30},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, response.Envs, map[string]string{
+		amd.VisibleDevicesEnv:           "GPU-0,GPU-2",
+		amd.DeviceMemoryLimitEnv + "_0": "16384m",
+		amd.DeviceMemoryLimitEnv + "_1": "32768m",
+		amd.DeviceCoreLimitEnv:          "30",
+	})
+	// Only the render nodes of the GPUs allocated are mounted.
+	assert.DeepEqual(t, response.Devices, []*kubeletdevicepluginv1beta1.DeviceSpec{
+		{ContainerPath: "/dev/kfd", HostPath: "/dev/kfd", Permissions: "rw"},
+		{ContainerPath: "/dev/dri/renderD128", HostPath: "/dev/dri/renderD128", Permissions: "rw"},
+		{ContainerPath: "/dev/dri/renderD130", HostPath: "/dev/dri/renderD130", Permissions: "rw"},
+	})
+
+	_, err = plugin.allocateResponse(device.ContainerDevices{{UUID: "GPU-3"}})
+	assert.ErrorContains(t, err, "GPU GPU-3 not found")
+}
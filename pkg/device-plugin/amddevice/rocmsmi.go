@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amddevice
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/amd"
+)
+
+// DiscoverDevices returns the GPUs of the node reported by the rocm-smi at rocmSMIPath, each shared by up to
+// splitCount containers.
+func DiscoverDevices(rocmSMIPath string, splitCount int32) ([]*device.DeviceInfo, error) {
+	output, err := exec.Command(rocmSMIPath, "--showuniqueid", "--showproductname", "--showmeminfo", "vram", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %v", rocmSMIPath, err)
+	}
+	return parseROCmSMI(output, splitCount)
+}
+
+// parseROCmSMI returns the GPUs of the JSON output of rocm-smi, by card index. The GPUs are identified by the
+// UUID ROCm gives them, GPU- followed by their unique ID, which ROCR_VISIBLE_DEVICES accepts.
+func parseROCmSMI(output []byte, splitCount int32) ([]*device.DeviceInfo, error) {
+	var cards map[string]map[string]string
+	if err := json.Unmarshal(output, &cards); err != nil {
+		return nil, fmt.Errorf("failed to parse the output of rocm-smi: %v", err)
+	}
+	var res []*device.DeviceInfo
+	for card, fields := range cards {
+		index, err := strconv.Atoi(strings.TrimPrefix(card, "card"))
+		if !strings.HasPrefix(card, "card") || err != nil {
+			continue
+		}
+		// The case of the fields differs between the versions of rocm-smi.
+		lower := make(map[string]string, len(fields))
+		for k, v := range fields {
+			lower[strings.ToLower(k)] = strings.TrimSpace(v)
+		}
+		uniqueID := strings.TrimPrefix(strings.ToLower(lower["unique id"]), "0x")
+		if uniqueID == "" || uniqueID == "n/a" {
+			return nil, fmt.Errorf("unique ID of %s not reported by rocm-smi", card)
+		}
+		vram, err := strconv.ParseInt(lower["vram total memory (b)"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VRAM total memory of %s: %v", card, err)
+		}
+		model := strings.TrimPrefix(lower["card series"], "AMD ")
+		if model == "" {
+			model = "GPU"
+		}
+		res = append(res, &device.DeviceInfo{
+			ID:           "GPU-" + uniqueID,
+			Index:        uint(index),
+			Count:        splitCount,
+			Devmem:       int32(vram / 1024 / 1024),
+			Devcore:      100,
+			Type:         amd.AMDDevice + "-" + model,
+			Health:       true,
+			DeviceVendor: amd.AMDCommonWord,
+		})
+	}
+	slices.SortFunc(res, func(a, b *device.DeviceInfo) int { return int(a.Index) - int(b.Index) })
+	return res, nil
+}
+
+// RenderNodes returns the DRM render node of each GPU by its ID, read from the sysfs mounted at sysfs. Only the
+// render nodes of the GPUs allocated to a container are mounted in it, with /dev/kfd.
+func RenderNodes(sysfs string, devices []*device.DeviceInfo) (map[string]string, error) {
+	res := make(map[string]string, len(devices))
+	for _, dev := range devices {
+		entries, err := os.ReadDir(filepath.Join(sysfs, "class", "drm", fmt.Sprintf("card%d", dev.Index), "device", "drm"))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), "renderD") {
+				res[dev.ID] = "/dev/dri/" + entry.Name()
+				break
+			}
+		}
+		if _, ok := res[dev.ID]; !ok {
+			return nil, fmt.Errorf("render node of card%d not found", dev.Index)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockdevice
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/mock"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func TestApiDevices(t *testing.T) {
+	plugin := NewMockDevicePlugin(mock.MockConfig{DeviceCount: 2, DeviceSplitCount: 3}, "node1")
+	devices := plugin.apiDevices()
+	assert.Equal(t, len(devices), 6)
+	assert.Equal(t, devices[0].ID, "MOCK-node1-0-0")
+	assert.Equal(t, devices[5].ID, "MOCK-node1-1-2")
+}
+
+func TestRegisterAnnotations(t *testing.T) {
+	plugin := NewMockDevicePlugin(mock.MockConfig{DeviceCount: 1, DeviceMemory: 1024}, "node1")
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	annos := plugin.registerAnnotations(now)
+	assert.Equal(t, annos[mock.HandshakeAnnos], "Reported_"+util.FormatHandshakeTime(now))
+	devices, err := device.UnMarshalNodeDevices(annos[mock.RegisterAnnos])
+	assert.NilError(t, err)
+	assert.Equal(t, len(devices), 1)
+	assert.Equal(t, devices[0].ID, "MOCK-node1-0")
+	assert.Equal(t, devices[0].Devmem, int32(1024))
+	assert.Equal(t, devices[0].Type, "Mock-GPU")
+}
+
+func TestNextDeviceRequest(t *testing.T) {
+	mock.InitMockDevice(mock.MockConfig{})
+	pd := device.PodSingleDevice{
+		{{UUID: "MOCK-node1-0", Type: mock.MockDevice, Usedmem: 1024, Usedcores: 30}},
+		{{UUID: "MOCK-node1-1", Type: mock.MockDevice, Usedmem: 2048, Usedcores: 50}},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		device.InRequestDevices[mock.MockDevice]: device.EncodePodSingleDevice(pd),
+	}}}
+
+	devreq, remaining, err := nextDeviceRequest(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, len(devreq), 1)
+	assert.Equal(t, devreq[0].UUID, "MOCK-node1-0")
+
+	pod.Annotations[device.InRequestDevices[mock.MockDevice]] = device.EncodePodSingleDevice(remaining)
+	devreq, remaining, err = nextDeviceRequest(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, len(devreq), 1)
+	assert.Equal(t, devreq[0].UUID, "MOCK-node1-1")
+
+	pod.Annotations[device.InRequestDevices[mock.MockDevice]] = device.EncodePodSingleDevice(remaining)
+	_, _, err = nextDeviceRequest(pod)
+	assert.ErrorContains(t, err, "device request not found")
+}
+
+func TestAllocateResponse(t *testing.T) {
+	response := allocateResponse(device.ContainerDevices{
+		{UUID: "MOCK-node1-0", Usedmem: 1024, Usedcores: 30},
+		{UUID: "MOCK-node1-1", Usedmem: 2048, Usedcores: 30},
+	})
+	assert.DeepEqual(t, response.Envs, map[string]string{
+		mock.VisibleDevicesEnv:           "MOCK-node1-0,MOCK-node1-1",
+		mock.DeviceMemoryLimitEnv + "_0": "1024m",
+		mock.DeviceMemoryLimitEnv + "_1": "2048m",
+		mock.DeviceCoreLimitEnv:          "30",
+	})
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockdevice
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/mock"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// registerAnnotations returns the node annotations registering the synthetic devices and answering the handshake of
+// the scheduler at now.
+func (plugin *MockDevicePlugin) registerAnnotations(now time.Time) map[string]string {
+	return map[string]string{
+		mock.RegisterAnnos:  device.MarshalNodeDevices(plugin.devices),
+		mock.HandshakeAnnos: "Reported_" + util.FormatHandshakeTime(now),
+	}
+}
+
+// RegisterInAnnotation registers the synthetic devices in the annotations of the node.
+func (plugin *MockDevicePlugin) RegisterInAnnotation() error {
+	node, err := util.GetNode(plugin.nodeName)
+	if err != nil {
+		klog.Errorln("get node error", err.Error())
+		return err
+	}
+	annos := plugin.registerAnnotations(time.Now())
+	klog.V(4).Infof("patch node with the following annos %v", annos)
+	return util.PatchNodeAnnotations(node, annos)
+}
+
+// WatchAndRegister registers the synthetic devices in the annotations of the node until stop is closed.
+func (plugin *MockDevicePlugin) WatchAndRegister(stop <-chan struct{}) {
+	klog.Info("Starting WatchAndRegister")
+	errorSleepInterval := time.Second * 5
+	successSleepInterval := time.Second * 30
+	for {
+		interval := successSleepInterval
+		if err := plugin.RegisterInAnnotation(); err != nil {
+			klog.Errorf("Failed to register annotation: %v", err)
+			interval = errorSleepInterval
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,275 @@
+/*
+ * Copyright (c) 2025, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+)
+
+const (
+	defaultPrewarmTimeout = 10 * time.Second
+	defaultPrewarmTTL     = 5 * time.Minute
+)
+
+var allocationLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "hami_device_plugin_allocation_latency_seconds",
+	Help:    "Latency from the bind of a pod to the allocation of the GPUs of a container, by whether they were prewarmed",
+	Buckets: prometheus.ExponentialBuckets(0.5, 2, 10),
+}, []string{"prewarmed"})
+
+// RegisterMetrics registers the metrics of the device plugin.
+func RegisterMetrics(reg prometheus.Registerer) {
+	reg.MustRegister(allocationLatency)
+}
+
+// observeAllocationLatency observes the latency of the allocation of a container of the pod from its bind.
+func observeAllocationLatency(pod *corev1.Pod, prewarmed bool, now time.Time) {
+	bindTime, err := strconv.ParseInt(pod.Annotations[util.BindTimeAnnotations], 10, 64)
+	if err != nil {
+		return
+	}
+	allocationLatency.WithLabelValues(strconv.FormatBool(prewarmed)).Observe(max(now.Sub(time.Unix(bindTime, 0)).Seconds(), 0))
+}
+
+// containerCacheDir is the host directory of the HAMi-core shared region of a container.
+func containerCacheDir(hookPath string, uid types.UID, container string) string {
+	return fmt.Sprintf("%s/vgpu/containers/%s_%s", hookPath, uid, container)
+}
+
+// prewarmedPod is a pod seen by the prewarmer. Dirs are the prewarmed cache directories of the containers not
+// allocated yet, claimed the containers allocated, whose directories belong to the allocation.
+type prewarmedPod struct {
+	dirs    map[string]string
+	claimed map[string]bool
+	created time.Time
+}
+
+func newPrewarmedPod(now time.Time) *prewarmedPod {
+	return &prewarmedPod{dirs: make(map[string]string), claimed: make(map[string]bool), created: now}
+}
+
+// prewarmer prepares the GPUs assigned to a pod as soon as the scheduler locks the node to bind it, before the
+// kubelet calls Allocate: it creates the HAMi-core cache directories of the containers and runs the warming
+// command on the GPUs. The GPUs are known from the allocation annotation of the pod, set at filter. It's best
+// effort, allocations never wait for it, and the preparations of pods not allocated within the TTL are removed.
+type prewarmer struct {
+	config   nvidia.PrewarmConfig
+	hookPath string
+	getPod   func(ctx context.Context, namespace, name string) (*corev1.Pod, error)
+	warm     func(ctx context.Context, uuids []string) error
+
+	mu   sync.Mutex
+	pods map[types.UID]*prewarmedPod
+	// The node lock last seen, the node is updated many times while it's held
+	lastLock string
+}
+
+func newPrewarmer(config nvidia.PrewarmConfig, hookPath string) *prewarmer {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultPrewarmTimeout
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultPrewarmTTL
+	}
+	p := &prewarmer{
+		config:   config,
+		hookPath: hookPath,
+		getPod: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			return client.GetClient().CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+		pods: make(map[types.UID]*prewarmedPod),
+	}
+	p.warm = p.runCommand
+	return p
+}
+
+// run prewarms the pods the node is locked for until stop is closed, and cleans up the expired preparations.
+func (p *prewarmer) run(nodeName string, stop <-chan any) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+	factory := informers.NewSharedInformerFactoryWithOptions(client.GetClient(), 0, informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+		o.FieldSelector = fields.OneTermEqualSelector("metadata.name", nodeName).String()
+	}))
+	onNode := func(obj any) {
+		if node, ok := obj.(*corev1.Node); ok {
+			go p.onNodeLock(ctx, node.Annotations[nodelock.NodeLockKey])
+		}
+	}
+	_, err := factory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onNode,
+		UpdateFunc: func(_, obj any) { onNode(obj) },
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to watch the node, GPUs aren't prewarmed", "node", nodeName)
+		return
+	}
+	factory.Start(ctx.Done())
+
+	ticker := time.NewTicker(p.config.TTL / 5)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.expire(time.Now())
+		}
+	}
+}
+
+// onNodeLock prewarms the pod the node lock of value is held for, if any.
+func (p *prewarmer) onNodeLock(ctx context.Context, value string) {
+	p.mu.Lock()
+	seen := value == p.lastLock
+	p.lastLock = value
+	p.mu.Unlock()
+	if value == "" || seen {
+		return
+	}
+	_, ns, name, err := nodelock.ParseNodeLock(value)
+	if err != nil || ns == "" || name == "" {
+		return
+	}
+	pod, err := p.getPod(ctx, ns, name)
+	if err != nil {
+		klog.V(4).ErrorS(err, "Failed to get the pod to prewarm", "pod", klog.KRef(ns, name))
+		return
+	}
+	p.prewarm(ctx, pod, time.Now())
+}
+
+// prewarm prepares the containers of the pod assigned NVIDIA GPUs, once.
+func (p *prewarmer) prewarm(ctx context.Context, pod *corev1.Pod, now time.Time) {
+	pd, err := device.DecodePodDevices(device.InRequestDevices, pod.Annotations)
+	if err != nil || len(pd[nvidia.NvidiaGPUDevice]) == 0 {
+		return
+	}
+	p.mu.Lock()
+	if _, ok := p.pods[pod.UID]; ok {
+		p.mu.Unlock()
+		return
+	}
+	entry := newPrewarmedPod(now)
+	p.pods[pod.UID] = entry
+	p.mu.Unlock()
+
+	uuids := make([]string, 0)
+	for idx, ctrdevs := range pd[nvidia.NvidiaGPUDevice] {
+		if len(ctrdevs) == 0 || idx >= len(pod.Spec.Containers) {
+			continue
+		}
+		for _, dev := range ctrdevs {
+			uuids = append(uuids, strings.Split(dev.UUID, "[")[0])
+		}
+		name := pod.Spec.Containers[idx].Name
+		dir := containerCacheDir(p.hookPath, pod.UID, name)
+		// The lock keeps an allocation racing with the prewarm from having its directory recorded, and removed on expiry.
+		p.mu.Lock()
+		if !entry.claimed[name] {
+			if err := os.MkdirAll(dir, 0777); err != nil {
+				klog.ErrorS(err, "Failed to prewarm the cache directory of a container", "pod", klog.KObj(pod), "dir", dir)
+			} else {
+				os.Chmod(dir, 0777)
+				entry.dirs[name] = dir
+			}
+		}
+		p.mu.Unlock()
+	}
+	klog.InfoS("Prewarming the GPUs of the pod", "pod", klog.KObj(pod), "devices", uuids)
+
+	if len(p.config.Command) == 0 || len(uuids) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+	if err := p.warm(ctx, uuids); err != nil {
+		klog.ErrorS(err, "Failed to run the prewarm command, the GPUs are allocated cold", "pod", klog.KObj(pod), "devices", uuids)
+	}
+}
+
+// runCommand runs the prewarm command with the GPUs visible.
+func (p *prewarmer) runCommand(ctx context.Context, uuids []string) error {
+	cmd := exec.CommandContext(ctx, p.config.Command[0], p.config.Command[1:]...)
+	cmd.Env = append(os.Environ(), "CUDA_VISIBLE_DEVICES="+strings.Join(uuids, ","))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+// claim reports whether the cache directory of the container of the pod was prewarmed, the preparation then
+// belongs to the allocation.
+func (p *prewarmer) claim(uid types.UID, container string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.pods[uid]
+	if !ok {
+		// The pod is allocated before it's prewarmed, it mustn't be prewarmed anymore.
+		entry = newPrewarmedPod(time.Now())
+		p.pods[uid] = entry
+	}
+	entry.claimed[container] = true
+	_, ok = entry.dirs[container]
+	delete(entry.dirs, container)
+	return ok
+}
+
+// expire removes the cache directories prepared for the pods not allocated within the TTL, e.g. because their
+// bind failed.
+func (p *prewarmer) expire(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for uid, entry := range p.pods {
+		if now.Sub(entry.created) < p.config.TTL {
+			continue
+		}
+		for container, dir := range entry.dirs {
+			klog.InfoS("Removing the expired prewarmed cache directory", "uid", uid, "container", container, "dir", dir)
+			os.RemoveAll(dir)
+		}
+		delete(p.pods, uid)
+	}
+}
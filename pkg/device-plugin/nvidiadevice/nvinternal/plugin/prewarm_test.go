@@ -0,0 +1,90 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func TestPrewarmer(t *testing.T) {
+	nvidia.InitNvidiaDevice(nvidia.NvidiaConfig{})
+	hookPath := t.TempDir()
+	var warmed []string
+	p := newPrewarmer(nvidia.PrewarmConfig{Enabled: true, Command: []string{"warm"}, TTL: time.Minute}, hookPath)
+	p.warm = func(ctx context.Context, uuids []string) error {
+		_, ok := ctx.Deadline()
+		assert.Assert(t, ok, "the prewarm command must be time-bounded")
+		warmed = append(warmed, uuids...)
+		return errors.New("no CUDA")
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "uid-1", Annotations: map[string]string{
+			device.InRequestDevices[nvidia.NvidiaGPUDevice]: ";GPU-0,NVIDIA,4000,30:GPU-1,NVIDIA,4000,30:;",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "sidecar"}, {Name: "cuda"}}},
+	}
+	p.getPod = func(_ context.Context, namespace, name string) (*corev1.Pod, error) {
+		assert.Equal(t, namespace+"/"+name, "default/train")
+		return pod, nil
+	}
+
+	t0 := time.Now()
+	p.onNodeLock(context.Background(), t0.Format(time.RFC3339)+",default,train")
+	// The failed command doesn't fail the prewarm
+	assert.DeepEqual(t, warmed, []string{"GPU-0", "GPU-1"})
+	dir := containerCacheDir(hookPath, pod.UID, "cuda")
+	_, err := os.Stat(dir)
+	assert.NilError(t, err)
+	_, err = os.Stat(containerCacheDir(hookPath, pod.UID, "sidecar"))
+	assert.Assert(t, os.IsNotExist(err))
+
+	// Pods are prewarmed once
+	p.onNodeLock(context.Background(), t0.Add(time.Second).Format(time.RFC3339)+",default,train")
+	assert.Equal(t, len(warmed), 2)
+
+	assert.Assert(t, p.claim(pod.UID, "cuda"))
+	assert.Assert(t, !p.claim(pod.UID, "cuda"))
+	// Pods allocated cold aren't prewarmed afterwards
+	assert.Assert(t, !p.claim("uid-2", "cuda"))
+	cold := pod.DeepCopy()
+	cold.UID = "uid-2"
+	p.prewarm(context.Background(), cold, t0)
+	_, err = os.Stat(containerCacheDir(hookPath, cold.UID, "cuda"))
+	assert.Assert(t, os.IsNotExist(err))
+
+	// Allocated directories are kept on expiry, the ones of pods never allocated are removed
+	unbound := pod.DeepCopy()
+	unbound.UID = "uid-3"
+	p.prewarm(context.Background(), unbound, t0)
+	p.expire(t0.Add(2 * time.Minute))
+	_, err = os.Stat(dir)
+	assert.NilError(t, err)
+	_, err = os.Stat(containerCacheDir(hookPath, unbound.UID, "cuda"))
+	assert.Assert(t, os.IsNotExist(err))
+	assert.Equal(t, len(p.pods), 0)
+}
@@ -37,6 +37,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -86,6 +87,32 @@ func GetNumaNode(d nvml.Device) (bool, int, error) {
 	return true, node, nil
 }
 
+// eccErrorTracker remembers when the uncorrectable ECC errors of each device last increased.
+type eccErrorTracker struct {
+	counts map[string]uint64
+	times  map[string]int64
+}
+
+// observe records the volatile uncorrectable ECC error count of the device, returning the unix time
+// errors were last seen, 0 if none.
+func (t *eccErrorTracker) observe(uuid string, count uint64, now time.Time) int64 {
+	if t.counts == nil {
+		t.counts = make(map[string]uint64)
+		t.times = make(map[string]int64)
+	}
+	if prev, ok := t.counts[uuid]; count > 0 && (!ok || count > prev) {
+		t.times[uuid] = now.Unix()
+	}
+	t.counts[uuid] = count
+	return t.times[uuid]
+}
+
+// reportedTemperature rounds the temperature up to steps of 5 Celsius, so the registration
+// isn't patched for every degree.
+func reportedTemperature(temperature uint32) int32 {
+	return int32((temperature + 4) / 5 * 5)
+}
+
 func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 	devs := plugin.Devices()
 	defer nvml.Shutdown()
@@ -94,7 +121,16 @@ func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 		klog.Errorln("nvml Init err: ", nvret)
 		panic(0)
 	}
+	driverVersion, ret := nvml.SystemGetDriverVersion()
+	if ret != nvml.SUCCESS {
+		klog.ErrorS(nil, "Failed to get the driver version, pods requiring a minimum driver version won't fit the GPUs", "ret", ret)
+	}
+	ready := runtimeReady()
+	if !ready {
+		klog.Warningf("HAMi hook not found in %s/vgpu, the GPUs are registered not ready to be shared", hostHookPath)
+	}
 	res := make([]*device.DeviceInfo, 0, len(devs))
+	staticMigs := make(map[string]string)
 	for UUID := range devs {
 		ndev, ret := nvml.DeviceGetHandleByUUID(UUID)
 		if ret != nvml.SUCCESS {
@@ -120,10 +156,15 @@ func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 			panic(0)
 		}
 
-		registeredmem := int32(memoryTotal / 1024 / 1024)
+		physicalmem := int32(memoryTotal / 1024 / 1024)
+		registeredmem := physicalmem
 		if *plugin.schedulerConfig.DeviceMemoryScaling != 1 {
 			registeredmem = int32(float64(registeredmem) * *plugin.schedulerConfig.DeviceMemoryScaling)
 		}
+		if registeredmem <= physicalmem {
+			// Only memory scaled up has an oversubscribed tier
+			physicalmem = 0
+		}
 		klog.Infoln("MemoryScaling=", plugin.schedulerConfig.DeviceMemoryScaling, "registeredmem=", registeredmem)
 		health := true
 		for _, val := range devs {
@@ -142,24 +183,81 @@ func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 		if !ok {
 			klog.ErrorS(err, "failed to get numa information from sysfs", "idx", idx)
 		}
+		temperature := int32(0)
+		if t, ret := ndev.GetTemperature(nvml.TEMPERATURE_GPU); ret == nvml.SUCCESS {
+			temperature = reportedTemperature(t)
+		}
+		eccErrorTime := int64(0)
+		if count, ret := ndev.GetTotalEccErrors(nvml.MEMORY_ERROR_TYPE_UNCORRECTED, nvml.VOLATILE_ECC); ret == nvml.SUCCESS {
+			eccErrorTime = plugin.eccErrors.observe(UUID, count, time.Now())
+		}
+		serial, ret := ndev.GetSerial()
+		if ret != nvml.SUCCESS {
+			klog.V(4).InfoS("Failed to get the serial number, the GPU is identified by its UUID", "uuid", UUID, "ret", ret)
+			serial = ""
+		}
+		eccMode := util.ECCMode("")
+		switch current, _, ret := ndev.GetEccMode(); ret {
+		case nvml.SUCCESS:
+			eccMode = util.ECCModeOff
+			if current == nvml.FEATURE_ENABLED {
+				eccMode = util.ECCModeOn
+			}
+		case nvml.ERROR_NOT_SUPPORTED:
+			eccMode = util.ECCModeOff
+		default:
+			klog.V(4).InfoS("Failed to get the ECC mode", "uuid", UUID, "ret", ret)
+		}
 		if !strings.HasPrefix(Model, "NVIDIA") {
 			// If the model name does not start with "NVIDIA ", we assume it is a virtual GPU or a non-NVIDIA device.
 			// This is to handle cases where the model name might not be in the expected format.
 			Model = fmt.Sprintf("NVIDIA-%s", Model)
 		}
-		res = append(res, &device.DeviceInfo{
-			ID:      UUID,
-			Index:   uint(idx),
-			Count:   int32(*plugin.schedulerConfig.DeviceSplitCount),
-			Devmem:  registeredmem,
-			Devcore: int32(*plugin.schedulerConfig.DeviceCoreScaling * 100),
-			Type:    Model,
-			Numa:    numa,
-			Mode:    plugin.operatingMode,
-			Health:  health,
-		})
+		dev := &device.DeviceInfo{
+			ID:            UUID,
+			Index:         uint(idx),
+			Count:         int32(*plugin.schedulerConfig.DeviceSplitCount),
+			Devmem:        registeredmem,
+			PhysicalMem:   physicalmem,
+			Devcore:       int32(*plugin.schedulerConfig.DeviceCoreScaling * 100),
+			Type:          Model,
+			Numa:          numa,
+			Mode:          plugin.operatingMode,
+			Health:        health,
+			Temperature:   temperature,
+			ECCErrorTime:  eccErrorTime,
+			DriverVersion: driverVersion,
+			Pool:          nvidia.DevicePoolOf(UUID, uint(idx)),
+			RuntimeReady:  &ready,
+			Serial:        serial,
+			ECCMode:       eccMode,
+		}
+		// MIG instances created outside of HAMi are registered instead of their GPU, in mig mode HAMi creates them.
+		if plugin.operatingMode != "mig" {
+			instances, err := staticMigInstances(ndev)
+			if err != nil {
+				klog.ErrorS(err, "Failed to list the MIG instances of the GPU, registering it whole", "uuid", UUID)
+			}
+			if len(instances) > 0 {
+				for _, mig := range staticMigDevices(dev, instances) {
+					staticMigs[mig.ID] = UUID
+					res = append(res, mig)
+					klog.Infof("nvml registered MIG instance id=%v of device %v, memory=%v, type=%v", mig.ID, idx, mig.Devmem, mig.Type)
+				}
+				continue
+			}
+		}
+		res = append(res, dev)
 		klog.Infof("nvml registered device id=%v, memory=%v, type=%v, numa=%v", idx, registeredmem, Model, numa)
 	}
+	plugin.setStaticMigs(staticMigs)
+	// Devices are sorted for the registration not to change with the order of the devices listed.
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Index != res[j].Index {
+			return res[i].Index < res[j].Index
+		}
+		return res[i].ID < res[j].ID
+	})
 	return &res
 }
 
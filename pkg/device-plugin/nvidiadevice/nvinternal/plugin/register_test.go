@@ -0,0 +1,46 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestECCErrorTracker(t *testing.T) {
+	var tracker eccErrorTracker
+	t0 := time.Unix(1000, 0)
+	assert.Equal(t, tracker.observe("gpu-0", 0, t0), int64(0))
+	// Errors present at startup are reported as seen now.
+	assert.Equal(t, tracker.observe("gpu-1", 2, t0), t0.Unix())
+
+	t1 := t0.Add(time.Minute)
+	assert.Equal(t, tracker.observe("gpu-0", 1, t1), t1.Unix())
+	assert.Equal(t, tracker.observe("gpu-1", 2, t1), t0.Unix())
+
+	t2 := t1.Add(time.Minute)
+	assert.Equal(t, tracker.observe("gpu-0", 1, t2), t1.Unix())
+	assert.Equal(t, tracker.observe("gpu-1", 3, t2), t2.Unix())
+}
+
+func TestReportedTemperature(t *testing.T) {
+	for temperature, want := range map[uint32]int32{0: 0, 1: 5, 5: 5, 61: 65, 80: 80, 83: 85} {
+		assert.Equal(t, reportedTemperature(temperature), want)
+	}
+}
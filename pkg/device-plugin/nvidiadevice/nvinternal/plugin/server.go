@@ -47,11 +47,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	spec "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
 	"github.com/google/uuid"
 	"github.com/imdario/mergo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/klog/v2"
 	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
@@ -62,6 +66,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
 	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 // Constants for use by the 'volume-mounts' device list strategy
@@ -103,6 +108,14 @@ type NvidiaDevicePlugin struct {
 	operatingMode string
 	migCurrent    nvidia.MigPartedSpec
 	deviceCache   string
+	eccErrors     eccErrorTracker
+	// GPU of each MIG instance created outside of HAMi registered, by UUID
+	staticMigs      map[string]string
+	staticMigsMutex sync.Mutex
+	// NVML devices assigned to pods are looked up in on allocation
+	nvmllib nvml.Interface
+	// Prepares the GPUs assigned to pods ahead of their allocation, nil if disabled
+	prewarm *prewarmer
 
 	server *grpc.Server
 	health chan *rm.Device
@@ -130,10 +143,16 @@ func readFromConfigFile(sConfig *nvidia.NvidiaConfig, path string) (string, erro
 			if val.FilterDevice != nil && (len(val.FilterDevice.UUID) > 0 || len(val.FilterDevice.Index) > 0) {
 				nvidia.DevicePluginFilterDevice = val.FilterDevice
 			}
+			if err := nvidia.ValidateDevicePools(val.DevicePools); err != nil {
+				klog.ErrorS(err, "Invalid device pools, the devices are registered in no pool")
+			} else {
+				nvidia.DevicePluginDevicePools = val.DevicePools
+			}
 			if len(val.OperatingMode) > 0 {
 				mode = val.OperatingMode
 			}
 			klog.Infof("FilterDevice: %v", val.FilterDevice)
+			klog.Infof("DevicePools: %v", val.DevicePools)
 		}
 	}
 	return mode, nil
@@ -163,6 +182,10 @@ func NewNvidiaDevicePlugin(nvconfig *nvidia.DeviceConfig, resourceManager rm.Res
 	if err := config.InitDevicesWithConfig(sConfig); err != nil {
 		klog.Fatalf("failed to initialize devices: %v", err)
 	}
+	var prewarm *prewarmer
+	if sConfig.NvidiaConfig.Prewarm.Enabled {
+		prewarm = newPrewarmer(sConfig.NvidiaConfig.Prewarm, hostHookPath)
+	}
 	return &NvidiaDevicePlugin{
 		rm:                         resourceManager,
 		config:                     nvconfig,
@@ -181,6 +204,8 @@ func NewNvidiaDevicePlugin(nvconfig *nvidia.DeviceConfig, resourceManager rm.Res
 		operatingMode:              mode,
 		migCurrent:                 nvidia.MigPartedSpec{},
 		deviceCache:                "",
+		nvmllib:                    nvml.New(),
+		prewarm:                    prewarm,
 
 		// These will be reinitialized every
 		// time the plugin server is restarted.
@@ -314,6 +339,10 @@ func (plugin *NvidiaDevicePlugin) Start() error {
 		plugin.WatchAndRegister(plugin.disableWatchAndRegister, plugin.ackDisableWatchAndRegister)
 	}()
 
+	if plugin.prewarm != nil {
+		go plugin.prewarm.run(os.Getenv(util.NodeNameEnvName), plugin.stop)
+	}
+
 	if deviceSupportMig {
 		plugin.ApplyMigTemplate()
 	}
@@ -454,7 +483,7 @@ func (plugin *NvidiaDevicePlugin) GetPreferredAllocation(ctx context.Context, r
 }
 
 // Allocate which return list of devices.
-func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdevicepluginv1beta1.AllocateRequest) (*kubeletdevicepluginv1beta1.AllocateResponse, error) {
+func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdevicepluginv1beta1.AllocateRequest) (_ *kubeletdevicepluginv1beta1.AllocateResponse, err error) {
 	klog.InfoS("Allocate", "request", reqs)
 	responses := kubeletdevicepluginv1beta1.AllocateResponse{}
 	nodename := os.Getenv(util.NodeNameEnvName)
@@ -463,6 +492,9 @@ func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdev
 		//nodelock.ReleaseNodeLock(nodename, NodeLockNvidia, current)
 		return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
 	}
+	_, span := tracing.StartPodSpan(ctx, current, "deviceplugin.Allocate",
+		trace.WithAttributes(attribute.String("hami.resource", string(plugin.rm.Resource()))))
+	defer func() { tracing.EndSpan(span, err) }()
 	klog.Infof("Allocate pod name is %s/%s, annotation is %+v", current.Namespace, current.Name, current.Annotations)
 
 	for idx, req := range reqs.ContainerRequests {
@@ -501,6 +533,29 @@ func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdev
 				PodAllocationFailed(nodename, current, NodeLockNvidia)
 				return &kubeletdevicepluginv1beta1.AllocateResponse{}, errors.New("device number not matched")
 			}
+			if unknown := plugin.unregisteredDevices(devreq); len(unknown) > 0 {
+				err = fmt.Errorf("devices %v assigned to the pod are not on node %s anymore, e.g. because the GPU was replaced, recreate the pod to schedule it again", unknown, nodename)
+				recordPodEvent(current, corev1.EventTypeWarning, EventReasonOrphanedDeviceAssignment, err.Error())
+				PodAllocationFailed(nodename, current, NodeLockNvidia)
+				return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
+			}
+			if missing := plugin.missingDevices(devreq); len(missing) > 0 {
+				err = fmt.Errorf("devices %v assigned to the pod are gone from node %s, e.g. fell off the bus, the scheduler reschedules the pod", missing, nodename)
+				recordPodEvent(current, corev1.EventTypeWarning, EventReasonAllocationDeviceMissing, err.Error())
+				if perr := util.PatchPodAnnotations(current, map[string]string{util.DeviceMissingAnnotations: strings.Join(missing, ",")}); perr != nil {
+					klog.ErrorS(perr, "Failed to annotate pod with the missing devices", "pod", klog.KObj(current), "devices", missing)
+				}
+				PodAllocationFailed(nodename, current, NodeLockNvidia)
+				return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
+			}
+			uuids := make([]string, 0, len(devreq))
+			for _, dev := range devreq {
+				uuids = append(uuids, dev.UUID)
+			}
+			span.AddEvent("container allocated", trace.WithAttributes(
+				attribute.String("hami.container", currentCtr.Name),
+				attribute.StringSlice("hami.device_uuids", uuids),
+			))
 			response, err := plugin.getAllocateResponse(plugin.GetContainerDeviceStrArray(devreq))
 			if err != nil {
 				return nil, fmt.Errorf("failed to get allocate response: %v", err)
@@ -513,14 +568,26 @@ func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdev
 			}
 
 			if plugin.operatingMode != "mig" {
+				// Containers mixing whole and shared GPUs limit the cores of each GPU by index.
+				mixedCores := false
+				for _, dev := range devreq {
+					mixedCores = mixedCores || dev.Usedcores != devreq[0].Usedcores
+				}
 				for i, dev := range devreq {
-					limitKey := fmt.Sprintf("CUDA_DEVICE_MEMORY_LIMIT_%v", i)
+					limitKey := fmt.Sprintf("%s_%v", nvidia.DeviceMemoryLimitEnv, i)
 					response.Envs[limitKey] = fmt.Sprintf("%vm", dev.Usedmem)
+					if mixedCores {
+						response.Envs[fmt.Sprintf("%s_%v", nvidia.DeviceSMLimitEnv, i)] = fmt.Sprint(dev.Usedcores)
+					}
+				}
+				response.Envs[nvidia.DeviceSMLimitEnv] = fmt.Sprint(devreq[0].Usedcores)
+				// HAMi-core enforces the soft memory limit like the shared isolation.
+				if devreq[0].OOMMode == util.OOMModeSoft {
+					response.Envs[util.IsolationEnv] = string(util.IsolationShared)
 				}
-				response.Envs["CUDA_DEVICE_SM_LIMIT"] = fmt.Sprint(devreq[0].Usedcores)
-				response.Envs["CUDA_DEVICE_MEMORY_SHARED_CACHE"] = fmt.Sprintf("%s/vgpu/%v.cache", hostHookPath, uuid.New().String())
+				response.Envs[nvidia.DeviceMemorySharedCacheEnv] = fmt.Sprintf("%s/vgpu/%v.cache", hostHookPath, uuid.New().String())
 				if *plugin.schedulerConfig.DeviceMemoryScaling > 1 {
-					response.Envs["CUDA_OVERSUBSCRIBE"] = "true"
+					response.Envs[nvidia.OversubscribeEnv] = "true"
 				}
 				if *plugin.schedulerConfig.LogLevel != "" {
 					response.Envs["LIBCUDA_LOG_LEVEL"] = string(*plugin.schedulerConfig.LogLevel)
@@ -528,8 +595,15 @@ func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdev
 				if plugin.schedulerConfig.DisableCoreLimit {
 					response.Envs[util.CoreLimitSwitch] = "disable"
 				}
-				cacheFileHostDirectory := fmt.Sprintf("%s/vgpu/containers/%s_%s", hostHookPath, current.UID, currentCtr.Name)
-				os.RemoveAll(cacheFileHostDirectory)
+				if weight, ok := current.Annotations[nvidia.GPUWeightAllocated]; ok {
+					response.Envs[nvidia.TaskWeightEnv] = weight
+				}
+				cacheFileHostDirectory := containerCacheDir(hostHookPath, current.UID, currentCtr.Name)
+				prewarmed := plugin.prewarm.claim(current.UID, currentCtr.Name)
+				if !prewarmed {
+					os.RemoveAll(cacheFileHostDirectory)
+				}
+				observeAllocationLatency(current, prewarmed, time.Now())
 
 				os.MkdirAll(cacheFileHostDirectory, 0777)
 				os.Chmod(cacheFileHostDirectory, 0777)
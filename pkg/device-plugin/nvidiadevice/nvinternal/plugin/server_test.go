@@ -416,6 +416,7 @@ func Test_configOverride(t *testing.T) {
 			OperatingMode            string               `json:"operatingmode"`
 			Migstrategy              string               `json:"migstrategy"`
 			FilterDevice             *nvidia.FilterDevice `json:"filterdevices"`
+			DevicePools              []nvidia.DevicePool  `json:"devicepools"`
 		}{
 			{
 				NodeDefaultConfig: nvidia.NodeDefaultConfig{
@@ -440,6 +441,10 @@ func Test_configOverride(t *testing.T) {
 				OperatingMode: "custom",
 				Migstrategy:   "mixed",
 				FilterDevice:  nil,
+				DevicePools: []nvidia.DevicePool{
+					{Name: "prod", Index: []uint{0, 1, 2, 3, 4, 5}},
+					{Name: "dev", UUID: []string{"GPU-6", "GPU-7"}},
+				},
 			},
 		},
 	}
@@ -467,6 +472,20 @@ func Test_configOverride(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
+	t.Cleanup(func() { nvidia.DevicePluginDevicePools = nil })
+	for _, test := range []struct {
+		uuid  string
+		index uint
+		want  string
+	}{
+		{uuid: "GPU-0", index: 0, want: "prod"},
+		{uuid: "GPU-7", index: 7, want: "dev"},
+		{uuid: "GPU-8", index: 8, want: ""},
+	} {
+		if got := nvidia.DevicePoolOf(test.uuid, test.index); got != test.want {
+			t.Errorf("DevicePoolOf(%s, %d) = %q, want %q", test.uuid, test.index, got, test.want)
+		}
+	}
 	expected := nvidia.NvidiaConfig{
 		NodeDefaultConfig: nvidia.NodeDefaultConfig{
 			DeviceSplitCount:    func() *uint { v := uint(3); return &v }(),
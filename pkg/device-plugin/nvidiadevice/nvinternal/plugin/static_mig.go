@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+// staticMigInstance is a MIG instance created outside of HAMi, e.g. with nvidia-smi.
+type staticMigInstance struct {
+	UUID string
+	// Profile is e.g. 2g.20gb, or 1c.2g.20gb for a compute instance smaller than its GPU instance.
+	Profile string
+	// Memory in MB
+	Memory int32
+}
+
+// staticMigInstances returns the MIG instances of the GPU sorted by UUID, none if MIG is disabled on it.
+func staticMigInstances(gpu nvml.Device) ([]staticMigInstance, error) {
+	current, _, ret := gpu.GetMigMode()
+	if ret == nvml.ERROR_NOT_SUPPORTED {
+		return nil, nil
+	}
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting MIG mode: %v", ret)
+	}
+	if current != nvml.DEVICE_MIG_ENABLE {
+		return nil, nil
+	}
+	count, ret := gpu.GetMaxMigDeviceCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("error getting the maximum number of MIG devices: %v", ret)
+	}
+	res := make([]staticMigInstance, 0, count)
+	for i := range count {
+		mig, ret := gpu.GetMigDeviceHandleByIndex(i)
+		if ret == nvml.ERROR_NOT_FOUND || ret == nvml.ERROR_INVALID_ARGUMENT {
+			continue
+		}
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("error getting MIG device %d: %v", i, ret)
+		}
+		uuid, ret := mig.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("error getting the UUID of MIG device %d: %v", i, ret)
+		}
+		attrs, ret := mig.GetAttributes()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("error getting the attributes of MIG device %s: %v", uuid, ret)
+		}
+		res = append(res, staticMigInstance{UUID: uuid, Profile: migProfile(attrs), Memory: int32(attrs.MemorySizeMB)})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].UUID < res[j].UUID })
+	return res, nil
+}
+
+// migProfile returns the profile name of a MIG device, its memory rounded up to GB as nvidia-smi does.
+func migProfile(attrs nvml.DeviceAttributes) string {
+	res := fmt.Sprintf("%dg.%dgb", attrs.GpuInstanceSliceCount, (attrs.MemorySizeMB+1023)/1024)
+	if attrs.ComputeInstanceSliceCount != 0 && attrs.ComputeInstanceSliceCount < attrs.GpuInstanceSliceCount {
+		res = fmt.Sprintf("%dc.%s", attrs.ComputeInstanceSliceCount, res)
+	}
+	return res
+}
+
+// staticMigType returns the device type of a MIG instance of the GPU model, e.g. NVIDIA A100-MIG-2g.20gb for a
+// 2g.20gb instance of a NVIDIA A100-SXM4-80GB.
+func staticMigType(model, profile string) string {
+	name := strings.TrimLeft(strings.TrimPrefix(model, "NVIDIA"), " -")
+	if i := strings.IndexAny(name, " -"); i > 0 {
+		name = name[:i]
+	}
+	return "NVIDIA " + name + nvidia.StaticMIGTypeMarker + profile
+}
+
+// staticMigDevices returns the devices registered for the MIG instances of the GPU instead of the GPU itself, so
+// its memory is only counted once. Each instance is allocated whole to a single container.
+func staticMigDevices(gpu *device.DeviceInfo, instances []staticMigInstance) []*device.DeviceInfo {
+	res := make([]*device.DeviceInfo, 0, len(instances))
+	for _, mig := range instances {
+		dev := *gpu
+		dev.ID = mig.UUID
+		dev.Count = 1
+		dev.Devmem = mig.Memory
+		dev.PhysicalMem = 0
+		dev.Type = staticMigType(gpu.Type, mig.Profile)
+		// The instances share the serial number of their GPU, they're identified by their UUID.
+		dev.Serial = ""
+		res = append(res, &dev)
+	}
+	return res
+}
+
+// setStaticMigs records the GPU of each MIG instance registered, for their allocations to be accepted.
+func (plugin *NvidiaDevicePlugin) setStaticMigs(parents map[string]string) {
+	plugin.staticMigsMutex.Lock()
+	defer plugin.staticMigsMutex.Unlock()
+	plugin.staticMigs = parents
+}
+
+// staticMigParent returns the GPU of the MIG instance registered, false if uuid isn't one.
+func (plugin *NvidiaDevicePlugin) staticMigParent(uuid string) (string, bool) {
+	plugin.staticMigsMutex.Lock()
+	defer plugin.staticMigsMutex.Unlock()
+	parent, ok := plugin.staticMigs[uuid]
+	return parent, ok
+}
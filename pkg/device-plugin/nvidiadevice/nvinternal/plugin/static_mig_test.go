@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock"
+	"gotest.tools/v3/assert"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
+)
+
+// newMigGPU mocks a GPU with MIG mode set, whose MIG device slots hold the instances, nil for an empty slot.
+func newMigGPU(mode int, instances []*staticMigInstance, attrs []nvml.DeviceAttributes) *mock.Device {
+	return &mock.Device{
+		GetMigModeFunc: func() (int, int, nvml.Return) { return mode, mode, nvml.SUCCESS },
+		GetMaxMigDeviceCountFunc: func() (int, nvml.Return) {
+			return len(instances), nvml.SUCCESS
+		},
+		GetMigDeviceHandleByIndexFunc: func(n int) (nvml.Device, nvml.Return) {
+			if instances[n] == nil {
+				return nil, nvml.ERROR_NOT_FOUND
+			}
+			return &mock.Device{
+				GetUUIDFunc:       func() (string, nvml.Return) { return instances[n].UUID, nvml.SUCCESS },
+				GetAttributesFunc: func() (nvml.DeviceAttributes, nvml.Return) { return attrs[n], nvml.SUCCESS },
+			}, nvml.SUCCESS
+		},
+	}
+}
+
+func TestStaticMigInstances(t *testing.T) {
+	instances := []*staticMigInstance{{UUID: "MIG-b"}, nil, {UUID: "MIG-a"}, {UUID: "MIG-c"}}
+	attrs := []nvml.DeviceAttributes{
+		{GpuInstanceSliceCount: 2, ComputeInstanceSliceCount: 2, MemorySizeMB: 19968},
+		{},
+		{GpuInstanceSliceCount: 3, ComputeInstanceSliceCount: 3, MemorySizeMB: 40192},
+		{GpuInstanceSliceCount: 2, ComputeInstanceSliceCount: 1, MemorySizeMB: 19968},
+	}
+	want := []staticMigInstance{
+		{UUID: "MIG-a", Profile: "3g.40gb", Memory: 40192},
+		{UUID: "MIG-b", Profile: "2g.20gb", Memory: 19968},
+		{UUID: "MIG-c", Profile: "1c.2g.20gb", Memory: 19968},
+	}
+	got, err := staticMigInstances(newMigGPU(nvml.DEVICE_MIG_ENABLE, instances, attrs))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, want)
+
+	// The instances are listed in the same order whatever the order of their slots, e.g. after a restart.
+	instances[0], instances[2], attrs[0], attrs[2] = instances[2], instances[0], attrs[2], attrs[0]
+	got, err = staticMigInstances(newMigGPU(nvml.DEVICE_MIG_ENABLE, instances, attrs))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, want)
+
+	got, err = staticMigInstances(newMigGPU(nvml.DEVICE_MIG_DISABLE, instances, attrs))
+	assert.NilError(t, err)
+	assert.Equal(t, len(got), 0)
+	got, err = staticMigInstances(&mock.Device{
+		GetMigModeFunc: func() (int, int, nvml.Return) { return 0, 0, nvml.ERROR_NOT_SUPPORTED },
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(got), 0)
+}
+
+func TestStaticMigDevices(t *testing.T) {
+	gpu := &device.DeviceInfo{
+		ID: "GPU-0", Index: 1, Count: 10, Devmem: 81920, Devcore: 100, Type: "NVIDIA A100-SXM4-80GB", Mode: "hami-core",
+		Health: true, Numa: 1,
+	}
+	got := staticMigDevices(gpu, []staticMigInstance{
+		{UUID: "MIG-a", Profile: "3g.40gb", Memory: 40192},
+		{UUID: "MIG-b", Profile: "2g.20gb", Memory: 19968},
+	})
+	assert.DeepEqual(t, got, []*device.DeviceInfo{
+		{ID: "MIG-a", Index: 1, Count: 1, Devmem: 40192, Devcore: 100, Type: "NVIDIA A100-MIG-3g.40gb", Mode: "hami-core", Health: true, Numa: 1},
+		{ID: "MIG-b", Index: 1, Count: 1, Devmem: 19968, Devcore: 100, Type: "NVIDIA A100-MIG-2g.20gb", Mode: "hami-core", Health: true, Numa: 1},
+	})
+	// The GPU isn't modified.
+	assert.Equal(t, gpu.ID, "GPU-0")
+
+	for model, want := range map[string]string{
+		"NVIDIA A100-SXM4-80GB": "NVIDIA A100-MIG-1g.10gb",
+		"NVIDIA H100 80GB HBM3": "NVIDIA H100-MIG-1g.10gb",
+		"NVIDIA-A30":            "NVIDIA A30-MIG-1g.10gb",
+		"NVIDIA A100 80GB PCIe": "NVIDIA A100-MIG-1g.10gb",
+	} {
+		assert.Equal(t, staticMigType(model, "1g.10gb"), want)
+	}
+}
+
+func TestUnregisteredStaticMigs(t *testing.T) {
+	plugin := NvidiaDevicePlugin{rm: devicesResourceManager{devices: rm.Devices{"GPU-0": &rm.Device{}}}}
+	plugin.setStaticMigs(map[string]string{"MIG-a": "GPU-0", "MIG-b": "GPU-gone"})
+	unknown := plugin.unregisteredDevices(device.ContainerDevices{{UUID: "MIG-a"}, {UUID: "MIG-b"}, {UUID: "MIG-c"}})
+	assert.DeepEqual(t, unknown, []string{"MIG-b", "MIG-c"})
+}
@@ -49,6 +49,18 @@ func GetLibPath() string {
 	return libPath
 }
 
+// runtimeReady reports whether the HAMi hook isolating the containers sharing GPUs is installed on the node, by
+// probing the library and the preload file mounted into them.
+func runtimeReady() bool {
+	for _, path := range []string{GetLibPath(), hostHookPath + "/vgpu/ld.so.preload"} {
+		if _, err := os.Stat(path); err != nil {
+			klog.V(4).InfoS("HAMi hook not found", "path", path, "err", err)
+			return false
+		}
+	}
+	return true
+}
+
 func GetNextDeviceRequest(dtype string, p corev1.Pod) (corev1.Container, device.ContainerDevices, error) {
 	pdevices, err := device.DecodePodDevices(device.InRequestDevices, p.Annotations)
 	if err != nil {
@@ -437,6 +449,81 @@ func (nv *NvidiaDevicePlugin) GetContainerDeviceStrArray(c device.ContainerDevic
 	return tmp
 }
 
+// EventReasonOrphanedDeviceAssignment indicates a pod is assigned devices no longer on its node.
+const EventReasonOrphanedDeviceAssignment = "OrphanedDeviceAssignment"
+
+// unregisteredDevices returns the UUIDs of the devices in c the plugin doesn't manage, e.g. because they were
+// assigned before the GPU was replaced.
+func (nv *NvidiaDevicePlugin) unregisteredDevices(c device.ContainerDevices) []string {
+	devices := nv.Devices()
+	res := []string{}
+	for _, val := range c {
+		// MIG instances are assigned as <device UUID>[<template>-<instance>], or by their own UUID if created
+		// outside of HAMi.
+		id := strings.Split(val.UUID, "[")[0]
+		if parent, ok := nv.staticMigParent(id); ok {
+			id = parent
+		}
+		if !devices.Contains(id) {
+			res = append(res, val.UUID)
+		}
+	}
+	return res
+}
+
+// EventReasonAllocationDeviceMissing indicates a pod is assigned devices NVML no longer finds on its node.
+const EventReasonAllocationDeviceMissing = "AllocationDeviceMissing"
+
+// missingDevices returns the UUIDs of the devices in c NVML doesn't find anymore, e.g. because they fell off the
+// bus after the scheduler assigned them. Nothing is reported missing if NVML can't be initialized.
+func (nv *NvidiaDevicePlugin) missingDevices(c device.ContainerDevices) []string {
+	res := []string{}
+	if nv.nvmllib == nil {
+		return res
+	}
+	if ret := nv.nvmllib.Init(); ret != nvml.SUCCESS {
+		klog.Errorln("nvml Init err: ", ret)
+		return res
+	}
+	defer nv.nvmllib.Shutdown()
+	for _, val := range c {
+		// MIG instances are assigned as <device UUID>[<template>-<instance>].
+		_, ret := nv.nvmllib.DeviceGetHandleByUUID(strings.Split(val.UUID, "[")[0])
+		if ret == nvml.ERROR_NOT_FOUND || ret == nvml.ERROR_GPU_IS_LOST {
+			res = append(res, val.UUID)
+		}
+	}
+	return res
+}
+
+// recordPodEvent records an event on the pod, failures are only logged.
+func recordPodEvent(pod *corev1.Pod, eventType, reason, message string) {
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: pod.Name + ".",
+			Namespace:    pod.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  pod.Namespace,
+			Name:       pod.Name,
+			UID:        pod.UID,
+		},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         corev1.EventSource{Component: "hami-device-plugin", Host: os.Getenv(util.NodeNameEnvName)},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := client.GetClient().CoreV1().Events(pod.Namespace).Create(context.Background(), event, metav1.CreateOptions{}); err != nil {
+		klog.ErrorS(err, "Failed to record pod event", "pod", klog.KObj(pod), "reason", reason)
+	}
+}
+
 func PodAllocationTrySuccess(nodeName string, devName string, lockName string, pod *corev1.Pod) {
 	refreshed, err := client.GetClient().CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
 	if err != nil {
@@ -17,14 +17,18 @@
 package plugin
 
 import (
+	"os"
 	"testing"
 
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock"
 	"golang.org/x/net/context"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
 	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
@@ -273,3 +277,105 @@ func Test_PodAllocationFailed(t *testing.T) {
 		t.Errorf("Expected DeviceBindPhase annotation to be '%s', got '%s'", util.DeviceBindFailed, annos)
 	}
 }
+
+type devicesResourceManager struct {
+	rm.ResourceManager
+	devices rm.Devices
+}
+
+func (m devicesResourceManager) Devices() rm.Devices {
+	return m.devices
+}
+
+func TestUnregisteredDevices(t *testing.T) {
+	plugin := NvidiaDevicePlugin{rm: devicesResourceManager{devices: rm.Devices{
+		"GPU-new": &rm.Device{},
+		"GPU-mig": &rm.Device{},
+	}}}
+	unknown := plugin.unregisteredDevices(device.ContainerDevices{
+		{UUID: "GPU-new"},
+		{UUID: "GPU-mig[1g.10gb-0]"},
+		{UUID: "GPU-replaced"},
+		{UUID: "GPU-replaced-mig[1g.10gb-1]"},
+	})
+	if len(unknown) != 2 || unknown[0] != "GPU-replaced" || unknown[1] != "GPU-replaced-mig[1g.10gb-1]" {
+		t.Errorf("Expected the replaced devices to be unregistered, got %v", unknown)
+	}
+}
+
+func TestMissingDevices(t *testing.T) {
+	nvmllib := &mock.Interface{
+		InitFunc:     func() nvml.Return { return nvml.SUCCESS },
+		ShutdownFunc: func() nvml.Return { return nvml.SUCCESS },
+		DeviceGetHandleByUUIDFunc: func(uuid string) (nvml.Device, nvml.Return) {
+			switch uuid {
+			case "GPU-present", "GPU-mig":
+				return &mock.Device{}, nvml.SUCCESS
+			case "GPU-lost":
+				return nil, nvml.ERROR_GPU_IS_LOST
+			case "GPU-busy":
+				return nil, nvml.ERROR_UNKNOWN
+			}
+			return nil, nvml.ERROR_NOT_FOUND
+		},
+	}
+	plugin := NvidiaDevicePlugin{nvmllib: nvmllib}
+	missing := plugin.missingDevices(device.ContainerDevices{
+		{UUID: "GPU-present"},
+		{UUID: "GPU-mig[1g.10gb-0]"},
+		{UUID: "GPU-lost"},
+		{UUID: "GPU-busy"},
+		{UUID: "GPU-gone-mig[1g.10gb-1]"},
+	})
+	if len(missing) != 2 || missing[0] != "GPU-lost" || missing[1] != "GPU-gone-mig[1g.10gb-1]" {
+		t.Errorf("Expected the devices fallen off the bus to be missing, got %v", missing)
+	}
+
+	// Nothing is reported missing without NVML.
+	nvmllib.InitFunc = func() nvml.Return { return nvml.ERROR_LIBRARY_NOT_FOUND }
+	if missing := plugin.missingDevices(device.ContainerDevices{{UUID: "GPU-lost"}}); len(missing) != 0 {
+		t.Errorf("Expected no missing devices if NVML fails to initialize, got %v", missing)
+	}
+}
+
+func TestRecordPodEvent(t *testing.T) {
+	client.KubeClient = fake.NewSimpleClientset()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "test-uid"}}
+
+	recordPodEvent(pod, corev1.EventTypeWarning, EventReasonOrphanedDeviceAssignment, "recreate the pod")
+
+	events, err := client.KubeClient.CoreV1().Events(pod.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	if len(events.Items) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events.Items))
+	}
+	event := events.Items[0]
+	if event.InvolvedObject.UID != pod.UID || event.Reason != EventReasonOrphanedDeviceAssignment || event.Message != "recreate the pod" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
+
+func TestRuntimeReady(t *testing.T) {
+	defer func(path string) { hostHookPath = path }(hostHookPath)
+	hostHookPath = t.TempDir()
+	if runtimeReady() {
+		t.Fatalf("expected the runtime not ready without the HAMi hook")
+	}
+	if err := os.MkdirAll(hostHookPath+"/vgpu", 0o755); err != nil {
+		t.Fatalf("Failed to create the hook directory: %v", err)
+	}
+	if err := os.WriteFile(hostHookPath+"/vgpu/libvgpu.so", nil, 0o644); err != nil {
+		t.Fatalf("Failed to create the hook library: %v", err)
+	}
+	if runtimeReady() {
+		t.Fatalf("expected the runtime not ready without ld.so.preload")
+	}
+	if err := os.WriteFile(hostHookPath+"/vgpu/ld.so.preload", nil, 0o644); err != nil {
+		t.Fatalf("Failed to create ld.so.preload: %v", err)
+	}
+	if !runtimeReady() {
+		t.Fatalf("expected the runtime ready with the HAMi hook installed")
+	}
+}
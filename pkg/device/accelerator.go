@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// AcceleratorMemoryResource requests devices of whichever vendor has capacity, with this much memory in MiB
+	// each. The webhook replaces it by the resources of the vendor picked.
+	AcceleratorMemoryResource = "hami.io/accelerator-mem"
+	// AcceleratorCountResource is the number of devices requested with AcceleratorMemoryResource, 1 if not set.
+	AcceleratorCountResource = "hami.io/accelerator"
+)
+
+// AcceleratorDevices is implemented by the devices able to satisfy the vendor agnostic AcceleratorMemoryResource.
+type AcceleratorDevices interface {
+	// RequestAccelerator sets the resources of the container to request count devices with mem MiB each.
+	RequestAccelerator(ctr *corev1.Container, count, mem int64)
+}
+
+// AcceleratorRequest returns the number of devices and their memory in MiB the container requests with the
+// vendor agnostic resources, and whether it requests any.
+func AcceleratorRequest(ctr *corev1.Container) (int64, int64, bool, error) {
+	mem, ok := acceleratorResource(ctr, AcceleratorMemoryResource)
+	count, countOk := acceleratorResource(ctr, AcceleratorCountResource)
+	if !ok {
+		if countOk {
+			return 0, 0, false, InvalidRequestErrorf("%s is requested without %s", AcceleratorCountResource, AcceleratorMemoryResource)
+		}
+		return 0, 0, false, nil
+	}
+	if !countOk {
+		count = 1
+	}
+	if mem <= 0 || count <= 0 {
+		return 0, 0, false, InvalidRequestErrorf("%s and %s of container %s must be positive", AcceleratorMemoryResource, AcceleratorCountResource, ctr.Name)
+	}
+	return count, mem, true, nil
+}
+
+func acceleratorResource(ctr *corev1.Container, name corev1.ResourceName) (int64, bool) {
+	if qty, ok := ctr.Resources.Limits[name]; ok {
+		return qty.Value(), true
+	}
+	if qty, ok := ctr.Resources.Requests[name]; ok {
+		return qty.Value(), true
+	}
+	return 0, false
+}
+
+// RemoveAcceleratorRequest removes the vendor agnostic resources from the container.
+func RemoveAcceleratorRequest(ctr *corev1.Container) {
+	for _, name := range []corev1.ResourceName{AcceleratorMemoryResource, AcceleratorCountResource} {
+		delete(ctr.Resources.Limits, name)
+		delete(ctr.Resources.Requests, name)
+	}
+}
+
+// SetAcceleratorLimits sets the limits of the container to count devices with mem MiB each by the count and
+// memory resource names of a vendor, for implementations of AcceleratorDevices.
+func SetAcceleratorLimits(ctr *corev1.Container, names ResourceNames, count, mem int64) {
+	if ctr.Resources.Limits == nil {
+		ctr.Resources.Limits = make(corev1.ResourceList)
+	}
+	ctr.Resources.Limits[corev1.ResourceName(names.ResourceCountName)] = *resource.NewQuantity(count, resource.DecimalSI)
+	ctr.Resources.Limits[corev1.ResourceName(names.ResourceMemoryName)] = *resource.NewQuantity(mem, resource.DecimalSI)
+}
+
+// AcceleratorVendors returns the vendors able to satisfy AcceleratorMemoryResource among the allowed ones, or
+// among all devices if allowed is empty, sorted by name.
+func AcceleratorVendors(allowed []string) []string {
+	res := make([]string, 0)
+	for vendor, dev := range GetDevices() {
+		if _, ok := dev.(AcceleratorDevices); !ok {
+			continue
+		}
+		if len(allowed) > 0 && !slices.Contains(allowed, vendor) {
+			continue
+		}
+		res = append(res, vendor)
+	}
+	sort.Strings(res)
+	return res
+}
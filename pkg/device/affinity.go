@@ -0,0 +1,84 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// CheckDeviceAffinity returns why the device affinity, anti-affinity or workload type of the pod excludes the
+// device, empty if it doesn't. devices are the candidate devices of the node: a pod matching its own affinity is
+// placed like any other pod if none of them hosts a matching pod yet, so the first pod of a co-located group can
+// be scheduled.
+func CheckDeviceAffinity(dev *DeviceUsage, pod *corev1.Pod, devices []*DeviceUsage) string {
+	if hostsIncompatibleWorkload(dev, pod) {
+		return common.CardWorkloadTypeConflict
+	}
+	if selector, _ := util.GetDeviceAntiAffinity(pod); selector != nil && hostsMatchingPod(dev, pod, selector) {
+		return common.CardAntiAffinityConflict
+	}
+	selector, _ := util.GetDeviceAffinity(pod)
+	if selector == nil || hostsMatchingPod(dev, pod, selector) {
+		return ""
+	}
+	if selector.Matches(labels.Set(pod.Labels)) {
+		for _, d := range devices {
+			if hostsMatchingPod(d, pod, selector) {
+				return common.CardAffinityMismatch
+			}
+		}
+		return ""
+	}
+	return common.CardAffinityMismatch
+}
+
+// hostsMatchingPod reports whether any pod other than pod using the device matches the selector.
+func hostsMatchingPod(dev *DeviceUsage, pod *corev1.Pod, selector labels.Selector) bool {
+	for _, pi := range dev.PodInfos {
+		if pi.Pod == nil || pi.UID == pod.UID {
+			continue
+		}
+		if selector.Matches(labels.Set(pi.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostsIncompatibleWorkload reports whether any pod other than pod using the device has a workload type pod is
+// incompatible with, or is incompatible with the workload type of pod, so either pod declaring it is enough.
+func hostsIncompatibleWorkload(dev *DeviceUsage, pod *corev1.Pod) bool {
+	workloadType, incompatible := util.GetWorkloadType(pod), util.GetIncompatibleTypes(pod)
+	for _, pi := range dev.PodInfos {
+		if pi.Pod == nil || pi.UID == pod.UID {
+			continue
+		}
+		if t := util.GetWorkloadType(pi.Pod); t != "" && slices.Contains(incompatible, t) {
+			return true
+		}
+		if workloadType != "" && slices.Contains(util.GetIncompatibleTypes(pi.Pod), workloadType) {
+			return true
+		}
+	}
+	return false
+}
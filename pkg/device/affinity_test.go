@@ -0,0 +1,173 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func TestCheckDeviceAffinity(t *testing.T) {
+	podInfo := func(uid string, labels map[string]string) *PodInfo {
+		return &PodInfo{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: k8stypes.UID(uid), Labels: labels}}}
+	}
+	miner := &DeviceUsage{ID: "dev-0", PodInfos: []*PodInfo{podInfo("miner", map[string]string{"app": "miner"})}}
+	trainer := &DeviceUsage{ID: "dev-1", PodInfos: []*PodInfo{podInfo("trainer", map[string]string{"app": "trainer"})}}
+	idle := &DeviceUsage{ID: "dev-2"}
+	devices := []*DeviceUsage{miner, trainer, idle}
+
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		labels  map[string]string
+		uid     string
+		dev     *DeviceUsage
+		devices []*DeviceUsage
+		want    string
+	}{
+		{
+			name: "no affinity",
+			dev:  miner,
+		},
+		{
+			name:  "anti-affinity to a pod on the device",
+			annos: map[string]string{util.DeviceAntiAffinityAnnotationKey: "app=miner"},
+			dev:   miner,
+			want:  common.CardAntiAffinityConflict,
+		},
+		{
+			name:  "anti-affinity to pods not on the device",
+			annos: map[string]string{util.DeviceAntiAffinityAnnotationKey: "app=miner"},
+			dev:   trainer,
+		},
+		{
+			name:  "anti-affinity ignores the pod itself",
+			annos: map[string]string{util.DeviceAntiAffinityAnnotationKey: "app=miner"},
+			uid:   "miner",
+			dev:   miner,
+		},
+		{
+			name:    "affinity to a pod on the device",
+			annos:   map[string]string{util.DeviceAffinityAnnotationKey: "app=trainer"},
+			dev:     trainer,
+			devices: devices,
+		},
+		{
+			name:    "affinity to pods not on the device",
+			annos:   map[string]string{util.DeviceAffinityAnnotationKey: "app=trainer"},
+			dev:     idle,
+			devices: devices,
+			want:    common.CardAffinityMismatch,
+		},
+		{
+			name:    "affinity without any matching pod",
+			annos:   map[string]string{util.DeviceAffinityAnnotationKey: "app=infer"},
+			dev:     idle,
+			devices: devices,
+			want:    common.CardAffinityMismatch,
+		},
+		{
+			name:    "first pod of a group matching its own affinity",
+			annos:   map[string]string{util.DeviceAffinityAnnotationKey: "app=infer"},
+			labels:  map[string]string{"app": "infer"},
+			dev:     idle,
+			devices: devices,
+		},
+		{
+			name:    "later pod of a group matching its own affinity",
+			annos:   map[string]string{util.DeviceAffinityAnnotationKey: "app=trainer"},
+			labels:  map[string]string{"app": "trainer"},
+			dev:     idle,
+			devices: devices,
+			want:    common.CardAffinityMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: k8stypes.UID(test.uid), Annotations: test.annos, Labels: test.labels}}
+			assert.Equal(t, CheckDeviceAffinity(test.dev, pod, test.devices), test.want)
+		})
+	}
+}
+
+func TestCheckDeviceAffinity_WorkloadType(t *testing.T) {
+	podInfo := func(uid, workloadType, incompatible string) *PodInfo {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: k8stypes.UID(uid)}}
+		if workloadType != "" {
+			pod.Labels = map[string]string{util.WorkloadTypeLabelKey: workloadType}
+		}
+		if incompatible != "" {
+			pod.Annotations = map[string]string{util.IncompatibleTypesAnnotationKey: incompatible}
+		}
+		return &PodInfo{Pod: pod}
+	}
+	training := &DeviceUsage{ID: "dev-0", PodInfos: []*PodInfo{podInfo("trainer", "training", "")}}
+	inference := &DeviceUsage{ID: "dev-1", PodInfos: []*PodInfo{podInfo("server", "inference", "training")}}
+	untyped := &DeviceUsage{ID: "dev-2", PodInfos: []*PodInfo{podInfo("other", "", "")}}
+
+	tests := []struct {
+		name string
+		pod  *PodInfo
+		dev  *DeviceUsage
+		want string
+	}{
+		{
+			name: "device hosting an incompatible workload type",
+			pod:  podInfo("infer", "inference", "training"),
+			dev:  training,
+			want: common.CardWorkloadTypeConflict,
+		},
+		{
+			name: "device hosting a compatible workload type",
+			pod:  podInfo("infer", "inference", "training"),
+			dev:  inference,
+		},
+		{
+			name: "device hosting an untyped workload",
+			pod:  podInfo("infer", "inference", "training"),
+			dev:  untyped,
+		},
+		{
+			name: "device hosting a workload incompatible with the pod type",
+			pod:  podInfo("train", "training", ""),
+			dev:  inference,
+			want: common.CardWorkloadTypeConflict,
+		},
+		{
+			name: "untyped pod on a device hosting a workload incompatible with a type",
+			pod:  podInfo("other", "", ""),
+			dev:  inference,
+		},
+		{
+			name: "incompatible type ignores the pod itself",
+			pod:  podInfo("trainer", "inference", "training"),
+			dev:  training,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, CheckDeviceAffinity(test.dev, test.pod.Pod, nil), test.want)
+		})
+	}
+}
@@ -24,6 +24,8 @@ import (
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -33,6 +35,7 @@ import (
 type AMDDevices struct {
 	resourceCountName  string
 	resourceMemoryName string
+	resourceCoreName   string
 }
 
 const (
@@ -43,24 +46,63 @@ const (
 	AMDNoUseUUID       = "amd.com/nouse-gpu-uuid"
 	AMDAssignedNode    = "amd.com/predicate-node"
 	Mi300xMemory       = 192000
+
+	// HandshakeAnnos and RegisterAnnos are the node annotations of the HAMi AMD device plugin, which registers the
+	// GPUs of the node to be sliced. The GPUs of the nodes without them are the whole GPUs of their capacity.
+	HandshakeAnnos = "hami.io/node-handshake-amd"
+	RegisterAnnos  = "hami.io/node-amd-register"
+
+	// VisibleDevicesEnv lists the GPUs allocated to a container, isolating them from the other GPUs of the node.
+	VisibleDevicesEnv = "ROCR_VISIBLE_DEVICES"
+	// DeviceMemoryLimitEnv is the memory limit of each GPU of a container, suffixed by its index, for the ROCm
+	// limiter, like CUDA_DEVICE_MEMORY_LIMIT of HAMi-core.
+	DeviceMemoryLimitEnv = "HIP_DEVICE_MEMORY_LIMIT"
+	// DeviceCoreLimitEnv is the core limit of the GPUs of a container for the ROCm limiter.
+	DeviceCoreLimitEnv = "HIP_DEVICE_SM_LIMIT"
+
+	// NodeLockAMD should same with device plugin node lock name
+	// there is a bug with nodelock package utils, the key is hard coded as "hami.io/mutex.lock"
+	// so we can only use this value now.
+	NodeLockAMD = "hami.io/mutex.lock"
+
+	DefaultDeviceSplitCount = 10
 )
 
+// AMDConfig configures the AMD GPUs. With ResourceMemoryName and ResourceCoreName set, the GPUs registered by the
+// HAMi AMD device plugin are sliced by memory and cores, each shared by up to DeviceSplitCount containers.
 type AMDConfig struct {
 	ResourceCountName  string `yaml:"resourceCountName"`
 	ResourceMemoryName string `yaml:"resourceMemoryName"`
+	ResourceCoreName   string `yaml:"resourceCoreName"`
+	DeviceSplitCount   int32  `yaml:"deviceSplitCount"`
 }
 
 func InitAMDGPUDevice(config AMDConfig) *AMDDevices {
 	_, ok := device.SupportDevices[AMDDevice]
 	if !ok {
+		device.InRequestDevices[AMDDevice] = "hami.io/amd-devices-to-allocate"
 		device.SupportDevices[AMDDevice] = "hami.io/amd-devices-allocated"
+		util.HandshakeAnnos[AMDDevice] = HandshakeAnnos
+		util.RegisterAnnos[AMDDevice] = RegisterAnnos
 	}
 	return &AMDDevices{
 		resourceCountName:  config.ResourceCountName,
 		resourceMemoryName: config.ResourceMemoryName,
+		resourceCoreName:   config.ResourceCoreName,
 	}
 }
 
+// sliced reports whether the GPUs registered by the HAMi AMD device plugin are sliced by memory.
+func (dev *AMDDevices) sliced() bool {
+	return dev.resourceMemoryName != ""
+}
+
+// registered reports whether the GPUs of the node are registered by the HAMi AMD device plugin.
+func registered(n *corev1.Node) bool {
+	_, ok := n.Annotations[RegisterAnnos]
+	return ok
+}
+
 func (dev *AMDDevices) CommonWord() string {
 	return AMDCommonWord
 }
@@ -68,7 +110,7 @@ func (dev *AMDDevices) CommonWord() string {
 func ParseConfig(fs *flag.FlagSet) {
 }
 
-func (dev *AMDDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *AMDDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(dev.resourceCountName)]
 	if !ok {
 		_, ok = ctr.Resources.Limits[corev1.ResourceName(dev.resourceMemoryName)]
@@ -78,6 +120,9 @@ func (dev *AMDDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bo
 }
 
 func (dev *AMDDevices) GetNodeDevices(n corev1.Node) ([]*device.DeviceInfo, error) {
+	if registered(&n) {
+		return dev.getRegisteredDevices(n)
+	}
 	nodedevices := []*device.DeviceInfo{}
 	i := 0
 	counts, ok := n.Status.Capacity.Name(corev1.ResourceName(dev.resourceCountName), resource.DecimalSI).AsInt64()
@@ -107,25 +152,64 @@ func (dev *AMDDevices) GetNodeDevices(n corev1.Node) ([]*device.DeviceInfo, erro
 	return nodedevices, nil
 }
 
+// getRegisteredDevices returns the GPUs of the node registered by the HAMi AMD device plugin.
+func (dev *AMDDevices) getRegisteredDevices(n corev1.Node) ([]*device.DeviceInfo, error) {
+	devEncoded := n.Annotations[RegisterAnnos]
+	nodedevices, err := device.UnMarshalNodeDevices(devEncoded)
+	if err != nil {
+		klog.ErrorS(err, "failed to decode node devices", "node", n.Name, "device annotation", devEncoded)
+		return []*device.DeviceInfo{}, err
+	}
+	if len(nodedevices) == 0 {
+		klog.InfoS("no AMD GPU found", "node", n.Name, "device annotation", devEncoded)
+		return []*device.DeviceInfo{}, fmt.Errorf("no AMD GPU found on node")
+	}
+	for idx := range nodedevices {
+		nodedevices[idx].DeviceVendor = AMDCommonWord
+	}
+	klog.V(5).InfoS("nodes device information", "node", n.Name, "nodedevices", devEncoded)
+	return nodedevices, nil
+}
+
 func (dev *AMDDevices) PatchAnnotations(pod *corev1.Pod, annoinput *map[string]string, pd device.PodDevices) map[string]string {
 	devlist, ok := pd[AMDDevice]
 	if ok && len(devlist) > 0 {
-		(*annoinput)[device.SupportDevices[AMDDevice]] = device.EncodePodSingleDevice(devlist)
+		deviceStr := device.EncodePodSingleDevice(devlist)
+		(*annoinput)[device.InRequestDevices[AMDDevice]] = deviceStr
+		(*annoinput)[device.SupportDevices[AMDDevice]] = deviceStr
 	}
 	klog.V(4).InfoS("annos", "input", (*annoinput))
 	return *annoinput
 }
 
+// requestsDevices reports whether a container of the pod requests AMD GPUs.
+func (dev *AMDDevices) requestsDevices(p *corev1.Pod) bool {
+	for _, val := range p.Spec.Containers {
+		if dev.GenerateResourceRequests(&val).Nums > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// LockNode locks the nodes whose GPUs are registered by the HAMi AMD device plugin, which releases the lock once
+// it allocated them.
 func (dev *AMDDevices) LockNode(n *corev1.Node, p *corev1.Pod) error {
-	return nil
+	if !registered(n) || !dev.requestsDevices(p) {
+		return nil
+	}
+	return nodelock.LockNode(n.Name, NodeLockAMD, p)
 }
 
 func (dev *AMDDevices) ReleaseNodeLock(n *corev1.Node, p *corev1.Pod) error {
-	return nil
+	if !registered(n) || !dev.requestsDevices(p) {
+		return nil
+	}
+	return nodelock.ReleaseNodeLock(n.Name, NodeLockAMD, p, false)
 }
 
 func (dev *AMDDevices) NodeCleanUp(nn string) error {
-	return nil
+	return util.MarkAnnotationsToDelete(HandshakeAnnos, nn)
 }
 
 func (dev *AMDDevices) checkType(n device.ContainerDeviceRequest) (bool, bool, bool) {
@@ -154,16 +238,35 @@ func (dev *AMDDevices) checkUUID(annos map[string]string, d device.DeviceUsage)
 	return true
 }
 
+// CheckHealth handshakes with the HAMi AMD device plugin, the GPUs of capacity are always healthy.
 func (dev *AMDDevices) CheckHealth(devType string, n *corev1.Node) (bool, bool) {
-	return true, true
+	if !registered(n) {
+		return true, true
+	}
+	return device.CheckHealth(devType, n)
 }
 
 func (dev *AMDDevices) GetResourceNames() device.ResourceNames {
 	return device.ResourceNames{
 		ResourceCountName:  dev.resourceCountName,
 		ResourceMemoryName: dev.resourceMemoryName,
-		ResourceCoreName:   "",
+		ResourceCoreName:   dev.resourceCoreName,
+	}
+}
+
+// resourceValue returns the value of the resource of the container, from its limits or else its requests.
+func resourceValue(ctr *corev1.Container, name string) (int64, bool) {
+	if name == "" {
+		return 0, false
+	}
+	v, ok := ctr.Resources.Limits[corev1.ResourceName(name)]
+	if !ok {
+		v, ok = ctr.Resources.Requests[corev1.ResourceName(name)]
+	}
+	if !ok {
+		return 0, false
 	}
+	return v.AsInt64()
 }
 
 func (dev *AMDDevices) GenerateResourceRequests(ctr *corev1.Container) device.ContainerDeviceRequest {
@@ -179,12 +282,28 @@ func (dev *AMDDevices) GenerateResourceRequests(ctr *corev1.Container) device.Co
 			klog.InfoS("Detected AMD device request",
 				"container", ctr.Name,
 				"deviceCount", n)
+			if !dev.sliced() {
+				return device.ContainerDeviceRequest{
+					Nums:             int32(n),
+					Type:             AMDDevice,
+					Memreq:           Mi300xMemory,
+					MemPercentagereq: 0,
+					Coresreq:         0,
+				}
+			}
+			// The whole memory of the GPUs unless a slice is requested.
+			memnum, _ := resourceValue(ctr, dev.resourceMemoryName)
+			corenum, _ := resourceValue(ctr, dev.resourceCoreName)
+			mempnum := int32(0)
+			if memnum == 0 {
+				mempnum = 100
+			}
 			return device.ContainerDeviceRequest{
 				Nums:             int32(n),
 				Type:             AMDDevice,
-				Memreq:           Mi300xMemory,
-				MemPercentagereq: 0,
-				Coresreq:         0,
+				Memreq:           int32(memnum),
+				MemPercentagereq: mempnum,
+				Coresreq:         int32(corenum),
 			}
 		}
 	}
@@ -235,6 +354,36 @@ func (amddevice *AMDDevices) Fit(devices []*device.DeviceUsage, request device.C
 			klog.V(5).InfoS(common.CardTimeSlicingExhausted, "pod", klog.KObj(pod), "device", dev.ID, "count", dev.Count, "used", dev.Used)
 			continue
 		}
+		if k.Coresreq > 100 {
+			klog.ErrorS(nil, "core limit can't exceed 100", "pod", klog.KObj(pod), "device", dev.ID)
+			k.Coresreq = 100
+		}
+		memreq := k.Memreq
+		if k.Memreq == 0 {
+			memreq = dev.Totalmem * k.MemPercentagereq / 100
+		}
+		if dev.Totalmem-dev.Usedmem < memreq {
+			reason[common.CardInsufficientMemory]++
+			klog.V(5).InfoS(common.CardInsufficientMemory, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total memory", dev.Totalmem, "device used memory", dev.Usedmem, "request memory", memreq)
+			continue
+		}
+		if dev.Totalcore-dev.Usedcores < k.Coresreq {
+			reason[common.CardInsufficientCore]++
+			klog.V(5).InfoS(common.CardInsufficientCore, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total core", dev.Totalcore, "device used core", dev.Usedcores, "request cores", k.Coresreq)
+			continue
+		}
+		// Coresreq=100 indicates it want this card exclusively
+		if dev.Totalcore == 100 && k.Coresreq == 100 && dev.Used > 0 {
+			reason[common.ExclusiveDeviceAllocateConflict]++
+			klog.V(5).InfoS(common.ExclusiveDeviceAllocateConflict, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "used", dev.Used)
+			continue
+		}
+		// You can't allocate core=0 job to an already full GPU
+		if dev.Totalcore != 0 && dev.Usedcores == dev.Totalcore && k.Coresreq == 0 {
+			reason[common.CardComputeUnitsExhausted]++
+			klog.V(5).InfoS(common.CardComputeUnitsExhausted, "pod", klog.KObj(pod), "device", dev.ID, "device index", i)
+			continue
+		}
 
 		klog.V(5).InfoS("find fit device", "pod", klog.KObj(pod), "device", dev.ID)
 
@@ -244,8 +393,8 @@ func (amddevice *AMDDevices) Fit(devices []*device.DeviceUsage, request device.C
 				Idx:        int(dev.Index),
 				UUID:       dev.ID,
 				Type:       k.Type,
-				Usedmem:    Mi300xMemory,
-				Usedcores:  0,
+				Usedmem:    memreq,
+				Usedcores:  k.Coresreq,
 				CustomInfo: map[string]any{},
 			})
 		}
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
 
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -81,7 +82,7 @@ func Test_MutateAdmission(t *testing.T) {
 				ResourceCountName: "amd.com/gpu",
 			}
 			dev := InitAMDGPUDevice(config)
-			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			assert.Equal(t, result, test.want)
 		})
 	}
@@ -646,3 +647,54 @@ func TestDevices_Fit(t *testing.T) {
 		})
 	}
 }
+
+func Test_SlicedDevices(t *testing.T) {
+	dev := InitAMDGPUDevice(AMDConfig{
+		ResourceCountName:  "amd.com/gpu",
+		ResourceMemoryName: "amd.com/gpumem",
+		ResourceCoreName:   "amd.com/gpucores",
+	})
+
+	// The GPUs registered by the HAMi device plugin are used rather than the capacity.
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "mi210", Annotations: map[string]string{
+			RegisterAnnos: device.MarshalNodeDevices([]*device.DeviceInfo{
+				{ID: "GPU-0", Index: 0, Count: 10, Devmem: 65520, Devcore: 100, Type: "AMDGPU-Instinct MI210", Health: true},
+			}),
+		}},
+		Status: corev1.NodeStatus{Capacity: corev1.ResourceList{"amd.com/gpu": *resource.NewQuantity(10, resource.DecimalSI)}},
+	}
+	nodedevices, err := dev.GetNodeDevices(node)
+	assert.NilError(t, err)
+	assert.Equal(t, len(nodedevices), 1)
+	assert.Equal(t, nodedevices[0].Devmem, int32(65520))
+	assert.Equal(t, nodedevices[0].DeviceVendor, AMDCommonWord)
+
+	slice := dev.GenerateResourceRequests(&corev1.Container{Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"amd.com/gpu": resource.MustParse("1"), "amd.com/gpumem": resource.MustParse("40000"), "amd.com/gpucores": resource.MustParse("30"),
+	}}})
+	assert.DeepEqual(t, slice, device.ContainerDeviceRequest{Nums: 1, Type: AMDDevice, Memreq: 40000, Coresreq: 30})
+	whole := dev.GenerateResourceRequests(&corev1.Container{Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"amd.com/gpu": resource.MustParse("1"),
+	}}})
+	assert.DeepEqual(t, whole, device.ContainerDeviceRequest{Nums: 1, Type: AMDDevice, MemPercentagereq: 100})
+
+	usage := &device.DeviceUsage{ID: "GPU-0", Count: 10, Totalmem: 65520, Totalcore: 100, Type: "AMDGPU-Instinct MI210", Health: true}
+	pod := &corev1.Pod{}
+	fit, devs, _ := dev.Fit([]*device.DeviceUsage{usage}, slice, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, fit)
+	assert.Equal(t, devs[AMDDevice][0].Usedmem, int32(40000))
+	assert.Equal(t, devs[AMDDevice][0].Usedcores, int32(30))
+	assert.NilError(t, dev.AddResourceUsage(pod, usage, &devs[AMDDevice][0]))
+
+	// The memory left doesn't fit another slice, nor the whole GPU.
+	fit, _, reason := dev.Fit([]*device.DeviceUsage{usage}, slice, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+	assert.Equal(t, reason, "1/1 "+common.CardInsufficientMemory)
+	fit, _, _ = dev.Fit([]*device.DeviceUsage{usage}, whole, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+	smaller := slice
+	smaller.Memreq = 20000
+	fit, _, _ = dev.Fit([]*device.DeviceUsage{usage}, smaller, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, fit)
+}
@@ -95,6 +95,7 @@ func InitDevices(config []VNPUConfig) []*Devices {
 			device.InRequestDevices[commonWord] = fmt.Sprintf("hami.io/%s-devices-to-allocate", commonWord)
 			device.SupportDevices[commonWord] = fmt.Sprintf("hami.io/%s-devices-allocated", commonWord)
 			util.HandshakeAnnos[commonWord] = dev.handshakeAnno
+			util.RegisterAnnos[commonWord] = dev.nodeRegisterAnno
 		}
 		devs = append(devs, dev)
 		klog.Infof("load ascend vnpu config %s: %v", commonWord, dev.config)
@@ -110,7 +111,7 @@ func (dev *Devices) CommonWord() string {
 	return dev.config.CommonWord
 }
 
-func (dev *Devices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *Devices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	count, ok := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceName)]
 	if !ok {
 		return false, nil
@@ -120,12 +121,15 @@ func (dev *Devices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool,
 	if ok {
 		trimMem, _ = dev.trimMemory(memory.Value())
 		if trimMem <= 0 {
-			return false, fmt.Errorf("%s %d is invalid", dev.config.ResourceMemoryName, memory.Value())
+			return false, device.InvalidRequestErrorf("%s %d is invalid", dev.config.ResourceMemoryName, memory.Value())
 		}
+		warnings.Adjusted(ctr.Name, dev.config.ResourceMemoryName, memory.Value(), trimMem)
+	} else {
+		warnings.Defaulted(ctr.Name, dev.config.ResourceMemoryName, trimMem)
 	}
 	if count.Value() > 1 {
 		if trimMem != dev.config.MemoryAllocatable {
-			return true, errors.New("vNPU nor supported for multiple devices")
+			return true, device.InvalidRequestErrorf("vNPU nor supported for multiple devices")
 		}
 	}
 	ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceMemoryName)] = resource.MustParse(fmt.Sprint(trimMem))
@@ -696,7 +696,7 @@ func Test_MutateAdmission(t *testing.T) {
 					},
 				},
 			}
-			result, _ := dev.MutateAdmission(&test.args.ctr, &test.args.pod)
+			result, _ := dev.MutateAdmission(&test.args.ctr, &test.args.pod, nil)
 
 			if result != test.want {
 				t.Fatalf("exec MutateAdmission method expect return is %+v, but got is %+v", test.want, result)
@@ -80,7 +80,7 @@ func (dev *AWSNeuronDevices) CommonWord() string {
 func ParseConfig(fs *flag.FlagSet) {
 }
 
-func (dev *AWSNeuronDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *AWSNeuronDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(dev.resourceCountName)]
 	if !ok {
 		_, ok = ctr.Resources.Limits[corev1.ResourceName(dev.resourceCoreName)]
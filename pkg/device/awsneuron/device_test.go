@@ -101,7 +101,7 @@ func Test_MutateAdmission(t *testing.T) {
 				ResourceCoreName:  "aws.amazon.com/neuroncore",
 			}
 			dev := InitAWSNeuronDevice(config)
-			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			assert.Equal(t, result, test.want)
 		})
 	}
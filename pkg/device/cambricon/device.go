@@ -18,22 +18,20 @@ package cambricon
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"math/rand"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 )
 
@@ -52,7 +50,6 @@ const (
 	DsmluLockTime         = "cambricon.com/dsmlu.lock"
 	DsmluProfile          = "CAMBRICON_DSMLU_PROFILE"
 	DsmluResourceAssigned = "CAMBRICON_DSMLU_ASSIGNED"
-	retry                 = 5
 )
 
 var (
@@ -93,29 +90,23 @@ func (dev *CambriconDevices) CommonWord() string {
 }
 
 func (dev *CambriconDevices) setNodeLock(node *corev1.Node) error {
-	ctx := context.Background()
 	if _, ok := node.Annotations[DsmluLockTime]; ok {
 		return fmt.Errorf("node %s is locked", node.Name)
 	}
-
-	patchedAnnotation, err := json.Marshal(
-		map[string]any{
-			"metadata": map[string]map[string]string{"annotations": {
-				DsmluLockTime: time.Now().Format(time.RFC3339),
-			}}})
-	if err != nil {
-		klog.ErrorS(err, "Failed to patch node annotation", "node", node.Name)
-		return fmt.Errorf("patch node annotation %v", err)
-	}
-
-	_, err = client.GetClient().CoreV1().Nodes().Patch(ctx, node.Name, types.StrategicMergePatchType, patchedAnnotation, metav1.PatchOptions{})
-	for i := 0; i < retry && err != nil; i++ {
-		klog.ErrorS(err, "Failed to patch node annotation", "node", node.Name, "retry", i)
-		time.Sleep(time.Duration(rand.Intn(i+1)) * 10 * time.Millisecond)
-		_, err = client.GetClient().CoreV1().Nodes().Patch(ctx, node.Name, types.StrategicMergePatchType, patchedAnnotation, metav1.PatchOptions{})
-	}
+	err := util.PatchAnnotationsWithRetry(context.Background(), client.GetClient(), util.NodeRef(node.Name), func(meta *metav1.ObjectMeta) error {
+		// Locked by another scheduler since the node was read.
+		if _, ok := meta.Annotations[DsmluLockTime]; ok {
+			return fmt.Errorf("node %s is locked", node.Name)
+		}
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, 1)
+		}
+		meta.Annotations[DsmluLockTime] = time.Now().Format(time.RFC3339)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("setNodeLock exceeds retry count %d", retry)
+		klog.ErrorS(err, "Failed to set node lock", "node", node.Name)
+		return fmt.Errorf("setNodeLock: %w", err)
 	}
 	klog.InfoS("Node lock set", "node", node.Name)
 	return nil
@@ -160,16 +151,13 @@ func (dev *CambriconDevices) ReleaseNodeLock(n *corev1.Node, p *corev1.Pod) erro
 		return nil
 	}
 
-	newNode := n.DeepCopy()
-	delete(newNode.Annotations, DsmluLockTime)
-	_, err := client.GetClient().CoreV1().Nodes().Update(context.Background(), newNode, metav1.UpdateOptions{})
-	for i := 0; i < retry && err != nil; i++ {
-		klog.ErrorS(err, "Failed to patch node annotation", "node", n.Name, "retry", i)
-		time.Sleep(time.Duration(rand.Intn(i+1)) * 10 * time.Millisecond)
-		_, err = client.GetClient().CoreV1().Nodes().Update(context.Background(), newNode, metav1.UpdateOptions{})
-	}
+	err := util.PatchAnnotationsWithRetry(context.Background(), client.GetClient(), util.NodeRef(n.Name), func(meta *metav1.ObjectMeta) error {
+		delete(meta.Annotations, DsmluLockTime)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("releaseNodeLock exceeds retry count %d", retry)
+		klog.ErrorS(err, "Failed to release node lock", "node", n.Name)
+		return fmt.Errorf("releaseNodeLock: %w", err)
 	}
 	delete(n.Annotations, DsmluLockTime)
 	klog.InfoS("Node lock released", "node", n.Name)
@@ -213,14 +201,21 @@ func (dev *CambriconDevices) AssertNuma(annos map[string]string) bool {
 	return false
 }
 
-func (dev *CambriconDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *CambriconDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(MLUResourceCount)]
 	return ok, nil
 }
 
+// RequestAccelerator implements device.AcceleratorDevices.
+func (dev *CambriconDevices) RequestAccelerator(ctr *corev1.Container, count, mem int64) {
+	device.SetAcceleratorLimits(ctr, dev.GetResourceNames(), count, mem)
+}
+
+// checkType reports whether the MLU type is allowed by the use-mlutype annotation, or if it's not set by the
+// nouse-mlutype annotation, see device.TypePattern for the syntax of their entries.
 func (dev *CambriconDevices) checkType(annos map[string]string, d device.DeviceUsage, n device.ContainerDeviceRequest) (bool, bool, bool) {
 	if strings.Compare(n.Type, CambriconMLUDevice) == 0 {
-		return true, true, false
+		return true, device.TypeAllowed(annos, MLUInUse, MLUNoUse, d.Type), false
 	}
 	return false, false, false
 }
@@ -124,7 +124,7 @@ func Test_MutateAdmission(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			dev := CambriconDevices{}
-			result, _ := dev.MutateAdmission(&test.args.ctr, &test.args.pod)
+			result, _ := dev.MutateAdmission(&test.args.ctr, &test.args.pod, nil)
 			assert.Equal(t, result, test.want)
 		})
 	}
@@ -175,6 +175,54 @@ func Test_checkType(t *testing.T) {
 			want1: true,
 			want2: true,
 		},
+		{
+			name: "type allowed by use-mlutype",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+				n     device.ContainerDeviceRequest
+			}{
+				annos: map[string]string{MLUInUse: "=MLU370"},
+				d:     device.DeviceUsage{Type: "MLU370"},
+				n: device.ContainerDeviceRequest{
+					Type: dev.CommonWord(),
+				},
+			},
+			want1: true,
+			want2: true,
+		},
+		{
+			name: "type not exactly the one of use-mlutype",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+				n     device.ContainerDeviceRequest
+			}{
+				annos: map[string]string{MLUInUse: "=MLU370"},
+				d:     device.DeviceUsage{Type: "MLU370-X8"},
+				n: device.ContainerDeviceRequest{
+					Type: dev.CommonWord(),
+				},
+			},
+			want1: true,
+			want2: false,
+		},
+		{
+			name: "type excluded by nouse-mlutype",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+				n     device.ContainerDeviceRequest
+			}{
+				annos: map[string]string{MLUNoUse: "MLU290"},
+				d:     device.DeviceUsage{Type: "MLU290"},
+				n: device.ContainerDeviceRequest{
+					Type: dev.CommonWord(),
+				},
+			},
+			want1: true,
+			want2: false,
+		},
 		{
 			name: "the different type",
 			args: struct {
@@ -27,16 +27,35 @@ const (
 	CardTimeSlicingExhausted          = "CardTimeSlicingExhausted"
 	CardComputeUnitsExhausted         = "CardComputeUnitsExhausted"
 	CardInsufficientMemory            = "CardInsufficientMemory"
+	CardInsufficientPhysicalMemory    = "CardInsufficientPhysicalMemory"
 	CardInsufficientCore              = "CardInsufficientCore"
+	CardInsufficientEngines           = "CardInsufficientEngines"
 	CardNotHealth                     = "CardNotHealth"
+	CardOverheated                    = "CardOverheated"
+	CardECCError                      = "CardECCError"
+	CardDriverTooOld                  = "CardDriverTooOld"
+	CardECCModeMismatch               = "CardECCModeMismatch"
+	CardPoolMismatch                  = "CardPoolMismatch"
+	CardNotVerified                   = "CardNotVerified"
+	CardRuntimeNotReady               = "CardRuntimeNotReady"
+	CardAffinityMismatch              = "CardAffinityMismatch"
+	CardAntiAffinityConflict          = "CardAntiAffinityConflict"
+	CardWorkloadTypeConflict          = "CardWorkloadTypeConflict"
 	NumaNotFit                        = "NumaNotFit"
 	ExclusiveDeviceAllocateConflict   = "ExclusiveDeviceAllocateConflict"
 	CardNotFoundCustomFilterRule      = "CardNotFoundCustomFilterRule"
 	NodeInsufficientDevice            = "NodeInsufficientDevice"
 	AllocatedCardsInsufficientRequest = "AllocatedCardsInsufficientRequest"
+	CardIndicesNotContiguous          = "CardIndicesNotContiguous"
 	NodeUnfitPod                      = "NodeUnfitPod"
 	NodeFitPod                        = "NodeFitPod"
 	ResourceQuotaNotFit               = "ResourceQuotaNotFit"
+	ComputeModeConflict               = "ComputeModeConflict"
+	NodeVolumeMismatch                = "NodeVolumeMismatch"
+	NodeContainerRuntimeMismatch      = "NodeContainerRuntimeMismatch"
+	NodeNotSuggested                  = "NodeNotSuggested"
+	SuggestedDevicesUnfit             = "SuggestedDevicesUnfit"
+	AllocationExceedsMaxMemory        = "AllocationExceedsMaxMemory"
 )
 
 func GenReason(reasons map[string]int, cards int) string {
@@ -47,6 +66,17 @@ func GenReason(reasons map[string]int, cards int) string {
 	return strings.Join(reason, ", ")
 }
 
+// GenNodeInsufficientDeviceReason is the reason of a node registering fewer devices of the requested type than requested.
+func GenNodeInsufficientDeviceReason(requested, registered int) string {
+	return fmt.Sprintf("requested %d devices, node has %d", requested, registered)
+}
+
+// GenNodeInsufficientMemoryReason is the reason of a node registering less total device memory in MiB than
+// the minimum requested.
+func GenNodeInsufficientMemoryReason(requested, registered int64) string {
+	return fmt.Sprintf("requested %dMiB device memory, node has %dMiB", requested, registered)
+}
+
 func ParseReason(reason string) map[string]int {
 	reasons := strings.Split(reason, ", ")
 
@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -34,7 +36,7 @@ import (
 
 type Devices interface {
 	CommonWord() string
-	MutateAdmission(ctr *corev1.Container, pod *corev1.Pod) (bool, error)
+	MutateAdmission(ctr *corev1.Container, pod *corev1.Pod, warnings *AdmissionWarnings) (bool, error)
 	CheckHealth(devType string, n *corev1.Node) (bool, bool)
 	NodeCleanUp(nn string) error
 	GetResourceNames() ResourceNames
@@ -75,22 +77,54 @@ type AllowedMigGeometries struct {
 }
 
 type DeviceUsage struct {
-	ID          string
-	Index       uint
-	Used        int32
-	Count       int32
-	Usedmem     int32
-	Totalmem    int32
-	Totalcore   int32
-	Usedcores   int32
-	Mode        string
-	MigTemplate []Geometry
-	MigUsage    MigInUse
-	Numa        int
-	Type        string
-	Health      bool
-	PodInfos    []*PodInfo
-	CustomInfo  map[string]any
+	ID      string
+	Index   uint
+	Used    int32
+	Count   int32
+	Usedmem int32
+	// Opportunisticmem is the part of Usedmem used by burstable pods beyond their guaranteed memory.
+	Opportunisticmem int32
+	// Physicalmem is the physical memory of a device whose Totalmem is scaled up, 0 if it isn't.
+	Physicalmem int32
+	// Oversubscribedmem is the part of Usedmem beyond Physicalmem, used by pods allowing memory oversubscription.
+	Oversubscribedmem int32
+	Totalmem          int32
+	Totalcore         int32
+	Usedcores         int32
+	Totalengines      int32
+	Usedengines       int32
+	Mode              string
+	MigTemplate       []Geometry
+	MigUsage          MigInUse
+	Numa              int
+	Type              string
+	Health            bool
+	// Temperature of the device in Celsius, 0 if not reported.
+	Temperature int32
+	// ECCErrorTime is the unix time uncorrectable ECC errors of the device were last seen, 0 if none.
+	ECCErrorTime int64
+	// DriverVersion of the device, e.g. "535.104.05", empty if not reported.
+	DriverVersion string
+	// Pool is the device pool of the device, empty if in no pool.
+	Pool       string
+	PodInfos   []*PodInfo
+	CustomInfo map[string]any
+	// RuntimeNotReady is set when the device plugin registered the HAMi hook missing on the node, so the device
+	// can't isolate the pods sharing it. Devices registered by device plugins not reporting it are ready.
+	RuntimeNotReady bool
+	// Serial is the serial number of the device, empty if not reported.
+	Serial string
+	// ECCMode is the current ECC mode of the device, empty if not reported.
+	ECCMode util.ECCMode
+}
+
+// StableID returns the serial number of the device, which unlike its UUID persists across resets, e.g. into or out
+// of MIG mode, its UUID if it has none. UUIDs remain the key of allocations.
+func (d *DeviceUsage) StableID() string {
+	if d.Serial != "" {
+		return d.Serial
+	}
+	return d.ID
 }
 
 type DeviceInfo struct {
@@ -98,15 +132,24 @@ type DeviceInfo struct {
 	Index           uint            `json:"index,omitempty"`
 	Count           int32           `json:"count,omitempty"`
 	Devmem          int32           `json:"devmem,omitempty"`
+	PhysicalMem     int32           `json:"physicalmem,omitempty"`
 	Devcore         int32           `json:"devcore,omitempty"`
+	Engines         int32           `json:"engines,omitempty"`
 	Type            string          `json:"type,omitempty"`
 	Numa            int             `json:"numa,omitempty"`
 	Mode            string          `json:"mode,omitempty"`
 	MIGTemplate     []Geometry      `json:"migtemplate,omitempty"`
 	Health          bool            `json:"health,omitempty"`
+	Temperature     int32           `json:"temperature,omitempty"`
+	ECCErrorTime    int64           `json:"eccerrortime,omitempty"`
+	DriverVersion   string          `json:"driverversion,omitempty"`
+	Pool            string          `json:"pool,omitempty"`
+	RuntimeReady    *bool           `json:"runtimeready,omitempty"`
 	DeviceVendor    string          `json:"devicevendor,omitempty"`
 	CustomInfo      map[string]any  `json:"custominfo,omitempty"`
 	DevicePairScore DevicePairScore `json:"devicepairscore,omitempty"`
+	Serial          string          `json:"serial,omitempty"`
+	ECCMode         util.ECCMode    `json:"eccmode,omitempty"`
 }
 
 type DevicePairScores []DevicePairScore
@@ -129,11 +172,18 @@ type ResourceNames struct {
 
 type ContainerDevice struct {
 	// TODO current Idx cannot use, because EncodeContainerDevices method not encode this filed.
-	Idx        int
-	UUID       string
-	Type       string
-	Usedmem    int32
-	Usedcores  int32
+	Idx       int
+	UUID      string
+	Type      string
+	Usedmem   int32
+	Usedcores int32
+	// Usedengines are the encode/decode engine sessions of the device, they aren't encoded when 0.
+	Usedengines int32
+	// Oversubscribedmem is the part of Usedmem beyond the physical memory of the device, it isn't encoded when 0.
+	Oversubscribedmem int32
+	// OOMMode is how the memory limit of the device is enforced for the container, it isn't encoded when empty,
+	// which is util.OOMModeHard.
+	OOMMode    util.OOMMode
 	CustomInfo map[string]any
 }
 
@@ -143,6 +193,8 @@ type ContainerDeviceRequest struct {
 	Memreq           int32
 	MemPercentagereq int32
 	Coresreq         int32
+	// Enginesreq are the encode/decode engine sessions requested of each device.
+	Enginesreq int32
 }
 
 type ContainerDevices []ContainerDevice
@@ -159,6 +211,9 @@ const (
 
 	// OnePodMultiContainerSplitSymbol this is when one pod having multi container and more than one container use device, use ; symbol to join device info.
 	OnePodMultiContainerSplitSymbol = ";"
+
+	// HandshakeTimeout is how long the device plugin has to answer a handshake request before its devices are unhealthy.
+	HandshakeTimeout = time.Second * 60
 )
 
 var (
@@ -272,15 +327,24 @@ func MarshalNodeDevices(dlist []*DeviceInfo) string {
 	devAnnos := []*DeviceInfo{}
 	for _, val := range dlist {
 		devAnnos = append(devAnnos, &DeviceInfo{
-			ID:      val.ID,
-			Count:   val.Count,
-			Devmem:  val.Devmem,
-			Devcore: val.Devcore,
-			Type:    val.Type,
-			Numa:    val.Numa,
-			Health:  val.Health,
-			Index:   val.Index,
-			Mode:    val.Mode,
+			ID:            val.ID,
+			Count:         val.Count,
+			Devmem:        val.Devmem,
+			PhysicalMem:   val.PhysicalMem,
+			Devcore:       val.Devcore,
+			Engines:       val.Engines,
+			Type:          val.Type,
+			Numa:          val.Numa,
+			Health:        val.Health,
+			Index:         val.Index,
+			Mode:          val.Mode,
+			Temperature:   val.Temperature,
+			ECCErrorTime:  val.ECCErrorTime,
+			DriverVersion: val.DriverVersion,
+			Pool:          val.Pool,
+			RuntimeReady:  val.RuntimeReady,
+			Serial:        val.Serial,
+			ECCMode:       val.ECCMode,
 		})
 	}
 	data, err := json.Marshal(devAnnos)
@@ -299,18 +363,36 @@ func UnMarshalNodeDevices(str string) ([]*DeviceInfo, error) {
 func EncodeContainerDevices(cd ContainerDevices) string {
 	tmp := ""
 	for _, val := range cd {
-		tmp += val.UUID + "," + val.Type + "," + strconv.Itoa(int(val.Usedmem)) + "," + strconv.Itoa(int(val.Usedcores)) + OneContainerMultiDeviceSplitSymbol
+		tmp += val.UUID + "," + val.Type + "," + strconv.Itoa(int(val.Usedmem)) + "," + strconv.Itoa(int(val.Usedcores))
+		tmp += encodeContainerDeviceExtras(val)
+		tmp += OneContainerMultiDeviceSplitSymbol
 	}
 	klog.Infof("Encoded container Devices: %s", tmp)
 	return tmp
 	//return strings.Join(cd, ",")
 }
 
+// encodeContainerDeviceExtras encodes the optional engines, oversubscribed memory and OOM mode of the device, the
+// fields before the last one set are encoded as well, so the fields keep their positions.
+func encodeContainerDeviceExtras(val ContainerDevice) string {
+	if val.OOMMode != "" {
+		return "," + strconv.Itoa(int(val.Usedengines)) + "," + strconv.Itoa(int(val.Oversubscribedmem)) + "," + string(val.OOMMode)
+	}
+	if val.Oversubscribedmem > 0 {
+		return "," + strconv.Itoa(int(val.Usedengines)) + "," + strconv.Itoa(int(val.Oversubscribedmem))
+	}
+	if val.Usedengines > 0 {
+		return "," + strconv.Itoa(int(val.Usedengines))
+	}
+	return ""
+}
+
 func EncodeContainerDeviceType(cd ContainerDevices, t string) string {
 	tmp := ""
 	for _, val := range cd {
 		if strings.Compare(val.Type, t) == 0 {
 			tmp += val.UUID + "," + val.Type + "," + strconv.Itoa(int(val.Usedmem)) + "," + strconv.Itoa(int(val.Usedcores))
+			tmp += encodeContainerDeviceExtras(val)
 		}
 		tmp += OneContainerMultiDeviceSplitSymbol
 	}
@@ -359,6 +441,20 @@ func DecodeContainerDevices(str string) (ContainerDevices, error) {
 			tmpdev.Usedmem = int32(devmem)
 			devcores, _ := strconv.ParseInt(tmpstr[3], 10, 32)
 			tmpdev.Usedcores = int32(devcores)
+			tmpdev.Usedengines = 0
+			if len(tmpstr) > 4 {
+				engines, _ := strconv.ParseInt(tmpstr[4], 10, 32)
+				tmpdev.Usedengines = int32(engines)
+			}
+			tmpdev.Oversubscribedmem = 0
+			if len(tmpstr) > 5 {
+				overmem, _ := strconv.ParseInt(tmpstr[5], 10, 32)
+				tmpdev.Oversubscribedmem = int32(overmem)
+			}
+			tmpdev.OOMMode = ""
+			if len(tmpstr) > 6 {
+				tmpdev.OOMMode = util.OOMMode(tmpstr[6])
+			}
 			contdev = append(contdev, tmpdev)
 		}
 	}
@@ -420,18 +516,30 @@ func GetDevicesUUIDList(infos []*DeviceInfo) []string {
 	return uuids
 }
 
+// HandshakeDeadline returns when the pending handshake request of devType on node times out.
+func HandshakeDeadline(devType string, node *corev1.Node) (time.Time, bool) {
+	handshake, ok := node.Annotations[util.HandshakeAnnos[devType]]
+	if !ok || !strings.HasPrefix(handshake, "Requesting") {
+		return time.Time{}, false
+	}
+	return handshakeRequestDeadline(devType, node, handshake)
+}
+
 func CheckHealth(devType string, node *corev1.Node) (bool, bool) {
 	handshake := node.Annotations[util.HandshakeAnnos[devType]]
 	if strings.Contains(handshake, "Requesting") {
-		formertime, _ := time.Parse(time.DateTime, strings.Split(handshake, "_")[1])
-		return time.Now().Before(formertime.Add(time.Second * 60)), false
-	} else if strings.Contains(handshake, "Deleted") {
+		deadline, _ := handshakeRequestDeadline(devType, node, handshake)
+		return handshakeClock.Now().Before(deadline), false
+	}
+	observeHandshake(devType, node, handshake)
+	if strings.Contains(handshake, "Deleted") {
 		return true, false
 	} else {
 		_, ok := util.HandshakeAnnos[devType]
 		if ok {
+			now := handshakeClock.Now()
 			tmppat := make(map[string]string)
-			tmppat[util.HandshakeAnnos[devType]] = "Requesting_" + time.Now().Format(time.DateTime)
+			tmppat[util.HandshakeAnnos[devType]] = "Requesting_" + util.FormatHandshakeTime(now)
 			klog.V(5).InfoS("New timestamp for annotation", "nodeName", node.Name, "annotationKey", util.HandshakeAnnos[devType], "annotationValue", tmppat[util.HandshakeAnnos[devType]])
 			n, err := util.GetNode(node.Name)
 			if err != nil {
@@ -441,6 +549,8 @@ func CheckHealth(devType string, node *corev1.Node) (bool, bool) {
 			klog.V(5).InfoS("Patching node annotations", "nodeName", node.Name, "annotations", tmppat)
 			if err := util.PatchNodeAnnotations(n, tmppat); err != nil {
 				klog.ErrorS(err, "Failed to patch node annotations", "nodeName", node.Name)
+			} else {
+				observeHandshakeWrite(devType, node.Name, tmppat[util.HandshakeAnnos[devType]], now)
 			}
 		}
 		return true, true
@@ -484,6 +594,11 @@ func Resourcereqs(pod *corev1.Pod) (counts PodDeviceRequests) {
 	klog.V(4).InfoS("Processing resource requirements",
 		"pod", klog.KObj(pod),
 		"containerCount", len(pod.Spec.Containers))
+	// Volcano pods requesting volcano vgpu resources have their device requests recorded at admission.
+	intent, err := util.GetDeviceIntent(pod)
+	if err != nil {
+		klog.ErrorS(err, "Ignoring device intent", "pod", klog.KObj(pod))
+	}
 	//Count Nvidia GPU
 	cnt := int32(0)
 	for i := range pod.Spec.Containers {
@@ -493,8 +608,16 @@ func Resourcereqs(pod *corev1.Pod) (counts PodDeviceRequests) {
 			"pod", klog.KObj(pod),
 			"containerIndex", i,
 			"containerName", pod.Spec.Containers[i].Name)
+		ctr := &pod.Spec.Containers[i]
+		if i < len(intent) && len(intent[i]) > 0 {
+			ctr = ctr.DeepCopy()
+			if ctr.Resources.Limits == nil {
+				ctr.Resources.Limits = make(corev1.ResourceList)
+			}
+			maps.Copy(ctr.Resources.Limits, intent[i])
+		}
 		for idx, val := range devices {
-			request := val.GenerateResourceRequests(&pod.Spec.Containers[i])
+			request := val.GenerateResourceRequests(ctr)
 			if request.Nums > 0 {
 				cnt += request.Nums
 				counts[i][idx] = request
@@ -508,3 +631,75 @@ func Resourcereqs(pod *corev1.Pod) (counts PodDeviceRequests) {
 	}
 	return counts
 }
+
+// MergeContainerRequests merges the requests of all containers into the first container
+// requesting each device type, taking the max of every request, so that one allocation
+// can be shared by all containers of the pod.
+func MergeContainerRequests(reqs PodDeviceRequests) PodDeviceRequests {
+	merged := make(PodDeviceRequests, len(reqs))
+	first := make(map[string]int)
+	for i, ctr := range reqs {
+		merged[i] = make(ContainerDeviceRequests)
+		for vendor, req := range ctr {
+			idx, ok := first[vendor]
+			if !ok {
+				first[vendor] = i
+				merged[i][vendor] = req
+				continue
+			}
+			m := merged[idx][vendor]
+			m.Nums = max(m.Nums, req.Nums)
+			m.Memreq = max(m.Memreq, req.Memreq)
+			m.Coresreq = max(m.Coresreq, req.Coresreq)
+			// 101 means no percentage is requested.
+			if m.MemPercentagereq == 101 || req.MemPercentagereq != 101 && req.MemPercentagereq > m.MemPercentagereq {
+				m.MemPercentagereq = req.MemPercentagereq
+			}
+			merged[idx][vendor] = m
+		}
+	}
+	return merged
+}
+
+// ShareContainerDevices returns devs allocated for the requests merged by MergeContainerRequests,
+// with the devices of the first container assigned to every container requesting the device type.
+func ShareContainerDevices(reqs PodDeviceRequests, devs PodDevices) PodDevices {
+	shared := make(PodDevices, len(devs))
+	for vendor, podDevs := range devs {
+		shared[vendor] = slices.Clone(podDevs)
+		var src ContainerDevices
+		for _, ctrDevs := range podDevs {
+			if len(ctrDevs) > 0 {
+				src = ctrDevs
+				break
+			}
+		}
+		for i := range shared[vendor] {
+			if i < len(reqs) && reqs[i][vendor].Nums > 0 {
+				shared[vendor][i] = slices.Clone(src)
+			}
+		}
+	}
+	return shared
+}
+
+// DedupPodDevices returns devs with every device kept in the first container using it only,
+// the usage of a device shared by the containers of a pod is counted once.
+func DedupPodDevices(devs PodDevices) PodDevices {
+	res := make(PodDevices, len(devs))
+	for vendor, podDevs := range devs {
+		seen := make(map[string]bool)
+		res[vendor] = make(PodSingleDevice, len(podDevs))
+		for i, ctrDevs := range podDevs {
+			res[vendor][i] = ContainerDevices{}
+			for _, d := range ctrDevs {
+				if seen[d.UUID] {
+					continue
+				}
+				seen[d.UUID] = true
+				res[vendor][i] = append(res[vendor][i], d)
+			}
+		}
+	}
+	return res
+}
@@ -140,7 +140,7 @@ func TestPodDevicesCoding(t *testing.T) {
 			args: PodDevices{
 				"NVIDIA": PodSingleDevice{
 					ContainerDevices{
-						ContainerDevice{0, "UUID1", "Type1", 1000, 30, nil},
+						ContainerDevice{0, "UUID1", "Type1", 1000, 30, 0, 0, "", nil},
 					},
 				},
 			},
@@ -150,10 +150,10 @@ func TestPodDevicesCoding(t *testing.T) {
 			args: PodDevices{
 				"NVIDIA": PodSingleDevice{
 					ContainerDevices{
-						ContainerDevice{0, "UUID1", "Type1", 1000, 30, nil},
+						ContainerDevice{0, "UUID1", "Type1", 1000, 30, 0, 0, "", nil},
 					},
 					ContainerDevices{
-						ContainerDevice{0, "UUID1", "Type1", 1000, 30, nil},
+						ContainerDevice{0, "UUID1", "Type1", 1000, 30, 0, 0, "", nil},
 					},
 				},
 			},
@@ -163,8 +163,42 @@ func TestPodDevicesCoding(t *testing.T) {
 			args: PodDevices{
 				"NVIDIA": PodSingleDevice{
 					ContainerDevices{
-						ContainerDevice{0, "UUID1", "Type1", 1000, 30, nil},
-						ContainerDevice{0, "UUID2", "Type1", 1000, 30, nil},
+						ContainerDevice{0, "UUID1", "Type1", 1000, 30, 0, 0, "", nil},
+						ContainerDevice{0, "UUID2", "Type1", 1000, 30, 0, 0, "", nil},
+					},
+				},
+			},
+		},
+		{
+			name: "one pod one container use encode/decode engines",
+			args: PodDevices{
+				"NVIDIA": PodSingleDevice{
+					ContainerDevices{
+						ContainerDevice{0, "UUID1", "Type1", 1000, 0, 2, 0, "", nil},
+					},
+				},
+			},
+		},
+		{
+			name: "one pod one container use oversubscribed memory",
+			args: PodDevices{
+				"NVIDIA": PodSingleDevice{
+					ContainerDevices{
+						ContainerDevice{0, "UUID1", "Type1", 3000, 30, 0, 1000, "", nil},
+						ContainerDevice{0, "UUID2", "Type1", 3000, 30, 2, 500, "", nil},
+					},
+				},
+			},
+		},
+		{
+			name: "one pod two containers, one in the soft oom mode",
+			args: PodDevices{
+				"NVIDIA": PodSingleDevice{
+					ContainerDevices{
+						ContainerDevice{0, "UUID1", "Type1", 3000, 30, 0, 0, util.OOMModeSoft, nil},
+					},
+					ContainerDevices{
+						ContainerDevice{0, "UUID1", "Type1", 3000, 30, 0, 0, "", nil},
 					},
 				},
 			},
@@ -305,6 +339,42 @@ func TestMarshalNodeDevices(t *testing.T) {
 			},
 			want: "[{\"index\":1,\"id\":\"id-1\",\"count\":1,\"devmem\":1024,\"devcore\":10,\"type\":\"type\",\"numa\":0,\"health\":true},{\"index\":2,\"id\":\"id-2\",\"count\":2,\"devmem\":2048,\"devcore\":20,\"type\":\"type2\",\"numa\":1,\"health\":false}]",
 		},
+		{
+			name: "test serial",
+			args: args{
+				dlist: []*DeviceInfo{
+					{
+						Index:   1,
+						ID:      "id-1",
+						Count:   1,
+						Devmem:  1024,
+						Devcore: 10,
+						Type:    "type",
+						Health:  true,
+						Serial:  "1320221034567",
+					},
+				},
+			},
+			want: "[{\"index\":1,\"id\":\"id-1\",\"count\":1,\"devmem\":1024,\"devcore\":10,\"type\":\"type\",\"numa\":0,\"health\":true,\"serial\":\"1320221034567\"}]",
+		},
+		{
+			name: "test ecc mode",
+			args: args{
+				dlist: []*DeviceInfo{
+					{
+						Index:   1,
+						ID:      "id-1",
+						Count:   1,
+						Devmem:  1024,
+						Devcore: 10,
+						Type:    "type",
+						Health:  true,
+						ECCMode: util.ECCModeOn,
+					},
+				},
+			},
+			want: "[{\"index\":1,\"id\":\"id-1\",\"count\":1,\"devmem\":1024,\"devcore\":10,\"type\":\"type\",\"numa\":0,\"health\":true,\"eccmode\":\"on\"}]",
+		},
 		{
 			name: "test empty",
 			args: args{
@@ -389,6 +459,25 @@ func TestUnMarshalNodeDevices(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "test serial",
+			args: args{
+				str: "[{\"index\":1,\"id\":\"id-1\",\"count\":1,\"devmem\":1024,\"devcore\":10,\"type\":\"type\",\"health\":true,\"serial\":\"1320221034567\"}]",
+			},
+			want: []*DeviceInfo{
+				{
+					Index:   1,
+					ID:      "id-1",
+					Count:   1,
+					Devmem:  1024,
+					Devcore: 10,
+					Type:    "type",
+					Health:  true,
+					Serial:  "1320221034567",
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -801,3 +890,25 @@ func TestEncodeContainerDeviceType(t *testing.T) {
 		})
 	}
 }
+
+func TestShareWithinPodHelpers(t *testing.T) {
+	reqs := PodDeviceRequests{
+		{"NVIDIA": {Nums: 1, Type: "NVIDIA", Memreq: 3000, MemPercentagereq: 101, Coresreq: 50}},
+		{},
+		{"NVIDIA": {Nums: 1, Type: "NVIDIA", Memreq: 5000, MemPercentagereq: 101, Coresreq: 20}},
+	}
+	merged := MergeContainerRequests(reqs)
+	assert.DeepEqual(t, merged, PodDeviceRequests{
+		{"NVIDIA": {Nums: 1, Type: "NVIDIA", Memreq: 5000, MemPercentagereq: 101, Coresreq: 50}},
+		{},
+		{},
+	})
+
+	dev := ContainerDevice{UUID: "GPU-0", Type: "NVIDIA", Usedmem: 5000, Usedcores: 50}
+	allocated := PodDevices{"NVIDIA": PodSingleDevice{{dev}, {}, {}}}
+	shared := ShareContainerDevices(reqs, allocated)
+	assert.DeepEqual(t, shared, PodDevices{"NVIDIA": PodSingleDevice{{dev}, {}, {dev}}})
+	assert.DeepEqual(t, allocated, PodDevices{"NVIDIA": PodSingleDevice{{dev}, {}, {}}})
+
+	assert.DeepEqual(t, DedupPodDevices(shared), allocated)
+}
@@ -67,10 +67,13 @@ func (dev *EnflameDevices) CommonWord() string {
 	return EnflameVGCUCommonWord
 }
 
-func (dev *EnflameDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *EnflameDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	count, ok := ctr.Resources.Limits[corev1.ResourceName(EnflameResourceNameVGCU)]
 	if ok {
 		if count.Value() > 1 {
+			if requested, ok := ctr.Resources.Limits[corev1.ResourceName(EnflameResourceNameVGCUPercentage)]; ok {
+				warnings.Adjusted(ctr.Name, EnflameResourceNameVGCUPercentage, requested.Value(), 100)
+			}
 			ctr.Resources.Limits[corev1.ResourceName(EnflameResourceNameVGCUPercentage)] = *resource.NewQuantity(int64(100), resource.DecimalSI)
 			ctr.Resources.Limits[corev1.ResourceName(SharedResourceName)] = *resource.NewQuantity(int64(dev.factor*int(count.Value())), resource.DecimalSI)
 		} else {
@@ -86,6 +89,11 @@ func (dev *EnflameDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod)
 			for i := 0; i < dev.factor; i++ {
 				if slice*float64(i) < float64(percentage) && float64(percentage) <= slice*float64((i+1)) {
 					percentage = int64(slice * float64(i+1))
+					if ok {
+						warnings.Adjusted(ctr.Name, EnflameResourceNameVGCUPercentage, percentageResource.Value(), percentage)
+					} else {
+						warnings.Defaulted(ctr.Name, EnflameResourceNameVGCUPercentage, percentage)
+					}
 					ctr.Resources.Limits[corev1.ResourceName(EnflameResourceNameVGCUPercentage)] = *resource.NewQuantity(percentage, resource.DecimalSI)
 					ctr.Resources.Limits[corev1.ResourceName(SharedResourceName)] = *resource.NewQuantity(int64(i+1), resource.DecimalSI)
 					ctr.Resources.Requests[corev1.ResourceName(EnflameResourceNameVGCUPercentage)] = *resource.NewQuantity(percentage, resource.DecimalSI)
@@ -209,7 +209,7 @@ func Test_MutateAdmission(t *testing.T) {
 			dev := EnflameDevices{
 				factor: 4,
 			}
-			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			assert.Equal(t, result, test.want)
 			limits := test.args.ctr.Resources.Limits[corev1.ResourceName(EnflameResourceNameVGCUPercentage)]
 			number, _ := limits.AsInt64()
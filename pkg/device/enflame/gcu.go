@@ -49,7 +49,7 @@ func (dev *GCUDevices) CommonWord() string {
 	return EnflameGCUCommonWord
 }
 
-func (dev *GCUDevices) MutateAdmission(ctr *corev1.Container, pod *corev1.Pod) (bool, error) {
+func (dev *GCUDevices) MutateAdmission(ctr *corev1.Container, pod *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(EnflameResourceNameGCU)]
 	return ok, nil
 }
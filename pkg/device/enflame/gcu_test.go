@@ -117,7 +117,7 @@ func TestGCUDevices_MutateAdmission(t *testing.T) {
 			}
 			InitGCUDevice(config)
 			dev := &GCUDevices{}
-			result, err := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, err := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			assert.Equal(t, result, test.want)
 			assert.Equal(t, err, test.err)
 		})
@@ -0,0 +1,65 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// OwnedEnvDevices is implemented by the devices whose device plugin sets environment variables in the containers
+// it allocates devices to, e.g. the limits enforced by HAMi-core, which the containers must not set themselves.
+type OwnedEnvDevices interface {
+	// OwnedEnvs returns the names of the environment variables owned by the device plugin. A name also covers
+	// its variants suffixed by _<device index>.
+	OwnedEnvs() []string
+}
+
+// OwnedEnv returns the name among owned covering the environment variable name, and whether any does.
+func OwnedEnv(owned []string, name string) (string, bool) {
+	for _, o := range owned {
+		if name == o {
+			return o, true
+		}
+		if index, ok := strings.CutPrefix(name, o+"_"); ok {
+			if _, err := strconv.Atoi(index); err == nil {
+				return o, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ContainerOwnedEnvs returns the names of the environment variables the container sets which are owned by the
+// device plugin of devs.
+func ContainerOwnedEnvs(ctr *corev1.Container, devs []Devices) []string {
+	res := make([]string, 0)
+	for _, dev := range devs {
+		owner, ok := dev.(OwnedEnvDevices)
+		if !ok {
+			continue
+		}
+		for _, env := range ctr.Env {
+			if _, ok := OwnedEnv(owner.OwnedEnvs(), env.Name); ok {
+				res = append(res, env.Name)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidRequest is the category of errors caused by a device request the user has to fix.
+	ErrInvalidRequest = errors.New("invalid device request")
+	// ErrInternal is the category of errors caused by HAMi or the cluster, not by the request.
+	ErrInternal = errors.New("internal error")
+)
+
+// categorizedError is an error matching its category with errors.Is, keeping its own message.
+type categorizedError struct {
+	category error
+	msg      string
+}
+
+func (e *categorizedError) Error() string {
+	return e.msg
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.category
+}
+
+// InvalidRequestErrorf returns an error of category ErrInvalidRequest formatted with fmt.Sprintf.
+func InvalidRequestErrorf(format string, a ...any) error {
+	return &categorizedError{category: ErrInvalidRequest, msg: fmt.Sprintf(format, a...)}
+}
+
+// InternalErrorf returns an error of category ErrInternal formatted with fmt.Sprintf.
+func InternalErrorf(format string, a ...any) error {
+	return &categorizedError{category: ErrInternal, msg: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCategorizedErrors(t *testing.T) {
+	invalid := InvalidRequestErrorf("%s %d is invalid", "gpumem", 3)
+	assert.Equal(t, invalid.Error(), "gpumem 3 is invalid")
+	assert.Assert(t, errors.Is(invalid, ErrInvalidRequest))
+	assert.Assert(t, !errors.Is(invalid, ErrInternal))
+
+	internal := InternalErrorf("failed to read config")
+	assert.Equal(t, internal.Error(), "failed to read config")
+	assert.Assert(t, errors.Is(internal, ErrInternal))
+	assert.Assert(t, !errors.Is(internal, ErrInvalidRequest))
+
+	// The category is kept when wrapped.
+	assert.Assert(t, errors.Is(fmt.Errorf("container c: %w", invalid), ErrInvalidRequest))
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// HandshakeClockTolerance is how far the clock of whoever wrote a handshake request may lag behind the clock of
+// the scheduler, for requests whose timestamp has to be trusted because the scheduler didn't see them written.
+var HandshakeClockTolerance = 30 * time.Second
+
+// handshakeClock is the clock handshake requests are timed by.
+var handshakeClock clock.PassiveClock = clock.RealClock{}
+
+// handshakeObservation is the handshake annotation of a device type on a node as last seen by the scheduler.
+type handshakeObservation struct {
+	value           string
+	resourceVersion string
+	// Time the value is pending since
+	since time.Time
+	// Whether since is the timestamp of the value, rather than the time the scheduler saw it written
+	stamped bool
+}
+
+// handshakeObservations keeps when the scheduler saw handshake requests written, by node and device type, so
+// their staleness depends on the clock of the scheduler instead of the clock of their writer where possible.
+var handshakeObservations = struct {
+	sync.Mutex
+	seen map[string]handshakeObservation
+}{seen: make(map[string]handshakeObservation)}
+
+func handshakeKey(devType, nodeName string) string {
+	return nodeName + "/" + devType
+}
+
+// observeHandshakeWrite records the scheduler wrote the handshake annotation value at t.
+func observeHandshakeWrite(devType, nodeName, value string, t time.Time) {
+	handshakeObservations.Lock()
+	defer handshakeObservations.Unlock()
+	handshakeObservations.seen[handshakeKey(devType, nodeName)] = handshakeObservation{value: value, since: t}
+}
+
+// handshakeRequestDeadline returns when the handshake request value of devType on node times out, and whether
+// its timestamp is valid. A request seen written, by the scheduler itself or by a change of the resourceVersion of
+// the node, times out HandshakeTimeout after that. Otherwise, e.g. after a restart of the scheduler, its timestamp
+// is trusted with HandshakeClockTolerance, timestamps ahead of the scheduler are capped to its clock.
+func handshakeRequestDeadline(devType string, node *corev1.Node, value string) (time.Time, bool) {
+	parts := strings.SplitN(value, "_", 2)
+	if len(parts) < 2 {
+		return time.Time{}, false
+	}
+	stamp, err := util.ParseHandshakeTime(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	now := handshakeClock.Now()
+	key := handshakeKey(devType, node.Name)
+	handshakeObservations.Lock()
+	defer handshakeObservations.Unlock()
+	obs, seen := handshakeObservations.seen[key]
+	switch {
+	case seen && obs.value == value:
+	case seen && node.ResourceVersion != "" && obs.resourceVersion != "" && node.ResourceVersion != obs.resourceVersion:
+		obs = handshakeObservation{value: value, since: now}
+	default:
+		obs = handshakeObservation{value: value, since: stamp, stamped: true}
+		if stamp.After(now) {
+			obs.since = now
+		}
+	}
+	obs.resourceVersion = node.ResourceVersion
+	handshakeObservations.seen[key] = obs
+	deadline := obs.since.Add(HandshakeTimeout)
+	if obs.stamped {
+		deadline = deadline.Add(HandshakeClockTolerance)
+	}
+	return deadline, true
+}
+
+// observeHandshake records the handshake annotation value of devType on node was seen, so a later request is
+// known to be written after it.
+func observeHandshake(devType string, node *corev1.Node, value string) {
+	handshakeObservations.Lock()
+	defer handshakeObservations.Unlock()
+	key := handshakeKey(devType, node.Name)
+	if obs, ok := handshakeObservations.seen[key]; ok && obs.value == value {
+		obs.resourceVersion = node.ResourceVersion
+		handshakeObservations.seen[key] = obs
+		return
+	}
+	handshakeObservations.seen[key] = handshakeObservation{value: value, resourceVersion: node.ResourceVersion, since: handshakeClock.Now()}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_CheckHealth_SkewedHandshake(t *testing.T) {
+	const devType = "skewed"
+	util.HandshakeAnnos[devType] = "hami.io/node-handshake-skewed"
+	defer delete(util.HandshakeAnnos, devType)
+	now := time.Date(2025, 6, 13, 9, 0, 0, 0, time.UTC)
+	fakeClock := testingclock.NewFakeClock(now)
+	defer func(c clock.PassiveClock) { handshakeClock = c }(handshakeClock)
+	handshakeClock = fakeClock
+	defer func(d time.Duration) { HandshakeClockTolerance = d }(HandshakeClockTolerance)
+	HandshakeClockTolerance = 30 * time.Second
+
+	newNode := func(name, resourceVersion, handshake string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: resourceVersion,
+			Annotations:     map[string]string{util.HandshakeAnnos[devType]: handshake},
+		}}
+	}
+	healthy := func(node *corev1.Node) bool {
+		health, _ := CheckHealth(devType, node)
+		return health
+	}
+
+	tests := []struct {
+		name      string
+		handshake string
+		// Time after now the request is stale
+		staleAfter time.Duration
+	}{
+		{
+			name:       "written now",
+			handshake:  "Requesting_" + util.FormatHandshakeTime(now),
+			staleAfter: HandshakeTimeout + HandshakeClockTolerance,
+		},
+		{
+			name:       "writer clock behind within the tolerance",
+			handshake:  "Requesting_" + util.FormatHandshakeTime(now.Add(-20*time.Second)),
+			staleAfter: HandshakeTimeout + HandshakeClockTolerance - 20*time.Second,
+		},
+		{
+			name:       "writer clock ahead is capped to the scheduler clock",
+			handshake:  "Requesting_" + util.FormatHandshakeTime(now.Add(time.Hour)),
+			staleAfter: HandshakeTimeout + HandshakeClockTolerance,
+		},
+		{
+			name:       "zone offset",
+			handshake:  "Requesting_" + now.In(time.FixedZone("UTC+8", 8*3600)).Format(time.RFC3339),
+			staleAfter: HandshakeTimeout + HandshakeClockTolerance,
+		},
+		{
+			name:       "legacy format in local time",
+			handshake:  "Requesting_" + now.Local().Format(time.DateTime),
+			staleAfter: HandshakeTimeout + HandshakeClockTolerance,
+		},
+		{
+			name:       "expired",
+			handshake:  "Requesting_" + util.FormatHandshakeTime(now.Add(-time.Hour)),
+			staleAfter: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fakeClock.SetTime(now)
+			node := newNode(test.name, "", test.handshake)
+			if test.staleAfter > 0 {
+				assert.Assert(t, healthy(node))
+				fakeClock.SetTime(now.Add(test.staleAfter - time.Second))
+				assert.Assert(t, healthy(node))
+			}
+			fakeClock.SetTime(now.Add(test.staleAfter))
+			assert.Assert(t, !healthy(node))
+		})
+	}
+
+	// A request seen written by a change of the node times out by the clock of the scheduler, however skewed
+	// its timestamp is.
+	for _, skew := range []time.Duration{-time.Hour, time.Hour} {
+		fakeClock.SetTime(now)
+		assert.Assert(t, healthy(newNode("observed", "1", "Deleted_"+util.FormatHandshakeTime(now))))
+		node := newNode("observed", "2", "Requesting_"+util.FormatHandshakeTime(now.Add(skew)))
+		assert.Assert(t, healthy(node))
+		fakeClock.SetTime(now.Add(HandshakeTimeout - time.Second))
+		assert.Assert(t, healthy(node))
+		fakeClock.SetTime(now.Add(HandshakeTimeout))
+		assert.Assert(t, !healthy(node))
+	}
+}
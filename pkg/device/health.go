@@ -0,0 +1,57 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+var (
+	// MaxTemperature excludes devices hotter than it in Celsius. Disabled if 0.
+	MaxTemperature int32
+	// StrictMaxTemperature excludes devices hotter than it in Celsius for pods of the strict GPU health policy.
+	// MaxTemperature applies if 0.
+	StrictMaxTemperature int32
+	// ECCErrorWindow excludes devices with uncorrectable ECC errors seen within it. Pods of the strict GPU health
+	// policy exclude devices with any uncorrectable ECC errors.
+	ECCErrorWindow time.Duration
+)
+
+// CheckDeviceHealth returns why the temperature or ECC errors of the device exclude it for the pod,
+// empty if they don't.
+func CheckDeviceHealth(dev *DeviceUsage, pod *corev1.Pod, now time.Time) string {
+	policy, _ := util.GetGPUHealthPolicy(pod)
+	maxTemperature := MaxTemperature
+	if policy == util.GPUHealthPolicyStrict && StrictMaxTemperature > 0 {
+		maxTemperature = StrictMaxTemperature
+	}
+	if maxTemperature > 0 && dev.Temperature > maxTemperature {
+		return common.CardOverheated
+	}
+	if dev.ECCErrorTime == 0 {
+		return ""
+	}
+	if policy == util.GPUHealthPolicyStrict || now.Sub(time.Unix(dev.ECCErrorTime, 0)) < ECCErrorWindow {
+		return common.CardECCError
+	}
+	return ""
+}
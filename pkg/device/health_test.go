@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func TestCheckDeviceHealth(t *testing.T) {
+	now := time.Now()
+	strict := map[string]string{util.GPUHealthPolicyAnnotationKey: string(util.GPUHealthPolicyStrict)}
+	tests := []struct {
+		name                 string
+		maxTemperature       int32
+		strictMaxTemperature int32
+		dev                  DeviceUsage
+		annos                map[string]string
+		want                 string
+	}{
+		{
+			name: "nothing reported",
+			dev:  DeviceUsage{},
+		},
+		{
+			name: "temperature gating disabled",
+			dev:  DeviceUsage{Temperature: 95},
+		},
+		{
+			name:           "below max temperature",
+			maxTemperature: 85,
+			dev:            DeviceUsage{Temperature: 85},
+		},
+		{
+			name:           "above max temperature",
+			maxTemperature: 85,
+			dev:            DeviceUsage{Temperature: 90},
+			want:           common.CardOverheated,
+		},
+		{
+			name:                 "above strict max temperature",
+			maxTemperature:       85,
+			strictMaxTemperature: 75,
+			dev:                  DeviceUsage{Temperature: 80},
+			annos:                strict,
+			want:                 common.CardOverheated,
+		},
+		{
+			name:           "strict policy without strict max temperature",
+			maxTemperature: 85,
+			dev:            DeviceUsage{Temperature: 80},
+			annos:          strict,
+		},
+		{
+			name: "ECC errors within the window",
+			dev:  DeviceUsage{ECCErrorTime: now.Add(-time.Hour).Unix()},
+			want: common.CardECCError,
+		},
+		{
+			name: "ECC errors out of the window",
+			dev:  DeviceUsage{ECCErrorTime: now.Add(-48 * time.Hour).Unix()},
+		},
+		{
+			name:  "strict policy with ECC errors out of the window",
+			dev:   DeviceUsage{ECCErrorTime: now.Add(-48 * time.Hour).Unix()},
+			annos: strict,
+			want:  common.CardECCError,
+		},
+	}
+	defer func() { MaxTemperature, StrictMaxTemperature, ECCErrorWindow = 0, 0, 0 }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			MaxTemperature, StrictMaxTemperature, ECCErrorWindow = test.maxTemperature, test.strictMaxTemperature, 24*time.Hour
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			assert.Equal(t, CheckDeviceHealth(&test.dev, pod, now), test.want)
+		})
+	}
+}
@@ -73,6 +73,7 @@ func InitDCUDevice(config HygonConfig) *DCUDevices {
 		device.InRequestDevices[HygonDCUDevice] = "hami.io/dcu-devices-to-allocate"
 		device.SupportDevices[HygonDCUDevice] = "hami.io/dcu-devices-allocated"
 		util.HandshakeAnnos[HygonDCUDevice] = HandshakeAnnos
+		util.RegisterAnnos[HygonDCUDevice] = RegisterAnnos
 	}
 	return &DCUDevices{}
 }
@@ -87,41 +88,20 @@ func ParseConfig(fs *flag.FlagSet) {
 	fs.StringVar(&HygonResourceCores, "dcu-cores", "hygon.com/dcucores", "dcu core resource")
 }
 
-func (dev *DCUDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *DCUDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(HygonResourceCount)]
 	return ok, nil
 }
 
+// RequestAccelerator implements device.AcceleratorDevices.
+func (dev *DCUDevices) RequestAccelerator(ctr *corev1.Container, count, mem int64) {
+	device.SetAcceleratorLimits(ctr, dev.GetResourceNames(), count, mem)
+}
+
+// checkDCUtype reports whether the DCU type is allowed by the use-dcutype annotation, or if it's not set by the
+// nouse-dcutype annotation, see device.TypePattern for the syntax of their entries.
 func checkDCUtype(annos map[string]string, cardtype string) bool {
-	if inuse, ok := annos[DCUInUse]; ok {
-		if !strings.Contains(inuse, ",") {
-			if strings.Contains(strings.ToUpper(cardtype), strings.ToUpper(inuse)) {
-				return true
-			}
-		} else {
-			for val := range strings.SplitSeq(inuse, ",") {
-				if strings.Contains(strings.ToUpper(cardtype), strings.ToUpper(val)) {
-					return true
-				}
-			}
-		}
-		return false
-	}
-	if nouse, ok := annos[DCUNoUse]; ok {
-		if !strings.Contains(nouse, ",") {
-			if strings.Contains(strings.ToUpper(cardtype), strings.ToUpper(nouse)) {
-				return false
-			}
-		} else {
-			for val := range strings.SplitSeq(nouse, ",") {
-				if strings.Contains(strings.ToUpper(cardtype), strings.ToUpper(val)) {
-					return false
-				}
-			}
-		}
-		return true
-	}
-	return true
+	return device.TypeAllowed(annos, DCUInUse, DCUNoUse, cardtype)
 }
 
 func (dev *DCUDevices) LockNode(n *corev1.Node, p *corev1.Pod) error {
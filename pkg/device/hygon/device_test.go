@@ -73,7 +73,7 @@ func Test_MutateAdmission(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			dev := DCUDevices{}
-			result, err := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, err := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			if err != test.err {
 				klog.InfoS("set to resource limits failed")
 			}
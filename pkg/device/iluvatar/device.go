@@ -69,6 +69,7 @@ func InitIluvatarDevice(config []IluvatarConfig) []*IluvatarDevices {
 		device.InRequestDevices[commonWord] = fmt.Sprintf("hami.io/%s-devices-to-allocate", commonWord)
 		device.SupportDevices[commonWord] = fmt.Sprintf("hami.io/%s-devices-allocated", commonWord)
 		util.HandshakeAnnos[commonWord] = dev.handshakeAnno
+		util.RegisterAnnos[commonWord] = dev.nodeRegisterAnno
 		devs = append(devs, dev)
 		klog.Infof("load iluvatar gpu config %s: %v", commonWord, dev.config)
 	}
@@ -83,11 +84,17 @@ func ParseConfig(fs *flag.FlagSet) {
 	fs.BoolVar(&enableIluvatar, "enable-iluvatar", false, "enable iluvatar device")
 }
 
-func (dev *IluvatarDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *IluvatarDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	count, ok := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCountName)]
 	if ok {
 		if count.Value() > 1 {
-			ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCoreName)] = *resource.NewQuantity(count.Value()*int64(100), resource.DecimalSI)
+			cores := count.Value() * int64(100)
+			if requested, ok := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCoreName)]; ok {
+				warnings.Adjusted(ctr.Name, dev.config.ResourceCoreName, requested.Value(), cores)
+			} else {
+				warnings.Defaulted(ctr.Name, dev.config.ResourceCoreName, cores)
+			}
+			ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCoreName)] = *resource.NewQuantity(cores, resource.DecimalSI)
 		}
 	}
 	return ok, nil
@@ -246,7 +246,7 @@ func Test_MutateAdmission(t *testing.T) {
 					ResourceCoreName:   "iluvatar.ai/MR-V100.vCore",
 				},
 			}
-			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			assert.Equal(t, result, test.want)
 		})
 	}
@@ -56,7 +56,7 @@ func (dev *KunlunDevices) CommonWord() string {
 	return KunlunGPUCommonWord
 }
 
-func (dev *KunlunDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *KunlunDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(KunlunResourceCount)]
 	return ok, nil
 }
@@ -58,6 +58,7 @@ func InitKunlunVDevice(config KunlunConfig) *KunlunVDevices {
 		device.InRequestDevices[XPUDevice] = "hami.io/xpu-devices-to-allocate"
 		device.SupportDevices[XPUDevice] = "hami.io/xpu-devices-allocated"
 		util.HandshakeAnnos[XPUDevice] = HandshakeAnnos
+		util.RegisterAnnos[XPUDevice] = RegisterAnnos
 	}
 	return &KunlunVDevices{}
 }
@@ -76,7 +77,7 @@ func (dev *KunlunVDevices) CommonWord() string {
 	return XPUDevice
 }
 
-func (dev *KunlunVDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *KunlunVDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(KunlunResourceVCount)]
 	if !ok {
 		return false, nil
@@ -84,6 +85,7 @@ func (dev *KunlunVDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod)
 	memory, ok := ctr.Resources.Limits[corev1.ResourceName(KunlunResourceVMemory)]
 	if ok {
 		trimMem := dev.trimMemory(memory.Value())
+		warnings.Adjusted(ctr.Name, KunlunResourceVMemory, memory.Value(), trimMem)
 		ctr.Resources.Limits[corev1.ResourceName(KunlunResourceVMemory)] = resource.MustParse(fmt.Sprint(trimMem))
 		ctr.Resources.Requests[corev1.ResourceName(KunlunResourceVMemory)] = resource.MustParse(fmt.Sprint(trimMem))
 		return true, nil
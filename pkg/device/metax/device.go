@@ -58,7 +58,7 @@ func (dev *MetaxDevices) CommonWord() string {
 	return MetaxGPUCommonWord
 }
 
-func (dev *MetaxDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *MetaxDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	_, ok := ctr.Resources.Limits[corev1.ResourceName(MetaxResourceCount)]
 	return ok, nil
 }
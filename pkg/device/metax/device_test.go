@@ -204,7 +204,7 @@ func Test_MutateAdmission(t *testing.T) {
 			}
 			InitMetaxDevice(config)
 			dev := MetaxDevices{}
-			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			assert.Equal(t, result, test.want)
 		})
 	}
@@ -19,7 +19,6 @@ package metax
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"maps"
 	"slices"
 	"sort"
@@ -81,7 +80,7 @@ func (sdev *MetaxSDevices) CommonWord() string {
 	return MetaxSGPUCommonWord
 }
 
-func (sdev *MetaxSDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (sdev *MetaxSDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	count, ok := ctr.Resources.Limits[corev1.ResourceName(MetaxResourceNameVCount)]
 	if !ok {
 		return false, nil
@@ -90,12 +89,12 @@ func (sdev *MetaxSDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod)
 	appClass, ok := p.GetAnnotations()[MetaxSGPUAppClass]
 	if ok {
 		if appClass != Online && appClass != Offline {
-			return true, fmt.Errorf("%s must be set one of [%s, %s]",
+			return true, device.InvalidRequestErrorf("%s must be set one of [%s, %s]",
 				MetaxSGPUAppClass, Online, Offline)
 		}
 
 		if count.Value() != 1 {
-			return true, fmt.Errorf("app-class pod must request single device")
+			return true, device.InvalidRequestErrorf("app-class pod must request single device")
 		}
 	}
 
@@ -107,6 +106,7 @@ func (sdev *MetaxSDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod)
 			}
 
 			p.Annotations[MetaxSGPUQosPolicy] = BestEffort
+			warnings.Addf("%s is not set, defaulted to %s", MetaxSGPUQosPolicy, BestEffort)
 			return true, nil
 		}
 
@@ -115,7 +115,7 @@ func (sdev *MetaxSDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod)
 			qos == BurstShare {
 			return true, nil
 		} else {
-			return true, fmt.Errorf("%s must be set one of [%s, %s, %s]",
+			return true, device.InvalidRequestErrorf("%s must be set one of [%s, %s, %s]",
 				MetaxSGPUQosPolicy, BestEffort, FixedShare, BurstShare)
 		}
 	}
@@ -287,7 +287,7 @@ func TestMutateAdmission(t *testing.T) {
 			fs := flag.FlagSet{}
 			ParseConfig(&fs)
 
-			resFound, resErr := metaxSDevice.MutateAdmission(ts.container, ts.pod)
+			resFound, resErr := metaxSDevice.MutateAdmission(ts.container, ts.pod, nil)
 
 			if resFound != ts.expectedFound {
 				t.Errorf("MutateAdmission failed: resFound %v, expectedFound %v",
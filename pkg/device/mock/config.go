@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mock
+
+import (
+	"fmt"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+const (
+	DefaultResourceCountName  = "hami.io/mock-device"
+	DefaultResourceMemoryName = "hami.io/mock-memory"
+	DefaultResourceCoreName   = "hami.io/mock-cores"
+	DefaultDeviceType         = "GPU"
+	DefaultDeviceCount        = 2
+	DefaultDeviceMemory       = 16384
+	DefaultDeviceCores        = 100
+	DefaultDeviceSplitCount   = 10
+)
+
+// MockConfig configures the mock vendor, which fakes devices to test HAMi end to end without hardware. The
+// scheduler only registers it if Enabled, and the mock device plugin advertises DeviceCount devices of DeviceType,
+// prefixed by MockDevice, with DeviceMemory MB and DeviceCores cores on each node, shared by up to DeviceSplitCount
+// containers.
+type MockConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	ResourceCountName  string `yaml:"resourceCountName"`
+	ResourceMemoryName string `yaml:"resourceMemoryName"`
+	ResourceCoreName   string `yaml:"resourceCoreName"`
+	DeviceType         string `yaml:"deviceType"`
+	DeviceCount        int32  `yaml:"deviceCount"`
+	DeviceMemory       int32  `yaml:"deviceMemory"`
+	DeviceCores        int32  `yaml:"deviceCores"`
+	DeviceSplitCount   int32  `yaml:"deviceSplitCount"`
+}
+
+// WithDefaults returns the config with its unset fields set to their defaults.
+func (c MockConfig) WithDefaults() MockConfig {
+	if c.ResourceCountName == "" {
+		c.ResourceCountName = DefaultResourceCountName
+	}
+	if c.ResourceMemoryName == "" {
+		c.ResourceMemoryName = DefaultResourceMemoryName
+	}
+	if c.ResourceCoreName == "" {
+		c.ResourceCoreName = DefaultResourceCoreName
+	}
+	if c.DeviceType == "" {
+		c.DeviceType = DefaultDeviceType
+	}
+	if c.DeviceCount == 0 {
+		c.DeviceCount = DefaultDeviceCount
+	}
+	if c.DeviceMemory == 0 {
+		c.DeviceMemory = DefaultDeviceMemory
+	}
+	if c.DeviceCores == 0 {
+		c.DeviceCores = DefaultDeviceCores
+	}
+	if c.DeviceSplitCount == 0 {
+		c.DeviceSplitCount = DefaultDeviceSplitCount
+	}
+	return c
+}
+
+// NodeDevices returns the synthetic devices of the node nodeName.
+func (c MockConfig) NodeDevices(nodeName string) []*device.DeviceInfo {
+	c = c.WithDefaults()
+	devices := make([]*device.DeviceInfo, 0, c.DeviceCount)
+	for i := range c.DeviceCount {
+		devices = append(devices, &device.DeviceInfo{
+			ID:           fmt.Sprintf("MOCK-%s-%d", nodeName, i),
+			Index:        uint(i),
+			Count:        c.DeviceSplitCount,
+			Devmem:       c.DeviceMemory,
+			Devcore:      c.DeviceCores,
+			Type:         MockDevice + "-" + c.DeviceType,
+			Health:       true,
+			DeviceVendor: MockCommonWord,
+		})
+	}
+	return devices
+}
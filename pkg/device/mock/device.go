@@ -0,0 +1,316 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mock
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+type MockDevices struct {
+	resourceCountName  string
+	resourceMemoryName string
+	resourceCoreName   string
+}
+
+const (
+	HandshakeAnnos = "hami.io/node-handshake-mock"
+	RegisterAnnos  = "hami.io/node-mock-register"
+	MockDevice     = "Mock"
+	MockCommonWord = "Mock"
+	// MockUseUUID is user can use specify mock device for set mock UUID.
+	MockUseUUID = "hami.io/use-mock-uuid"
+	// MockNoUseUUID is user can not use specify mock device for set mock UUID.
+	MockNoUseUUID = "hami.io/nouse-mock-uuid"
+
+	// VisibleDevicesEnv lists the mock devices allocated to a container, like NVIDIA_VISIBLE_DEVICES.
+	VisibleDevicesEnv = "MOCK_VISIBLE_DEVICES"
+	// DeviceMemoryLimitEnv is the memory limit of each mock device of a container, suffixed by its index.
+	DeviceMemoryLimitEnv = "MOCK_DEVICE_MEMORY_LIMIT"
+	// DeviceCoreLimitEnv is the core limit of the mock devices of a container.
+	DeviceCoreLimitEnv = "MOCK_DEVICE_CORE_LIMIT"
+
+	// NodeLockMock should same with device plugin node lock name
+	// there is a bug with nodelock package utils, the key is hard coded as "hami.io/mutex.lock"
+	// so we can only use this value now.
+	NodeLockMock = "hami.io/mutex.lock"
+)
+
+func InitMockDevice(config MockConfig) *MockDevices {
+	config = config.WithDefaults()
+	_, ok := device.InRequestDevices[MockDevice]
+	if !ok {
+		device.InRequestDevices[MockDevice] = "hami.io/mock-devices-to-allocate"
+		device.SupportDevices[MockDevice] = "hami.io/mock-devices-allocated"
+		util.HandshakeAnnos[MockDevice] = HandshakeAnnos
+		util.RegisterAnnos[MockDevice] = RegisterAnnos
+	}
+	return &MockDevices{
+		resourceCountName:  config.ResourceCountName,
+		resourceMemoryName: config.ResourceMemoryName,
+		resourceCoreName:   config.ResourceCoreName,
+	}
+}
+
+func (dev *MockDevices) CommonWord() string {
+	return MockCommonWord
+}
+
+func (dev *MockDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
+	_, ok := ctr.Resources.Limits[corev1.ResourceName(dev.resourceCountName)]
+	return ok, nil
+}
+
+func (dev *MockDevices) LockNode(n *corev1.Node, p *corev1.Pod) error {
+	found := false
+	for _, val := range p.Spec.Containers {
+		if (dev.GenerateResourceRequests(&val).Nums) > 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+	return nodelock.LockNode(n.Name, NodeLockMock, p)
+}
+
+func (dev *MockDevices) ReleaseNodeLock(n *corev1.Node, p *corev1.Pod) error {
+	found := false
+	for _, val := range p.Spec.Containers {
+		if (dev.GenerateResourceRequests(&val).Nums) > 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+	return nodelock.ReleaseNodeLock(n.Name, NodeLockMock, p, false)
+}
+
+func (dev *MockDevices) GetNodeDevices(n corev1.Node) ([]*device.DeviceInfo, error) {
+	devEncoded, ok := n.Annotations[RegisterAnnos]
+	if !ok {
+		return []*device.DeviceInfo{}, errors.New("annos not found " + RegisterAnnos)
+	}
+	nodedevices, err := device.UnMarshalNodeDevices(devEncoded)
+	if err != nil {
+		klog.ErrorS(err, "failed to decode node devices", "node", n.Name, "device annotation", devEncoded)
+		return []*device.DeviceInfo{}, err
+	}
+	if len(nodedevices) == 0 {
+		klog.InfoS("no mock device found", "node", n.Name, "device annotation", devEncoded)
+		return []*device.DeviceInfo{}, errors.New("no mock device found on node")
+	}
+	for idx := range nodedevices {
+		nodedevices[idx].DeviceVendor = MockCommonWord
+	}
+	klog.V(5).InfoS("nodes device information", "node", n.Name, "nodedevices", devEncoded)
+	return nodedevices, nil
+}
+
+func (dev *MockDevices) NodeCleanUp(nn string) error {
+	return util.MarkAnnotationsToDelete(HandshakeAnnos, nn)
+}
+
+func (dev *MockDevices) CheckHealth(devType string, n *corev1.Node) (bool, bool) {
+	return device.CheckHealth(devType, n)
+}
+
+func (dev *MockDevices) checkUUID(annos map[string]string, d device.DeviceUsage) bool {
+	userUUID, ok := annos[MockUseUUID]
+	if ok {
+		klog.V(5).Infof("check uuid for mock user uuid [%s], device id is %s", userUUID, d.ID)
+		// use , symbol to connect multiple uuid
+		userUUIDs := strings.Split(userUUID, ",")
+		return slices.Contains(userUUIDs, d.ID)
+	}
+
+	noUserUUID, ok := annos[MockNoUseUUID]
+	if ok {
+		klog.V(5).Infof("check uuid for mock not user uuid [%s], device id is %s", noUserUUID, d.ID)
+		// use , symbol to connect multiple uuid
+		noUserUUIDs := strings.Split(noUserUUID, ",")
+		return !slices.Contains(noUserUUIDs, d.ID)
+	}
+	return true
+}
+
+func (dev *MockDevices) GenerateResourceRequests(ctr *corev1.Container) device.ContainerDeviceRequest {
+	klog.V(4).Info("Start to count mock devices for container ", ctr.Name)
+	v, ok := ctr.Resources.Limits[corev1.ResourceName(dev.resourceCountName)]
+	if !ok {
+		v, ok = ctr.Resources.Requests[corev1.ResourceName(dev.resourceCountName)]
+	}
+	if !ok {
+		return device.ContainerDeviceRequest{}
+	}
+	n, ok := v.AsInt64()
+	if !ok {
+		return device.ContainerDeviceRequest{}
+	}
+	memnum := int32(0)
+	mem, ok := ctr.Resources.Limits[corev1.ResourceName(dev.resourceMemoryName)]
+	if !ok {
+		mem, ok = ctr.Resources.Requests[corev1.ResourceName(dev.resourceMemoryName)]
+	}
+	if ok {
+		if memnums, ok := mem.AsInt64(); ok {
+			memnum = int32(memnums)
+		}
+	}
+	corenum := int32(0)
+	core, ok := ctr.Resources.Limits[corev1.ResourceName(dev.resourceCoreName)]
+	if !ok {
+		core, ok = ctr.Resources.Requests[corev1.ResourceName(dev.resourceCoreName)]
+	}
+	if ok {
+		if corenums, ok := core.AsInt64(); ok {
+			corenum = int32(corenums)
+		}
+	}
+	mempnum := int32(0)
+	if memnum == 0 {
+		mempnum = 100
+	}
+	return device.ContainerDeviceRequest{
+		Nums:             int32(n),
+		Type:             MockDevice,
+		Memreq:           memnum,
+		MemPercentagereq: mempnum,
+		Coresreq:         corenum,
+	}
+}
+
+func (dev *MockDevices) PatchAnnotations(pod *corev1.Pod, annoinput *map[string]string, pd device.PodDevices) map[string]string {
+	devlist, ok := pd[MockDevice]
+	if ok && len(devlist) > 0 {
+		deviceStr := device.EncodePodSingleDevice(devlist)
+		(*annoinput)[device.InRequestDevices[MockDevice]] = deviceStr
+		(*annoinput)[device.SupportDevices[MockDevice]] = deviceStr
+		klog.V(5).Infof("pod add notation key [%s], values is [%s]", device.SupportDevices[MockDevice], deviceStr)
+	}
+	return *annoinput
+}
+
+func (dev *MockDevices) ScoreNode(node *corev1.Node, podDevices device.PodSingleDevice, previous []*device.DeviceUsage, policy string) float32 {
+	return 0
+}
+
+func (dev *MockDevices) AddResourceUsage(pod *corev1.Pod, n *device.DeviceUsage, ctr *device.ContainerDevice) error {
+	n.Used++
+	n.Usedcores += ctr.Usedcores
+	n.Usedmem += ctr.Usedmem
+	return nil
+}
+
+func (mock *MockDevices) Fit(devices []*device.DeviceUsage, request device.ContainerDeviceRequest, pod *corev1.Pod, nodeInfo *device.NodeInfo, allocated *device.PodDevices) (bool, map[string]device.ContainerDevices, string) {
+	k := request
+	originReq := k.Nums
+	klog.InfoS("Allocating device for container request", "pod", klog.KObj(pod), "card request", k)
+	tmpDevs := make(map[string]device.ContainerDevices)
+	reason := make(map[string]int)
+	for i := len(devices) - 1; i >= 0; i-- {
+		dev := devices[i]
+		klog.V(4).InfoS("scoring pod", "pod", klog.KObj(pod), "device", dev.ID, "Memreq", k.Memreq, "MemPercentagereq", k.MemPercentagereq, "Coresreq", k.Coresreq, "Nums", k.Nums, "device index", i)
+
+		if k.Type != MockDevice || !strings.Contains(dev.Type, MockDevice) {
+			reason[common.CardTypeMismatch]++
+			klog.V(5).InfoS(common.CardTypeMismatch, "pod", klog.KObj(pod), "device", dev.ID, dev.Type, k.Type)
+			continue
+		}
+		if !mock.checkUUID(pod.GetAnnotations(), *dev) {
+			reason[common.CardUUIDMismatch]++
+			klog.V(5).InfoS(common.CardUUIDMismatch, "pod", klog.KObj(pod), "device", dev.ID, "current device info is:", *dev)
+			continue
+		}
+		if dev.Count <= dev.Used {
+			reason[common.CardTimeSlicingExhausted]++
+			klog.V(5).InfoS(common.CardTimeSlicingExhausted, "pod", klog.KObj(pod), "device", dev.ID, "count", dev.Count, "used", dev.Used)
+			continue
+		}
+		if k.Coresreq > 100 {
+			klog.ErrorS(nil, "core limit can't exceed 100", "pod", klog.KObj(pod), "device", dev.ID)
+			k.Coresreq = 100
+		}
+		memreq := k.Memreq
+		if k.Memreq == 0 {
+			memreq = dev.Totalmem * k.MemPercentagereq / 100
+		}
+		if dev.Totalmem-dev.Usedmem < memreq {
+			reason[common.CardInsufficientMemory]++
+			klog.V(5).InfoS(common.CardInsufficientMemory, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total memory", dev.Totalmem, "device used memory", dev.Usedmem, "request memory", memreq)
+			continue
+		}
+		if dev.Totalcore-dev.Usedcores < k.Coresreq {
+			reason[common.CardInsufficientCore]++
+			klog.V(5).InfoS(common.CardInsufficientCore, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total core", dev.Totalcore, "device used core", dev.Usedcores, "request cores", k.Coresreq)
+			continue
+		}
+		// Coresreq=100 indicates it want this card exclusively
+		if dev.Totalcore == 100 && k.Coresreq == 100 && dev.Used > 0 {
+			reason[common.ExclusiveDeviceAllocateConflict]++
+			klog.V(5).InfoS(common.ExclusiveDeviceAllocateConflict, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "used", dev.Used)
+			continue
+		}
+		// You can't allocate core=0 job to an already full GPU
+		if dev.Totalcore != 0 && dev.Usedcores == dev.Totalcore && k.Coresreq == 0 {
+			reason[common.CardComputeUnitsExhausted]++
+			klog.V(5).InfoS(common.CardComputeUnitsExhausted, "pod", klog.KObj(pod), "device", dev.ID, "device index", i)
+			continue
+		}
+
+		if k.Nums > 0 {
+			klog.V(5).InfoS("find fit device", "pod", klog.KObj(pod), "device", dev.ID)
+			k.Nums--
+			tmpDevs[k.Type] = append(tmpDevs[k.Type], device.ContainerDevice{
+				Idx:       int(dev.Index),
+				UUID:      dev.ID,
+				Type:      k.Type,
+				Usedmem:   memreq,
+				Usedcores: k.Coresreq,
+			})
+		}
+		if k.Nums == 0 {
+			klog.V(4).InfoS("device allocate success", "pod", klog.KObj(pod), "allocate device", tmpDevs)
+			return true, tmpDevs, ""
+		}
+	}
+	if len(tmpDevs) > 0 {
+		reason[common.AllocatedCardsInsufficientRequest] = len(tmpDevs)
+		klog.V(5).InfoS(common.AllocatedCardsInsufficientRequest, "pod", klog.KObj(pod), "request", originReq, "allocated", len(tmpDevs))
+	}
+	return false, tmpDevs, common.GenReason(reason, len(devices))
+}
+
+func (dev *MockDevices) GetResourceNames() device.ResourceNames {
+	return device.ResourceNames{
+		ResourceCountName:  dev.resourceCountName,
+		ResourceMemoryName: dev.resourceMemoryName,
+		ResourceCoreName:   dev.resourceCoreName,
+	}
+}
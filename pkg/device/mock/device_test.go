@@ -0,0 +1,161 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mock
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+)
+
+func Test_GetNodeDevices(t *testing.T) {
+	dev := InitMockDevice(MockConfig{Enabled: true})
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Annotations: map[string]string{
+				RegisterAnnos: device.MarshalNodeDevices(MockConfig{DeviceCount: 3}.NodeDevices("node1")),
+			},
+		},
+	}
+	devices, err := dev.GetNodeDevices(node)
+	assert.NilError(t, err)
+	assert.Equal(t, len(devices), 3)
+	assert.Equal(t, devices[2].ID, "MOCK-node1-2")
+	assert.Equal(t, devices[2].Type, "Mock-GPU")
+	assert.Equal(t, devices[2].Count, int32(DefaultDeviceSplitCount))
+	assert.Equal(t, devices[2].Devmem, int32(DefaultDeviceMemory))
+	assert.Equal(t, devices[2].DeviceVendor, MockCommonWord)
+
+	_, err = dev.GetNodeDevices(corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}})
+	assert.ErrorContains(t, err, "annos not found")
+}
+
+func Test_GenerateResourceRequests(t *testing.T) {
+	dev := InitMockDevice(MockConfig{Enabled: true})
+	tests := []struct {
+		name   string
+		limits corev1.ResourceList
+		want   device.ContainerDeviceRequest
+	}{
+		{
+			name:   "no mock device",
+			limits: corev1.ResourceList{},
+			want:   device.ContainerDeviceRequest{},
+		},
+		{
+			name: "memory and cores",
+			limits: corev1.ResourceList{
+				DefaultResourceCountName:  resource.MustParse("2"),
+				DefaultResourceMemoryName: resource.MustParse("1024"),
+				DefaultResourceCoreName:   resource.MustParse("30"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 2, Type: MockDevice, Memreq: 1024, Coresreq: 30},
+		},
+		{
+			name: "whole memory",
+			limits: corev1.ResourceList{
+				DefaultResourceCountName: resource.MustParse("1"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: MockDevice, MemPercentagereq: 100},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctr := &corev1.Container{Resources: corev1.ResourceRequirements{Limits: test.limits}}
+			assert.DeepEqual(t, dev.GenerateResourceRequests(ctr), test.want)
+		})
+	}
+}
+
+func TestDevices_Fit(t *testing.T) {
+	dev := InitMockDevice(MockConfig{Enabled: true})
+	newDevices := func() []*device.DeviceUsage {
+		return []*device.DeviceUsage{
+			{ID: "MOCK-node1-0", Index: 0, Count: 10, Totalmem: 16384, Totalcore: 100, Type: "Mock-GPU", Health: true},
+			{ID: "MOCK-node1-1", Index: 1, Count: 10, Used: 1, Usedmem: 16000, Usedcores: 10, Totalmem: 16384, Totalcore: 100, Type: "Mock-GPU", Health: true},
+		}
+	}
+	tests := []struct {
+		name    string
+		request device.ContainerDeviceRequest
+		annos   map[string]string
+		fit     bool
+		uuids   []string
+		reason  string
+	}{
+		{
+			name:    "fits the device with enough memory",
+			request: device.ContainerDeviceRequest{Nums: 1, Type: MockDevice, Memreq: 1024, Coresreq: 30},
+			fit:     true,
+			uuids:   []string{"MOCK-node1-0"},
+		},
+		{
+			name:    "fits both devices",
+			request: device.ContainerDeviceRequest{Nums: 2, Type: MockDevice, Memreq: 256, Coresreq: 30},
+			fit:     true,
+			uuids:   []string{"MOCK-node1-1", "MOCK-node1-0"},
+		},
+		{
+			name:    "not enough memory",
+			request: device.ContainerDeviceRequest{Nums: 1, Type: MockDevice, Memreq: 20000},
+			reason:  "2/2 " + common.CardInsufficientMemory,
+		},
+		{
+			name:    "uuid excluded",
+			request: device.ContainerDeviceRequest{Nums: 1, Type: MockDevice, Memreq: 256},
+			annos:   map[string]string{MockNoUseUUID: "MOCK-node1-0,MOCK-node1-1"},
+			reason:  "2/2 " + common.CardUUIDMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default", Annotations: test.annos}}
+			fit, result, reason := dev.Fit(newDevices(), test.request, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, test.fit)
+			if !test.fit {
+				assert.Equal(t, reason, test.reason)
+				return
+			}
+			var uuids []string
+			for _, cd := range result[MockDevice] {
+				uuids = append(uuids, cd.UUID)
+				assert.Equal(t, cd.Usedcores, test.request.Coresreq)
+			}
+			assert.DeepEqual(t, uuids, test.uuids)
+		})
+	}
+}
+
+func Test_PatchAnnotations(t *testing.T) {
+	dev := InitMockDevice(MockConfig{Enabled: true})
+	annos := map[string]string{}
+	pd := device.PodDevices{
+		MockDevice: device.PodSingleDevice{
+			{{UUID: "MOCK-node1-0", Type: MockDevice, Usedmem: 1024, Usedcores: 30}},
+		},
+	}
+	dev.PatchAnnotations(&corev1.Pod{}, &annos, pd)
+	assert.Equal(t, annos["hami.io/mock-devices-allocated"], "MOCK-node1-0,Mock,1024,30:;")
+	assert.Equal(t, annos["hami.io/mock-devices-to-allocate"], "MOCK-node1-0,Mock,1024,30:;")
+}
@@ -17,7 +17,6 @@ limitations under the License.
 package mthreads
 
 import (
-	"errors"
 	"flag"
 	"fmt"
 	"slices"
@@ -87,30 +86,46 @@ func ParseConfig(fs *flag.FlagSet) {
 	fs.StringVar(&MthreadsResourceCores, "mthreads-cores", "mthreads.com/sgpu-core", "mthreads core resource")
 }
 
-func (dev *MthreadsDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *MthreadsDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	count, ok := ctr.Resources.Limits[corev1.ResourceName(MthreadsResourceCount)]
 	if ok {
 		if count.Value() > 1 {
-			ctr.Resources.Limits[corev1.ResourceName(MthreadsResourceCores)] = *resource.NewQuantity(count.Value()*int64(coresPerMthreadsGPU), resource.DecimalSI)
-			ctr.Resources.Limits[corev1.ResourceName(MthreadsResourceMemory)] = *resource.NewQuantity(count.Value()*int64(memoryPerMthreadsGPU), resource.DecimalSI)
+			setWholeDevices(ctr, count.Value(), warnings)
 			p.Annotations["mthreads.com/request-gpu-num"] = fmt.Sprint(count.Value())
 			return ok, nil
 		}
 		mem, memok := ctr.Resources.Limits[corev1.ResourceName(MthreadsResourceMemory)]
 		if !memok {
-			ctr.Resources.Limits[corev1.ResourceName(MthreadsResourceCores)] = *resource.NewQuantity(count.Value()*int64(coresPerMthreadsGPU), resource.DecimalSI)
-			ctr.Resources.Limits[corev1.ResourceName(MthreadsResourceMemory)] = *resource.NewQuantity(count.Value()*int64(memoryPerMthreadsGPU), resource.DecimalSI)
+			setWholeDevices(ctr, count.Value(), warnings)
 		} else {
 			memnum, _ := mem.AsInt64()
 			found := slices.Contains(legalMemoryslices, memnum)
 			if !found {
-				return true, errors.New("sGPU memory request value is invalid, valid values are [1, 2, 4, 8, 16, 32, 64, 96]")
+				return true, device.InvalidRequestErrorf("sGPU memory request value is invalid, valid values are [1, 2, 4, 8, 16, 32, 64, 96]")
 			}
 		}
 	}
 	return ok, nil
 }
 
+// setWholeDevices sets the cores and memory of the container to those of count whole GPUs.
+func setWholeDevices(ctr *corev1.Container, count int64, warnings *device.AdmissionWarnings) {
+	for _, r := range []struct {
+		name  string
+		value int64
+	}{
+		{MthreadsResourceCores, count * int64(coresPerMthreadsGPU)},
+		{MthreadsResourceMemory, count * int64(memoryPerMthreadsGPU)},
+	} {
+		if requested, ok := ctr.Resources.Limits[corev1.ResourceName(r.name)]; ok {
+			warnings.Adjusted(ctr.Name, r.name, requested.Value(), r.value)
+		} else {
+			warnings.Defaulted(ctr.Name, r.name, r.value)
+		}
+		ctr.Resources.Limits[corev1.ResourceName(r.name)] = *resource.NewQuantity(r.value, resource.DecimalSI)
+	}
+}
+
 func (dev *MthreadsDevices) GetNodeDevices(n corev1.Node) ([]*device.DeviceInfo, error) {
 	nodedevices := []*device.DeviceInfo{}
 	i := 0
@@ -172,7 +172,7 @@ func Test_MutateAdmission(t *testing.T) {
 			}
 			InitMthreadsDevice(config)
 			dev := MthreadsDevices{}
-			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p)
+			result, _ := dev.MutateAdmission(test.args.ctr, test.args.p, nil)
 			assert.Equal(t, result, test.want)
 		})
 	}
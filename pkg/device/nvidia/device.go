@@ -20,9 +20,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	spec "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -44,17 +46,43 @@ const (
 	GPUNoUse             = "nvidia.com/nouse-gputype"
 	NumaBind             = "nvidia.com/numa-bind"
 	NodeLockNvidia       = "hami.io/mutex.lock"
+	// StaticMIGTypeMarker separates the model and profile in the type of the MIG instances created outside of
+	// HAMi, e.g. NVIDIA A100-MIG-2g.20gb. They only match the types of nvidia.com/use-gputype naming MIG.
+	StaticMIGTypeMarker = "-MIG-"
 	// GPUUseUUID is user can use specify GPU device for set GPU UUID.
 	GPUUseUUID = "nvidia.com/use-gpuuuid"
 	// GPUNoUseUUID is user can not use specify GPU device for set GPU UUID.
 	GPUNoUseUUID = "nvidia.com/nouse-gpuuuid"
 	AllocateMode = "nvidia.com/vgpu-mode"
+	// GPUWeight is user set Pod annotation of the weight of the pod, from MinGPUWeight to MaxGPUWeight, when HAMi-core
+	// time-shares a GPU between runnable pods.
+	GPUWeight = "nvidia.com/gpu-weight"
+	// GPUWeightAllocated records the weight of the pod at assignment, GPUWeight or the default weight.
+	GPUWeightAllocated = "hami.io/vgpu-weight-allocated"
+	MinGPUWeight       = 1
+	MaxGPUWeight       = 100
 
 	MigMode      = "mig"
 	HamiCoreMode = "hami-core"
 	MpsMode      = "mps"
 )
 
+// Environment variables the device plugin sets in containers allocated shared GPUs, for HAMi-core to enforce
+// the limits. The limits of the GPUs of a container are suffixed by the index of the GPU.
+const (
+	DeviceMemoryLimitEnv       = "CUDA_DEVICE_MEMORY_LIMIT"
+	DeviceSMLimitEnv           = "CUDA_DEVICE_SM_LIMIT"
+	DeviceMemorySharedCacheEnv = "CUDA_DEVICE_MEMORY_SHARED_CACHE"
+	OversubscribeEnv           = "CUDA_OVERSUBSCRIBE"
+	TaskWeightEnv              = "CUDA_TASK_WEIGHT"
+	// VisibleDevicesEnv lists the GPUs the NVIDIA container runtime exposes to a container.
+	VisibleDevicesEnv = "NVIDIA_VISIBLE_DEVICES"
+)
+
+// OwnedEnvs are the environment variables containers allocated GPUs must not set: the ones set by the device
+// plugin, and LD_PRELOAD which could preload a library bypassing HAMi-core.
+var OwnedEnvs = []string{DeviceMemoryLimitEnv, DeviceSMLimitEnv, DeviceMemorySharedCacheEnv, OversubscribeEnv, TaskWeightEnv, "LD_PRELOAD"}
+
 var (
 	NodeName          string
 	RuntimeSocketFlag string
@@ -62,6 +90,8 @@ var (
 
 	// DevicePluginFilterDevice need device-plugin filter this device, don't register this device.
 	DevicePluginFilterDevice *FilterDevice
+	// DevicePluginDevicePools are the device pools of the devices the device-plugin registers.
+	DevicePluginDevicePools []DevicePool
 )
 
 type MigPartedSpec struct {
@@ -117,6 +147,39 @@ type NvidiaConfig struct {
 	GPUCorePolicy GPUCoreUtilizationPolicy `yaml:"gpuCorePolicy"`
 	// RuntimeClassName is the name of the runtime class to be added to pod.spec.runtimeClassName
 	RuntimeClassName string `yaml:"runtimeClassName"`
+	// ResourceEncoderName is the resource of the encode/decode engine sessions of each GPU, e.g. hami.io/nvenc.
+	// They are allocated separately from the cores, so transcoding can share a GPU with compute.
+	ResourceEncoderName string `yaml:"resourceEncoderName"`
+	// EncoderEngines is the number of encode/decode engine sessions of each GPU not reporting its own.
+	EncoderEngines int32 `yaml:"encoderEngines"`
+	// DefaultGPUWeight is the weight of pods not setting GPUWeight, none if 0 and HAMi-core weighs them alike.
+	DefaultGPUWeight int32 `yaml:"defaultGPUWeight"`
+	// ComputeOnlyMemory is the memory in MB allocated to compute-only containers, which request cores with a memory
+	// of 0, e.g. for their CUDA context. Memories of 0 are treated as unset if 0.
+	ComputeOnlyMemory int32 `yaml:"computeOnlyMemory"`
+	// MinAutoCores and MaxAutoCores bound the cores in percent the webhook and scheduler assign to containers not
+	// requesting them, e.g. DefaultCores or the 100 cores of whole GPUs. Unbounded if 0.
+	MinAutoCores int32 `yaml:"minAutoCores"`
+	MaxAutoCores int32 `yaml:"maxAutoCores"`
+	// IndependentCoresAndMemory makes the scheduler allocate the cores and memory of GPUs independently, so pods
+	// only fail to fit a GPU once the cores or the memory they request are exhausted: 100 cores don't reserve the
+	// GPU exclusively, and requests of 0 cores fit GPUs whose cores are all allocated.
+	IndependentCoresAndMemory bool `yaml:"independentCoresAndMemory"`
+	// Prewarm makes the device plugin prepare the GPUs assigned to pods before the kubelet allocates them.
+	Prewarm PrewarmConfig `yaml:"prewarm"`
+}
+
+// PrewarmConfig configures the preparation of the GPUs assigned to a pod by the device plugin, as soon as the
+// scheduler starts binding the pod, to cut the startup of pods on idle GPUs.
+type PrewarmConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Command is run with the GPUs visible by CUDA_VISIBLE_DEVICES, e.g. a tiny program creating a CUDA context.
+	// None if empty.
+	Command []string `yaml:"command"`
+	// Timeout of Command, 10s if 0.
+	Timeout time.Duration `yaml:"timeout"`
+	// TTL after which the preparations for pods never allocated are removed, 5m if 0.
+	TTL time.Duration `yaml:"ttl"`
 }
 
 // These configs can be specified for each node by using Nodeconfig.
@@ -135,6 +198,15 @@ type FilterDevice struct {
 	Index []uint `json:"index"`
 }
 
+// DevicePool names the devices of a node in the pool, pods select the pool by util.DevicePoolAnnotationKey.
+type DevicePool struct {
+	Name string `json:"name"`
+	// UUID are the IDs of the devices in the pool.
+	UUID []string `json:"uuid"`
+	// Index are the indexes of the devices in the pool.
+	Index []uint `json:"index"`
+}
+
 type DevicePluginConfigs struct {
 	Nodeconfig []struct {
 		// These configs is shared and will overwrite those in NvidiaConfig.
@@ -143,6 +215,7 @@ type DevicePluginConfigs struct {
 		OperatingMode     string        `json:"operatingmode"`
 		Migstrategy       string        `json:"migstrategy"`
 		FilterDevice      *FilterDevice `json:"filterdevices"`
+		DevicePools       []DevicePool  `json:"devicepools"`
 	} `json:"nodeconfig"`
 }
 
@@ -165,6 +238,7 @@ func InitNvidiaDevice(nvconfig NvidiaConfig) *NvidiaGPUDevices {
 		device.InRequestDevices[NvidiaGPUDevice] = "hami.io/vgpu-devices-to-allocate"
 		device.SupportDevices[NvidiaGPUDevice] = "hami.io/vgpu-devices-allocated"
 		util.HandshakeAnnos[NvidiaGPUDevice] = HandshakeAnnos
+		util.RegisterAnnos[NvidiaGPUDevice] = RegisterAnnos
 	}
 	return &NvidiaGPUDevices{
 		config:         nvconfig,
@@ -208,6 +282,53 @@ func FilterDeviceToRegister(uuid, indexStr string) bool {
 	return false
 }
 
+// DevicePoolOf returns the name of the first device pool the device of uuid or index is in, empty if none.
+func DevicePoolOf(uuid string, index uint) string {
+	for _, pool := range DevicePluginDevicePools {
+		if slices.Contains(pool.UUID, uuid) || slices.Contains(pool.Index, index) {
+			return pool.Name
+		}
+	}
+	return ""
+}
+
+// ValidateDevicePools checks the device pools are named, and don't share devices.
+func ValidateDevicePools(pools []DevicePool) error {
+	uuids, indexes := make(map[string]string), make(map[uint]string)
+	for _, pool := range pools {
+		if strings.TrimSpace(pool.Name) == "" {
+			return errors.New("device pool without name")
+		}
+		for _, uuid := range pool.UUID {
+			if other, ok := uuids[uuid]; ok && other != pool.Name {
+				return fmt.Errorf("device %s is in device pools %s and %s", uuid, other, pool.Name)
+			}
+			uuids[uuid] = pool.Name
+		}
+		for _, index := range pool.Index {
+			if other, ok := indexes[index]; ok && other != pool.Name {
+				return fmt.Errorf("device %d is in device pools %s and %s", index, other, pool.Name)
+			}
+			indexes[index] = pool.Name
+		}
+	}
+	return nil
+}
+
+// ValidateAutoCores checks MinAutoCores and MaxAutoCores are percentages, and MinAutoCores isn't above MaxAutoCores.
+func (c NvidiaConfig) ValidateAutoCores() error {
+	if c.MinAutoCores < 0 || c.MinAutoCores > 100 {
+		return fmt.Errorf("minAutoCores %d must be from 0 to 100", c.MinAutoCores)
+	}
+	if c.MaxAutoCores < 0 || c.MaxAutoCores > 100 {
+		return fmt.Errorf("maxAutoCores %d must be from 0 to 100", c.MaxAutoCores)
+	}
+	if c.MinAutoCores > 0 && c.MaxAutoCores > 0 && c.MinAutoCores > c.MaxAutoCores {
+		return fmt.Errorf("minAutoCores %d must not be above maxAutoCores %d", c.MinAutoCores, c.MaxAutoCores)
+	}
+	return nil
+}
+
 func (dev *NvidiaGPUDevices) NodeCleanUp(nn string) error {
 	return util.MarkAnnotationsToDelete(HandshakeAnnos, nn)
 }
@@ -281,6 +402,9 @@ func (dev *NvidiaGPUDevices) GetNodeDevices(n corev1.Node) ([]*device.DeviceInfo
 		nodedevices[idx].DeviceVendor = dev.CommonWord()
 	}
 	for _, val := range nodedevices {
+		if val.Engines == 0 && val.Mode != MigMode && dev.config.ResourceEncoderName != "" {
+			val.Engines = dev.config.EncoderEngines
+		}
 		if val.Mode == MigMode {
 			val.MIGTemplate = make([]device.Geometry, 0)
 			for _, migTemplates := range dev.config.MigGeometriesList {
@@ -327,65 +451,182 @@ func (dev *NvidiaGPUDevices) GetNodeDevices(n corev1.Node) ([]*device.DeviceInfo
 	return nodedevices, nil
 }
 
-func (dev *NvidiaGPUDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod) (bool, error) {
+func (dev *NvidiaGPUDevices) MutateAdmission(ctr *corev1.Container, p *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
 	/*gpu related */
 	priority, ok := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourcePriority)]
 	if ok {
-		ctr.Env = append(ctr.Env, corev1.EnvVar{
-			Name:  util.TaskPriority,
-			Value: fmt.Sprint(priority.Value()),
-		})
+		util.SetContainerEnv(ctr, util.TaskPriority, fmt.Sprint(priority.Value()))
 	}
 
 	if dev.config.GPUCorePolicy != "" &&
 		dev.config.GPUCorePolicy != DefaultCorePolicy {
-		ctr.Env = append(ctr.Env, corev1.EnvVar{
-			Name:  util.CoreLimitSwitch,
-			Value: string(dev.config.GPUCorePolicy),
-		})
+		util.SetContainerEnv(ctr, util.CoreLimitSwitch, string(dev.config.GPUCorePolicy))
+	}
+
+	// The pod level GPU request is a device request itself, the webhook can't tell the pod requests devices if it's
+	// invalid.
+	req, err := util.GetPodGPURequest(p)
+	if err != nil {
+		return false, device.InvalidRequestErrorf("%v", err)
+	}
+	if req != nil {
+		if slices.Contains(req.Sidecars, ctr.Name) {
+			return false, dev.mutatePodGPUSidecar(ctr)
+		}
+		if ctr.Name == req.Container {
+			if err := dev.mutatePodGPUContainer(ctr, req); err != nil {
+				return false, err
+			}
+		}
 	}
 
-	hasResource := dev.mutateContainerResource(ctr)
-	if dev.defaultExclusiveCoreIfNeeded(ctr) {
-		hasResource = true
+	mode, _ := util.GetComputeMode(p)
+	hasResource := false
+	// An explicit zero device count is a no-op request, the container does not use GPU.
+	if count, ok := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceCountName)); !ok || count > 0 {
+		hasResource = dev.mutateContainerResource(ctr, warnings)
+		// MPS pods share the GPU, they must not take all the cores by default.
+		if mode != util.ComputeModeMPS && dev.defaultExclusiveCoreIfNeeded(ctr, warnings) {
+			hasResource = true
+		}
+	} else {
+		for _, name := range []string{dev.config.ResourceMemoryName, dev.config.ResourceMemoryPercentageName, dev.config.ResourceCoreName, dev.config.ResourceEncoderName} {
+			if resourceNonZero(ctr, corev1.ResourceName(name)) {
+				return false, device.InvalidRequestErrorf("%s is requested while %s is 0, remove it or request at least one GPU", name, dev.config.ResourceCountName)
+			}
+		}
 	}
 
 	if hasResource {
+		if _, err := dev.gpuWeight(p); err != nil {
+			return false, err
+		}
+		for _, key := range []string{GPUInUse, GPUNoUse} {
+			if value, ok := p.Annotations[key]; ok {
+				if _, err := device.ParseTypePatterns(value); err != nil {
+					return false, device.InvalidRequestErrorf("invalid %s annotation %q: %v", key, value, err)
+				}
+			}
+		}
+		if engines, _ := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceEncoderName)); dev.config.EncoderEngines > 0 && engines > int64(dev.config.EncoderEngines) {
+			return false, device.InvalidRequestErrorf("container %s requests %d %s of each GPU, but a GPU has %d", ctr.Name, engines, dev.config.ResourceEncoderName, dev.config.EncoderEngines)
+		}
+		if memEach, _ := util.GetGPUMemoryEach(p); memEach != nil {
+			if count, _ := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceCountName)); count != int64(len(memEach)) {
+				return false, device.InvalidRequestErrorf("%s sets the memory of %d GPUs, but container %s requests %d GPUs", util.GPUMemoryEachAnnotationKey, len(memEach), ctr.Name, count)
+			}
+		}
+		if shared, _ := util.GetSharedGPU(p); shared != nil {
+			if memEach, _ := util.GetGPUMemoryEach(p); memEach != nil {
+				return false, device.InvalidRequestErrorf("%s and %s can't be set together", util.SharedGPUAnnotationKey, util.GPUMemoryEachAnnotationKey)
+			}
+		}
+		dev.copyLimitsToRequests(ctr)
+		if mode != util.ComputeModeDefault {
+			util.SetContainerEnv(ctr, util.ComputeModeEnv, string(mode))
+		}
+		if isolation, _ := util.GetIsolationLevel(p); isolation != util.IsolationStrict {
+			util.SetContainerEnv(ctr, util.IsolationEnv, string(isolation))
+		}
 		// Set runtime class name if it is not set by user and the runtime class name is configured
 		if p.Spec.RuntimeClassName == nil && dev.config.RuntimeClassName != "" {
 			p.Spec.RuntimeClassName = &dev.config.RuntimeClassName
+			warnings.Addf("runtimeClassName is not set, defaulted to %s", dev.config.RuntimeClassName)
 		}
 	}
 
 	if !hasResource && dev.config.OverwriteEnv {
-		ctr.Env = append(ctr.Env, corev1.EnvVar{
-			Name:  "NVIDIA_VISIBLE_DEVICES",
-			Value: "none",
-		})
+		util.SetContainerEnv(ctr, VisibleDevicesEnv, "none")
 	}
 	return hasResource, nil
 }
 
-func (dev *NvidiaGPUDevices) mutateContainerResource(ctr *corev1.Container) bool {
-	_, resourceNameOK := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCountName)]
-	if resourceNameOK {
-		return true
+// mutatePodGPUContainer sets the limits of the container the pod level GPU request is attributed to, the request is
+// then handled and accounted like one of the container.
+func (dev *NvidiaGPUDevices) mutatePodGPUContainer(ctr *corev1.Container, req *util.PodGPURequest) error {
+	if name, ok := dev.requestedResource(ctr); ok {
+		return device.InvalidRequestErrorf("container %s requests %s, but the pod level GPU request is attributed to it", ctr.Name, name)
 	}
+	if ctr.Resources.Limits == nil {
+		ctr.Resources.Limits = corev1.ResourceList{}
+	}
+	ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCountName)] = *resource.NewQuantity(req.Count, resource.BinarySI)
+	if req.Mem > 0 && dev.config.ResourceMemoryName != "" {
+		ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceMemoryName)] = *resource.NewQuantity(req.Mem, resource.BinarySI)
+	}
+	if req.Cores > 0 && dev.config.ResourceCoreName != "" {
+		ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCoreName)] = *resource.NewQuantity(req.Cores, resource.BinarySI)
+	}
+	return nil
+}
 
-	_, resourceCoresOK := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCoreName)]
-	_, resourceMemOK := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceMemoryName)]
-	_, resourceMemPercentageOK := ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceMemoryPercentageName)]
+// mutatePodGPUSidecar makes the pod level GPUs visible to a sidecar, by the annotation of their UUIDs the scheduler
+// sets on assignment. The kubelet doesn't call the device plugin for containers without device requests, so the env
+// is resolved by the downward API instead. The sidecar isn't limited by HAMi-core, nor accounted.
+func (dev *NvidiaGPUDevices) mutatePodGPUSidecar(ctr *corev1.Container) error {
+	if name, ok := dev.requestedResource(ctr); ok {
+		return device.InvalidRequestErrorf("container %s requests %s, but it's a sidecar of the pod level GPU request", ctr.Name, name)
+	}
+	env := ctr.Env[:0]
+	for _, e := range ctr.Env {
+		if e.Name != VisibleDevicesEnv {
+			env = append(env, e)
+		}
+	}
+	ctr.Env = append(env, corev1.EnvVar{Name: VisibleDevicesEnv, ValueFrom: &corev1.EnvVarSource{
+		FieldRef: &corev1.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.annotations['%s']", util.PodVisibleDevicesAnnotationKey)},
+	}})
+	return nil
+}
 
-	if resourceCoresOK || resourceMemOK || resourceMemPercentageOK {
+// requestedResource returns the first GPU resource the container requests, if any.
+func (dev *NvidiaGPUDevices) requestedResource(ctr *corev1.Container) (string, bool) {
+	for _, name := range []string{dev.config.ResourceCountName, dev.config.ResourceMemoryName, dev.config.ResourceMemoryPercentageName, dev.config.ResourceCoreName, dev.config.ResourceEncoderName} {
+		if name != "" && resourcePresent(ctr, corev1.ResourceName(name)) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// gpuWeight returns the weight of the pod set by GPUWeight, or the default weight, 0 if none. The weight only
+// orders runnable pods in HAMi-core, it doesn't change what fits a GPU.
+func (dev *NvidiaGPUDevices) gpuWeight(p *corev1.Pod) (int32, error) {
+	val, ok := p.Annotations[GPUWeight]
+	if !ok {
+		return dev.config.DefaultGPUWeight, nil
+	}
+	weight, err := strconv.ParseInt(strings.TrimSpace(val), 10, 32)
+	if err != nil || weight < MinGPUWeight || weight > MaxGPUWeight {
+		return 0, device.InvalidRequestErrorf("invalid %s annotation %q, must be an integer from %d to %d", GPUWeight, val, MinGPUWeight, MaxGPUWeight)
+	}
+	return int32(weight), nil
+}
+
+func (dev *NvidiaGPUDevices) mutateContainerResource(ctr *corev1.Container, warnings *device.AdmissionWarnings) bool {
+	if resourcePresent(ctr, corev1.ResourceName(dev.config.ResourceCountName)) {
+		return true
+	}
+
+	// Zero quantities don't request a GPU, e.g. from templated manifests.
+	limitSet := func(name string) bool {
+		qty, ok := ctr.Resources.Limits[corev1.ResourceName(name)]
+		return ok && !qty.IsZero()
+	}
+	if limitSet(dev.config.ResourceCoreName) || limitSet(dev.config.ResourceMemoryName) || limitSet(dev.config.ResourceMemoryPercentageName) || limitSet(dev.config.ResourceEncoderName) {
 		if dev.config.DefaultGPUNum > 0 {
+			if ctr.Resources.Limits == nil {
+				ctr.Resources.Limits = corev1.ResourceList{}
+			}
 			ctr.Resources.Limits[corev1.ResourceName(dev.config.ResourceCountName)] = *resource.NewQuantity(int64(dev.config.DefaultGPUNum), resource.BinarySI)
+			warnings.Defaulted(ctr.Name, dev.config.ResourceCountName, int64(dev.config.DefaultGPUNum))
 			return true
 		}
 	}
 	return false
 }
 
-func (dev *NvidiaGPUDevices) defaultExclusiveCoreIfNeeded(ctr *corev1.Container) bool {
+func (dev *NvidiaGPUDevices) defaultExclusiveCoreIfNeeded(ctr *corev1.Container, warnings *device.AdmissionWarnings) bool {
 	if ctr == nil {
 		return false
 	}
@@ -396,16 +637,20 @@ func (dev *NvidiaGPUDevices) defaultExclusiveCoreIfNeeded(ctr *corev1.Container)
 	}
 
 	coreName := corev1.ResourceName(dev.config.ResourceCoreName)
-	if coreName == "" || resourcePresent(ctr, coreName) {
+	if coreName == "" || resourceNonZero(ctr, coreName) {
+		return false
+	}
+	// Encode/decode engine requests don't need the cores, e.g. transcoding.
+	if resourceNonZero(ctr, corev1.ResourceName(dev.config.ResourceEncoderName)) {
 		return false
 	}
 
 	exclusive := false
-	if pct, ok := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceMemoryPercentageName)); ok {
+	if pct, ok := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceMemoryPercentageName)); ok && pct != 0 {
 		exclusive = pct == 100
 	} else if dev.config.ResourceMemoryName == "" {
 		exclusive = true
-	} else if _, ok := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceMemoryName)); !ok {
+	} else if !resourceNonZero(ctr, corev1.ResourceName(dev.config.ResourceMemoryName)) {
 		exclusive = true
 	}
 
@@ -416,10 +661,63 @@ func (dev *NvidiaGPUDevices) defaultExclusiveCoreIfNeeded(ctr *corev1.Container)
 	if ctr.Resources.Limits == nil {
 		ctr.Resources.Limits = corev1.ResourceList{}
 	}
-	ctr.Resources.Limits[coreName] = *resource.NewQuantity(100, resource.DecimalSI)
+	cores := dev.autoCores(100)
+	ctr.Resources.Limits[coreName] = *resource.NewQuantity(int64(cores), resource.DecimalSI)
+	warnings.Defaulted(ctr.Name, string(coreName), int64(cores))
 	return true
 }
 
+// autoCores returns cores bounded by MinAutoCores and MaxAutoCores.
+func (dev *NvidiaGPUDevices) autoCores(cores int32) int32 {
+	if dev.config.MinAutoCores > 0 && cores < dev.config.MinAutoCores {
+		cores = dev.config.MinAutoCores
+	}
+	if dev.config.MaxAutoCores > 0 && cores > dev.config.MaxAutoCores {
+		cores = dev.config.MaxAutoCores
+	}
+	return cores
+}
+
+// copyLimitsToRequests fills requests of gpu resources which are only set in limits,
+// as kubernetes does for extended resources. Resources defaulted by the webhook
+// are only written to limits, so they need to be copied here.
+func (dev *NvidiaGPUDevices) copyLimitsToRequests(ctr *corev1.Container) {
+	names := []string{
+		dev.config.ResourceCountName,
+		dev.config.ResourceMemoryName,
+		dev.config.ResourceMemoryPercentageName,
+		dev.config.ResourceCoreName,
+		dev.config.ResourceEncoderName,
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		qty, ok := ctr.Resources.Limits[corev1.ResourceName(name)]
+		if !ok {
+			continue
+		}
+		if _, ok := ctr.Resources.Requests[corev1.ResourceName(name)]; ok {
+			continue
+		}
+		if ctr.Resources.Requests == nil {
+			ctr.Resources.Requests = corev1.ResourceList{}
+		}
+		ctr.Resources.Requests[corev1.ResourceName(name)] = qty.DeepCopy()
+	}
+}
+
+// isComputeOnly reports whether the container requests a memory of 0 explicitly with cores, it's then allocated
+// ComputeOnlyMemory instead of the default memory.
+func (dev *NvidiaGPUDevices) isComputeOnly(ctr *corev1.Container) bool {
+	if dev.config.ComputeOnlyMemory <= 0 {
+		return false
+	}
+	mem, ok := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceMemoryName))
+	return ok && mem == 0 && !resourceNonZero(ctr, corev1.ResourceName(dev.config.ResourceMemoryPercentageName)) &&
+		resourceNonZero(ctr, corev1.ResourceName(dev.config.ResourceCoreName))
+}
+
 func resourceValue(ctr *corev1.Container, name corev1.ResourceName) (int64, bool) {
 	if name == "" || ctr == nil {
 		return 0, false
@@ -433,6 +731,12 @@ func resourceValue(ctr *corev1.Container, name corev1.ResourceName) (int64, bool
 	return 0, false
 }
 
+// resourceNonZero reports whether the resource is set to a nonzero quantity for the container.
+func resourceNonZero(ctr *corev1.Container, name corev1.ResourceName) bool {
+	v, ok := resourceValue(ctr, name)
+	return ok && v != 0
+}
+
 func resourcePresent(ctr *corev1.Container, name corev1.ResourceName) bool {
 	if ctr == nil || name == "" {
 		return false
@@ -446,25 +750,35 @@ func resourcePresent(ctr *corev1.Container, name corev1.ResourceName) bool {
 	return false
 }
 
+// checkGPUtype reports whether the GPU type is allowed by the use-gputype and nouse-gputype annotations, see
+// device.TypePattern for the syntax of their entries. Static MIG instances only match the entries naming MIG.
 func checkGPUtype(annos map[string]string, cardtype string) bool {
-	cardtype = strings.ToUpper(cardtype)
-	if inuse, ok := annos[GPUInUse]; ok {
-		useTypes := strings.Split(inuse, ",")
-		if !slices.ContainsFunc(useTypes, func(useType string) bool {
-			return strings.Contains(cardtype, strings.ToUpper(useType))
+	isMIG := strings.Contains(strings.ToUpper(cardtype), StaticMIGTypeMarker)
+	if useTypes := gpuTypePatterns(annos, GPUInUse); useTypes != nil {
+		if !slices.ContainsFunc(useTypes, func(useType device.TypePattern) bool {
+			return useType.Matches(cardtype) && (!isMIG || strings.Contains(strings.ToUpper(useType.String()), "MIG"))
 		}) {
 			return false
 		}
 	}
-	if unuse, ok := annos[GPUNoUse]; ok {
-		unuseTypes := strings.Split(unuse, ",")
-		if slices.ContainsFunc(unuseTypes, func(unuseType string) bool {
-			return strings.Contains(cardtype, strings.ToUpper(unuseType))
-		}) {
-			return false
+	return !device.MatchesAnyType(gpuTypePatterns(annos, GPUNoUse), cardtype)
+}
+
+// gpuTypePatterns returns the valid entries of the GPU type annotation, nil if it's not set or empty. Invalid
+// entries never match, an annotation with only invalid entries matches no GPU.
+func gpuTypePatterns(annos map[string]string, key string) []device.TypePattern {
+	value, ok := annos[key]
+	if !ok {
+		return nil
+	}
+	patterns, err := device.ParseTypePatterns(value)
+	if err != nil {
+		klog.V(5).InfoS("Ignoring invalid GPU type entries", "annotation", key, "err", err)
+		if patterns == nil {
+			patterns = []device.TypePattern{}
 		}
 	}
-	return true
+	return patterns
 }
 
 func assertNuma(annos map[string]string) bool {
@@ -490,38 +804,83 @@ func (dev *NvidiaGPUDevices) checkType(annos map[string]string, d device.DeviceU
 	return false, false
 }
 
-func (dev *NvidiaGPUDevices) checkUUID(annos map[string]string, d device.DeviceUsage) bool {
-	userUUID, ok := annos[GPUUseUUID]
-	if ok {
-		klog.V(5).Infof("check uuid for nvidia user uuid [%s], device id is %s", userUUID, d.ID)
-		// use , symbol to connect multiple uuid
-		userUUIDs := strings.Split(userUUID, ",")
-		return slices.Contains(userUUIDs, d.ID)
-	}
+// uuidFilter holds the parsed use-gpuuuid and nouse-gpuuuid annotations of a pod.
+type uuidFilter struct {
+	use   *device.UUIDSet
+	noUse *device.UUIDSet
+}
 
-	noUserUUID, ok := annos[GPUNoUseUUID]
-	if ok {
-		klog.V(5).Infof("check uuid for nvidia not user uuid [%s], device id is %s", noUserUUID, d.ID)
-		// use , symbol to connect multiple uuid
-		noUserUUIDs := strings.Split(noUserUUID, ",")
-		return !slices.Contains(noUserUUIDs, d.ID)
+func newUUIDFilter(annos map[string]string) uuidFilter {
+	filter := uuidFilter{}
+	// use , symbol to connect multiple uuid
+	if userUUID, ok := annos[GPUUseUUID]; ok {
+		filter.use = device.ParseUUIDSet(userUUID)
+	}
+	if noUserUUID, ok := annos[GPUNoUseUUID]; ok {
+		filter.noUse = device.ParseUUIDSet(noUserUUID)
 	}
+	return filter
+}
 
+func (dev *NvidiaGPUDevices) checkUUID(filter uuidFilter, d device.DeviceUsage) bool {
+	if filter.use != nil {
+		klog.V(5).Infof("check uuid for nvidia user uuid count [%d], device id is %s", filter.use.Len(), d.ID)
+		return filter.use.Contains(d.ID)
+	}
+	if filter.noUse != nil {
+		klog.V(5).Infof("check uuid for nvidia not user uuid count [%d], device id is %s", filter.noUse.Len(), d.ID)
+		return !filter.noUse.Contains(d.ID)
+	}
 	return true
 }
 
 func (dev *NvidiaGPUDevices) PatchAnnotations(pod *corev1.Pod, annoinput *map[string]string, pd device.PodDevices) map[string]string {
 	devlist, ok := pd[NvidiaGPUDevice]
 	if ok && len(devlist) > 0 {
-		deviceStr := device.EncodePodSingleDevice(devlist)
+		deviceStr := device.EncodePodSingleDevice(withOOMModes(pod, devlist))
 		(*annoinput)[device.InRequestDevices[NvidiaGPUDevice]] = deviceStr
 		(*annoinput)[device.SupportDevices[NvidiaGPUDevice]] = deviceStr
 		klog.V(5).Infof("pod add notation key [%s], values is [%s]", device.InRequestDevices[NvidiaGPUDevice], deviceStr)
 		klog.V(5).Infof("pod add notation key [%s], values is [%s]", device.SupportDevices[NvidiaGPUDevice], deviceStr)
+		if weight, err := dev.gpuWeight(pod); err == nil && weight > 0 {
+			(*annoinput)[GPUWeightAllocated] = strconv.Itoa(int(weight))
+		}
+		if req, err := util.GetPodGPURequest(pod); err == nil && req != nil && len(req.Sidecars) > 0 {
+			idx := slices.IndexFunc(pod.Spec.Containers, func(ctr corev1.Container) bool { return ctr.Name == req.Container })
+			if idx >= 0 && idx < len(devlist) {
+				uuids := make([]string, 0, len(devlist[idx]))
+				for _, d := range devlist[idx] {
+					uuids = append(uuids, d.UUID)
+				}
+				(*annoinput)[util.PodVisibleDevicesAnnotationKey] = strings.Join(uuids, ",")
+			}
+		}
 	}
 	return *annoinput
 }
 
+// withOOMModes returns devlist with the devices of the containers in util.OOMModeSoft marked so, for the device
+// plugin to enforce their memory limit softly. The memory allocated is the same in either mode.
+func withOOMModes(pod *corev1.Pod, devlist device.PodSingleDevice) device.PodSingleDevice {
+	modes, err := util.GetOOMModes(pod)
+	if err != nil {
+		klog.ErrorS(err, "Ignoring OOM mode", "pod", klog.KObj(pod))
+		return devlist
+	}
+	res := make(device.PodSingleDevice, len(devlist))
+	for idx, ctrdevs := range devlist {
+		res[idx] = ctrdevs
+		if idx >= len(pod.Spec.Containers) || modes[pod.Spec.Containers[idx].Name] != util.OOMModeSoft {
+			continue
+		}
+		res[idx] = slices.Clone(ctrdevs)
+		for i := range res[idx] {
+			res[idx][i].OOMMode = util.OOMModeSoft
+		}
+	}
+	return res
+}
+
 func (dev *NvidiaGPUDevices) GenerateResourceRequests(ctr *corev1.Container) device.ContainerDeviceRequest {
 	resourceName := corev1.ResourceName(dev.config.ResourceCountName)
 	resourceMem := corev1.ResourceName(dev.config.ResourceMemoryName)
@@ -532,7 +891,8 @@ func (dev *NvidiaGPUDevices) GenerateResourceRequests(ctr *corev1.Container) dev
 		v, ok = ctr.Resources.Requests[resourceName]
 	}
 	if ok {
-		if n, ok := v.AsInt64(); ok {
+		// Zero quantities are the same as unset.
+		if n, ok := v.AsInt64(); ok && n > 0 {
 			memnum := 0
 			mem, ok := ctr.Resources.Limits[resourceMem]
 			if !ok {
@@ -551,10 +911,13 @@ func (dev *NvidiaGPUDevices) GenerateResourceRequests(ctr *corev1.Container) dev
 			}
 			if ok {
 				mempnums, ok := mem.AsInt64()
-				if ok {
+				if ok && mempnums > 0 {
 					mempnum = int32(mempnums)
 				}
 			}
+			if mempnum == 101 && memnum == 0 && dev.isComputeOnly(ctr) {
+				memnum = int(dev.config.ComputeOnlyMemory)
+			}
 			if mempnum == 101 && memnum == 0 {
 				if dev.config.DefaultMemory != 0 {
 					memnum = int(dev.config.DefaultMemory)
@@ -562,14 +925,19 @@ func (dev *NvidiaGPUDevices) GenerateResourceRequests(ctr *corev1.Container) dev
 					mempnum = 100
 				}
 			}
+			engines, _ := resourceValue(ctr, corev1.ResourceName(dev.config.ResourceEncoderName))
 			corenum := dev.config.DefaultCores
+			// Encode/decode engine requests don't need the cores, e.g. transcoding.
+			if engines == 0 {
+				corenum = dev.autoCores(corenum)
+			}
 			core, ok := ctr.Resources.Limits[resourceCores]
 			if !ok {
 				core, ok = ctr.Resources.Requests[resourceCores]
 			}
 			if ok {
 				corenums, ok := core.AsInt64()
-				if ok {
+				if ok && corenums > 0 {
 					corenum = int32(corenums)
 				}
 			}
@@ -579,6 +947,7 @@ func (dev *NvidiaGPUDevices) GenerateResourceRequests(ctr *corev1.Container) dev
 				Memreq:           int32(memnum),
 				MemPercentagereq: int32(mempnum),
 				Coresreq:         int32(corenum),
+				Enginesreq:       int32(engines),
 			}
 		}
 	}
@@ -689,10 +1058,31 @@ func (dev *NvidiaGPUDevices) AddResourceUsage(pod *corev1.Pod, n *device.DeviceU
 		}
 	}
 	n.Usedcores += ctr.Usedcores
+	n.Usedengines += ctr.Usedengines
 	n.Usedmem += ctr.Usedmem
+	n.Opportunisticmem += device.OpportunisticMem(pod, ctr.Usedmem)
+	n.Oversubscribedmem += ctr.Oversubscribedmem
 	return nil
 }
 
+// computeModeFit checks whether a pod in the compute mode can share the device with the pods already on it,
+// an exclusive pod only takes unused devices and MPS pods only share devices with other MPS pods.
+func computeModeFit(mode util.ComputeMode, d *device.DeviceUsage) bool {
+	if d.Used == 0 {
+		return true
+	}
+	if mode == util.ComputeModeExclusive {
+		return false
+	}
+	for _, pi := range d.PodInfos {
+		podMode, _ := util.GetComputeMode(pi.Pod)
+		if podMode == util.ComputeModeExclusive || (podMode == util.ComputeModeMPS) != (mode == util.ComputeModeMPS) {
+			return false
+		}
+	}
+	return true
+}
+
 func fitQuota(tmpDevs map[string]device.ContainerDevices, ns string, memreq int64, coresreq int64) bool {
 	mem := memreq
 	core := coresreq
@@ -713,6 +1103,40 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 	tmpDevs = make(map[string]device.ContainerDevices)
 	reason := make(map[string]int)
 	needTopology := util.GetGPUSchedulerPolicyByPod(device.GPUSchedulerPolicy, pod) == util.GPUSchedulerPolicyTopology.String()
+	uuids := newUUIDFilter(pod.GetAnnotations())
+	mode, _ := util.GetComputeMode(pod)
+	qos, _ := util.GetQoSClass(pod)
+	minDriver, _ := util.GetMinDriverVersion(pod)
+	// GPUs not reporting their ECC mode don't fit a required one.
+	eccMode, _ := util.GetRequiredECCMode(pod)
+	pool := device.PodDevicePool(pod)
+	// Only the GPUs which passed the maintenance tests of the node, if required.
+	var verified *device.UUIDSet
+	if util.RequiresVerifiedDevices(pod) {
+		verified = device.NodeVerifiedDevices(nodeInfo)
+	}
+	oversubscribe := util.AllowsMemoryOversubscription(pod)
+	now := time.Now()
+	// The memory of each GPU by index instead of the same memory of every GPU, eachIdx records the index
+	// each device of tmpDevs is allocated for.
+	memEach, _ := util.GetGPUMemoryEach(pod)
+	if len(memEach) != int(originReq) {
+		memEach = nil
+	}
+	// The last GPU of the request is shared with the memory and cores of shared, the others are allocated whole.
+	shared, _ := util.GetSharedGPU(pod)
+	// GPUs with contiguous indices are looked for among all the GPUs fitting the request, fallback records the GPUs
+	// allocated without them.
+	contiguous, _ := util.GetContiguousIndices(pod)
+	if memEach != nil || shared != nil || originReq < 2 {
+		contiguous = util.ContiguousIndicesNone
+	}
+	if memEach != nil || shared != nil || contiguous != util.ContiguousIndicesNone {
+		needTopology = false
+	}
+	var fallback device.ContainerDevices
+	fits := 0
+	eachIdx := make([]int, 0, originReq)
 	for i := len(devices) - 1; i >= 0; i-- {
 		dev := devices[i]
 		klog.V(4).InfoS("scoring pod", "pod", klog.KObj(pod), "device", dev.ID, "Memreq", k.Memreq, "MemPercentagereq", k.MemPercentagereq, "Coresreq", k.Coresreq, "Nums", k.Nums, "device index", i)
@@ -721,6 +1145,31 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			klog.V(5).InfoS(common.CardNotHealth, "pod", klog.KObj(pod), "device", dev.ID, "health", dev.Health)
 			continue
 		}
+		if r := device.CheckDeviceHealth(dev, pod, now); r != "" {
+			reason[r]++
+			klog.V(5).InfoS(r, "pod", klog.KObj(pod), "device", dev.ID, "temperature", dev.Temperature, "eccErrorTime", dev.ECCErrorTime)
+			continue
+		}
+		if !driverVersionFits(dev, minDriver) {
+			reason[common.CardDriverTooOld]++
+			klog.V(5).InfoS(common.CardDriverTooOld, "pod", klog.KObj(pod), "device", dev.ID, "driverVersion", dev.DriverVersion, "minDriverVersion", minDriver)
+			continue
+		}
+		if eccMode != "" && dev.ECCMode != eccMode {
+			reason[common.CardECCModeMismatch]++
+			klog.V(5).InfoS(common.CardECCModeMismatch, "pod", klog.KObj(pod), "device", dev.ID, "eccMode", dev.ECCMode, "requiredECCMode", eccMode)
+			continue
+		}
+		if dev.Pool != pool {
+			reason[common.CardPoolMismatch]++
+			klog.V(5).InfoS(common.CardPoolMismatch, "pod", klog.KObj(pod), "device", dev.ID, "devicePool", dev.Pool, "pool", pool)
+			continue
+		}
+		if verified != nil && !verified.Contains(dev.ID) {
+			reason[common.CardNotVerified]++
+			klog.V(5).InfoS(common.CardNotVerified, "pod", klog.KObj(pod), "device", dev.ID)
+			continue
+		}
 		found, numa := nv.checkType(pod.GetAnnotations(), *dev, k)
 		if !found {
 			reason[common.CardTypeMismatch]++
@@ -735,8 +1184,9 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			k.Nums = originReq
 			prevnuma = dev.Numa
 			tmpDevs = make(map[string]device.ContainerDevices)
+			eachIdx = eachIdx[:0]
 		}
-		if !nv.checkUUID(pod.GetAnnotations(), *dev) {
+		if !nv.checkUUID(uuids, *dev) {
 			reason[common.CardUUIDMismatch]++
 			klog.V(5).InfoS(common.CardUUIDMismatch, "pod", klog.KObj(pod), "device", dev.ID, "current device info is:", *dev)
 			continue
@@ -753,6 +1203,7 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			k.Coresreq = 100
 			//return false, tmpDevs
 		}
+		coresreq := k.Coresreq
 		if k.Memreq > 0 {
 			memreq = k.Memreq
 		}
@@ -760,29 +1211,69 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			//This incurs an issue
 			memreq = dev.Totalmem * k.MemPercentagereq / 100
 		}
-		if !fitQuota(tmpDevs, pod.Namespace, int64(memreq), int64(k.Coresreq)) {
+		idx := -1
+		if memEach != nil {
+			idx = memEachIndex(memEach, eachIdx, device.AvailableMem(dev, qos))
+			memreq = memEach[idx]
+		}
+		if shared != nil {
+			idx, memreq, coresreq = sharedGPUIndex(shared, eachIdx, originReq, dev)
+			if idx == -1 {
+				reason[common.ExclusiveDeviceAllocateConflict]++
+				klog.V(5).InfoS(common.ExclusiveDeviceAllocateConflict, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "used", dev.Used)
+				continue
+			}
+		}
+		// Slices of a GPU are only isolated by the HAMi hook, MIG instances are by the GPU itself.
+		if dev.RuntimeNotReady && dev.Mode != MigMode && (memreq < dev.Totalmem || coresreq < dev.Totalcore) {
+			reason[common.CardRuntimeNotReady]++
+			klog.V(5).InfoS(common.CardRuntimeNotReady, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "request memory", memreq, "request cores", coresreq)
+			continue
+		}
+		if !fitQuota(tmpDevs, pod.Namespace, int64(memreq), int64(coresreq)) {
 			reason[common.ResourceQuotaNotFit]++
-			klog.V(3).InfoS(common.ResourceQuotaNotFit, "pod", pod.Name, "memreq", memreq, "coresreq", k.Coresreq)
+			klog.V(3).InfoS(common.ResourceQuotaNotFit, "pod", pod.Name, "memreq", memreq, "coresreq", coresreq)
 			continue
 		}
-		if dev.Totalmem-dev.Usedmem < memreq {
+		if device.AvailableMem(dev, qos) < memreq {
 			reason[common.CardInsufficientMemory]++
-			klog.V(5).InfoS(common.CardInsufficientMemory, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total memory", dev.Totalmem, "device used memory", dev.Usedmem, "request memory", memreq)
+			klog.V(5).InfoS(common.CardInsufficientMemory, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total memory", dev.Totalmem, "device used memory", dev.Usedmem, "device opportunistic memory", dev.Opportunisticmem, "qos", qos, "request memory", memreq)
+			continue
+		}
+		overmem := device.OversubscribedMem(dev, memreq)
+		if overmem > 0 && !oversubscribe {
+			reason[common.CardInsufficientPhysicalMemory]++
+			klog.V(5).InfoS(common.CardInsufficientPhysicalMemory, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device physical memory", dev.Physicalmem, "device used memory", dev.Usedmem, "device oversubscribed memory", dev.Oversubscribedmem, "request memory", memreq)
 			continue
 		}
-		if dev.Totalcore-dev.Usedcores < k.Coresreq {
+		if dev.Totalcore-dev.Usedcores < coresreq {
 			reason[common.CardInsufficientCore]++
-			klog.V(5).InfoS(common.CardInsufficientCore, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total core", dev.Totalcore, "device used core", dev.Usedcores, "request cores", k.Coresreq)
+			klog.V(5).InfoS(common.CardInsufficientCore, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total core", dev.Totalcore, "device used core", dev.Usedcores, "request cores", coresreq)
+			continue
+		}
+		if k.Enginesreq > 0 && dev.Totalengines-dev.Usedengines < k.Enginesreq {
+			reason[common.CardInsufficientEngines]++
+			klog.V(5).InfoS(common.CardInsufficientEngines, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total engines", dev.Totalengines, "device used engines", dev.Usedengines, "request engines", k.Enginesreq)
 			continue
 		}
 		// Coresreq=100 indicates it want this card exclusively
-		if dev.Totalcore == 100 && k.Coresreq == 100 && dev.Used > 0 {
+		if !nv.config.IndependentCoresAndMemory && dev.Totalcore == 100 && coresreq == 100 && dev.Used > 0 {
 			reason[common.ExclusiveDeviceAllocateConflict]++
 			klog.V(5).InfoS(common.ExclusiveDeviceAllocateConflict, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "used", dev.Used)
 			continue
 		}
-		// You can't allocate core=0 job to an already full GPU
-		if dev.Totalcore != 0 && dev.Usedcores == dev.Totalcore && k.Coresreq == 0 {
+		if !computeModeFit(mode, dev) {
+			reason[common.ComputeModeConflict]++
+			klog.V(5).InfoS(common.ComputeModeConflict, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "compute mode", mode)
+			continue
+		}
+		if r := device.CheckDeviceAffinity(dev, pod, devices); r != "" {
+			reason[r]++
+			klog.V(5).InfoS(r, "pod", klog.KObj(pod), "device", dev.ID, "device index", i)
+			continue
+		}
+		// You can't allocate core=0 job to an already full GPU, unless it only uses the encode/decode engines
+		if !nv.config.IndependentCoresAndMemory && dev.Totalcore != 0 && dev.Usedcores == dev.Totalcore && coresreq == 0 && k.Enginesreq == 0 {
 			reason[common.CardComputeUnitsExhausted]++
 			klog.V(5).InfoS(common.CardComputeUnitsExhausted, "pod", klog.KObj(pod), "device", dev.ID, "device index", i)
 			continue
@@ -795,18 +1286,37 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 
 		if k.Nums > 0 {
 			klog.V(5).InfoS("find fit device", "pod", klog.KObj(pod), "device", dev.ID)
-			if !needTopology {
+			if !needTopology && contiguous == util.ContiguousIndicesNone {
 				k.Nums--
 			}
 			tmpDevs[k.Type] = append(tmpDevs[k.Type], device.ContainerDevice{
-				Idx:       int(dev.Index),
-				UUID:      dev.ID,
-				Type:      k.Type,
-				Usedmem:   memreq,
-				Usedcores: k.Coresreq,
+				Idx:               int(dev.Index),
+				UUID:              dev.ID,
+				Type:              k.Type,
+				Usedmem:           memreq,
+				Usedcores:         coresreq,
+				Usedengines:       k.Enginesreq,
+				Oversubscribedmem: overmem,
 			})
+			if memEach != nil || shared != nil {
+				eachIdx = append(eachIdx, idx)
+			}
+			if contiguous != util.ContiguousIndicesNone {
+				fits++
+				if fallback == nil && len(tmpDevs[k.Type]) == int(originReq) {
+					fallback = slices.Clone(tmpDevs[k.Type])
+				}
+				if devs, ok := contiguousDevices(tmpDevs[k.Type], int(originReq)); ok {
+					tmpDevs[k.Type] = devs
+					klog.V(4).InfoS("device allocate success", "pod", klog.KObj(pod), "allocate device", tmpDevs)
+					return true, tmpDevs, ""
+				}
+			}
 		}
 		if k.Nums == 0 && !needTopology {
+			if memEach != nil || shared != nil {
+				tmpDevs[k.Type] = orderByMemEachIndex(tmpDevs[k.Type], eachIdx)
+			}
 			klog.V(4).InfoS("device allocate success", "pod", klog.KObj(pod), "allocate device", tmpDevs)
 			return true, tmpDevs, ""
 		}
@@ -835,6 +1345,16 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			return true, tmpDevs, ""
 		}
 	}
+	if fallback != nil {
+		if contiguous == util.ContiguousIndicesPreferred {
+			tmpDevs = map[string]device.ContainerDevices{k.Type: fallback}
+			klog.V(4).InfoS("device allocate success without contiguous indices", "pod", klog.KObj(pod), "allocate device", tmpDevs)
+			return true, tmpDevs, ""
+		}
+		reason[common.CardIndicesNotContiguous] = fits
+		klog.V(5).InfoS(common.CardIndicesNotContiguous, "pod", klog.KObj(pod), "request", originReq, "fit", fits)
+		return false, tmpDevs, common.GenReason(reason, len(devices))
+	}
 	if len(tmpDevs) > 0 {
 		reason[common.AllocatedCardsInsufficientRequest] = len(tmpDevs)
 		klog.V(5).InfoS(common.AllocatedCardsInsufficientRequest, "pod", klog.KObj(pod), "request", originReq, "allocated", len(tmpDevs))
@@ -842,6 +1362,94 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 	return false, tmpDevs, common.GenReason(reason, len(devices))
 }
 
+// contiguousDevices returns n of devs with contiguous indices, the lowest ones if several, or false if devs have
+// no n contiguous indices. The devices of the same index, e.g. MIG instances, count once.
+func contiguousDevices(devs device.ContainerDevices, n int) (device.ContainerDevices, bool) {
+	byIdx := make(map[int]device.ContainerDevice, len(devs))
+	for _, d := range devs {
+		if _, ok := byIdx[d.Idx]; !ok {
+			byIdx[d.Idx] = d
+		}
+	}
+	indices := slices.Sorted(maps.Keys(byIdx))
+	for start := 0; start+n <= len(indices); start++ {
+		if indices[start+n-1]-indices[start] != n-1 {
+			continue
+		}
+		res := make(device.ContainerDevices, 0, n)
+		for _, idx := range indices[start : start+n] {
+			res = append(res, byIdx[idx])
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// driverVersionFits reports whether the driver of the device is at least minDriver, any driver fits if it's
+// empty. Devices not reporting their driver version don't fit a minimum.
+func driverVersionFits(dev *device.DeviceUsage, minDriver string) bool {
+	if minDriver == "" {
+		return true
+	}
+	cmp, err := util.CompareDriverVersions(dev.DriverVersion, minDriver)
+	return err == nil && cmp >= 0
+}
+
+// memEachIndex returns the index of the largest memory of memEach not allocated yet that fits in the available
+// memory of a device, or of the smallest one if none fits. Giving each device the largest memory it fits allocates
+// all the memory whenever the devices can fit it.
+func memEachIndex(memEach []int32, allocated []int, available int32) int {
+	res := -1
+	for i, mem := range memEach {
+		if slices.Contains(allocated, i) {
+			continue
+		}
+		switch {
+		case res == -1:
+			res = i
+		case mem <= available && (memEach[res] > available || mem > memEach[res]):
+			res = i
+		case memEach[res] > available && mem < memEach[res]:
+			res = i
+		}
+	}
+	return res
+}
+
+// sharedGPUIndex returns the index of the request the device is allocated for, with the memory and cores to allocate.
+// Devices not used by any pod are allocated whole for the first indexes while some are left, other devices are
+// allocated for the last index of the shared GPU. It returns -1 if the device fits no index left.
+func sharedGPUIndex(shared *util.SharedGPU, allocated []int, nums int32, dev *device.DeviceUsage) (int, int32, int32) {
+	sharedIdx := int(nums) - 1
+	whole := 0
+	for _, idx := range allocated {
+		if idx != sharedIdx {
+			whole++
+		}
+	}
+	if dev.Used == 0 && whole < sharedIdx {
+		return whole, dev.Totalmem, 100
+	}
+	if slices.Contains(allocated, sharedIdx) {
+		return -1, 0, 0
+	}
+	mem := shared.Mem
+	if mem == 0 {
+		mem = dev.Totalmem * shared.MemPercentage / 100
+	}
+	return sharedIdx, mem, shared.Cores
+}
+
+// orderByMemEachIndex orders the devices allocated for the indexes of the request, e.g. of the memory of each GPU,
+// by the indexes.
+func orderByMemEachIndex(devs device.ContainerDevices, eachIdx []int) device.ContainerDevices {
+	res := make(device.ContainerDevices, len(devs))
+	for i, idx := range eachIdx {
+		res[idx] = devs[i]
+	}
+	return res
+}
+
 func (dev *NvidiaGPUDevices) GetResourceNames() device.ResourceNames {
 	return device.ResourceNames{
 		ResourceCountName:  dev.config.ResourceCountName,
@@ -850,6 +1458,16 @@ func (dev *NvidiaGPUDevices) GetResourceNames() device.ResourceNames {
 	}
 }
 
+// OwnedEnvs implements device.OwnedEnvDevices.
+func (dev *NvidiaGPUDevices) OwnedEnvs() []string {
+	return OwnedEnvs
+}
+
+// RequestAccelerator implements device.AcceleratorDevices.
+func (dev *NvidiaGPUDevices) RequestAccelerator(ctr *corev1.Container, count, mem int64) {
+	device.SetAcceleratorLimits(ctr, dev.GetResourceNames(), count, mem)
+}
+
 func generateCombinations(request device.ContainerDeviceRequest, tmpDevs map[string]device.ContainerDevices) []device.ContainerDevices {
 	k := request
 	num := int(k.Nums)
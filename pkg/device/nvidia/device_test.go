@@ -18,14 +18,21 @@ package nvidia
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 )
 
@@ -113,7 +120,7 @@ func Test_MutateAdmission(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, _ := gpuDevices.MutateAdmission(test.args, &corev1.Pod{})
+			got, _ := gpuDevices.MutateAdmission(test.args, &corev1.Pod{}, nil)
 			if test.want != got {
 				t.Fatalf("exec MutateAdmission method expect return is %+v, but got is %+v", test.want, got)
 			}
@@ -199,6 +206,22 @@ func TestMutateAdmissionDefaultsExclusiveCore(t *testing.T) {
 			wantCore:   true,
 			expectCore: 70,
 		},
+		{
+			name: "exclusive cores bounded by maxAutoCores",
+			config: NvidiaConfig{
+				ResourceCountName:            "nvidia.com/gpu",
+				ResourceMemoryName:           "nvidia.com/gpumem",
+				ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+				ResourceCoreName:             "nvidia.com/gpucores",
+				DefaultGPUNum:                1,
+				MaxAutoCores:                 80,
+			},
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("1"),
+			},
+			wantCore:   true,
+			expectCore: 80,
+		},
 		{
 			name:   "explicit cores in requests remains unchanged",
 			config: defaultConfig,
@@ -259,7 +282,7 @@ func TestMutateAdmissionDefaultsExclusiveCore(t *testing.T) {
 				},
 			}
 			dev := &NvidiaGPUDevices{config: tt.config}
-			dev.MutateAdmission(ctr, &corev1.Pod{})
+			dev.MutateAdmission(ctr, &corev1.Pod{}, nil)
 
 			coreName := corev1.ResourceName(tt.config.ResourceCoreName)
 			qty, exists := ctr.Resources.Limits[coreName]
@@ -283,207 +306,960 @@ func TestMutateAdmissionDefaultsExclusiveCore(t *testing.T) {
 	}
 }
 
-func Test_checkUUID(t *testing.T) {
-	gpuDevices := &NvidiaGPUDevices{
-		config: NvidiaConfig{
-			ResourceCountName:            "nvidia.com/gpu",
-			ResourceMemoryName:           "nvidia.com/gpumem",
-			ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
-			ResourceCoreName:             "nvidia.com/gpucores",
-			DefaultGPUNum:                int32(1),
-		},
+func TestMutateAdmissionWarnings(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		DefaultGPUNum:                1,
+		RuntimeClassName:             "nvidia",
+	}}
+	ctr := &corev1.Container{Name: "train", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"nvidia.com/gpumem-percentage": resource.MustParse("100"),
+	}}}
+	warnings := &device.AdmissionWarnings{}
+	found, err := dev.MutateAdmission(ctr, &corev1.Pod{}, warnings)
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	assert.DeepEqual(t, warnings.List(), []string{
+		"container train: nvidia.com/gpu is not set, defaulted to 1",
+		"container train: nvidia.com/gpucores is not set, defaulted to 100",
+		"runtimeClassName is not set, defaulted to nvidia",
+	})
+
+	// Nothing is warned for the resources the container sets.
+	ctr = &corev1.Container{Name: "train", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"nvidia.com/gpu":      resource.MustParse("1"),
+		"nvidia.com/gpumem":   resource.MustParse("4000"),
+		"nvidia.com/gpucores": resource.MustParse("30"),
+	}}}
+	warnings = &device.AdmissionWarnings{}
+	_, err = dev.MutateAdmission(ctr, &corev1.Pod{Spec: corev1.PodSpec{RuntimeClassName: ptr.To("nvidia")}}, warnings)
+	assert.NilError(t, err)
+	assert.Assert(t, warnings.List() == nil)
+}
+
+func TestMutateAdmissionNormalizeResources(t *testing.T) {
+	config := NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		DefaultGPUNum:                1,
 	}
+
 	tests := []struct {
-		name string
-		args struct {
-			annos map[string]string
-			d     device.DeviceUsage
-		}
-		want bool
+		name         string
+		limits       corev1.ResourceList
+		requests     corev1.ResourceList
+		want         bool
+		wantErr      string
+		wantRequests corev1.ResourceList
 	}{
 		{
-			name: "don't set GPUUseUUID and GPUNoUseUUID annotation",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: make(map[string]string),
-				d:     device.DeviceUsage{},
+			name: "limits only are copied to requests",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("2"),
+				"nvidia.com/gpumem": resource.MustParse("1024"),
 			},
 			want: true,
+			wantRequests: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("2"),
+				"nvidia.com/gpumem": resource.MustParse("1024"),
+			},
 		},
 		{
-			name: "use set GPUUseUUID don't set GPUNoUseUUID annotation,device match",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: map[string]string{
-					GPUUseUUID: "abc,123",
-				},
-				d: device.DeviceUsage{
-					ID: "abc",
-				},
+			name: "defaulted resources are copied to requests",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpumem-percentage": resource.MustParse("100"),
 			},
 			want: true,
+			wantRequests: corev1.ResourceList{
+				"nvidia.com/gpu":               resource.MustParse("1"),
+				"nvidia.com/gpumem-percentage": resource.MustParse("100"),
+				"nvidia.com/gpucores":          resource.MustParse("100"),
+			},
 		},
 		{
-			name: "use set GPUUseUUID don't set GPUNoUseUUID annotation,device don't match",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: map[string]string{
-					GPUUseUUID: "abc,123",
-				},
-				d: device.DeviceUsage{
-					ID: "1abc",
-				},
+			name: "existing requests are not overwritten",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("2048"),
+			},
+			requests: corev1.ResourceList{
+				"nvidia.com/gpumem": resource.MustParse("1024"),
+			},
+			want: true,
+			wantRequests: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("1024"),
+			},
+		},
+		{
+			name: "requests only",
+			requests: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("1024"),
+			},
+			want: true,
+			wantRequests: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("1024"),
+			},
+		},
+		{
+			name: "zero count in limits means no gpu",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("0"),
 			},
 			want: false,
 		},
 		{
-			name: "use don't set GPUUseUUID set GPUNoUseUUID annotation,device match",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: map[string]string{
-					GPUNoUseUUID: "abc,123",
-				},
-				d: device.DeviceUsage{
-					ID: "abc",
-				},
+			name: "zero count in requests means no gpu",
+			requests: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("0"),
+				"nvidia.com/gpumem": resource.MustParse("0"),
 			},
 			want: false,
+			wantRequests: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("0"),
+				"nvidia.com/gpumem": resource.MustParse("0"),
+			},
 		},
 		{
-			name: "use don't set GPUUseUUID set GPUNoUseUUID annotation,device  don't match",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: map[string]string{
-					GPUNoUseUUID: "abc,123",
-				},
-				d: device.DeviceUsage{
-					ID: "1abc",
-				},
+			name: "zero count in limits and requests means no gpu",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("0"),
 			},
-			want: true,
+			requests: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("0"),
+			},
+			want: false,
+			wantRequests: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("0"),
+			},
+		},
+		{
+			name: "zero shared resources without count mean no gpu",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpumem":            resource.MustParse("0"),
+				"nvidia.com/gpumem-percentage": resource.MustParse("0"),
+			},
+			want: false,
+		},
+		{
+			name: "zero count with memory is denied",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("0"),
+				"nvidia.com/gpumem": resource.MustParse("3000"),
+			},
+			wantErr: "nvidia.com/gpumem is requested while nvidia.com/gpu is 0",
+		},
+		{
+			name: "zero count in requests with cores is denied",
+			requests: corev1.ResourceList{
+				"nvidia.com/gpu":      resource.MustParse("0"),
+				"nvidia.com/gpucores": resource.MustParse("50"),
+			},
+			wantErr: "nvidia.com/gpucores is requested while nvidia.com/gpu is 0",
 		},
 	}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			got := gpuDevices.checkUUID(test.args.annos, test.args.d)
-			assert.Equal(t, test.want, got)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctr := &corev1.Container{
+				Resources: corev1.ResourceRequirements{
+					Limits:   tt.limits,
+					Requests: tt.requests,
+				},
+			}
+			dev := &NvidiaGPUDevices{config: config}
+			got, err := dev.MutateAdmission(ctr, &corev1.Pod{}, nil)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, len(tt.wantRequests), len(ctr.Resources.Requests))
+			for name, want := range tt.wantRequests {
+				qty, ok := ctr.Resources.Requests[name]
+				assert.Assert(t, ok, "request %s not found", name)
+				assert.Equal(t, want.Value(), qty.Value(), "request %s", name)
+			}
+			if !tt.want {
+				_, ok := ctr.Resources.Limits["nvidia.com/gpucores"]
+				assert.Assert(t, !ok, "cores should not be defaulted for zero count")
+			}
 		})
 	}
 }
 
-func Test_checkType(t *testing.T) {
-	gpuDevices := &NvidiaGPUDevices{
-		config: NvidiaConfig{
-			ResourceCountName:            "nvidia.com/gpu",
-			ResourceMemoryName:           "nvidia.com/gpumem",
-			ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
-			ResourceCoreName:             "nvidia.com/gpucores",
-			DefaultGPUNum:                int32(1),
-		},
-	}
+func TestGenerateResourceRequestsZeroQuantities(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		DefaultCores:                 20,
+	}}
+
 	tests := []struct {
-		name string
-		args struct {
-			annos map[string]string
-			d     device.DeviceUsage
-		}
-		want bool
+		name     string
+		limits   corev1.ResourceList
+		requests corev1.ResourceList
+		want     device.ContainerDeviceRequest
 	}{
 		{
-			name: "use set GPUInUse don't set GPUNoUse annotation,device match",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: map[string]string{
-					GPUInUse: "A10",
-				},
-				d: device.DeviceUsage{
-					Type: "NVIDIA A100",
-				},
-			},
-			want: true,
+			name:   "zero count in limits",
+			limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")},
 		},
 		{
-			name: "use set GPUInUse set GPUNoUse annotation,device don't match",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: map[string]string{
-					GPUInUse: "A10",
-					GPUNoUse: "A100",
-				},
-				d: device.DeviceUsage{
-					Type: "NVIDIA A100",
-				},
-			},
-			want: false,
+			name:     "zero count in requests",
+			requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")},
 		},
 		{
-			name: "use set GPUInUse set GPUNoUse annotation,device match",
-			args: struct {
-				annos map[string]string
-				d     device.DeviceUsage
-			}{
-				annos: map[string]string{
-					GPUInUse: "A10",
-					GPUNoUse: "A100",
-				},
-				d: device.DeviceUsage{
-					Type: "NVIDIA A10",
-				},
+			name:     "zero count in limits and requests",
+			limits:   corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")},
+			requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")},
+		},
+		{
+			name: "zero shared resources are defaulted",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":               resource.MustParse("1"),
+				"nvidia.com/gpumem":            resource.MustParse("0"),
+				"nvidia.com/gpumem-percentage": resource.MustParse("0"),
+				"nvidia.com/gpucores":          resource.MustParse("0"),
 			},
-			want: true,
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, MemPercentagereq: 100, Coresreq: 20},
 		},
 	}
-	req := device.ContainerDeviceRequest{
-		Type: NvidiaGPUDevice,
-	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			got, _ := gpuDevices.checkType(test.args.annos, test.args.d, req)
-			assert.Equal(t, test.want, got)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctr := &corev1.Container{Resources: corev1.ResourceRequirements{Limits: tt.limits, Requests: tt.requests}}
+			assert.DeepEqual(t, dev.GenerateResourceRequests(ctr), tt.want)
 		})
 	}
 }
 
-func Test_FilterDeviceToRegister(t *testing.T) {
+func TestGenerateResourceRequestsComputeOnly(t *testing.T) {
+	config := NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		DefaultMemory:                4000,
+	}
+
 	tests := []struct {
-		name string
-		args struct {
-			uuid string
-			idx  string
-			*FilterDevice
-		}
-		want bool
+		name        string
+		computeOnly int32
+		limits      corev1.ResourceList
+		want        device.ContainerDeviceRequest
 	}{
 		{
-			name: "filter is nil",
-			args: struct {
-				uuid string
-				idx  string
-				*FilterDevice
-			}{
-				uuid:         "GPU-8dcd427f-483b-b48f-d7e5-75fb19a52b76",
-				idx:          "0",
-				FilterDevice: nil,
+			name:        "zero memory with cores is compute-only",
+			computeOnly: 256,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":      resource.MustParse("1"),
+				"nvidia.com/gpumem":   resource.MustParse("0"),
+				"nvidia.com/gpucores": resource.MustParse("30"),
 			},
-			want: false,
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 256, MemPercentagereq: 101, Coresreq: 30},
 		},
 		{
-			name: "uuid is empty",
+			name: "zero memory is unset when compute-only is disabled",
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":      resource.MustParse("1"),
+				"nvidia.com/gpumem":   resource.MustParse("0"),
+				"nvidia.com/gpucores": resource.MustParse("30"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 30},
+		},
+		{
+			name:        "zero memory without cores is unset",
+			computeOnly: 256,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("0"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101},
+		},
+		{
+			name:        "memory unset with cores is unset",
+			computeOnly: 256,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":      resource.MustParse("1"),
+				"nvidia.com/gpucores": resource.MustParse("30"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 30},
+		},
+		{
+			name:        "memory percentage takes precedence",
+			computeOnly: 256,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":               resource.MustParse("1"),
+				"nvidia.com/gpumem":            resource.MustParse("0"),
+				"nvidia.com/gpumem-percentage": resource.MustParse("10"),
+				"nvidia.com/gpucores":          resource.MustParse("30"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, MemPercentagereq: 10, Coresreq: 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &NvidiaGPUDevices{config: config}
+			dev.config.ComputeOnlyMemory = tt.computeOnly
+			ctr := &corev1.Container{Resources: corev1.ResourceRequirements{Limits: tt.limits}}
+			assert.DeepEqual(t, dev.GenerateResourceRequests(ctr), tt.want)
+		})
+	}
+}
+
+func TestGenerateResourceRequestsAutoCores(t *testing.T) {
+	config := NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		ResourceEncoderName:          "nvidia.com/nvenc",
+	}
+
+	tests := []struct {
+		name         string
+		defaultCores int32
+		minCores     int32
+		maxCores     int32
+		limits       corev1.ResourceList
+		want         device.ContainerDeviceRequest
+	}{
+		{
+			name:     "default cores raised to the floor",
+			minCores: 10,
+			maxCores: 50,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("4000"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 10},
+		},
+		{
+			name:         "default cores lowered to the ceiling",
+			defaultCores: 80,
+			minCores:     10,
+			maxCores:     50,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("4000"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 50},
+		},
+		{
+			name:         "default cores within the bounds",
+			defaultCores: 30,
+			minCores:     10,
+			maxCores:     50,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("4000"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 30},
+		},
+		{
+			name:         "unbounded",
+			defaultCores: 80,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("4000"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 80},
+		},
+		{
+			name:     "requested cores not bounded",
+			minCores: 10,
+			maxCores: 50,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":      resource.MustParse("1"),
+				"nvidia.com/gpumem":   resource.MustParse("4000"),
+				"nvidia.com/gpucores": resource.MustParse("70"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 70},
+		},
+		{
+			name:     "encoder engines without cores",
+			minCores: 10,
+			limits: corev1.ResourceList{
+				"nvidia.com/gpu":    resource.MustParse("1"),
+				"nvidia.com/gpumem": resource.MustParse("4000"),
+				"nvidia.com/nvenc":  resource.MustParse("2"),
+			},
+			want: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Enginesreq: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &NvidiaGPUDevices{config: config}
+			dev.config.DefaultCores = tt.defaultCores
+			dev.config.MinAutoCores = tt.minCores
+			dev.config.MaxAutoCores = tt.maxCores
+			ctr := &corev1.Container{Resources: corev1.ResourceRequirements{Limits: tt.limits}}
+			assert.DeepEqual(t, dev.GenerateResourceRequests(ctr), tt.want)
+		})
+	}
+}
+
+func Test_ValidateAutoCores(t *testing.T) {
+	assert.NilError(t, NvidiaConfig{}.ValidateAutoCores())
+	assert.NilError(t, NvidiaConfig{MinAutoCores: 10, MaxAutoCores: 50}.ValidateAutoCores())
+	assert.NilError(t, NvidiaConfig{MinAutoCores: 10}.ValidateAutoCores())
+	assert.ErrorContains(t, NvidiaConfig{MaxAutoCores: 120}.ValidateAutoCores(), "maxAutoCores 120 must be from 0 to 100")
+	assert.ErrorContains(t, NvidiaConfig{MinAutoCores: 60, MaxAutoCores: 50}.ValidateAutoCores(), "must not be above maxAutoCores")
+}
+
+func TestMutateAdmissionComputeMode(t *testing.T) {
+	config := NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		DefaultGPUNum:                1,
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		limits    corev1.ResourceList
+		wantEnv   string
+		wantCores bool
+	}{
+		{
+			name:      "no compute mode",
+			limits:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			wantCores: true,
+		},
+		{
+			name:      "default mode",
+			mode:      "default",
+			limits:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			wantCores: true,
+		},
+		{
+			name:    "mps mode is shareable",
+			mode:    "mps",
+			limits:  corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			wantEnv: "mps",
+		},
+		{
+			name:      "exclusive mode",
+			mode:      "exclusive",
+			limits:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			wantEnv:   "exclusive",
+			wantCores: true,
+		},
+		{
+			name:   "no gpu requested",
+			mode:   "mps",
+			limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dev := InitNvidiaDevice(config)
+			pod := &corev1.Pod{}
+			if test.mode != "" {
+				pod.Annotations = map[string]string{util.ComputeModeAnnotationKey: test.mode}
+			}
+			ctr := &corev1.Container{Resources: corev1.ResourceRequirements{Limits: test.limits}}
+			_, err := dev.MutateAdmission(ctr, pod, nil)
+			assert.NilError(t, err)
+
+			env := ""
+			for _, e := range ctr.Env {
+				if e.Name == util.ComputeModeEnv {
+					env = e.Value
+				}
+			}
+			assert.Equal(t, env, test.wantEnv)
+			_, ok := ctr.Resources.Limits["nvidia.com/gpucores"]
+			assert.Equal(t, ok, test.wantCores)
+		})
+	}
+}
+
+func TestMutateAdmissionIsolation(t *testing.T) {
+	config := NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		DefaultGPUNum:                1,
+	}
+
+	tests := []struct {
+		name      string
+		isolation string
+		limits    corev1.ResourceList
+		wantEnv   string
+	}{
+		{
+			name:   "no isolation level",
+			limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+		},
+		{
+			name:      "strict isolation is the default",
+			isolation: "strict",
+			limits:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+		},
+		{
+			name:      "shared isolation",
+			isolation: "shared",
+			limits:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			wantEnv:   "shared",
+		},
+		{
+			name:      "no gpu requested",
+			isolation: "shared",
+			limits:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dev := InitNvidiaDevice(config)
+			pod := &corev1.Pod{}
+			if test.isolation != "" {
+				pod.Annotations = map[string]string{util.IsolationAnnotationKey: test.isolation}
+			}
+			ctr := &corev1.Container{Resources: corev1.ResourceRequirements{Limits: test.limits}}
+			_, err := dev.MutateAdmission(ctr, pod, nil)
+			assert.NilError(t, err)
+
+			env := ""
+			for _, e := range ctr.Env {
+				if e.Name == util.IsolationEnv {
+					env = e.Value
+				}
+			}
+			assert.Equal(t, env, test.wantEnv)
+		})
+	}
+}
+
+func Test_checkUUID(t *testing.T) {
+	gpuDevices := &NvidiaGPUDevices{
+		config: NvidiaConfig{
+			ResourceCountName:            "nvidia.com/gpu",
+			ResourceMemoryName:           "nvidia.com/gpumem",
+			ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+			ResourceCoreName:             "nvidia.com/gpucores",
+			DefaultGPUNum:                int32(1),
+		},
+	}
+	tests := []struct {
+		name string
+		args struct {
+			annos map[string]string
+			d     device.DeviceUsage
+		}
+		want bool
+	}{
+		{
+			name: "don't set GPUUseUUID and GPUNoUseUUID annotation",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: make(map[string]string),
+				d:     device.DeviceUsage{},
+			},
+			want: true,
+		},
+		{
+			name: "use set GPUUseUUID don't set GPUNoUseUUID annotation,device match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUUseUUID: "abc,123",
+				},
+				d: device.DeviceUsage{
+					ID: "abc",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "use set GPUUseUUID don't set GPUNoUseUUID annotation,device don't match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUUseUUID: "abc,123",
+				},
+				d: device.DeviceUsage{
+					ID: "1abc",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "use don't set GPUUseUUID set GPUNoUseUUID annotation,device match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUNoUseUUID: "abc,123",
+				},
+				d: device.DeviceUsage{
+					ID: "abc",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "use don't set GPUUseUUID set GPUNoUseUUID annotation,device  don't match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUNoUseUUID: "abc,123",
+				},
+				d: device.DeviceUsage{
+					ID: "1abc",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "use set GPUUseUUID with prefix pattern and whitespace,device match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUUseUUID: " GPU-abc* , 123,",
+				},
+				d: device.DeviceUsage{
+					ID: "GPU-abcdef",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "use set GPUNoUseUUID with prefix pattern,device match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUNoUseUUID: "GPU-abc*",
+				},
+				d: device.DeviceUsage{
+					ID: "GPU-abcdef",
+				},
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := gpuDevices.checkUUID(newUUIDFilter(test.args.annos), test.args.d)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func Test_checkType(t *testing.T) {
+	gpuDevices := &NvidiaGPUDevices{
+		config: NvidiaConfig{
+			ResourceCountName:            "nvidia.com/gpu",
+			ResourceMemoryName:           "nvidia.com/gpumem",
+			ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+			ResourceCoreName:             "nvidia.com/gpucores",
+			DefaultGPUNum:                int32(1),
+		},
+	}
+	tests := []struct {
+		name string
+		args struct {
+			annos map[string]string
+			d     device.DeviceUsage
+		}
+		want bool
+	}{
+		{
+			name: "use set GPUInUse don't set GPUNoUse annotation,device match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A10",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "use set GPUInUse set GPUNoUse annotation,device don't match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A10",
+					GPUNoUse: "A100",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "use set GPUInUse set GPUNoUse annotation,device match",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A10",
+					GPUNoUse: "A100",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A10",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "static MIG instance doesn't match its model",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A100",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100-MIG-2g.20gb",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "static MIG instance matches its profile",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A100-MIG-2g.20gb",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100-MIG-2g.20gb",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "static MIG instance doesn't match another profile",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A100-MIG-1g.10gb",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100-MIG-2g.20gb",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "exact GPUInUse doesn't match a longer model",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "=A10",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "exact GPUInUse matches its model",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "=A10",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A10",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "exact GPUNoUse only excludes its model",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUNoUse: "=A10",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "exact GPUNoUse excludes its model",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUNoUse: "=A10",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A10",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "glob GPUInUse matches a form factor",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A100-SXM*",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100-SXM4-80GB",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "glob GPUNoUse excludes a form factor",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A100",
+					GPUNoUse: "A100-SXM*",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100-SXM4-80GB",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "regexp GPUInUse",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "~^NVIDIA A100-PCIE",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100-PCIE-40GB",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "glob GPUInUse matches static MIG profiles",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "A100-MIG-*",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A100-MIG-2g.20gb",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "invalid GPUInUse matches no GPU",
+			args: struct {
+				annos map[string]string
+				d     device.DeviceUsage
+			}{
+				annos: map[string]string{
+					GPUInUse: "~A10(",
+				},
+				d: device.DeviceUsage{
+					Type: "NVIDIA A10",
+				},
+			},
+			want: false,
+		},
+	}
+	req := device.ContainerDeviceRequest{
+		Type: NvidiaGPUDevice,
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, _ := gpuDevices.checkType(test.args.annos, test.args.d, req)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func Test_FilterDeviceToRegister(t *testing.T) {
+	tests := []struct {
+		name string
+		args struct {
+			uuid string
+			idx  string
+			*FilterDevice
+		}
+		want bool
+	}{
+		{
+			name: "filter is nil",
+			args: struct {
+				uuid string
+				idx  string
+				*FilterDevice
+			}{
+				uuid:         "GPU-8dcd427f-483b-b48f-d7e5-75fb19a52b76",
+				idx:          "0",
+				FilterDevice: nil,
+			},
+			want: false,
+		},
+		{
+			name: "uuid is empty",
 			args: struct {
 				uuid string
 				idx  string
@@ -697,6 +1473,30 @@ func Test_PatchAnnotations(t *testing.T) {
 
 }
 
+func Test_PatchAnnotationsOOMMode(t *testing.T) {
+	InitNvidiaDevice(NvidiaConfig{})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.OOMModeAnnotationKey: "train=soft"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "sidecar"}, {Name: "train"}, {Name: "infer"}}},
+	}
+	devlist := device.PodSingleDevice{
+		{},
+		{{UUID: "GPU-0", Type: NvidiaGPUDevice, Usedmem: 2000, Usedcores: 30}, {UUID: "GPU-1", Type: NvidiaGPUDevice, Usedmem: 2000, Usedcores: 30}},
+		{{UUID: "GPU-0", Type: NvidiaGPUDevice, Usedmem: 1000, Usedcores: 10}},
+	}
+	result := (&NvidiaGPUDevices{}).PatchAnnotations(pod, &map[string]string{}, device.PodDevices{NvidiaGPUDevice: devlist})
+	want := ";GPU-0,NVIDIA,2000,30,0,0,soft:GPU-1,NVIDIA,2000,30,0,0,soft:;GPU-0,NVIDIA,1000,10:;"
+	assert.Equal(t, result[device.SupportDevices[NvidiaGPUDevice]], want)
+	// The devices allocated are unchanged, only the annotation records the mode.
+	assert.Equal(t, devlist[1][0].OOMMode, util.OOMMode(""))
+
+	pd, err := device.DecodePodDevices(device.SupportDevices, result)
+	assert.NilError(t, err)
+	assert.Equal(t, pd[NvidiaGPUDevice][0][1].OOMMode, util.OOMModeSoft)
+	assert.Equal(t, pd[NvidiaGPUDevice][1][0].OOMMode, util.OOMMode(""))
+	assert.Equal(t, pd[NvidiaGPUDevice][1][0].Usedmem, int32(1000))
+}
+
 func Test_GetNodeDevices(t *testing.T) {
 	tests := []struct {
 		name string
@@ -984,130 +1784,339 @@ func TestDevices_Fit(t *testing.T) {
 				Coresreq:         50,
 				Type:             NvidiaGPUDevice,
 			},
-			annos:      map[string]string{GPUNoUseUUID: "dev-0"},
+			annos:      map[string]string{GPUNoUseUUID: "dev-0"},
+			wantFit:    false,
+			wantLen:    0,
+			wantDevIDs: []string{},
+			wantReason: "1/1 CardUuidMismatch",
+		},
+		{
+			name: "fit fail: card overused",
+			devices: []*device.DeviceUsage{{
+				ID:        "dev-0",
+				Index:     0,
+				Used:      100,
+				Count:     100,
+				Usedmem:   0,
+				Totalmem:  1280,
+				Totalcore: 100,
+				Usedcores: 0,
+				Numa:      0,
+				Type:      NvidiaGPUDevice,
+				Health:    true,
+			}},
+			request: device.ContainerDeviceRequest{
+				Nums:             1,
+				Memreq:           512,
+				MemPercentagereq: 0,
+				Coresreq:         50,
+				Type:             NvidiaGPUDevice,
+			},
+			annos:      map[string]string{},
+			wantFit:    false,
+			wantLen:    0,
+			wantDevIDs: []string{},
+			wantReason: "1/1 CardTimeSlicingExhausted",
+		},
+		{
+			name: "fit success: but core limit can't exceed 100",
+			devices: []*device.DeviceUsage{{
+				ID:        "dev-0",
+				Index:     0,
+				Used:      0,
+				Count:     100,
+				Usedmem:   0,
+				Totalmem:  1280,
+				Totalcore: 100,
+				Usedcores: 0,
+				Numa:      0,
+				Type:      NvidiaGPUDevice,
+				Health:    true,
+			}},
+			request: device.ContainerDeviceRequest{
+				Nums:             1,
+				Memreq:           512,
+				MemPercentagereq: 0,
+				Coresreq:         120,
+				Type:             NvidiaGPUDevice,
+			},
+			annos:      map[string]string{},
+			wantFit:    true,
+			wantLen:    1,
+			wantDevIDs: []string{"dev-0"},
+			wantReason: "",
+		},
+		{
+			name: "fit fail:  card exclusively",
+			devices: []*device.DeviceUsage{{
+				ID:        "dev-0",
+				Index:     0,
+				Used:      20,
+				Count:     100,
+				Usedmem:   0,
+				Totalmem:  1280,
+				Totalcore: 100,
+				Usedcores: 0,
+				Numa:      0,
+				Type:      NvidiaGPUDevice,
+				Health:    true,
+			}},
+			request: device.ContainerDeviceRequest{
+				Nums:             1,
+				Memreq:           512,
+				MemPercentagereq: 0,
+				Coresreq:         100,
+				Type:             NvidiaGPUDevice,
+			},
+			annos:      map[string]string{},
+			wantFit:    false,
+			wantLen:    0,
+			wantDevIDs: []string{},
+			wantReason: "1/1 ExclusiveDeviceAllocateConflict",
+		},
+		{
+			name: "fit fail:  CardComputeUnitsExhausted",
+			devices: []*device.DeviceUsage{{
+				ID:        "dev-0",
+				Index:     0,
+				Used:      20,
+				Count:     100,
+				Usedmem:   0,
+				Totalmem:  1280,
+				Totalcore: 100,
+				Usedcores: 100,
+				Numa:      0,
+				Type:      NvidiaGPUDevice,
+				Health:    true,
+			}},
+			request: device.ContainerDeviceRequest{
+				Nums:             1,
+				Memreq:           512,
+				MemPercentagereq: 0,
+				Coresreq:         0,
+				Type:             NvidiaGPUDevice,
+			},
+			annos:      map[string]string{},
+			wantFit:    false,
+			wantLen:    0,
+			wantDevIDs: []string{},
+			wantReason: "1/1 CardComputeUnitsExhausted",
+		},
+		{
+			name: "fit fail:  AllocatedCardsInsufficientRequest",
+			devices: []*device.DeviceUsage{{
+				ID:        "dev-0",
+				Index:     0,
+				Used:      20,
+				Count:     100,
+				Usedmem:   0,
+				Totalmem:  1280,
+				Totalcore: 100,
+				Usedcores: 10,
+				Numa:      0,
+				Type:      NvidiaGPUDevice,
+				Health:    true,
+			}},
+			request: device.ContainerDeviceRequest{
+				Nums:             2,
+				Memreq:           512,
+				MemPercentagereq: 0,
+				Coresreq:         20,
+				Type:             NvidiaGPUDevice,
+			},
+			annos:      map[string]string{},
+			wantFit:    false,
+			wantLen:    0,
+			wantDevIDs: []string{},
+			wantReason: "1/1 AllocatedCardsInsufficientRequest",
+		},
+		{
+			name: "fit success:  memory percentage",
+			devices: []*device.DeviceUsage{{
+				ID:        "dev-0",
+				Index:     0,
+				Used:      20,
+				Count:     100,
+				Usedmem:   0,
+				Totalmem:  1280,
+				Totalcore: 100,
+				Usedcores: 10,
+				Numa:      0,
+				Type:      NvidiaGPUDevice,
+				Health:    true,
+			}},
+			request: device.ContainerDeviceRequest{
+				Nums:             1,
+				Memreq:           0,
+				MemPercentagereq: 10,
+				Coresreq:         20,
+				Type:             NvidiaGPUDevice,
+			},
+			annos:      map[string]string{},
+			wantFit:    true,
+			wantLen:    1,
+			wantDevIDs: []string{"dev-0"},
+			wantReason: "",
+		},
+		{
+			name: "fit fail:  CardNotHealth",
+			devices: []*device.DeviceUsage{{
+				ID:        "dev-0",
+				Index:     0,
+				Used:      20,
+				Count:     100,
+				Usedmem:   0,
+				Totalmem:  1280,
+				Totalcore: 100,
+				Usedcores: 10,
+				Numa:      0,
+				Type:      NvidiaGPUDevice,
+				Health:    false,
+			}},
+			request: device.ContainerDeviceRequest{
+				Nums:             1,
+				Memreq:           0,
+				MemPercentagereq: 10,
+				Coresreq:         20,
+				Type:             NvidiaGPUDevice,
+			},
+			annos:      map[string]string{},
 			wantFit:    false,
 			wantLen:    0,
 			wantDevIDs: []string{},
-			wantReason: "1/1 CardUuidMismatch",
+			wantReason: "1/1 CardNotHealth",
 		},
 		{
-			name: "fit fail: card overused",
+			name: "fit success: mps pod shares device with mps pods",
 			devices: []*device.DeviceUsage{{
 				ID:        "dev-0",
 				Index:     0,
-				Used:      100,
+				Used:      2,
 				Count:     100,
 				Usedmem:   0,
 				Totalmem:  1280,
 				Totalcore: 100,
-				Usedcores: 0,
+				Usedcores: 10,
 				Numa:      0,
 				Type:      NvidiaGPUDevice,
 				Health:    true,
+				PodInfos: []*device.PodInfo{
+					{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeMPS)}}}},
+					{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeMPS)}}}},
+				},
 			}},
 			request: device.ContainerDeviceRequest{
 				Nums:             1,
-				Memreq:           512,
-				MemPercentagereq: 0,
-				Coresreq:         50,
+				Memreq:           0,
+				MemPercentagereq: 10,
+				Coresreq:         20,
 				Type:             NvidiaGPUDevice,
 			},
-			annos:      map[string]string{},
-			wantFit:    false,
-			wantLen:    0,
-			wantDevIDs: []string{},
-			wantReason: "1/1 CardTimeSlicingExhausted",
+			annos:      map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeMPS)},
+			wantFit:    true,
+			wantLen:    1,
+			wantDevIDs: []string{"dev-0"},
+			wantReason: "",
 		},
 		{
-			name: "fit success: but core limit can't exceed 100",
+			name: "fit fail: mps pod on device used by time slicing pod",
 			devices: []*device.DeviceUsage{{
 				ID:        "dev-0",
 				Index:     0,
-				Used:      0,
+				Used:      1,
 				Count:     100,
 				Usedmem:   0,
 				Totalmem:  1280,
 				Totalcore: 100,
-				Usedcores: 0,
+				Usedcores: 10,
 				Numa:      0,
 				Type:      NvidiaGPUDevice,
 				Health:    true,
+				PodInfos: []*device.PodInfo{
+					{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: nil}}},
+				},
 			}},
 			request: device.ContainerDeviceRequest{
 				Nums:             1,
-				Memreq:           512,
-				MemPercentagereq: 0,
-				Coresreq:         120,
+				Memreq:           0,
+				MemPercentagereq: 10,
+				Coresreq:         20,
 				Type:             NvidiaGPUDevice,
 			},
-			annos:      map[string]string{},
-			wantFit:    true,
-			wantLen:    1,
-			wantDevIDs: []string{"dev-0"},
-			wantReason: "",
+			annos:      map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeMPS)},
+			wantFit:    false,
+			wantLen:    0,
+			wantDevIDs: []string{},
+			wantReason: "1/1 ComputeModeConflict",
 		},
 		{
-			name: "fit fail:  card exclusively",
+			name: "fit fail: time slicing pod on device used by mps pod",
 			devices: []*device.DeviceUsage{{
 				ID:        "dev-0",
 				Index:     0,
-				Used:      20,
+				Used:      1,
 				Count:     100,
 				Usedmem:   0,
 				Totalmem:  1280,
 				Totalcore: 100,
-				Usedcores: 0,
+				Usedcores: 10,
 				Numa:      0,
 				Type:      NvidiaGPUDevice,
 				Health:    true,
+				PodInfos: []*device.PodInfo{
+					{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeMPS)}}}},
+				},
 			}},
 			request: device.ContainerDeviceRequest{
 				Nums:             1,
-				Memreq:           512,
-				MemPercentagereq: 0,
-				Coresreq:         100,
+				Memreq:           0,
+				MemPercentagereq: 10,
+				Coresreq:         20,
 				Type:             NvidiaGPUDevice,
 			},
 			annos:      map[string]string{},
 			wantFit:    false,
 			wantLen:    0,
 			wantDevIDs: []string{},
-			wantReason: "1/1 ExclusiveDeviceAllocateConflict",
+			wantReason: "1/1 ComputeModeConflict",
 		},
 		{
-			name: "fit fail:  CardComputeUnitsExhausted",
+			name: "fit fail: exclusive pod on used device",
 			devices: []*device.DeviceUsage{{
 				ID:        "dev-0",
 				Index:     0,
-				Used:      20,
+				Used:      1,
 				Count:     100,
 				Usedmem:   0,
 				Totalmem:  1280,
 				Totalcore: 100,
-				Usedcores: 100,
+				Usedcores: 10,
 				Numa:      0,
 				Type:      NvidiaGPUDevice,
 				Health:    true,
+				PodInfos: []*device.PodInfo{
+					{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: nil}}},
+				},
 			}},
 			request: device.ContainerDeviceRequest{
 				Nums:             1,
-				Memreq:           512,
-				MemPercentagereq: 0,
-				Coresreq:         0,
+				Memreq:           0,
+				MemPercentagereq: 10,
+				Coresreq:         20,
 				Type:             NvidiaGPUDevice,
 			},
-			annos:      map[string]string{},
+			annos:      map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeExclusive)},
 			wantFit:    false,
 			wantLen:    0,
 			wantDevIDs: []string{},
-			wantReason: "1/1 CardComputeUnitsExhausted",
+			wantReason: "1/1 ComputeModeConflict",
 		},
 		{
-			name: "fit fail:  AllocatedCardsInsufficientRequest",
+			name: "fit fail: device used by exclusive pod",
 			devices: []*device.DeviceUsage{{
 				ID:        "dev-0",
 				Index:     0,
-				Used:      20,
+				Used:      1,
 				Count:     100,
 				Usedmem:   0,
 				Totalmem:  1280,
@@ -1116,11 +2125,14 @@ func TestDevices_Fit(t *testing.T) {
 				Numa:      0,
 				Type:      NvidiaGPUDevice,
 				Health:    true,
+				PodInfos: []*device.PodInfo{
+					{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeExclusive)}}}},
+				},
 			}},
 			request: device.ContainerDeviceRequest{
-				Nums:             2,
-				Memreq:           512,
-				MemPercentagereq: 0,
+				Nums:             1,
+				Memreq:           0,
+				MemPercentagereq: 10,
 				Coresreq:         20,
 				Type:             NvidiaGPUDevice,
 			},
@@ -1128,96 +2140,442 @@ func TestDevices_Fit(t *testing.T) {
 			wantFit:    false,
 			wantLen:    0,
 			wantDevIDs: []string{},
-			wantReason: "1/1 AllocatedCardsInsufficientRequest",
+			wantReason: "1/1 ComputeModeConflict",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allocated := &device.PodDevices{}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: test.annos,
+				},
+			}
+			fit, result, reason := dev.Fit(test.devices, test.request, pod, &device.NodeInfo{}, allocated)
+			if fit != test.wantFit {
+				t.Errorf("Fit: got %v, want %v", fit, test.wantFit)
+			}
+			if test.wantFit {
+				if len(result[NvidiaGPUDevice]) != test.wantLen {
+					t.Errorf("expected len: %d, got len %d", test.wantLen, len(result[NvidiaGPUDevice]))
+				}
+				for idx, id := range test.wantDevIDs {
+					if id != result[NvidiaGPUDevice][idx].UUID {
+						t.Errorf("expected device id: %s, got device id %s", id, result[NvidiaGPUDevice][idx].UUID)
+					}
+				}
+			}
+
+			if reason != test.wantReason {
+				t.Errorf("expected reason: %s, got reason: %s", test.wantReason, reason)
+			}
+		})
+	}
+}
+
+func TestDevices_FitExcludesUnhealthy(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	device.MaxTemperature, device.StrictMaxTemperature, device.ECCErrorWindow = 85, 75, time.Hour
+	defer func() { device.MaxTemperature, device.StrictMaxTemperature, device.ECCErrorWindow = 0, 0, 0 }()
+	eccError := time.Now().Add(-2 * time.Hour).Unix()
+	newDevices := func() []*device.DeviceUsage {
+		return []*device.DeviceUsage{
+			{ID: "dev-0", Count: 10, Totalmem: 1024, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, Temperature: 80},
+			{ID: "dev-1", Count: 10, Totalmem: 1024, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, Temperature: 60, ECCErrorTime: eccError},
+			{ID: "dev-2", Count: 10, Totalmem: 1024, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, Temperature: 90},
+		}
+	}
+	request := device.ContainerDeviceRequest{Nums: 1, Memreq: 512, MemPercentagereq: 101, Type: NvidiaGPUDevice}
+
+	tests := []struct {
+		name       string
+		annos      map[string]string
+		wantFit    bool
+		wantDevID  string
+		wantReason string
+	}{
+		{
+			name:      "overheated device is excluded, ECC errors out of the window are not",
+			wantFit:   true,
+			wantDevID: "dev-1",
+		},
+		{
+			name:       "strict policy excludes devices with any ECC errors",
+			annos:      map[string]string{util.GPUHealthPolicyAnnotationKey: string(util.GPUHealthPolicyStrict)},
+			wantFit:    false,
+			wantReason: "2/3 CardOverheated, 1/3 CardECCError",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			fit, result, reason := dev.Fit(newDevices(), request, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, test.wantFit)
+			if test.wantFit {
+				assert.Equal(t, result[NvidiaGPUDevice][0].UUID, test.wantDevID)
+				return
+			}
+			assert.Equal(t, common.ParseReason(reason)[common.CardOverheated], common.ParseReason(test.wantReason)[common.CardOverheated])
+			assert.Equal(t, common.ParseReason(reason)[common.CardECCError], common.ParseReason(test.wantReason)[common.CardECCError])
+		})
+	}
+}
+
+func TestDevices_FitDeviceAffinity(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	podInfo := func(name string, labels map[string]string) *device.PodInfo {
+		return &device.PodInfo{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, UID: k8stypes.UID(name), Labels: labels}}}
+	}
+	newDevices := func() []*device.DeviceUsage {
+		return []*device.DeviceUsage{
+			{ID: "dev-0", Count: 10, Used: 1, Totalmem: 1024, Usedmem: 256, Totalcore: 100, Type: NvidiaGPUDevice, Health: true,
+				PodInfos: []*device.PodInfo{podInfo("miner", map[string]string{"app": "miner"})}},
+			{ID: "dev-1", Count: 10, Used: 1, Totalmem: 1024, Usedmem: 256, Totalcore: 100, Type: NvidiaGPUDevice, Health: true,
+				PodInfos: []*device.PodInfo{podInfo("trainer", map[string]string{"app": "trainer"})}},
+		}
+	}
+	request := device.ContainerDeviceRequest{Nums: 1, Memreq: 512, MemPercentagereq: 101, Type: NvidiaGPUDevice}
+
+	tests := []struct {
+		name       string
+		annos      map[string]string
+		wantFit    bool
+		wantDevID  string
+		wantReason string
+	}{
+		{
+			name:      "anti-affinity skips the device hosting a matching pod",
+			annos:     map[string]string{util.DeviceAntiAffinityAnnotationKey: "app=trainer"},
+			wantFit:   true,
+			wantDevID: "dev-0",
+		},
+		{
+			name:      "affinity places the pod next to a matching pod",
+			annos:     map[string]string{util.DeviceAffinityAnnotationKey: "app=trainer"},
+			wantFit:   true,
+			wantDevID: "dev-1",
+		},
+		{
+			name:       "anti-affinity to every device",
+			annos:      map[string]string{util.DeviceAntiAffinityAnnotationKey: "app in (miner, trainer)"},
+			wantFit:    false,
+			wantReason: "2/2 CardAntiAffinityConflict",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			fit, result, reason := dev.Fit(newDevices(), request, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, test.wantFit)
+			if test.wantFit {
+				assert.Equal(t, result[NvidiaGPUDevice][0].UUID, test.wantDevID)
+				return
+			}
+			assert.Equal(t, common.ParseReason(reason)[common.CardAntiAffinityConflict], common.ParseReason(test.wantReason)[common.CardAntiAffinityConflict])
+		})
+	}
+}
+
+func TestDevices_FitMemoryEach(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	newDevices := func(free ...int32) []*device.DeviceUsage {
+		devices := make([]*device.DeviceUsage, 0, len(free))
+		for i, mem := range free {
+			devices = append(devices, &device.DeviceUsage{
+				ID: fmt.Sprintf("dev-%d", i), Index: uint(i), Count: 10, Totalmem: 32768, Usedmem: 32768 - mem, Totalcore: 100, Type: NvidiaGPUDevice, Health: true,
+			})
+		}
+		return devices
+	}
+
+	tests := []struct {
+		name       string
+		memEach    string
+		devices    []*device.DeviceUsage
+		wantFit    bool
+		wantDevs   []string
+		wantMems   []int32
+		wantReason string
+	}{
+		{
+			name:     "each gpu fits its memory",
+			memEach:  "20480,10240,10240,10240",
+			devices:  newDevices(24000, 12000, 12000, 12000, 8000),
+			wantFit:  true,
+			wantDevs: []string{"dev-0", "dev-3", "dev-2", "dev-1"},
+			wantMems: []int32{20480, 10240, 10240, 10240},
+		},
+		{
+			name:     "the larger memory on the gpu fitting it",
+			memEach:  "10240,20480",
+			devices:  newDevices(12000, 24000),
+			wantFit:  true,
+			wantDevs: []string{"dev-0", "dev-1"},
+			wantMems: []int32{10240, 20480},
+		},
+		{
+			name:       "no gpu fits the larger memory",
+			memEach:    "20480,10240,10240,10240",
+			devices:    newDevices(12000, 12000, 12000, 12000),
+			wantFit:    false,
+			wantReason: "1/4 CardInsufficientMemory",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.GPUMemoryEachAnnotationKey: test.memEach}}}
+			request := device.ContainerDeviceRequest{Nums: int32(len(strings.Split(test.memEach, ","))), MemPercentagereq: 100, Type: NvidiaGPUDevice}
+			fit, result, reason := dev.Fit(test.devices, request, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, test.wantFit)
+			if !test.wantFit {
+				assert.Equal(t, common.ParseReason(reason)[common.CardInsufficientMemory], common.ParseReason(test.wantReason)[common.CardInsufficientMemory])
+				return
+			}
+			devs, mems := []string{}, []int32{}
+			for _, d := range result[NvidiaGPUDevice] {
+				devs = append(devs, d.UUID)
+				mems = append(mems, d.Usedmem)
+			}
+			assert.DeepEqual(t, devs, test.wantDevs)
+			assert.DeepEqual(t, mems, test.wantMems)
+		})
+	}
+}
+
+func TestDevices_FitContiguousIndices(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	// newDevices returns 4 GPUs, of which the full ones have no memory free.
+	newDevices := func(full ...int) []*device.DeviceUsage {
+		devices := make([]*device.DeviceUsage, 0, 4)
+		for i := range 4 {
+			d := &device.DeviceUsage{
+				ID: fmt.Sprintf("dev-%d", i), Index: uint(i), Count: 10, Totalmem: 32768, Totalcore: 100, Type: NvidiaGPUDevice, Health: true,
+			}
+			if slices.Contains(full, i) {
+				d.Usedmem = d.Totalmem
+			}
+			devices = append(devices, d)
+		}
+		return devices
+	}
+
+	tests := []struct {
+		name       string
+		annos      map[string]string
+		devices    []*device.DeviceUsage
+		wantFit    bool
+		wantDevs   []string
+		wantReason string
+	}{
+		{
+			name:     "indices not considered",
+			devices:  newDevices(2),
+			wantFit:  true,
+			wantDevs: []string{"dev-3", "dev-1"},
+		},
+		{
+			name:     "contiguous indices preferred",
+			annos:    map[string]string{util.ContiguousIndicesAnnotationKey: "true"},
+			devices:  newDevices(2),
+			wantFit:  true,
+			wantDevs: []string{"dev-0", "dev-1"},
+		},
+		{
+			name:     "contiguous indices required",
+			annos:    map[string]string{util.ContiguousIndicesAnnotationKey: "required"},
+			devices:  newDevices(2),
+			wantFit:  true,
+			wantDevs: []string{"dev-0", "dev-1"},
+		},
+		{
+			name:     "no contiguous indices, preferred",
+			annos:    map[string]string{util.ContiguousIndicesAnnotationKey: "preferred"},
+			devices:  newDevices(1, 3),
+			wantFit:  true,
+			wantDevs: []string{"dev-2", "dev-0"},
+		},
+		{
+			name:       "no contiguous indices, required",
+			annos:      map[string]string{util.ContiguousIndicesAnnotationKey: "required"},
+			devices:    newDevices(1, 3),
+			wantFit:    false,
+			wantReason: "2/4 " + common.CardInsufficientMemory + ", 2/4 " + common.CardIndicesNotContiguous,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			request := device.ContainerDeviceRequest{Nums: 2, Memreq: 1024, Type: NvidiaGPUDevice}
+			fit, result, reason := dev.Fit(test.devices, request, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, test.wantFit)
+			if !test.wantFit {
+				assert.DeepEqual(t, common.ParseReason(reason), common.ParseReason(test.wantReason))
+				return
+			}
+			devs := []string{}
+			for _, d := range result[NvidiaGPUDevice] {
+				devs = append(devs, d.UUID)
+			}
+			assert.DeepEqual(t, devs, test.wantDevs)
+		})
+	}
+}
+
+func Test_contiguousDevices(t *testing.T) {
+	devs := device.ContainerDevices{{Idx: 5, UUID: "dev-5"}, {Idx: 2, UUID: "dev-2"}, {Idx: 3, UUID: "dev-3"}, {Idx: 3, UUID: "dev-3"}, {Idx: 6, UUID: "dev-6"}}
+	res, ok := contiguousDevices(devs, 2)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, res, device.ContainerDevices{{Idx: 2, UUID: "dev-2"}, {Idx: 3, UUID: "dev-3"}})
+	_, ok = contiguousDevices(devs, 3)
+	assert.Assert(t, !ok)
+}
+
+func TestMutateAdmissionMemoryEach(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName:  "nvidia.com/gpu",
+		ResourceMemoryName: "nvidia.com/gpumem",
+		ResourceCoreName:   "nvidia.com/gpucores",
+		DefaultGPUNum:      1,
+	}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.GPUMemoryEachAnnotationKey: "20480,10240,10240,10240"}}}
+
+	tests := []struct {
+		name    string
+		limits  corev1.ResourceList
+		want    bool
+		wantErr string
+	}{
+		{
+			name:   "count matches",
+			limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")},
+			want:   true,
+		},
+		{
+			name:    "count mismatches",
+			limits:  corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+			wantErr: "hami.io/gpumem-each sets the memory of 4 GPUs, but container ctr requests 2 GPUs",
+		},
+		{
+			name:    "defaulted count mismatches",
+			limits:  corev1.ResourceList{"nvidia.com/gpucores": resource.MustParse("50")},
+			wantErr: "container ctr requests 1 GPUs",
+		},
+		{
+			name: "container without gpu",
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctr := &corev1.Container{Name: "ctr", Resources: corev1.ResourceRequirements{Limits: test.limits}}
+			got, err := dev.MutateAdmission(ctr, pod, nil)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, test.want)
+		})
+	}
+}
+
+func TestDevices_FitSharedGPU(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	// newDevices returns devices used by the number of pods, each using 8192 MB and 20% of the cores.
+	newDevices := func(used ...int32) []*device.DeviceUsage {
+		devices := make([]*device.DeviceUsage, 0, len(used))
+		for i, n := range used {
+			devices = append(devices, &device.DeviceUsage{
+				ID: fmt.Sprintf("dev-%d", i), Index: uint(i), Count: 10, Used: n, Totalmem: 32768, Usedmem: n * 8192, Totalcore: 100, Usedcores: n * 20, Type: NvidiaGPUDevice, Health: true,
+			})
+		}
+		return devices
+	}
+
+	tests := []struct {
+		name       string
+		shared     string
+		nums       int32
+		devices    []*device.DeviceUsage
+		wantFit    bool
+		wantDevs   []string
+		wantMems   []int32
+		wantCores  []int32
+		wantReason string
+	}{
+		{
+			name:      "two exclusive and one shared",
+			shared:    "gpumem=4000,gpucores=30",
+			nums:      3,
+			devices:   newDevices(0, 1, 0, 0),
+			wantFit:   true,
+			wantDevs:  []string{"dev-3", "dev-2", "dev-1"},
+			wantMems:  []int32{32768, 32768, 4000},
+			wantCores: []int32{100, 100, 30},
 		},
 		{
-			name: "fit success:  memory percentage",
-			devices: []*device.DeviceUsage{{
-				ID:        "dev-0",
-				Index:     0,
-				Used:      20,
-				Count:     100,
-				Usedmem:   0,
-				Totalmem:  1280,
-				Totalcore: 100,
-				Usedcores: 10,
-				Numa:      0,
-				Type:      NvidiaGPUDevice,
-				Health:    true,
-			}},
-			request: device.ContainerDeviceRequest{
-				Nums:             1,
-				Memreq:           0,
-				MemPercentagereq: 10,
-				Coresreq:         20,
-				Type:             NvidiaGPUDevice,
-			},
-			annos:      map[string]string{},
-			wantFit:    true,
-			wantLen:    1,
-			wantDevIDs: []string{"dev-0"},
-			wantReason: "",
+			name:      "shared on a free gpu",
+			shared:    "gpumem-percentage=25,gpucores=30",
+			nums:      3,
+			devices:   newDevices(0, 0, 0),
+			wantFit:   true,
+			wantDevs:  []string{"dev-2", "dev-1", "dev-0"},
+			wantMems:  []int32{32768, 32768, 8192},
+			wantCores: []int32{100, 100, 30},
 		},
 		{
-			name: "fit fail:  CardNotHealth",
-			devices: []*device.DeviceUsage{{
-				ID:        "dev-0",
-				Index:     0,
-				Used:      20,
-				Count:     100,
-				Usedmem:   0,
-				Totalmem:  1280,
-				Totalcore: 100,
-				Usedcores: 10,
-				Numa:      0,
-				Type:      NvidiaGPUDevice,
-				Health:    false,
-			}},
-			request: device.ContainerDeviceRequest{
-				Nums:             1,
-				Memreq:           0,
-				MemPercentagereq: 10,
-				Coresreq:         20,
-				Type:             NvidiaGPUDevice,
-			},
-			annos:      map[string]string{},
+			name:       "not enough free gpus",
+			shared:     "gpumem=4000",
+			nums:       3,
+			devices:    newDevices(0, 1, 1),
 			wantFit:    false,
-			wantLen:    0,
-			wantDevIDs: []string{},
-			wantReason: "1/1 CardNotHealth",
+			wantReason: "1/3 ExclusiveDeviceAllocateConflict",
 		},
 	}
-
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			allocated := &device.PodDevices{}
-			pod := &corev1.Pod{
-				ObjectMeta: metav1.ObjectMeta{
-					Annotations: test.annos,
-				},
-			}
-			fit, result, reason := dev.Fit(test.devices, test.request, pod, &device.NodeInfo{}, allocated)
-			if fit != test.wantFit {
-				t.Errorf("Fit: got %v, want %v", fit, test.wantFit)
-			}
-			if test.wantFit {
-				if len(result[NvidiaGPUDevice]) != test.wantLen {
-					t.Errorf("expected len: %d, got len %d", test.wantLen, len(result[NvidiaGPUDevice]))
-				}
-				for idx, id := range test.wantDevIDs {
-					if id != result[NvidiaGPUDevice][idx].UUID {
-						t.Errorf("expected device id: %s, got device id %s", id, result[NvidiaGPUDevice][idx].UUID)
-					}
-				}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.SharedGPUAnnotationKey: test.shared}}}
+			request := device.ContainerDeviceRequest{Nums: test.nums, MemPercentagereq: 100, Type: NvidiaGPUDevice}
+			fit, result, reason := dev.Fit(test.devices, request, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, test.wantFit)
+			if !test.wantFit {
+				assert.Equal(t, common.ParseReason(reason)[common.ExclusiveDeviceAllocateConflict], common.ParseReason(test.wantReason)[common.ExclusiveDeviceAllocateConflict])
+				return
 			}
-
-			if reason != test.wantReason {
-				t.Errorf("expected reason: %s, got reason: %s", test.wantReason, reason)
+			devs, mems, cores := []string{}, []int32{}, []int32{}
+			for _, d := range result[NvidiaGPUDevice] {
+				devs = append(devs, d.UUID)
+				mems = append(mems, d.Usedmem)
+				cores = append(cores, d.Usedcores)
 			}
+			assert.DeepEqual(t, devs, test.wantDevs)
+			assert.DeepEqual(t, mems, test.wantMems)
+			assert.DeepEqual(t, cores, test.wantCores)
 		})
 	}
 }
 
+func TestMutateAdmissionSharedGPU(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName:  "nvidia.com/gpu",
+		ResourceMemoryName: "nvidia.com/gpumem",
+		ResourceCoreName:   "nvidia.com/gpucores",
+		DefaultGPUNum:      1,
+	}}
+	ctr := &corev1.Container{Name: "ctr", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("3")}}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{util.SharedGPUAnnotationKey: "gpumem=4000,gpucores=30"}}}
+	got, err := dev.MutateAdmission(ctr.DeepCopy(), pod, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, got)
+
+	pod.Annotations[util.GPUMemoryEachAnnotationKey] = "4000,4000,4000"
+	_, err = dev.MutateAdmission(ctr.DeepCopy(), pod, nil)
+	assert.ErrorContains(t, err, "hami.io/shared-gpu and hami.io/gpumem-each can't be set together")
+	assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+}
+
 func TestDevices_AddResourceUsage(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1268,3 +2626,562 @@ func TestDevices_AddResourceUsage(t *testing.T) {
 		})
 	}
 }
+
+func TestMutateAdmissionEncoderEngines(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName:   "hami.io/gpu",
+		ResourceMemoryName:  "hami.io/gpumem",
+		ResourceCoreName:    "hami.io/gpucores",
+		ResourceEncoderName: "hami.io/nvenc",
+		EncoderEngines:      2,
+		DefaultGPUNum:       1,
+	}}
+
+	ctr := &corev1.Container{Name: "transcode", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"hami.io/nvenc":  resource.MustParse("1"),
+		"hami.io/gpumem": resource.MustParse("1000"),
+	}}}
+	got, err := dev.MutateAdmission(ctr, &corev1.Pod{}, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, got)
+	// A GPU is defaulted, but not the cores, the engines are separate from them.
+	assert.Equal(t, ctr.Resources.Limits.Name("hami.io/gpu", resource.DecimalSI).Value(), int64(1))
+	_, ok := ctr.Resources.Limits["hami.io/gpucores"]
+	assert.Assert(t, !ok)
+	assert.Equal(t, ctr.Resources.Requests.Name("hami.io/nvenc", resource.DecimalSI).Value(), int64(1))
+	assert.DeepEqual(t, dev.GenerateResourceRequests(ctr), device.ContainerDeviceRequest{
+		Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101, Enginesreq: 1,
+	})
+
+	ctr = &corev1.Container{Name: "transcode", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"hami.io/gpu":   resource.MustParse("1"),
+		"hami.io/nvenc": resource.MustParse("3"),
+	}}}
+	_, err = dev.MutateAdmission(ctr, &corev1.Pod{}, nil)
+	assert.ErrorContains(t, err, "container transcode requests 3 hami.io/nvenc of each GPU, but a GPU has 2")
+	assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+
+	ctr = &corev1.Container{Name: "transcode", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"hami.io/gpu":   resource.MustParse("0"),
+		"hami.io/nvenc": resource.MustParse("1"),
+	}}}
+	_, err = dev.MutateAdmission(ctr, &corev1.Pod{}, nil)
+	assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+}
+
+func TestDevices_FitEncoderEngines(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{ResourceEncoderName: "hami.io/nvenc", EncoderEngines: 2})
+	// The GPU runs a compute job taking all the cores.
+	gpu := &device.DeviceUsage{
+		ID: "dev-0", Count: 10, Used: 1, Totalmem: 16000, Usedmem: 8000, Totalcore: 100, Usedcores: 100, Totalengines: 2,
+		Type: NvidiaGPUDevice, Health: true,
+	}
+	transcode := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101, Enginesreq: 1}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "transcode", Namespace: "default"}}
+
+	for range 2 {
+		fit, result, reason := dev.Fit([]*device.DeviceUsage{gpu}, transcode, pod, &device.NodeInfo{}, &device.PodDevices{})
+		assert.Assert(t, fit, "reason %s", reason)
+		assert.Equal(t, len(result[NvidiaGPUDevice]), 1)
+		ctr := result[NvidiaGPUDevice][0]
+		assert.Equal(t, ctr.Usedcores, int32(0))
+		assert.Equal(t, ctr.Usedengines, int32(1))
+		assert.NilError(t, dev.AddResourceUsage(pod, gpu, &ctr))
+	}
+	assert.Equal(t, gpu.Usedengines, int32(2))
+	assert.Equal(t, gpu.Usedcores, int32(100))
+
+	// The engines are all used.
+	fit, _, reason := dev.Fit([]*device.DeviceUsage{gpu}, transcode, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+	assert.Equal(t, common.ParseReason(reason)[common.CardInsufficientEngines], 1)
+
+	// Jobs without cores nor engines still don't fit a GPU whose cores are all used.
+	fit, _, reason = dev.Fit([]*device.DeviceUsage{gpu}, device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+	assert.Equal(t, common.ParseReason(reason)[common.CardComputeUnitsExhausted], 1)
+}
+
+func TestDevices_FitComputeOnly(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{
+		ResourceCountName:  "nvidia.com/gpu",
+		ResourceMemoryName: "nvidia.com/gpumem",
+		ResourceCoreName:   "nvidia.com/gpucores",
+		ComputeOnlyMemory:  256,
+	})
+	// The GPU runs a job taking most of the memory and half of the cores.
+	gpu := &device.DeviceUsage{
+		ID: "dev-0", Count: 10, Used: 1, Totalmem: 16000, Usedmem: 15000, Totalcore: 100, Usedcores: 50,
+		Type: NvidiaGPUDevice, Health: true,
+	}
+	computeOnly := dev.GenerateResourceRequests(&corev1.Container{Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		"nvidia.com/gpu":      resource.MustParse("1"),
+		"nvidia.com/gpumem":   resource.MustParse("0"),
+		"nvidia.com/gpucores": resource.MustParse("20"),
+	}}})
+	assert.Equal(t, computeOnly.Memreq, int32(256))
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "inference", Namespace: "default"}}
+
+	for range 2 {
+		fit, result, reason := dev.Fit([]*device.DeviceUsage{gpu}, computeOnly, pod, &device.NodeInfo{}, &device.PodDevices{})
+		assert.Assert(t, fit, "reason %s", reason)
+		ctr := result[NvidiaGPUDevice][0]
+		assert.Equal(t, ctr.Usedmem, int32(256))
+		assert.Equal(t, ctr.Usedcores, int32(20))
+		assert.NilError(t, dev.AddResourceUsage(pod, gpu, &ctr))
+	}
+	assert.Equal(t, gpu.Usedmem, int32(15512))
+	assert.Equal(t, gpu.Usedcores, int32(90))
+
+	// The cores left are too few.
+	fit, _, reason := dev.Fit([]*device.DeviceUsage{gpu}, computeOnly, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+	assert.Equal(t, common.ParseReason(reason)[common.CardInsufficientCore], 1)
+
+	// The memory left is too little.
+	gpu.Usedcores, gpu.Usedmem = 50, 15900
+	fit, _, reason = dev.Fit([]*device.DeviceUsage{gpu}, computeOnly, pod, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+	assert.Equal(t, common.ParseReason(reason)[common.CardInsufficientMemory], 1)
+}
+
+func TestDevices_FitMinDriverVersion(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	gpu := func(id, driver string) *device.DeviceUsage {
+		return &device.DeviceUsage{ID: id, Count: 10, Totalmem: 16000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, DriverVersion: driver}
+	}
+	req := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}
+	tests := []struct {
+		name       string
+		minDriver  string
+		devices    []*device.DeviceUsage
+		want       string
+		wantReason string
+	}{
+		{name: "no constraint", devices: []*device.DeviceUsage{gpu("dev-0", "")}, want: "dev-0"},
+		{name: "newer driver", minDriver: "535.104.05", devices: []*device.DeviceUsage{gpu("dev-0", "535.54.03"), gpu("dev-1", "550.54.15")}, want: "dev-1"},
+		{name: "same driver with leading zeros dropped", minDriver: "535.104.05", devices: []*device.DeviceUsage{gpu("dev-0", "535.104.5")}, want: "dev-0"},
+		{
+			name:       "older or unreported drivers",
+			minDriver:  "535.104.05",
+			devices:    []*device.DeviceUsage{gpu("dev-0", "535.54.03"), gpu("dev-1", ""), gpu("dev-2", "470.223.02")},
+			wantReason: "3/3 CardDriverTooOld",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cuda", Namespace: "default", Annotations: map[string]string{}}}
+			if test.minDriver != "" {
+				pod.Annotations[util.MinDriverVersionAnnotationKey] = test.minDriver
+			}
+			fit, result, reason := dev.Fit(test.devices, req, pod, &device.NodeInfo{}, &device.PodDevices{})
+			if test.wantReason != "" {
+				assert.Assert(t, !fit)
+				assert.Equal(t, reason, test.wantReason)
+				return
+			}
+			assert.Assert(t, fit, "reason %s", reason)
+			assert.Equal(t, result[NvidiaGPUDevice][0].UUID, test.want)
+		})
+	}
+}
+
+func TestDevices_FitRequiredECCMode(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	gpu := func(id string, mode util.ECCMode) *device.DeviceUsage {
+		return &device.DeviceUsage{ID: id, Count: 10, Totalmem: 16000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, ECCMode: mode}
+	}
+	// GPUs with ECC on, off and not reporting it.
+	mixed := func() []*device.DeviceUsage {
+		return []*device.DeviceUsage{gpu("dev-0", util.ECCModeOff), gpu("dev-1", util.ECCModeOn), gpu("dev-2", "")}
+	}
+	req := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}
+	tests := []struct {
+		name       string
+		eccMode    string
+		devices    []*device.DeviceUsage
+		want       string
+		wantReason string
+	}{
+		{name: "no requirement", devices: mixed(), want: "dev-2"},
+		{name: "ecc on", eccMode: "on", devices: mixed(), want: "dev-1"},
+		{name: "ecc off", eccMode: "off", devices: mixed(), want: "dev-0"},
+		{
+			name:       "no gpu with ecc on",
+			eccMode:    "on",
+			devices:    []*device.DeviceUsage{gpu("dev-0", util.ECCModeOff), gpu("dev-1", "")},
+			wantReason: "2/2 " + common.CardECCModeMismatch,
+		},
+		{
+			name:       "no gpu with ecc off",
+			eccMode:    "off",
+			devices:    []*device.DeviceUsage{gpu("dev-0", util.ECCModeOn), gpu("dev-1", "")},
+			wantReason: "2/2 " + common.CardECCModeMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cuda", Namespace: "default", Annotations: map[string]string{}}}
+			if test.eccMode != "" {
+				pod.Annotations[util.RequireECCAnnotationKey] = test.eccMode
+			}
+			fit, result, reason := dev.Fit(test.devices, req, pod, &device.NodeInfo{}, &device.PodDevices{})
+			if test.wantReason != "" {
+				assert.Assert(t, !fit)
+				assert.Equal(t, reason, test.wantReason)
+				return
+			}
+			assert.Assert(t, fit, "reason %s", reason)
+			assert.Equal(t, result[NvidiaGPUDevice][0].UUID, test.want)
+		})
+	}
+}
+
+func TestDevices_FitIndependentCoresAndMemory(t *testing.T) {
+	memoryHeavy := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 14000, MemPercentagereq: 101}
+	computeHeavy := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 2000, MemPercentagereq: 101, Coresreq: 100}
+	tests := []struct {
+		name        string
+		independent bool
+		requests    []device.ContainerDeviceRequest
+		wantReason  string
+	}{
+		{name: "memory heavy then compute heavy", independent: true, requests: []device.ContainerDeviceRequest{memoryHeavy, computeHeavy}},
+		{name: "compute heavy then memory heavy", independent: true, requests: []device.ContainerDeviceRequest{computeHeavy, memoryHeavy}},
+		{
+			name:       "memory heavy then compute heavy exclusively",
+			requests:   []device.ContainerDeviceRequest{memoryHeavy, computeHeavy},
+			wantReason: "1/1 " + common.ExclusiveDeviceAllocateConflict,
+		},
+		{
+			name:       "compute heavy then memory heavy exclusively",
+			requests:   []device.ContainerDeviceRequest{computeHeavy, memoryHeavy},
+			wantReason: "1/1 " + common.CardComputeUnitsExhausted,
+		},
+		{
+			name:        "memory exhausted",
+			independent: true,
+			requests:    []device.ContainerDeviceRequest{memoryHeavy, computeHeavy, {Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}},
+			wantReason:  "1/1 " + common.CardInsufficientMemory,
+		},
+		{
+			name:        "cores exhausted",
+			independent: true,
+			requests:    []device.ContainerDeviceRequest{memoryHeavy, computeHeavy, {Nums: 1, Type: NvidiaGPUDevice, MemPercentagereq: 101, Coresreq: 10}},
+			wantReason:  "1/1 " + common.CardInsufficientCore,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dev := InitNvidiaDevice(NvidiaConfig{IndependentCoresAndMemory: test.independent})
+			gpu := &device.DeviceUsage{ID: "dev-0", Count: 10, Totalmem: 16000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true}
+			for i, req := range test.requests {
+				pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}}
+				fit, result, reason := dev.Fit([]*device.DeviceUsage{gpu}, req, pod, &device.NodeInfo{}, &device.PodDevices{})
+				if i == len(test.requests)-1 && test.wantReason != "" {
+					assert.Assert(t, !fit)
+					assert.Equal(t, reason, test.wantReason)
+					return
+				}
+				assert.Assert(t, fit, "request %d: %s", i, reason)
+				assert.NilError(t, dev.AddResourceUsage(pod, gpu, &result[NvidiaGPUDevice][0]))
+			}
+			assert.Equal(t, gpu.Used, int32(2))
+		})
+	}
+}
+
+func TestDevices_FitMemoryOversubscription(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	// A GPU with 16000 MB of physical memory scaled up to 32000 MB, 12000 MB used of which 2000 MB oversubscribed
+	gpu := func() *device.DeviceUsage {
+		return &device.DeviceUsage{
+			ID: "dev-0", Count: 10, Totalmem: 32000, Physicalmem: 16000, Usedmem: 12000, Oversubscribedmem: 2000,
+			Totalcore: 100, Type: NvidiaGPUDevice, Health: true,
+		}
+	}
+	tests := []struct {
+		name       string
+		memreq     int32
+		allow      bool
+		wantOver   int32
+		wantReason string
+	}{
+		{name: "physical memory left", memreq: 6000, wantOver: 0},
+		{name: "physical memory exceeded", memreq: 8000, wantReason: "1/1 CardInsufficientPhysicalMemory"},
+		{name: "oversubscription allowed", memreq: 8000, allow: true, wantOver: 2000},
+		{name: "scaled memory exceeded", memreq: 24000, allow: true, wantReason: "1/1 CardInsufficientMemory"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cuda", Namespace: "default", Annotations: map[string]string{}}}
+			if test.allow {
+				pod.Annotations[util.AllowMemoryOversubscriptionAnnotationKey] = "true"
+			}
+			req := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: test.memreq, MemPercentagereq: 101}
+			n := gpu()
+			fit, result, reason := dev.Fit([]*device.DeviceUsage{n}, req, pod, &device.NodeInfo{}, &device.PodDevices{})
+			if test.wantReason != "" {
+				assert.Assert(t, !fit)
+				assert.Equal(t, reason, test.wantReason)
+				return
+			}
+			assert.Assert(t, fit, "reason %s", reason)
+			ctr := result[NvidiaGPUDevice][0]
+			assert.Equal(t, ctr.Oversubscribedmem, test.wantOver)
+			assert.NilError(t, dev.AddResourceUsage(pod, n, &ctr))
+			assert.Equal(t, n.Oversubscribedmem, 2000+test.wantOver)
+		})
+	}
+}
+
+func TestDevices_FitDevicePool(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	t.Cleanup(func() { device.DefaultDevicePool = "" })
+	gpu := func(id, pool string) *device.DeviceUsage {
+		return &device.DeviceUsage{ID: id, Count: 10, Totalmem: 16000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, Pool: pool}
+	}
+	// 2 GPUs of the node are in the prod pool and 1 in the dev pool
+	devices := []*device.DeviceUsage{gpu("dev-0", "prod"), gpu("dev-1", "prod"), gpu("dev-2", "dev")}
+	req := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}
+	tests := []struct {
+		name        string
+		pool        *string
+		defaultPool string
+		want        string
+		wantReason  string
+	}{
+		{name: "prod pool", pool: ptr.To("prod"), want: "dev-1"},
+		{name: "dev pool", pool: ptr.To("dev"), want: "dev-2"},
+		{name: "default pool", defaultPool: "dev", want: "dev-2"},
+		{name: "no pool by default", wantReason: "3/3 CardPoolMismatch"},
+		{name: "nonexistent pool", pool: ptr.To("staging"), defaultPool: "prod", wantReason: "3/3 CardPoolMismatch"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			device.DefaultDevicePool = test.defaultPool
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cuda", Namespace: "default", Annotations: map[string]string{}}}
+			if test.pool != nil {
+				pod.Annotations[util.DevicePoolAnnotationKey] = *test.pool
+			}
+			fit, result, reason := dev.Fit(devices, req, pod, &device.NodeInfo{}, &device.PodDevices{})
+			if test.wantReason != "" {
+				assert.Assert(t, !fit)
+				assert.Equal(t, reason, test.wantReason)
+				return
+			}
+			assert.Assert(t, fit, "reason %s", reason)
+			assert.Equal(t, result[NvidiaGPUDevice][0].UUID, test.want)
+		})
+	}
+}
+
+func TestDevices_FitVerifiedDevices(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	gpu := func(id string) *device.DeviceUsage {
+		return &device.DeviceUsage{ID: id, Count: 10, Totalmem: 16000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true}
+	}
+	devices := []*device.DeviceUsage{gpu("GPU-0"), gpu("GPU-1"), gpu("GPU-2")}
+	req := device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}
+	tests := []struct {
+		name       string
+		require    *string
+		verified   *string
+		want       string
+		wantReason string
+	}{
+		{name: "verified GPUs only", require: ptr.To("true"), verified: ptr.To("GPU-0,GPU-1"), want: "GPU-1"},
+		{name: "verified GPUs by prefix", require: ptr.To("true"), verified: ptr.To("GPU-0*"), want: "GPU-0"},
+		{name: "unverified GPUs allocated to other pods", verified: ptr.To("GPU-0,GPU-1"), want: "GPU-2"},
+		{name: "verification not required", require: ptr.To("false"), verified: ptr.To("GPU-0"), want: "GPU-2"},
+		{name: "no verified GPU on the node", require: ptr.To("true"), wantReason: "3/3 CardNotVerified"},
+		{name: "verified GPUs not on the node", require: ptr.To("true"), verified: ptr.To("GPU-3"), wantReason: "3/3 CardNotVerified"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cuda", Namespace: "default", Annotations: map[string]string{}}}
+			if test.require != nil {
+				pod.Annotations[util.RequireVerifiedAnnotationKey] = *test.require
+			}
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: map[string]string{}}}
+			if test.verified != nil {
+				node.Annotations[util.VerifiedDevicesAnnotationKey] = *test.verified
+			}
+			fit, result, reason := dev.Fit(devices, req, pod, &device.NodeInfo{ID: "node1", Node: node}, &device.PodDevices{})
+			if test.wantReason != "" {
+				assert.Assert(t, !fit)
+				assert.Equal(t, reason, test.wantReason)
+				return
+			}
+			assert.Assert(t, fit, "reason %s", reason)
+			assert.Equal(t, result[NvidiaGPUDevice][0].UUID, test.want)
+		})
+	}
+}
+
+func TestDevices_FitRuntimeNotReady(t *testing.T) {
+	dev := InitNvidiaDevice(NvidiaConfig{})
+	tests := []struct {
+		name       string
+		notReady   bool
+		mode       string
+		req        device.ContainerDeviceRequest
+		wantReason string
+	}{
+		{name: "slice of a ready GPU", req: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101, Coresreq: 30}},
+		{name: "slice of memory of a GPU not ready", notReady: true, req: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101, Coresreq: 100}, wantReason: "1/1 CardRuntimeNotReady"},
+		{name: "slice of cores of a GPU not ready", notReady: true, req: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, MemPercentagereq: 100, Coresreq: 50}, wantReason: "1/1 CardRuntimeNotReady"},
+		{name: "whole GPU not ready", notReady: true, req: device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice, MemPercentagereq: 100, Coresreq: 100}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			devices := []*device.DeviceUsage{{ID: "GPU-0", Count: 10, Totalmem: 16000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, RuntimeNotReady: test.notReady}}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cuda", Namespace: "default"}}
+			fit, result, reason := dev.Fit(devices, test.req, pod, &device.NodeInfo{}, &device.PodDevices{})
+			if test.wantReason != "" {
+				assert.Assert(t, !fit)
+				assert.Equal(t, reason, test.wantReason)
+				return
+			}
+			assert.Assert(t, fit, "reason %s", reason)
+			assert.Equal(t, result[NvidiaGPUDevice][0].UUID, "GPU-0")
+		})
+	}
+}
+
+func Test_ValidateDevicePools(t *testing.T) {
+	assert.NilError(t, ValidateDevicePools([]DevicePool{{Name: "prod", Index: []uint{0, 1}}, {Name: "dev", Index: []uint{2}, UUID: []string{"GPU-3"}}}))
+	assert.ErrorContains(t, ValidateDevicePools([]DevicePool{{Index: []uint{0}}}), "without name")
+	assert.ErrorContains(t, ValidateDevicePools([]DevicePool{{Name: "prod", Index: []uint{0, 1}}, {Name: "dev", Index: []uint{1}}}), "device 1 is in device pools prod and dev")
+}
+
+func TestGPUWeight(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName: "nvidia.com/gpu",
+		DefaultGPUNum:     1,
+		DefaultGPUWeight:  10,
+	}}
+	pd := device.PodDevices{NvidiaGPUDevice: device.PodSingleDevice{{{UUID: "GPU-0", Type: NvidiaGPUDevice, Usedmem: 1000, Usedcores: 50}}}}
+	tests := []struct {
+		name     string
+		weight   string
+		wantErr  bool
+		recorded string
+	}{
+		{name: "weight set", weight: "80", recorded: "80"},
+		{name: "default weight", recorded: "10"},
+		{name: "weight out of range", weight: "101", wantErr: true},
+		{name: "weight not an integer", weight: "high", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if test.weight != "" {
+				pod.Annotations[GPUWeight] = test.weight
+			}
+			ctr := &corev1.Container{Name: "serve", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}}}
+			_, err := dev.MutateAdmission(ctr, pod, nil)
+			if test.wantErr {
+				assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+				return
+			}
+			assert.NilError(t, err)
+			annos := dev.PatchAnnotations(pod, &map[string]string{}, pd)
+			assert.Equal(t, annos[GPUWeightAllocated], test.recorded)
+		})
+	}
+
+	// Pods without a weight or a default weight don't record any.
+	dev.config.DefaultGPUWeight = 0
+	annos := dev.PatchAnnotations(&corev1.Pod{}, &map[string]string{}, pd)
+	_, ok := annos[GPUWeightAllocated]
+	assert.Assert(t, !ok)
+}
+
+func TestMutateAdmissionGPUType(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName: "nvidia.com/gpu",
+		DefaultGPUNum:     1,
+	}}
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		wantErr bool
+	}{
+		{name: "no type annotations"},
+		{name: "valid entries", annos: map[string]string{GPUInUse: "=A10,A100-SXM*,~^NVIDIA H100", GPUNoUse: "A30"}},
+		{name: "invalid use-gputype regexp", annos: map[string]string{GPUInUse: "~A10("}, wantErr: true},
+		{name: "invalid nouse-gputype glob", annos: map[string]string{GPUNoUse: "A100-["}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			ctr := &corev1.Container{Name: "cuda", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}}}
+			_, err := dev.MutateAdmission(ctr, pod, nil)
+			if test.wantErr {
+				assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestPodGPURequest(t *testing.T) {
+	dev := &NvidiaGPUDevices{config: NvidiaConfig{
+		ResourceCountName:  "nvidia.com/gpu",
+		ResourceMemoryName: "nvidia.com/gpumem",
+		ResourceCoreName:   "nvidia.com/gpucores",
+		DefaultGPUNum:      1,
+		OverwriteEnv:       true,
+	}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			util.PodGPUMemoryAnnotationKey:    "4000",
+			util.PodGPUCoresAnnotationKey:     "30",
+			util.PodGPUContainerAnnotationKey: "infer",
+			util.PodGPUSidecarsAnnotationKey:  "proxy",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "proxy"}, {Name: "infer"}, {Name: "log"}}},
+	}
+
+	found, err := dev.MutateAdmission(&pod.Spec.Containers[0], pod, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, !found)
+	assert.DeepEqual(t, pod.Spec.Containers[0].Env, []corev1.EnvVar{{Name: VisibleDevicesEnv, ValueFrom: &corev1.EnvVarSource{
+		FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations['hami.io/pod-visible-devices']"},
+	}}})
+	assert.Equal(t, len(pod.Spec.Containers[0].Resources.Limits), 0)
+
+	found, err = dev.MutateAdmission(&pod.Spec.Containers[1], pod, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	limits := pod.Spec.Containers[1].Resources.Limits
+	assert.Equal(t, limits.Name("nvidia.com/gpu", resource.BinarySI).Value(), int64(1))
+	assert.Equal(t, limits.Name("nvidia.com/gpumem", resource.BinarySI).Value(), int64(4000))
+	assert.Equal(t, limits.Name("nvidia.com/gpucores", resource.BinarySI).Value(), int64(30))
+
+	// Containers neither requesting nor seeing the GPUs don't see any.
+	found, err = dev.MutateAdmission(&pod.Spec.Containers[2], pod, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, !found)
+	assert.DeepEqual(t, pod.Spec.Containers[2].Env, []corev1.EnvVar{{Name: VisibleDevicesEnv, Value: "none"}})
+
+	// Only the container the request is attributed to is allocated GPUs, the sidecar sees their UUIDs.
+	pd := device.PodDevices{NvidiaGPUDevice: device.PodSingleDevice{
+		{},
+		{{UUID: "GPU-0", Type: NvidiaGPUDevice, Usedmem: 4000, Usedcores: 30}},
+		{},
+	}}
+	annos := dev.PatchAnnotations(pod, &map[string]string{}, pd)
+	assert.Equal(t, annos[util.PodVisibleDevicesAnnotationKey], "GPU-0")
+
+	// The pod level request can't be combined with the requests of its containers.
+	for _, idx := range []int{0, 1} {
+		ctr := corev1.Container{Name: pod.Spec.Containers[idx].Name, Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{"nvidia.com/gpumem": resource.MustParse("1000")},
+		}}
+		_, err = dev.MutateAdmission(&ctr, pod, nil)
+		assert.Assert(t, errors.Is(err, device.ErrInvalidRequest), "container %s", ctr.Name)
+	}
+}
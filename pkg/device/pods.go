@@ -69,6 +69,8 @@ func (m *PodManager) AddPod(pod *corev1.Pod, nodeID string, devices PodDevices)
 			"devices", devices,
 		)
 	} else {
+		// Keep the latest pod, so filters on its labels and annotations see their current values.
+		m.pods[pod.UID].Pod = pod
 		m.pods[pod.UID].Devices = devices
 		klog.V(5).InfoS("Pod devices updated",
 			"pod", klog.KRef(pod.Namespace, pod.Name),
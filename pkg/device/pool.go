@@ -0,0 +1,37 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// DefaultDevicePool is the device pool of pods not setting util.DevicePoolAnnotationKey, the devices in no
+// pool if empty.
+var DefaultDevicePool string
+
+// PodDevicePool returns the device pool the devices of the pod must be in, empty for the devices in no pool.
+func PodDevicePool(pod *corev1.Pod) string {
+	if pool, ok := pod.GetAnnotations()[util.DevicePoolAnnotationKey]; ok {
+		return strings.TrimSpace(pool)
+	}
+	return DefaultDevicePool
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// OpportunisticMem returns the part of usedmem allocated to the pod on a device beyond its guaranteed memory,
+// 0 unless the pod is burstable.
+func OpportunisticMem(pod *corev1.Pod, usedmem int32) int32 {
+	if qos, _ := util.GetQoSClass(pod); qos != util.QoSBurstable {
+		return 0
+	}
+	guaranteed, _ := util.GetGuaranteedMemory(pod)
+	return max(usedmem-guaranteed, 0)
+}
+
+// AvailableMem returns the memory of the device available to a pod of the QoS class. Opportunistic memory of
+// burstable pods is available to guaranteed pods, while burstable pods only claim memory no pod uses.
+func AvailableMem(dev *DeviceUsage, qos util.QoSClass) int32 {
+	if qos == util.QoSBurstable {
+		return dev.Totalmem - dev.Usedmem
+	}
+	return dev.Totalmem - dev.Usedmem + dev.Opportunisticmem
+}
+
+// OversubscribedMem returns the part of memreq beyond the physical memory left on the device, 0 if the device
+// memory isn't scaled up.
+func OversubscribedMem(dev *DeviceUsage, memreq int32) int32 {
+	if dev.Physicalmem == 0 {
+		return 0
+	}
+	return max(dev.Usedmem-dev.Oversubscribedmem+memreq-dev.Physicalmem, 0)
+}
+
+// ReclaimedMem returns the opportunistic memory of the device reclaimed by guaranteed pods, the amount burstable
+// pods on it have logically shrunk by.
+func ReclaimedMem(dev *DeviceUsage) int32 {
+	return max(dev.Usedmem-dev.Totalmem, 0)
+}
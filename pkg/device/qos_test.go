@@ -0,0 +1,59 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func TestOpportunisticMem(t *testing.T) {
+	tests := []struct {
+		name  string
+		annos map[string]string
+		want  int32
+	}{
+		{name: "guaranteed", annos: map[string]string{util.GuaranteedMemoryAnnotationKey: "1000"}, want: 0},
+		{name: "burstable", annos: map[string]string{util.QoSAnnotationKey: "burstable", util.GuaranteedMemoryAnnotationKey: "1000"}, want: 3000},
+		{name: "burstable without guaranteed memory", annos: map[string]string{util.QoSAnnotationKey: "burstable"}, want: 4000},
+		{name: "guaranteed memory above the request", annos: map[string]string{util.QoSAnnotationKey: "burstable", util.GuaranteedMemoryAnnotationKey: "5000"}, want: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			assert.Equal(t, OpportunisticMem(pod, 4000), test.want)
+		})
+	}
+}
+
+func TestAvailableMem(t *testing.T) {
+	dev := &DeviceUsage{Totalmem: 8000, Usedmem: 6000, Opportunisticmem: 4000}
+	assert.Equal(t, AvailableMem(dev, util.QoSBurstable), int32(2000))
+	assert.Equal(t, AvailableMem(dev, util.QoSGuaranteed), int32(6000))
+	assert.Equal(t, ReclaimedMem(dev), int32(0))
+
+	// A guaranteed pod took 5000 of which 3000 were opportunistic.
+	dev.Usedmem += 5000
+	assert.Equal(t, AvailableMem(dev, util.QoSBurstable), int32(-3000))
+	assert.Equal(t, AvailableMem(dev, util.QoSGuaranteed), int32(1000))
+	assert.Equal(t, ReclaimedMem(dev), int32(3000))
+}
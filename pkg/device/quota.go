@@ -21,6 +21,7 @@ import (
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 )
 
@@ -31,9 +32,17 @@ type Quota struct {
 
 type DeviceQuota map[string]*Quota
 
+// podUsage is the usage attributed to a pod, by resource name.
+type podUsage struct {
+	namespace string
+	usage     map[string]int64
+}
+
 type QuotaManager struct {
 	Quotas map[string]*DeviceQuota
-	mutex  sync.RWMutex
+	// Usage attributed to pods by UID, so a pod is counted once however many times its usage is added.
+	pods  map[k8stypes.UID]podUsage
+	mutex sync.RWMutex
 }
 
 var localCache QuotaManager
@@ -48,6 +57,7 @@ func NewQuotaManager() *QuotaManager {
 	once.Do(func() {
 		localCache = QuotaManager{
 			Quotas: make(map[string]*DeviceQuota),
+			pods:   make(map[k8stypes.UID]podUsage),
 		}
 	})
 	return &localCache
@@ -106,30 +116,20 @@ func countPodDevices(podDev PodDevices) map[string]int64 {
 	return res
 }
 
+// AddUsage attributes the usage of podDev to the pod, replacing the usage attributed to it before.
 func (q *QuotaManager) AddUsage(pod *corev1.Pod, podDev PodDevices) {
 	usage := countPodDevices(podDev)
-	if len(usage) == 0 {
-		return
-	}
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
-	if q.Quotas[pod.Namespace] == nil {
-		q.Quotas[pod.Namespace] = &DeviceQuota{}
-	}
-	dp, ok := q.Quotas[pod.Namespace]
-	if !ok {
+	q.rmPodUsage(pod.UID)
+	if len(usage) == 0 {
 		return
 	}
-	for idx, val := range usage {
-		_, ok := (*dp)[idx]
-		if !ok {
-			(*dp)[idx] = &Quota{
-				Used:  0,
-				Limit: 0,
-			}
-		}
-		(*dp)[idx].Used += val
+	if q.pods == nil {
+		q.pods = make(map[k8stypes.UID]podUsage)
 	}
+	q.pods[pod.UID] = podUsage{namespace: pod.Namespace, usage: usage}
+	q.addNamespaceUsage(pod.Namespace, usage, 1)
 	if klog.V(4).Enabled() {
 		for _, val := range q.Quotas {
 			for idx, val1 := range *val {
@@ -139,23 +139,13 @@ func (q *QuotaManager) AddUsage(pod *corev1.Pod, podDev PodDevices) {
 	}
 }
 
-func (q *QuotaManager) RmUsage(pod *corev1.Pod, podDev PodDevices) {
-	usage := countPodDevices(podDev)
-	if len(usage) == 0 {
-		return
-	}
+// RmUsage removes the usage attributed to the pod.
+func (q *QuotaManager) RmUsage(pod *corev1.Pod) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
-	dp, ok := q.Quotas[pod.Namespace]
-	if !ok {
+	if !q.rmPodUsage(pod.UID) {
 		return
 	}
-	for idx, val := range usage {
-		_, ok = (*dp)[idx]
-		if ok {
-			(*dp)[idx].Used -= val
-		}
-	}
 	if klog.V(4).Enabled() {
 		for _, val := range q.Quotas {
 			for idx, val1 := range *val {
@@ -165,6 +155,72 @@ func (q *QuotaManager) RmUsage(pod *corev1.Pod, podDev PodDevices) {
 	}
 }
 
+// rmPodUsage removes the usage attributed to the pod with uid, it returns false if there is none.
+func (q *QuotaManager) rmPodUsage(uid k8stypes.UID) bool {
+	pu, ok := q.pods[uid]
+	if !ok {
+		return false
+	}
+	delete(q.pods, uid)
+	q.addNamespaceUsage(pu.namespace, pu.usage, -1)
+	return true
+}
+
+func (q *QuotaManager) addNamespaceUsage(ns string, usage map[string]int64, sign int64) {
+	if q.Quotas[ns] == nil {
+		q.Quotas[ns] = &DeviceQuota{}
+	}
+	dp := q.Quotas[ns]
+	for idx, val := range usage {
+		if _, ok := (*dp)[idx]; !ok {
+			(*dp)[idx] = &Quota{}
+		}
+		(*dp)[idx].Used += sign * val
+	}
+}
+
+// Reconcile drops the usage attributed to pods for which cached returns false, and recomputes the usage of
+// every namespace from the usage attributed to pods. It returns the number of usages found out of sync.
+func (q *QuotaManager) Reconcile(cached func(uid k8stypes.UID) bool) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for uid, pu := range q.pods {
+		if !cached(uid) {
+			klog.InfoS("Dropping quota usage of pod no longer cached", "podUID", uid, "namespace", pu.namespace, "usage", pu.usage)
+			delete(q.pods, uid)
+		}
+	}
+	expected := make(map[string]map[string]int64)
+	for _, pu := range q.pods {
+		if expected[pu.namespace] == nil {
+			expected[pu.namespace] = make(map[string]int64)
+		}
+		for idx, val := range pu.usage {
+			expected[pu.namespace][idx] += val
+		}
+	}
+	drifted := 0
+	for ns, dp := range q.Quotas {
+		for idx, quota := range *dp {
+			if want := expected[ns][idx]; quota.Used != want {
+				klog.InfoS("Correcting quota usage out of sync", "namespace", ns, "resource", idx, "used", quota.Used, "expected", want)
+				quota.Used = want
+				drifted++
+			}
+		}
+	}
+	for ns, usage := range expected {
+		for idx, val := range usage {
+			if dp := q.Quotas[ns]; dp == nil || (*dp)[idx] == nil {
+				klog.InfoS("Correcting quota usage out of sync", "namespace", ns, "resource", idx, "used", 0, "expected", val)
+				q.addNamespaceUsage(ns, map[string]int64{idx: val}, 1)
+				drifted++
+			}
+		}
+	}
+	return drifted
+}
+
 func IsManagedQuota(quotaName string) bool {
 	for _, val := range GetDevices() {
 		names := val.GetResourceNames()
@@ -32,7 +32,7 @@ func (m *MockDevices) CommonWord() string {
 	return "mock"
 }
 
-func (m *MockDevices) MutateAdmission(ctr *corev1.Container, pod *corev1.Pod) (bool, error) {
+func (m *MockDevices) MutateAdmission(ctr *corev1.Container, pod *corev1.Pod, warnings *AdmissionWarnings) (bool, error) {
 	return true, nil
 }
 
@@ -183,7 +183,7 @@ func TestAddUsageAndRmUsage(t *testing.T) {
 		t.Errorf("AddUsage: expected Used core 100, got %d", (*qm.Quotas[ns])[coreName].Used)
 	}
 
-	qm.RmUsage(pod, podDev)
+	qm.RmUsage(pod)
 	if (*qm.Quotas[ns])[memName].Used != 0 {
 		t.Errorf("RmUsage: expected Used memory 0, got %d", (*qm.Quotas[ns])[memName].Used)
 	}
@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// TypeExactPrefix marks an entry of a device type list as an exact match, ie: "=A10".
+	TypeExactPrefix = "="
+	// TypeRegexpPrefix marks an entry of a device type list as a regular expression, ie: "~^NVIDIA A10(0|G)?$".
+	TypeRegexpPrefix = "~"
+)
+
+// TypePattern is an entry of a comma separated device type list, such as the value of
+// use-gputype/nouse-gputype annotations, matched case-insensitively against device types. The vendors
+// selecting devices by type annotations, NVIDIA, Hygon and Cambricon, match them this way; the others
+// only select devices by UUID.
+//   - "=<type>" matches the device type, or its part following a "-" or " ", exactly:
+//     "=A10" matches "NVIDIA A10" but not "NVIDIA A100".
+//   - "~<regexp>" matches the device types matching the regular expression anywhere.
+//   - an entry containing "*", "?" or "[" is a glob pattern, matched like an exact entry:
+//     "A100-SXM*" matches "NVIDIA A100-SXM4-80GB" but not "NVIDIA A100-PCIE-40GB".
+//   - any other entry matches the device types containing it: "A10" matches both
+//     "NVIDIA A10" and "NVIDIA A100".
+type TypePattern struct {
+	entry string
+	match func(cardtype string) bool
+}
+
+// ParseTypePatterns parses a comma separated device type list. Surrounding whitespace is
+// trimmed and empty entries are dropped. Invalid entries are reported in the error and
+// left out of the patterns returned, so they never match.
+func ParseTypePatterns(str string) ([]TypePattern, error) {
+	var patterns []TypePattern
+	var errs []error
+	for entry := range strings.SplitSeq(str, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		p, err := parseTypePattern(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, errors.Join(errs...)
+}
+
+func parseTypePattern(entry string) (TypePattern, error) {
+	p := TypePattern{entry: entry}
+	upper := strings.ToUpper(entry)
+	switch {
+	case strings.HasPrefix(entry, TypeRegexpPrefix):
+		re, err := regexp.Compile("(?i)" + strings.TrimPrefix(entry, TypeRegexpPrefix))
+		if err != nil {
+			return p, fmt.Errorf("invalid device type regular expression %q: %w", entry, err)
+		}
+		p.match = re.MatchString
+	case strings.HasPrefix(entry, TypeExactPrefix):
+		exact := strings.TrimSpace(strings.TrimPrefix(upper, TypeExactPrefix))
+		if exact == "" {
+			return p, fmt.Errorf("invalid device type %q, the exact type is empty", entry)
+		}
+		p.match = func(cardtype string) bool {
+			return anyTypeSuffix(cardtype, func(suffix string) bool { return suffix == exact })
+		}
+	case strings.ContainsAny(entry, "*?["):
+		if _, err := path.Match(upper, ""); err != nil {
+			return p, fmt.Errorf("invalid device type glob pattern %q: %w", entry, err)
+		}
+		p.match = func(cardtype string) bool {
+			return anyTypeSuffix(cardtype, func(suffix string) bool {
+				ok, _ := path.Match(upper, suffix)
+				return ok
+			})
+		}
+	default:
+		p.match = func(cardtype string) bool { return strings.Contains(strings.ToUpper(cardtype), upper) }
+	}
+	return p, nil
+}
+
+// anyTypeSuffix reports whether match accepts the upper-cased device type or one of its
+// parts following a "-" or " ", so the vendor prefix of the type is optional.
+func anyTypeSuffix(cardtype string, match func(string) bool) bool {
+	cardtype = strings.ToUpper(cardtype)
+	if match(cardtype) {
+		return true
+	}
+	for i, r := range cardtype {
+		if (r == '-' || r == ' ') && match(cardtype[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the device type matches the pattern.
+func (p TypePattern) Matches(cardtype string) bool {
+	return p.match != nil && p.match(cardtype)
+}
+
+// String returns the entry the pattern was parsed from.
+func (p TypePattern) String() string {
+	return p.entry
+}
+
+// MatchesAnyType reports whether the device type matches one of the patterns.
+func MatchesAnyType(patterns []TypePattern, cardtype string) bool {
+	for _, p := range patterns {
+		if p.Matches(cardtype) {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeAllowed reports whether the device type is allowed by the useAnno annotation, or if it's not set by the
+// noUseAnno annotation, of a pod. An empty useAnno annotation allows every type, one with only invalid entries
+// none.
+func TypeAllowed(annos map[string]string, useAnno, noUseAnno, cardtype string) bool {
+	if inuse, ok := annos[useAnno]; ok {
+		if useTypes, err := ParseTypePatterns(inuse); useTypes != nil || err != nil {
+			return MatchesAnyType(useTypes, cardtype)
+		}
+		return true
+	}
+	if nouse, ok := annos[noUseAnno]; ok {
+		unuseTypes, _ := ParseTypePatterns(nouse)
+		return !MatchesAnyType(unuseTypes, cardtype)
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseTypePatterns(t *testing.T) {
+	const (
+		a10        = "NVIDIA A10"
+		a100       = "NVIDIA A100-PCIE-40GB"
+		a100SXM    = "NVIDIA A100-SXM4-80GB"
+		a10Dashed  = "NVIDIA-NVIDIA-A10"
+		a100Dashed = "NVIDIA-A100"
+	)
+	tests := []struct {
+		name     string
+		input    string
+		err      bool
+		len      int
+		match    []string
+		notMatch []string
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			len:      0,
+			notMatch: []string{a10, a100},
+		},
+		{
+			name:  "substring matches every model containing it",
+			input: "A10",
+			len:   1,
+			match: []string{a10, a100, a100SXM, a10Dashed, a100Dashed},
+		},
+		{
+			name:     "substring is case-insensitive",
+			input:    "sxm",
+			len:      1,
+			match:    []string{a100SXM},
+			notMatch: []string{a10, a100},
+		},
+		{
+			name:     "exact entry only matches its model",
+			input:    "=A10",
+			len:      1,
+			match:    []string{a10, a10Dashed, "a10"},
+			notMatch: []string{a100, a100SXM, a100Dashed, "NVIDIA A10G"},
+		},
+		{
+			name:     "exact entry with the vendor prefix",
+			input:    "=nvidia a100-sxm4-80gb",
+			len:      1,
+			match:    []string{a100SXM},
+			notMatch: []string{a10, a100},
+		},
+		{
+			name:     "exact entry doesn't match a part of a suffix",
+			input:    "=SXM4",
+			len:      1,
+			notMatch: []string{a100SXM},
+		},
+		{
+			name:     "glob matches the models of a form factor",
+			input:    "A100-SXM*",
+			len:      1,
+			match:    []string{a100SXM},
+			notMatch: []string{a10, a100, a100Dashed},
+		},
+		{
+			name:     "glob matches a single character",
+			input:    "A10?",
+			len:      1,
+			match:    []string{"NVIDIA A10G", "NVIDIA A100"},
+			notMatch: []string{a10, a100, a100SXM},
+		},
+		{
+			name:     "glob with a character class",
+			input:    "A100-[ps]*",
+			len:      1,
+			match:    []string{a100, a100SXM},
+			notMatch: []string{a10, a100Dashed},
+		},
+		{
+			name:     "regular expression",
+			input:    "~a100-(pcie|sxm4)-40gb$",
+			len:      1,
+			match:    []string{a100},
+			notMatch: []string{a10, a100SXM},
+		},
+		{
+			name:     "anchored regular expression",
+			input:    "~^NVIDIA A10$",
+			len:      1,
+			match:    []string{a10},
+			notMatch: []string{a100, a10Dashed},
+		},
+		{
+			name:     "mixed entries",
+			input:    " =A10 , A100-SXM* ,, ~-pcie-",
+			len:      3,
+			match:    []string{a10, a100, a100SXM},
+			notMatch: []string{a100Dashed, "NVIDIA H100"},
+		},
+		{
+			name:     "invalid regular expression",
+			input:    "~A10(",
+			err:      true,
+			len:      0,
+			notMatch: []string{a10},
+		},
+		{
+			name:     "invalid glob is dropped, valid entries kept",
+			input:    "A100-[*,=A10",
+			err:      true,
+			len:      1,
+			match:    []string{a10},
+			notMatch: []string{a100},
+		},
+		{
+			name:  "empty exact entry",
+			input: "= ",
+			err:   true,
+			len:   0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patterns, err := ParseTypePatterns(test.input)
+			assert.Equal(t, err != nil, test.err, "err: %v", err)
+			assert.Equal(t, len(patterns), test.len)
+			for _, cardtype := range test.match {
+				assert.Assert(t, MatchesAnyType(patterns, cardtype), "expected %q to match %q", test.input, cardtype)
+			}
+			for _, cardtype := range test.notMatch {
+				assert.Assert(t, !MatchesAnyType(patterns, cardtype), "expected %q not to match %q", test.input, cardtype)
+			}
+		})
+	}
+}
+
+func TestTypePatternString(t *testing.T) {
+	patterns, err := ParseTypePatterns(" =A10 ,A100-MIG-*")
+	assert.NilError(t, err)
+	assert.Equal(t, patterns[0].String(), "=A10")
+	assert.Equal(t, patterns[1].String(), "A100-MIG-*")
+	assert.Assert(t, !TypePattern{}.Matches("NVIDIA A10"))
+}
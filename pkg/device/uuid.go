@@ -0,0 +1,83 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"slices"
+	"strings"
+)
+
+// UUIDPrefixWildcard marks an entry of a uuid list as a prefix pattern, ie: "GPU-AAA*".
+const UUIDPrefixWildcard = "*"
+
+// UUIDSet is the parsed form of a comma separated uuid list, such as the value of
+// use-gpuuuid/nouse-gpuuuid annotations. It should be parsed once per pod and
+// reused for every device checked during fit.
+type UUIDSet struct {
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+// ParseUUIDSet parses a comma separated uuid list. Surrounding whitespace is trimmed,
+// empty entries and duplicates are dropped, entries ending with "*" are prefix patterns.
+func ParseUUIDSet(str string) *UUIDSet {
+	set := &UUIDSet{
+		exact: make(map[string]struct{}),
+	}
+	for entry := range strings.SplitSeq(str, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(entry, UUIDPrefixWildcard); ok {
+			prefix = strings.TrimSpace(prefix)
+			if prefix == "" {
+				continue
+			}
+			if !slices.Contains(set.prefixes, prefix) {
+				set.prefixes = append(set.prefixes, prefix)
+			}
+			continue
+		}
+		set.exact[entry] = struct{}{}
+	}
+	return set
+}
+
+// Contains reports whether uuid matches one of the exact entries or prefix patterns.
+func (s *UUIDSet) Contains(uuid string) bool {
+	if s == nil {
+		return false
+	}
+	if _, ok := s.exact[uuid]; ok {
+		return true
+	}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(uuid, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of distinct exact entries and prefix patterns.
+func (s *UUIDSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.exact) + len(s.prefixes)
+}
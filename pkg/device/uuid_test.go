@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseUUIDSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		len      int
+		match    []string
+		notMatch []string
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			len:      0,
+			notMatch: []string{"", "GPU-0"},
+		},
+		{
+			name:     "single uuid",
+			input:    "GPU-0",
+			len:      1,
+			match:    []string{"GPU-0"},
+			notMatch: []string{"GPU-01", "1GPU-0"},
+		},
+		{
+			name:     "empty entries and trailing comma",
+			input:    ",GPU-0,,GPU-1,",
+			len:      2,
+			match:    []string{"GPU-0", "GPU-1"},
+			notMatch: []string{""},
+		},
+		{
+			name:  "duplicate uuids",
+			input: "GPU-0,GPU-0,GPU-1*,GPU-1*",
+			len:   2,
+			match: []string{"GPU-0", "GPU-1", "GPU-10"},
+		},
+		{
+			name:     "whitespace around entries",
+			input:    " GPU-0 ,\tGPU-1\n, GPU-2 * ",
+			len:      3,
+			match:    []string{"GPU-0", "GPU-1", "GPU-2", "GPU-2a"},
+			notMatch: []string{" GPU-0", "GPU-3"},
+		},
+		{
+			name:     "bare wildcard is ignored",
+			input:    "*, * ,GPU-0",
+			len:      1,
+			match:    []string{"GPU-0"},
+			notMatch: []string{"GPU-1", "*"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set := ParseUUIDSet(test.input)
+			assert.Equal(t, test.len, set.Len())
+			for _, uuid := range test.match {
+				assert.Assert(t, set.Contains(uuid), "expected %q to match", uuid)
+			}
+			for _, uuid := range test.notMatch {
+				assert.Assert(t, !set.Contains(uuid), "expected %q not to match", uuid)
+			}
+		})
+	}
+}
+
+func TestUUIDSetNil(t *testing.T) {
+	var set *UUIDSet
+	assert.Equal(t, 0, set.Len())
+	assert.Equal(t, false, set.Contains("GPU-0"))
+}
+
+func FuzzParseUUIDSet(f *testing.F) {
+	for _, seed := range []string{"", ",", "GPU-0", "GPU-0,", ",,GPU-0,,GPU-0", " GPU-0 , GPU-1*", "*", "a*,b*,a*"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		set := ParseUUIDSet(input)
+		entries := strings.Split(input, ",")
+		if set.Len() > len(entries) {
+			t.Fatalf("parsed %d entries from %d items", set.Len(), len(entries))
+		}
+		for _, entry := range entries {
+			entry = strings.TrimSpace(entry)
+			if entry == "" || strings.HasSuffix(entry, UUIDPrefixWildcard) {
+				continue
+			}
+			if !set.Contains(entry) {
+				t.Fatalf("entry %q of %q not matched", entry, input)
+			}
+		}
+	})
+}
+
+func benchmarkUUIDs(n int) (string, []string) {
+	uuids := make([]string, 0, n)
+	for i := range n {
+		uuids = append(uuids, fmt.Sprintf("GPU-%08d-0000-0000-0000-000000000000", i))
+	}
+	return strings.Join(uuids, ","), uuids
+}
+
+// BenchmarkUUIDMatchSplit is the former behavior, the annotation is split again for every device.
+func BenchmarkUUIDMatchSplit(b *testing.B) {
+	anno, uuids := benchmarkUUIDs(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, uuid := range uuids {
+			_ = slices.Contains(strings.Split(anno, ","), uuid)
+		}
+	}
+}
+
+func BenchmarkUUIDMatchSet(b *testing.B) {
+	anno, uuids := benchmarkUUIDs(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set := ParseUUIDSet(anno)
+		for _, uuid := range uuids {
+			_ = set.Contains(uuid)
+		}
+	}
+}
+
+func BenchmarkParseUUIDSet(b *testing.B) {
+	anno, _ := benchmarkUUIDs(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ParseUUIDSet(anno)
+	}
+}
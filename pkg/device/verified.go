@@ -0,0 +1,30 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// NodeVerifiedDevices returns the devices of the node listed by util.VerifiedDevicesAnnotationKey, an empty set if
+// the node has none. Entries ending with "*" are prefix patterns like in ParseUUIDSet.
+func NodeVerifiedDevices(nodeInfo *NodeInfo) *UUIDSet {
+	if nodeInfo == nil || nodeInfo.Node == nil {
+		return ParseUUIDSet("")
+	}
+	return ParseUUIDSet(nodeInfo.Node.Annotations[util.VerifiedDevicesAnnotationKey])
+}
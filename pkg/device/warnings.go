@@ -0,0 +1,61 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AdmissionWarnings collects the warnings of the admission response of a pod, which kubectl shows to the user,
+// for what the webhook changed in the pod or let pass without denying it. Its methods do nothing on nil.
+type AdmissionWarnings struct {
+	warnings []string
+}
+
+// Addf adds a warning formatted with fmt.Sprintf, unless the same warning was already added.
+func (w *AdmissionWarnings) Addf(format string, a ...any) {
+	if w == nil {
+		return
+	}
+	msg := fmt.Sprintf(format, a...)
+	if !slices.Contains(w.warnings, msg) {
+		w.warnings = append(w.warnings, msg)
+	}
+}
+
+// Defaulted warns that the resource of the container, which it didn't request, was set to value.
+func (w *AdmissionWarnings) Defaulted(ctr, name string, value int64) {
+	w.Addf("container %s: %s is not set, defaulted to %d", ctr, name, value)
+}
+
+// Adjusted warns that the resource the container requested was changed from requested to value, e.g. rounded to
+// what the devices can allocate. Nothing is added if they're equal.
+func (w *AdmissionWarnings) Adjusted(ctr, name string, requested, value int64) {
+	if requested == value {
+		return
+	}
+	w.Addf("container %s: %s %d is adjusted to %d", ctr, name, requested, value)
+}
+
+// List returns the warnings in the order they were added.
+func (w *AdmissionWarnings) List() []string {
+	if w == nil {
+		return nil
+	}
+	return slices.Clone(w.warnings)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestAdmissionWarnings(t *testing.T) {
+	w := &AdmissionWarnings{}
+	w.Defaulted("train", "nvidia.com/gpucores", 100)
+	w.Adjusted("train", "huawei.com/Ascend910B-memory", 3000, 4096)
+	// Unchanged values and duplicates aren't warned.
+	w.Adjusted("train", "nvidia.com/gpumem", 4000, 4000)
+	w.Defaulted("train", "nvidia.com/gpucores", 100)
+	w.Addf("environment variables %s of container %s are removed", "CUDA_VISIBLE_DEVICES", "train")
+	assert.DeepEqual(t, w.List(), []string{
+		"container train: nvidia.com/gpucores is not set, defaulted to 100",
+		"container train: huawei.com/Ascend910B-memory 3000 is adjusted to 4096",
+		"environment variables CUDA_VISIBLE_DEVICES of container train are removed",
+	})
+
+	var none *AdmissionWarnings
+	none.Defaulted("train", "nvidia.com/gpucores", 100)
+	assert.Assert(t, none.List() == nil)
+}
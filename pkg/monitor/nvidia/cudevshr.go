@@ -161,6 +161,11 @@ func (l *ContainerLister) Clientset() *kubernetes.Clientset {
 	return l.clientset
 }
 
+// PodLister lists the pods of the node.
+func (l *ContainerLister) PodLister() corelisters.PodLister {
+	return l.podLister
+}
+
 func (l *ContainerLister) Update() error {
 
 	l.mutex.Lock()
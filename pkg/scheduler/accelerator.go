@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+// AcceleratorPicker picks the vendor satisfying vendor agnostic requests of devices.
+type AcceleratorPicker interface {
+	// PickAcceleratorVendor returns the vendor among vendors to request count devices with mem MiB each of.
+	PickAcceleratorVendor(vendors []string, count, mem int64) (string, error)
+}
+
+// PickAcceleratorVendor returns the vendor among vendors with the most nodes having count healthy devices with
+// mem MiB free. Vendors are ranked by the nodes having such devices at all if none has them free, so the pod
+// waits for the vendor most likely to free them.
+func (s *Scheduler) PickAcceleratorVendor(vendors []string, count, mem int64) (string, error) {
+	if len(vendors) == 0 {
+		return "", device.InvalidRequestErrorf("no vendor can satisfy %s", device.AcceleratorMemoryResource)
+	}
+	// Memory and shares of the devices used by pods, by device ID.
+	usedmem := make(map[string]int64)
+	used := make(map[string]int32)
+	for _, p := range s.podManager.ListPodsInfo() {
+		for _, single := range p.Devices {
+			for _, ctrdevs := range single {
+				for _, d := range ctrdevs {
+					// MIG instances are assigned as <device UUID>[<template>-<instance>].
+					id := strings.Split(d.UUID, "[")[0]
+					usedmem[id] += int64(d.Usedmem)
+					used[id]++
+				}
+			}
+		}
+	}
+
+	nodes, err := s.ListNodes()
+	if err != nil {
+		return "", err
+	}
+	free := make(map[string]int)
+	capable := make(map[string]int)
+	for _, node := range nodes {
+		for _, vendor := range vendors {
+			fitting, sized := int64(0), int64(0)
+			for _, d := range node.Devices[vendor] {
+				if !d.Health || s.isMissingDevice(node.ID, d.ID) || int64(d.Devmem) < mem {
+					continue
+				}
+				sized++
+				if used[d.ID] < d.Count && int64(d.Devmem)-usedmem[d.ID] >= mem {
+					fitting++
+				}
+			}
+			if sized >= count {
+				capable[vendor]++
+			}
+			if fitting >= count {
+				free[vendor]++
+			}
+		}
+	}
+	picked := ""
+	for _, vendor := range vendors {
+		if picked == "" || free[vendor] > free[picked] ||
+			free[vendor] == free[picked] && capable[vendor] > capable[picked] {
+			picked = vendor
+		}
+	}
+	if capable[picked] == 0 {
+		return "", device.InvalidRequestErrorf("no node has %d devices of vendors %v with %d MiB memory", count, vendors, mem)
+	}
+	klog.V(4).InfoS("Picked accelerator vendor", "vendor", picked, "count", count, "memory", mem,
+		"nodesFree", free[picked], "nodesCapable", capable[picked])
+	return picked, nil
+}
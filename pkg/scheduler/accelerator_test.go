@@ -0,0 +1,124 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/hygon"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_requestAccelerators(t *testing.T) {
+	initTestDevices(t, func(c *config.Config) {
+		c.HygonConfig = hygon.HygonConfig{
+			ResourceCountName:  "hygon.com/dcunum",
+			ResourceMemoryName: "hygon.com/dcumem",
+			ResourceCoreName:   "hygon.com/dcucores",
+		}
+		c.AcceleratorVendors = []string{nvidia.NvidiaGPUDevice, hygon.HygonDCUDevice}
+	})
+
+	s := NewScheduler()
+	defer s.Stop()
+	newNode := func(name, vendor string) {
+		s.addNode(name, &device.NodeInfo{
+			ID:   name,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			Devices: map[string][]device.DeviceInfo{vendor: {{
+				ID: name + "-0", Count: 10, Devmem: 16000, Devcore: 100, Type: vendor, Health: true, DeviceVendor: vendor,
+			}}},
+		})
+	}
+	newNode("gpu-node", nvidia.NvidiaGPUDevice)
+	newNode("dcu-node", hygon.HygonDCUDevice)
+	use := func(name, node, vendor string, mem int32) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name)}}
+		s.podManager.AddPod(pod, node, device.PodDevices{vendor: device.PodSingleDevice{{
+			{UUID: node + "-0", Type: vendor, Usedmem: mem},
+		}}})
+		return pod
+	}
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "abstract", Namespace: "default"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "worker", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					device.AcceleratorMemoryResource: resource.MustParse("8000"),
+				}}},
+				{Name: "sidecar"},
+			}},
+		}
+	}
+	h := &webhook{accelerators: s}
+	limit := func(pod *corev1.Pod, name corev1.ResourceName) int64 {
+		q := pod.Spec.Containers[0].Resources.Limits[name]
+		return q.Value()
+	}
+
+	// The GPU is busy, the DCU has capacity.
+	gpuPod := use("gpu-pod", "gpu-node", nvidia.NvidiaGPUDevice, 12000)
+	pod := newPod()
+	vendor, err := h.requestAccelerators(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, vendor, hygon.HygonDCUDevice)
+	assert.Equal(t, pod.Annotations[util.AcceleratorVendorAnnotationKey], hygon.HygonDCUDevice)
+	assert.Equal(t, len(pod.Spec.Containers[0].Resources.Limits), 2)
+	assert.Equal(t, limit(pod, "hygon.com/dcunum"), int64(1))
+	assert.Equal(t, limit(pod, "hygon.com/dcumem"), int64(8000))
+	assert.Equal(t, len(pod.Spec.Containers[1].Resources.Limits), 0)
+
+	// Now the DCU is busy and the GPU has capacity.
+	s.podManager.DelPod(gpuPod)
+	use("dcu-pod", "dcu-node", hygon.HygonDCUDevice, 12000)
+	pod = newPod()
+	vendor, err = h.requestAccelerators(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, vendor, nvidia.NvidiaGPUDevice)
+	assert.Equal(t, limit(pod, "hami.io/gpu"), int64(1))
+	assert.Equal(t, limit(pod, "hami.io/gpumem"), int64(8000))
+	_, ok := pod.Spec.Containers[0].Resources.Limits[device.AcceleratorMemoryResource]
+	assert.Assert(t, !ok)
+
+	// Vendors not allowed by the config don't participate.
+	config.AcceleratorVendors = []string{hygon.HygonDCUDevice}
+	vendor, err = h.requestAccelerators(newPod())
+	assert.NilError(t, err)
+	assert.Equal(t, vendor, hygon.HygonDCUDevice)
+
+	// No vendor has devices that large.
+	pod = newPod()
+	pod.Spec.Containers[0].Resources.Limits[device.AcceleratorMemoryResource] = resource.MustParse("32000")
+	_, err = h.requestAccelerators(pod)
+	assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+
+	// The count is only valid with the memory.
+	pod = newPod()
+	pod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{device.AcceleratorCountResource: resource.MustParse("2")}
+	_, err = h.requestAccelerators(pod)
+	assert.Assert(t, errors.Is(err, device.ErrInvalidRequest))
+}
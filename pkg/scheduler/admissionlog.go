@@ -0,0 +1,116 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	AdmissionDecisionAllow  = "allow"
+	AdmissionDecisionMutate = "mutate"
+	AdmissionDecisionDeny   = "deny"
+	AdmissionDecisionError  = "error"
+)
+
+// AdmissionDecision is the decision of the webhook on a pod, logged as a JSON line for auditing.
+type AdmissionDecision struct {
+	Time      time.Time             `json:"timestamp"`
+	UID       types.UID             `json:"uid"`
+	Operation admissionv1.Operation `json:"operation"`
+	Namespace string                `json:"namespace"`
+	Pod       string                `json:"pod"`
+	Decision  string                `json:"decision"`
+	Reason    string                `json:"reason,omitempty"`
+	// Resources are the device resources of the pod matched by the webhook.
+	Resources []string                  `json:"resources,omitempty"`
+	UserInfo  authenticationv1.UserInfo `json:"userInfo"`
+}
+
+// admissionDecisionLogger writes the admission decisions to a destination separate from the klog output,
+// regardless of the log verbosity. A nil logger logs nothing.
+type admissionDecisionLogger struct {
+	mutex sync.Mutex
+	enc   *json.Encoder
+}
+
+// newAdmissionDecisionLogger returns the logger of dest, which is stdout, stderr or the path of a file the
+// decisions are appended to. Logging is disabled if dest is empty.
+func newAdmissionDecisionLogger(dest string) (*admissionDecisionLogger, error) {
+	var w io.Writer
+	switch dest {
+	case "":
+		return nil, nil
+	case "stdout":
+		w = os.Stdout
+	case "stderr":
+		w = os.Stderr
+	default:
+		f, err := os.OpenFile(dest, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open admission decision log: %v", err)
+		}
+		w = f
+	}
+	return &admissionDecisionLogger{enc: json.NewEncoder(w)}, nil
+}
+
+// log logs the decision resp of the webhook on the pod of req, identified by namespace and podName.
+func (l *admissionDecisionLogger) log(req admission.Request, namespace, podName string, resources []string, resp admission.Response) {
+	if l == nil {
+		return
+	}
+	d := AdmissionDecision{
+		Time:      time.Now(),
+		UID:       req.UID,
+		Operation: req.Operation,
+		Namespace: namespace,
+		Pod:       podName,
+		Resources: resources,
+		UserInfo:  req.UserInfo,
+	}
+	switch {
+	case resp.Allowed && len(resp.Patches) > 0:
+		d.Decision = AdmissionDecisionMutate
+	case resp.Allowed:
+		d.Decision = AdmissionDecisionAllow
+	case resp.Result != nil && resp.Result.Code == http.StatusForbidden:
+		d.Decision = AdmissionDecisionDeny
+	default:
+		d.Decision = AdmissionDecisionError
+	}
+	if resp.Result != nil {
+		d.Reason = resp.Result.Message
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if err := l.enc.Encode(d); err != nil {
+		klog.ErrorS(err, "Failed to log admission decision", "pod", klog.KRef(namespace, podName), "uid", req.UID)
+	}
+}
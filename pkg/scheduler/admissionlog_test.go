@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func TestAdmissionDecisionLog(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	var buf bytes.Buffer
+	h := &webhook{
+		decoder:   admission.NewDecoder(clientgoscheme.Scheme),
+		decisions: &admissionDecisionLogger{enc: json.NewEncoder(&buf)},
+	}
+	user := authenticationv1.UserInfo{Username: "alice", Groups: []string{"system:authenticated"}}
+	newRequest := func(pod *corev1.Pod) admission.Request {
+		raw, err := json.Marshal(pod)
+		assert.NilError(t, err)
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "req-" + pod.UID,
+			Operation: admissionv1.Create,
+			Namespace: "default",
+			Name:      pod.Name,
+			Object:    runtime.RawExtension{Raw: raw},
+			UserInfo:  user,
+		}}
+	}
+	newPod := func(name, schedulerName string, limits corev1.ResourceList) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID("uid-" + name)},
+			Spec: corev1.PodSpec{
+				SchedulerName: schedulerName,
+				Containers: []corev1.Container{{
+					Name:      "ctr",
+					Resources: corev1.ResourceRequirements{Limits: limits},
+				}},
+			},
+		}
+	}
+
+	allowed := newPod("allowed", "other-scheduler", nil)
+	denied := newPod("denied", "", nil)
+	denied.Spec.Containers = nil
+	mutated := newPod("mutated", "", corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")})
+	for _, pod := range []*corev1.Pod{allowed, denied, mutated} {
+		h.Handle(context.Background(), newRequest(pod))
+	}
+
+	dec := json.NewDecoder(&buf)
+	wantUser := map[string]any{"username": "alice", "groups": []any{"system:authenticated"}}
+	tests := []map[string]any{
+		{
+			"uid":       "req-uid-allowed",
+			"operation": "CREATE",
+			"namespace": "default",
+			"pod":       "allowed",
+			"decision":  AdmissionDecisionAllow,
+			"reason":    "pod already has different scheduler assigned",
+			"userInfo":  wantUser,
+		},
+		{
+			"uid":       "req-uid-denied",
+			"operation": "CREATE",
+			"namespace": "default",
+			"pod":       "denied",
+			"decision":  AdmissionDecisionDeny,
+			"reason":    "pod has no containers",
+			"userInfo":  wantUser,
+		},
+		{
+			"uid":       "req-uid-mutated",
+			"operation": "CREATE",
+			"namespace": "default",
+			"pod":       "mutated",
+			"decision":  AdmissionDecisionMutate,
+			"resources": []any{"hami.io/gpu"},
+			"userInfo":  wantUser,
+		},
+	}
+	for _, want := range tests {
+		var got map[string]any
+		assert.NilError(t, dec.Decode(&got))
+		_, err := time.Parse(time.RFC3339Nano, got["timestamp"].(string))
+		assert.NilError(t, err)
+		delete(got, "timestamp")
+		assert.DeepEqual(t, got, want)
+	}
+	assert.Assert(t, !dec.More())
+}
+
+func Test_newAdmissionDecisionLogger(t *testing.T) {
+	l, err := newAdmissionDecisionLogger("")
+	assert.NilError(t, err)
+	assert.Assert(t, l == nil)
+	// A disabled logger logs nothing.
+	l.log(admission.Request{}, "", "pod", nil, admission.Allowed(""))
+
+	path := filepath.Join(t.TempDir(), "decisions.log")
+	assert.NilError(t, os.WriteFile(path, []byte("{}\n"), 0o600))
+	l, err = newAdmissionDecisionLogger(path)
+	assert.NilError(t, err)
+	l.log(admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Namespace: "default"}}, "default", "pod", nil, admission.Errored(http.StatusInternalServerError, errors.New("marshal failed")))
+	data, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	assert.Equal(t, len(lines), 2)
+	var d AdmissionDecision
+	assert.NilError(t, json.Unmarshal(lines[1], &d))
+	assert.Equal(t, d.Decision, AdmissionDecisionError)
+	assert.Equal(t, d.Reason, "marshal failed")
+
+	_, err = newAdmissionDecisionLogger(filepath.Join(t.TempDir(), "missing", "decisions.log"))
+	assert.ErrorContains(t, err, "failed to open admission decision log")
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// errAllocationLeaseHeld is returned when another replica of the scheduler committed an allocation to the node
+// this replica hasn't seen yet.
+var errAllocationLeaseHeld = errors.New("allocation lease of node held by another scheduler replica")
+
+// allocationLease is the value of util.AllocationLeaseAnnotations, the last allocation committed to a node.
+type allocationLease struct {
+	// Identity of the replica of the scheduler
+	holder string
+	pod    k8stypes.UID
+	time   time.Time
+}
+
+func (l allocationLease) String() string {
+	return strings.Join([]string{l.holder, string(l.pod), l.time.UTC().Format(time.RFC3339)}, ",")
+}
+
+func parseAllocationLease(value string) (allocationLease, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return allocationLease{}, false
+	}
+	t, err := time.Parse(time.RFC3339, parts[2])
+	if err != nil {
+		return allocationLease{}, false
+	}
+	return allocationLease{holder: parts[0], pod: k8stypes.UID(parts[1]), time: t}, true
+}
+
+// withoutAllocationLease returns the annotations but the allocation lease, which changes with every allocation to
+// the node without changing its devices.
+func withoutAllocationLease(annotations map[string]string) map[string]string {
+	if _, ok := annotations[util.AllocationLeaseAnnotations]; !ok {
+		return annotations
+	}
+	res := maps.Clone(annotations)
+	delete(res, util.AllocationLeaseAnnotations)
+	return res
+}
+
+// newReplicaIdentity returns the identity of this replica of the scheduler in allocation leases, unique even
+// across restarts with the same hostname.
+func newReplicaIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.ErrorS(err, "Failed to get hostname for the scheduler replica identity")
+	}
+	return hostname + "_" + string(uuid.NewUUID())
+}
+
+// acquireAllocationLease commits the allocation of the pod to the node against the other replicas of the
+// scheduler, by writing the allocation lease of the node conditionally on the resourceVersion of the node, so of
+// two replicas allocating to the node at the same time only one succeeds. It fails with errAllocationLeaseHeld
+// while another replica holds the lease within config.AllocationLeaseDuration for a pod this replica hasn't seen
+// yet, as the devices this replica picked may be allocated to that pod.
+func (s *Scheduler) acquireAllocationLease(ctx context.Context, nodeID string, pod *corev1.Pod) error {
+	if config.AllocationLeaseDuration <= 0 {
+		return nil
+	}
+	return util.PatchAnnotationsWithRetry(ctx, s.kubeClient, util.NodeRef(nodeID), func(meta *metav1.ObjectMeta) error {
+		now := s.clock.Now()
+		if held, ok := parseAllocationLease(meta.Annotations[util.AllocationLeaseAnnotations]); ok &&
+			held.holder != s.identity && held.pod != pod.UID && now.Before(held.time.Add(config.AllocationLeaseDuration)) {
+			if _, seen := s.podManager.GetPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: held.pod}}); !seen {
+				return fmt.Errorf("%w: replica %s allocated pod %s to node %s at %s", errAllocationLeaseHeld,
+					held.holder, held.pod, nodeID, held.time.Format(time.RFC3339))
+			}
+		}
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, 1)
+		}
+		meta.Annotations[util.AllocationLeaseAnnotations] = allocationLease{holder: s.identity, pod: pod.UID, time: now}.String()
+		return nil
+	})
+}
+
+// releaseAllocationLease removes the allocation lease of the node if it's still the one this replica committed for
+// the pod, so the other replicas don't wait out the lease of an allocation which failed.
+func (s *Scheduler) releaseAllocationLease(ctx context.Context, nodeID string, pod *corev1.Pod) error {
+	if config.AllocationLeaseDuration <= 0 {
+		return nil
+	}
+	return util.PatchAnnotationsWithRetry(ctx, s.kubeClient, util.NodeRef(nodeID), func(meta *metav1.ObjectMeta) error {
+		if held, ok := parseAllocationLease(meta.Annotations[util.AllocationLeaseAnnotations]); ok && held.holder == s.identity && held.pod == pod.UID {
+			delete(meta.Annotations, util.AllocationLeaseAnnotations)
+		}
+		return nil
+	})
+}
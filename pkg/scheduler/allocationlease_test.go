@@ -0,0 +1,193 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+// conflictingNodePatches makes patches of nodes with a resourceVersion conflict if the node changed since, as the
+// API server does, and bumps the resourceVersion of nodes on patches.
+func conflictingNodePatches(kubeClient *fake.Clientset) {
+	var mutex sync.Mutex
+	kubeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		patch := action.(k8stesting.PatchAction)
+		obj, err := kubeClient.Tracker().Get(corev1.SchemeGroupVersion.WithResource("nodes"), "", patch.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		node := obj.(*corev1.Node).DeepCopy()
+		var p struct {
+			Metadata struct {
+				ResourceVersion string            `json:"resourceVersion"`
+				Annotations     map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(patch.GetPatch(), &p); err != nil {
+			return true, nil, err
+		}
+		if p.Metadata.ResourceVersion != "" && p.Metadata.ResourceVersion != node.ResourceVersion {
+			return true, nil, apierrors.NewConflict(corev1.Resource("nodes"), node.Name, errors.New("the object has been modified"))
+		}
+		if node.Annotations == nil {
+			node.Annotations = make(map[string]string)
+		}
+		for k, v := range p.Metadata.Annotations {
+			node.Annotations[k] = v
+		}
+		rv, _ := strconv.Atoi(node.ResourceVersion)
+		node.ResourceVersion = strconv.Itoa(rv + 1)
+		return true, node, kubeClient.Tracker().Update(corev1.SchemeGroupVersion.WithResource("nodes"), node, "")
+	})
+}
+
+func Test_acquireAllocationLease(t *testing.T) {
+	restoreConfig(t)
+	config.AllocationLeaseDuration = time.Minute
+
+	kubeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", ResourceVersion: "1"}})
+	conflictingNodePatches(kubeClient)
+	// Both replicas read the node before either commits.
+	var mutex sync.Mutex
+	reads := 0
+	bothRead := make(chan struct{})
+	kubeClient.PrependReactor("get", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mutex.Lock()
+		reads++
+		if reads == 2 {
+			close(bothRead)
+		}
+		mutex.Unlock()
+		<-bothRead
+		return false, nil, nil
+	})
+	replicas := make([]*Scheduler, 2)
+	pods := make([]*corev1.Pod, 2)
+	for i := range replicas {
+		replicas[i] = NewScheduler()
+		replicas[i].kubeClient = kubeClient
+		replicas[i].identity = "replica-" + strconv.Itoa(i)
+		pods[i] = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "pod-" + strconv.Itoa(i), Namespace: "default", UID: k8stypes.UID("pod-" + strconv.Itoa(i)),
+		}}
+	}
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range replicas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = replicas[i].acquireAllocationLease(context.Background(), "node1", pods[i])
+		}(i)
+	}
+	wg.Wait()
+	winner, loser := 0, 1
+	if errs[0] != nil {
+		winner, loser = 1, 0
+	}
+	assert.NilError(t, errs[winner])
+	assert.Assert(t, errors.Is(errs[loser], errAllocationLeaseHeld), "got %v", errs[loser])
+	node, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	lease, ok := parseAllocationLease(node.Annotations[util.AllocationLeaseAnnotations])
+	assert.Assert(t, ok)
+	assert.Equal(t, lease.holder, replicas[winner].identity)
+	assert.Equal(t, lease.pod, pods[winner].UID)
+
+	// The loser commits once it has seen the pod allocated by the winner.
+	replicas[loser].podManager.AddPod(pods[winner], "node1", device.PodDevices{})
+	assert.NilError(t, replicas[loser].acquireAllocationLease(context.Background(), "node1", pods[loser]))
+	// The winner in turn has to see the pod of the loser before allocating to the node again.
+	next := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Namespace: "default", UID: "pod-2"}}
+	err = replicas[winner].acquireAllocationLease(context.Background(), "node1", next)
+	assert.Assert(t, errors.Is(err, errAllocationLeaseHeld), "got %v", err)
+	replicas[winner].podManager.AddPod(pods[loser], "node1", device.PodDevices{})
+	assert.NilError(t, replicas[winner].acquireAllocationLease(context.Background(), "node1", next))
+}
+
+func Test_Filter_ReleasesAllocationLease(t *testing.T) {
+	initTestDevices(t)
+	config.AllocationLeaseDuration = time.Minute
+
+	s := NewScheduler()
+	defer s.Stop()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "train"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "train",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+		}}},
+	}
+	kubeClient := fake.NewSimpleClientset(pod, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	// Patching the pod fails after the lease of the node is acquired.
+	kubeClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+	client.KubeClient = kubeClient
+	s.kubeClient = kubeClient
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "GPU-0", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+		}},
+	})
+
+	nodeNames := []string{"node1"}
+	_, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+	assert.ErrorContains(t, err, "apiserver unavailable")
+	acquired := false
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == "patch" && action.GetResource().Resource == "nodes" {
+			acquired = true
+		}
+	}
+	assert.Assert(t, acquired, "allocation lease not acquired")
+	// The other replicas don't wait for the lease of the failed allocation.
+	node, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, leased := node.Annotations[util.AllocationLeaseAnnotations]
+	assert.Assert(t, !leased, "allocation lease kept: %v", node.Annotations)
+	_, ok := s.podManager.GetPod(pod)
+	assert.Assert(t, !ok, "pod kept allocated")
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extenderpb
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+// FilterArgsFromExtender converts the kube-scheduler extender filter args.
+func FilterArgsFromExtender(args extenderv1.ExtenderArgs) (*FilterArgs, error) {
+	pod, err := marshalPod(args.Pod)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := marshalNodes(args.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterArgs{Pod: pod, Nodes: nodes, NodeNames: nodeNamesFromExtender(args.NodeNames)}, nil
+}
+
+// ToExtender converts the args to the kube-scheduler extender filter args.
+func (x *FilterArgs) ToExtender() (extenderv1.ExtenderArgs, error) {
+	pod, err := unmarshalPod(x.GetPod())
+	if err != nil {
+		return extenderv1.ExtenderArgs{}, err
+	}
+	nodes, err := unmarshalNodes(x.GetNodes())
+	if err != nil {
+		return extenderv1.ExtenderArgs{}, err
+	}
+	return extenderv1.ExtenderArgs{Pod: pod, Nodes: nodes, NodeNames: x.GetNodeNames().toExtender()}, nil
+}
+
+// FilterResultFromExtender converts the kube-scheduler extender filter result.
+func FilterResultFromExtender(result *extenderv1.ExtenderFilterResult) (*FilterResult, error) {
+	if result == nil {
+		return &FilterResult{}, nil
+	}
+	nodes, err := marshalNodes(result.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterResult{
+		Nodes:                      nodes,
+		NodeNames:                  nodeNamesFromExtender(result.NodeNames),
+		FailedNodes:                result.FailedNodes,
+		FailedAndUnresolvableNodes: result.FailedAndUnresolvableNodes,
+		Error:                      result.Error,
+	}, nil
+}
+
+// ToExtender converts the result to the kube-scheduler extender filter result.
+func (x *FilterResult) ToExtender() (*extenderv1.ExtenderFilterResult, error) {
+	nodes, err := unmarshalNodes(x.GetNodes())
+	if err != nil {
+		return nil, err
+	}
+	return &extenderv1.ExtenderFilterResult{
+		Nodes:                      nodes,
+		NodeNames:                  x.GetNodeNames().toExtender(),
+		FailedNodes:                x.GetFailedNodes(),
+		FailedAndUnresolvableNodes: x.GetFailedAndUnresolvableNodes(),
+		Error:                      x.GetError(),
+	}, nil
+}
+
+// BindArgsFromExtender converts the kube-scheduler extender binding args.
+func BindArgsFromExtender(args extenderv1.ExtenderBindingArgs) *BindArgs {
+	return &BindArgs{
+		PodName:      args.PodName,
+		PodNamespace: args.PodNamespace,
+		PodUid:       string(args.PodUID),
+		Node:         args.Node,
+	}
+}
+
+// ToExtender converts the args to the kube-scheduler extender binding args.
+func (x *BindArgs) ToExtender() extenderv1.ExtenderBindingArgs {
+	return extenderv1.ExtenderBindingArgs{
+		PodName:      x.GetPodName(),
+		PodNamespace: x.GetPodNamespace(),
+		PodUID:       types.UID(x.GetPodUid()),
+		Node:         x.GetNode(),
+	}
+}
+
+// BindResultFromExtender converts the kube-scheduler extender binding result.
+func BindResultFromExtender(result *extenderv1.ExtenderBindingResult) *BindResult {
+	if result == nil {
+		return &BindResult{}
+	}
+	return &BindResult{Error: result.Error}
+}
+
+// ToExtender converts the result to the kube-scheduler extender binding result.
+func (x *BindResult) ToExtender() *extenderv1.ExtenderBindingResult {
+	return &extenderv1.ExtenderBindingResult{Error: x.GetError()}
+}
+
+// PreemptArgsFromExtender converts the kube-scheduler extender preemption args.
+func PreemptArgsFromExtender(args extenderv1.ExtenderPreemptionArgs) (*PreemptArgs, error) {
+	pod, err := marshalPod(args.Pod)
+	if err != nil {
+		return nil, err
+	}
+	res := &PreemptArgs{Pod: pod, NodeNameToMetaVictims: metaVictimsFromExtender(args.NodeNameToMetaVictims)}
+	if args.NodeNameToVictims != nil {
+		res.NodeNameToVictims = make(map[string]*Victims, len(args.NodeNameToVictims))
+		for node, v := range args.NodeNameToVictims {
+			victims := &Victims{NumPdbViolations: v.NumPDBViolations}
+			for _, p := range v.Pods {
+				data, err := marshalPod(p)
+				if err != nil {
+					return nil, err
+				}
+				victims.Pods = append(victims.Pods, data)
+			}
+			res.NodeNameToVictims[node] = victims
+		}
+	}
+	return res, nil
+}
+
+// ToExtender converts the args to the kube-scheduler extender preemption args.
+func (x *PreemptArgs) ToExtender() (extenderv1.ExtenderPreemptionArgs, error) {
+	pod, err := unmarshalPod(x.GetPod())
+	if err != nil {
+		return extenderv1.ExtenderPreemptionArgs{}, err
+	}
+	res := extenderv1.ExtenderPreemptionArgs{Pod: pod, NodeNameToMetaVictims: metaVictimsToExtender(x.GetNodeNameToMetaVictims())}
+	if x.GetNodeNameToVictims() != nil {
+		res.NodeNameToVictims = make(map[string]*extenderv1.Victims, len(x.GetNodeNameToVictims()))
+		for node, v := range x.GetNodeNameToVictims() {
+			victims := &extenderv1.Victims{NumPDBViolations: v.GetNumPdbViolations()}
+			for _, data := range v.GetPods() {
+				p, err := unmarshalPod(data)
+				if err != nil {
+					return extenderv1.ExtenderPreemptionArgs{}, err
+				}
+				victims.Pods = append(victims.Pods, p)
+			}
+			res.NodeNameToVictims[node] = victims
+		}
+	}
+	return res, nil
+}
+
+// PreemptResultFromExtender converts the kube-scheduler extender preemption result.
+func PreemptResultFromExtender(result *extenderv1.ExtenderPreemptionResult) *PreemptResult {
+	if result == nil {
+		return &PreemptResult{}
+	}
+	return &PreemptResult{NodeNameToMetaVictims: metaVictimsFromExtender(result.NodeNameToMetaVictims)}
+}
+
+// ToExtender converts the result to the kube-scheduler extender preemption result.
+func (x *PreemptResult) ToExtender() *extenderv1.ExtenderPreemptionResult {
+	return &extenderv1.ExtenderPreemptionResult{NodeNameToMetaVictims: metaVictimsToExtender(x.GetNodeNameToMetaVictims())}
+}
+
+func nodeNamesFromExtender(names *[]string) *NodeNames {
+	if names == nil {
+		return nil
+	}
+	return &NodeNames{Names: *names}
+}
+
+func (x *NodeNames) toExtender() *[]string {
+	if x == nil {
+		return nil
+	}
+	names := x.GetNames()
+	if names == nil {
+		names = []string{}
+	}
+	return &names
+}
+
+func metaVictimsFromExtender(victims map[string]*extenderv1.MetaVictims) map[string]*MetaVictims {
+	if victims == nil {
+		return nil
+	}
+	res := make(map[string]*MetaVictims, len(victims))
+	for node, v := range victims {
+		mv := &MetaVictims{NumPdbViolations: v.NumPDBViolations}
+		for _, p := range v.Pods {
+			mv.Pods = append(mv.Pods, &MetaPod{Uid: p.UID})
+		}
+		res[node] = mv
+	}
+	return res
+}
+
+func metaVictimsToExtender(victims map[string]*MetaVictims) map[string]*extenderv1.MetaVictims {
+	if victims == nil {
+		return nil
+	}
+	res := make(map[string]*extenderv1.MetaVictims, len(victims))
+	for node, v := range victims {
+		mv := &extenderv1.MetaVictims{NumPDBViolations: v.GetNumPdbViolations()}
+		for _, p := range v.GetPods() {
+			mv.Pods = append(mv.Pods, &extenderv1.MetaPod{UID: p.GetUid()})
+		}
+		res[node] = mv
+	}
+	return res
+}
+
+func marshalPod(pod *corev1.Pod) ([]byte, error) {
+	if pod == nil {
+		return nil, nil
+	}
+	data, err := pod.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	return data, nil
+}
+
+func unmarshalPod(data []byte) (*corev1.Pod, error) {
+	if data == nil {
+		return nil, nil
+	}
+	pod := &corev1.Pod{}
+	if err := pod.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pod: %v", err)
+	}
+	return pod, nil
+}
+
+func marshalNodes(nodes *corev1.NodeList) ([]byte, error) {
+	if nodes == nil {
+		return nil, nil
+	}
+	data, err := nodes.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal nodes: %v", err)
+	}
+	return data, nil
+}
+
+func unmarshalNodes(data []byte) (*corev1.NodeList, error) {
+	if data == nil {
+		return nil, nil
+	}
+	nodes := &corev1.NodeList{}
+	if err := nodes.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal nodes: %v", err)
+	}
+	return nodes, nil
+}
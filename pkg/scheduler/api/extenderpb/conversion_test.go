@@ -0,0 +1,105 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extenderpb
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+// roundTrip encodes and decodes m on the wire.
+func roundTrip[T proto.Message](t *testing.T, m T, out T) T {
+	t.Helper()
+	data, err := proto.Marshal(m)
+	assert.NilError(t, err)
+	assert.NilError(t, proto.Unmarshal(data, out))
+	return out
+}
+
+var testPod = &corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:        "gpu-pod",
+		Namespace:   "default",
+		UID:         "uid1",
+		Annotations: map[string]string{"hami.io/gpu-scheduler-policy": "binpack"},
+	},
+	Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name:      "cuda",
+		Image:     "cuda:12.4",
+		Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}},
+	}}},
+}
+
+func TestFilterConversion(t *testing.T) {
+	for _, args := range []extenderv1.ExtenderArgs{
+		{Pod: testPod, NodeNames: &[]string{"node1", "node2"}},
+		{Pod: testPod, NodeNames: &[]string{}},
+		{Pod: testPod, Nodes: &corev1.NodeList{Items: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}}}},
+	} {
+		in, err := FilterArgsFromExtender(args)
+		assert.NilError(t, err)
+		got, err := roundTrip(t, in, &FilterArgs{}).ToExtender()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, args)
+	}
+
+	for _, result := range []*extenderv1.ExtenderFilterResult{
+		{NodeNames: &[]string{"node1"}},
+		{FailedNodes: map[string]string{"node1": "NodeUnfitPod"}, FailedAndUnresolvableNodes: map[string]string{"node2": "node unregistered"}},
+		{Error: "calcScore failed"},
+	} {
+		out, err := FilterResultFromExtender(result)
+		assert.NilError(t, err)
+		got, err := roundTrip(t, out, &FilterResult{}).ToExtender()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, result)
+	}
+}
+
+func TestBindConversion(t *testing.T) {
+	args := extenderv1.ExtenderBindingArgs{PodName: "gpu-pod", PodNamespace: "default", PodUID: "uid1", Node: "node1"}
+	assert.DeepEqual(t, roundTrip(t, BindArgsFromExtender(args), &BindArgs{}).ToExtender(), args)
+	result := &extenderv1.ExtenderBindingResult{Error: "node locked"}
+	assert.DeepEqual(t, roundTrip(t, BindResultFromExtender(result), &BindResult{}).ToExtender(), result)
+}
+
+func TestPreemptConversion(t *testing.T) {
+	metaVictims := map[string]*extenderv1.MetaVictims{
+		"node1": {Pods: []*extenderv1.MetaPod{{UID: "uid2"}, {UID: "uid3"}}, NumPDBViolations: 1},
+	}
+	for _, args := range []extenderv1.ExtenderPreemptionArgs{
+		{Pod: testPod, NodeNameToMetaVictims: metaVictims},
+		{Pod: testPod, NodeNameToVictims: map[string]*extenderv1.Victims{
+			"node1": {Pods: []*corev1.Pod{testPod}, NumPDBViolations: 2},
+		}},
+	} {
+		in, err := PreemptArgsFromExtender(args)
+		assert.NilError(t, err)
+		got, err := roundTrip(t, in, &PreemptArgs{}).ToExtender()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, args)
+	}
+
+	result := &extenderv1.ExtenderPreemptionResult{NodeNameToMetaVictims: metaVictims}
+	assert.DeepEqual(t, roundTrip(t, PreemptResultFromExtender(result), &PreemptResult{}).ToExtender(), result)
+}
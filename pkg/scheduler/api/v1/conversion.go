@@ -0,0 +1,52 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+func (a *FilterArgs) ToExtender() extenderv1.ExtenderArgs {
+	return extenderv1.ExtenderArgs{
+		Pod:       a.Pod,
+		Nodes:     a.Nodes,
+		NodeNames: a.NodeNames,
+	}
+}
+
+func FilterResultFromExtender(r *extenderv1.ExtenderFilterResult) *FilterResult {
+	return &FilterResult{
+		Nodes:                      r.Nodes,
+		NodeNames:                  r.NodeNames,
+		FailedNodes:                r.FailedNodes,
+		FailedAndUnresolvableNodes: r.FailedAndUnresolvableNodes,
+		Error:                      r.Error,
+	}
+}
+
+func (a *BindArgs) ToExtender() extenderv1.ExtenderBindingArgs {
+	return extenderv1.ExtenderBindingArgs{
+		PodName:      a.PodName,
+		PodNamespace: a.PodNamespace,
+		PodUID:       a.PodUID,
+		Node:         a.Node,
+	}
+}
+
+func BindResultFromExtender(r *extenderv1.ExtenderBindingResult) *BindResult {
+	return &BindResult{Error: r.Error}
+}
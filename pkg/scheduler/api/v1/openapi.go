@@ -0,0 +1,186 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OpenAPIPath is the path the OpenAPI document is served at.
+const OpenAPIPath = "/openapi.json"
+
+// Endpoint is an endpoint of the scheduler HTTP API.
+type Endpoint struct {
+	Method  string
+	Path    string
+	Summary string
+	// Request and Response are the body types, Request is nil for GET.
+	Request  any
+	Response any
+	// Successor is the path replacing a deprecated endpoint.
+	Successor string
+}
+
+// Endpoints are the endpoints of this version.
+var Endpoints = []Endpoint{
+	{Method: "POST", Path: PathPrefix + "/filter", Summary: "Filter nodes for a pod, kube-scheduler extender filter verb", Request: FilterArgs{}, Response: FilterResult{}},
+	{Method: "POST", Path: PathPrefix + "/bind", Summary: "Bind a pod to a node, kube-scheduler extender bind verb", Request: BindArgs{}, Response: BindResult{}},
+	{Method: "GET", Path: PathPrefix + "/nodes", Summary: "List device usage of nodes", Response: NodeList{}},
+	{Method: "GET", Path: PathPrefix + "/nodes/serials", Summary: "List the UUIDs of devices by serial number", Response: DeviceSerialList{}},
+	{Method: "GET", Path: PathPrefix + "/decisions", Summary: "List recent scheduling decisions", Response: DecisionList{}},
+}
+
+// DeprecatedEndpoints are the unversioned endpoints kept as aliases of their successors.
+var DeprecatedEndpoints = []Endpoint{
+	{Method: "POST", Path: "/filter", Summary: "Deprecated, use " + PathPrefix + "/filter", Successor: PathPrefix + "/filter"},
+	{Method: "POST", Path: "/bind", Summary: "Deprecated, use " + PathPrefix + "/bind", Successor: PathPrefix + "/bind"},
+}
+
+// OpenAPI returns the OpenAPI 3.0 document of the scheduler HTTP API, the schemas
+// are generated from the types of this package.
+func OpenAPI() map[string]any {
+	g := &schemaGenerator{schemas: make(map[string]any)}
+	paths := make(map[string]any)
+	for _, e := range Endpoints {
+		op := map[string]any{
+			"summary": e.Summary,
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "OK",
+					"content":     jsonContent(g.schema(reflect.TypeOf(e.Response))),
+				},
+			},
+		}
+		if e.Request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(g.schema(reflect.TypeOf(e.Request))),
+			}
+		}
+		paths[e.Path] = map[string]any{strings.ToLower(e.Method): op}
+	}
+	for _, e := range DeprecatedEndpoints {
+		paths[e.Path] = map[string]any{strings.ToLower(e.Method): map[string]any{
+			"summary":    e.Summary,
+			"deprecated": true,
+			"responses": map[string]any{
+				"200": map[string]any{"description": "OK, with the body of the kube-scheduler extender v1 types"},
+			},
+		}}
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "HAMi scheduler API",
+			"version": Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+		},
+	}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+var (
+	packagePath = reflect.TypeOf(FilterArgs{}).PkgPath()
+	timeType    = reflect.TypeOf(metav1.Time{})
+)
+
+type schemaGenerator struct {
+	schemas map[string]any
+}
+
+// schema returns the schema of t, types of this package are added to the components and referenced.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.PkgPath() != packagePath {
+			// Kubernetes objects, e.g. the pod being scheduled, are described by the Kubernetes API.
+			return map[string]any{"type": "object", "description": t.PkgPath() + "." + t.Name()}
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = nil
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int32, reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	g.addFields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			// Inlined, e.g. metav1.TypeMeta.
+			g.addFields(f.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1 contains the request and response types of the hami.io/v1 scheduler HTTP API.
+// Fields may only be added to these types, renaming or removing a field needs a new version.
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	GroupName    = "hami.io"
+	Version      = "v1"
+	GroupVersion = GroupName + "/" + Version
+	// PathPrefix is the path all endpoints of this version are served under.
+	PathPrefix = "/apis/" + GroupVersion
+)
+
+// FilterArgs is the request of the filter endpoint, sent by kube-scheduler as extender args.
+type FilterArgs struct {
+	// Pod is the pod being scheduled.
+	Pod *corev1.Pod `json:"pod"`
+	// Nodes are the candidate nodes, only set if the extender is not node cache capable.
+	Nodes *corev1.NodeList `json:"nodes,omitempty"`
+	// NodeNames are the names of candidate nodes, only set if the extender is node cache capable.
+	NodeNames *[]string `json:"nodeNames,omitempty"`
+}
+
+// FilterResult is the response of the filter endpoint.
+type FilterResult struct {
+	// Nodes are the nodes the pod fits, only set if the extender is not node cache capable.
+	Nodes *corev1.NodeList `json:"nodes,omitempty"`
+	// NodeNames are the names of nodes the pod fits, only set if the extender is node cache capable.
+	NodeNames *[]string `json:"nodeNames,omitempty"`
+	// FailedNodes maps the nodes the pod doesn't fit to the reason.
+	FailedNodes map[string]string `json:"failedNodes,omitempty"`
+	// FailedAndUnresolvableNodes maps the nodes preemption can't help to the reason.
+	FailedAndUnresolvableNodes map[string]string `json:"failedAndUnresolvableNodes,omitempty"`
+	// Error is set if filtering failed.
+	Error string `json:"error,omitempty"`
+}
+
+// BindArgs is the request of the bind endpoint.
+type BindArgs struct {
+	PodName      string    `json:"podName"`
+	PodNamespace string    `json:"podNamespace"`
+	PodUID       types.UID `json:"podUID"`
+	// Node is the node selected by kube-scheduler.
+	Node string `json:"node"`
+}
+
+// BindResult is the response of the bind endpoint.
+type BindResult struct {
+	// Error is set if binding failed.
+	Error string `json:"error,omitempty"`
+}
+
+// NodeList is the response of the nodes endpoint.
+type NodeList struct {
+	metav1.TypeMeta `json:",inline"`
+	Items           []Node `json:"items"`
+}
+
+// Node is a node with devices registered to the scheduler.
+type Node struct {
+	Name    string   `json:"name"`
+	Devices []Device `json:"devices"`
+	// Orphaned are the devices assigned to pods on the node that aren't registered on it, e.g. because the
+	// GPU was replaced. Their usage isn't counted in Devices.
+	Orphaned []OrphanedDevice `json:"orphaned,omitempty"`
+}
+
+// Device is the usage of a device on a node.
+type Device struct {
+	ID string `json:"id"`
+	// Serial is the serial number of the device, stable across reboots and resets changing ID. Unset if the
+	// device plugin didn't register it.
+	Serial string `json:"serial,omitempty"`
+	Index  uint   `json:"index"`
+	Type   string `json:"type"`
+	Mode   string `json:"mode,omitempty"`
+	Numa   int    `json:"numa"`
+	Health bool   `json:"health"`
+	// Count is the number of tasks that can share the device.
+	Count int32 `json:"count"`
+	// Used is the number of tasks using the device.
+	Used int32 `json:"used"`
+	// TotalMemory and UsedMemory are in MB.
+	TotalMemory int32 `json:"totalMemory"`
+	UsedMemory  int32 `json:"usedMemory"`
+	// TotalCores and UsedCores are in percent of the device.
+	TotalCores int32 `json:"totalCores"`
+	UsedCores  int32 `json:"usedCores"`
+	// Pods are the pods using the device.
+	Pods []PodReference `json:"pods,omitempty"`
+}
+
+// DeviceSerialList is the response of the device serials endpoint, sorted by node and serial number.
+type DeviceSerialList struct {
+	metav1.TypeMeta `json:",inline"`
+	Items           []DeviceSerial `json:"items"`
+}
+
+// DeviceSerial maps the serial number of a device to its current UUID.
+type DeviceSerial struct {
+	Node   string `json:"node"`
+	Serial string `json:"serial"`
+	UUID   string `json:"uuid"`
+}
+
+// OrphanedDevice is a device assigned to a pod that isn't registered on its node.
+type OrphanedDevice struct {
+	ID  string       `json:"id"`
+	Pod PodReference `json:"pod"`
+	// UsedMemory is in MB, UsedCores in percent of the device.
+	UsedMemory int32 `json:"usedMemory"`
+	UsedCores  int32 `json:"usedCores"`
+}
+
+// PodReference identifies a pod.
+type PodReference struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid,omitempty"`
+}
+
+// DecisionStage is the scheduling stage of a decision.
+type DecisionStage string
+
+const (
+	DecisionStageFilter DecisionStage = "Filter"
+	DecisionStageBind   DecisionStage = "Bind"
+)
+
+// DecisionList is the response of the decisions endpoint, oldest decision first.
+type DecisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	Items           []Decision `json:"items"`
+}
+
+// Decision is the outcome of filtering or binding a pod.
+type Decision struct {
+	Time      metav1.Time   `json:"time"`
+	Pod       PodReference  `json:"pod"`
+	Stage     DecisionStage `json:"stage"`
+	Succeeded bool          `json:"succeeded"`
+	// Node is the node the pod is scheduled or bound to.
+	Node string `json:"node,omitempty"`
+	// Devices are the devices allocated to the pod by a successful filter.
+	Devices []AllocatedDevice `json:"devices,omitempty"`
+	// Message is the reason of a failed decision.
+	Message string `json:"message,omitempty"`
+}
+
+// AllocatedDevice is a device allocated to a container.
+type AllocatedDevice struct {
+	Vendor string `json:"vendor"`
+	// Container is the index of the container in the pod spec.
+	Container  int    `json:"container"`
+	UUID       string `json:"uuid"`
+	Type       string `json:"type"`
+	UsedMemory int32  `json:"usedMemory"`
+	UsedCores  int32  `json:"usedCores"`
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// decodeStrict decodes the golden file into v, failing on fields v doesn't have.
+func decodeStrict(t *testing.T, file string, v any) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", file))
+	assert.NilError(t, err)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	assert.NilError(t, dec.Decode(v))
+	return data
+}
+
+func assertJSONEqual(t *testing.T, got, want []byte) {
+	t.Helper()
+	var gotObj, wantObj any
+	assert.NilError(t, json.Unmarshal(got, &gotObj))
+	assert.NilError(t, json.Unmarshal(want, &wantObj))
+	assert.DeepEqual(t, gotObj, wantObj)
+}
+
+// TestContract fails on any change of the types breaking clients of hami.io/v1: every golden
+// file must decode without unknown fields and encode back to the same document.
+func TestContract(t *testing.T) {
+	tests := []struct {
+		file string
+		obj  any
+	}{
+		{file: "filter_args.json", obj: &FilterArgs{}},
+		{file: "filter_result.json", obj: &FilterResult{}},
+		{file: "bind_args.json", obj: &BindArgs{}},
+		{file: "bind_result.json", obj: &BindResult{}},
+		{file: "node_list.json", obj: &NodeList{}},
+		{file: "device_serial_list.json", obj: &DeviceSerialList{}},
+		{file: "decision_list.json", obj: &DecisionList{}},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			data := decodeStrict(t, test.file, test.obj)
+			got, err := json.Marshal(test.obj)
+			assert.NilError(t, err)
+			assertJSONEqual(t, got, data)
+		})
+	}
+}
+
+// TestFilterArgsFromKubeScheduler checks the args kube-scheduler sends, which have no json tags, are decoded.
+func TestFilterArgsFromKubeScheduler(t *testing.T) {
+	var args FilterArgs
+	decodeStrict(t, "filter_args_extender.json", &args)
+	assert.Equal(t, args.Pod.Name, "gpu-pod")
+	assert.DeepEqual(t, *args.NodeNames, []string{"node1", "node2"})
+
+	extenderArgs := args.ToExtender()
+	assert.Equal(t, extenderArgs.Pod, args.Pod)
+	assert.DeepEqual(t, *extenderArgs.NodeNames, []string{"node1", "node2"})
+}
+
+// TestOpenAPI fails when the OpenAPI document changes, run with -update to accept the change.
+func TestOpenAPI(t *testing.T) {
+	got, err := json.MarshalIndent(OpenAPI(), "", "  ")
+	assert.NilError(t, err)
+	got = append(got, '\n')
+	golden := filepath.Join("testdata", "openapi.json")
+	if *update {
+		assert.NilError(t, os.WriteFile(golden, got, 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), string(want))
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// bindBackoffs tracks the nodes pods failed to bind to, so their retries within config.BindFailureCooldown
+// select other nodes first instead of hitting the same node again.
+type bindBackoffs struct {
+	mutex sync.Mutex
+	// Time pods by UID last failed to bind to nodes by name
+	failed map[k8stypes.UID]map[string]time.Time
+}
+
+// recordBindFailure records the pod failed to bind to the node now.
+func (s *Scheduler) recordBindFailure(uid k8stypes.UID, node string) {
+	if config.BindFailureCooldown <= 0 {
+		return
+	}
+	now := s.clock.Now()
+	s.bindBackoffs.mutex.Lock()
+	defer s.bindBackoffs.mutex.Unlock()
+	if s.bindBackoffs.failed == nil {
+		s.bindBackoffs.failed = make(map[k8stypes.UID]map[string]time.Time)
+	}
+	// Failures of pods never retried are dropped here once their cooldown is over.
+	for id, nodes := range s.bindBackoffs.failed {
+		for name, t := range nodes {
+			if now.Sub(t) >= config.BindFailureCooldown {
+				delete(nodes, name)
+			}
+		}
+		if len(nodes) == 0 {
+			delete(s.bindBackoffs.failed, id)
+		}
+	}
+	if s.bindBackoffs.failed[uid] == nil {
+		s.bindBackoffs.failed[uid] = make(map[string]time.Time)
+	}
+	s.bindBackoffs.failed[uid][node] = now
+}
+
+// bindBackoffNodes returns the nodes the pod failed to bind to within the cooldown.
+func (s *Scheduler) bindBackoffNodes(uid k8stypes.UID) map[string]bool {
+	now := s.clock.Now()
+	s.bindBackoffs.mutex.Lock()
+	defer s.bindBackoffs.mutex.Unlock()
+	res := make(map[string]bool)
+	for name, t := range s.bindBackoffs.failed[uid] {
+		if now.Sub(t) < config.BindFailureCooldown {
+			res[name] = true
+		}
+	}
+	return res
+}
+
+// clearBindBackoff stops tracking the bind failures of the pod, once it's bound or deleted.
+func (s *Scheduler) clearBindBackoff(uid k8stypes.UID) {
+	s.bindBackoffs.mutex.Lock()
+	defer s.bindBackoffs.mutex.Unlock()
+	delete(s.bindBackoffs.failed, uid)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func TestBindFailureCooldown(t *testing.T) {
+	initTestDevices(t)
+	config.BindFailureCooldown = 30 * time.Second
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "retry", Namespace: "default", UID: "retry-uid"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "gpu",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.BinarySI)},
+			},
+		}}},
+	}
+	s := NewScheduler()
+	defer s.Stop()
+	clock := testingclock.NewFakeClock(time.Unix(1700000000, 0))
+	s.clock = clock
+	// The nodes aren't found by binds, so they fail.
+	client.KubeClient = fake.NewSimpleClientset(pod.DeepCopy())
+	s.kubeClient = client.KubeClient
+	s.addAllEventHandlers()
+	nodeNames := []string{"node1", "node2"}
+	for _, name := range nodeNames {
+		s.addNode(name, &device.NodeInfo{
+			ID:   name,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+				ID: name + "-GPU", Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+			}}},
+		})
+	}
+	filter := func() string {
+		got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+		assert.NilError(t, err)
+		assert.Assert(t, got.NodeNames != nil && len(*got.NodeNames) == 1)
+		return (*got.NodeNames)[0]
+	}
+
+	// The equal nodes are ordered by name.
+	assert.Equal(t, filter(), "node2")
+	result, err := s.Bind(extenderv1.ExtenderBindingArgs{PodName: pod.Name, PodNamespace: pod.Namespace, PodUID: pod.UID, Node: "node2"})
+	assert.NilError(t, err)
+	assert.Assert(t, result.Error != "")
+
+	// The next cycle within the cooldown avoids the node.
+	clock.Step(10 * time.Second)
+	assert.Equal(t, filter(), "node1")
+	assert.DeepEqual(t, s.bindBackoffNodes(pod.UID), map[string]bool{"node2": true})
+
+	// Once the cooldown is over, the node is selected again.
+	clock.Step(30 * time.Second)
+	assert.Equal(t, filter(), "node2")
+	assert.DeepEqual(t, s.bindBackoffNodes(pod.UID), map[string]bool{})
+
+	// Other pods aren't affected, and deleted pods aren't tracked anymore.
+	s.recordBindFailure(pod.UID, "node2")
+	assert.DeepEqual(t, s.bindBackoffNodes("other-uid"), map[string]bool{})
+	s.clearBindBackoff(pod.UID)
+	assert.DeepEqual(t, s.bindBackoffNodes(pod.UID), map[string]bool{})
+}
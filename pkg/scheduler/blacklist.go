@@ -0,0 +1,43 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// blacklistedDevices returns the devices of the node listed in its util.BlacklistUUIDsAnnotations, nil if none.
+// They're excluded from allocation like unhealthy devices, while the usage of pods assigned them is still
+// counted. The annotation is read from the latest node, the node devices are registered from is only replaced
+// when the devices change.
+func (s *Scheduler) blacklistedDevices(node *device.NodeInfo) *device.UUIDSet {
+	n := node.Node
+	if s.nodeLister != nil {
+		if latest, err := s.nodeLister.Get(node.ID); err == nil {
+			n = latest
+		}
+	}
+	if n == nil {
+		return nil
+	}
+	value, ok := n.Annotations[util.BlacklistUUIDsAnnotations]
+	if !ok {
+		return nil
+	}
+	return device.ParseUUIDSet(value)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Filter_NodeBlacklist(t *testing.T) {
+	initTestDevices(t)
+
+	// The devices were registered before the operator annotated the node.
+	registered := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	annotated := registered.DeepCopy()
+	annotated.Annotations = map[string]string{util.BlacklistUUIDsAnnotations: " GPU-0 ,GPU-9"}
+	other := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}
+	client.KubeClient = fake.NewSimpleClientset(annotated, other)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	informerFactory := informers.NewSharedInformerFactory(client.KubeClient, 0)
+	s.nodeLister = informerFactory.Core().V1().Nodes().Lister()
+	informerFactory.Start(s.stopCh)
+	informerFactory.WaitForCacheSync(s.stopCh)
+	gpu := func(id string) device.DeviceInfo {
+		return device.DeviceInfo{ID: id, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice}
+	}
+	s.addNode("node1", &device.NodeInfo{
+		ID:      "node1",
+		Node:    registered,
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {gpu("GPU-0"), gpu("GPU-1")}},
+	})
+	s.addNode("node2", &device.NodeInfo{
+		ID:      "node2",
+		Node:    other,
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {gpu("GPU-0")}},
+	})
+
+	// The pod running on the blacklisted GPU keeps it, its usage is still counted.
+	running := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default", UID: "running"}}
+	s.podManager.AddPod(running, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+		{UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 3000, Usedcores: 30},
+	}}})
+	usage, _, err := s.getNodesUsage(&[]string{"node1", "node2"}, nil)
+	assert.NilError(t, err)
+	for _, d := range (*usage)["node1"].Devices.DeviceLists {
+		assert.Equal(t, d.Device.Health, d.Device.ID != "GPU-0", "device %s", d.Device.ID)
+		if d.Device.ID == "GPU-0" {
+			assert.Equal(t, d.Device.Usedmem, int32(3000))
+		}
+	}
+	// The blacklist is scoped to its node, the GPU of node2 with the same UUID is allocatable.
+	assert.Assert(t, (*usage)["node2"].Devices.DeviceLists[0].Device.Health)
+
+	newPod := func(name string, gpus int64) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name)},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "train",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(gpus, resource.DecimalSI),
+					"hami.io/gpumem": *resource.NewQuantity(1000, resource.DecimalSI),
+				}},
+			}}},
+		}
+		_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		return pod
+	}
+	nodeNames := []string{"node1"}
+
+	// Only GPU-1 is allocatable on node1.
+	single := newPod("single", 1)
+	got, err := s.Filter(extenderv1.ExtenderArgs{Pod: single, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+	pi, ok := s.podManager.GetPod(single)
+	assert.Assert(t, ok)
+	assert.Equal(t, pi.Devices[nvidia.NvidiaGPUDevice][0][0].UUID, "GPU-1")
+
+	double := newPod("double", 2)
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: double, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0, "pod placed on %v", got.NodeNames)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// AllocationState is the summary of the devices of the cluster and of those allocated to namespaces.
+type AllocationState interface {
+	// Summary returns the summary of the cluster, with the namespaces restricted to namespace.
+	Summary(namespace string) *ClusterSummary
+}
+
+// podDeviceRequest returns the devices the pod requests, summed over its containers and the vendors. Memory
+// requested in percentage of a device isn't known until allocation and isn't counted, as in the summary.
+func podDeviceRequest(pod *corev1.Pod) DeviceAmount {
+	var res DeviceAmount
+	for _, ctrRequests := range device.Resourcereqs(pod) {
+		for _, req := range ctrRequests {
+			res.add(int64(req.Nums), int64(req.Nums)*int64(req.Memreq), int64(req.Nums)*int64(req.Coresreq))
+		}
+	}
+	return res
+}
+
+// checkMaxMemoryPerAllocation fails if a container of the pod requests more device memory per device than the max
+// memory per allocation of namespace in cfg. Memory requested in percentage of a device is checked on allocation.
+func checkMaxMemoryPerAllocation(cfg *config.Reloadable, namespace string, pod *corev1.Pod) error {
+	maxMem := cfg.MaxMemoryPerAllocation(namespace)
+	if maxMem == 0 {
+		return nil
+	}
+	for i, ctrRequests := range device.Resourcereqs(pod) {
+		for _, req := range ctrRequests {
+			if int64(req.Memreq) > maxMem {
+				return device.InvalidRequestErrorf("container %s requests %d MiB of device memory per device, over the max of %d MiB per allocation of namespace %s",
+					pod.Spec.Containers[i].Name, req.Memreq, maxMem, namespace)
+			}
+		}
+	}
+	return nil
+}
+
+// checkNamespaceBudget fails if admitting the pod would push the devices allocated to its namespace over the
+// budget of the namespace in cfg, and warns if within nearLimitPercent of it. The devices allocated are those of
+// the last summary of the cluster, including the requests of the pending pods of the namespace so pods admitted
+// before are counted until they're scheduled. Pods admitted since the last summary aren't counted, a burst of pods
+// admitted within a refresh of the summary may exceed the budget together. Percent budgets aren't enforced until
+// the capacity of the cluster is known.
+func checkNamespaceBudget(cfg *config.Reloadable, allocations AllocationState, namespace string, pod *corev1.Pod, warnings *device.AdmissionWarnings) error {
+	if allocations == nil {
+		return nil
+	}
+	budget, ok := cfg.NamespaceBudget(namespace)
+	if !ok {
+		return nil
+	}
+	request := podDeviceRequest(pod)
+	if request.Devices == 0 {
+		return nil
+	}
+	summary := allocations.Summary(namespace)
+	var total, allocated DeviceAmount
+	for _, v := range summary.Vendors {
+		total.add(v.Total.Devices, v.Total.Memory, v.Total.Cores)
+	}
+	for _, ns := range summary.Namespaces {
+		for _, amounts := range []map[string]DeviceAmount{ns.Allocated, ns.UnmetDemand} {
+			for _, amount := range amounts {
+				allocated.add(amount.Devices, amount.Memory, amount.Cores)
+			}
+		}
+	}
+	// The budget is the lowest of the absolute and the percent one set, the percent one is skipped while the
+	// capacity is unknown, e.g. before the first summary, rather than denying every pod.
+	check := func(resource string, requested, used, limit, percent, capacity int64) error {
+		if percent > 0 && capacity > 0 && (limit == 0 || capacity*percent/100 < limit) {
+			limit = capacity * percent / 100
+		} else if limit == 0 {
+			return nil
+		}
+		if requested > 0 && used+requested > limit {
+			return device.InvalidRequestErrorf("the pod requests %d device %s, but namespace %s is allocated %d of its budget of %d",
+				requested, resource, namespace, used, limit)
+		}
+		if requested > 0 && (used+requested)*100 >= limit*nearLimitPercent {
+			warnings.Addf("namespace %s is allocated %d of its budget of %d device %s with the pod", namespace, used+requested, limit, resource)
+		}
+		return nil
+	}
+	if err := check("cores", request.Cores, allocated.Cores, budget.Cores, budget.CoresPercent, total.Cores); err != nil {
+		return err
+	}
+	return check("memory", request.Memory, allocated.Memory, budget.Memory, budget.MemoryPercent, total.Memory)
+}
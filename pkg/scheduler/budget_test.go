@@ -0,0 +1,321 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func TestHandleNamespaceBudgets(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	config.NamespaceBudgets = []config.NamespaceBudget{
+		// Half of the 200 cores of the cluster
+		{Namespace: "team-a", CoresPercent: 50},
+		{Namespace: "team-b", Memory: 10000},
+	}
+
+	s := NewScheduler()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	s.addNode("node1", &device.NodeInfo{ID: "node1", Node: node, Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+		{ID: "GPU-0", Count: 10, Devmem: 16000, Devcore: 100, Type: "NVIDIA-Tesla T4", Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+		{ID: "GPU-1", Count: 10, Devmem: 16000, Devcore: 100, Type: "NVIDIA-Tesla T4", Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+	}}})
+	for _, ns := range []string{"team-a", "team-b"} {
+		running := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: ns, UID: "running-" + ns}}
+		s.podManager.AddPod(running, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+			{UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 8000, Usedcores: 60},
+		}}})
+	}
+	s.refreshSummary()
+	h := &webhook{decoder: admission.NewDecoder(clientgoscheme.Scheme), allocations: s}
+
+	tests := []struct {
+		name      string
+		namespace string
+		limits    corev1.ResourceList
+		warnings  []string
+		denied    string
+	}{
+		{
+			name:      "within the percent budget",
+			namespace: "team-a",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("1"), "hami.io/gpucores": resource.MustParse("30")},
+		},
+		{
+			name:      "close to the percent budget",
+			namespace: "team-a",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("1"), "hami.io/gpucores": resource.MustParse("40")},
+			warnings:  []string{"namespace team-a is allocated 100 of its budget of 100 device cores with the pod"},
+		},
+		{
+			name:      "over the percent budget",
+			namespace: "team-a",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("1"), "hami.io/gpucores": resource.MustParse("50")},
+			denied:    "the pod requests 50 device cores, but namespace team-a is allocated 60 of its budget of 100",
+		},
+		{
+			name:      "over the memory budget",
+			namespace: "team-b",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("2"), "hami.io/gpumem": resource.MustParse("2000")},
+			denied:    "the pod requests 4000 device memory, but namespace team-b is allocated 8000 of its budget of 10000",
+		},
+		{
+			name:      "namespace without budget",
+			namespace: "team-c",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("2"), "hami.io/gpucores": resource.MustParse("100")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: test.namespace},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "train", Image: "cuda", Resources: corev1.ResourceRequirements{Limits: test.limits},
+				}}},
+			}
+			raw, err := json.Marshal(pod)
+			assert.NilError(t, err)
+			resp := h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "req-uid",
+				Operation: admissionv1.Create,
+				Namespace: test.namespace,
+				Name:      "pod",
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			if test.denied != "" {
+				assert.Assert(t, !resp.Allowed)
+				assert.Equal(t, resp.Result.Message, test.denied)
+				return
+			}
+			assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+			assert.DeepEqual(t, resp.Warnings, test.warnings)
+		})
+	}
+}
+
+func TestHandleNamespaceBudgetsSummary(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	config.NamespaceBudgets = []config.NamespaceBudget{
+		{Namespace: "team-a", CoresPercent: 50},
+		{Namespace: "team-b", Cores: 100},
+	}
+
+	s := NewScheduler()
+	defer s.Stop()
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	s.podLister = listerscorev1.NewPodLister(pods)
+	h := &webhook{decoder: admission.NewDecoder(clientgoscheme.Scheme), allocations: s}
+	newPod := func(namespace, name, cores string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{SchedulerName: "hami-scheduler", Containers: []corev1.Container{{
+				Name: "train", Image: "cuda", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu": resource.MustParse("1"), "hami.io/gpucores": resource.MustParse(cores),
+				}},
+			}}},
+		}
+	}
+	admit := func(pod *corev1.Pod) admission.Response {
+		t.Helper()
+		raw, err := json.Marshal(pod)
+		assert.NilError(t, err)
+		return h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "req-uid",
+			Operation: admissionv1.Create,
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+	}
+
+	// The capacity of the cluster is unknown until the devices of its nodes are seen, the percent budget isn't
+	// enforced rather than denying every pod.
+	s.refreshSummary()
+	resp := admit(newPod("team-a", "early", "100"))
+	assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+
+	// Pods admitted are counted once pending in a summary, those admitted since the last one aren't.
+	pending := newPod("team-b", "pending", "60")
+	resp = admit(pending)
+	assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+	assert.NilError(t, pods.Add(pending))
+	resp = admit(newPod("team-b", "burst", "60"))
+	assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+	s.refreshSummary()
+	resp = admit(newPod("team-b", "late", "60"))
+	assert.Assert(t, !resp.Allowed)
+	assert.Equal(t, resp.Result.Message, "the pod requests 60 device cores, but namespace team-b is allocated 60 of its budget of 100")
+}
+
+func TestHandleMaxMemoryPerAllocation(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	config.MaxMemoryPerAllocation = 40000
+	config.NamespaceBudgets = []config.NamespaceBudget{
+		{Namespace: "team-a", MaxMemoryPerAllocation: 20000},
+		{Namespace: "team-b", MaxMemoryPerAllocation: 80000},
+	}
+	h := &webhook{decoder: admission.NewDecoder(clientgoscheme.Scheme)}
+
+	tests := []struct {
+		name      string
+		namespace string
+		limits    corev1.ResourceList
+		denied    string
+	}{
+		{
+			name:      "under the max",
+			namespace: "default",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("2"), "hami.io/gpumem": resource.MustParse("40000")},
+		},
+		{
+			name:      "over the max",
+			namespace: "default",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("1"), "hami.io/gpumem": resource.MustParse("80000")},
+			denied:    "container train requests 80000 MiB of device memory per device, over the max of 40000 MiB per allocation of namespace default",
+		},
+		{
+			name:      "over the lower max of the namespace",
+			namespace: "team-a",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("1"), "hami.io/gpumem": resource.MustParse("30000")},
+			denied:    "container train requests 30000 MiB of device memory per device, over the max of 20000 MiB per allocation of namespace team-a",
+		},
+		{
+			name:      "under the higher max of the namespace",
+			namespace: "team-b",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("1"), "hami.io/gpumem": resource.MustParse("80000")},
+		},
+		{
+			name:      "memory in percentage checked on allocation",
+			namespace: "default",
+			limits:    corev1.ResourceList{"hami.io/gpu": resource.MustParse("1"), "hami.io/gpumem-percentage": resource.MustParse("100")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: test.namespace},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "train", Image: "cuda", Resources: corev1.ResourceRequirements{Limits: test.limits},
+				}}},
+			}
+			raw, err := json.Marshal(pod)
+			assert.NilError(t, err)
+			resp := h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "req-uid",
+				Operation: admissionv1.Create,
+				Namespace: test.namespace,
+				Name:      "pod",
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			if test.denied != "" {
+				assert.Assert(t, !resp.Allowed)
+				assert.Equal(t, resp.Result.Message, test.denied)
+				return
+			}
+			assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+		})
+	}
+}
+
+func Test_Filter_MaxMemoryPerAllocation(t *testing.T) {
+	initTestDevices(t)
+	config.MaxMemoryPerAllocation = 40000
+
+	s := NewScheduler()
+	defer s.Stop()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	for name, mem := range map[string]int32{"node-a100": 80000, "node-a10": 24000} {
+		s.addNode(name, &device.NodeInfo{
+			ID:   name,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+				{ID: name + "-0", Count: 10, Devmem: mem, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			}},
+		})
+	}
+
+	// The whole memory of the GPU of node-a100 is over the max, node-a10 is left.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "whole-gpu", Namespace: "default", UID: "whole-gpu"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "train",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+				"hami.io/gpu":               resource.MustParse("1"),
+				"hami.io/gpumem-percentage": resource.MustParse("100"),
+			}},
+		}}},
+	}
+	_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	assert.NilError(t, err)
+	nodeNames := []string{"node-a100", "node-a10"}
+	got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"node-a10"})
+	assert.Equal(t, got.FailedNodes["node-a100"], common.NodeUnfitPod)
+
+	// On a node with both GPUs, the GPU over the max is skipped rather than failing the node.
+	s.addNode("node-mixed", &device.NodeInfo{
+		ID:   "node-mixed",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-mixed"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "node-mixed-a100", Index: 0, Count: 10, Devmem: 80000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			{ID: "node-mixed-a10", Index: 1, Count: 10, Devmem: 24000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+		}},
+	})
+	mixed := pod.DeepCopy()
+	mixed.Name, mixed.UID = "whole-gpu-mixed", "whole-gpu-mixed"
+	_, err = client.KubeClient.CoreV1().Pods(mixed.Namespace).Create(context.Background(), mixed, metav1.CreateOptions{})
+	assert.NilError(t, err)
+	nodeNames = []string{"node-a100", "node-mixed"}
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: mixed, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"node-mixed"})
+	current, err := client.KubeClient.CoreV1().Pods(mixed.Namespace).Get(context.Background(), mixed.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	devices, err := device.DecodePodDevices(device.SupportDevices, current.Annotations)
+	assert.NilError(t, err)
+	assert.Equal(t, devices[nvidia.NvidiaGPUDevice][0][0].UUID, "node-mixed-a10")
+	assert.Equal(t, devices[nvidia.NvidiaGPUDevice][0][0].Usedmem, int32(24000))
+}
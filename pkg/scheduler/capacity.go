@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// CapacityConfigMapKey is the key of the aggregated cluster capacity in the published ConfigMap.
+const CapacityConfigMapKey = "capacity.json"
+
+// DeviceTypeCapacity is the schedulable capacity of one device type, keyed by the
+// resource names pods request it with, so it can be used as quota of a Kueue ResourceFlavor.
+type DeviceTypeCapacity struct {
+	Vendor    string           `json:"vendor"`
+	Type      string           `json:"type"`
+	Devices   int              `json:"devices"`
+	Resources map[string]int64 `json:"resources"`
+}
+
+// ClusterCapacity is the content of CapacityConfigMapKey.
+type ClusterCapacity struct {
+	DeviceTypes []DeviceTypeCapacity `json:"deviceTypes"`
+}
+
+// aggregateCapacity sums up healthy devices registered on the given nodes per vendor and device type.
+func (s *Scheduler) aggregateCapacity(nodeNames []string) ClusterCapacity {
+	byType := make(map[string]*DeviceTypeCapacity)
+	for _, nodeName := range nodeNames {
+		node, err := s.GetNode(nodeName)
+		if err != nil {
+			continue
+		}
+		for vendor, devices := range node.Devices {
+			dev, ok := device.GetDevices()[vendor]
+			if !ok {
+				continue
+			}
+			names := dev.GetResourceNames()
+			for _, d := range devices {
+				if !d.Health {
+					continue
+				}
+				key := vendor + "/" + d.Type
+				c, ok := byType[key]
+				if !ok {
+					c = &DeviceTypeCapacity{Vendor: vendor, Type: d.Type, Resources: make(map[string]int64)}
+					byType[key] = c
+				}
+				c.Devices++
+				addCapacity(c.Resources, names.ResourceCountName, int64(d.Count))
+				addCapacity(c.Resources, names.ResourceMemoryName, int64(d.Devmem))
+				addCapacity(c.Resources, names.ResourceCoreName, int64(d.Devcore))
+			}
+		}
+	}
+	res := ClusterCapacity{DeviceTypes: make([]DeviceTypeCapacity, 0, len(byType))}
+	for _, c := range byType {
+		res.DeviceTypes = append(res.DeviceTypes, *c)
+	}
+	sort.Slice(res.DeviceTypes, func(i, j int) bool {
+		if res.DeviceTypes[i].Vendor != res.DeviceTypes[j].Vendor {
+			return res.DeviceTypes[i].Vendor < res.DeviceTypes[j].Vendor
+		}
+		return res.DeviceTypes[i].Type < res.DeviceTypes[j].Type
+	})
+	return res
+}
+
+func addCapacity(resources map[string]int64, name string, value int64) {
+	if name == "" {
+		return
+	}
+	resources[name] += value
+}
+
+// publishCapacity writes the capacity of the given nodes into config.KueueCapacityConfigMap,
+// the ConfigMap is only updated when the capacity changed.
+func (s *Scheduler) publishCapacity(nodeNames []string) error {
+	if config.KueueCapacityConfigMap == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.aggregateCapacity(nodeNames))
+	if err != nil {
+		return err
+	}
+	if string(data) == s.publishedCapacity {
+		return nil
+	}
+	if err := s.writeConfigMapKey(config.KueueCapacityConfigMap, CapacityConfigMapKey, string(data)); err != nil {
+		return err
+	}
+	s.publishedCapacity = string(data)
+	klog.V(4).InfoS("Published cluster device capacity", "configmap", config.KueueCapacityConfigMap, "capacity", s.publishedCapacity)
+	return nil
+}
+
+// writeConfigMapKey sets the key of the namespace/name ConfigMap to value, creating the ConfigMap if missing.
+func (s *Scheduler) writeConfigMapKey(ref, key, value string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(ref)
+	if err != nil || namespace == "" {
+		return fmt.Errorf("invalid configmap %q, must be namespace/name", ref)
+	}
+	cms := s.kubeClient.CoreV1().ConfigMaps(namespace)
+	cm, err := cms.Get(context.Background(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string]string{key: value},
+		}
+		_, err = cms.Create(context.Background(), cm, metav1.CreateOptions{})
+	} else if err == nil {
+		cm = cm.DeepCopy()
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = value
+		_, err = cms.Update(context.Background(), cm, metav1.UpdateOptions{})
+	}
+	return err
+}
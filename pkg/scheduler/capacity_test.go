@@ -0,0 +1,117 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func Test_publishCapacity(t *testing.T) {
+	initTestDevices(t)
+	config.KueueCapacityConfigMap = "kube-system/hami-capacity"
+
+	s := NewScheduler()
+	kubeClient := fake.NewSimpleClientset()
+	s.kubeClient = kubeClient
+
+	newDevice := func(id string, devType string) device.DeviceInfo {
+		return device.DeviceInfo{
+			ID:           id,
+			Count:        10,
+			Devmem:       16000,
+			Devcore:      100,
+			Type:         devType,
+			Health:       true,
+			DeviceVendor: nvidia.NvidiaGPUDevice,
+		}
+	}
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {newDevice("GPU-0", "NVIDIA-Tesla T4"), newDevice("GPU-1", "NVIDIA-Tesla T4")},
+		},
+	})
+	s.addNode("node2", &device.NodeInfo{
+		ID:   "node2",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {newDevice("GPU-2", "NVIDIA-Tesla T4"), newDevice("GPU-3", "NVIDIA-A100")},
+		},
+	})
+
+	published := func() ClusterCapacity {
+		t.Helper()
+		cm, err := kubeClient.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "hami-capacity", metav1.GetOptions{})
+		assert.NilError(t, err)
+		var capacity ClusterCapacity
+		assert.NilError(t, json.Unmarshal([]byte(cm.Data[CapacityConfigMapKey]), &capacity))
+		return capacity
+	}
+	t4 := func(devices int64) DeviceTypeCapacity {
+		return DeviceTypeCapacity{
+			Vendor:  nvidia.NvidiaGPUDevice,
+			Type:    "NVIDIA-Tesla T4",
+			Devices: int(devices),
+			Resources: map[string]int64{
+				"hami.io/gpu":      10 * devices,
+				"hami.io/gpumem":   16000 * devices,
+				"hami.io/gpucores": 100 * devices,
+			},
+		}
+	}
+	a100 := DeviceTypeCapacity{
+		Vendor:    nvidia.NvidiaGPUDevice,
+		Type:      "NVIDIA-A100",
+		Devices:   1,
+		Resources: map[string]int64{"hami.io/gpu": 10, "hami.io/gpumem": 16000, "hami.io/gpucores": 100},
+	}
+
+	assert.NilError(t, s.publishCapacity([]string{"node1", "node2"}))
+	assert.DeepEqual(t, published(), ClusterCapacity{DeviceTypes: []DeviceTypeCapacity{a100, t4(3)}})
+
+	// Nothing changed, the ConfigMap is not written again.
+	actions := len(kubeClient.Actions())
+	assert.NilError(t, s.publishCapacity([]string{"node1", "node2"}))
+	assert.Equal(t, len(kubeClient.Actions()), actions)
+
+	// A device of node1 turns unhealthy.
+	node1, _ := s.GetNode("node1")
+	node1.Devices[nvidia.NvidiaGPUDevice][1].Health = false
+	assert.NilError(t, s.publishCapacity([]string{"node1", "node2"}))
+	assert.DeepEqual(t, published(), ClusterCapacity{DeviceTypes: []DeviceTypeCapacity{a100, t4(2)}})
+
+	// node2 fails the health check and its devices are removed.
+	s.rmNodeDevices("node2", nvidia.NvidiaGPUDevice)
+	assert.NilError(t, s.publishCapacity([]string{"node1", "node2"}))
+	assert.DeepEqual(t, published(), ClusterCapacity{DeviceTypes: []DeviceTypeCapacity{t4(1)}})
+
+	// node1 is deleted from the cluster.
+	assert.NilError(t, s.publishCapacity([]string{}))
+	assert.DeepEqual(t, published(), ClusterCapacity{DeviceTypes: []DeviceTypeCapacity{}})
+}
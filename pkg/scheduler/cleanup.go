@@ -0,0 +1,200 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// cleanupInterval is the interval of the cleanup of stale annotations.
+const cleanupInterval = 10 * time.Minute
+
+// cleanedAnnotations counts the nodes and pods stale annotations were removed from.
+type cleanedAnnotations struct {
+	mutex sync.Mutex
+	// Vendor registrations removed from nodes
+	registrations int64
+	pods          int64
+}
+
+// CleanedStaleAnnotations returns the number of vendor registrations removed from nodes, and of pods
+// assignment annotations were removed from, by the cleanup of stale annotations.
+func (s *Scheduler) CleanedStaleAnnotations() (registrations, pods int64) {
+	s.cleaned.mutex.Lock()
+	defer s.cleaned.mutex.Unlock()
+	return s.cleaned.registrations, s.cleaned.pods
+}
+
+// cleanupStaleAnnotationsLoop removes stale annotations every cleanupInterval until the scheduler is stopped.
+func (s *Scheduler) cleanupStaleAnnotationsLoop() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanupStaleAnnotations(context.Background())
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// cleanupStaleAnnotations removes the registration and handshake annotations of the vendors whose handshake on
+// a node is older than config.StaleRegistrationAge, and the assignment annotations of the pods which succeeded
+// or failed config.FinishedPodAnnotationRetention ago. Vendors whose handshake can't be parsed are kept.
+func (s *Scheduler) cleanupStaleAnnotations(ctx context.Context) {
+	now := s.clock.Now()
+	nodes, err := s.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes to clean up stale registrations")
+	}
+	for _, node := range nodes {
+		if len(staleRegistrations(node.Annotations, now)) == 0 {
+			continue
+		}
+		var removed []string
+		err := util.PatchAnnotationsWithRetry(ctx, s.kubeClient, util.NodeRef(node.Name), func(meta *metav1.ObjectMeta) error {
+			removed = staleRegistrations(meta.Annotations, now)
+			for _, vendor := range removed {
+				delete(meta.Annotations, util.RegisterAnnos[vendor])
+				delete(meta.Annotations, util.HandshakeAnnos[vendor])
+			}
+			return nil
+		})
+		if err != nil {
+			klog.ErrorS(err, "Failed to remove stale device registrations, retrying on the next cleanup", "node", node.Name)
+			continue
+		}
+		if len(removed) == 0 {
+			continue
+		}
+		klog.InfoS("Removed stale device registrations", "node", node.Name, "vendors", removed)
+		s.cleaned.mutex.Lock()
+		s.cleaned.registrations += int64(len(removed))
+		s.cleaned.mutex.Unlock()
+	}
+
+	pods, err := s.podLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods to clean up stale assignments")
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		if now.Sub(podFinishTime(pod)) <= config.FinishedPodAnnotationRetention {
+			continue
+		}
+		if len(staleAssignments(pod.Annotations)) == 0 {
+			continue
+		}
+		var removed []string
+		err := util.PatchAnnotationsWithRetry(ctx, s.kubeClient, util.PodRef(pod.Namespace, pod.Name), func(meta *metav1.ObjectMeta) error {
+			removed = staleAssignments(meta.Annotations)
+			for _, key := range removed {
+				delete(meta.Annotations, key)
+			}
+			return nil
+		})
+		if err != nil {
+			klog.ErrorS(err, "Failed to remove assignment annotations of finished pod, retrying on the next cleanup", "pod", klog.KObj(pod))
+			continue
+		}
+		if len(removed) == 0 {
+			continue
+		}
+		klog.V(4).InfoS("Removed assignment annotations of finished pod", "pod", klog.KObj(pod), "annotations", removed)
+		s.cleaned.mutex.Lock()
+		s.cleaned.pods++
+		s.cleaned.mutex.Unlock()
+	}
+}
+
+// staleRegistrations returns the vendors registered in the annotations of a node whose handshake is older than
+// config.StaleRegistrationAge at now.
+func staleRegistrations(annotations map[string]string, now time.Time) []string {
+	res := make([]string, 0)
+	for _, vendor := range slices.Sorted(maps.Keys(util.RegisterAnnos)) {
+		if _, ok := annotations[util.RegisterAnnos[vendor]]; !ok {
+			continue
+		}
+		handshakeTime, ok := parseHandshake(annotations[util.HandshakeAnnos[vendor]])
+		if !ok || now.Sub(handshakeTime) <= config.StaleRegistrationAge {
+			continue
+		}
+		res = append(res, vendor)
+	}
+	return res
+}
+
+// staleAssignments returns the assignment annotations in the annotations of a finished pod.
+func staleAssignments(annotations map[string]string) []string {
+	res := make([]string, 0)
+	for _, key := range assignmentAnnotations() {
+		if _, ok := annotations[key]; ok {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+// parseHandshake returns the time of a handshake annotation value, e.g. Reported_2025-01-02T15:04:05Z.
+func parseHandshake(value string) (time.Time, bool) {
+	_, stamp, ok := strings.Cut(value, "_")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := util.ParseHandshakeTime(stamp)
+	return t, err == nil
+}
+
+// podFinishTime returns when the last container of the finished pod terminated, its creation if unknown.
+func podFinishTime(pod *corev1.Pod) time.Time {
+	res := pod.CreationTimestamp.Time
+	for _, status := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+		if t := status.State.Terminated; t != nil && t.FinishedAt.After(res) {
+			res = t.FinishedAt.Time
+		}
+	}
+	return res
+}
+
+// assignmentAnnotations are the annotations the scheduler assigns devices to pods by.
+func assignmentAnnotations() []string {
+	res := []string{util.AssignedNodeAnnotations, util.AssignedTimeAnnotations, util.BindTimeAnnotations, util.DeviceBindPhase}
+	for _, vendor := range slices.Sorted(maps.Keys(device.InRequestDevices)) {
+		res = append(res, device.InRequestDevices[vendor])
+	}
+	for _, vendor := range slices.Sorted(maps.Keys(device.SupportDevices)) {
+		res = append(res, device.SupportDevices[vendor])
+	}
+	return res
+}
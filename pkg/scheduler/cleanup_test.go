@@ -0,0 +1,121 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/hygon"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_cleanupStaleAnnotations(t *testing.T) {
+	initTestDevices(t)
+	util.HandshakeAnnos[hygon.HygonDCUDevice] = hygon.HandshakeAnnos
+	util.RegisterAnnos[hygon.HygonDCUDevice] = hygon.RegisterAnnos
+	config.StaleRegistrationAge = 24 * time.Hour
+	config.FinishedPodAnnotationRetention = time.Hour
+
+	now := time.Now()
+	handshake := func(age time.Duration) string {
+		return "Reported_" + util.FormatHandshakeTime(now.Add(-age))
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", ResourceVersion: "1", Annotations: map[string]string{
+		// The device plugin of NVIDIA GPUs is gone, the one of DCUs is live
+		nvidia.RegisterAnnos:  "GPU-0,10,16000,100,NVIDIA-Tesla T4,0,true:",
+		nvidia.HandshakeAnnos: handshake(48 * time.Hour),
+		hygon.RegisterAnnos:   "DCU-0,10,16000,100,DCU,0,true:",
+		hygon.HandshakeAnnos:  handshake(time.Minute),
+		"other":               "kept",
+	}}}
+	unparsed := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", ResourceVersion: "1", Annotations: map[string]string{
+		nvidia.RegisterAnnos:  "GPU-0,10,16000,100,NVIDIA-Tesla T4,0,true:",
+		nvidia.HandshakeAnnos: "Reported",
+	}}}
+	assigned := map[string]string{
+		util.AssignedNodeAnnotations:                    "node1",
+		util.DeviceBindPhase:                            util.DeviceBindSuccess,
+		device.InRequestDevices[nvidia.NvidiaGPUDevice]: ";",
+		device.SupportDevices[nvidia.NvidiaGPUDevice]:   "GPU-0,NVIDIA,4000,30:;",
+		"other": "kept",
+	}
+	newPod := func(name string, phase corev1.PodPhase, finished time.Time) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", ResourceVersion: "1", CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)), Annotations: assigned},
+			Status: corev1.PodStatus{Phase: phase, ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(finished)}},
+			}}},
+		}
+	}
+	old := newPod("old", corev1.PodSucceeded, now.Add(-2*time.Hour))
+	recent := newPod("recent", corev1.PodFailed, now.Add(-time.Minute))
+	running := newPod("running", corev1.PodRunning, now.Add(-2*time.Hour))
+
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = fake.NewClientset(node, unparsed, old, recent, running)
+	s.clock = testingclock.NewFakeClock(now)
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range []any{node, unparsed} {
+		assert.NilError(t, nodes.Add(obj))
+	}
+	for _, obj := range []any{old, recent, running} {
+		assert.NilError(t, pods.Add(obj))
+	}
+	s.nodeLister = listerscorev1.NewNodeLister(nodes)
+	s.podLister = listerscorev1.NewPodLister(pods)
+
+	s.cleanupStaleAnnotations(context.Background())
+
+	got, err := s.kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.Annotations, map[string]string{
+		hygon.RegisterAnnos:  node.Annotations[hygon.RegisterAnnos],
+		hygon.HandshakeAnnos: node.Annotations[hygon.HandshakeAnnos],
+		"other":              "kept",
+	})
+	got, err = s.kubeClient.CoreV1().Nodes().Get(context.Background(), "node2", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.Annotations, unparsed.Annotations)
+
+	for _, pod := range []*corev1.Pod{old, recent, running} {
+		got, err := s.kubeClient.CoreV1().Pods("default").Get(context.Background(), pod.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		if pod == old {
+			assert.DeepEqual(t, got.Annotations, map[string]string{"other": "kept"})
+		} else {
+			assert.DeepEqual(t, got.Annotations, assigned)
+		}
+	}
+	registrations, cleaned := s.CleanedStaleAnnotations()
+	assert.Equal(t, registrations, int64(1))
+	assert.Equal(t, cleaned, int64(1))
+}
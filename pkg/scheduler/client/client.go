@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client calls the gRPC extender service of HAMi scheduler with the types of
+// k8s.io/kube-scheduler/extender/v1, e.g. from a kube-scheduler plugin.
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/api/extenderpb"
+)
+
+// Client is a client of the gRPC extender service. Errors of the service are gRPC status errors.
+type Client struct {
+	extender extenderpb.ExtenderClient
+	conn     *grpc.ClientConn
+}
+
+// New returns a client calling the service over conn.
+func New(conn grpc.ClientConnInterface) *Client {
+	return &Client{extender: extenderpb.NewExtenderClient(conn)}
+}
+
+// Dial returns a client calling the service at target, e.g. 127.0.0.1:9090. Close releases the connection.
+func Dial(target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := New(conn)
+	c.conn = conn
+	return c, nil
+}
+
+// Close closes the connection created by Dial.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Filter returns the nodes the pod of args fits.
+func (c *Client) Filter(ctx context.Context, args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error) {
+	in, err := extenderpb.FilterArgsFromExtender(args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.extender.Filter(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out.ToExtender()
+}
+
+// Bind binds the pod of args to the node.
+func (c *Client) Bind(ctx context.Context, args extenderv1.ExtenderBindingArgs) (*extenderv1.ExtenderBindingResult, error) {
+	out, err := c.extender.Bind(ctx, extenderpb.BindArgsFromExtender(args))
+	if err != nil {
+		return nil, err
+	}
+	return out.ToExtender(), nil
+}
+
+// Preempt returns the candidate nodes of args on which the pod fits once the victims are evicted.
+func (c *Client) Preempt(ctx context.Context, args extenderv1.ExtenderPreemptionArgs) (*extenderv1.ExtenderPreemptionResult, error) {
+	in, err := extenderpb.PreemptArgsFromExtender(args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.extender.Preempt(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out.ToExtender(), nil
+}
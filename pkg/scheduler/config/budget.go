@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NamespaceBudget caps the device cores and memory allocated to the pods of Namespace, summed over the devices
+// of every vendor, which the webhook enforces at admission. Cores are in percent of a device and memory in MiB,
+// the percent budgets are of the capacity of the cluster. Budgets of 0 don't cap.
+type NamespaceBudget struct {
+	Namespace     string `yaml:"namespace"`
+	Cores         int64  `yaml:"cores"`
+	Memory        int64  `yaml:"memory"`
+	CoresPercent  int64  `yaml:"coresPercent"`
+	MemoryPercent int64  `yaml:"memoryPercent"`
+	// MaxMemoryPerAllocation overrides MaxMemoryPerAllocation for the namespace if not 0.
+	MaxMemoryPerAllocation int64 `yaml:"maxMemoryPerAllocation"`
+}
+
+// NamespaceBudgets are the budgets of the devices of namespaces.
+var NamespaceBudgets []NamespaceBudget
+
+// MaxMemoryPerAllocation is the most device memory in MiB a container may be allocated on a single device,
+// unlimited if 0.
+var MaxMemoryPerAllocation int64
+
+// NamespaceBudget returns the active budget of namespace, false if it has none.
+func (r *Reloadable) NamespaceBudget(namespace string) (NamespaceBudget, bool) {
+	for _, b := range r.NamespaceBudgets {
+		if b.Namespace == namespace {
+			return b, true
+		}
+	}
+	return NamespaceBudget{}, false
+}
+
+// MaxMemoryPerAllocation returns the most device memory in MiB a container of namespace may be allocated on a
+// single device, the override of its budget or MaxMemoryPerAllocation, unlimited if 0.
+func (r *Reloadable) MaxMemoryPerAllocation(namespace string) int64 {
+	if b, ok := r.NamespaceBudget(namespace); ok && b.MaxMemoryPerAllocation > 0 {
+		return b.MaxMemoryPerAllocation
+	}
+	return MaxMemoryPerAllocation
+}
+
+func validateNamespaceBudgets(budgets []NamespaceBudget) error {
+	seen := make(map[string]bool, len(budgets))
+	for _, b := range budgets {
+		if strings.TrimSpace(b.Namespace) == "" {
+			return fmt.Errorf("empty namespace in namespace budgets")
+		}
+		if seen[b.Namespace] {
+			return fmt.Errorf("duplicate budget of namespace %s", b.Namespace)
+		}
+		seen[b.Namespace] = true
+		if b.Cores < 0 || b.Memory < 0 || b.MaxMemoryPerAllocation < 0 {
+			return fmt.Errorf("budget of namespace %s must not be negative", b.Namespace)
+		}
+		if b.CoresPercent < 0 || b.CoresPercent > 100 || b.MemoryPercent < 0 || b.MemoryPercent > 100 {
+			return fmt.Errorf("percent budgets of namespace %s must be within 0 and 100", b.Namespace)
+		}
+	}
+	return nil
+}
@@ -36,16 +36,19 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/device/iluvatar"
 	"github.com/Project-HAMi/HAMi/pkg/device/kunlun"
 	"github.com/Project-HAMi/HAMi/pkg/device/metax"
+	"github.com/Project-HAMi/HAMi/pkg/device/mock"
 	"github.com/Project-HAMi/HAMi/pkg/device/mthreads"
 	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 )
 
 var (
-	QPS                float32
-	Burst              int
-	Timeout            int
-	HTTPBind           string
+	QPS      float32
+	Burst    int
+	Timeout  int
+	HTTPBind string
+	// GRPCBind is the address of the gRPC extender server, disabled if empty.
+	GRPCBind           string
 	SchedulerName      string
 	MetricsBindAddress string
 
@@ -62,8 +65,188 @@ var (
 	// NodeLockTimeout is the timeout for node locks.
 	NodeLockTimeout time.Duration
 
+	// BindFailureCooldown is how long nodes a pod failed to bind to are deprioritized for the pod.
+	BindFailureCooldown time.Duration
+
+	// ShutdownGracePeriod is how long to wait for in-flight binds on shutdown before rolling them back.
+	ShutdownGracePeriod time.Duration
+
+	// ExtenderDeadline is how long filter and bind requests may take, slightly under the extender timeout of
+	// kube-scheduler, before filter returns the nodes fit so far and bind is rolled back. Disabled if 0.
+	ExtenderDeadline time.Duration
+
 	// If set to false, When Pod.Spec.SchedulerName equals to the const DefaultSchedulerName in k8s.io/api/core/v1 package, webhook will not overwrite it, default value is true.
 	ForceOverwriteDefaultScheduler bool
+
+	// VolcanoSchedulerName is the schedulerName of pods scheduled by volcano. If not empty, webhook still mutates
+	// device resources of those pods but keeps their schedulerName, and the volcano extender routes are served.
+	VolcanoSchedulerName string
+
+	// KueueCapacityConfigMap is the namespace/name of the ConfigMap the schedulable device capacity of the
+	// cluster is published to, e.g. for the quota of Kueue ResourceFlavors. Disabled if empty.
+	KueueCapacityConfigMap string
+
+	// FreeMemoryBands are the bounds of the bands of free device memory nodes are labeled with, as quantities in
+	// ascending order. Disabled if empty.
+	FreeMemoryBands []string
+	// FreeMemoryBandHysteresis is how far the free memory of a node must leave its band for its label to change.
+	FreeMemoryBandHysteresis string
+	// FreeCapacityConfigMap is the namespace/name of the ConfigMap the number of nodes in each band of free
+	// memory is published to, e.g. for multi-cluster placement. Disabled if empty.
+	FreeCapacityConfigMap string
+
+	// UtilizationProfileConfigMap is the namespace/name of the ConfigMap of the utilization profiles of workloads
+	// referenced by pods, whose peaks are allocated instead of the requests of the pods. Disabled if empty.
+	UtilizationProfileConfigMap string
+
+	// NodeResyncPeriod is the period of full reconciles of node devices. Between them node devices
+	// are only synced on node events.
+	NodeResyncPeriod time.Duration
+
+	// UtilizationSourceURL is the URL of the Prometheus server, or of the metrics endpoint of HAMi monitor,
+	// the measured utilization of devices is scraped from for the utilization GPU policy. Disabled if empty.
+	UtilizationSourceURL string
+	// UtilizationQuery is the Prometheus query of the utilization of devices in percent, labeled by deviceuuid.
+	// The metrics endpoint of HAMi monitor is read if empty.
+	UtilizationQuery string
+	// UtilizationScrapeInterval is the interval of scraping the utilization of devices.
+	UtilizationScrapeInterval time.Duration
+
+	// RightSizingSourceURL is the URL of the Prometheus server, or of the metrics endpoint of HAMi monitor, the device
+	// memory usage of containers is scraped from to recommend the device memory of Deployments and StatefulSets.
+	// Disabled if empty.
+	RightSizingSourceURL string
+	// RightSizingQuery is the Prometheus query of the device memory usage of containers in bytes, labeled like
+	// vGPU_device_memory_usage_in_bytes. The metrics endpoint of HAMi monitor is read if empty.
+	RightSizingQuery string
+	// RightSizingInterval is the interval of scraping the device memory usage of containers.
+	RightSizingInterval time.Duration
+	// RightSizingWindow is how long the peak usage of workloads is measured over, workloads are only recommended
+	// once observed that long.
+	RightSizingWindow time.Duration
+	// RightSizingThreshold is the share of the request the recommendation must differ from it by to be recorded.
+	RightSizingThreshold float64
+
+	// ManageWebhookConfig makes the scheduler issue and rotate its own serving certificate and write the CA
+	// bundle into the MutatingWebhookConfiguration, instead of using the cert and key files.
+	ManageWebhookConfig bool
+	// WebhookConfigName is the name of the MutatingWebhookConfiguration of the scheduler.
+	WebhookConfigName string
+	// WebhookService is the namespace/name of the Service the webhook is reached through.
+	WebhookService string
+	// WebhookCertSecret is the name of the Secret in the namespace of WebhookService keeping the certificates.
+	WebhookCertSecret string
+	// WebhookCertValidity is the validity of the issued serving certificate.
+	WebhookCertValidity time.Duration
+
+	// EnableDRA makes the scheduler allocate ResourceClaims of DRADriverName as a DRA control plane controller.
+	EnableDRA bool
+	// DRADriverName is the name of the HAMi DRA driver, set as controller of its ResourceClaims.
+	DRADriverName string
+
+	// ManageNodeLabels makes the scheduler label nodes with the types and mode of their registered devices.
+	ManageNodeLabels bool
+
+	// EnableFaultInjection makes the scheduler inject the faults set on its fault injection routes, for testing only.
+	EnableFaultInjection bool
+
+	// EnforceLimitRanges makes the webhook apply the defaults of the LimitRanges of the namespace to the device
+	// resources pods omit, and deny pods whose device resources are out of their min and max.
+	EnforceLimitRanges bool
+
+	// SetRequestsToLimits makes the webhook set the cpu and memory requests of pods requesting devices to their
+	// limits, so those pods are of the Guaranteed QoS class if every container sets the limits.
+	SetRequestsToLimits bool
+
+	// WebhookIncludeOwnerKinds are the kinds of the owners of the pods the webhook mutates, every kind if empty.
+	// Pods without owner are of the kind "None".
+	WebhookIncludeOwnerKinds []string
+	// WebhookExcludeOwnerKinds are the kinds of the owners of the pods the webhook never mutates, taking
+	// precedence over WebhookIncludeOwnerKinds.
+	WebhookExcludeOwnerKinds []string
+
+	// OwnedEnvPolicy is what the webhook does with pods setting environment variables owned by the device plugin
+	// of the devices they request: allow, deny or override them.
+	OwnedEnvPolicy string
+
+	// AdmissionDecisionLog is the destination every admission decision of the webhook is logged to as a JSON
+	// line, stdout, stderr or the path of a file. Disabled if empty.
+	AdmissionDecisionLog string
+
+	// ReservationTTL is how long devices reserved for a pod by filter are kept while the pod isn't bound.
+	// Disabled if 0.
+	ReservationTTL time.Duration
+
+	// VolumeLocality is the default locality of pods to the nodes their node-local volumes are on, one of
+	// none, preferred and strict.
+	VolumeLocality string
+
+	// ContainerRuntime is the default container runtime of the nodes to place pods requesting devices on, any
+	// runtime if empty. ContainerRuntimeAffinity is whether it's strict or preferred.
+	ContainerRuntime         string
+	ContainerRuntimeAffinity string
+
+	// SuggestedPlacementMode is the default mode of the placements suggested for pods by external optimizers, one
+	// of preferred and strict.
+	SuggestedPlacementMode string
+
+	// AllowedRuntimeClasses are the runtime classes pods requesting devices may use, every runtime class if empty.
+	AllowedRuntimeClasses []string
+
+	// EvictOrphanedAssignments deletes pods assigned devices no longer registered on their node, e.g. after
+	// the GPU was replaced, once they have been for OrphanedAssignmentGracePeriod.
+	EvictOrphanedAssignments      bool
+	OrphanedAssignmentGracePeriod time.Duration
+
+	// MissingDeviceExclusion is how long devices the device plugin found gone from their node on allocation are
+	// excluded. Disabled if 0.
+	MissingDeviceExclusion time.Duration
+
+	// AllowUnmanagedWholeDevices lets pods only requesting whole devices be placed on nodes advertising the
+	// device resources without devices registered by HAMi, when no node managed by HAMi fits them.
+	AllowUnmanagedWholeDevices bool
+
+	// AllocationLeaseDuration is how long the allocation a replica of the scheduler committed to a node keeps
+	// other replicas from allocating to the node until they've seen it. Disabled if 0, for a single replica.
+	AllocationLeaseDuration time.Duration
+
+	// DefaultGPURequestLabels are the labels of legacy pods not setting device resources the webhook requests a
+	// single NVIDIA GPU for, of DefaultGPURequestMemory MB and DefaultGPURequestCores cores, the defaults of the
+	// device config if 0. Disabled if empty.
+	DefaultGPURequestLabels map[string]string
+	DefaultGPURequestMemory int32
+	DefaultGPURequestCores  int32
+
+	// CleanupStaleAnnotations removes the registration annotations of device vendors whose handshake on a node is
+	// older than StaleRegistrationAge, e.g. after their device plugin was removed, and the assignment annotations
+	// of pods finished for FinishedPodAnnotationRetention.
+	CleanupStaleAnnotations        bool
+	StaleRegistrationAge           time.Duration
+	FinishedPodAnnotationRetention time.Duration
+
+	// InPlaceGPUResize resizes the NVIDIA GPUs assigned to running pods whose containers change the GPU memory or
+	// cores they request, by in-place pod resize, if the devices have room for it.
+	InPlaceGPUResize bool
+
+	// ExportDeviceAllocations mirrors the device allocation of each node into a DeviceAllocation object.
+	ExportDeviceAllocations bool
+
+	// DeviceResidencyWarning is how long before their MaxDeviceResidency pods are warned, and
+	// EvictExpiredDeviceResidency evicts the pods owned by a controller past it, within their disruption budgets.
+	DeviceResidencyWarning      time.Duration
+	EvictExpiredDeviceResidency bool
+
+	// Defragment evicts the pods labeled with util.RebalanceLabelKey, owned by a controller and of a priority up to
+	// DefragmentMaxPriority, whose rescheduling consolidates the partially used devices, every DefragmentInterval,
+	// at most DefragmentMaxEvictions at a time and within their disruption budgets.
+	Defragment             bool
+	DefragmentInterval     time.Duration
+	DefragmentMaxPriority  int32
+	DefragmentMaxEvictions int
+
+	// AcceleratorVendors are the vendors allowed to satisfy requests of device.AcceleratorMemoryResource, every
+	// vendor able to if empty.
+	AcceleratorVendors []string
 )
 
 type Config struct {
@@ -77,7 +260,22 @@ type Config struct {
 	KunlunConfig    kunlun.KunlunConfig       `yaml:"kunlun"`
 	AWSNeuronConfig awsneuron.AWSNeuronConfig `yaml:"awsneuron"`
 	AMDGPUConfig    amd.AMDConfig             `yaml:"amd"`
+	MockConfig      mock.MockConfig           `yaml:"mock"`
 	VNPUs           []ascend.VNPUConfig       `yaml:"vnpus"`
+	// NodeGroupTemplates describe the devices of cluster autoscaler node groups.
+	NodeGroupTemplates []NodeGroupTemplate `yaml:"nodeGroupTemplates"`
+	// ImageAllowlist are the images permitted to use devices, matched by prefix or digest.
+	ImageAllowlist []string `yaml:"imageAllowlist"`
+	// GPUModelPolicies override the scheduling policies by GPU model.
+	GPUModelPolicies []GPUModelPolicy `yaml:"gpuModelPolicies"`
+	// NamespaceBudgets cap the devices allocated to namespaces.
+	NamespaceBudgets []NamespaceBudget `yaml:"namespaceBudgets"`
+	// MaxDeviceResidency is the longest pods may use the devices of a type.
+	MaxDeviceResidency map[string]time.Duration `yaml:"maxDeviceResidency"`
+	// AcceleratorVendors are the vendors allowed to satisfy vendor agnostic requests of devices.
+	AcceleratorVendors []string `yaml:"acceleratorVendors"`
+	// Scheduler overrides the scheduler flags, it's reloaded if ConfigReload is set.
+	Scheduler SchedulerConfig `yaml:"scheduler"`
 }
 
 var (
@@ -125,6 +323,12 @@ func InitDevicesWithConfig(config *Config) error {
 			if !ok {
 				return nil, fmt.Errorf("invalid configuration for %s", nvidia.NvidiaGPUDevice)
 			}
+			if w := nvidiaConfig.DefaultGPUWeight; w != 0 && (w < nvidia.MinGPUWeight || w > nvidia.MaxGPUWeight) {
+				return nil, fmt.Errorf("defaultGPUWeight %d must be from %d to %d, or 0 for none", w, nvidia.MinGPUWeight, nvidia.MaxGPUWeight)
+			}
+			if err := nvidiaConfig.ValidateAutoCores(); err != nil {
+				return nil, err
+			}
 			return nvidia.InitNvidiaDevice(nvidiaConfig), nil
 		}, config.NvidiaConfig},
 		{cambricon.CambriconMLUDevice, cambricon.CambriconMLUCommonWord, func(cfg any) (device.Devices, error) {
@@ -227,10 +431,57 @@ func InitDevicesWithConfig(config *Config) error {
 		klog.Infof("Iluvatar device %s initialized", commonWord)
 	}
 
+	// Initialize the mock devices, only enabled to test without hardware
+	if config.MockConfig.Enabled {
+		device.DevicesMap[mock.MockCommonWord] = mock.InitMockDevice(config.MockConfig)
+		device.DevicesToHandle = append(device.DevicesToHandle, mock.MockCommonWord)
+		klog.Infof("Mock device %s initialized", mock.MockCommonWord)
+	}
+
 	if len(initErrors) > 0 {
 		return fmt.Errorf("errors occurred during initialization: %v", initErrors)
 	}
 
+	templates, err := resolveNodeGroupTemplates(config.NodeGroupTemplates, config.NvidiaConfig)
+	if err != nil {
+		klog.Errorf("Invalid node group templates: %v", err)
+		return err
+	}
+	NodeGroupTemplates = templates
+
+	if err := validateImageAllowlist(config.ImageAllowlist); err != nil {
+		klog.Errorf("Invalid image allowlist: %v", err)
+		return err
+	}
+	ImageAllowlist = config.ImageAllowlist
+
+	if err := validateGPUModelPolicies(config.GPUModelPolicies); err != nil {
+		klog.Errorf("Invalid GPU model policies: %v", err)
+		return err
+	}
+	GPUModelPolicies = config.GPUModelPolicies
+
+	if err := validateNamespaceBudgets(config.NamespaceBudgets); err != nil {
+		klog.Errorf("Invalid namespace budgets: %v", err)
+		return err
+	}
+	NamespaceBudgets = config.NamespaceBudgets
+
+	if err := validateMaxDeviceResidency(config.MaxDeviceResidency); err != nil {
+		klog.Errorf("Invalid max device residency: %v", err)
+		return err
+	}
+	MaxDeviceResidency = config.MaxDeviceResidency
+
+	for _, vendor := range config.AcceleratorVendors {
+		if _, ok := device.DevicesMap[vendor].(device.AcceleratorDevices); !ok {
+			err := fmt.Errorf("accelerator vendor %q can't satisfy %s", vendor, device.AcceleratorMemoryResource)
+			klog.Errorf("Invalid accelerator vendors: %v", err)
+			return err
+		}
+	}
+	AcceleratorVendors = config.AcceleratorVendors
+
 	klog.Info("All devices initialized successfully")
 	return nil
 }
@@ -247,6 +498,7 @@ func validateConfig(config *Config) error {
 		!reflect.DeepEqual(config.AWSNeuronConfig, awsneuron.AWSNeuronConfig{}) ||
 		!reflect.DeepEqual(config.EnflameConfig, enflame.EnflameConfig{}) ||
 		!reflect.DeepEqual(config.AMDGPUConfig, amd.AMDConfig{}) ||
+		config.MockConfig.Enabled ||
 		len(config.VNPUs) > 0 {
 		return nil
 	}
@@ -268,6 +520,11 @@ func InitDevices() {
 	if err != nil {
 		klog.Fatalf("Failed to initialize devices: %v", err)
 	}
+	reloadable, err := newReloadable(config)
+	if err != nil {
+		klog.Fatalf("Invalid scheduler configuration: %v", err)
+	}
+	active.Store(reloadable)
 }
 
 func InitDefaultDevices() {
@@ -0,0 +1,72 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImageAllowlist are the images permitted to use devices, every image is permitted if empty.
+var ImageAllowlist []string
+
+// ImageAllowed reports whether containers of image may use devices by the active image allowlist.
+func ImageAllowed(image string) bool {
+	return Current().ImageAllowed(image)
+}
+
+// ImageAllowed reports whether containers of image may use devices. An entry matches images
+// by digest if it is a digest, e.g. sha256:..., or a reference with a digest, e.g. repo@sha256:...,
+// otherwise it matches images starting with it, e.g. registry.example.com/ml/.
+func (r *Reloadable) ImageAllowed(image string) bool {
+	if len(r.ImageAllowlist) == 0 {
+		return true
+	}
+	_, digest, _ := strings.Cut(image, "@")
+	for _, entry := range r.ImageAllowlist {
+		switch {
+		case isDigest(entry):
+			if digest == entry {
+				return true
+			}
+		case strings.Contains(entry, "@"):
+			if image == entry {
+				return true
+			}
+		case strings.HasPrefix(image, entry):
+			return true
+		}
+	}
+	return false
+}
+
+func isDigest(s string) bool {
+	algorithm, hex, ok := strings.Cut(s, ":")
+	return ok && hex != "" && (algorithm == "sha256" || algorithm == "sha384" || algorithm == "sha512")
+}
+
+func validateImageAllowlist(allowlist []string) error {
+	for _, entry := range allowlist {
+		if strings.TrimSpace(entry) == "" {
+			return fmt.Errorf("empty entry in image allowlist")
+		}
+		if _, digest, ok := strings.Cut(entry, "@"); ok && !isDigest(digest) {
+			return fmt.Errorf("invalid digest in image allowlist entry %q", entry)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_ImageAllowed(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name      string
+		allowlist []string
+		image     string
+		want      bool
+	}{
+		{name: "empty allowlist", image: "nginx", want: true},
+		{name: "prefix", allowlist: []string{"registry.example.com/ml/"}, image: "registry.example.com/ml/pytorch:2.4", want: true},
+		{name: "prefix mismatch", allowlist: []string{"registry.example.com/ml/"}, image: "registry.example.com/web/nginx", want: false},
+		{name: "digest", allowlist: []string{digest}, image: "docker.io/library/cuda@" + digest, want: true},
+		{name: "digest of tag only image", allowlist: []string{digest}, image: "docker.io/library/cuda:12.4", want: false},
+		{name: "reference with digest", allowlist: []string{"cuda@" + digest}, image: "cuda@" + digest, want: true},
+		{name: "reference with digest of other repository", allowlist: []string{"cuda@" + digest}, image: "evil@" + digest, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ImageAllowlist = test.allowlist
+			t.Cleanup(func() { ImageAllowlist = nil })
+			assert.Equal(t, ImageAllowed(test.image), test.want)
+		})
+	}
+}
+
+func Test_validateImageAllowlist(t *testing.T) {
+	assert.NilError(t, validateImageAllowlist([]string{"registry.example.com/", "sha256:abc", "cuda@sha256:abc"}))
+	assert.ErrorContains(t, validateImageAllowlist([]string{" "}), "empty entry")
+	assert.ErrorContains(t, validateImageAllowlist([]string{"cuda@latest"}), "invalid digest")
+}
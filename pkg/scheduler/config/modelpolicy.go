@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// GPUModelPolicy overrides the scheduling policies when allocating GPUs of Model, matched case-insensitively
+// within the device type, e.g. A100 matches NVIDIA-NVIDIA A100-SXM4-40GB. Empty policies aren't overridden.
+type GPUModelPolicy struct {
+	Model               string `yaml:"model"`
+	NodeSchedulerPolicy string `yaml:"nodeSchedulerPolicy"`
+	GPUSchedulerPolicy  string `yaml:"gpuSchedulerPolicy"`
+	// Cost weighs the devices of Model against the other devices of a node, the cheaper ones fit requests first
+	// whatever their score. Devices cost 1 if 0.
+	Cost float64 `yaml:"cost"`
+}
+
+// GPUModelPolicies are the scheduling policies by GPU model, the first entry matching a model applies.
+var GPUModelPolicies []GPUModelPolicy
+
+// ModelPolicy returns the policies of the first entry of the active GPU model policies matching model, the zero
+// policy if none does.
+func (r *Reloadable) ModelPolicy(model string) GPUModelPolicy {
+	if model == "" {
+		return GPUModelPolicy{}
+	}
+	model = strings.ToUpper(model)
+	for _, p := range r.GPUModelPolicies {
+		if strings.Contains(model, strings.ToUpper(p.Model)) {
+			return p
+		}
+	}
+	return GPUModelPolicy{}
+}
+
+// DeviceCost returns the cost of the devices of deviceType by the active GPU model policies, 1 if none is set.
+func (r *Reloadable) DeviceCost(deviceType string) float64 {
+	if cost := r.ModelPolicy(deviceType).Cost; cost > 0 {
+		return cost
+	}
+	return 1
+}
+
+func validateGPUModelPolicies(policies []GPUModelPolicy) error {
+	for _, p := range policies {
+		if strings.TrimSpace(p.Model) == "" {
+			return fmt.Errorf("empty model in GPU model policies")
+		}
+		if p.Cost < 0 {
+			return fmt.Errorf("cost %v of model %s is negative", p.Cost, p.Model)
+		}
+		switch util.SchedulerPolicyName(p.NodeSchedulerPolicy) {
+		case "", util.NodeSchedulerPolicyBinpack, util.NodeSchedulerPolicySpread:
+		default:
+			return fmt.Errorf("node scheduler policy %q of model %s is not one of %s and %s", p.NodeSchedulerPolicy, p.Model,
+				util.NodeSchedulerPolicyBinpack, util.NodeSchedulerPolicySpread)
+		}
+		// The topology-aware policy is only read from the flag by the devices.
+		switch util.SchedulerPolicyName(p.GPUSchedulerPolicy) {
+		case "", util.GPUSchedulerPolicyBinpack, util.GPUSchedulerPolicySpread, util.GPUSchedulerPolicyUtilization:
+		default:
+			return fmt.Errorf("GPU scheduler policy %q of model %s is not one of %s, %s and %s", p.GPUSchedulerPolicy, p.Model,
+				util.GPUSchedulerPolicyBinpack, util.GPUSchedulerPolicySpread, util.GPUSchedulerPolicyUtilization)
+		}
+	}
+	return nil
+}
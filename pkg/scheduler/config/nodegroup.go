@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+// AutoscalerResourceTagPrefix is the prefix of node group tags cluster autoscaler reads the
+// resources of template nodes from, when the node group has no node to copy them from.
+const AutoscalerResourceTagPrefix = "k8s.io/cluster-autoscaler/node-template/resources/"
+
+// NodeGroupTemplates are the validated node group templates of the device config.
+var NodeGroupTemplates []NodeGroupTemplate
+
+// NodeGroupTemplate describes the devices of every node in a cluster autoscaler node group,
+// so the node group can be scaled up from zero for pods requesting device resources.
+type NodeGroupTemplate struct {
+	// Name is the name of the node group.
+	Name    string           `yaml:"name" json:"name"`
+	Devices []DeviceTemplate `yaml:"devices" json:"devices"`
+}
+
+// DeviceTemplate describes the devices of one type on a node.
+type DeviceTemplate struct {
+	// Vendor is the device vendor as in device config, e.g. NVIDIA.
+	Vendor string `yaml:"vendor" json:"vendor"`
+	// Type is the device type, e.g. NVIDIA-Tesla T4, only informational.
+	Type string `yaml:"type" json:"type"`
+	// Count is the number of devices on a node.
+	Count int32 `yaml:"count" json:"count"`
+	// Memory is the physical memory of each device in MB.
+	Memory int32 `yaml:"memory" json:"memory"`
+	// SplitCount is the number of tasks sharing a device, nvidia.deviceSplitCount for NVIDIA devices if not set.
+	SplitCount int32 `yaml:"splitCount" json:"splitCount"`
+}
+
+// Capacity returns the device resources every node of the group provides, as registered by device plugins.
+func (t NodeGroupTemplate) Capacity() corev1.ResourceList {
+	res := corev1.ResourceList{}
+	add := func(name string, value int64) {
+		if name == "" {
+			return
+		}
+		q := res[corev1.ResourceName(name)]
+		q.Add(*resource.NewQuantity(value, resource.DecimalSI))
+		res[corev1.ResourceName(name)] = q
+	}
+	for _, d := range t.Devices {
+		dev, ok := device.GetDevices()[d.Vendor]
+		if !ok {
+			continue
+		}
+		names := dev.GetResourceNames()
+		splitCount := max(d.SplitCount, 1)
+		add(names.ResourceCountName, int64(d.Count)*int64(splitCount))
+		add(names.ResourceMemoryName, int64(d.Count)*int64(d.Memory))
+		add(names.ResourceCoreName, int64(d.Count)*100)
+	}
+	return res
+}
+
+// AutoscalerTags returns the node group tags telling cluster autoscaler the device resources of the group.
+func (t NodeGroupTemplate) AutoscalerTags() map[string]string {
+	tags := make(map[string]string)
+	for name, q := range t.Capacity() {
+		tags[AutoscalerResourceTagPrefix+string(name)] = q.String()
+	}
+	return tags
+}
+
+// resolveNodeGroupTemplates validates templates against initialized devices and fills
+// in the defaults of NVIDIA devices from the nvidia config.
+func resolveNodeGroupTemplates(templates []NodeGroupTemplate, nvidiaConfig nvidia.NvidiaConfig) ([]NodeGroupTemplate, error) {
+	resolved := make([]NodeGroupTemplate, 0, len(templates))
+	names := make(map[string]bool, len(templates))
+	for i, t := range templates {
+		if t.Name == "" {
+			return nil, fmt.Errorf("nodeGroupTemplates[%d]: name is empty", i)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("nodeGroupTemplates[%d]: duplicate node group %s", i, t.Name)
+		}
+		names[t.Name] = true
+		if len(t.Devices) == 0 {
+			return nil, fmt.Errorf("node group %s: no devices", t.Name)
+		}
+		devices := make([]DeviceTemplate, 0, len(t.Devices))
+		for j, d := range t.Devices {
+			dev, ok := device.GetDevices()[d.Vendor]
+			if !ok {
+				return nil, fmt.Errorf("node group %s: devices[%d]: unknown vendor %q", t.Name, j, d.Vendor)
+			}
+			if d.Count <= 0 {
+				return nil, fmt.Errorf("node group %s: devices[%d]: count must be positive", t.Name, j)
+			}
+			if d.SplitCount < 0 {
+				return nil, fmt.Errorf("node group %s: devices[%d]: splitCount can't be negative", t.Name, j)
+			}
+			if d.Memory < 0 || d.Memory == 0 && dev.GetResourceNames().ResourceMemoryName != "" {
+				return nil, fmt.Errorf("node group %s: devices[%d]: memory must be positive", t.Name, j)
+			}
+			if d.Vendor == nvidia.NvidiaGPUDevice {
+				if d.SplitCount == 0 && nvidiaConfig.DeviceSplitCount != nil {
+					d.SplitCount = int32(*nvidiaConfig.DeviceSplitCount)
+				}
+				if nvidiaConfig.DeviceMemoryScaling != nil {
+					d.Memory = int32(float64(d.Memory) * *nvidiaConfig.DeviceMemoryScaling)
+				}
+			}
+			devices = append(devices, d)
+		}
+		t.Devices = devices
+		resolved = append(resolved, t)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+const nodeGroupConfig = `
+nvidia:
+  resourceCountName: "nvidia.com/gpu"
+  resourceMemoryName: "nvidia.com/gpumem"
+  resourceMemoryPercentageName: "nvidia.com/gpumem-percentage"
+  resourceCoreName: "nvidia.com/gpucores"
+  defaultGPUNum: 1
+  deviceSplitCount: 10
+  deviceMemoryScaling: 1
+amd:
+  resourceCountName: "amd.com/gpu"
+nodeGroupTemplates:
+  - name: gpu-t4
+    devices:
+      - vendor: NVIDIA
+        type: NVIDIA-Tesla T4
+        count: 4
+        memory: 15360
+  - name: gpu-mixed
+    devices:
+      - vendor: NVIDIA
+        type: NVIDIA-A100
+        count: 2
+        memory: 40960
+        splitCount: 4
+      - vendor: AMDGPU
+        count: 1
+`
+
+func loadNodeGroupConfig(t *testing.T, data string) (*Config, error) {
+	t.Helper()
+	var c Config
+	assert.NilError(t, yaml.Unmarshal([]byte(data), &c))
+	return &c, InitDevicesWithConfig(&c)
+}
+
+// buildTemplateNode builds a node from the node group tags the way cluster autoscaler
+// builds template nodes for node groups without any node.
+func buildTemplateNode(t *testing.T, nodeGroup string, tags map[string]string) *corev1.Node {
+	t.Helper()
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("template-node-for-%s-1234", nodeGroup)},
+		Status: corev1.NodeStatus{Capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("8"),
+			corev1.ResourceMemory: resource.MustParse("32Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}},
+	}
+	for k, v := range tags {
+		name, ok := strings.CutPrefix(k, AutoscalerResourceTagPrefix)
+		if !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(v)
+		assert.NilError(t, err)
+		node.Status.Capacity[corev1.ResourceName(name)] = q
+	}
+	node.Status.Allocatable = node.Status.Capacity.DeepCopy()
+	return node
+}
+
+// fitsResources is the resource fit check of cluster autoscaler simulation, requests of
+// extended resources default to their limits.
+func fitsResources(pod *corev1.Pod, node *corev1.Node) bool {
+	requests := corev1.ResourceList{}
+	for _, ctr := range pod.Spec.Containers {
+		ctrRequests := ctr.Resources.Limits.DeepCopy()
+		for name, q := range ctr.Resources.Requests {
+			ctrRequests[name] = q
+		}
+		for name, q := range ctrRequests {
+			sum := requests[name]
+			sum.Add(q)
+			requests[name] = sum
+		}
+	}
+	for name, q := range requests {
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok || allocatable.Cmp(q) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_NodeGroupTemplates(t *testing.T) {
+	_, err := loadNodeGroupConfig(t, nodeGroupConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, len(NodeGroupTemplates), 2)
+
+	assert.DeepEqual(t, NodeGroupTemplates[0].AutoscalerTags(), map[string]string{
+		AutoscalerResourceTagPrefix + "nvidia.com/gpu":      "40",
+		AutoscalerResourceTagPrefix + "nvidia.com/gpumem":   "61440",
+		AutoscalerResourceTagPrefix + "nvidia.com/gpucores": "400",
+	})
+	assert.DeepEqual(t, NodeGroupTemplates[1].AutoscalerTags(), map[string]string{
+		AutoscalerResourceTagPrefix + "nvidia.com/gpu":      "8",
+		AutoscalerResourceTagPrefix + "nvidia.com/gpumem":   "81920",
+		AutoscalerResourceTagPrefix + "nvidia.com/gpucores": "200",
+		AutoscalerResourceTagPrefix + "amd.com/gpu":         "1",
+	})
+}
+
+func Test_NodeGroupTemplates_AutoscalerSimulation(t *testing.T) {
+	_, err := loadNodeGroupConfig(t, nodeGroupConfig)
+	assert.NilError(t, err)
+	node := buildTemplateNode(t, "gpu-t4", NodeGroupTemplates[0].AutoscalerTags())
+
+	newPod := func(limits corev1.ResourceList) *corev1.Pod {
+		pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "cuda",
+			Resources: corev1.ResourceRequirements{Limits: limits},
+		}}}}
+		// The pending pod cluster autoscaler sees is already mutated by the webhook.
+		for _, dev := range device.GetDevices() {
+			_, err := dev.MutateAdmission(&pod.Spec.Containers[0], pod, nil)
+			assert.NilError(t, err)
+		}
+		return pod
+	}
+
+	tests := []struct {
+		name   string
+		limits corev1.ResourceList
+		want   bool
+	}{
+		{
+			name:   "gpu memory slice",
+			limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1"), "nvidia.com/gpumem": resource.MustParse("3000")},
+			want:   true,
+		},
+		{
+			name:   "whole gpus with defaulted cores",
+			limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")},
+			want:   true,
+		},
+		{
+			name:   "more memory than the node group has",
+			limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1"), "nvidia.com/gpumem": resource.MustParse("70000")},
+			want:   false,
+		},
+		{
+			name:   "device type the node group does not have",
+			limits: corev1.ResourceList{"amd.com/gpu": resource.MustParse("1")},
+			want:   false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, fitsResources(newPod(test.limits), node), test.want)
+		})
+	}
+}
+
+func Test_NodeGroupTemplates_Invalid(t *testing.T) {
+	prefix := strings.Split(nodeGroupConfig, "nodeGroupTemplates:")[0]
+	tests := []struct {
+		name      string
+		templates string
+		wantErr   string
+	}{
+		{
+			name: "empty name",
+			templates: `
+  - devices:
+      - {vendor: NVIDIA, count: 1, memory: 1000}`,
+			wantErr: "name is empty",
+		},
+		{
+			name: "duplicate name",
+			templates: `
+  - name: a
+    devices:
+      - {vendor: NVIDIA, count: 1, memory: 1000}
+  - name: a
+    devices:
+      - {vendor: NVIDIA, count: 1, memory: 1000}`,
+			wantErr: "duplicate node group a",
+		},
+		{
+			name: "no devices",
+			templates: `
+  - name: a`,
+			wantErr: "no devices",
+		},
+		{
+			name: "unknown vendor",
+			templates: `
+  - name: a
+    devices:
+      - {vendor: nvidia, count: 1, memory: 1000}`,
+			wantErr: "unknown vendor",
+		},
+		{
+			name: "zero count",
+			templates: `
+  - name: a
+    devices:
+      - {vendor: NVIDIA, memory: 1000}`,
+			wantErr: "count must be positive",
+		},
+		{
+			name: "missing memory",
+			templates: `
+  - name: a
+    devices:
+      - {vendor: NVIDIA, count: 1}`,
+			wantErr: "memory must be positive",
+		},
+		{
+			name: "negative split count",
+			templates: `
+  - name: a
+    devices:
+      - {vendor: NVIDIA, count: 1, memory: 1000, splitCount: -1}`,
+			wantErr: "splitCount can't be negative",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadNodeGroupConfig(t, prefix+"nodeGroupTemplates:"+test.templates)
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
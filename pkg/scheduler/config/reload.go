@@ -0,0 +1,164 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// ConfigReload makes the scheduler reload the reloadable configuration when the device config file changes
+// or on requests to the reload endpoint.
+var ConfigReload bool
+
+// SchedulerConfig is the scheduler section of the device config file, overriding the flags of the same name.
+// Unlike the device sections, it's applied again on reload.
+type SchedulerConfig struct {
+	SchedulerName                  *string `yaml:"schedulerName"`
+	ForceOverwriteDefaultScheduler *bool   `yaml:"forceOverwriteDefaultScheduler"`
+	NodeSchedulerPolicy            *string `yaml:"nodeSchedulerPolicy"`
+}
+
+// Reloadable is the configuration the webhook and the scheduler read per request, swapped as a whole on reload.
+// It must not be modified once returned by Current.
+type Reloadable struct {
+	SchedulerName                  string
+	ForceOverwriteDefaultScheduler bool
+	NodeSchedulerPolicy            string
+	// ImageAllowlist are the images permitted to use devices, every image is permitted if empty.
+	ImageAllowlist []string
+	// GPUModelPolicies are the scheduling policies by GPU model, see ModelPolicy.
+	GPUModelPolicies []GPUModelPolicy
+	// NamespaceBudgets cap the devices allocated to namespaces, see NamespaceBudget.
+	NamespaceBudgets []NamespaceBudget
+}
+
+var active atomic.Pointer[Reloadable]
+
+// Current returns the active reloadable configuration, the one of the flags and variables until the device
+// config file is loaded.
+func Current() *Reloadable {
+	if r := active.Load(); r != nil {
+		return r
+	}
+	return &Reloadable{
+		SchedulerName:                  SchedulerName,
+		ForceOverwriteDefaultScheduler: ForceOverwriteDefaultScheduler,
+		NodeSchedulerPolicy:            NodeSchedulerPolicy,
+		ImageAllowlist:                 ImageAllowlist,
+		GPUModelPolicies:               GPUModelPolicies,
+		NamespaceBudgets:               NamespaceBudgets,
+	}
+}
+
+// newReloadable returns the reloadable configuration of config, the flags are used for the settings it omits.
+func newReloadable(config *Config) (*Reloadable, error) {
+	r := &Reloadable{
+		SchedulerName:                  SchedulerName,
+		ForceOverwriteDefaultScheduler: ForceOverwriteDefaultScheduler,
+		NodeSchedulerPolicy:            NodeSchedulerPolicy,
+		ImageAllowlist:                 config.ImageAllowlist,
+		GPUModelPolicies:               config.GPUModelPolicies,
+		NamespaceBudgets:               config.NamespaceBudgets,
+	}
+	if config.Scheduler.SchedulerName != nil {
+		r.SchedulerName = *config.Scheduler.SchedulerName
+	}
+	if config.Scheduler.ForceOverwriteDefaultScheduler != nil {
+		r.ForceOverwriteDefaultScheduler = *config.Scheduler.ForceOverwriteDefaultScheduler
+	}
+	if config.Scheduler.NodeSchedulerPolicy != nil {
+		r.NodeSchedulerPolicy = *config.Scheduler.NodeSchedulerPolicy
+	}
+	switch util.SchedulerPolicyName(r.NodeSchedulerPolicy) {
+	case util.NodeSchedulerPolicyBinpack, util.NodeSchedulerPolicySpread:
+	default:
+		return nil, fmt.Errorf("node scheduler policy %q is not one of %s and %s", r.NodeSchedulerPolicy,
+			util.NodeSchedulerPolicyBinpack, util.NodeSchedulerPolicySpread)
+	}
+	if err := validateImageAllowlist(r.ImageAllowlist); err != nil {
+		return nil, fmt.Errorf("invalid image allowlist: %v", err)
+	}
+	if err := validateGPUModelPolicies(r.GPUModelPolicies); err != nil {
+		return nil, err
+	}
+	if err := validateNamespaceBudgets(r.NamespaceBudgets); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the device config file again and activates its reloadable configuration. An invalid file is
+// rejected and the active configuration is kept. The device sections are only read at startup.
+func Reload() error {
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load device config file %s: %v", configFile, err)
+	}
+	r, err := newReloadable(config)
+	if err != nil {
+		return err
+	}
+	active.Store(r)
+	klog.InfoS("Reloaded scheduler configuration", "schedulerName", r.SchedulerName,
+		"forceOverwriteDefaultScheduler", r.ForceOverwriteDefaultScheduler, "nodeSchedulerPolicy", r.NodeSchedulerPolicy,
+		"imageAllowlist", r.ImageAllowlist, "gpuModelPolicies", r.GPUModelPolicies,
+		"namespaceBudgets", r.NamespaceBudgets)
+	return nil
+}
+
+// WatchConfigFile reloads the configuration whenever the device config file changes, until ctx is done.
+// The directory of the file is watched, since ConfigMap volumes are updated by swapping a symlink.
+func WatchConfigFile(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+		return err
+	}
+	klog.InfoS("Watching device config file for reloads", "file", configFile)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+			if err := Reload(); err != nil {
+				klog.ErrorS(err, "Rejected configuration reload", "event", event)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			klog.ErrorS(err, "Device config file watch error")
+		}
+	}
+}
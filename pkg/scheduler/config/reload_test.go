@@ -0,0 +1,161 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+const reloadTestDevices = `
+nvidia:
+  resourceCountName: nvidia.com/gpu
+`
+
+func writeReloadTestConfig(t *testing.T, path, scheduler string) {
+	t.Helper()
+	assert.NilError(t, os.WriteFile(path, []byte(reloadTestDevices+scheduler), 0o600))
+}
+
+func setupReloadTest(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "device-config.yaml")
+	file, name, policy, force := configFile, SchedulerName, NodeSchedulerPolicy, ForceOverwriteDefaultScheduler
+	t.Cleanup(func() {
+		configFile, SchedulerName, NodeSchedulerPolicy, ForceOverwriteDefaultScheduler = file, name, policy, force
+		active.Store(nil)
+	})
+	configFile = path
+	SchedulerName = "hami-scheduler"
+	NodeSchedulerPolicy = "binpack"
+	ForceOverwriteDefaultScheduler = true
+	active.Store(nil)
+	return path
+}
+
+func TestReload(t *testing.T) {
+	path := setupReloadTest(t)
+
+	// The flags are active until the file is loaded, and used for the settings it omits.
+	assert.DeepEqual(t, Current(), &Reloadable{SchedulerName: "hami-scheduler", ForceOverwriteDefaultScheduler: true, NodeSchedulerPolicy: "binpack"})
+	writeReloadTestConfig(t, path, `
+imageAllowlist:
+  - registry.example.com/ml/
+scheduler:
+  nodeSchedulerPolicy: spread
+  forceOverwriteDefaultScheduler: false
+`)
+	assert.NilError(t, Reload())
+	assert.DeepEqual(t, Current(), &Reloadable{
+		SchedulerName:                  "hami-scheduler",
+		ForceOverwriteDefaultScheduler: false,
+		NodeSchedulerPolicy:            "spread",
+		ImageAllowlist:                 []string{"registry.example.com/ml/"},
+	})
+	assert.Assert(t, ImageAllowed("registry.example.com/ml/pytorch:2.4"))
+	assert.Assert(t, !ImageAllowed("docker.io/library/pytorch:2.4"))
+
+	// Invalid reloads are rejected, the active configuration is kept.
+	active := Current()
+	for _, invalid := range []string{
+		"scheduler:\n  nodeSchedulerPolicy: random\n",
+		"imageAllowlist:\n  - repo@md5:abc\n",
+		"gpuModelPolicies:\n  - model: T4\n    gpuSchedulerPolicy: topology-aware\n",
+		"gpuModelPolicies:\n  - nodeSchedulerPolicy: spread\n",
+		"namespaceBudgets:\n  - namespace: team-a\n    coresPercent: 150\n",
+		"namespaceBudgets:\n  - namespace: team-a\n  - namespace: team-a\n",
+		"namespaceBudgets:\n  - namespace: team-a\n    maxMemoryPerAllocation: -1\n",
+		"scheduler: [",
+	} {
+		writeReloadTestConfig(t, path, invalid)
+		assert.Assert(t, Reload() != nil, "reload of %q", invalid)
+		assert.Equal(t, Current(), active)
+	}
+	assert.NilError(t, os.Remove(path))
+	assert.Assert(t, Reload() != nil)
+	assert.Equal(t, Current(), active)
+}
+
+func TestReloadConcurrentReads(t *testing.T) {
+	path := setupReloadTest(t)
+	configs := map[string]string{
+		"binpack": "scheduler:\n  schedulerName: binpack-scheduler\n  nodeSchedulerPolicy: binpack\nimageAllowlist: [binpack/]\n",
+		"spread":  "scheduler:\n  schedulerName: spread-scheduler\n  nodeSchedulerPolicy: spread\nimageAllowlist: [spread/]\n",
+	}
+	writeReloadTestConfig(t, path, configs["binpack"])
+	assert.NilError(t, Reload())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				// Every read sees one of the configurations as a whole.
+				r := Current()
+				if r.SchedulerName != r.NodeSchedulerPolicy+"-scheduler" || !r.ImageAllowed(r.NodeSchedulerPolicy+"/image") {
+					errs <- r.SchedulerName + " " + r.NodeSchedulerPolicy
+					return
+				}
+			}
+		}()
+	}
+	for i := range 100 {
+		policy := []string{"spread", "binpack"}[i%2]
+		// The file is replaced like the kubelet updates ConfigMap volumes, so reads of the file aren't torn.
+		tmp := path + ".tmp"
+		writeReloadTestConfig(t, tmp, configs[policy])
+		assert.NilError(t, os.Rename(tmp, path))
+		assert.NilError(t, Reload())
+		assert.Equal(t, Current().NodeSchedulerPolicy, policy)
+	}
+	cancel()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("read a mix of configurations: %s", err)
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	path := setupReloadTest(t)
+	writeReloadTestConfig(t, path, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- WatchConfigFile(ctx) }()
+	defer func() {
+		cancel()
+		assert.NilError(t, <-done)
+	}()
+
+	// Rewritten until the watch is set up.
+	deadline := time.Now().Add(5 * time.Second)
+	for Current().NodeSchedulerPolicy != "spread" {
+		assert.Assert(t, time.Now().Before(deadline), "configuration not reloaded")
+		writeReloadTestConfig(t, path, "scheduler:\n  nodeSchedulerPolicy: spread\n")
+		time.Sleep(50 * time.Millisecond)
+	}
+}
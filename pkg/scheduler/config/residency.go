@@ -0,0 +1,40 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+// MaxDeviceResidency is the longest a pod may use the devices of a type, e.g. NVIDIA, from its bind, so workloads
+// are rescheduled to other devices regularly, e.g. after driver updates. Device types without one are unlimited.
+var MaxDeviceResidency map[string]time.Duration
+
+func validateMaxDeviceResidency(residency map[string]time.Duration) error {
+	for deviceType, d := range residency {
+		if _, ok := device.DevicesMap[deviceType]; !ok {
+			return fmt.Errorf("unknown device type %q", deviceType)
+		}
+		if d <= 0 {
+			return fmt.Errorf("max residency of device type %s must be positive", deviceType)
+		}
+	}
+	return nil
+}
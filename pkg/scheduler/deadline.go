@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// nearDeadlineRatio is the share of the extender deadline past which a response is counted as near timeout.
+const nearDeadlineRatio = 0.8
+
+// errExtenderDeadlineExceeded is the cause of the cancellation of filter and bind requests past the extender deadline.
+var errExtenderDeadlineExceeded = errors.New("extender deadline exceeded")
+
+// NearTimeoutKey are the labels of the responses to kube-scheduler near the extender deadline.
+type NearTimeoutKey struct {
+	// Verb is the extender verb, filter or bind.
+	Verb string
+	// Exceeded is set for the responses past the deadline, which are partial or failed.
+	Exceeded bool
+}
+
+type nearTimeouts struct {
+	mutex  sync.Mutex
+	counts map[NearTimeoutKey]int64
+}
+
+// withExtenderDeadline returns ctx cancelled once config.ExtenderDeadline elapsed, ctx cancelled on its cancel only
+// if it's 0.
+func withExtenderDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if config.ExtenderDeadline <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, config.ExtenderDeadline, errExtenderDeadlineExceeded)
+}
+
+// observeExtenderResponse counts the response to verb if it took longer than nearDeadlineRatio of the extender
+// deadline since start.
+func (s *Scheduler) observeExtenderResponse(verb string, start time.Time) {
+	if config.ExtenderDeadline <= 0 {
+		return
+	}
+	elapsed := s.clock.Since(start)
+	if elapsed < time.Duration(float64(config.ExtenderDeadline)*nearDeadlineRatio) {
+		return
+	}
+	key := NearTimeoutKey{Verb: verb, Exceeded: elapsed >= config.ExtenderDeadline}
+	s.nearTimeouts.mutex.Lock()
+	defer s.nearTimeouts.mutex.Unlock()
+	if s.nearTimeouts.counts == nil {
+		s.nearTimeouts.counts = make(map[NearTimeoutKey]int64)
+	}
+	s.nearTimeouts.counts[key]++
+}
+
+// NearTimeoutResponses returns the number of responses to kube-scheduler near or past the extender deadline by labels.
+func (s *Scheduler) NearTimeoutResponses() map[NearTimeoutKey]int64 {
+	s.nearTimeouts.mutex.Lock()
+	defer s.nearTimeouts.mutex.Unlock()
+	return maps.Clone(s.nearTimeouts.counts)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/faultinject"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+)
+
+func Test_Bind_ExtenderDeadline(t *testing.T) {
+	initTestDevices(t)
+	defer faultinject.ResetForTest()
+	config.ExtenderDeadline = 50 * time.Millisecond
+	nodelock.ResetNodeLocksForTest()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "train"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "train",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.DecimalSI)}},
+		}}},
+	}
+	client.KubeClient = fake.NewSimpleClientset(pod, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+
+	// Patching the pod is slower than the deadline, the pod isn't bound and the bind is rolled back.
+	faultinject.Enable()
+	assert.NilError(t, faultinject.Set(faultinject.PointBindPatch, faultinject.Fault{Delay: metav1.Duration{Duration: 100 * time.Millisecond}, Count: 1}))
+	res, err := s.Bind(extenderv1.ExtenderBindingArgs{PodName: pod.Name, PodNamespace: pod.Namespace, PodUID: pod.UID, Node: "node1"})
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(res.Error, errExtenderDeadlineExceeded.Error()), res.Error)
+
+	node, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, locked := node.Annotations[nodelock.NodeLockKey]
+	assert.Assert(t, !locked, "node lock kept: %v", node.Annotations)
+	current, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, current.Spec.NodeName, "")
+	_, phase := current.Annotations[util.DeviceBindPhase]
+	assert.Assert(t, !phase, "bind phase kept: %v", current.Annotations)
+	assert.DeepEqual(t, s.NearTimeoutResponses(), map[NearTimeoutKey]int64{{Verb: "bind", Exceeded: true}: 1})
+}
+
+func Test_scoreNodes_ExtenderDeadline(t *testing.T) {
+	s := NewScheduler()
+	defer s.Stop()
+	nodes := make(map[string]*NodeUsage)
+	for _, name := range []string{"node-1", "node-2"} {
+		devices := make([]device.DeviceInfo, 2)
+		for i := range devices {
+			devices[i] = device.DeviceInfo{
+				ID: fmt.Sprintf("%s-GPU-%d", name, i), Count: 10, Devmem: 81920, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+			}
+		}
+		s.addNode(name, &device.NodeInfo{
+			ID:      name,
+			Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices},
+		})
+		nodes[name] = newCapacityTestNode(2)
+	}
+	requests := device.PodDeviceRequests{{nvidia.NvidiaGPUDevice: {Nums: 1, Type: nvidia.NvidiaGPUDevice, Memreq: 1000, Coresreq: 10}}}
+	// The node already found unfit keeps its reason.
+	failedNodes := map[string]string{"node-3": "NodeUnfitPod"}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errExtenderDeadlineExceeded)
+	res, failureReason, err := s.scoreNodes(ctx, &nodes, requests, &corev1.Pod{}, failedNodes)
+	assert.NilError(t, err)
+	assert.Equal(t, len(res.NodeList), 0)
+	pending := failureReason[errExtenderDeadlineExceeded.Error()]
+	sort.Strings(pending)
+	assert.DeepEqual(t, pending, []string{"node-1", "node-2"})
+	assert.DeepEqual(t, failedNodes, map[string]string{
+		"node-1": errExtenderDeadlineExceeded.Error(),
+		"node-2": errExtenderDeadlineExceeded.Error(),
+		"node-3": "NodeUnfitPod",
+	})
+}
+
+func Test_observeExtenderResponse(t *testing.T) {
+	restoreConfig(t)
+	now := time.Now()
+	clock := testingclock.NewFakeClock(now)
+	s := &Scheduler{clock: clock}
+
+	config.ExtenderDeadline = 0
+	clock.SetTime(now.Add(time.Minute))
+	s.observeExtenderResponse("filter", now)
+	assert.Equal(t, len(s.NearTimeoutResponses()), 0)
+
+	config.ExtenderDeadline = 10 * time.Second
+	for _, elapsed := range []time.Duration{time.Second, 7 * time.Second, 8 * time.Second, 9 * time.Second, 10 * time.Second} {
+		clock.SetTime(now.Add(elapsed))
+		s.observeExtenderResponse("filter", now)
+	}
+	clock.SetTime(now.Add(12 * time.Second))
+	s.observeExtenderResponse("bind", now)
+	assert.DeepEqual(t, s.NearTimeoutResponses(), map[NearTimeoutKey]int64{
+		{Verb: "filter", Exceeded: false}: 2,
+		{Verb: "filter", Exceeded: true}:  1,
+		{Verb: "bind", Exceeded: true}:    1,
+	})
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+// maxDecisions bounds the scheduling decisions kept in memory.
+const maxDecisions = 1000
+
+const (
+	DecisionStageFilter = "Filter"
+	DecisionStageBind   = "Bind"
+)
+
+// Decision is the outcome of filtering or binding a pod.
+type Decision struct {
+	Time      time.Time
+	Pod       types.NamespacedName
+	UID       types.UID
+	Stage     string
+	Succeeded bool
+	Node      string
+	Devices   device.PodDevices
+	Message   string
+}
+
+// decisionLog keeps the latest maxDecisions decisions.
+type decisionLog struct {
+	mutex sync.Mutex
+	items []Decision
+	next  int
+}
+
+func (l *decisionLog) add(d Decision) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if len(l.items) < maxDecisions {
+		l.items = append(l.items, d)
+		return
+	}
+	l.items[l.next] = d
+	l.next = (l.next + 1) % maxDecisions
+}
+
+// list returns the decisions, oldest first.
+func (l *decisionLog) list() []Decision {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	res := make([]Decision, 0, len(l.items))
+	res = append(res, l.items[l.next:]...)
+	return append(res, l.items[:l.next]...)
+}
+
+// ListDecisions returns the latest scheduling decisions, oldest first.
+func (s *Scheduler) ListDecisions() []Decision {
+	return s.decisions.list()
+}
+
+func (s *Scheduler) recordFilterDecision(pod *corev1.Pod, result *extenderv1.ExtenderFilterResult, err error) {
+	d := Decision{
+		Time:  time.Now(),
+		Pod:   types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name},
+		UID:   pod.UID,
+		Stage: DecisionStageFilter,
+	}
+	switch {
+	case err != nil:
+		d.Message = err.Error()
+	case result == nil:
+		return
+	case result.Error != "":
+		d.Message = result.Error
+	case result.NodeNames != nil && len(*result.NodeNames) > 0:
+		d.Succeeded = true
+		d.Node = (*result.NodeNames)[0]
+		if pi, ok := s.podManager.GetPod(pod); ok {
+			d.Devices = pi.Devices
+		}
+	default:
+		d.Message = fmt.Sprintf("no available node, %d nodes do not meet", len(result.FailedNodes))
+	}
+	s.decisions.add(d)
+}
+
+func (s *Scheduler) recordBindDecision(args extenderv1.ExtenderBindingArgs, result *extenderv1.ExtenderBindingResult, err error) {
+	d := Decision{
+		Time:  time.Now(),
+		Pod:   types.NamespacedName{Namespace: args.PodNamespace, Name: args.PodName},
+		UID:   args.PodUID,
+		Stage: DecisionStageBind,
+		Node:  args.Node,
+	}
+	switch {
+	case err != nil:
+		d.Message = err.Error()
+	case result != nil && result.Error != "":
+		d.Message = result.Error
+	default:
+		d.Succeeded = true
+	}
+	s.decisions.add(d)
+}
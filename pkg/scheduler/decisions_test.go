@@ -0,0 +1,78 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+func Test_decisionLog(t *testing.T) {
+	var l decisionLog
+	assert.Equal(t, len(l.list()), 0)
+	for i := range maxDecisions + 10 {
+		l.add(Decision{Node: fmt.Sprintf("node%d", i)})
+	}
+	decisions := l.list()
+	assert.Equal(t, len(decisions), maxDecisions)
+	assert.Equal(t, decisions[0].Node, "node10")
+	assert.Equal(t, decisions[maxDecisions-1].Node, fmt.Sprintf("node%d", maxDecisions+9))
+}
+
+func Test_recordDecisions(t *testing.T) {
+	s := NewScheduler()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default", UID: "uid1"}}
+	podDevices := device.PodDevices{"NVIDIA": device.PodSingleDevice{{{UUID: "GPU-0", Type: "NVIDIA", Usedmem: 1000}}}}
+	s.podManager.AddPod(pod, "node1", podDevices)
+
+	s.recordFilterDecision(pod, &extenderv1.ExtenderFilterResult{NodeNames: &[]string{"node1"}}, nil)
+	s.recordFilterDecision(pod, &extenderv1.ExtenderFilterResult{FailedNodes: map[string]string{"node1": "full", "node2": "full"}}, nil)
+	s.recordFilterDecision(pod, nil, errors.New("calcScore failed"))
+	s.recordBindDecision(extenderv1.ExtenderBindingArgs{PodName: "pod1", PodNamespace: "default", PodUID: "uid1", Node: "node1"}, &extenderv1.ExtenderBindingResult{Error: "node locked"}, nil)
+	s.recordBindDecision(extenderv1.ExtenderBindingArgs{PodName: "pod1", PodNamespace: "default", PodUID: "uid1", Node: "node1"}, &extenderv1.ExtenderBindingResult{}, nil)
+
+	decisions := s.ListDecisions()
+	assert.Equal(t, len(decisions), 5)
+	for _, d := range decisions {
+		assert.Equal(t, d.Pod.String(), "default/pod1")
+		assert.Equal(t, string(d.UID), "uid1")
+	}
+
+	assert.Equal(t, decisions[0].Stage, DecisionStageFilter)
+	assert.Assert(t, decisions[0].Succeeded)
+	assert.Equal(t, decisions[0].Node, "node1")
+	assert.DeepEqual(t, decisions[0].Devices, podDevices)
+
+	assert.Assert(t, !decisions[1].Succeeded)
+	assert.Equal(t, decisions[1].Message, "no available node, 2 nodes do not meet")
+	assert.Assert(t, !decisions[2].Succeeded)
+	assert.Equal(t, decisions[2].Message, "calcScore failed")
+
+	assert.Equal(t, decisions[3].Stage, DecisionStageBind)
+	assert.Assert(t, !decisions[3].Succeeded)
+	assert.Equal(t, decisions[3].Message, "node locked")
+	assert.Assert(t, decisions[4].Succeeded)
+	assert.Equal(t, decisions[4].Node, "node1")
+}
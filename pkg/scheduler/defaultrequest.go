@@ -0,0 +1,65 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// applyDefaultGPURequest requests a single NVIDIA GPU for the first container of the pod if it carries all the
+// config.DefaultGPURequestLabels and none of its containers requests devices, for legacy workloads which can't
+// set device resources. It reports whether the request was set, the pod then goes through the device flow.
+func applyDefaultGPURequest(pod *corev1.Pod) bool {
+	if len(config.DefaultGPURequestLabels) == 0 {
+		return false
+	}
+	for k, v := range config.DefaultGPURequestLabels {
+		if l, ok := pod.Labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	dev, ok := device.GetDevices()[nvidia.NvidiaGPUDevice]
+	if !ok {
+		return false
+	}
+	vendors := vendorResourceNames()
+	for idx := range pod.Spec.Containers {
+		for _, names := range vendors {
+			if requestsAnyResource(&pod.Spec.Containers[idx], names) {
+				return false
+			}
+		}
+	}
+	rn := dev.GetResourceNames()
+	ctr := &pod.Spec.Containers[0]
+	if ctr.Resources.Limits == nil {
+		ctr.Resources.Limits = make(corev1.ResourceList)
+	}
+	ctr.Resources.Limits[corev1.ResourceName(rn.ResourceCountName)] = *resource.NewQuantity(1, resource.BinarySI)
+	if config.DefaultGPURequestMemory > 0 && rn.ResourceMemoryName != "" {
+		ctr.Resources.Limits[corev1.ResourceName(rn.ResourceMemoryName)] = *resource.NewQuantity(int64(config.DefaultGPURequestMemory), resource.BinarySI)
+	}
+	if config.DefaultGPURequestCores > 0 && rn.ResourceCoreName != "" {
+		ctr.Resources.Limits[corev1.ResourceName(rn.ResourceCoreName)] = *resource.NewQuantity(int64(config.DefaultGPURequestCores), resource.BinarySI)
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func TestHandleDefaultGPURequest(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	config.DefaultGPURequestLabels = map[string]string{"needs-gpu": "true"}
+	config.DefaultGPURequestMemory = 4000
+	config.DefaultGPURequestCores = 0
+	h := &webhook{decoder: admission.NewDecoder(clientgoscheme.Scheme)}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		limits corev1.ResourceList
+		// Limits set on the first container, by name
+		want          map[string]string
+		wantScheduler bool
+	}{
+		{
+			name:          "labeled pod",
+			labels:        map[string]string{"needs-gpu": "true", "app": "ci"},
+			limits:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			want:          map[string]string{"hami.io~1gpu": "1", "hami.io~1gpumem": "4000"},
+			wantScheduler: true,
+		},
+		{
+			name:   "unlabeled pod",
+			labels: map[string]string{"app": "ci"},
+			limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			want:   map[string]string{},
+		},
+		{
+			name:   "label of another value",
+			labels: map[string]string{"needs-gpu": "false"},
+			limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			want:   map[string]string{},
+		},
+		{
+			name:          "labeled pod requesting devices kept",
+			labels:        map[string]string{"needs-gpu": "true"},
+			limits:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), "hami.io/gpu": resource.MustParse("2"), "hami.io/gpumem": resource.MustParse("8000")},
+			want:          map[string]string{},
+			wantScheduler: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default", Labels: test.labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "build", Image: "ci", Resources: corev1.ResourceRequirements{Limits: test.limits}},
+					{Name: "cache", Image: "cache"},
+				}},
+			}
+			raw, err := json.Marshal(pod)
+			assert.NilError(t, err)
+			resp := h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "req-uid",
+				Operation: admissionv1.Create,
+				Namespace: "default",
+				Name:      "job",
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+			limits := make(map[string]string)
+			scheduler := false
+			for _, patch := range resp.Patches {
+				if name, ok := strings.CutPrefix(patch.Path, "/spec/containers/0/resources/limits/"); ok {
+					limits[name] = patch.Value.(string)
+				}
+				assert.Assert(t, !strings.HasPrefix(patch.Path, "/spec/containers/1/resources"), "unexpected patch %v", patch)
+				scheduler = scheduler || patch.Path == "/spec/schedulerName"
+			}
+			assert.DeepEqual(t, limits, test.want)
+			assert.Equal(t, scheduler, test.wantScheduler)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	// EventReasonDefragmented indicates a pod was evicted to consolidate the partially used devices.
+	EventReasonDefragmented = "Defragmented"
+
+	// defragmentAvoidanceTTL is how long the replicas of a pod evicted by the defragmentation fit its devices
+	// last, long enough for its controller to recreate it.
+	defragmentAvoidanceTTL = 10 * time.Minute
+)
+
+// defragmentLoop defragments the devices every config.DefragmentInterval until the scheduler is stopped.
+func (s *Scheduler) defragmentLoop() {
+	ticker := time.NewTicker(config.DefragmentInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.defragment(context.Background())
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// defragmentable reports whether the defragmentation may evict the pod, only the running pods owned by a
+// controller recreating them, of a priority up to config.DefragmentMaxPriority.
+func defragmentable(pi *device.PodInfo) bool {
+	pod := pi.Pod
+	if pod == nil || util.IsPodInTerminatedState(pod) || pod.DeletionTimestamp != nil || metav1.GetControllerOf(pod) == nil {
+		return false
+	}
+	return podPriority(pod) <= config.DefragmentMaxPriority
+}
+
+// podPriority returns the priority of the pod, 0 if it has none.
+func podPriority(pod *corev1.Pod) int32 {
+	if pod.Spec.Priority != nil {
+		return *pod.Spec.Priority
+	}
+	return 0
+}
+
+// defragment evicts the defragmentable pods whose rescheduling reduces the number of partially used devices,
+// planned like RebalanceCandidates, at most config.DefragmentMaxEvictions, and returns the number evicted. The
+// evictions go through the eviction API, which denies those the disruption budgets of the pods no longer allow,
+// and the devices of the evicted pods are avoided by the pods of their controller for defragmentAvoidanceTTL so
+// they're rescheduled onto the devices consolidated.
+func (s *Scheduler) defragment(ctx context.Context) int {
+	report, err := s.planRebalance(ctx, defragmentable)
+	if err != nil {
+		klog.ErrorS(err, "Failed to plan the defragmentation of devices")
+		return 0
+	}
+	evicted := 0
+	for _, c := range report.Candidates {
+		if config.DefragmentMaxEvictions > 0 && evicted >= config.DefragmentMaxEvictions {
+			break
+		}
+		pi, ok := s.podManager.GetPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: c.UID}})
+		if !ok || !defragmentable(pi) {
+			continue
+		}
+		if s.evictForDefragment(ctx, pi, c) {
+			evicted++
+		}
+	}
+	klog.V(4).InfoS("Defragmented devices", "candidates", len(report.Candidates), "evicted", evicted,
+		"partialDevices", report.PartialDevices, "partialDevicesAfter", report.PartialDevicesAfter)
+	return evicted
+}
+
+func (s *Scheduler) evictForDefragment(ctx context.Context, pi *device.PodInfo, c RebalanceCandidate) bool {
+	err := s.kubeClient.CoreV1().Pods(pi.Namespace).EvictV1(ctx, &policyv1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Name: pi.Name, Namespace: pi.Namespace},
+		DeleteOptions: &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &pi.UID}},
+	})
+	if apierrors.IsTooManyRequests(err) {
+		klog.V(4).InfoS("Eviction of pod to defragment devices disallowed by its disruption budget", "pod", klog.KObj(pi.Pod))
+		return false
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to evict pod to defragment devices", "pod", klog.KObj(pi.Pod))
+		return false
+	}
+	s.avoidDevices(metav1.GetControllerOf(pi.Pod).UID, c.Node, c.Devices, defragmentAvoidanceTTL)
+	klog.InfoS("Evicted pod to defragment devices", "pod", klog.KObj(pi.Pod), "nodeID", c.Node, "devices", c.Devices,
+		"targetNode", c.TargetNode, "targetDevices", c.TargetDevices)
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(pi.Pod, corev1.EventTypeNormal, EventReasonDefragmented,
+			fmt.Sprintf("Evicted the pod from devices %v to consolidate them, it fits devices %v of node %s", c.Devices, c.TargetDevices, c.TargetNode))
+	}
+	return true
+}
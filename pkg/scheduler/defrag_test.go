@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_defragment(t *testing.T) {
+	initTestDevices(t)
+	config.DefragmentMaxPriority = 0
+	config.DefragmentMaxEvictions = 5
+
+	owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "batch", UID: "batch-rs", Controller: ptr.To(true)}
+	newPod := func(name string, priority int32, mem int64) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				UID:             k8stypes.UID(name),
+				Labels:          map[string]string{"app": "batch", util.RebalanceLabelKey: "true"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Spec: corev1.PodSpec{Priority: ptr.To(priority), Containers: []corev1.Container{{
+				Name: "gpu",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+					"hami.io/gpumem": *resource.NewQuantity(mem, resource.BinarySI),
+				}},
+			}}},
+		}
+	}
+	pdb := func(allowed int32) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "default"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "batch"}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+	tests := []struct {
+		name        string
+		allowed     int32
+		wantEvicted []string
+	}{
+		// small moves onto the GPU of large, consolidating 3 partially used GPUs into 2. critical is on the
+		// least used GPU but its priority is too high to be moved.
+		{name: "beneficial move", allowed: 1, wantEvicted: []string{"small"}},
+		{name: "move blocked by disruption budget", allowed: 0, wantEvicted: []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(pdb(test.allowed))
+			evicted := make([]string, 0)
+			client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				evicted = append(evicted, action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName())
+				return true, nil, nil
+			})
+			s := NewScheduler()
+			defer s.Stop()
+			s.kubeClient = client
+			var devices []device.DeviceInfo
+			for i, id := range []string{"gpu1", "gpu2", "gpu3"} {
+				devices = append(devices, device.DeviceInfo{ID: id, Index: uint(i), Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice})
+			}
+			s.addNode("node1", &device.NodeInfo{
+				ID:      "node1",
+				Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+				Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices},
+			})
+			for _, p := range []struct {
+				pod *corev1.Pod
+				gpu string
+				mem int32
+			}{
+				{pod: newPod("critical", 1000, 1000), gpu: "gpu1", mem: 1000},
+				{pod: newPod("small", 0, 2000), gpu: "gpu2", mem: 2000},
+				{pod: newPod("large", 0, 4000), gpu: "gpu3", mem: 4000},
+			} {
+				s.podManager.AddPod(p.pod, "node1", device.PodDevices{
+					nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: p.gpu, Type: nvidia.NvidiaGPUDevice, Usedmem: p.mem}}},
+				})
+			}
+
+			assert.Equal(t, s.defragment(context.Background()), len(test.wantEvicted))
+			assert.DeepEqual(t, evicted, test.wantEvicted)
+		})
+	}
+}
+
+func Test_defragmentable(t *testing.T) {
+	restoreConfig(t)
+	config.DefragmentMaxPriority = 100
+	owner := metav1.OwnerReference{APIVersion: "batch/v1", Kind: "Job", Name: "job", UID: "job", Controller: ptr.To(true)}
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want bool
+	}{
+		{name: "owned low priority", pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}}, Spec: corev1.PodSpec{Priority: ptr.To[int32](100)}}, want: true},
+		{name: "owned without priority", pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}}}, want: true},
+		{name: "owned high priority", pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}}, Spec: corev1.PodSpec{Priority: ptr.To[int32](101)}}},
+		{name: "bare pod", pod: &corev1.Pod{}},
+		{name: "succeeded", pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, defragmentable(&device.PodInfo{Pod: test.pod}), test.want)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DeviceAllocationKind is the kind of the cluster scoped objects mirroring the device allocation of each node,
+	// named after the node.
+	DeviceAllocationKind = "DeviceAllocation"
+	// deviceAllocationManagedBy is the managed-by label of the DeviceAllocation objects written by the scheduler.
+	deviceAllocationManagedBy = "hami-scheduler"
+)
+
+// DeviceAllocationResource is the resource of DeviceAllocation objects.
+var DeviceAllocationResource = schema.GroupVersionResource{Group: "hami.io", Version: "v1alpha1", Resource: "deviceallocations"}
+
+// DeviceAllocationStatus is the status of a DeviceAllocation, the devices of the node and the pods allocated them.
+type DeviceAllocationStatus struct {
+	Node    string            `json:"node"`
+	Devices []AllocatedDevice `json:"devices"`
+}
+
+// AllocatedDevice is a device of a node and its allocation, memory in MB and cores in percent.
+type AllocatedDevice struct {
+	ID         string                `json:"id"`
+	Type       string                `json:"type"`
+	Health     bool                  `json:"health"`
+	Shares     int32                 `json:"shares"`
+	UsedShares int32                 `json:"usedShares"`
+	Memory     int32                 `json:"memory"`
+	UsedMemory int32                 `json:"usedMemory"`
+	Cores      int32                 `json:"cores"`
+	UsedCores  int32                 `json:"usedCores"`
+	Pods       []DevicePodAllocation `json:"pods,omitempty"`
+}
+
+// DevicePodAllocation is the memory and cores of a device allocated to a pod, summed over its containers.
+type DevicePodAllocation struct {
+	Namespace string       `json:"namespace"`
+	Name      string       `json:"name"`
+	UID       k8stypes.UID `json:"uid"`
+	Memory    int32        `json:"memory"`
+	Cores     int32        `json:"cores"`
+}
+
+// allocationExport is the state of the DeviceAllocation objects, only used by the summary loop.
+type allocationExport struct {
+	client dynamic.Interface
+	// Status last written by node
+	published map[string]string
+	listed    bool
+}
+
+// SetDeviceAllocationClient enables mirroring the device allocation of each node into a DeviceAllocation object
+// with client, it must be called before the scheduler starts.
+func (s *Scheduler) SetDeviceAllocationClient(client dynamic.Interface) {
+	s.allocationExport.client = client
+	s.allocationExport.published = make(map[string]string)
+}
+
+// deviceAllocationStatus returns the status of the DeviceAllocation of the node.
+func deviceAllocationStatus(nodeID string, node *NodeUsage) DeviceAllocationStatus {
+	res := DeviceAllocationStatus{Node: nodeID, Devices: make([]AllocatedDevice, 0, len(node.Devices.DeviceLists))}
+	for _, ds := range node.Devices.DeviceLists {
+		d := ds.Device
+		allocated := AllocatedDevice{
+			ID:         d.ID,
+			Type:       d.Type,
+			Health:     d.Health,
+			Shares:     d.Count,
+			UsedShares: d.Used,
+			Memory:     d.Totalmem,
+			UsedMemory: d.Usedmem,
+			Cores:      d.Totalcore,
+			UsedCores:  d.Usedcores,
+		}
+		pods := make(map[k8stypes.UID]*DevicePodAllocation)
+		for _, pi := range d.PodInfos {
+			// Pods are listed once for each of their containers on the device.
+			if _, ok := pods[pi.UID]; ok {
+				continue
+			}
+			pod := &DevicePodAllocation{Namespace: pi.Namespace, Name: pi.Name, UID: pi.UID}
+			for _, podSingle := range pi.Devices {
+				for _, ctrdevs := range podSingle {
+					for _, cd := range ctrdevs {
+						if strings.Split(cd.UUID, "[")[0] == d.ID {
+							pod.Memory += cd.Usedmem
+							pod.Cores += cd.Usedcores
+						}
+					}
+				}
+			}
+			pods[pi.UID] = pod
+		}
+		for _, pod := range pods {
+			allocated.Pods = append(allocated.Pods, *pod)
+		}
+		sort.Slice(allocated.Pods, func(i, j int) bool {
+			if allocated.Pods[i].Namespace != allocated.Pods[j].Namespace {
+				return allocated.Pods[i].Namespace < allocated.Pods[j].Namespace
+			}
+			return allocated.Pods[i].Name < allocated.Pods[j].Name
+		})
+		res.Devices = append(res.Devices, allocated)
+	}
+	sort.Slice(res.Devices, func(i, j int) bool { return res.Devices[i].ID < res.Devices[j].ID })
+	return res
+}
+
+// syncDeviceAllocations writes the DeviceAllocation of every node whose allocation changed, and deletes the ones
+// of nodes gone, including those written before a restart.
+func (s *Scheduler) syncDeviceAllocations(ctx context.Context) {
+	export := &s.allocationExport
+	if export.client == nil {
+		return
+	}
+	resource := export.client.Resource(DeviceAllocationResource)
+	if !export.listed {
+		list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/managed-by=" + deviceAllocationManagedBy})
+		if err != nil {
+			klog.ErrorS(err, "Failed to list device allocations")
+			return
+		}
+		for _, obj := range list.Items {
+			if _, ok := export.published[obj.GetName()]; !ok {
+				export.published[obj.GetName()] = ""
+			}
+		}
+		export.listed = true
+	}
+
+	nodes, err := s.ListNodes()
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes to export device allocations")
+		return
+	}
+	names := slices.Collect(maps.Keys(nodes))
+	usage, _, err := s.getNodesUsage(&names, nil)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get node usage to export device allocations")
+		return
+	}
+	for nodeID, node := range *usage {
+		status := deviceAllocationStatus(nodeID, node)
+		data, err := json.Marshal(status)
+		if err != nil {
+			continue
+		}
+		if published, ok := export.published[nodeID]; ok && published == string(data) {
+			continue
+		}
+		if err := writeDeviceAllocation(ctx, resource, nodeID, status); err != nil {
+			klog.ErrorS(err, "Failed to write device allocation", "nodeID", nodeID)
+			continue
+		}
+		klog.V(4).InfoS("Exported device allocation", "nodeID", nodeID)
+		export.published[nodeID] = string(data)
+	}
+	for nodeID := range export.published {
+		if _, ok := (*usage)[nodeID]; ok {
+			continue
+		}
+		if err := resource.Delete(ctx, nodeID, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to delete device allocation of node gone", "nodeID", nodeID)
+			continue
+		}
+		klog.V(4).InfoS("Deleted device allocation of node gone", "nodeID", nodeID)
+		delete(export.published, nodeID)
+	}
+}
+
+// writeDeviceAllocation creates or updates the DeviceAllocation of the node with status.
+func writeDeviceAllocation(ctx context.Context, resource dynamic.NamespaceableResourceInterface, nodeID string, status DeviceAllocationStatus) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+	obj, err := resource.Get(ctx, nodeID, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		obj = &unstructured.Unstructured{}
+		obj.SetAPIVersion(DeviceAllocationResource.GroupVersion().String())
+		obj.SetKind(DeviceAllocationKind)
+		obj.SetName(nodeID)
+		obj.SetLabels(map[string]string{"app.kubernetes.io/managed-by": deviceAllocationManagedBy})
+		obj.Object["status"] = content
+		_, err = resource.Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	obj.Object["status"] = content
+	_, err = resource.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
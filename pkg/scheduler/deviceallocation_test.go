@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func Test_syncDeviceAllocations(t *testing.T) {
+	stale := &unstructured.Unstructured{}
+	stale.SetAPIVersion(DeviceAllocationResource.GroupVersion().String())
+	stale.SetKind(DeviceAllocationKind)
+	stale.SetName("gone")
+	stale.SetLabels(map[string]string{"app.kubernetes.io/managed-by": deviceAllocationManagedBy})
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{DeviceAllocationResource: "DeviceAllocationList"}, stale)
+
+	s := NewScheduler()
+	defer s.Stop()
+	s.SetDeviceAllocationClient(client)
+	for _, name := range []string{"node1", "node2"} {
+		s.addNode(name, &device.NodeInfo{
+			ID:   name,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+				{ID: name + "-GPU-0", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			}},
+		})
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "uid-1"}}
+	// Both containers of the pod share the device
+	s.podManager.AddPod(pod, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{
+		{{UUID: "node1-GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 4000, Usedcores: 30}},
+		{{UUID: "node1-GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 2000, Usedcores: 10}},
+	}})
+	get := func(name string) *DeviceAllocationStatus {
+		obj, err := client.Resource(DeviceAllocationResource).Get(context.Background(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, obj.GetLabels()["app.kubernetes.io/managed-by"], deviceAllocationManagedBy)
+		res := &DeviceAllocationStatus{}
+		assert.NilError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object["status"].(map[string]any), res))
+		return res
+	}
+	writes := func() int {
+		res := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "create" || action.GetVerb() == "update" {
+				res++
+			}
+		}
+		return res
+	}
+
+	s.syncDeviceAllocations(context.Background())
+	assert.DeepEqual(t, get("node1"), &DeviceAllocationStatus{Node: "node1", Devices: []AllocatedDevice{{
+		ID: "node1-GPU-0", Type: nvidia.NvidiaGPUDevice, Health: true, Shares: 10, UsedShares: 2,
+		Memory: 16000, UsedMemory: 6000, Cores: 100, UsedCores: 40,
+		Pods: []DevicePodAllocation{{Namespace: "default", Name: "train", UID: k8stypes.UID("uid-1"), Memory: 6000, Cores: 40}},
+	}}})
+	assert.Equal(t, get("node2").Devices[0].UsedMemory, int32(0))
+	// The objects of nodes gone are deleted
+	assert.Assert(t, get("gone") == nil)
+	assert.Equal(t, writes(), 2)
+
+	// Unchanged allocations aren't written again
+	s.syncDeviceAllocations(context.Background())
+	assert.Equal(t, writes(), 2)
+
+	// Allocations changes are mirrored
+	s.podManager.DelPod(pod)
+	s.rmNode("node2")
+	s.syncDeviceAllocations(context.Background())
+	assert.Equal(t, get("node1").Devices[0].UsedMemory, int32(0))
+	assert.Assert(t, get("node1").Devices[0].Pods == nil)
+	assert.Assert(t, get("node2") == nil)
+	assert.Equal(t, writes(), 3)
+}
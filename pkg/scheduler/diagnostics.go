@@ -0,0 +1,156 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+const (
+	// diagnosticsErrorWindow is how far back failed decisions are counted in the diagnostics.
+	diagnosticsErrorWindow = time.Hour
+	// redactedValue replaces the values of configs which may be secrets.
+	redactedValue = "REDACTED"
+)
+
+// secretFlagWords are the words of the names of the flags whose values are redacted from the diagnostics.
+var secretFlagWords = []string{"password", "token", "secret", "key", "cert", "credential"}
+
+// DiagnosticsNodes are the nodes with devices registered.
+type DiagnosticsNodes struct {
+	WithDevices int `json:"withDevices"`
+}
+
+// DiagnosticsErrors are the filter and bind decisions which failed within Window.
+type DiagnosticsErrors struct {
+	Window        string    `json:"window"`
+	FailedFilters int       `json:"failedFilters"`
+	FailedBinds   int       `json:"failedBinds"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastErrorTime time.Time `json:"lastErrorTime,omitzero"`
+}
+
+// Diagnostics is the state of the scheduler served by the diagnostics route for troubleshooting. Caches are
+// the informer caches by resource and whether they're synced, Config are the flags of the scheduler with the
+// values which may be secrets redacted.
+type Diagnostics struct {
+	Time        time.Time         `json:"time"`
+	DeviceTypes []string          `json:"deviceTypes"`
+	Caches      map[string]bool   `json:"caches"`
+	Nodes       DiagnosticsNodes  `json:"nodes"`
+	Capacity    []VendorSummary   `json:"capacity"`
+	Errors      DiagnosticsErrors `json:"errors"`
+	Config      map[string]string `json:"config"`
+}
+
+// Diagnostics returns the diagnostics of the scheduler from its current state, with the values of flags. It
+// doesn't change anything.
+func (s *Scheduler) Diagnostics(flags *pflag.FlagSet) *Diagnostics {
+	now := time.Now()
+	res := &Diagnostics{
+		Time:        now,
+		DeviceTypes: make([]string, 0, len(device.GetDevices())),
+		Caches:      make(map[string]bool, len(s.cachesSynced)),
+		Capacity:    s.Summary("").Vendors,
+		Errors:      DiagnosticsErrors{Window: diagnosticsErrorWindow.String()},
+		Config:      redactedFlags(flags),
+	}
+	for vendor := range device.GetDevices() {
+		res.DeviceTypes = append(res.DeviceTypes, vendor)
+	}
+	slices.Sort(res.DeviceTypes)
+	for name, synced := range s.cachesSynced {
+		res.Caches[name] = synced()
+	}
+
+	nodes, err := s.ListNodes()
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes for diagnostics")
+	}
+	for _, node := range nodes {
+		for _, devices := range node.Devices {
+			if len(devices) > 0 {
+				res.Nodes.WithDevices++
+				break
+			}
+		}
+	}
+
+	for _, d := range s.ListDecisions() {
+		if d.Succeeded || now.Sub(d.Time) > diagnosticsErrorWindow {
+			continue
+		}
+		switch d.Stage {
+		case DecisionStageFilter:
+			res.Errors.FailedFilters++
+		case DecisionStageBind:
+			res.Errors.FailedBinds++
+		}
+		if !d.Time.Before(res.Errors.LastErrorTime) {
+			res.Errors.LastError, res.Errors.LastErrorTime = d.Message, d.Time
+		}
+	}
+	return res
+}
+
+// redactedFlags returns the values of flags by name. Flags whose names hint at secrets, e.g. key_file, are
+// redacted, as well as the passwords and query values of URLs.
+func redactedFlags(flags *pflag.FlagSet) map[string]string {
+	res := make(map[string]string)
+	if flags == nil {
+		return res
+	}
+	flags.VisitAll(func(f *pflag.Flag) {
+		res[f.Name] = redactedFlagValue(f.Name, f.Value.String())
+	})
+	return res
+}
+
+func redactedFlagValue(name, value string) string {
+	if value == "" {
+		return value
+	}
+	lower := strings.ToLower(name)
+	for _, word := range secretFlagWords {
+		if strings.Contains(lower, word) {
+			return redactedValue
+		}
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return value
+	}
+	if u.User != nil {
+		u.User = url.User(redactedValue)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for k := range query {
+			query.Set(k, redactedValue)
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,486 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	resourceapi "k8s.io/api/resource/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	resourcelisters "k8s.io/client-go/listers/resource/v1alpha3"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// DRAFinalizer protects ResourceClaims allocated by HAMi until their devices are released.
+const DRAFinalizer = "hami.io/dra-allocation"
+
+// DRAParameters are the opaque parameters of the HAMi DRA driver. They are set in the config of a
+// DeviceClass or of a ResourceClaim, the latter taking precedence, and carry what the resource limits
+// of a container carry in the resource-based flow.
+type DRAParameters struct {
+	// Type is the device vendor, e.g. NVIDIA.
+	Type string `json:"type,omitempty"`
+	// Memory is the device memory in MB of each device.
+	Memory int32 `json:"memory,omitempty"`
+	// MemoryPercentage is the percentage of the memory of each device, used if Memory is not set.
+	MemoryPercentage int32 `json:"memoryPercentage,omitempty"`
+	// Cores is the percentage of the cores of each device.
+	Cores int32 `json:"cores,omitempty"`
+}
+
+func (p *DRAParameters) merge(other DRAParameters) {
+	if other.Type != "" {
+		p.Type = other.Type
+	}
+	if other.Memory != 0 {
+		p.Memory = other.Memory
+	}
+	if other.MemoryPercentage != 0 {
+		p.MemoryPercentage = other.MemoryPercentage
+	}
+	if other.Cores != 0 {
+		p.Cores = other.Cores
+	}
+}
+
+// mergeOpaqueParameters merges the parameters of the driver in config into p.
+func mergeOpaqueParameters(p *DRAParameters, driver string, config resourceapi.DeviceConfiguration) error {
+	if config.Opaque == nil || config.Opaque.Driver != driver {
+		return nil
+	}
+	var params DRAParameters
+	if err := json.Unmarshal(config.Opaque.Parameters.Raw, &params); err != nil {
+		return fmt.Errorf("invalid parameters of driver %s: %v", driver, err)
+	}
+	p.merge(params)
+	return nil
+}
+
+// claimDeviceRequests maps the requests of a ResourceClaim to HAMi device requests, one per claim
+// request in the place of one per container.
+func claimDeviceRequests(driver string, claim *resourceapi.ResourceClaim, getClass func(name string) (*resourceapi.DeviceClass, error)) (device.PodDeviceRequests, error) {
+	requests := make(device.PodDeviceRequests, 0, len(claim.Spec.Devices.Requests))
+	for _, req := range claim.Spec.Devices.Requests {
+		if req.AllocationMode == resourceapi.DeviceAllocationModeAll {
+			return nil, fmt.Errorf("request %s: allocation mode %s is not supported", req.Name, req.AllocationMode)
+		}
+		if req.AdminAccess {
+			return nil, fmt.Errorf("request %s: admin access is not supported", req.Name)
+		}
+		if len(req.Selectors) > 0 {
+			return nil, fmt.Errorf("request %s: selectors are not supported", req.Name)
+		}
+		class, err := getClass(req.DeviceClassName)
+		if err != nil {
+			return nil, fmt.Errorf("request %s: failed to get device class %s: %v", req.Name, req.DeviceClassName, err)
+		}
+		var params DRAParameters
+		for _, config := range class.Spec.Config {
+			if err := mergeOpaqueParameters(&params, driver, config.DeviceConfiguration); err != nil {
+				return nil, fmt.Errorf("device class %s: %v", class.Name, err)
+			}
+		}
+		for _, config := range claim.Spec.Devices.Config {
+			if len(config.Requests) > 0 && !slices.Contains(config.Requests, req.Name) {
+				continue
+			}
+			if err := mergeOpaqueParameters(&params, driver, config.DeviceConfiguration); err != nil {
+				return nil, fmt.Errorf("request %s: %v", req.Name, err)
+			}
+		}
+		if params.Type == "" {
+			return nil, fmt.Errorf("request %s: device class %s sets no device type", req.Name, class.Name)
+		}
+		if _, ok := device.GetDevices()[params.Type]; !ok {
+			return nil, fmt.Errorf("request %s: unknown device type %s", req.Name, params.Type)
+		}
+		count := req.Count
+		if count == 0 {
+			count = 1
+		}
+		// Same defaults as the resource-based flow, the whole device memory if none is requested.
+		memPercentage := params.MemoryPercentage
+		if memPercentage == 0 {
+			memPercentage = 101
+			if params.Memory == 0 {
+				memPercentage = 100
+			}
+		}
+		requests = append(requests, device.ContainerDeviceRequests{
+			params.Type: {
+				Nums:             int32(count),
+				Type:             params.Type,
+				Memreq:           params.Memory,
+				MemPercentagereq: memPercentage,
+				Coresreq:         params.Cores,
+			},
+		})
+	}
+	return requests, nil
+}
+
+// claimPod returns the pod the devices of claim are tracked as by podManager. Scheduling
+// annotations of the claim, e.g. the GPU scheduler policy, apply as they do on pods.
+func claimPod(claim *resourceapi.ResourceClaim) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        claim.Name,
+		Namespace:   claim.Namespace,
+		UID:         claim.UID,
+		Annotations: claim.Annotations,
+	}}
+}
+
+// draDeviceName turns a device UUID into a DNS label, as required of device names in allocation results.
+func draDeviceName(uuid string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(uuid))
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+// draAllocationResults lists the devices allocated for each request of claim.
+func draAllocationResults(driver, nodeName string, claim *resourceapi.ResourceClaim, devices device.PodDevices) []resourceapi.DeviceRequestAllocationResult {
+	results := []resourceapi.DeviceRequestAllocationResult{}
+	types := make([]string, 0, len(devices))
+	for t := range devices {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for i, req := range claim.Spec.Devices.Requests {
+		for _, t := range types {
+			if i >= len(devices[t]) {
+				continue
+			}
+			for _, d := range devices[t][i] {
+				results = append(results, resourceapi.DeviceRequestAllocationResult{
+					Request: req.Name,
+					Driver:  driver,
+					Pool:    nodeName,
+					Device:  draDeviceName(d.UUID),
+				})
+			}
+		}
+	}
+	return results
+}
+
+// draController allocates the ResourceClaims of the DRA driver as a control plane controller,
+// with the device registry and fit logic of the resource-based flow.
+type draController struct {
+	s           *Scheduler
+	driver      string
+	client      kubernetes.Interface
+	podLister   listerscorev1.PodLister
+	claimLister resourcelisters.ResourceClaimLister
+	classLister resourcelisters.DeviceClassLister
+	// Allocations are serialized so each fit sees the claims allocated before it.
+	mutex sync.Mutex
+}
+
+// startDRAController watches ResourceClaims and PodSchedulingContexts of the DRA driver.
+func (s *Scheduler) startDRAController(driver string) {
+	klog.InfoS("Starting HAMi DRA controller", "driver", driver)
+	informerFactory := informers.NewSharedInformerFactory(s.kubeClient, time.Second*30)
+	resourceInformers := informerFactory.Resource().V1alpha3()
+	d := &draController{
+		s:           s,
+		driver:      driver,
+		client:      s.kubeClient,
+		podLister:   s.podLister,
+		claimLister: resourceInformers.ResourceClaims().Lister(),
+		classLister: resourceInformers.DeviceClasses().Lister(),
+	}
+	resourceInformers.ResourceClaims().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    d.onAddClaim,
+		UpdateFunc: func(_, newObj any) { d.onAddClaim(newObj) },
+		DeleteFunc: d.onDelClaim,
+	})
+	resourceInformers.PodSchedulingContexts().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    d.onAddSchedulingContext,
+		UpdateFunc: func(_, newObj any) { d.onAddSchedulingContext(newObj) },
+	})
+	informerFactory.Start(s.stopCh)
+	informerFactory.WaitForCacheSync(s.stopCh)
+}
+
+func (d *draController) onAddClaim(obj any) {
+	claim, ok := obj.(*resourceapi.ResourceClaim)
+	if !ok {
+		klog.Errorf("unknown add object type")
+		return
+	}
+	if claim.Spec.Controller != d.driver {
+		return
+	}
+	if err := d.syncClaim(context.Background(), claim); err != nil {
+		klog.ErrorS(err, "Failed to sync resource claim", "claim", klog.KObj(claim))
+	}
+}
+
+func (d *draController) onDelClaim(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	claim, ok := obj.(*resourceapi.ResourceClaim)
+	if !ok {
+		klog.Errorf("unknown del object type")
+		return
+	}
+	d.s.podManager.DelPod(claimPod(claim))
+}
+
+func (d *draController) onAddSchedulingContext(obj any) {
+	sc, ok := obj.(*resourceapi.PodSchedulingContext)
+	if !ok {
+		klog.Errorf("unknown add object type")
+		return
+	}
+	if err := d.syncSchedulingContext(context.Background(), sc); err != nil {
+		klog.ErrorS(err, "Failed to sync pod scheduling context", "podSchedulingContext", klog.KObj(sc))
+	}
+}
+
+// syncClaim releases the devices of a claim no longer in use, and tracks the devices of allocated
+// claims in podManager, e.g. after a restart.
+func (d *draController) syncClaim(ctx context.Context, claim *resourceapi.ResourceClaim) error {
+	allocation := claim.Status.Allocation
+	if allocation == nil || allocation.Controller != d.driver {
+		if claim.DeletionTimestamp != nil && slices.Contains(claim.Finalizers, DRAFinalizer) {
+			return d.deallocate(ctx, claim)
+		}
+		return nil
+	}
+	if (claim.DeletionTimestamp != nil || claim.Status.DeallocationRequested) && len(claim.Status.ReservedFor) == 0 {
+		return d.deallocate(ctx, claim)
+	}
+	nodeID, ok := claim.Annotations[util.AssignedNodeAnnotations]
+	if !ok {
+		return nil
+	}
+	devices, err := device.DecodePodDevices(device.SupportDevices, claim.Annotations)
+	if err != nil {
+		return err
+	}
+	d.s.podManager.AddPod(claimPod(claim), nodeID, devices)
+	return nil
+}
+
+// deallocate clears the allocation of claim and releases its devices.
+func (d *draController) deallocate(ctx context.Context, claim *resourceapi.ResourceClaim) error {
+	claims := d.client.ResourceV1alpha3().ResourceClaims(claim.Namespace)
+	if claim.Status.Allocation != nil || claim.Status.DeallocationRequested {
+		claim = claim.DeepCopy()
+		claim.Status.Allocation = nil
+		claim.Status.DeallocationRequested = false
+		updated, err := claims.UpdateStatus(ctx, claim, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to clear allocation: %v", err)
+		}
+		claim = updated
+	}
+	d.s.podManager.DelPod(claimPod(claim))
+	claim = claim.DeepCopy()
+	for _, key := range device.SupportDevices {
+		delete(claim.Annotations, key)
+	}
+	delete(claim.Annotations, util.AssignedNodeAnnotations)
+	claim.Finalizers = slices.DeleteFunc(claim.Finalizers, func(f string) bool { return f == DRAFinalizer })
+	if _, err := claims.Update(ctx, claim, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to remove finalizer: %v", err)
+	}
+	klog.InfoS("Resource claim deallocated", "claim", klog.KObj(claim))
+	return nil
+}
+
+// podClaims returns the unallocated claims of the DRA driver referenced by pod, keyed by their name in the pod.
+func (d *draController) podClaims(pod *corev1.Pod) (map[string]*resourceapi.ResourceClaim, error) {
+	claims := make(map[string]*resourceapi.ResourceClaim)
+	for _, ref := range pod.Spec.ResourceClaims {
+		name := ref.ResourceClaimName
+		for _, status := range pod.Status.ResourceClaimStatuses {
+			if name == nil && status.Name == ref.Name {
+				name = status.ResourceClaimName
+			}
+		}
+		// The claim of the template is not created yet.
+		if name == nil {
+			continue
+		}
+		claim, err := d.claimLister.ResourceClaims(pod.Namespace).Get(*name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if claim.Spec.Controller != d.driver || claim.Status.Allocation != nil || claim.DeletionTimestamp != nil {
+			continue
+		}
+		claims[ref.Name] = claim
+	}
+	return claims, nil
+}
+
+// syncSchedulingContext allocates the claims of the pod on the selected node, and reports the
+// potential nodes the remaining claims don't fit.
+func (d *draController) syncSchedulingContext(ctx context.Context, sc *resourceapi.PodSchedulingContext) error {
+	pod, err := d.podLister.Pods(sc.Namespace).Get(sc.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	claims, err := d.podClaims(pod)
+	if err != nil || len(claims) == 0 {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	nodeNames := slices.Clone(sc.Spec.PotentialNodes)
+	if sc.Spec.SelectedNode != "" {
+		if !slices.Contains(nodeNames, sc.Spec.SelectedNode) {
+			nodeNames = append(nodeNames, sc.Spec.SelectedNode)
+		}
+		for name, claim := range claims {
+			if err := d.allocate(ctx, claim, sc.Spec.SelectedNode); err != nil {
+				klog.InfoS("Failed to allocate resource claim", "claim", klog.KObj(claim), "node", sc.Spec.SelectedNode, "reason", err)
+				continue
+			}
+			delete(claims, name)
+		}
+	}
+
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	statuses := make([]resourceapi.ResourceClaimSchedulingStatus, 0, len(names))
+	for _, name := range names {
+		fits, err := d.fit(claims[name], nodeNames)
+		if err != nil {
+			klog.InfoS("Resource claim fits no node", "claim", klog.KObj(claims[name]), "reason", err)
+		}
+		unsuitable := []string{}
+		for _, nodeName := range nodeNames {
+			if !slices.ContainsFunc(fits, func(f *nodeFit) bool { return f.nodeID == nodeName }) {
+				unsuitable = append(unsuitable, nodeName)
+			}
+		}
+		statuses = append(statuses, resourceapi.ResourceClaimSchedulingStatus{Name: name, UnsuitableNodes: unsuitable})
+	}
+	if equality.Semantic.DeepEqual(sc.Status.ResourceClaims, statuses) {
+		return nil
+	}
+	sc = sc.DeepCopy()
+	sc.Status.ResourceClaims = statuses
+	_, err = d.client.ResourceV1alpha3().PodSchedulingContexts(sc.Namespace).UpdateStatus(ctx, sc, metav1.UpdateOptions{})
+	return err
+}
+
+// fit returns the nodes claim fits, with their devices, without changing any state.
+func (d *draController) fit(claim *resourceapi.ResourceClaim, nodeNames []string) ([]*nodeFit, error) {
+	requests, err := claimDeviceRequests(d.driver, claim, d.classLister.Get)
+	if err != nil {
+		return nil, err
+	}
+	pod := claimPod(claim)
+	nodeUsage, failedNodes, err := d.s.getNodesUsage(&nodeNames, pod)
+	if err != nil {
+		return nil, err
+	}
+	nodeScores, _, err := d.s.scoreNodes(context.Background(), nodeUsage, requests, pod, failedNodes)
+	if err != nil {
+		return nil, err
+	}
+	fits := make([]*nodeFit, 0, len(nodeScores.NodeList))
+	for _, score := range nodeScores.NodeList {
+		fits = append(fits, &nodeFit{nodeID: score.NodeID, score: score.Score, devices: score.Devices})
+	}
+	return fits, nil
+}
+
+// allocate allocates the devices of claim on the node, recording them in the annotations of the
+// claim like on pods and in its allocation result.
+func (d *draController) allocate(ctx context.Context, claim *resourceapi.ResourceClaim, nodeName string) error {
+	fits, err := d.fit(claim, []string{nodeName})
+	if err != nil {
+		return err
+	}
+	if len(fits) == 0 {
+		return fmt.Errorf("claim does not fit node %s", nodeName)
+	}
+	devices := fits[0].devices
+	pod := claimPod(claim)
+	d.s.podManager.AddPod(pod, nodeName, devices)
+
+	claims := d.client.ResourceV1alpha3().ResourceClaims(claim.Namespace)
+	claim = claim.DeepCopy()
+	if claim.Annotations == nil {
+		claim.Annotations = make(map[string]string)
+	}
+	for key, value := range device.EncodePodDevices(device.SupportDevices, devices) {
+		claim.Annotations[key] = value
+	}
+	claim.Annotations[util.AssignedNodeAnnotations] = nodeName
+	if !slices.Contains(claim.Finalizers, DRAFinalizer) {
+		claim.Finalizers = append(claim.Finalizers, DRAFinalizer)
+	}
+	updated, err := claims.Update(ctx, claim, metav1.UpdateOptions{})
+	if err != nil {
+		d.s.podManager.DelPod(pod)
+		return fmt.Errorf("failed to record devices: %v", err)
+	}
+	updated.Status.Allocation = &resourceapi.AllocationResult{
+		Devices: resourceapi.DeviceAllocationResult{Results: draAllocationResults(d.driver, nodeName, claim, devices)},
+		NodeSelector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{nodeName}}},
+		}}},
+		Controller: d.driver,
+	}
+	if _, err := claims.UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		d.s.podManager.DelPod(pod)
+		return fmt.Errorf("failed to write allocation: %v", err)
+	}
+	klog.InfoS("Resource claim allocated", "claim", klog.KObj(claim), "node", nodeName, "devices", devices)
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	resourceapi "k8s.io/api/resource/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	resourcelisters "k8s.io/client-go/listers/resource/v1alpha3"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const testDRADriver = "gpu.hami.io"
+
+func draConfig(params string) resourceapi.DeviceConfiguration {
+	return resourceapi.DeviceConfiguration{Opaque: &resourceapi.OpaqueDeviceConfiguration{
+		Driver:     testDRADriver,
+		Parameters: runtime.RawExtension{Raw: []byte(params)},
+	}}
+}
+
+func draClass(name, params string) *resourceapi.DeviceClass {
+	return &resourceapi.DeviceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: resourceapi.DeviceClassSpec{Config: []resourceapi.DeviceClassConfiguration{
+			{DeviceConfiguration: draConfig(params)},
+		}},
+	}
+}
+
+func draClaim(name string, requests ...resourceapi.DeviceRequest) *resourceapi.ResourceClaim {
+	return &resourceapi.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid")},
+		Spec: resourceapi.ResourceClaimSpec{
+			Devices:    resourceapi.DeviceClaim{Requests: requests},
+			Controller: testDRADriver,
+		},
+	}
+}
+
+func Test_claimDeviceRequests(t *testing.T) {
+	initTestDevices(t)
+	classes := map[string]*resourceapi.DeviceClass{
+		"gpu.hami.io":  draClass("gpu.hami.io", `{"type": "NVIDIA", "memory": 1000}`),
+		"whole":        draClass("whole", `{"type": "NVIDIA"}`),
+		"no-type":      draClass("no-type", `{"memory": 1000}`),
+		"unknown-type": draClass("unknown-type", `{"type": "unknown"}`),
+	}
+	getClass := func(name string) (*resourceapi.DeviceClass, error) {
+		if class, ok := classes[name]; ok {
+			return class, nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+	tests := []struct {
+		name    string
+		claim   *resourceapi.ResourceClaim
+		want    device.PodDeviceRequests
+		wantErr string
+	}{
+		{
+			name: "class parameters",
+			claim: draClaim("claim", resourceapi.DeviceRequest{
+				Name: "gpu", DeviceClassName: "gpu.hami.io", AllocationMode: resourceapi.DeviceAllocationModeExactCount,
+			}),
+			want: device.PodDeviceRequests{{nvidia.NvidiaGPUDevice: {Nums: 1, Type: nvidia.NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}}},
+		},
+		{
+			name: "claim parameters of the request override class parameters",
+			claim: func() *resourceapi.ResourceClaim {
+				claim := draClaim("claim",
+					resourceapi.DeviceRequest{Name: "train", DeviceClassName: "gpu.hami.io", Count: 2},
+					resourceapi.DeviceRequest{Name: "infer", DeviceClassName: "gpu.hami.io"},
+				)
+				claim.Spec.Devices.Config = []resourceapi.DeviceClaimConfiguration{
+					{Requests: []string{"train"}, DeviceConfiguration: draConfig(`{"memoryPercentage": 50, "cores": 30}`)},
+					{DeviceConfiguration: resourceapi.DeviceConfiguration{Opaque: &resourceapi.OpaqueDeviceConfiguration{
+						Driver: "other.example.com", Parameters: runtime.RawExtension{Raw: []byte(`{"memory": 1}`)},
+					}}},
+				}
+				return claim
+			}(),
+			want: device.PodDeviceRequests{
+				{nvidia.NvidiaGPUDevice: {Nums: 2, Type: nvidia.NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 50, Coresreq: 30}},
+				{nvidia.NvidiaGPUDevice: {Nums: 1, Type: nvidia.NvidiaGPUDevice, Memreq: 1000, MemPercentagereq: 101}},
+			},
+		},
+		{
+			name:  "whole device memory without memory parameters",
+			claim: draClaim("claim", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "whole"}),
+			want:  device.PodDeviceRequests{{nvidia.NvidiaGPUDevice: {Nums: 1, Type: nvidia.NvidiaGPUDevice, MemPercentagereq: 100}}},
+		},
+		{
+			name:    "all devices",
+			claim:   draClaim("claim", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "gpu.hami.io", AllocationMode: resourceapi.DeviceAllocationModeAll}),
+			wantErr: "allocation mode All is not supported",
+		},
+		{
+			name: "selectors",
+			claim: draClaim("claim", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "gpu.hami.io", Selectors: []resourceapi.DeviceSelector{
+				{CEL: &resourceapi.CELDeviceSelector{Expression: "true"}},
+			}}),
+			wantErr: "selectors are not supported",
+		},
+		{
+			name:    "missing class",
+			claim:   draClaim("claim", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "missing"}),
+			wantErr: "failed to get device class missing",
+		},
+		{
+			name:    "no device type",
+			claim:   draClaim("claim", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "no-type"}),
+			wantErr: "device class no-type sets no device type",
+		},
+		{
+			name:    "unknown device type",
+			claim:   draClaim("claim", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "unknown-type"}),
+			wantErr: "unknown device type unknown",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := claimDeviceRequests(testDRADriver, test.claim, getClass)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, test.want)
+		})
+	}
+}
+
+func Test_draDeviceName(t *testing.T) {
+	assert.Equal(t, draDeviceName("GPU-8aa3f1c2-0b5e"), "gpu-8aa3f1c2-0b5e")
+	assert.Equal(t, draDeviceName("GPU-0[1-3]"), "gpu-0-1-3")
+}
+
+func Test_draController(t *testing.T) {
+	initTestDevices(t)
+	s := NewScheduler()
+	for node, ids := range map[string][]string{"node1": {"GPU-1", "GPU-2"}, "node2": {"GPU-3"}} {
+		var devices []device.DeviceInfo
+		for i, id := range ids {
+			devices = append(devices, device.DeviceInfo{ID: id, Index: uint(i), Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice})
+		}
+		s.addNode(node, &device.NodeInfo{
+			ID:      node,
+			Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: node}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices},
+		})
+	}
+
+	class := draClass("gpu.hami.io", `{"type": "NVIDIA"}`)
+	shared := draClaim("shared", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "gpu.hami.io"})
+	shared.Spec.Devices.Config = []resourceapi.DeviceClaimConfiguration{{DeviceConfiguration: draConfig(`{"memory": 3000, "cores": 20}`)}}
+	pair := draClaim("pair", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "gpu.hami.io", Count: 2})
+	pair.Spec.Devices.Config = []resourceapi.DeviceClaimConfiguration{{DeviceConfiguration: draConfig(`{"memory": 4000}`)}}
+	other := draClaim("other", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "gpu.hami.io"})
+	other.Spec.Controller = "other.example.com"
+	templateClaimName := "pair"
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec: corev1.PodSpec{ResourceClaims: []corev1.PodResourceClaim{
+			{Name: "shared", ResourceClaimName: &shared.Name},
+			{Name: "pair"},
+			{Name: "other", ResourceClaimName: &other.Name},
+		}},
+		Status: corev1.PodStatus{ResourceClaimStatuses: []corev1.PodResourceClaimStatus{{Name: "pair", ResourceClaimName: &templateClaimName}}},
+	}
+	sc := &resourceapi.PodSchedulingContext{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec:       resourceapi.PodSchedulingContextSpec{PotentialNodes: []string{"node1", "node2"}},
+	}
+
+	client := fake.NewSimpleClientset(shared, pair, other, sc)
+	s.kubeClient = client
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NilError(t, podIndexer.Add(pod))
+	claimIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, claim := range []*resourceapi.ResourceClaim{shared, pair, other} {
+		assert.NilError(t, claimIndexer.Add(claim))
+	}
+	classIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NilError(t, classIndexer.Add(class))
+	d := &draController{
+		s:           s,
+		driver:      testDRADriver,
+		client:      client,
+		podLister:   listerscorev1.NewPodLister(podIndexer),
+		claimLister: resourcelisters.NewResourceClaimLister(claimIndexer),
+		classLister: resourcelisters.NewDeviceClassLister(classIndexer),
+	}
+	ctx := context.Background()
+
+	// The pair of devices only fits node1.
+	assert.NilError(t, d.syncSchedulingContext(ctx, sc))
+	sc, err := client.ResourceV1alpha3().PodSchedulingContexts("default").Get(ctx, "pod", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sc.Status.ResourceClaims, []resourceapi.ResourceClaimSchedulingStatus{
+		{Name: "pair", UnsuitableNodes: []string{"node2"}},
+		{Name: "shared", UnsuitableNodes: []string{}},
+	})
+
+	sc.Spec.SelectedNode = "node1"
+	assert.NilError(t, d.syncSchedulingContext(ctx, sc))
+	allocated, err := client.ResourceV1alpha3().ResourceClaims("default").Get(ctx, "shared", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, allocated.Finalizers, []string{DRAFinalizer})
+	assert.Equal(t, allocated.Annotations[util.AssignedNodeAnnotations], "node1")
+	assert.Equal(t, allocated.Status.Allocation.Controller, testDRADriver)
+	assert.DeepEqual(t, allocated.Status.Allocation.NodeSelector.NodeSelectorTerms[0].MatchFields[0].Values, []string{"node1"})
+	results := allocated.Status.Allocation.Devices.Results
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].Request, "gpu")
+	assert.Equal(t, results[0].Pool, "node1")
+	pi, ok := s.podManager.GetPod(claimPod(shared))
+	assert.Assert(t, ok)
+	assert.Equal(t, pi.NodeID, "node1")
+	assert.Equal(t, pi.Devices[nvidia.NvidiaGPUDevice][0][0].Usedmem, int32(3000))
+	assert.Equal(t, pi.Devices[nvidia.NvidiaGPUDevice][0][0].Usedcores, int32(20))
+	assert.Equal(t, draDeviceName(pi.Devices[nvidia.NvidiaGPUDevice][0][0].UUID), results[0].Device)
+
+	pairAllocated, err := client.ResourceV1alpha3().ResourceClaims("default").Get(ctx, "pair", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(pairAllocated.Status.Allocation.Devices.Results), 2)
+	// Claims of other drivers are left alone.
+	untouched, err := client.ResourceV1alpha3().ResourceClaims("default").Get(ctx, "other", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Assert(t, untouched.Status.Allocation == nil)
+
+	// Both devices of node1 are shared now, the whole memory of a device is only left on node2.
+	third := draClaim("third", resourceapi.DeviceRequest{Name: "gpu", DeviceClassName: "gpu.hami.io"})
+	fits, err := d.fit(third, []string{"node1", "node2"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].nodeID, "node2")
+
+	// Deallocation releases the devices.
+	allocated.Status.DeallocationRequested = true
+	assert.NilError(t, d.syncClaim(ctx, allocated))
+	released, err := client.ResourceV1alpha3().ResourceClaims("default").Get(ctx, "shared", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Assert(t, released.Status.Allocation == nil)
+	assert.Assert(t, !released.Status.DeallocationRequested)
+	assert.Equal(t, len(released.Finalizers), 0)
+	_, ok = released.Annotations[util.AssignedNodeAnnotations]
+	assert.Assert(t, !ok)
+	_, ok = s.podManager.GetPod(claimPod(shared))
+	assert.Assert(t, !ok)
+
+	// Allocated claims are tracked again after a restart.
+	restarted := NewScheduler()
+	d.s = restarted
+	assert.NilError(t, d.syncClaim(ctx, pairAllocated))
+	pi, ok = restarted.podManager.GetPod(claimPod(pair))
+	assert.Assert(t, ok)
+	assert.Equal(t, len(pi.Devices[nvidia.NvidiaGPUDevice][0]), 2)
+}
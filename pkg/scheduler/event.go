@@ -40,6 +40,9 @@ const (
 	EventReasonBindingFailed = "BindingFailed"
 	// EventReasonBindingSucceed indicates that  binding succeed.
 	EventReasonBindingSucceed = "BindingSucceed"
+
+	// EventReasonDebugTrace records the filter result for pods with scheduler tracing enabled.
+	EventReasonDebugTrace = "DebugTrace"
 )
 
 func (s *Scheduler) addAllEventHandlers() {
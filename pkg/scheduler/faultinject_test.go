@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/faultinject"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+)
+
+func Test_Bind_FaultInjection(t *testing.T) {
+	defer faultinject.ResetForTest()
+	initTestDevices(t)
+	nodelock.ResetNodeLocksForTest()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "train"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "train",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.DecimalSI)}},
+		}}},
+	}
+	client.KubeClient = fake.NewSimpleClientset(pod, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	args := extenderv1.ExtenderBindingArgs{PodName: pod.Name, PodNamespace: pod.Namespace, PodUID: pod.UID, Node: "node1"}
+	assertRolledBack := func() {
+		t.Helper()
+		node, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+		assert.NilError(t, err)
+		_, locked := node.Annotations[nodelock.NodeLockKey]
+		assert.Assert(t, !locked, "node lock kept: %v", node.Annotations)
+		current, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, current.Spec.NodeName, "")
+		_, phase := current.Annotations[util.DeviceBindPhase]
+		assert.Assert(t, !phase)
+	}
+
+	faultinject.Enable()
+	// The node lock is acquired, then released once patching the pod fails.
+	assert.NilError(t, faultinject.Set(faultinject.PointBindPatch, faultinject.Fault{Error: "conflict", Count: 1}))
+	res, err := s.Bind(args)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(res.Error, "injected fault at bind-patch: conflict"), res.Error)
+	assertRolledBack()
+
+	// The node lock can't be acquired, nothing is patched.
+	assert.NilError(t, faultinject.Set(faultinject.PointNodeLockAcquire, faultinject.Fault{Error: "timeout", Count: 1}))
+	res, err = s.Bind(args)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(res.Error, "injected fault at node-lock-acquire: timeout"), res.Error)
+	assertRolledBack()
+
+	// The faults were injected once, the retry binds the pod.
+	res, err = s.Bind(args)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Error, "")
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	// FreeMemoryBandLabel is the label of the band of device memory left free on a node, e.g. 10Gi-20Gi.
+	FreeMemoryBandLabel = "hami.io/free-gpumem-band"
+	// FreeCapacityConfigMapKey is the key of the free capacity of the cluster in the published ConfigMap.
+	FreeCapacityConfigMapKey = "free-capacity.json"
+)
+
+// MemoryBand is a range of free device memory in MB, from Low included to High excluded, High is 0 for the
+// last band.
+type MemoryBand struct {
+	Name string
+	Low  int64
+	High int64
+}
+
+func (b MemoryBand) contains(free, margin int64) bool {
+	return free >= b.Low-margin && (b.High == 0 || free < b.High+margin)
+}
+
+// FreeMemoryBands are the bands nodes are labeled with by their free device memory. A node keeps its band until
+// its free memory leaves the band by more than Hysteresis MB, so labels don't change on every pod.
+type FreeMemoryBands struct {
+	Bands      []MemoryBand
+	Hysteresis int64
+}
+
+// ParseFreeMemoryBands returns the bands delimited by the quantities of bounds in ascending order, e.g. 10Gi and
+// 20Gi delimit the bands 0-10Gi, 10Gi-20Gi and over-20Gi.
+func ParseFreeMemoryBands(bounds []string, hysteresis string) (*FreeMemoryBands, error) {
+	if len(bounds) == 0 {
+		return nil, fmt.Errorf("no bounds of free memory bands")
+	}
+	res := &FreeMemoryBands{}
+	low, lowName := int64(0), "0"
+	for _, bound := range bounds {
+		bound = strings.TrimSpace(bound)
+		q, err := resource.ParseQuantity(bound)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound %q: %v", bound, err)
+		}
+		high := q.Value() / (1024 * 1024)
+		if high <= low {
+			return nil, fmt.Errorf("bound %q must be above the previous bound %s", bound, lowName)
+		}
+		res.Bands = append(res.Bands, MemoryBand{Name: lowName + "-" + bound, Low: low, High: high})
+		low, lowName = high, bound
+	}
+	res.Bands = append(res.Bands, MemoryBand{Name: "over-" + lowName, Low: low})
+	for _, b := range res.Bands {
+		if errs := validation.IsValidLabelValue(b.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("band %q is not a valid label value: %s", b.Name, strings.Join(errs, "; "))
+		}
+	}
+	q, err := resource.ParseQuantity(hysteresis)
+	if err != nil || q.Sign() < 0 {
+		return nil, fmt.Errorf("invalid hysteresis %q, must be a non-negative quantity", hysteresis)
+	}
+	res.Hysteresis = q.Value() / (1024 * 1024)
+	return res, nil
+}
+
+// bandOf returns the band of free memory, current if free is still within it by the hysteresis.
+func (b *FreeMemoryBands) bandOf(free int64, current string) string {
+	for _, band := range b.Bands {
+		if band.Name == current && band.contains(free, b.Hysteresis) {
+			return current
+		}
+	}
+	for _, band := range b.Bands {
+		if band.contains(free, 0) {
+			return band.Name
+		}
+	}
+	return b.Bands[0].Name
+}
+
+// FreeCapacityBand is the number of nodes in a band of free device memory.
+type FreeCapacityBand struct {
+	Band  string `json:"band"`
+	Nodes int    `json:"nodes"`
+}
+
+// FreeCapacity is the content of FreeCapacityConfigMapKey. LargestBand is the highest band of any node, so the
+// largest device memory a pod can be placed with is within it.
+type FreeCapacity struct {
+	Bands       []FreeCapacityBand `json:"bands"`
+	LargestBand string             `json:"largestBand"`
+}
+
+// freeCapacity is the state of the free capacity labels, only used by the registration loop.
+type freeCapacity struct {
+	bands *FreeMemoryBands
+	// Bands nodes are labeled with
+	labeled   map[string]string
+	published string
+}
+
+// SetFreeMemoryBands enables labeling nodes with the bands of their free device memory, it must be called
+// before the registration loop starts.
+func (s *Scheduler) SetFreeMemoryBands(bands *FreeMemoryBands) {
+	s.freeCapacity.bands = bands
+	s.freeCapacity.labeled = make(map[string]string)
+}
+
+// syncFreeCapacity labels the nodes with the bands of their free device memory, and publishes the number of
+// nodes in each band into config.FreeCapacityConfigMap. Nodes and the ConfigMap are only written on changes.
+func (s *Scheduler) syncFreeCapacity(usage map[string]*NodeUsage) {
+	bands := s.freeCapacity.bands
+	if bands == nil {
+		return
+	}
+	counts := make(map[string]int, len(bands.Bands))
+	labeled := make(map[string]string, len(usage))
+	for nodeName, u := range usage {
+		free := int64(0)
+		for _, d := range u.Devices.DeviceLists {
+			if d.Device.Health && d.Device.Totalmem > d.Device.Usedmem {
+				free += int64(d.Device.Totalmem - d.Device.Usedmem)
+			}
+		}
+		current, ok := s.freeCapacity.labeled[nodeName]
+		if !ok && u.Node != nil {
+			current = u.Node.Labels[FreeMemoryBandLabel]
+		}
+		band := bands.bandOf(free, current)
+		if band != current || !ok {
+			if err := s.patchFreeMemoryBand(nodeName, band); err != nil {
+				klog.ErrorS(err, "Failed to label node with its free memory band", "nodeName", nodeName, "band", band)
+				continue
+			}
+		}
+		labeled[nodeName] = band
+		counts[band]++
+	}
+	s.freeCapacity.labeled = labeled
+
+	if config.FreeCapacityConfigMap == "" {
+		return
+	}
+	res := FreeCapacity{Bands: make([]FreeCapacityBand, 0, len(bands.Bands))}
+	for _, band := range bands.Bands {
+		res.Bands = append(res.Bands, FreeCapacityBand{Band: band.Name, Nodes: counts[band.Name]})
+		if counts[band.Name] > 0 {
+			res.LargestBand = band.Name
+		}
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		klog.ErrorS(err, "Failed to marshal free capacity")
+		return
+	}
+	if string(data) == s.freeCapacity.published {
+		return
+	}
+	if err := s.writeConfigMapKey(config.FreeCapacityConfigMap, FreeCapacityConfigMapKey, string(data)); err != nil {
+		klog.ErrorS(err, "Failed to publish free capacity", "configmap", config.FreeCapacityConfigMap)
+		return
+	}
+	s.freeCapacity.published = string(data)
+	klog.V(4).InfoS("Published free capacity", "configmap", config.FreeCapacityConfigMap, "capacity", s.freeCapacity.published)
+}
+
+// patchFreeMemoryBand sets the free memory band label of the node by util.PatchAnnotationsWithRetry. The node
+// is only patched when its label differs, e.g. it's already set after a restart.
+func (s *Scheduler) patchFreeMemoryBand(nodeName, band string) error {
+	err := util.PatchAnnotationsWithRetry(context.Background(), s.kubeClient, util.NodeRef(nodeName), func(meta *metav1.ObjectMeta) error {
+		if meta.Labels == nil {
+			meta.Labels = make(map[string]string, 1)
+		}
+		meta.Labels[FreeMemoryBandLabel] = band
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	klog.V(4).InfoS("Labeled node with its free memory band", "nodeName", nodeName, "band", band)
+	return nil
+}
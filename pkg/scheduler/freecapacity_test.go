@@ -0,0 +1,124 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
+)
+
+func Test_ParseFreeMemoryBands(t *testing.T) {
+	bands, err := ParseFreeMemoryBands([]string{"10Gi", " 20Gi", "40Gi"}, "1Gi")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, bands, &FreeMemoryBands{
+		Bands: []MemoryBand{
+			{Name: "0-10Gi", Low: 0, High: 10240},
+			{Name: "10Gi-20Gi", Low: 10240, High: 20480},
+			{Name: "20Gi-40Gi", Low: 20480, High: 40960},
+			{Name: "over-40Gi", Low: 40960},
+		},
+		Hysteresis: 1024,
+	})
+
+	for _, test := range []struct {
+		bounds     []string
+		hysteresis string
+	}{
+		{bounds: nil, hysteresis: "1Gi"},
+		{bounds: []string{"ten"}, hysteresis: "1Gi"},
+		{bounds: []string{"20Gi", "10Gi"}, hysteresis: "1Gi"},
+		{bounds: []string{"10Gi", "10240Mi"}, hysteresis: "1Gi"},
+		{bounds: []string{"10Gi"}, hysteresis: "-1Gi"},
+	} {
+		_, err := ParseFreeMemoryBands(test.bounds, test.hysteresis)
+		assert.Assert(t, err != nil, "bounds %v hysteresis %s", test.bounds, test.hysteresis)
+	}
+}
+
+func Test_syncFreeCapacity(t *testing.T) {
+	restoreConfig(t)
+	config.FreeCapacityConfigMap = "kube-system/hami-free-capacity"
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	node2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{FreeMemoryBandLabel: "10Gi-20Gi"}}}
+	kubeClient := fake.NewSimpleClientset(node1, node2)
+	s := NewScheduler()
+	s.kubeClient = kubeClient
+	bands, err := ParseFreeMemoryBands([]string{"10Gi", "20Gi"}, "1Gi")
+	assert.NilError(t, err)
+	s.SetFreeMemoryBands(bands)
+
+	gpu := func(total, used int32, health bool) *policy.DeviceListsScore {
+		return &policy.DeviceListsScore{Device: &device.DeviceUsage{Totalmem: total, Usedmem: used, Health: health}}
+	}
+	usage := func(node *corev1.Node, devices ...*policy.DeviceListsScore) *NodeUsage {
+		return &NodeUsage{Node: node, Devices: policy.DeviceUsageList{DeviceLists: devices}}
+	}
+	label := func(name string) string {
+		node, err := kubeClient.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		return node.Labels[FreeMemoryBandLabel]
+	}
+	published := func() FreeCapacity {
+		cm, err := kubeClient.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "hami-free-capacity", metav1.GetOptions{})
+		assert.NilError(t, err)
+		var res FreeCapacity
+		assert.NilError(t, json.Unmarshal([]byte(cm.Data[FreeCapacityConfigMapKey]), &res))
+		return res
+	}
+
+	// 24000MB free on node1, node2 keeps the label it had before a restart, the unhealthy GPU isn't counted.
+	s.syncFreeCapacity(map[string]*NodeUsage{
+		"node1": usage(node1, gpu(16000, 4000, true), gpu(16000, 4000, true)),
+		"node2": usage(node2, gpu(16000, 2000, true), gpu(16000, 0, false)),
+	})
+	assert.Equal(t, label("node1"), "over-20Gi")
+	assert.Equal(t, label("node2"), "10Gi-20Gi")
+	assert.DeepEqual(t, published(), FreeCapacity{
+		Bands:       []FreeCapacityBand{{Band: "0-10Gi"}, {Band: "10Gi-20Gi", Nodes: 1}, {Band: "over-20Gi", Nodes: 1}},
+		LargestBand: "over-20Gi",
+	})
+
+	// node1 drops to 20000MB, within the hysteresis of its band, nothing is written.
+	actions := len(kubeClient.Actions())
+	s.syncFreeCapacity(map[string]*NodeUsage{
+		"node1": usage(node1, gpu(16000, 6000, true), gpu(16000, 6000, true)),
+		"node2": usage(node2, gpu(16000, 2000, true), gpu(16000, 0, false)),
+	})
+	assert.Equal(t, len(kubeClient.Actions()), actions)
+
+	// node1 drops to 16000MB, beyond the hysteresis, and node2 fills up.
+	s.syncFreeCapacity(map[string]*NodeUsage{
+		"node1": usage(node1, gpu(16000, 8000, true), gpu(16000, 8000, true)),
+		"node2": usage(node2, gpu(16000, 16000, true), gpu(16000, 0, false)),
+	})
+	assert.Equal(t, label("node1"), "10Gi-20Gi")
+	assert.Equal(t, label("node2"), "0-10Gi")
+	assert.DeepEqual(t, published(), FreeCapacity{
+		Bands:       []FreeCapacityBand{{Band: "0-10Gi", Nodes: 1}, {Band: "10Gi-20Gi", Nodes: 1}, {Band: "over-20Gi"}},
+		LargestBand: "10Gi-20Gi",
+	})
+}
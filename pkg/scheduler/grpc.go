@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/api/extenderpb"
+)
+
+// extenderServer serves the gRPC variant of the extender protocol, with the same logic and caches
+// as the HTTP extender.
+type extenderServer struct {
+	extenderpb.UnimplementedExtenderServer
+	s *Scheduler
+}
+
+// RegisterExtenderServer registers the gRPC extender service to server.
+func (s *Scheduler) RegisterExtenderServer(server grpc.ServiceRegistrar) {
+	extenderpb.RegisterExtenderServer(server, &extenderServer{s: s})
+}
+
+func (e *extenderServer) Filter(_ context.Context, in *extenderpb.FilterArgs) (*extenderpb.FilterResult, error) {
+	if e.s.ShuttingDown() {
+		return nil, status.Error(codes.Unavailable, ErrShuttingDown.Error())
+	}
+	args, err := in.ToExtender()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if args.Pod == nil || args.NodeNames == nil {
+		return nil, status.Error(codes.InvalidArgument, "pod and node names are required")
+	}
+	result, err := e.s.Filter(args)
+	if err != nil {
+		klog.ErrorS(err, "Filter error for pod", "pod", klog.KObj(args.Pod))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res, err := extenderpb.FilterResultFromExtender(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return res, nil
+}
+
+func (e *extenderServer) Bind(_ context.Context, in *extenderpb.BindArgs) (*extenderpb.BindResult, error) {
+	if e.s.ShuttingDown() {
+		return nil, status.Error(codes.Unavailable, ErrShuttingDown.Error())
+	}
+	args := in.ToExtender()
+	result, err := e.s.Bind(args)
+	if err != nil {
+		klog.ErrorS(err, "Bind error for pod", "pod", args.PodName, "namespace", args.PodNamespace)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return extenderpb.BindResultFromExtender(result), nil
+}
+
+func (e *extenderServer) Preempt(_ context.Context, in *extenderpb.PreemptArgs) (*extenderpb.PreemptResult, error) {
+	args, err := in.ToExtender()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if args.Pod == nil {
+		return nil, status.Error(codes.InvalidArgument, "pod is required")
+	}
+	result, err := e.s.Preempt(args)
+	if err != nil {
+		klog.ErrorS(err, "Preempt error for pod", "pod", klog.KObj(args.Pod))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return extenderpb.PreemptResultFromExtender(result), nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/v3/assert"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/api/extenderpb"
+	extenderclient "github.com/Project-HAMi/HAMi/pkg/scheduler/client"
+)
+
+// extenderTransport is a way tests call the filter of the extender.
+type extenderTransport struct {
+	name   string
+	filter func(extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error)
+}
+
+// extenderTransports returns the direct call of s and a shim serving the HTTP extender protocol
+// over the gRPC extender of s, so the extender tests run against both servers.
+func extenderTransports(t *testing.T, s *Scheduler) []extenderTransport {
+	c := newGRPCClient(t, s)
+	shim := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var args extenderv1.ExtenderArgs
+		var result *extenderv1.ExtenderFilterResult
+		err := json.NewDecoder(r.Body).Decode(&args)
+		if err == nil {
+			result, err = c.Filter(r.Context(), args)
+		}
+		if err != nil {
+			result = &extenderv1.ExtenderFilterResult{Error: status.Convert(err).Message()}
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(shim.Close)
+
+	return []extenderTransport{
+		{name: "direct", filter: s.Filter},
+		{name: "grpc", filter: func(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error) {
+			body, err := json.Marshal(args)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := http.Post(shim.URL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			var result extenderv1.ExtenderFilterResult
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				return nil, err
+			}
+			if result.Error != "" {
+				return nil, errors.New(result.Error)
+			}
+			return &result, nil
+		}},
+	}
+}
+
+// newGRPCConn serves the gRPC extender of s in memory and returns a connection to it.
+func newGRPCConn(t *testing.T, s *Scheduler) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	s.RegisterExtenderServer(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NilError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newGRPCClient(t *testing.T, s *Scheduler) *extenderclient.Client {
+	return extenderclient.New(newGRPCConn(t, s))
+}
+
+func Test_extenderServer_InvalidArgs(t *testing.T) {
+	conn := newGRPCConn(t, NewScheduler())
+	c := extenderclient.New(conn)
+	_, err := c.Filter(context.Background(), extenderv1.ExtenderArgs{})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	_, err = c.Preempt(context.Background(), extenderv1.ExtenderPreemptionArgs{})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	_, err = extenderpb.NewExtenderClient(conn).Filter(context.Background(), &extenderpb.FilterArgs{Pod: []byte{0xff}, NodeNames: &extenderpb.NodeNames{}})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
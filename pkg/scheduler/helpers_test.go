@@ -0,0 +1,105 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// saved returns a func setting v back to its value now.
+func saved[T any](v *T) func() {
+	old := *v
+	return func() { *v = old }
+}
+
+// restoreConfig sets the config globals the tests change back to their values now when the test ends. It must be
+// called before the test changes them.
+func restoreConfig(t testing.TB) {
+	t.Helper()
+	for _, restore := range []func(){
+		saved(&config.AcceleratorVendors),
+		saved(&config.AllocationLeaseDuration),
+		saved(&config.AllowUnmanagedWholeDevices),
+		saved(&config.AllowedRuntimeClasses),
+		saved(&config.BindFailureCooldown),
+		saved(&config.ContainerRuntime),
+		saved(&config.ContainerRuntimeAffinity),
+		saved(&config.DefaultGPURequestCores),
+		saved(&config.DefaultGPURequestLabels),
+		saved(&config.DefaultGPURequestMemory),
+		saved(&config.DefragmentMaxEvictions),
+		saved(&config.DefragmentMaxPriority),
+		saved(&config.DeviceResidencyWarning),
+		saved(&config.EvictExpiredDeviceResidency),
+		saved(&config.EvictOrphanedAssignments),
+		saved(&config.ExtenderDeadline),
+		saved(&config.FinishedPodAnnotationRetention),
+		saved(&config.ForceOverwriteDefaultScheduler),
+		saved(&config.FreeCapacityConfigMap),
+		saved(&config.GPUModelPolicies),
+		saved(&config.ImageAllowlist),
+		saved(&config.KueueCapacityConfigMap),
+		saved(&config.ManageNodeLabels),
+		saved(&config.MaxDeviceResidency),
+		saved(&config.MaxMemoryPerAllocation),
+		saved(&config.MissingDeviceExclusion),
+		saved(&config.NamespaceBudgets),
+		saved(&config.NodeGroupTemplates),
+		saved(&config.NodeSchedulerPolicy),
+		saved(&config.OrphanedAssignmentGracePeriod),
+		saved(&config.OwnedEnvPolicy),
+		saved(&config.ReservationTTL),
+		saved(&config.RightSizingThreshold),
+		saved(&config.RightSizingWindow),
+		saved(&config.SchedulerName),
+		saved(&config.SetRequestsToLimits),
+		saved(&config.StaleRegistrationAge),
+		saved(&config.SuggestedPlacementMode),
+		saved(&config.UtilizationProfileConfigMap),
+		saved(&config.VolcanoSchedulerName),
+		saved(&config.VolumeLocality),
+		saved(&config.WebhookExcludeOwnerKinds),
+		saved(&config.WebhookIncludeOwnerKinds),
+	} {
+		t.Cleanup(restore)
+	}
+}
+
+// initTestDevices initializes the devices with the NVIDIA GPUs of the hami.io resources the tests request, and
+// those configure adds, and restores the config globals when the test ends.
+func initTestDevices(t testing.TB, configure ...func(*config.Config)) {
+	t.Helper()
+	restoreConfig(t)
+	sConfig := &config.Config{
+		NvidiaConfig: nvidia.NvidiaConfig{
+			ResourceCountName:            "hami.io/gpu",
+			ResourceMemoryName:           "hami.io/gpumem",
+			ResourceMemoryPercentageName: "hami.io/gpumem-percentage",
+			ResourceCoreName:             "hami.io/gpucores",
+			DefaultGPUNum:                1,
+		},
+	}
+	for _, c := range configure {
+		c(sConfig)
+	}
+	assert.NilError(t, config.InitDevicesWithConfig(sConfig))
+}
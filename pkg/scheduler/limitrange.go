@@ -0,0 +1,185 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+// LimitRangeLister returns the lister of LimitRanges the webhook enforces on device resources, nil if
+// config.EnforceLimitRanges is off.
+func (s *Scheduler) LimitRangeLister() listerscorev1.LimitRangeLister {
+	return s.limitRangeLister
+}
+
+// namespaceLimitRanges returns the LimitRanges of the namespace, none if they aren't enforced.
+func (h *webhook) namespaceLimitRanges(namespace string) ([]*corev1.LimitRange, error) {
+	if h.limitRanges == nil {
+		return nil, nil
+	}
+	return h.limitRanges.LimitRanges(namespace).List(labels.Everything())
+}
+
+// vendorResourceNames returns the resource names of the devices of every vendor.
+func vendorResourceNames() [][]corev1.ResourceName {
+	res := make([][]corev1.ResourceName, 0, len(device.GetDevices()))
+	for _, dev := range device.GetDevices() {
+		names := make([]corev1.ResourceName, 0, 3)
+		rn := dev.GetResourceNames()
+		for _, name := range []string{rn.ResourceCountName, rn.ResourceMemoryName, rn.ResourceCoreName} {
+			if name != "" {
+				names = append(names, corev1.ResourceName(name))
+			}
+		}
+		res = append(res, names)
+	}
+	return res
+}
+
+// applyLimitRangeDefaults sets the device resources the containers of the pod omit to the defaults of the
+// Container limits of ranges, for the vendors whose devices the containers request. Containers not requesting
+// devices of a vendor don't get its defaults, so a default device count doesn't give every container devices.
+func applyLimitRangeDefaults(pod *corev1.Pod, ranges []*corev1.LimitRange, warnings *device.AdmissionWarnings) {
+	if len(ranges) == 0 {
+		return
+	}
+	vendors := vendorResourceNames()
+	for idx := range pod.Spec.Containers {
+		ctr := &pod.Spec.Containers[idx]
+		for _, names := range vendors {
+			if !requestsAnyResource(ctr, names) {
+				continue
+			}
+			for _, name := range names {
+				if _, ok := ctr.Resources.Limits[name]; ok {
+					continue
+				}
+				if _, ok := ctr.Resources.Requests[name]; ok {
+					continue
+				}
+				if def, ok := limitRangeDefault(ranges, name); ok {
+					if ctr.Resources.Limits == nil {
+						ctr.Resources.Limits = make(corev1.ResourceList)
+					}
+					ctr.Resources.Limits[name] = def
+					warnings.Defaulted(ctr.Name, string(name), def.Value())
+				}
+			}
+		}
+	}
+}
+
+func requestsAnyResource(ctr *corev1.Container, names []corev1.ResourceName) bool {
+	for _, name := range names {
+		if _, ok := ctr.Resources.Limits[name]; ok {
+			return true
+		}
+		if _, ok := ctr.Resources.Requests[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// limitRangeDefault returns the default of the resource in the first Container limit of ranges defaulting it,
+// the default request if it has no default limit, since device resources are requested by limits.
+func limitRangeDefault(ranges []*corev1.LimitRange, name corev1.ResourceName) (resource.Quantity, bool) {
+	for _, lr := range ranges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			if def, ok := item.Default[name]; ok {
+				return def, true
+			}
+			if def, ok := item.DefaultRequest[name]; ok {
+				return def, true
+			}
+		}
+	}
+	return resource.Quantity{}, false
+}
+
+// checkLimitRanges fails with device.ErrInvalidRequest if a device resource of a container, or the sum of the
+// containers of the pod, is out of the min and max of the Container and Pod limits of ranges. Resources within
+// nearLimitPercent of the max are warned.
+func checkLimitRanges(pod *corev1.Pod, ranges []*corev1.LimitRange, warnings *device.AdmissionWarnings) error {
+	if len(ranges) == 0 {
+		return nil
+	}
+	managed := make([]corev1.ResourceName, 0)
+	for _, names := range vendorResourceNames() {
+		managed = append(managed, names...)
+	}
+	slices.Sort(managed)
+	podTotal := make(corev1.ResourceList)
+	for _, ctr := range pod.Spec.Containers {
+		for _, name := range managed {
+			q, ok := ctr.Resources.Limits[name]
+			if !ok {
+				if q, ok = ctr.Resources.Requests[name]; !ok {
+					continue
+				}
+			}
+			total := podTotal[name]
+			total.Add(q)
+			podTotal[name] = total
+			if err := checkLimitRangeItems(ranges, corev1.LimitTypeContainer, name, q, "container "+ctr.Name, warnings); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range managed {
+		q, ok := podTotal[name]
+		if !ok {
+			continue
+		}
+		if err := checkLimitRangeItems(ranges, corev1.LimitTypePod, name, q, "the pod", warnings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkLimitRangeItems(ranges []*corev1.LimitRange, limitType corev1.LimitType, name corev1.ResourceName, q resource.Quantity, of string, warnings *device.AdmissionWarnings) error {
+	for _, lr := range ranges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != limitType {
+				continue
+			}
+			if lo, ok := item.Min[name]; ok && q.Cmp(lo) < 0 {
+				return device.InvalidRequestErrorf("minimum %s usage per %s is %s, but the limit of %s is %s, see LimitRange %s",
+					name, limitType, lo.String(), of, q.String(), lr.Name)
+			}
+			if hi, ok := item.Max[name]; ok && q.Cmp(hi) > 0 {
+				return device.InvalidRequestErrorf("maximum %s usage per %s is %s, but the limit of %s is %s, see LimitRange %s",
+					name, limitType, hi.String(), of, q.String(), lr.Name)
+			} else if ok && q.MilliValue()*100 >= hi.MilliValue()*nearLimitPercent {
+				warnings.Addf("the %s limit of %s is %s, close to the maximum of %s per %s, see LimitRange %s",
+					name, of, q.String(), hi.String(), limitType, lr.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func TestHandleLimitRanges(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NilError(t, indexer.Add(&corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu-limits", Namespace: "team-a"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{
+			{
+				Type:    corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{"hami.io/gpumem": resource.MustParse("4000"), "hami.io/gpucores": resource.MustParse("30")},
+				Min:     corev1.ResourceList{"hami.io/gpucores": resource.MustParse("10")},
+				Max:     corev1.ResourceList{"hami.io/gpumem": resource.MustParse("8000")},
+			},
+			{
+				Type: corev1.LimitTypePod,
+				Max:  corev1.ResourceList{"hami.io/gpu": resource.MustParse("2")},
+			},
+		}},
+	}))
+	h := &webhook{
+		decoder:     admission.NewDecoder(clientgoscheme.Scheme),
+		limitRanges: listerscorev1.NewLimitRangeLister(indexer),
+	}
+
+	container := func(name string, limits map[corev1.ResourceName]string) corev1.Container {
+		ctr := corev1.Container{Name: name, Image: "cuda"}
+		if len(limits) != 0 {
+			ctr.Resources.Limits = make(corev1.ResourceList)
+			for k, v := range limits {
+				ctr.Resources.Limits[k] = resource.MustParse(v)
+			}
+		}
+		return ctr
+	}
+	tests := []struct {
+		name       string
+		namespace  string
+		containers []corev1.Container
+		// Limits added to the first container
+		defaulted map[string]string
+		warnings  []string
+		denied    string
+	}{
+		{
+			name:       "defaults applied to the omitted device resources",
+			namespace:  "team-a",
+			containers: []corev1.Container{container("train", map[corev1.ResourceName]string{"hami.io/gpu": "1"})},
+			defaulted:  map[string]string{"hami.io~1gpumem": "4k", "hami.io~1gpucores": "30"},
+			warnings: []string{
+				"container train: hami.io/gpumem is not set, defaulted to 4000",
+				"container train: hami.io/gpucores is not set, defaulted to 30",
+			},
+		},
+		{
+			name:       "close to the max of a container and of the pod",
+			namespace:  "team-a",
+			containers: []corev1.Container{container("train", map[corev1.ResourceName]string{"hami.io/gpu": "2", "hami.io/gpumem": "7500"})},
+			defaulted:  map[string]string{"hami.io~1gpucores": "30"},
+			warnings: []string{
+				"container train: hami.io/gpucores is not set, defaulted to 30",
+				"the hami.io/gpumem limit of container train is 7500, close to the maximum of 8k per Container, see LimitRange gpu-limits",
+				"the hami.io/gpu limit of the pod is 2, close to the maximum of 2 per Pod, see LimitRange gpu-limits",
+			},
+		},
+		{
+			name:      "set device resources kept",
+			namespace: "team-a",
+			containers: []corev1.Container{container("train", map[corev1.ResourceName]string{
+				"hami.io/gpu": "1", "hami.io/gpumem": "6000", "hami.io/gpucores": "50",
+			})},
+			defaulted: map[string]string{},
+		},
+		{
+			name:       "no defaults for containers not requesting devices",
+			namespace:  "team-a",
+			containers: []corev1.Container{container("sidecar", nil)},
+			defaulted:  map[string]string{},
+		},
+		{
+			name:       "max of a container",
+			namespace:  "team-a",
+			containers: []corev1.Container{container("train", map[corev1.ResourceName]string{"hami.io/gpu": "1", "hami.io/gpumem": "16000"})},
+			denied:     "maximum hami.io/gpumem usage per Container is 8k, but the limit of container train is 16k, see LimitRange gpu-limits",
+		},
+		{
+			name:       "min of a container",
+			namespace:  "team-a",
+			containers: []corev1.Container{container("train", map[corev1.ResourceName]string{"hami.io/gpu": "1", "hami.io/gpucores": "5"})},
+			denied:     "minimum hami.io/gpucores usage per Container is 10, but the limit of container train is 5, see LimitRange gpu-limits",
+		},
+		{
+			name:      "max of the pod",
+			namespace: "team-a",
+			containers: []corev1.Container{
+				container("train", map[corev1.ResourceName]string{"hami.io/gpu": "2"}),
+				container("eval", map[corev1.ResourceName]string{"hami.io/gpu": "1"}),
+			},
+			denied: "maximum hami.io/gpu usage per Pod is 2, but the limit of the pod is 3, see LimitRange gpu-limits",
+		},
+		{
+			name:       "namespace without LimitRanges",
+			namespace:  "team-b",
+			containers: []corev1.Container{container("train", map[corev1.ResourceName]string{"hami.io/gpu": "1", "hami.io/gpumem": "16000"})},
+			defaulted:  map[string]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: test.namespace},
+				Spec:       corev1.PodSpec{Containers: test.containers},
+			}
+			raw, err := json.Marshal(pod)
+			assert.NilError(t, err)
+			resp := h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "req-uid",
+				Operation: admissionv1.Create,
+				Namespace: test.namespace,
+				Name:      "pod",
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			if test.denied != "" {
+				assert.Assert(t, !resp.Allowed)
+				assert.Equal(t, resp.Result.Message, test.denied)
+				return
+			}
+			assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+			defaulted := make(map[string]string)
+			for _, patch := range resp.Patches {
+				if name, ok := strings.CutPrefix(patch.Path, "/spec/containers/0/resources/limits/"); ok {
+					defaulted[name] = patch.Value.(string)
+				}
+			}
+			assert.DeepEqual(t, defaulted, test.defaulted)
+			assert.DeepEqual(t, resp.Warnings, test.warnings)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// EventReasonAllocationDeviceMissing indicates a pod is rescheduled because the device plugin didn't find the
+// devices assigned to it on its node.
+const EventReasonAllocationDeviceMissing = "AllocationDeviceMissing"
+
+// missingDevices tracks the devices the device plugin reported gone from their node, e.g. fallen off the bus,
+// so they're excluded for config.MissingDeviceExclusion instead of being assigned again before the node
+// registers its devices anew.
+type missingDevices struct {
+	mutex sync.Mutex
+	// Time devices by UUID were reported missing, by node name
+	since map[string]map[string]time.Time
+}
+
+// isMissingDevice reports whether the device of the node was reported missing within config.MissingDeviceExclusion.
+func (s *Scheduler) isMissingDevice(nodeID, uuid string) bool {
+	s.missingDevices.mutex.Lock()
+	defer s.missingDevices.mutex.Unlock()
+	t, ok := s.missingDevices.since[nodeID][uuid]
+	return ok && s.clock.Now().Sub(t) < config.MissingDeviceExclusion
+}
+
+// excludeMissingDevices records the devices of the node are missing now.
+func (s *Scheduler) excludeMissingDevices(nodeID string, uuids []string) {
+	if config.MissingDeviceExclusion <= 0 {
+		return
+	}
+	now := s.clock.Now()
+	s.missingDevices.mutex.Lock()
+	defer s.missingDevices.mutex.Unlock()
+	if s.missingDevices.since == nil {
+		s.missingDevices.since = make(map[string]map[string]time.Time)
+	}
+	// Devices not reported again are dropped here once their exclusion is over.
+	for name, devices := range s.missingDevices.since {
+		for uuid, t := range devices {
+			if now.Sub(t) >= config.MissingDeviceExclusion {
+				delete(devices, uuid)
+			}
+		}
+		if len(devices) == 0 {
+			delete(s.missingDevices.since, name)
+		}
+	}
+	if s.missingDevices.since[nodeID] == nil {
+		s.missingDevices.since[nodeID] = make(map[string]time.Time)
+	}
+	for _, uuid := range uuids {
+		// MIG instances are assigned as <device UUID>[<template>-<instance>].
+		s.missingDevices.since[nodeID][strings.Split(uuid, "[")[0]] = now
+	}
+}
+
+// handleMissingDevices reschedules a pod the device plugin failed to allocate because its assigned devices are
+// gone from the node. The devices are excluded, the assignment of the pod is removed and the pod is deleted for
+// its controller to recreate it. Bare pods can't be unbound from their node, an event asks to recreate them.
+func (s *Scheduler) handleMissingDevices(pod *corev1.Pod, nodeID string) {
+	missing := strings.Split(pod.Annotations[util.DeviceMissingAnnotations], ",")
+	klog.InfoS("Devices assigned to pod are missing on its node, rescheduling it",
+		"pod", klog.KObj(pod), "nodeID", nodeID, "devices", missing)
+	s.excludeMissingDevices(nodeID, missing)
+	s.settleReservation(pod)
+	s.quotaManager.RmUsage(pod)
+	s.podManager.DelPod(pod)
+
+	if err := s.clearAssignment(pod); err != nil && !apierrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to remove the device assignment of pod", "pod", klog.KObj(pod))
+		return
+	}
+	if metav1.GetControllerOf(pod) == nil {
+		s.recordMissingDevices(pod, fmt.Sprintf("Devices %v assigned to the pod are gone from node %s, recreate the pod to schedule it again", missing, nodeID))
+		return
+	}
+	s.recordMissingDevices(pod, fmt.Sprintf("Deleting pod assigned devices %v gone from node %s for its controller to recreate it", missing, nodeID))
+	err := s.kubeClient.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &pod.UID},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to delete pod assigned missing devices", "pod", klog.KObj(pod), "devices", missing)
+		return
+	}
+	klog.InfoS("Deleted pod assigned missing devices", "pod", klog.KObj(pod), "nodeID", nodeID, "devices", missing)
+}
+
+func (s *Scheduler) recordMissingDevices(pod *corev1.Pod, msg string) {
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(pod, corev1.EventTypeWarning, EventReasonAllocationDeviceMissing, msg)
+	}
+}
+
+// clearAssignment removes the annotations and label assigning the pod to its node and devices, so it's no longer
+// accounted to them and the missing devices aren't handled again.
+func (s *Scheduler) clearAssignment(pod *corev1.Pod) error {
+	return util.PatchAnnotationsWithRetry(context.Background(), s.kubeClient, util.PodRef(pod.Namespace, pod.Name), func(meta *metav1.ObjectMeta) error {
+		for _, key := range []string{util.AssignedNodeAnnotations, util.AssignedTimeAnnotations, util.BindTimeAnnotations, util.DeviceBindPhase, util.DeviceMissingAnnotations} {
+			delete(meta.Annotations, key)
+		}
+		for _, key := range device.InRequestDevices {
+			delete(meta.Annotations, key)
+		}
+		for _, key := range device.SupportDevices {
+			delete(meta.Annotations, key)
+		}
+		delete(meta.Labels, util.AssignedNodeAnnotations)
+		return nil
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_handleMissingDevices(t *testing.T) {
+	restoreConfig(t)
+	config.MissingDeviceExclusion = 10 * time.Minute
+
+	newPod := func(name string, owners ...metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				UID:             k8stypes.UID(name),
+				OwnerReferences: owners,
+				Labels:          map[string]string{util.AssignedNodeAnnotations: "node1"},
+				Annotations: map[string]string{
+					util.AssignedNodeAnnotations: "node1",
+					util.DeviceBindPhase:         util.DeviceBindFailed,
+				},
+			},
+			Spec: corev1.PodSpec{NodeName: "node1"},
+		}
+	}
+	owned := newPod("owned", metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", UID: "rs", Controller: ptr.To(true)})
+	bare := newPod("bare")
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = fake.NewSimpleClientset(owned, bare)
+	clock := testingclock.NewFakeClock(time.Now())
+	s.clock = clock
+
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "GPU-0", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			{ID: "GPU-1", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+		}},
+	})
+	devices := device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+		{UUID: "GPU-1", Type: nvidia.NvidiaGPUDevice, Usedmem: 4000, Usedcores: 40},
+	}}}
+	s.podManager.AddPod(owned, "node1", devices)
+	s.podManager.AddPod(bare, "node1", devices)
+
+	health := func() map[string]bool {
+		nodes := []string{"node1"}
+		usage, _, err := s.getNodesUsage(&nodes, nil)
+		assert.NilError(t, err)
+		res := make(map[string]bool)
+		for _, d := range (*usage)["node1"].Devices.DeviceLists {
+			res[d.Device.ID] = d.Device.Health
+		}
+		return res
+	}
+	getPod := func(pod *corev1.Pod) *corev1.Pod {
+		res, err := s.kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		assert.NilError(t, err)
+		return res
+	}
+
+	// The plugin found GPU-1 fell off the bus when allocating the owned pod.
+	owned.Annotations[util.DeviceMissingAnnotations] = "GPU-1"
+	s.onUpdatePod(nil, owned)
+	assert.Assert(t, getPod(owned) == nil)
+	_, ok := s.podManager.GetPod(owned)
+	assert.Assert(t, !ok)
+	assert.DeepEqual(t, health(), map[string]bool{"GPU-0": true, "GPU-1": false})
+
+	// Bare pods keep their node but lose their assignment.
+	bare.Annotations[util.DeviceMissingAnnotations] = "GPU-1"
+	s.onUpdatePod(nil, bare)
+	updated := getPod(bare)
+	assert.Assert(t, updated != nil)
+	_, ok = updated.Annotations[util.AssignedNodeAnnotations]
+	assert.Assert(t, !ok)
+	_, ok = updated.Annotations[util.DeviceMissingAnnotations]
+	assert.Assert(t, !ok)
+	_, ok = updated.Labels[util.AssignedNodeAnnotations]
+	assert.Assert(t, !ok)
+	_, ok = s.podManager.GetPod(bare)
+	assert.Assert(t, !ok)
+
+	// Excluded until the exclusion is over.
+	clock.Step(config.MissingDeviceExclusion - time.Second)
+	assert.DeepEqual(t, health(), map[string]bool{"GPU-0": true, "GPU-1": false})
+	clock.Step(time.Second)
+	assert.DeepEqual(t, health(), map[string]bool{"GPU-0": true, "GPU-1": true})
+}
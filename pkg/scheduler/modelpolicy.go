@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// requestedGPUModel returns the GPU model the pod requests by nvidia.GPUInUse, which is set for the model tried
+// with a GPU model fallback, empty unless it requests exactly one.
+func requestedGPUModel(pod *corev1.Pod) string {
+	if pod == nil {
+		return ""
+	}
+	model := strings.TrimSpace(pod.Annotations[nvidia.GPUInUse])
+	if strings.Contains(model, ",") {
+		return ""
+	}
+	return model
+}
+
+// nodeSchedulerPolicyOf returns the node scheduler policy of the pod: the one of its annotation, otherwise the
+// one of the GPU model it requests, otherwise the default one.
+func nodeSchedulerPolicyOf(cfg *config.Reloadable, pod *corev1.Pod) string {
+	if value, ok := pod.GetAnnotations()[policy.NodeSchedulerPolicyAnnotationKey]; ok {
+		return value
+	}
+	if p := cfg.ModelPolicy(requestedGPUModel(pod)).NodeSchedulerPolicy; p != "" {
+		return p
+	}
+	return cfg.NodeSchedulerPolicy
+}
+
+// gpuSchedulerPolicyOf returns the GPU scheduler policy of the pod on the node: the one of its annotation,
+// otherwise the one of the GPU model it requests, or else of the first device of the node whose model has one,
+// otherwise the default one.
+func gpuSchedulerPolicyOf(cfg *config.Reloadable, pod *corev1.Pod, node *device.NodeInfo) string {
+	defaultPolicy := cfg.ModelPolicy(requestedGPUModel(pod)).GPUSchedulerPolicy
+	if defaultPolicy == "" && len(cfg.GPUModelPolicies) != 0 {
+	devices:
+		for _, vendor := range slices.Sorted(maps.Keys(node.Devices)) {
+			for _, d := range node.Devices[vendor] {
+				if defaultPolicy = cfg.ModelPolicy(d.Type).GPUSchedulerPolicy; defaultPolicy != "" {
+					break devices
+				}
+			}
+		}
+	}
+	if defaultPolicy == "" {
+		defaultPolicy = device.GPUSchedulerPolicy
+	}
+	return util.GetGPUSchedulerPolicyByPod(defaultPolicy, pod)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Filter_GPUModelPolicies(t *testing.T) {
+	initTestDevices(t)
+	policies, nodePolicy, gpuPolicy := config.GPUModelPolicies, config.NodeSchedulerPolicy, device.GPUSchedulerPolicy
+	t.Cleanup(func() {
+		config.GPUModelPolicies, config.NodeSchedulerPolicy, device.GPUSchedulerPolicy = policies, nodePolicy, gpuPolicy
+	})
+	config.NodeSchedulerPolicy = util.NodeSchedulerPolicyBinpack.String()
+	device.GPUSchedulerPolicy = util.GPUSchedulerPolicySpread.String()
+	// Free A100s are kept by binpacking them, T4s are spread to reduce the contention.
+	config.GPUModelPolicies = []config.GPUModelPolicy{
+		{Model: "A100", NodeSchedulerPolicy: "binpack", GPUSchedulerPolicy: "binpack"},
+		{Model: "T4", NodeSchedulerPolicy: "spread", GPUSchedulerPolicy: "spread"},
+	}
+
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	for _, n := range []struct{ name, model string }{
+		{name: "a100-1", model: "NVIDIA-NVIDIA A100-SXM4-40GB"},
+		{name: "a100-2", model: "NVIDIA-NVIDIA A100-SXM4-40GB"},
+		{name: "t4-1", model: "NVIDIA-Tesla T4"},
+		{name: "t4-2", model: "NVIDIA-Tesla T4"},
+	} {
+		gpus := make([]device.DeviceInfo, 0, 2)
+		for i := range 2 {
+			gpus = append(gpus, device.DeviceInfo{
+				ID: fmt.Sprintf("%s-%d", n.name, i), Index: uint(i), Count: 10, Devmem: 16000, Devcore: 100,
+				Type: n.model, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+			})
+		}
+		s.addNode(n.name, &device.NodeInfo{
+			ID:      n.name,
+			Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: n.name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: gpus},
+		})
+	}
+	for i, used := range []struct {
+		node  string
+		uuids []string
+		mem   int32
+	}{
+		{node: "a100-1", uuids: []string{"a100-1-0"}, mem: 4000},
+		{node: "t4-1", uuids: []string{"t4-1-0"}, mem: 4000},
+		{node: "t4-2", uuids: []string{"t4-2-0", "t4-2-1"}, mem: 8000},
+	} {
+		ctrdevs := device.ContainerDevices{}
+		for _, uuid := range used.uuids {
+			ctrdevs = append(ctrdevs, device.ContainerDevice{UUID: uuid, Type: nvidia.NvidiaGPUDevice, Usedmem: used.mem})
+		}
+		s.podManager.AddPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("used-%d", i), Namespace: "default", UID: types.UID(fmt.Sprintf("used-%d", i))}},
+			used.node, device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{ctrdevs}})
+	}
+
+	nodeNames := []string{"a100-1", "a100-2", "t4-1", "t4-2"}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantNode    string
+		wantDevice  string
+	}{
+		{
+			name:        "A100 binpacks onto the used node and GPU",
+			annotations: map[string]string{nvidia.GPUInUse: "A100"},
+			wantNode:    "a100-1",
+			wantDevice:  "a100-1-0",
+		},
+		{
+			name:        "T4 spreads onto the least used node and GPU",
+			annotations: map[string]string{nvidia.GPUInUse: "T4"},
+			wantNode:    "t4-1",
+			wantDevice:  "t4-1-1",
+		},
+		{
+			name:        "model of a GPU model fallback",
+			annotations: map[string]string{util.GPUModelFallbackAnnotationKey: "H100>T4"},
+			wantNode:    "t4-1",
+			wantDevice:  "t4-1-1",
+		},
+		{
+			name:        "annotations override the policies of the model",
+			annotations: map[string]string{nvidia.GPUInUse: "T4", util.NodeSchedulerPolicyAnnotationKey: "binpack", util.GPUSchedulerPolicyAnnotationKey: "binpack"},
+			wantNode:    "t4-2",
+			wantDevice:  "t4-2-1",
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("model-%d", i), Namespace: "default", UID: types.UID(fmt.Sprintf("model-%d", i)),
+					Annotations: test.annotations,
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "gpu",
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+						"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+						"hami.io/gpumem": *resource.NewQuantity(4000, resource.BinarySI),
+					}},
+				}}},
+			}
+			_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			assert.NilError(t, err)
+
+			got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got.NodeNames, &[]string{test.wantNode})
+			info, ok := s.podManager.GetPod(pod)
+			assert.Assert(t, ok)
+			assert.Equal(t, info.Devices[nvidia.NvidiaGPUDevice][0][0].UUID, test.wantDevice)
+			s.podManager.DelPod(pod)
+		})
+	}
+}
+
+func Test_Filter_GPUModelCost(t *testing.T) {
+	initTestDevices(t)
+	policies, gpuPolicy := config.GPUModelPolicies, device.GPUSchedulerPolicy
+	t.Cleanup(func() {
+		config.GPUModelPolicies, device.GPUSchedulerPolicy = policies, gpuPolicy
+	})
+
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	gpus := make([]device.DeviceInfo, 0, 4)
+	for i, g := range []struct {
+		id, model string
+		mem       int32
+	}{
+		{id: "a100-0", model: "NVIDIA-NVIDIA A100-SXM4-80GB", mem: 80000},
+		{id: "a100-1", model: "NVIDIA-NVIDIA A100-SXM4-80GB", mem: 80000},
+		{id: "a30-0", model: "NVIDIA-NVIDIA A30", mem: 24000},
+		{id: "a30-1", model: "NVIDIA-NVIDIA A30", mem: 24000},
+	} {
+		gpus = append(gpus, device.DeviceInfo{
+			ID: g.id, Index: uint(i), Count: 10, Devmem: g.mem, Devcore: 100, Type: g.model, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+		})
+	}
+	s.addNode("mixed", &device.NodeInfo{
+		ID:      "mixed",
+		Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "mixed"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: gpus},
+	})
+	// Binpacking prefers the used A100 and spreading the free one, unless A100s cost more.
+	for i, used := range []struct {
+		uuid string
+		mem  int32
+	}{{uuid: "a100-0", mem: 20000}, {uuid: "a30-0", mem: 2000}} {
+		s.podManager.AddPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("used-%d", i), Namespace: "default", UID: types.UID(fmt.Sprintf("used-%d", i))}},
+			"mixed", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: used.uuid, Type: nvidia.NvidiaGPUDevice, Usedmem: used.mem}}}})
+	}
+
+	nodeNames := []string{"mixed"}
+	costs := []config.GPUModelPolicy{{Model: "A100", Cost: 4}, {Model: "A30", Cost: 1}}
+	tests := []struct {
+		name        string
+		policy      string
+		costs       []config.GPUModelPolicy
+		annotations map[string]string
+		wantDevice  string
+	}{
+		{name: "binpack without costs", policy: "binpack", wantDevice: "a100-0"},
+		{name: "spread without costs", policy: "spread", wantDevice: "a100-1"},
+		{name: "binpack onto the cheaper A30s", policy: "binpack", costs: costs, wantDevice: "a30-0"},
+		{name: "spread onto the cheaper A30s", policy: "spread", costs: costs, wantDevice: "a30-1"},
+		{
+			name:        "use-gputype forces the A100s",
+			policy:      "binpack",
+			costs:       costs,
+			annotations: map[string]string{nvidia.GPUInUse: "A100"},
+			wantDevice:  "a100-0",
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.GPUModelPolicies, device.GPUSchedulerPolicy = test.costs, test.policy
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("cost-%d", i), Namespace: "default", UID: types.UID(fmt.Sprintf("cost-%d", i)),
+					Annotations: test.annotations,
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "gpu",
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+						"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+						"hami.io/gpumem": *resource.NewQuantity(4000, resource.BinarySI),
+					}},
+				}}},
+			}
+			_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			assert.NilError(t, err)
+
+			got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got.NodeNames, &nodeNames)
+			info, ok := s.podManager.GetPod(pod)
+			assert.Assert(t, ok)
+			assert.Equal(t, info.Devices[nvidia.NvidiaGPUDevice][0][0].UUID, test.wantDevice)
+			s.podManager.DelPod(pod)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	// DeviceTypeLabelPrefix prefixes the labels of the device types registered on a node,
+	// e.g. hami.io/devicetype.nvidia-a100-sxm4-40gb=true.
+	DeviceTypeLabelPrefix = "hami.io/devicetype."
+	// VGPUModeLabel is the label of the mode devices of a node are registered in, e.g. hami-core or mig,
+	// "mixed" if they are registered in different modes.
+	VGPUModeLabel = "hami.io/vgpu-mode"
+
+	mixedVGPUMode = "mixed"
+	// maxLabelNameLength is the maximum length of the name part of a label key.
+	maxLabelNameLength = 63
+)
+
+// isManagedNodeLabel reports whether the label is owned by the scheduler. Labels of node-feature-discovery
+// or vendor feature discovery use other prefixes, so they are never touched.
+func isManagedNodeLabel(key string) bool {
+	return strings.HasPrefix(key, DeviceTypeLabelPrefix) || key == VGPUModeLabel
+}
+
+// deviceTypeLabel returns the label key of a device type, the type sanitized into a valid label name.
+func deviceTypeLabel(vendor, deviceType string) string {
+	vendor = sanitizeLabelName(vendor)
+	name := sanitizeLabelName(deviceType)
+	// Types usually start with the vendor already, e.g. NVIDIA A100-SXM4-40GB.
+	if !strings.HasPrefix(name, vendor) {
+		name = vendor + "-" + name
+	}
+	key := DeviceTypeLabelPrefix + name
+	// The name part of the key follows the domain prefix.
+	if prefix := strings.Index(key, "/") + 1; len(key)-prefix > maxLabelNameLength {
+		key = strings.TrimRight(key[:prefix+maxLabelNameLength], "-._")
+	}
+	return key
+}
+
+// sanitizeLabelName lower cases s and turns every run of characters not allowed in label names into a dash.
+func sanitizeLabelName(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '_' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.Trim(b.String(), "-._")
+}
+
+// desiredNodeLabels returns the managed labels of a node from the devices registered on it.
+func (s *Scheduler) desiredNodeLabels(nodeName string) map[string]string {
+	res := make(map[string]string)
+	node, err := s.GetNode(nodeName)
+	if err != nil {
+		return res
+	}
+	mode := ""
+	for vendor, devices := range node.Devices {
+		for _, d := range devices {
+			if d.Type != "" {
+				res[deviceTypeLabel(vendor, d.Type)] = "true"
+			}
+			switch {
+			case d.Mode == "" || d.Mode == mode:
+			case mode == "":
+				mode = d.Mode
+			default:
+				mode = mixedVGPUMode
+			}
+		}
+	}
+	if mode != "" {
+		res[VGPUModeLabel] = sanitizeLabelName(mode)
+	}
+	return res
+}
+
+// syncNodeLabels reconciles the managed labels of the node with its registered devices, adding labels of new
+// device types and modes and removing stale ones. The node is only patched when its labels differ, by
+// util.PatchAnnotationsWithRetry so the labels written concurrently by other owners aren't overwritten.
+func (s *Scheduler) syncNodeLabels(node *corev1.Node) {
+	if !config.ManageNodeLabels {
+		return
+	}
+	desired := s.desiredNodeLabels(node.Name)
+	if nodeLabelsSynced(node.Labels, desired) {
+		return
+	}
+	err := util.PatchAnnotationsWithRetry(context.Background(), s.kubeClient, util.NodeRef(node.Name), func(meta *metav1.ObjectMeta) error {
+		if meta.Labels == nil {
+			meta.Labels = make(map[string]string, len(desired))
+		}
+		for k := range meta.Labels {
+			if _, ok := desired[k]; !ok && isManagedNodeLabel(k) {
+				delete(meta.Labels, k)
+			}
+		}
+		maps.Copy(meta.Labels, desired)
+		return nil
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to patch node labels", "nodeName", node.Name)
+		return
+	}
+	klog.V(4).InfoS("Patched node labels", "nodeName", node.Name, "labels", desired)
+}
+
+// nodeLabelsSynced returns whether the managed labels of current are the desired ones.
+func nodeLabelsSynced(current, desired map[string]string) bool {
+	for k, v := range desired {
+		if current[k] != v {
+			return false
+		}
+	}
+	for k := range current {
+		if _, ok := desired[k]; !ok && isManagedNodeLabel(k) {
+			return false
+		}
+	}
+	return true
+}
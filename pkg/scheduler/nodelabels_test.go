@@ -0,0 +1,111 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func Test_deviceTypeLabel(t *testing.T) {
+	tests := []struct {
+		vendor     string
+		deviceType string
+		want       string
+	}{
+		{vendor: "NVIDIA", deviceType: "NVIDIA A100-SXM4-40GB", want: "hami.io/devicetype.nvidia-a100-sxm4-40gb"},
+		{vendor: "NVIDIA", deviceType: "NVIDIA-Tesla T4", want: "hami.io/devicetype.nvidia-tesla-t4"},
+		{vendor: "NVIDIA", deviceType: "NVIDIA", want: "hami.io/devicetype.nvidia"},
+		{vendor: "MLU", deviceType: "MLU370-X8", want: "hami.io/devicetype.mlu370-x8"},
+		{vendor: "Ascend", deviceType: "910B3", want: "hami.io/devicetype.ascend-910b3"},
+		{vendor: "NVIDIA", deviceType: " NVIDIA (H100) 80GB HBM3 ", want: "hami.io/devicetype.nvidia-h100-80gb-hbm3"},
+		{vendor: "NVIDIA", deviceType: "NVIDIA " + strings.Repeat("X", 60) + "-Y", want: "hami.io/devicetype.nvidia-" + strings.Repeat("x", 63-len("devicetype.nvidia-"))},
+	}
+	for _, test := range tests {
+		t.Run(test.deviceType, func(t *testing.T) {
+			assert.Equal(t, deviceTypeLabel(test.vendor, test.deviceType), test.want)
+		})
+	}
+}
+
+func Test_syncNodeLabels(t *testing.T) {
+	restoreConfig(t)
+	config.ManageNodeLabels = true
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{
+		"feature.node.kubernetes.io/pci-10de.present": "true",
+		"nvidia.com/gpu.product":                      "Tesla-T4",
+		"hami.io/devicetype.nvidia-tesla-t4":          "true",
+	}}}
+	kubeClient := fake.NewSimpleClientset(node)
+	s := NewScheduler()
+	s.kubeClient = kubeClient
+	register := func(devices ...device.DeviceInfo) {
+		s.addNode("node1", &device.NodeInfo{ID: "node1", Node: node, Devices: map[string][]device.DeviceInfo{"NVIDIA": devices}})
+	}
+	sync := func() map[string]string {
+		current, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+		assert.NilError(t, err)
+		s.syncNodeLabels(current)
+		current, err = kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+		assert.NilError(t, err)
+		return current.Labels
+	}
+
+	// The T4 was replaced by A100s, the stale label is removed and labels of other owners are kept.
+	register(
+		device.DeviceInfo{ID: "GPU-0", Type: "NVIDIA A100-SXM4-40GB", Mode: "hami-core"},
+		device.DeviceInfo{ID: "GPU-1", Type: "NVIDIA A100-SXM4-40GB", Mode: "hami-core"},
+	)
+	assert.DeepEqual(t, sync(), map[string]string{
+		"feature.node.kubernetes.io/pci-10de.present": "true",
+		"nvidia.com/gpu.product":                      "Tesla-T4",
+		"hami.io/devicetype.nvidia-a100-sxm4-40gb":    "true",
+		VGPUModeLabel: "hami-core",
+	})
+
+	// Nothing is patched when the labels are up to date.
+	actions := len(kubeClient.Actions())
+	sync()
+	assert.Equal(t, len(kubeClient.Actions()), actions+2)
+
+	register(
+		device.DeviceInfo{ID: "GPU-0", Type: "NVIDIA A100-SXM4-40GB", Mode: "hami-core"},
+		device.DeviceInfo{ID: "GPU-1", Type: "NVIDIA A100-SXM4-40GB", Mode: "mig"},
+	)
+	assert.Equal(t, sync()[VGPUModeLabel], "mixed")
+
+	// All managed labels are removed once no devices are registered.
+	s.rmNode("node1")
+	assert.DeepEqual(t, sync(), map[string]string{
+		"feature.node.kubernetes.io/pci-10de.present": "true",
+		"nvidia.com/gpu.product":                      "Tesla-T4",
+	})
+
+	// Labels are left alone if management is disabled.
+	config.ManageNodeLabels = false
+	register(device.DeviceInfo{ID: "GPU-0", Type: "NVIDIA A100-SXM4-40GB", Mode: "hami-core"})
+	assert.Equal(t, len(sync()), 2)
+}
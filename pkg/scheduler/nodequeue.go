@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// nodeQueue is the set of nodes whose devices must be synced on the next registration pass.
+// A node changed several times between two passes is only synced once.
+type nodeQueue struct {
+	mutex  sync.Mutex
+	nodes  map[string]struct{}
+	timers map[string]*time.Timer
+	notify func()
+}
+
+func newNodeQueue(notify func()) *nodeQueue {
+	return &nodeQueue{
+		nodes:  make(map[string]struct{}),
+		timers: make(map[string]*time.Timer),
+		notify: notify,
+	}
+}
+
+func (q *nodeQueue) add(nodeName string) {
+	q.mutex.Lock()
+	q.nodes[nodeName] = struct{}{}
+	q.mutex.Unlock()
+	q.notify()
+}
+
+// addAfter adds the node after d, replacing an earlier delayed add of the node.
+func (q *nodeQueue) addAfter(nodeName string, d time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if t, ok := q.timers[nodeName]; ok {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		q.mutex.Lock()
+		if q.timers[nodeName] == t {
+			delete(q.timers, nodeName)
+		}
+		q.mutex.Unlock()
+		q.add(nodeName)
+	})
+	q.timers[nodeName] = t
+}
+
+// forget drops the delayed add of a node which is gone.
+func (q *nodeQueue) forget(nodeName string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if t, ok := q.timers[nodeName]; ok {
+		t.Stop()
+		delete(q.timers, nodeName)
+	}
+}
+
+// drain returns the queued nodes and empties the queue.
+func (q *nodeQueue) drain() []string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	nodeNames := make([]string, 0, len(q.nodes))
+	for name := range q.nodes {
+		nodeNames = append(nodeNames, name)
+	}
+	clear(q.nodes)
+	return nodeNames
+}
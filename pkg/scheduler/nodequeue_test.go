@@ -0,0 +1,286 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/amd"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_nodeQueue(t *testing.T) {
+	notified := 0
+	q := newNodeQueue(func() { notified++ })
+
+	q.add("node1")
+	q.add("node2")
+	q.add("node1")
+	assert.Equal(t, notified, 3)
+	nodes := q.drain()
+	slices.Sort(nodes)
+	assert.DeepEqual(t, nodes, []string{"node1", "node2"})
+	assert.Equal(t, len(q.drain()), 0)
+
+	// A later delayed add replaces the earlier one.
+	added := make(chan struct{}, 2)
+	q.notify = func() { added <- struct{}{} }
+	q.addAfter("node1", time.Hour)
+	q.addAfter("node1", time.Millisecond)
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("expected delayed add")
+	}
+	assert.DeepEqual(t, q.drain(), []string{"node1"})
+
+	// A forgotten node is not added.
+	q.addAfter("node2", 10*time.Millisecond)
+	q.forget("node2")
+	select {
+	case <-added:
+		t.Fatal("unexpected add of forgotten node")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, len(q.drain()), 0)
+}
+
+func newAMDNode(name string, gpus int) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"gpu": "on"}},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{"amd.com/gpu": *resource.NewQuantity(int64(gpus), resource.DecimalSI)},
+		},
+	}
+}
+
+// newNodeSyncScheduler returns a scheduler listing nodes from the returned indexer.
+func newNodeSyncScheduler(t testing.TB) (*Scheduler, cache.Indexer) {
+	initTestDevices(t, func(c *config.Config) {
+		c.AMDGPUConfig = amd.AMDConfig{ResourceCountName: "amd.com/gpu"}
+	})
+	s := NewScheduler()
+	s.kubeClient = fake.NewSimpleClientset()
+	informer := informers.NewSharedInformerFactory(s.kubeClient, 0).Core().V1().Nodes()
+	s.nodeLister = informer.Lister()
+	return s, informer.Informer().GetIndexer()
+}
+
+func registeredDevices(s *Scheduler, nodeName string) int {
+	n, err := s.GetNode(nodeName)
+	if err != nil {
+		return -1
+	}
+	return len(n.Devices[amd.AMDCommonWord])
+}
+
+func Test_syncQueuedNodes(t *testing.T) {
+	s, indexer := newNodeSyncScheduler(t)
+	selector := labels.Set{"gpu": "on"}.AsSelector()
+	printedLog := map[string]bool{}
+
+	node1, node2 := newAMDNode("node1", 2), newAMDNode("node2", 4)
+	assert.NilError(t, indexer.Add(node1))
+	assert.NilError(t, indexer.Add(node2))
+	// A node registered before but no longer in the cluster.
+	s.addNode("stale", &device.NodeInfo{ID: "stale", Node: newAMDNode("stale", 1), Devices: map[string][]device.DeviceInfo{amd.AMDCommonWord: {{ID: "stale-0"}}}})
+	assert.NilError(t, s.resyncNodes(selector, printedLog))
+	assert.Equal(t, registeredDevices(s, "node1"), 2)
+	assert.Equal(t, registeredDevices(s, "node2"), 4)
+	assert.Equal(t, registeredDevices(s, "stale"), -1)
+
+	// A status heartbeat is ignored.
+	heartbeat := node1.DeepCopy()
+	heartbeat.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	s.onUpdateNode(node1, heartbeat)
+	assert.Equal(t, len(s.nodeQueue.drain()), 0)
+
+	// Only the changed node is synced.
+	updated := newAMDNode("node1", 3)
+	assert.NilError(t, indexer.Update(updated))
+	s.onUpdateNode(node1, updated)
+	s.syncQueuedNodes(selector, printedLog)
+	assert.Equal(t, registeredDevices(s, "node1"), 3)
+	assert.Equal(t, registeredDevices(s, "node2"), 4)
+
+	// A deleted node is removed.
+	assert.NilError(t, indexer.Delete(node2))
+	s.onDelNode(node2)
+	s.syncQueuedNodes(selector, printedLog)
+	assert.Equal(t, registeredDevices(s, "node2"), -1)
+
+	// A node no longer matching the selector is removed.
+	unselected := updated.DeepCopy()
+	unselected.Labels = nil
+	assert.NilError(t, indexer.Update(unselected))
+	s.onUpdateNode(updated, unselected)
+	s.syncQueuedNodes(selector, printedLog)
+	assert.Equal(t, registeredDevices(s, "node1"), -1)
+
+	// A node added back is registered again.
+	assert.NilError(t, indexer.Add(node2))
+	s.onAddNode(node2)
+	s.syncQueuedNodes(selector, printedLog)
+	assert.Equal(t, registeredDevices(s, "node2"), 4)
+}
+
+func Test_syncNode_HandshakeRecheck(t *testing.T) {
+	s, _ := newNodeSyncScheduler(t)
+	added := make(chan struct{}, 1)
+	s.nodeQueue.notify = func() { added <- struct{}{} }
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: map[string]string{
+		nvidia.RegisterAnnos: device.MarshalNodeDevices([]*device.DeviceInfo{{ID: "GPU-0", Count: 10, Devmem: 16000, Devcore: 100, Type: "NVIDIA", Health: true}}),
+		// Not answered by the device plugin yet, the handshake request times out in 2 seconds.
+		util.HandshakeAnnos[nvidia.NvidiaGPUDevice]: "Requesting_" + util.FormatHandshakeTime(time.Now().Add(2*time.Second-device.HandshakeTimeout-device.HandshakeClockTolerance)),
+	}}}
+	s.syncNode(node, map[string]bool{})
+	select {
+	case <-added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the node to be synced again when the handshake times out")
+	}
+	assert.DeepEqual(t, s.nodeQueue.drain(), []string{"node1"})
+}
+
+// Test_syncQueuedNodes_PodChurn checks node usage stays consistent with the pods when
+// pods come and go while nodes are synced.
+func Test_syncQueuedNodes_PodChurn(t *testing.T) {
+	s, indexer := newNodeSyncScheduler(t)
+	selector := labels.Everything()
+	printedLog := map[string]bool{}
+	nodes := make([]*corev1.Node, 4)
+	for i := range nodes {
+		nodes[i] = newAMDNode(fmt.Sprintf("node%d", i), 2)
+		assert.NilError(t, indexer.Add(nodes[i]))
+	}
+	assert.NilError(t, s.resyncNodes(selector, printedLog))
+
+	newPod := func(i int) *corev1.Pod {
+		nodeName := fmt.Sprintf("node%d", i%len(nodes))
+		devices := device.PodSingleDevice{{{UUID: fmt.Sprintf("%s-%s-%d", nodeName, amd.AMDDevice, i/len(nodes)%2), Type: amd.AMDDevice, Usedmem: 1000, Usedcores: 10}}}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod%d", i),
+				Namespace: "default",
+				UID:       types.UID(fmt.Sprintf("uid%d", i)),
+				Annotations: map[string]string{
+					util.AssignedNodeAnnotations:         nodeName,
+					device.SupportDevices[amd.AMDDevice]: device.EncodePodSingleDevice(devices),
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 400 {
+			pod := newPod(i)
+			s.onAddPod(pod)
+			s.onUpdatePod(pod, pod)
+			// Half of the pods of every device finish again.
+			if i/len(nodes)/2%2 == 1 {
+				s.onDelPod(pod)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			node := nodes[i%len(nodes)]
+			s.onUpdateNode(node, node)
+			s.nodeQueue.add(node.Name)
+			s.syncQueuedNodes(selector, printedLog)
+			nodeNames := s.nodeIDs()
+			_, _, err := s.getNodesUsage(&nodeNames, nil)
+			assert.NilError(t, err)
+		}
+	}()
+	wg.Wait()
+
+	nodeNames := s.nodeIDs()
+	usage, _, err := s.getNodesUsage(&nodeNames, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(*usage), len(nodes))
+	for _, n := range *usage {
+		for _, d := range n.Devices.DeviceLists {
+			// 200 running pods on 4 nodes with 2 devices each.
+			assert.Equal(t, d.Device.Used, int32(25), "device %s", d.Device.ID)
+			assert.Equal(t, d.Device.Usedmem, int32(25000), "device %s", d.Device.ID)
+			assert.Equal(t, d.Device.Usedcores, int32(250), "device %s", d.Device.ID)
+		}
+	}
+}
+
+// BenchmarkNodeSync compares a full rescan of a 500 node cluster, done every 15 seconds before,
+// with the incremental sync of the nodes changed since the last pass.
+func BenchmarkNodeSync(b *testing.B) {
+	const clusterSize, changedNodes = 500, 10
+	s, indexer := newNodeSyncScheduler(b)
+	selector := labels.Everything()
+	printedLog := map[string]bool{}
+	nodes := make([]*corev1.Node, clusterSize)
+	for i := range nodes {
+		nodes[i] = newAMDNode(fmt.Sprintf("node%d", i), 8)
+		if err := indexer.Add(nodes[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := s.resyncNodes(selector, printedLog); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("full-rescan", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := s.resyncNodes(selector, printedLog); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("incremental", func(b *testing.B) {
+		b.ReportAllocs()
+		i := 0
+		for b.Loop() {
+			for range changedNodes {
+				s.nodeQueue.add(nodes[i%clusterSize].Name)
+				i++
+			}
+			s.syncQueuedNodes(selector, printedLog)
+		}
+	})
+}
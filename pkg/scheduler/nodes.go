@@ -18,6 +18,8 @@ package scheduler
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
@@ -30,6 +32,8 @@ import (
 type NodeUsage struct {
 	Node    *corev1.Node
 	Devices policy.DeviceUsageList
+	// Orphaned are the devices assigned to pods on the node that aren't registered on it.
+	Orphaned []OrphanedDevice
 }
 
 type nodeManager struct {
@@ -49,19 +53,39 @@ func (m *nodeManager) addNode(nodeID string, nodeInfo *device.NodeInfo) {
 	}
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	_, ok := m.nodes[nodeID]
+	old, ok := m.nodes[nodeID]
 	if ok {
-		if len(nodeInfo.Devices) > 0 {
-			for vendor := range nodeInfo.Devices {
-				m.nodes[nodeID].Devices[vendor] = nodeInfo.Devices[vendor]
-			}
+		// Replace instead of modifying the registered NodeInfo, which may be in use by readers.
+		merged := &device.NodeInfo{
+			ID:      old.ID,
+			Node:    nodeInfo.Node,
+			Devices: maps.Clone(old.Devices),
 		}
-		m.nodes[nodeID].Node = nodeInfo.Node
+		maps.Copy(merged.Devices, nodeInfo.Devices)
+		m.nodes[nodeID] = merged
 	} else {
 		m.nodes[nodeID] = nodeInfo
 	}
 }
 
+// rmNode removes a node and all its devices.
+func (m *nodeManager) rmNode(nodeID string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.nodes[nodeID]; !ok {
+		return
+	}
+	delete(m.nodes, nodeID)
+	klog.InfoS("Removing node", "nodeName", nodeID)
+}
+
+// nodeIDs returns the IDs of registered nodes.
+func (m *nodeManager) nodeIDs() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return slices.Collect(maps.Keys(m.nodes))
+}
+
 func (m *nodeManager) rmNodeDevices(nodeID string, deviceVendor string) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -69,9 +93,12 @@ func (m *nodeManager) rmNodeDevices(nodeID string, deviceVendor string) {
 	if nodeInfo == nil {
 		return
 	}
-	delete(m.nodes[nodeID].Devices, deviceVendor)
-	if len(m.nodes[nodeID].Devices) == 0 {
+	devices := maps.Clone(nodeInfo.Devices)
+	delete(devices, deviceVendor)
+	if len(devices) == 0 {
 		delete(m.nodes, nodeID)
+	} else {
+		m.nodes[nodeID] = &device.NodeInfo{ID: nodeInfo.ID, Node: nodeInfo.Node, Devices: devices}
 	}
 	klog.InfoS("Removing device from node", "nodeName", nodeID, "deviceVendor", deviceVendor)
 }
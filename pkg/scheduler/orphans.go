@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// EventReasonOrphanedDeviceAssignment indicates a pod is assigned devices no longer registered on its node.
+const EventReasonOrphanedDeviceAssignment = "OrphanedDeviceAssignment"
+
+// OrphanedDevice is a device assigned to a pod that isn't registered on the pod's node, e.g. because the GPU
+// was replaced. Its usage isn't attributed to any device of the node.
+type OrphanedDevice struct {
+	Pod    *device.PodInfo
+	Device device.ContainerDevice
+}
+
+// orphans tracks since when pods are assigned devices not registered on their node.
+type orphans struct {
+	mutex sync.Mutex
+	since map[k8stypes.UID]time.Time
+}
+
+// handleOrphanedDevices tracks the pods assigned devices not registered on their node in usage, and deletes
+// those orphaned for config.OrphanedAssignmentGracePeriod if config.EvictOrphanedAssignments is set, so their
+// controller recreates them on devices that exist.
+func (s *Scheduler) handleOrphanedDevices(usage map[string]*NodeUsage) {
+	orphaned := make(map[k8stypes.UID]*OrphanedDevice)
+	for _, node := range usage {
+		for i, o := range node.Orphaned {
+			orphaned[o.Pod.UID] = &node.Orphaned[i]
+		}
+	}
+	now := s.clock.Now()
+	evict := make([]*OrphanedDevice, 0)
+	s.orphans.mutex.Lock()
+	for uid := range s.orphans.since {
+		if _, ok := orphaned[uid]; !ok {
+			delete(s.orphans.since, uid)
+		}
+	}
+	for uid, o := range orphaned {
+		since, ok := s.orphans.since[uid]
+		if !ok {
+			klog.InfoS("Pod assigned device not registered on its node, recreate it to schedule it again",
+				"pod", klog.KObj(o.Pod), "nodeID", o.Pod.NodeID, "deviceUUID", o.Device.UUID)
+			s.orphans.since[uid] = now
+			continue
+		}
+		if config.EvictOrphanedAssignments && now.Sub(since) >= config.OrphanedAssignmentGracePeriod {
+			evict = append(evict, o)
+		}
+	}
+	s.orphans.mutex.Unlock()
+
+	for _, o := range evict {
+		s.evictOrphanedPod(o)
+	}
+}
+
+func (s *Scheduler) evictOrphanedPod(o *OrphanedDevice) {
+	msg := fmt.Sprintf("Deleting pod assigned device %s no longer registered on node %s", o.Device.UUID, o.Pod.NodeID)
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(o.Pod.Pod, corev1.EventTypeWarning, EventReasonOrphanedDeviceAssignment, msg)
+	}
+	err := s.kubeClient.CoreV1().Pods(o.Pod.Namespace).Delete(context.Background(), o.Pod.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &o.Pod.UID},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to delete pod assigned device not registered on its node", "pod", klog.KObj(o.Pod), "deviceUUID", o.Device.UUID)
+		return
+	}
+	klog.InfoS("Deleted pod assigned device not registered on its node", "pod", klog.KObj(o.Pod), "nodeID", o.Pod.NodeID, "deviceUUID", o.Device.UUID)
+	s.orphans.mutex.Lock()
+	delete(s.orphans.since, o.Pod.UID)
+	s.orphans.mutex.Unlock()
+}
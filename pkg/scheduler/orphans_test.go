@@ -0,0 +1,111 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func Test_handleOrphanedDevices(t *testing.T) {
+	restoreConfig(t)
+	config.OrphanedAssignmentGracePeriod = 10 * time.Minute
+
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name)},
+			Spec:       corev1.PodSpec{NodeName: "node1"},
+		}
+	}
+	running, orphaned := newPod("running"), newPod("orphaned")
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = fake.NewSimpleClientset(running, orphaned)
+	clock := testingclock.NewFakeClock(time.Now())
+	s.clock = clock
+
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+			ID: "GPU-new", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+		}}},
+	})
+	s.podManager.AddPod(running, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+		{UUID: "GPU-new", Type: nvidia.NvidiaGPUDevice, Usedmem: 1000, Usedcores: 10},
+	}}})
+	// Assigned the GPU replaced by GPU-new.
+	s.podManager.AddPod(orphaned, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+		{UUID: "GPU-old", Type: nvidia.NvidiaGPUDevice, Usedmem: 4000, Usedcores: 40},
+	}}})
+
+	usage := func() map[string]*NodeUsage {
+		nodes := []string{"node1"}
+		res, _, err := s.getNodesUsage(&nodes, nil)
+		assert.NilError(t, err)
+		return *res
+	}
+	node := usage()["node1"]
+	assert.Equal(t, len(node.Devices.DeviceLists), 1)
+	dev := node.Devices.DeviceLists[0].Device
+	assert.Equal(t, dev.Used, int32(1))
+	assert.Equal(t, dev.Usedmem, int32(1000))
+	assert.Equal(t, len(node.Orphaned), 1)
+	assert.Equal(t, node.Orphaned[0].Pod.UID, orphaned.UID)
+	assert.Equal(t, node.Orphaned[0].Device.UUID, "GPU-old")
+
+	podExists := func(pod *corev1.Pod) bool {
+		_, err := s.kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		assert.NilError(t, err)
+		return true
+	}
+
+	// Orphaned pods are kept unless eviction is enabled.
+	s.handleOrphanedDevices(usage())
+	clock.Step(time.Hour)
+	s.handleOrphanedDevices(usage())
+	assert.Assert(t, podExists(orphaned))
+
+	// Evicted after the grace period.
+	config.EvictOrphanedAssignments = true
+	s.orphans.since = make(map[k8stypes.UID]time.Time)
+	s.handleOrphanedDevices(usage())
+	clock.Step(config.OrphanedAssignmentGracePeriod - time.Second)
+	s.handleOrphanedDevices(usage())
+	assert.Assert(t, podExists(orphaned))
+	clock.Step(time.Second)
+	s.handleOrphanedDevices(usage())
+	assert.Assert(t, !podExists(orphaned))
+	assert.Assert(t, podExists(running))
+	assert.Equal(t, len(s.orphans.since), 0)
+}
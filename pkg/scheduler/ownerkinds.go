@@ -0,0 +1,76 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NoOwnerKind is the pseudo kind of the owner of pods without owner references, e.g. bare pods.
+const NoOwnerKind = "None"
+
+// ownerKinds returns the kinds of the owners of the pod, from its controller reference up to the top-level owner
+// as far as the pod tells without API calls, NoOwnerKind if it has none. Pods of a ReplicaSet labeled with
+// the pod template hash are owned by a Deployment.
+func ownerKinds(pod *corev1.Pod) []string {
+	if len(pod.OwnerReferences) == 0 {
+		return []string{NoOwnerKind}
+	}
+	ref := pod.OwnerReferences[0]
+	for _, r := range pod.OwnerReferences {
+		if r.Controller != nil && *r.Controller {
+			ref = r
+			break
+		}
+	}
+	kinds := []string{ref.Kind}
+	if _, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok && ref.Kind == "ReplicaSet" {
+		kinds = append(kinds, "Deployment")
+	}
+	return kinds
+}
+
+// ownerKindExcluded returns the owner kind of the pod the webhook must leave the pod alone for, empty if it
+// handles the pod. An owner kind listed in exclude excludes the pod even if another one is listed in include,
+// pods are excluded if include is not empty and lists none of their owner kinds.
+func ownerKindExcluded(pod *corev1.Pod, include, exclude []string) (string, bool) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return "", false
+	}
+	listed := func(list []string, kind string) bool {
+		return slices.ContainsFunc(list, func(k string) bool { return strings.EqualFold(strings.TrimSpace(k), kind) })
+	}
+	kinds := ownerKinds(pod)
+	for _, kind := range kinds {
+		if listed(exclude, kind) {
+			return kind, true
+		}
+	}
+	if len(include) == 0 {
+		return "", false
+	}
+	for _, kind := range kinds {
+		if listed(include, kind) {
+			return "", false
+		}
+	}
+	return kinds[len(kinds)-1], true
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func TestHandleOwnerKinds(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	h := &webhook{decoder: admission.NewDecoder(clientgoscheme.Scheme)}
+
+	newPod := func(kind string, labels map[string]string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", Labels: labels},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "ctr",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+			}}},
+		}
+		if kind != "" {
+			pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: "owner", UID: "owner-uid", Controller: ptr.To(true)}}
+		}
+		return pod
+	}
+	// mutated reports whether the webhook mutated the pod, setting its schedulerName.
+	mutated := func(pod *corev1.Pod) bool {
+		raw, err := json.Marshal(pod)
+		assert.NilError(t, err)
+		resp := h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "req-uid",
+			Operation: admissionv1.Create,
+			Namespace: "default",
+			Name:      "pod",
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+		assert.Assert(t, resp.Allowed, "unexpected response %v", resp.Result)
+		for _, patch := range resp.Patches {
+			if patch.Path == "/spec/schedulerName" {
+				return true
+			}
+		}
+		return false
+	}
+	daemonSet := newPod("DaemonSet", nil)
+	bare := newPod("", nil)
+	job := newPod("Job", nil)
+	deployment := newPod("ReplicaSet", map[string]string{"pod-template-hash": "5d4f8b9c7"})
+	replicaSet := newPod("ReplicaSet", nil)
+
+	// Every pod is mutated without filters.
+	for _, pod := range []*corev1.Pod{daemonSet, bare, job, deployment} {
+		assert.Assert(t, mutated(pod))
+	}
+
+	// DaemonSet pods keep the default scheduler.
+	config.WebhookExcludeOwnerKinds = []string{"DaemonSet"}
+	assert.Assert(t, !mutated(daemonSet))
+	assert.Assert(t, mutated(bare))
+	assert.Assert(t, mutated(job))
+
+	// Only pods of Deployments and Jobs are mutated, bare pods and DaemonSet pods are not.
+	config.WebhookIncludeOwnerKinds = []string{"deployment", "Job"}
+	assert.Assert(t, mutated(deployment))
+	assert.Assert(t, mutated(job))
+	assert.Assert(t, !mutated(replicaSet))
+	assert.Assert(t, !mutated(bare))
+	assert.Assert(t, !mutated(daemonSet))
+
+	// Bare pods are matched by the None pseudo kind, exclusion takes precedence over inclusion.
+	config.WebhookIncludeOwnerKinds = []string{"None", "DaemonSet"}
+	assert.Assert(t, mutated(bare))
+	assert.Assert(t, !mutated(daemonSet))
+	config.WebhookExcludeOwnerKinds = []string{"None"}
+	assert.Assert(t, !mutated(bare))
+}
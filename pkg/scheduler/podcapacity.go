@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// maxCapacityReplicas is the largest maxReplicas of a capacity request.
+const maxCapacityReplicas = 1000
+
+// CapacityRequest is the request of the capacity endpoint.
+type CapacityRequest struct {
+	// Pod is the template of the replicas.
+	Pod *corev1.Pod `json:"pod"`
+	// NodeSelector restricts the candidates to the nodes with these labels, all the nodes with devices registered
+	// are candidates if empty.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// MaxReplicas is the number of replicas fitting after which the simulation stops, at most maxCapacityReplicas.
+	MaxReplicas int `json:"maxReplicas"`
+}
+
+// CapacityResponse is the response of the capacity endpoint.
+type CapacityResponse struct {
+	// Replicas is the number of replicas of the pod fitting the cluster.
+	Replicas int `json:"replicas"`
+	// Bounded is set when MaxReplicas replicas fit, more may fit.
+	Bounded bool `json:"bounded,omitempty"`
+	// Nodes are the replicas fitting each candidate node sorted by name.
+	Nodes []CapacityNode `json:"nodes"`
+	// Plan is the devices each replica would be allocated, in the order they're placed.
+	Plan         []PlannedReplica `json:"plan,omitempty"`
+	ErrorMessage string           `json:"errorMessage,omitempty"`
+}
+
+// CapacityNode is the number of replicas fitting a node.
+type CapacityNode struct {
+	Name     string `json:"name"`
+	Replicas int    `json:"replicas"`
+	// Reason is why no more replica fits the node, empty if the simulation was bounded before.
+	Reason string `json:"reason,omitempty"`
+}
+
+// PlannedReplica is the node and devices a replica would be allocated.
+type PlannedReplica struct {
+	Node    string            `json:"node"`
+	Devices device.PodDevices `json:"devices"`
+}
+
+// Capacity counts the replicas of the pod fitting the nodes with the filter logic, placing them one after the
+// other against a snapshot of the current usage, without allocating, binding or recording anything. In each
+// round a replica is placed on every node it still fits, the best scored nodes first, until no node fits or
+// MaxReplicas replicas are placed.
+func (s *Scheduler) Capacity(req CapacityRequest) CapacityResponse {
+	if req.Pod == nil {
+		return CapacityResponse{ErrorMessage: "capacity request has no pod"}
+	}
+	if req.MaxReplicas < 1 || req.MaxReplicas > maxCapacityReplicas {
+		return CapacityResponse{ErrorMessage: fmt.Sprintf("maxReplicas must be between 1 and %d", maxCapacityReplicas)}
+	}
+	if !podRequestsDevices(req.Pod) {
+		return CapacityResponse{ErrorMessage: "pod does not request any device"}
+	}
+	resourceReqs := device.Resourcereqs(req.Pod)
+	if util.IsShareGPUWithinPod(req.Pod) {
+		resourceReqs = device.MergeContainerRequests(resourceReqs)
+	}
+	nodeNames := s.nodeIDs()
+	// The usage is rebuilt from the cache, placing replicas only updates this snapshot.
+	usage, failedNodes, err := s.getNodesUsage(&nodeNames, req.Pod)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get node usage to compute capacity", "pod", klog.KObj(req.Pod))
+		return CapacityResponse{ErrorMessage: err.Error()}
+	}
+	selector := labels.SelectorFromSet(req.NodeSelector)
+	for nodeID, node := range *usage {
+		if node.Node == nil || !selector.Matches(labels.Set(node.Node.Labels)) {
+			delete(*usage, nodeID)
+		}
+	}
+	for nodeID := range failedNodes {
+		if node, err := s.GetNode(nodeID); err != nil || node.Node == nil || !selector.Matches(labels.Set(node.Node.Labels)) {
+			delete(failedNodes, nodeID)
+		}
+	}
+
+	res := CapacityResponse{Nodes: make([]CapacityNode, 0, len(*usage)+len(failedNodes))}
+	replicas := make(map[string]int, len(*usage))
+	reasons := make(map[string]string, len(*usage))
+	for len(*usage) != 0 && res.Replicas < req.MaxReplicas {
+		unfit := make(map[string]string)
+		scores, failureReason, err := s.scoreNodes(context.Background(), usage, resourceReqs, req.Pod, unfit)
+		if err != nil {
+			klog.ErrorS(err, "Failed to score nodes to compute capacity", "pod", klog.KObj(req.Pod))
+			return CapacityResponse{ErrorMessage: err.Error()}
+		}
+		for nodeID := range unfit {
+			reasons[nodeID] = unfitReason(nodeID, failureReason)
+			delete(*usage, nodeID)
+		}
+		scores.Sort()
+		for i := len(scores.NodeList) - 1; i >= 0 && res.Replicas < req.MaxReplicas; i-- {
+			score := scores.NodeList[i]
+			replicas[score.NodeID]++
+			res.Replicas++
+			res.Plan = append(res.Plan, PlannedReplica{Node: score.NodeID, Devices: score.Devices})
+		}
+	}
+	res.Bounded = res.Replicas == req.MaxReplicas
+
+	for nodeID := range *usage {
+		res.Nodes = append(res.Nodes, CapacityNode{Name: nodeID, Replicas: replicas[nodeID]})
+	}
+	for nodeID, reason := range reasons {
+		res.Nodes = append(res.Nodes, CapacityNode{Name: nodeID, Replicas: replicas[nodeID], Reason: reason})
+	}
+	for nodeID, reason := range failedNodes {
+		res.Nodes = append(res.Nodes, CapacityNode{Name: nodeID, Reason: reason})
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool { return res.Nodes[i].Name < res.Nodes[j].Name })
+	return res
+}
+
+// unfitReason returns the reasons the node is among the unfit nodes of, NodeUnfitPod if none.
+func unfitReason(nodeID string, failureReason map[string][]string) string {
+	res := make([]string, 0)
+	for reason, nodes := range failureReason {
+		for _, node := range nodes {
+			if node == nodeID {
+				res = append(res, reason)
+				break
+			}
+		}
+	}
+	if len(res) == 0 {
+		return common.NodeUnfitPod
+	}
+	sort.Strings(res)
+	return strings.Join(res, ",")
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Capacity(t *testing.T) {
+	initTestDevices(t)
+
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"pool": "a"}}}
+	node2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"pool": "a"}}}
+	node3 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node3", Labels: map[string]string{"pool": "b"}}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "infer", Namespace: "default"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "infer",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+				"hami.io/gpu":      *resource.NewQuantity(1, resource.DecimalSI),
+				"hami.io/gpumem":   *resource.NewQuantity(3000, resource.DecimalSI),
+				"hami.io/gpucores": *resource.NewQuantity(30, resource.DecimalSI),
+			}},
+		}}},
+	}
+	client.KubeClient = fake.NewSimpleClientset(node1, node2, node3)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	for _, n := range []struct {
+		node *corev1.Node
+		gpus int
+		mem  int32
+	}{{node1, 1, 8000}, {node2, 1, 2000}, {node3, 2, 16000}} {
+		devices := make([]device.DeviceInfo, 0, n.gpus)
+		for i := range n.gpus {
+			devices = append(devices, device.DeviceInfo{
+				ID: fmt.Sprintf("%s-GPU-%d", n.node.Name, i), Index: uint(i), Count: 10, Devmem: n.mem, Devcore: 100,
+				Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+			})
+		}
+		s.addNode(n.node.Name, &device.NodeInfo{ID: n.node.Name, Node: n.node, Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices}})
+	}
+
+	// node1 fits two replicas by memory, node2 none, node3 three on each GPU by cores.
+	res := s.Capacity(CapacityRequest{Pod: pod, MaxReplicas: 100})
+	assert.Equal(t, res.ErrorMessage, "")
+	assert.Equal(t, res.Replicas, 8)
+	assert.Assert(t, !res.Bounded)
+	assert.Equal(t, len(res.Nodes), 3)
+	assert.DeepEqual(t, res.Nodes[0], CapacityNode{Name: "node1", Replicas: 2, Reason: common.CardInsufficientMemory})
+	assert.Equal(t, res.Nodes[1].Replicas, 0)
+	assert.Assert(t, res.Nodes[1].Reason != "")
+	assert.DeepEqual(t, res.Nodes[2], CapacityNode{Name: "node3", Replicas: 6, Reason: common.CardInsufficientCore})
+	assert.Equal(t, len(res.Plan), 8)
+	perDevice := make(map[string]int32)
+	for _, replica := range res.Plan {
+		dev := replica.Devices[nvidia.NvidiaGPUDevice][0][0]
+		assert.Equal(t, dev.Usedmem, int32(3000))
+		perDevice[dev.UUID] += dev.Usedcores
+	}
+	assert.DeepEqual(t, perDevice, map[string]int32{"node1-GPU-0": 60, "node3-GPU-0": 90, "node3-GPU-1": 90})
+
+	// Nothing is allocated to the pod, so the capacity is the same again.
+	_, ok := s.podManager.GetPod(pod)
+	assert.Assert(t, !ok)
+	res = s.Capacity(CapacityRequest{Pod: pod, MaxReplicas: 100})
+	assert.Equal(t, res.Replicas, 8)
+
+	// The simulation stops at maxReplicas.
+	res = s.Capacity(CapacityRequest{Pod: pod, MaxReplicas: 3})
+	assert.Equal(t, res.Replicas, 3)
+	assert.Assert(t, res.Bounded)
+	assert.Equal(t, len(res.Plan), 3)
+	assert.Equal(t, res.Nodes[0].Reason, "")
+	assert.Equal(t, res.Nodes[2].Reason, "")
+
+	// Candidates are restricted to the nodes selected.
+	res = s.Capacity(CapacityRequest{Pod: pod, NodeSelector: map[string]string{"pool": "b"}, MaxReplicas: 100})
+	assert.Equal(t, res.Replicas, 6)
+	assert.DeepEqual(t, res.Nodes, []CapacityNode{{Name: "node3", Replicas: 6, Reason: common.CardInsufficientCore}})
+
+	res = s.Capacity(CapacityRequest{Pod: pod})
+	assert.Equal(t, res.ErrorMessage, "maxReplicas must be between 1 and 1000")
+	res = s.Capacity(CapacityRequest{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cpu", Namespace: "default"}}, MaxReplicas: 1})
+	assert.Equal(t, res.ErrorMessage, "pod does not request any device")
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package policy
 
 import (
+	"math/rand/v2"
+	"sort"
+
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 
@@ -27,6 +30,14 @@ type DeviceListsScore struct {
 	Device *device.DeviceUsage
 	// Score recode every device user/allocate score
 	Score float32
+	// Suggested devices, by hami.io/suggested-node-uuids, are fit first whatever their score.
+	Suggested bool
+	// Replicas of the pod using the device, devices used by fewer replicas are fit first whatever their score.
+	Replicas int
+	// Avoided devices, e.g. the ones replicas of the pod were evicted from, are fit last.
+	Avoided bool
+	// Cost of the device type, cheaper devices are fit first whatever their score.
+	Cost float64
 }
 
 type DeviceUsageList struct {
@@ -43,19 +54,59 @@ func (l DeviceUsageList) Swap(i, j int) {
 }
 
 func (l DeviceUsageList) Less(i, j int) bool {
+	if l.DeviceLists[i].Suggested != l.DeviceLists[j].Suggested {
+		return l.DeviceLists[j].Suggested
+	}
+	if l.DeviceLists[i].Avoided != l.DeviceLists[j].Avoided {
+		return l.DeviceLists[i].Avoided
+	}
+	if l.DeviceLists[i].Replicas != l.DeviceLists[j].Replicas {
+		return l.DeviceLists[i].Replicas > l.DeviceLists[j].Replicas
+	}
+	if l.DeviceLists[i].Cost != l.DeviceLists[j].Cost {
+		return l.DeviceLists[i].Cost > l.DeviceLists[j].Cost
+	}
+	if l.DeviceLists[i].Device.Numa == l.DeviceLists[j].Device.Numa && l.DeviceLists[i].Score == l.DeviceLists[j].Score {
+		if ScoreJitter {
+			return false
+		}
+		// Equal devices are ordered by UUID.
+		return l.DeviceLists[i].Device.ID < l.DeviceLists[j].Device.ID
+	}
 	if l.Policy == util.GPUSchedulerPolicyBinpack.String() {
 		if l.DeviceLists[i].Device.Numa == l.DeviceLists[j].Device.Numa {
 			return l.DeviceLists[i].Score < l.DeviceLists[j].Score
 		}
 		return l.DeviceLists[i].Device.Numa > l.DeviceLists[j].Device.Numa
 	}
-	// default policy is spread
+	// default policy is spread, the utilization policy spreads too
 	if l.DeviceLists[i].Device.Numa == l.DeviceLists[j].Device.Numa {
 		return l.DeviceLists[i].Score > l.DeviceLists[j].Score
 	}
 	return l.DeviceLists[i].Device.Numa < l.DeviceLists[j].Device.Numa
 }
 
+// Sort sorts the devices in the order devices fit them, see ScoreJitter for the order of equal devices.
+func (l DeviceUsageList) Sort() {
+	if ScoreJitter {
+		rand.Shuffle(l.Len(), l.Swap)
+		sort.Stable(l)
+		return
+	}
+	sort.Sort(l)
+}
+
+// ComputeScore computes the score of every device, blended with the measured utilization
+// of the device for the utilization policy.
+func (l DeviceUsageList) ComputeScore(requests device.ContainerDeviceRequests) {
+	for _, ds := range l.DeviceLists {
+		ds.ComputeScore(requests)
+		if l.Policy == util.GPUSchedulerPolicyUtilization.String() {
+			ds.blendUtilization()
+		}
+	}
+}
+
 func (ds *DeviceListsScore) ComputeScore(requests device.ContainerDeviceRequests) {
 	request, core, mem := int32(0), int32(0), int32(0)
 	// Here we are required to use the same type device
@@ -179,6 +179,42 @@ func TestDeviceUsageList_Less(t *testing.T) {
 			},
 			expectedLess: true,
 		},
+		{
+			name:   "Costlier device sorted first whatever its score",
+			policy: "binpack",
+			deviceLists: []*DeviceListsScore{
+				{Device: &device.DeviceUsage{Numa: 0}, Score: 20, Cost: 4},
+				{Device: &device.DeviceUsage{Numa: 0}, Score: 10, Cost: 1},
+			},
+			expectedLess: true,
+		},
+		{
+			name:   "Suggested device sorted last whatever its score",
+			policy: "binpack",
+			deviceLists: []*DeviceListsScore{
+				{Device: &device.DeviceUsage{Numa: 0}, Score: 10, Suggested: true, Avoided: true},
+				{Device: &device.DeviceUsage{Numa: 0}, Score: 20},
+			},
+			expectedLess: false,
+		},
+		{
+			name:   "Equal scores, last device by UUID sorted last",
+			policy: "spread",
+			deviceLists: []*DeviceListsScore{
+				{Device: &device.DeviceUsage{ID: "GPU-b", Numa: 0}, Score: 10},
+				{Device: &device.DeviceUsage{ID: "GPU-a", Numa: 0}, Score: 10},
+			},
+			expectedLess: false,
+		},
+		{
+			name:   "Equal scores, first device by UUID sorted first",
+			policy: "binpack",
+			deviceLists: []*DeviceListsScore{
+				{Device: &device.DeviceUsage{ID: "GPU-a", Numa: 0}, Score: 10},
+				{Device: &device.DeviceUsage{ID: "GPU-b", Numa: 0}, Score: 10},
+			},
+			expectedLess: true,
+		},
 	}
 
 	for _, tt := range tests {
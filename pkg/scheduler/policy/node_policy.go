@@ -17,6 +17,9 @@ limitations under the License.
 package policy
 
 import (
+	"math/rand/v2"
+	"sort"
+
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 
@@ -24,12 +27,29 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// ScoreJitter orders nodes and devices of equal scores randomly instead of by node name and device UUID,
+// spreading pods over equal nodes and devices at the cost of reproducible placements.
+var ScoreJitter bool
+
 type NodeScore struct {
 	NodeID  string
 	Node    *corev1.Node
 	Devices device.PodDevices
 	// Score recode every node all device user/allocate score
 	Score float32
+	// Nodes the pod is placed on as suggested by hami.io/suggested-node-uuids are selected before others whatever
+	// their score.
+	Suggested bool
+	// Preferred nodes, e.g. local to the volumes of the pod, are selected before others whatever their score.
+	Preferred bool
+	// Nodes running the container runtime the pod prefers are selected before others, after the preferred nodes,
+	// whatever their score.
+	PreferredRuntime bool
+	// Deprioritized nodes, e.g. the pod just failed to bind to, are selected after others whatever their score.
+	Deprioritized bool
+	// Replicas of the pod the node is ranked by to spread them, nodes with fewer are selected first whatever
+	// their score.
+	Replicas int
 }
 
 type NodeScoreList struct {
@@ -46,6 +66,28 @@ func (l NodeScoreList) Swap(i, j int) {
 }
 
 func (l NodeScoreList) Less(i, j int) bool {
+	if l.NodeList[i].Suggested != l.NodeList[j].Suggested {
+		return l.NodeList[j].Suggested
+	}
+	if l.NodeList[i].Preferred != l.NodeList[j].Preferred {
+		return l.NodeList[j].Preferred
+	}
+	if l.NodeList[i].PreferredRuntime != l.NodeList[j].PreferredRuntime {
+		return l.NodeList[j].PreferredRuntime
+	}
+	if l.NodeList[i].Deprioritized != l.NodeList[j].Deprioritized {
+		return l.NodeList[i].Deprioritized
+	}
+	if l.NodeList[i].Replicas != l.NodeList[j].Replicas {
+		return l.NodeList[i].Replicas > l.NodeList[j].Replicas
+	}
+	if l.NodeList[i].Score == l.NodeList[j].Score {
+		if ScoreJitter {
+			return false
+		}
+		// Equal nodes are ordered by name, the last one is selected.
+		return l.NodeList[i].NodeID < l.NodeList[j].NodeID
+	}
 	if l.Policy == util.NodeSchedulerPolicySpread.String() {
 		return l.NodeList[i].Score > l.NodeList[j].Score
 	}
@@ -53,6 +95,16 @@ func (l NodeScoreList) Less(i, j int) bool {
 	return l.NodeList[i].Score < l.NodeList[j].Score
 }
 
+// Sort sorts the nodes so the selected node is the last one, see ScoreJitter for the order of equal nodes.
+func (l NodeScoreList) Sort() {
+	if ScoreJitter {
+		rand.Shuffle(l.Len(), l.Swap)
+		sort.Stable(l)
+		return
+	}
+	sort.Sort(l)
+}
+
 func (ns *NodeScore) OverrideScore(previous []*device.DeviceUsage, policy string) {
 	// current user having request resource
 	devScore := float32(0)
@@ -177,6 +177,97 @@ func TestLess(t *testing.T) {
 			j:        1,
 			expected: false,
 		},
+		{
+			name: "Preferred node, i score higher",
+			nodeScoreList: NodeScoreList{
+				NodeList: []*NodeScore{
+					{NodeID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, Score: 20.0},
+					{NodeID: "node2", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}, Score: 10.0, Preferred: true},
+				},
+				Policy: "binpack",
+			},
+			i:        0,
+			j:        1,
+			expected: true,
+		},
+		{
+			name: "Preferred node, j score higher",
+			nodeScoreList: NodeScoreList{
+				NodeList: []*NodeScore{
+					{NodeID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, Score: 10.0, Preferred: true},
+					{NodeID: "node2", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}, Score: 20.0},
+				},
+				Policy: "spread",
+			},
+			i:        0,
+			j:        1,
+			expected: false,
+		},
+		{
+			name: "Deprioritized node, i score higher",
+			nodeScoreList: NodeScoreList{
+				NodeList: []*NodeScore{
+					{NodeID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, Score: 20.0, Deprioritized: true},
+					{NodeID: "node2", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}, Score: 10.0},
+				},
+				Policy: "binpack",
+			},
+			i:        0,
+			j:        1,
+			expected: true,
+		},
+		{
+			name: "Preferred node before deprioritized",
+			nodeScoreList: NodeScoreList{
+				NodeList: []*NodeScore{
+					{NodeID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, Score: 10.0, Preferred: true, Deprioritized: true},
+					{NodeID: "node2", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}, Score: 20.0},
+				},
+				Policy: "binpack",
+			},
+			i:        0,
+			j:        1,
+			expected: false,
+		},
+		{
+			name: "Suggested node before preferred",
+			nodeScoreList: NodeScoreList{
+				NodeList: []*NodeScore{
+					{NodeID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, Score: 20.0, Preferred: true},
+					{NodeID: "node2", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}, Score: 10.0, Suggested: true},
+				},
+				Policy: "binpack",
+			},
+			i:        0,
+			j:        1,
+			expected: true,
+		},
+		{
+			name: "Equal scores, last node by name sorted last",
+			nodeScoreList: NodeScoreList{
+				NodeList: []*NodeScore{
+					{NodeID: "node2", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}, Score: 10.0},
+					{NodeID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, Score: 10.0},
+				},
+				Policy: "spread",
+			},
+			i:        0,
+			j:        1,
+			expected: false,
+		},
+		{
+			name: "Equal scores, first node by name sorted first",
+			nodeScoreList: NodeScoreList{
+				NodeList: []*NodeScore{
+					{NodeID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, Score: 10.0},
+					{NodeID: "node2", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}, Score: 10.0},
+				},
+				Policy: "binpack",
+			},
+			i:        0,
+			j:        1,
+			expected: true,
+		},
 	}
 
 	for _, test := range tests {
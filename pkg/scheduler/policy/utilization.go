@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"sync"
+	"time"
+)
+
+// utilizationDecay is the weight of a new measurement in the decayed average.
+const utilizationDecay = 0.3
+
+var (
+	// DeviceUtilization is the measured utilization of devices used by the utilization GPU policy,
+	// nil if no measurement is ingested.
+	DeviceUtilization *UtilizationStore
+	// UtilizationWeight is the weight of the measured utilization in the score of the utilization
+	// GPU policy, between 0 and 1, the rest is the weight of the allocation.
+	UtilizationWeight float32 = 0.5
+)
+
+type utilizationSample struct {
+	value   float64
+	updated time.Time
+}
+
+// UtilizationStore keeps a decayed average of the measured utilization of every device.
+type UtilizationStore struct {
+	mutex      sync.RWMutex
+	staleAfter time.Duration
+	samples    map[string]utilizationSample
+	now        func() time.Time
+}
+
+// NewUtilizationStore returns a store ignoring averages not updated for staleAfter.
+func NewUtilizationStore(staleAfter time.Duration) *UtilizationStore {
+	return &UtilizationStore{
+		staleAfter: staleAfter,
+		samples:    make(map[string]utilizationSample),
+		now:        time.Now,
+	}
+}
+
+// Observe adds a measurement of the device, value is the utilization between 0 and 1.
+func (s *UtilizationStore) Observe(uuid string, value float64) {
+	value = min(max(value, 0), 1)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	sample, ok := s.samples[uuid]
+	if ok && now.Sub(sample.updated) <= s.staleAfter {
+		value = sample.value + utilizationDecay*(value-sample.value)
+	}
+	s.samples[uuid] = utilizationSample{value: value, updated: now}
+}
+
+// Get returns the decayed average utilization of the device, false if it's missing or stale.
+func (s *UtilizationStore) Get(uuid string) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	sample, ok := s.samples[uuid]
+	if !ok || s.now().Sub(sample.updated) > s.staleAfter {
+		return 0, false
+	}
+	return sample.value, true
+}
+
+// Prune drops the devices not measured for staleAfter.
+func (s *UtilizationStore) Prune() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	for uuid, sample := range s.samples {
+		if now.Sub(sample.updated) > s.staleAfter {
+			delete(s.samples, uuid)
+		}
+	}
+}
+
+// blendUtilization blends the allocation score of the device with its measured utilization,
+// the allocation score is kept if the utilization is not measured.
+func (ds *DeviceListsScore) blendUtilization() {
+	utilization, ok := DeviceUtilization.Get(ds.Device.ID)
+	if !ok {
+		return
+	}
+	// The allocation score sums three ratios, scale the utilization the same way.
+	measured := float32(Weight) * 3 * float32(utilization)
+	ds.Score = (1-UtilizationWeight)*ds.Score + UtilizationWeight*measured
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func newTestStore(now *time.Time) *UtilizationStore {
+	s := NewUtilizationStore(time.Minute)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestUtilizationStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestStore(&now)
+
+	_, ok := s.Get("GPU-0")
+	assert.Assert(t, !ok)
+
+	s.Observe("GPU-0", 0.5)
+	got, _ := s.Get("GPU-0")
+	assert.Equal(t, got, 0.5)
+
+	// Later measurements are decayed into the average.
+	now = now.Add(30 * time.Second)
+	s.Observe("GPU-0", 1)
+	got, ok = s.Get("GPU-0")
+	assert.Assert(t, ok)
+	assert.Assert(t, got > 0.64 && got < 0.66, "got %v", got)
+
+	// Stale averages are ignored and restarted by the next measurement.
+	now = now.Add(2 * time.Minute)
+	_, ok = s.Get("GPU-0")
+	assert.Assert(t, !ok)
+	s.Observe("GPU-0", 0.2)
+	got, _ = s.Get("GPU-0")
+	assert.Equal(t, got, 0.2)
+
+	now = now.Add(2 * time.Minute)
+	s.Prune()
+	assert.Equal(t, len(s.samples), 0)
+
+	var nilStore *UtilizationStore
+	_, ok = nilStore.Get("GPU-0")
+	assert.Assert(t, !ok)
+}
+
+func TestDeviceUsageList_ComputeScore_Utilization(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	DeviceUtilization = newTestStore(&now)
+	t.Cleanup(func() { DeviceUtilization = nil })
+
+	newList := func(policy string) DeviceUsageList {
+		// GPU-0 has less allocated than GPU-1.
+		return DeviceUsageList{Policy: policy, DeviceLists: []*DeviceListsScore{
+			{Device: &device.DeviceUsage{ID: "GPU-0", Count: 10, Used: 1, Totalmem: 8000, Usedmem: 1000, Totalcore: 100, Usedcores: 10}},
+			{Device: &device.DeviceUsage{ID: "GPU-1", Count: 10, Used: 2, Totalmem: 8000, Usedmem: 2000, Totalcore: 100, Usedcores: 20}},
+		}}
+	}
+	requests := device.ContainerDeviceRequests{"NVIDIA": {Nums: 1, Type: "NVIDIA", Memreq: 1000, MemPercentagereq: 101, Coresreq: 10}}
+	// best returns the device picked by Fit, the last one after sorting.
+	best := func(l DeviceUsageList) string {
+		l.ComputeScore(requests)
+		sort.Sort(l)
+		return l.DeviceLists[len(l.DeviceLists)-1].Device.ID
+	}
+
+	// Without measurements the utilization policy scores like spread.
+	spread, utilization := newList(util.GPUSchedulerPolicySpread.String()), newList(util.GPUSchedulerPolicyUtilization.String())
+	assert.Equal(t, best(spread), "GPU-0")
+	assert.Equal(t, best(utilization), "GPU-0")
+	for i := range spread.DeviceLists {
+		assert.Equal(t, utilization.DeviceLists[i].Score, spread.DeviceLists[i].Score)
+	}
+
+	// The busy GPU-0 is avoided once measured.
+	DeviceUtilization.Observe("GPU-0", 0.9)
+	DeviceUtilization.Observe("GPU-1", 0.1)
+	assert.Equal(t, best(newList(util.GPUSchedulerPolicyUtilization.String())), "GPU-1")
+	assert.Equal(t, best(newList(util.GPUSchedulerPolicySpread.String())), "GPU-0")
+
+	// Stale measurements fall back to allocation only.
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, best(newList(util.GPUSchedulerPolicyUtilization.String())), "GPU-0")
+}
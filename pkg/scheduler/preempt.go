@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// Preempt keeps the candidate nodes of args whose devices fit the pod once the victims are evicted,
+// the victims of the kept nodes are returned unchanged.
+func (s *Scheduler) Preempt(args extenderv1.ExtenderPreemptionArgs) (*extenderv1.ExtenderPreemptionResult, error) {
+	victims := args.NodeNameToMetaVictims
+	if victims == nil {
+		victims = make(map[string]*extenderv1.MetaVictims, len(args.NodeNameToVictims))
+		for node, v := range args.NodeNameToVictims {
+			mv := &extenderv1.MetaVictims{NumPDBViolations: v.NumPDBViolations}
+			for _, p := range v.Pods {
+				mv.Pods = append(mv.Pods, &extenderv1.MetaPod{UID: string(p.UID)})
+			}
+			victims[node] = mv
+		}
+	}
+	res := &extenderv1.ExtenderPreemptionResult{NodeNameToMetaVictims: make(map[string]*extenderv1.MetaVictims)}
+	if !podRequestsDevices(args.Pod) {
+		res.NodeNameToMetaVictims = victims
+		return res, nil
+	}
+	resourceReqs := device.Resourcereqs(args.Pod)
+	if util.IsShareGPUWithinPod(args.Pod) {
+		resourceReqs = device.MergeContainerRequests(resourceReqs)
+	}
+	nodeNames := make([]string, 0, len(victims))
+	for node := range victims {
+		nodeNames = append(nodeNames, node)
+	}
+	nodeUsage, failedNodes, err := s.getNodesUsage(&nodeNames, args.Pod)
+	if err != nil {
+		return nil, err
+	}
+	s.releasePodUsage(*nodeUsage, args.Pod)
+	for _, v := range victims {
+		for _, p := range v.Pods {
+			s.releasePodUsage(*nodeUsage, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: types.UID(p.UID)}})
+		}
+	}
+	nodeScores, err := s.calcScore(context.Background(), nodeUsage, resourceReqs, args.Pod, failedNodes)
+	if err != nil {
+		return nil, fmt.Errorf("calcScore failed %v for pod %v", err, args.Pod.Name)
+	}
+	for _, score := range nodeScores.NodeList {
+		res.NodeNameToMetaVictims[score.NodeID] = victims[score.NodeID]
+	}
+	klog.V(4).InfoS("Preemption candidates filtered", "pod", klog.KObj(args.Pod), "candidates", len(victims), "fitNodes", len(res.NodeNameToMetaVictims))
+	return res, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func Test_Preempt(t *testing.T) {
+	s := NewScheduler()
+	initTestDevices(t)
+	// Both nodes have one device with 2000 MiB free, the preemptor needs 6000 MiB.
+	for _, node := range []string{"node1", "node2"} {
+		s.addNode(node, &device.NodeInfo{
+			ID:   node,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: node}},
+			Devices: map[string][]device.DeviceInfo{
+				nvidia.NvidiaGPUDevice: {{ID: node + "-gpu0", Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice}},
+			},
+		})
+	}
+	for _, p := range []struct {
+		uid  string
+		node string
+		mem  int32
+	}{
+		{uid: "uid-a", node: "node1", mem: 4000},
+		{uid: "uid-b", node: "node1", mem: 2000},
+		{uid: "uid-c", node: "node2", mem: 4000},
+		{uid: "uid-d", node: "node2", mem: 2000},
+	} {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: p.uid, UID: types.UID(p.uid)}}
+		s.podManager.AddPod(pod, p.node, device.PodDevices{
+			nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: p.node + "-gpu0", Type: nvidia.NvidiaGPUDevice, Usedmem: p.mem}}},
+		})
+	}
+	preemptor := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "preemptor", Namespace: "default", UID: "preemptor-uid"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "gpu",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+				"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+				"hami.io/gpumem": *resource.NewQuantity(6000, resource.BinarySI),
+			}},
+		}}},
+	}
+	// Evicting the 4000 MiB pod frees enough memory on node1, evicting the 2000 MiB pod on node2 doesn't.
+	args := extenderv1.ExtenderPreemptionArgs{
+		Pod: preemptor,
+		NodeNameToMetaVictims: map[string]*extenderv1.MetaVictims{
+			"node1": {Pods: []*extenderv1.MetaPod{{UID: "uid-a"}}, NumPDBViolations: 1},
+			"node2": {Pods: []*extenderv1.MetaPod{{UID: "uid-d"}}},
+		},
+	}
+	want := &extenderv1.ExtenderPreemptionResult{NodeNameToMetaVictims: map[string]*extenderv1.MetaVictims{
+		"node1": {Pods: []*extenderv1.MetaPod{{UID: "uid-a"}}, NumPDBViolations: 1},
+	}}
+
+	got, err := s.Preempt(args)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, want)
+
+	got, err = newGRPCClient(t, s).Preempt(context.Background(), args)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, want)
+
+	// Victims given as pods are handled the same.
+	got, err = s.Preempt(extenderv1.ExtenderPreemptionArgs{
+		Pod: preemptor,
+		NodeNameToVictims: map[string]*extenderv1.Victims{
+			"node1": {Pods: []*corev1.Pod{{ObjectMeta: metav1.ObjectMeta{UID: "uid-a"}}}, NumPDBViolations: 1},
+			"node2": {Pods: []*corev1.Pod{{ObjectMeta: metav1.ObjectMeta{UID: "uid-d"}}}},
+		},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, want)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// UtilizationProfile is the peak usage of each device measured for a workload, stored by name in
+// config.UtilizationProfileConfigMap. Unset fields leave the requests of pods as is.
+type UtilizationProfile struct {
+	// Peak memory in MB
+	Mem *int32 `json:"gpumem,omitempty"`
+	// Peak cores in percent
+	Cores *int32 `json:"gpucores,omitempty"`
+}
+
+// getUtilizationProfile returns the profile referenced by the util.UtilizationProfileAnnotationKey annotation of the
+// pod, nil if it doesn't reference any or profiles are disabled.
+func (s *Scheduler) getUtilizationProfile(pod *corev1.Pod) (*UtilizationProfile, error) {
+	name := pod.Annotations[util.UtilizationProfileAnnotationKey]
+	if name == "" || config.UtilizationProfileConfigMap == "" {
+		return nil, nil
+	}
+	namespace, cmName, err := cache.SplitMetaNamespaceKey(config.UtilizationProfileConfigMap)
+	if err != nil || namespace == "" {
+		return nil, fmt.Errorf("invalid utilization profile configmap %q, must be namespace/name", config.UtilizationProfileConfigMap)
+	}
+	cm, err := s.kubeClient.CoreV1().ConfigMaps(namespace).Get(context.Background(), cmName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[name]
+	if !ok {
+		return nil, fmt.Errorf("utilization profile %q not found in configmap %s", name, config.UtilizationProfileConfigMap)
+	}
+	profile := &UtilizationProfile{}
+	if err := json.Unmarshal([]byte(data), profile); err != nil {
+		return nil, fmt.Errorf("invalid utilization profile %q: %v", name, err)
+	}
+	if profile.Mem != nil && *profile.Mem <= 0 {
+		return nil, fmt.Errorf("invalid utilization profile %q, gpumem must be a positive memory in MB", name)
+	}
+	if profile.Cores != nil && (*profile.Cores < 0 || *profile.Cores > 100) {
+		return nil, fmt.Errorf("invalid utilization profile %q, gpucores must be a percentage between 0 and 100", name)
+	}
+	return profile, nil
+}
+
+// applyUtilizationProfile returns the requests with the memory and cores of each device replaced by the peaks of the
+// profile, so the devices allocated to the pod, and counted for its quota, fit its measured usage.
+func applyUtilizationProfile(reqs device.PodDeviceRequests, profile *UtilizationProfile) device.PodDeviceRequests {
+	res := make(device.PodDeviceRequests, len(reqs))
+	for i, ctrReqs := range reqs {
+		res[i] = make(device.ContainerDeviceRequests, len(ctrReqs))
+		for devType, req := range ctrReqs {
+			if profile.Mem != nil {
+				req.Memreq = *profile.Mem
+				req.MemPercentagereq = 101
+			}
+			if profile.Cores != nil {
+				req.Coresreq = *profile.Cores
+			}
+			res[i][devType] = req
+		}
+	}
+	return res
+}
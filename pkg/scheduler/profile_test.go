@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Filter_UtilizationProfile(t *testing.T) {
+	initTestDevices(t)
+	config.UtilizationProfileConfigMap = "hami-system/hami-utilization-profiles"
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	profiles := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "hami-utilization-profiles", Namespace: "hami-system"},
+		Data: map[string]string{
+			"llama-infer": `{"gpumem": 3000, "gpucores": 30}`,
+			"mem-only":    `{"gpumem": 2000}`,
+			"broken":      `{"gpumem": -1}`,
+		},
+	}
+	client.KubeClient = fake.NewSimpleClientset(node, profiles)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: node,
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+			ID: "GPU-0", Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+		}}},
+	})
+	nodeNames := []string{"node1"}
+
+	newPod := func(name string, profile string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name)},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "infer",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":      *resource.NewQuantity(1, resource.DecimalSI),
+					"hami.io/gpumem":   *resource.NewQuantity(6000, resource.DecimalSI),
+					"hami.io/gpucores": *resource.NewQuantity(50, resource.DecimalSI),
+				}},
+			}}},
+		}
+		if profile != "" {
+			pod.Annotations = map[string]string{util.UtilizationProfileAnnotationKey: profile}
+		}
+		_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		return pod
+	}
+	allocated := func(pod *corev1.Pod) device.ContainerDevice {
+		pi, ok := s.podManager.GetPod(pod)
+		assert.Assert(t, ok)
+		return pi.Devices[nvidia.NvidiaGPUDevice][0][0]
+	}
+
+	// Profile-driven, the peak of the profile is reserved instead of the request.
+	profiled := newPod("profiled", "llama-infer")
+	got, err := s.Filter(extenderv1.ExtenderArgs{Pod: profiled, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+	dev := allocated(profiled)
+	assert.Equal(t, dev.Usedmem, int32(3000))
+	assert.Equal(t, dev.Usedcores, int32(30))
+
+	// Request-driven, the 6000MB requested don't fit next to another 3000MB.
+	requested := newPod("requested", "")
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: requested, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0)
+
+	// A profile without cores keeps the cores requested, and packs next to the first profiled pod.
+	memOnly := newPod("mem-only", "mem-only")
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: memOnly, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+	dev = allocated(memOnly)
+	assert.Equal(t, dev.Usedmem, int32(2000))
+	assert.Equal(t, dev.Usedcores, int32(50))
+
+	// Missing or invalid profiles fall back to the requests, which don't fit anymore.
+	for _, name := range []string{"missing", "broken"} {
+		pod := newPod("profile-"+name, name)
+		_, err := s.getUtilizationProfile(pod)
+		assert.Assert(t, err != nil)
+		got, err = s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+		assert.NilError(t, err)
+		assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0, "pod %s placed on %v", pod.Name, got.NodeNames)
+	}
+
+	// Without the configmap, profiles are ignored.
+	config.UtilizationProfileConfigMap = ""
+	profile, err := s.getUtilizationProfile(profiled)
+	assert.NilError(t, err)
+	assert.Assert(t, profile == nil)
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// RebalanceCandidate is a pod whose eviction and rescheduling would pack its devices onto fewer devices.
+type RebalanceCandidate struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+	Node      string    `json:"node"`
+	Devices   []string  `json:"devices"`
+	// TargetNode and TargetDevices are where the pod fits in the simulated re-placement.
+	TargetNode    string   `json:"targetNode"`
+	TargetDevices []string `json:"targetDevices"`
+}
+
+// RebalanceReport lists the rebalance candidates, in the order they were simulated.
+type RebalanceReport struct {
+	// PartialDevices is the number of partially used devices now.
+	PartialDevices int `json:"partialDevices"`
+	// PartialDevicesAfter is the number of partially used devices once all candidates are rescheduled.
+	PartialDevicesAfter int                  `json:"partialDevicesAfter"`
+	Candidates          []RebalanceCandidate `json:"candidates"`
+}
+
+// RebalanceCandidates simulates rescheduling the pods labeled with util.RebalanceLabelKey one by one
+// with the binpack policies, and reports the pods whose rescheduling reduces the number of partially
+// used devices. Pods are only reported within the disruptions allowed by their PodDisruptionBudgets.
+// Nothing is evicted, the report is meant to be consumed by a descheduler.
+func (s *Scheduler) RebalanceCandidates(ctx context.Context) (*RebalanceReport, error) {
+	return s.planRebalance(ctx, func(*device.PodInfo) bool { return true })
+}
+
+// planRebalance simulates rescheduling the labeled pods movable reports true for, see RebalanceCandidates.
+func (s *Scheduler) planRebalance(ctx context.Context, movable func(*device.PodInfo) bool) (*RebalanceReport, error) {
+	nodes, err := s.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make([]string, 0, len(nodes))
+	for name := range nodes {
+		nodeNames = append(nodeNames, name)
+	}
+	binpack := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		util.GPUSchedulerPolicyAnnotationKey: util.GPUSchedulerPolicyBinpack.String(),
+	}}}
+	nodeUsage, _, err := s.getNodesUsage(&nodeNames, binpack)
+	if err != nil {
+		return nil, err
+	}
+	usage := *nodeUsage
+	report := &RebalanceReport{PartialDevices: countPartialDevices(usage), Candidates: []RebalanceCandidate{}}
+	budgets := newDisruptionBudgets(s)
+	for _, pi := range rebalanceablePods(usage, s.podManager.ListPodsInfo(), movable) {
+		pdbs, err := budgets.matching(ctx, pi.Pod)
+		if err != nil {
+			return nil, err
+		}
+		if !budgets.allowed(pdbs) {
+			klog.V(5).InfoS("Skipping rebalance candidate disallowed by disruption budget", "pod", klog.KObj(pi.Pod))
+			continue
+		}
+		released := cloneNodeUsage(usage)
+		s.releasePodUsage(released, pi.Pod)
+		target, ok := s.replacePod(released, pi)
+		if !ok {
+			continue
+		}
+		addPodUsage(released[target.NodeID], target.Devices)
+		if countPartialDevices(released) >= countPartialDevices(usage) {
+			continue
+		}
+		usage = released
+		budgets.disrupt(pdbs)
+		report.Candidates = append(report.Candidates, RebalanceCandidate{
+			Namespace:     pi.Namespace,
+			Name:          pi.Name,
+			UID:           pi.UID,
+			Node:          pi.NodeID,
+			Devices:       podDeviceIDs(pi.Devices),
+			TargetNode:    target.NodeID,
+			TargetDevices: podDeviceIDs(target.Devices),
+		})
+	}
+	report.PartialDevicesAfter = countPartialDevices(usage)
+	klog.V(4).InfoS("Rebalance candidates computed", "candidates", len(report.Candidates), "partialDevices", report.PartialDevices, "partialDevicesAfter", report.PartialDevicesAfter)
+	return report, nil
+}
+
+// replacePod fits the pod on devices of usage other than its current ones with the binpack policies.
+func (s *Scheduler) replacePod(usage map[string]*NodeUsage, pi *device.PodInfo) (*policy.NodeScore, bool) {
+	trial := cloneNodeUsage(usage)
+	current := podDeviceIDs(pi.Devices)
+	if node, ok := trial[pi.NodeID]; ok {
+		for _, d := range node.Devices.DeviceLists {
+			if slices.Contains(current, d.Device.ID) {
+				d.Device.Health = false
+			}
+		}
+	}
+	pod := pi.Pod.DeepCopy()
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[util.NodeSchedulerPolicyAnnotationKey] = util.NodeSchedulerPolicyBinpack.String()
+	pod.Annotations[util.GPUSchedulerPolicyAnnotationKey] = util.GPUSchedulerPolicyBinpack.String()
+	resourceReqs := device.Resourcereqs(pod)
+	if util.IsShareGPUWithinPod(pod) {
+		resourceReqs = device.MergeContainerRequests(resourceReqs)
+	}
+	nodeScores, _, err := s.scoreNodes(context.Background(), &trial, resourceReqs, pod, make(map[string]string))
+	if err != nil {
+		klog.V(5).InfoS("Failed to simulate rescheduling", "pod", klog.KObj(pod), "error", err)
+		return nil, false
+	}
+	if len(nodeScores.NodeList) == 0 {
+		return nil, false
+	}
+	nodeScores.Sort()
+	return nodeScores.NodeList[len(nodeScores.NodeList)-1], true
+}
+
+// rebalanceablePods returns the labeled pods movable reports true for using partially used devices, the pods
+// on the least used devices first.
+func rebalanceablePods(usage map[string]*NodeUsage, pods []*device.PodInfo, movable func(*device.PodInfo) bool) []*device.PodInfo {
+	fill := make(map[types.UID]float64)
+	var res []*device.PodInfo
+	for _, pi := range pods {
+		if pi.Labels[util.RebalanceLabelKey] != "true" || !movable(pi) {
+			continue
+		}
+		node, ok := usage[pi.NodeID]
+		if !ok {
+			continue
+		}
+		ids := podDeviceIDs(pi.Devices)
+		minFill := 2.0
+		for _, d := range node.Devices.DeviceLists {
+			if slices.Contains(ids, d.Device.ID) && isPartiallyUsed(d.Device) {
+				minFill = min(minFill, float64(d.Device.Usedmem)/float64(d.Device.Totalmem))
+			}
+		}
+		if minFill > 1 {
+			continue
+		}
+		fill[pi.UID] = minFill
+		res = append(res, pi)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if fill[res[i].UID] != fill[res[j].UID] {
+			return fill[res[i].UID] < fill[res[j].UID]
+		}
+		if res[i].Namespace != res[j].Namespace {
+			return res[i].Namespace < res[j].Namespace
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// isPartiallyUsed reports whether the device is used by pods and can still be shared by more.
+func isPartiallyUsed(d *device.DeviceUsage) bool {
+	return d.Health && d.Used > 0 && d.Used < d.Count && d.Usedmem < d.Totalmem &&
+		(d.Totalcore == 0 || d.Usedcores < d.Totalcore)
+}
+
+func countPartialDevices(usage map[string]*NodeUsage) int {
+	count := 0
+	for _, node := range usage {
+		for _, d := range node.Devices.DeviceLists {
+			if isPartiallyUsed(d.Device) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func cloneNodeUsage(usage map[string]*NodeUsage) map[string]*NodeUsage {
+	res := make(map[string]*NodeUsage, len(usage))
+	for id, node := range usage {
+		clone := &NodeUsage{
+			Node: node.Node,
+			Devices: policy.DeviceUsageList{
+				Policy:      node.Devices.Policy,
+				DeviceLists: make([]*policy.DeviceListsScore, 0, len(node.Devices.DeviceLists)),
+			},
+		}
+		for _, d := range node.Devices.DeviceLists {
+			dev := *d.Device
+			dev.MigUsage.UsageList = slices.Clone(d.Device.MigUsage.UsageList)
+			dev.PodInfos = slices.Clone(d.Device.PodInfos)
+			dev.CustomInfo = maps.Clone(d.Device.CustomInfo)
+			clone.Devices.DeviceLists = append(clone.Devices.DeviceLists, &policy.DeviceListsScore{Score: d.Score, Cost: d.Cost, Device: &dev})
+		}
+		res[id] = clone
+	}
+	return res
+}
+
+// addPodUsage adds the usage of the pod devices to the node.
+func addPodUsage(node *NodeUsage, devices device.PodDevices) {
+	for _, podSingle := range devices {
+		for _, ctrdevs := range podSingle {
+			for _, udevice := range ctrdevs {
+				for _, d := range node.Devices.DeviceLists {
+					if d.Device.ID != strings.Split(udevice.UUID, "[")[0] {
+						continue
+					}
+					d.Device.Used++
+					d.Device.Usedmem += udevice.Usedmem
+					d.Device.Oversubscribedmem += udevice.Oversubscribedmem
+					d.Device.Usedcores += udevice.Usedcores
+					d.Device.Usedengines += udevice.Usedengines
+				}
+			}
+		}
+	}
+}
+
+// podDeviceIDs returns the sorted IDs of the devices of the pod.
+func podDeviceIDs(devices device.PodDevices) []string {
+	var res []string
+	for _, podSingle := range devices {
+		for _, ctrdevs := range podSingle {
+			for _, udevice := range ctrdevs {
+				id := strings.Split(udevice.UUID, "[")[0]
+				if !slices.Contains(res, id) {
+					res = append(res, id)
+				}
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// disruptionBudgets tracks the disruptions the PodDisruptionBudgets still allow.
+type disruptionBudgets struct {
+	s         *Scheduler
+	budgets   map[string][]policyv1.PodDisruptionBudget
+	disrupted map[types.NamespacedName]int32
+}
+
+func newDisruptionBudgets(s *Scheduler) *disruptionBudgets {
+	return &disruptionBudgets{
+		s:         s,
+		budgets:   make(map[string][]policyv1.PodDisruptionBudget),
+		disrupted: make(map[types.NamespacedName]int32),
+	}
+}
+
+// matching returns the budgets selecting the pod.
+func (b *disruptionBudgets) matching(ctx context.Context, pod *corev1.Pod) ([]policyv1.PodDisruptionBudget, error) {
+	pdbs, ok := b.budgets[pod.Namespace]
+	if !ok {
+		list, err := b.s.kubeClient.PolicyV1().PodDisruptionBudgets(pod.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pod disruption budgets of namespace %s: %v", pod.Namespace, err)
+		}
+		pdbs = list.Items
+		b.budgets[pod.Namespace] = pdbs
+	}
+	var res []policyv1.PodDisruptionBudget
+	for _, pdb := range pdbs {
+		// A nil selector selects no pod, an empty one selects every pod.
+		if pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			klog.V(5).InfoS("Invalid pod disruption budget selector", "pdb", klog.KObj(&pdb), "error", err)
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			res = append(res, pdb)
+		}
+	}
+	return res, nil
+}
+
+// allowed reports whether every budget allows one more disruption.
+func (b *disruptionBudgets) allowed(pdbs []policyv1.PodDisruptionBudget) bool {
+	for _, pdb := range pdbs {
+		if pdb.Status.DisruptionsAllowed-b.disrupted[types.NamespacedName{Namespace: pdb.Namespace, Name: pdb.Name}] <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// disrupt counts one disruption against every budget.
+func (b *disruptionBudgets) disrupt(pdbs []policyv1.PodDisruptionBudget) {
+	for _, pdb := range pdbs {
+		b.disrupted[types.NamespacedName{Namespace: pdb.Namespace, Name: pdb.Name}]++
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_RebalanceCandidates(t *testing.T) {
+	s := NewScheduler()
+	initTestDevices(t)
+	pdb := func(name, app string, allowed int32) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+	s.kubeClient = fake.NewSimpleClientset(pdb("train", "train", 1), pdb("critical", "critical", 0))
+
+	gpus := map[string][]string{"node1": {"gpu1", "gpu2", "gpu3", "gpu4"}, "node2": {"gpu5"}}
+	for node, ids := range gpus {
+		var devices []device.DeviceInfo
+		for i, id := range ids {
+			devices = append(devices, device.DeviceInfo{ID: id, Index: uint(i), Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice})
+		}
+		s.addNode(node, &device.NodeInfo{
+			ID:      node,
+			Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: node}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices},
+		})
+	}
+	// Every device is partially used.
+	for _, p := range []struct {
+		name      string
+		app       string
+		optIn     bool
+		node, gpu string
+		mem       int32
+	}{
+		{name: "train-a", app: "train", optIn: true, node: "node1", gpu: "gpu1", mem: 2000},
+		{name: "train-b", app: "train", optIn: true, node: "node1", gpu: "gpu2", mem: 2000},
+		{name: "infer", app: "infer", optIn: true, node: "node1", gpu: "gpu3", mem: 2000},
+		{name: "not-opted-in", app: "infer", node: "node1", gpu: "gpu4", mem: 6000},
+		{name: "critical", app: "critical", optIn: true, node: "node2", gpu: "gpu5", mem: 1000},
+	} {
+		labels := map[string]string{"app": p.app}
+		if p.optIn {
+			labels[util.RebalanceLabelKey] = "true"
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: p.name, Namespace: "default", UID: types.UID(p.name + "-uid"), Labels: labels},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "gpu",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+					"hami.io/gpumem": *resource.NewQuantity(int64(p.mem), resource.BinarySI),
+				}},
+			}}},
+		}
+		s.podManager.AddPod(pod, p.node, device.PodDevices{
+			nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: p.gpu, Type: nvidia.NvidiaGPUDevice, Usedmem: p.mem}}},
+		})
+	}
+
+	report, err := s.RebalanceCandidates(context.Background())
+	assert.NilError(t, err)
+	// critical is disallowed by its budget, infer moves onto the busiest device filling it, train-a
+	// joins train-b, and the budget of train then disallows train-b, leaving gpu2 and gpu5 partially used.
+	assert.DeepEqual(t, report, &RebalanceReport{
+		PartialDevices:      5,
+		PartialDevicesAfter: 2,
+		Candidates: []RebalanceCandidate{
+			{Namespace: "default", Name: "infer", UID: "infer-uid", Node: "node1", Devices: []string{"gpu3"}, TargetNode: "node1", TargetDevices: []string{"gpu4"}},
+			{Namespace: "default", Name: "train-a", UID: "train-a-uid", Node: "node1", Devices: []string{"gpu1"}, TargetNode: "node1", TargetDevices: []string{"gpu2"}},
+		},
+	})
+
+	// Nothing is evicted or changed in the scheduler cache.
+	pi, ok := s.podManager.GetPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "train-a-uid"}})
+	assert.Assert(t, ok)
+	assert.Equal(t, pi.Devices[nvidia.NvidiaGPUDevice][0][0].UUID, "gpu1")
+}
+
+func Test_RebalanceCandidates_NoImprovement(t *testing.T) {
+	s := NewScheduler()
+	initTestDevices(t)
+	s.kubeClient = fake.NewSimpleClientset()
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "gpu1", Index: 0, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			{ID: "gpu2", Index: 1, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+		}},
+	})
+	// The pods don't fit together on one device.
+	for _, gpu := range []string{"gpu1", "gpu2"} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: gpu, Namespace: "default", UID: types.UID(gpu + "-uid"), Labels: map[string]string{util.RebalanceLabelKey: "true"}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "gpu",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+					"hami.io/gpumem": *resource.NewQuantity(5000, resource.BinarySI),
+				}},
+			}}},
+		}
+		s.podManager.AddPod(pod, "node1", device.PodDevices{
+			nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: gpu, Type: nvidia.NvidiaGPUDevice, Usedmem: 5000}}},
+		})
+	}
+
+	report, err := s.RebalanceCandidates(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, report, &RebalanceReport{PartialDevices: 2, PartialDevicesAfter: 2, Candidates: []RebalanceCandidate{}})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// replicaSpread is how the replicas of a pod, the pods of the same controller, are spread.
+type replicaSpread struct {
+	spread util.ReplicaSpread
+	owner  k8stypes.UID
+}
+
+// replicaSpreadOf returns the replica spread of the pod, none if it has no controller to group its replicas by.
+func replicaSpreadOf(pod *corev1.Pod) replicaSpread {
+	spread, _ := util.GetReplicaSpread(pod)
+	ref := metav1.GetControllerOf(pod)
+	if spread == util.ReplicaSpreadNone || ref == nil {
+		return replicaSpread{spread: util.ReplicaSpreadNone}
+	}
+	return replicaSpread{spread: spread, owner: ref.UID}
+}
+
+// replicas returns the replicas of pod using the device.
+func (r replicaSpread) replicas(dev *device.DeviceUsage, pod *corev1.Pod) sets.Set[k8stypes.UID] {
+	res := sets.New[k8stypes.UID]()
+	for _, pi := range dev.PodInfos {
+		if pi.Pod == nil || pi.UID == pod.UID {
+			continue
+		}
+		if ref := metav1.GetControllerOf(pi.Pod); ref != nil && ref.UID == r.owner {
+			res.Insert(pi.UID)
+		}
+	}
+	return res
+}
+
+// markDevices records the replicas of pod using each device of the node for ReplicaSpreadDevice, so the devices
+// used by fewer replicas are fit first.
+func (r replicaSpread) markDevices(node *NodeUsage, pod *corev1.Pod) {
+	for _, ds := range node.Devices.DeviceLists {
+		ds.Replicas = 0
+		if r.spread == util.ReplicaSpreadDevice {
+			ds.Replicas = r.replicas(ds.Device, pod).Len()
+		}
+	}
+}
+
+// nodeReplicas returns the replicas the node fitting pod is ranked by: the replicas of pod on the node for
+// ReplicaSpreadNode, the devices allocated to pod on the node which replicas use for ReplicaSpreadDevice.
+func (r replicaSpread) nodeReplicas(node *NodeUsage, score *policy.NodeScore, pod *corev1.Pod) int {
+	switch r.spread {
+	case util.ReplicaSpreadNode:
+		res := sets.New[k8stypes.UID]()
+		for _, ds := range node.Devices.DeviceLists {
+			res = res.Union(r.replicas(ds.Device, pod))
+		}
+		return res.Len()
+	case util.ReplicaSpreadDevice:
+		allocated := sets.New[string]()
+		for _, psd := range score.Devices {
+			for _, ctr := range psd {
+				for _, d := range ctr {
+					allocated.Insert(d.UUID)
+				}
+			}
+		}
+		res := 0
+		for _, ds := range node.Devices.DeviceLists {
+			if allocated.Has(ds.Device.ID) && ds.Replicas > 0 {
+				res++
+			}
+		}
+		return res
+	}
+	return 0
+}
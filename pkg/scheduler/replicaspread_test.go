@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Filter_ReplicaSpread(t *testing.T) {
+	initTestDevices(t)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	client.KubeClient = fake.NewSimpleClientset(node)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	informerFactory := informers.NewSharedInformerFactory(client.KubeClient, 0)
+	s.nodeLister = informerFactory.Core().V1().Nodes().Lister()
+	informerFactory.Start(s.stopCh)
+	informerFactory.WaitForCacheSync(s.stopCh)
+	gpus := make([]device.DeviceInfo, 0, 3)
+	for i := range 3 {
+		gpus = append(gpus, device.DeviceInfo{
+			ID: fmt.Sprintf("GPU-%d", i), Index: uint(i), Count: 10, Devmem: 16000, Devcore: 100,
+			Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+		})
+	}
+	s.addNode("node1", &device.NodeInfo{ID: "node1", Node: node, Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: gpus}})
+
+	// The binpack GPU policy would put the replicas on the same GPU without spreading them.
+	owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "infer-5d4f8", UID: "rs-uid", Controller: ptr.To(true)}
+	nodeNames := []string{"node1"}
+	used := make(map[string]bool)
+	for i := range 3 {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("infer-%d", i), Namespace: "default", UID: k8stypes.UID(fmt.Sprintf("infer-%d", i)),
+				OwnerReferences: []metav1.OwnerReference{owner},
+				Annotations: map[string]string{
+					util.ReplicaSpreadAnnotationKey:      string(util.ReplicaSpreadDevice),
+					util.GPUSchedulerPolicyAnnotationKey: util.GPUSchedulerPolicyBinpack.String(),
+				},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "infer",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(1, resource.DecimalSI),
+					"hami.io/gpumem": *resource.NewQuantity(2000, resource.DecimalSI),
+				}},
+			}}},
+		}
+		_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+		info, ok := s.podManager.GetPod(pod)
+		assert.Assert(t, ok)
+		uuid := info.Devices[nvidia.NvidiaGPUDevice][0][0].UUID
+		assert.Assert(t, !used[uuid], "replica %s put on %s used by another replica", pod.Name, uuid)
+		used[uuid] = true
+	}
+	assert.Equal(t, len(used), 3)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// reservations tracks the devices reserved for pods by filter and not bound yet, so reservations of
+// binds that never complete are released after config.ReservationTTL instead of blocking other pods.
+type reservations struct {
+	mutex sync.Mutex
+	// Time devices were reserved for a pod by UID
+	reserved map[k8stypes.UID]time.Time
+	expired  int64
+	// Devices the pods of a controller by UID fit last, until their expiry
+	avoided map[k8stypes.UID]avoidedDevices
+}
+
+// avoidedDevices are devices, by stable ID, the pods of a controller are steered away from until expires.
+type avoidedDevices struct {
+	devices sets.Set[string]
+	expires time.Time
+}
+
+// avoidDevices makes the pods of the controller fit the devices of the node by UUID last for ttl. The devices are
+// recorded by stable ID, so they're still avoided once a reset changed their UUID.
+func (s *Scheduler) avoidDevices(controller k8stypes.UID, nodeID string, devices []string, ttl time.Duration) {
+	devices = s.stableDeviceIDs(nodeID, devices)
+	s.reservations.mutex.Lock()
+	defer s.reservations.mutex.Unlock()
+	avoided, ok := s.reservations.avoided[controller]
+	if !ok || s.clock.Now().After(avoided.expires) {
+		avoided = avoidedDevices{devices: sets.New[string]()}
+	}
+	avoided.devices.Insert(devices...)
+	avoided.expires = s.clock.Now().Add(ttl)
+	s.reservations.avoided[controller] = avoided
+}
+
+// devicesAvoidedBy returns the devices the pod fits last, none if it has no controller.
+func (s *Scheduler) devicesAvoidedBy(pod *corev1.Pod) sets.Set[string] {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return nil
+	}
+	s.reservations.mutex.Lock()
+	defer s.reservations.mutex.Unlock()
+	avoided, ok := s.reservations.avoided[ref.UID]
+	if !ok {
+		return nil
+	}
+	if s.clock.Now().After(avoided.expires) {
+		delete(s.reservations.avoided, ref.UID)
+		return nil
+	}
+	return avoided.devices
+}
+
+// isInFlight reports whether the devices assigned to the pod are reserved by filter but not bound yet.
+func isInFlight(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName != "" {
+		return false
+	}
+	_, bound := pod.Annotations[util.BindTimeAnnotations]
+	return !bound
+}
+
+// reserve records the devices assigned to the pod are reserved at t.
+func (s *Scheduler) reserve(pod *corev1.Pod, t time.Time) {
+	s.reservations.mutex.Lock()
+	defer s.reservations.mutex.Unlock()
+	s.reservations.reserved[pod.UID] = t
+}
+
+// settleReservation stops tracking the reservation of the pod, once it's bound or deleted.
+func (s *Scheduler) settleReservation(pod *corev1.Pod) {
+	s.reservations.mutex.Lock()
+	defer s.reservations.mutex.Unlock()
+	delete(s.reservations.reserved, pod.UID)
+}
+
+// trackReservation tracks the reservation of a pod assigned devices seen by the informer, with the time of the
+// assignment if it's not tracked yet, e.g. after a restart. It returns false if the reservation already expired,
+// the devices of the pod shouldn't be counted then.
+func (s *Scheduler) trackReservation(pod *corev1.Pod) bool {
+	if !isInFlight(pod) {
+		s.settleReservation(pod)
+		return true
+	}
+	s.reservations.mutex.Lock()
+	defer s.reservations.mutex.Unlock()
+	if _, ok := s.reservations.reserved[pod.UID]; ok {
+		return true
+	}
+	t := s.clock.Now()
+	if v, err := strconv.ParseInt(pod.Annotations[util.AssignedTimeAnnotations], 10, 64); err == nil {
+		t = time.Unix(v, 0)
+	}
+	if config.ReservationTTL > 0 && s.clock.Since(t) > config.ReservationTTL {
+		return false
+	}
+	s.reservations.reserved[pod.UID] = t
+	return true
+}
+
+// expireReservations releases the devices of pods not bound within config.ReservationTTL after filter reserved
+// them. They're reserved again when the pod is retried.
+func (s *Scheduler) expireReservations() {
+	if config.ReservationTTL <= 0 {
+		return
+	}
+	// The lock is held while releasing, so a pod reserved again by a concurrent filter isn't released.
+	s.reservations.mutex.Lock()
+	defer s.reservations.mutex.Unlock()
+	for uid, t := range s.reservations.reserved {
+		if s.clock.Since(t) <= config.ReservationTTL {
+			continue
+		}
+		delete(s.reservations.reserved, uid)
+		pi, ok := s.podManager.GetPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: uid}})
+		if !ok {
+			continue
+		}
+		klog.InfoS("Releasing devices of pod not bound in time", "pod", klog.KObj(pi.Pod), "nodeID", pi.NodeID, "ttl", config.ReservationTTL)
+		s.quotaManager.RmUsage(pi.Pod)
+		s.podManager.DelPod(pi.Pod)
+		s.reservations.expired++
+	}
+}
+
+// ExpiredReservations returns the number of reservations released because their pod wasn't bound in time.
+func (s *Scheduler) ExpiredReservations() int64 {
+	s.reservations.mutex.Lock()
+	defer s.reservations.mutex.Unlock()
+	return s.reservations.expired
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_expireReservations(t *testing.T) {
+	restoreConfig(t)
+	config.ReservationTTL = 5 * time.Minute
+
+	now := time.Unix(1700000000, 0)
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       k8stypes.UID(name),
+			Annotations: map[string]string{
+				util.AssignedNodeAnnotations: "node1",
+				util.AssignedTimeAnnotations: fmt.Sprint(now.Unix()),
+			},
+		}}
+	}
+	devices := device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+		{UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 1000, Usedcores: 10},
+	}}}
+
+	s := NewScheduler()
+	defer s.Stop()
+	clock := testingclock.NewFakeClock(now)
+	s.clock = clock
+
+	inFlight := newPod("in-flight")
+	s.podManager.AddPod(inFlight, "node1", devices)
+	s.reserve(inFlight, now)
+	bound := newPod("bound")
+	s.onAddPod(bound)
+	bound = bound.DeepCopy()
+	bound.Annotations[util.BindTimeAnnotations] = fmt.Sprint(now.Unix())
+	s.onUpdatePod(nil, bound)
+
+	// Reservations are kept within the ttl.
+	clock.Step(config.ReservationTTL)
+	s.expireReservations()
+	_, ok := s.podManager.GetPod(inFlight)
+	assert.Assert(t, ok)
+	assert.Equal(t, s.ExpiredReservations(), int64(0))
+
+	clock.Step(time.Second)
+	s.expireReservations()
+	_, ok = s.podManager.GetPod(inFlight)
+	assert.Assert(t, !ok)
+	_, ok = s.podManager.GetPod(bound)
+	assert.Assert(t, ok)
+	assert.Equal(t, s.ExpiredReservations(), int64(1))
+
+	// Updates of the expired pod don't reserve its devices again, a new filter does.
+	s.onUpdatePod(nil, inFlight)
+	_, ok = s.podManager.GetPod(inFlight)
+	assert.Assert(t, !ok)
+	s.podManager.AddPod(inFlight, "node1", devices)
+	s.reserve(inFlight, clock.Now())
+	s.onUpdatePod(nil, inFlight)
+	s.expireReservations()
+	_, ok = s.podManager.GetPod(inFlight)
+	assert.Assert(t, ok)
+
+	// Reservations aren't released if disabled.
+	config.ReservationTTL = 0
+	clock.Step(time.Hour)
+	s.expireReservations()
+	_, ok = s.podManager.GetPod(inFlight)
+	assert.Assert(t, ok)
+}
+
+func Test_trackReservation(t *testing.T) {
+	restoreConfig(t)
+	config.ReservationTTL = 5 * time.Minute
+
+	now := time.Unix(1700000000, 0)
+	s := NewScheduler()
+	defer s.Stop()
+	s.clock = testingclock.NewFakeClock(now)
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want bool
+	}{
+		{
+			name: "assigned within the ttl before a restart",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "recent", Annotations: map[string]string{
+				util.AssignedTimeAnnotations: fmt.Sprint(now.Add(-time.Minute).Unix()),
+			}}},
+			want: true,
+		},
+		{
+			name: "assigned before the ttl",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "stale", Annotations: map[string]string{
+				util.AssignedTimeAnnotations: fmt.Sprint(now.Add(-time.Hour).Unix()),
+			}}},
+			want: false,
+		},
+		{
+			name: "bound",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{UID: "bound", Annotations: map[string]string{
+					util.AssignedTimeAnnotations: fmt.Sprint(now.Add(-time.Hour).Unix()),
+				}},
+				Spec: corev1.PodSpec{NodeName: "node1"},
+			},
+			want: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, s.trackReservation(test.pod), test.want)
+		})
+	}
+}
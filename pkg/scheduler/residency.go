@@ -0,0 +1,154 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	// EventReasonDeviceResidencyExpiring indicates a pod approaches the max residency of its devices.
+	EventReasonDeviceResidencyExpiring = "DeviceResidencyExpiring"
+	// EventReasonDeviceResidencyExceeded indicates a pod exceeded the max residency of its devices.
+	EventReasonDeviceResidencyExceeded = "DeviceResidencyExceeded"
+
+	// deviceResidencyInterval is the interval the max device residency is enforced at.
+	deviceResidencyInterval = 5 * time.Minute
+	// residencyAvoidanceTTL is how long the replicas of a pod evicted past its max device residency fit its
+	// devices last, long enough for its controller to recreate it.
+	residencyAvoidanceTTL = time.Hour
+)
+
+// enforceDeviceResidencyLoop enforces the max device residency every deviceResidencyInterval until the scheduler
+// is stopped.
+func (s *Scheduler) enforceDeviceResidencyLoop() {
+	ticker := time.NewTicker(deviceResidencyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.enforceDeviceResidency(context.Background())
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// deviceResidencyDeadline returns when the bound pod reaches the max residency of its devices, the earliest of
+// its device types, false if it's unbound or none of its devices has one.
+func deviceResidencyDeadline(pi *device.PodInfo) (time.Time, bool) {
+	bindTime, err := strconv.ParseInt(pi.Pod.Annotations[util.BindTimeAnnotations], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var res time.Time
+	for deviceType, devices := range pi.Devices {
+		limit, ok := config.MaxDeviceResidency[deviceType]
+		if !ok || len(podDeviceIDs(device.PodDevices{deviceType: devices})) == 0 {
+			continue
+		}
+		if deadline := time.Unix(bindTime, 0).Add(limit); res.IsZero() || deadline.Before(res) {
+			res = deadline
+		}
+	}
+	return res, !res.IsZero()
+}
+
+// enforceDeviceResidency warns the pods within config.DeviceResidencyWarning of the max residency of their devices
+// by annotating them with util.DeviceResidencyDeadlineAnnotationKey, and reports the pods past it. Those owned by a
+// controller are evicted if config.EvictExpiredDeviceResidency is set, within their disruption budgets, and their
+// devices are avoided by the pods of the controller for residencyAvoidanceTTL so they're rescheduled elsewhere.
+func (s *Scheduler) enforceDeviceResidency(ctx context.Context) {
+	now := s.clock.Now()
+	for _, pi := range s.podManager.ListPodsInfo() {
+		if pi.Pod == nil || util.IsPodInTerminatedState(pi.Pod) || pi.Pod.DeletionTimestamp != nil {
+			continue
+		}
+		deadline, ok := deviceResidencyDeadline(pi)
+		if !ok || now.Before(deadline.Add(-config.DeviceResidencyWarning)) {
+			continue
+		}
+		if _, warned := pi.Pod.Annotations[util.DeviceResidencyDeadlineAnnotationKey]; !warned {
+			s.warnDeviceResidency(ctx, pi, deadline)
+		}
+		if now.Before(deadline) {
+			continue
+		}
+		if !config.EvictExpiredDeviceResidency || metav1.GetControllerOf(pi.Pod) == nil {
+			klog.V(4).InfoS("Pod exceeds the max residency of its devices", "pod", klog.KObj(pi.Pod), "nodeID", pi.NodeID, "deadline", deadline)
+			continue
+		}
+		s.evictExpiredResidency(ctx, pi, deadline)
+	}
+}
+
+func (s *Scheduler) warnDeviceResidency(ctx context.Context, pi *device.PodInfo, deadline time.Time) {
+	value := deadline.UTC().Format(time.RFC3339)
+	err := util.PatchAnnotationsWithRetry(ctx, s.kubeClient, util.PodRef(pi.Namespace, pi.Name), func(meta *metav1.ObjectMeta) error {
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, 1)
+		}
+		meta.Annotations[util.DeviceResidencyDeadlineAnnotationKey] = value
+		return nil
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to annotate pod approaching the max residency of its devices", "pod", klog.KObj(pi.Pod))
+		return
+	}
+	klog.InfoS("Pod approaches the max residency of its devices", "pod", klog.KObj(pi.Pod), "nodeID", pi.NodeID, "deadline", value)
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(pi.Pod, corev1.EventTypeWarning, EventReasonDeviceResidencyExpiring,
+			fmt.Sprintf("The pod reaches the max residency of its devices at %s and should be rescheduled", value))
+	}
+}
+
+// evictExpiredResidency evicts the pod by the eviction API, which denies evictions disallowed by the disruption
+// budgets of the pod, the eviction is retried on the next enforcement then.
+func (s *Scheduler) evictExpiredResidency(ctx context.Context, pi *device.PodInfo, deadline time.Time) {
+	err := s.kubeClient.CoreV1().Pods(pi.Namespace).EvictV1(ctx, &policyv1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Name: pi.Name, Namespace: pi.Namespace},
+		DeleteOptions: &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &pi.UID}},
+	})
+	if apierrors.IsTooManyRequests(err) {
+		klog.V(4).InfoS("Eviction of pod past the max residency of its devices disallowed by its disruption budget", "pod", klog.KObj(pi.Pod))
+		return
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to evict pod past the max residency of its devices", "pod", klog.KObj(pi.Pod))
+		return
+	}
+	devices := podDeviceIDs(pi.Devices)
+	s.avoidDevices(metav1.GetControllerOf(pi.Pod).UID, pi.NodeID, devices, residencyAvoidanceTTL)
+	klog.InfoS("Evicted pod past the max residency of its devices", "pod", klog.KObj(pi.Pod), "nodeID", pi.NodeID, "devices", devices, "deadline", deadline)
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(pi.Pod, corev1.EventTypeWarning, EventReasonDeviceResidencyExceeded,
+			fmt.Sprintf("Evicted the pod past the max residency of devices %v", devices))
+	}
+}
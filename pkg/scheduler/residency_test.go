@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_enforceDeviceResidency(t *testing.T) {
+	restoreConfig(t)
+	config.MaxDeviceResidency = map[string]time.Duration{nvidia.NvidiaGPUDevice: 30 * 24 * time.Hour}
+	config.DeviceResidencyWarning = 24 * time.Hour
+	config.EvictExpiredDeviceResidency = true
+
+	now := time.Now()
+	owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", UID: "rs", Controller: ptr.To(true)}
+	newPod := func(name string, age time.Duration, owners ...metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			UID:             k8stypes.UID(name),
+			OwnerReferences: owners,
+			Annotations: map[string]string{
+				util.AssignedNodeAnnotations: "node1",
+				util.BindTimeAnnotations:     strconv.FormatInt(now.Add(-age).Unix(), 10),
+			},
+		}}
+	}
+	fresh := newPod("fresh", 10*24*time.Hour, owner)
+	expiring := newPod("expiring", 29*24*time.Hour+time.Hour, owner)
+	expired := newPod("expired", 31*24*time.Hour, owner)
+	guarded := newPod("guarded", 31*24*time.Hour, owner)
+	bare := newPod("bare", 31*24*time.Hour)
+
+	client := fake.NewSimpleClientset(fresh, expiring, expired, guarded, bare)
+	evicted := make([]string, 0)
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		name := action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName()
+		if name == "guarded" {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		evicted = append(evicted, name)
+		return true, nil, nil
+	})
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client
+	clock := testingclock.NewFakeClock(now)
+	s.clock = clock
+	for i, pod := range []*corev1.Pod{fresh, expiring, expired, guarded, bare} {
+		s.podManager.AddPod(pod, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+			{UUID: "GPU-" + strconv.Itoa(i), Type: nvidia.NvidiaGPUDevice, Usedmem: 4000, Usedcores: 30},
+		}}})
+	}
+
+	s.enforceDeviceResidency(context.Background())
+
+	// Pods within the warning are annotated, evicted past the deadline if owned and the budget allows it
+	for _, pod := range []*corev1.Pod{fresh, expiring, expired, guarded, bare} {
+		got, err := client.CoreV1().Pods("default").Get(context.Background(), pod.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		_, warned := got.Annotations[util.DeviceResidencyDeadlineAnnotationKey]
+		assert.Equal(t, warned, pod != fresh, pod.Name)
+	}
+	got, _ := client.CoreV1().Pods("default").Get(context.Background(), "expiring", metav1.GetOptions{})
+	assert.Equal(t, got.Annotations[util.DeviceResidencyDeadlineAnnotationKey],
+		time.Unix(now.Add(-29*24*time.Hour-time.Hour).Unix(), 0).Add(30*24*time.Hour).UTC().Format(time.RFC3339))
+	assert.DeepEqual(t, evicted, []string{"expired"})
+
+	// Replicas of the evicted pod avoid its device for a while
+	replica := newPod("replica", 0, owner)
+	assert.DeepEqual(t, s.devicesAvoidedBy(replica), sets.New("GPU-2"))
+	assert.Assert(t, s.devicesAvoidedBy(newPod("other", 0)) == nil)
+	clock.Step(residencyAvoidanceTTL + time.Second)
+	assert.Assert(t, s.devicesAvoidedBy(replica) == nil)
+}
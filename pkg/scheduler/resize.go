@@ -0,0 +1,163 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	// EventReasonGPUResized indicates the GPU memory or cores of a running pod were resized in place.
+	EventReasonGPUResized = "GPUResized"
+	// EventReasonGPUResizeInfeasible indicates the GPU resize of a running pod doesn't fit its devices.
+	EventReasonGPUResizeInfeasible = "GPUResizeInfeasible"
+)
+
+// resizeGPUs resizes in place the NVIDIA GPUs assigned to the running pod when the memory or cores its containers
+// request changed, and returns the devices of the pod once resized. Shrinks always succeed, grows only if the
+// devices assigned have the memory and cores left, the resize is marked infeasible otherwise and podDev returned
+// unchanged. The new assignment is recorded in the allocation annotation, with util.GPUResizedAnnotationKey for the
+// monitor of the node to apply the new limits to the running containers. The number of GPUs can't be resized.
+func (s *Scheduler) resizeGPUs(pod *corev1.Pod, nodeID string, podDev device.PodDevices) device.PodDevices {
+	if pod.Status.Phase != corev1.PodRunning || pod.Annotations[util.DeviceBindPhase] != util.DeviceBindSuccess {
+		return podDev
+	}
+	assigned := podDev[nvidia.NvidiaGPUDevice]
+	dev, ok := device.GetDevices()[nvidia.NvidiaGPUDevice]
+	if !ok || len(assigned) == 0 {
+		return podDev
+	}
+	node, err := s.GetNode(nodeID)
+	if err != nil {
+		return podDev
+	}
+	totalmem := make(map[string]int32)
+	for _, d := range node.Devices[nvidia.NvidiaGPUDevice] {
+		totalmem[d.ID] = d.Devmem
+	}
+
+	resized := make(device.PodSingleDevice, len(assigned))
+	// Memory and cores grown by device
+	grownMem, grownCores := make(map[string]int32), make(map[string]int32)
+	changed := false
+	for idx, ctrdevs := range assigned {
+		resized[idx] = ctrdevs
+		if len(ctrdevs) == 0 || idx >= len(pod.Spec.Containers) {
+			continue
+		}
+		request := dev.GenerateResourceRequests(&pod.Spec.Containers[idx])
+		if int(request.Nums) != len(ctrdevs) {
+			s.rejectResize(pod, fmt.Sprintf("container %s requests %d GPUs but is assigned %d, the number of GPUs can't be resized in place",
+				pod.Spec.Containers[idx].Name, request.Nums, len(ctrdevs)))
+			return podDev
+		}
+		resized[idx] = make(device.ContainerDevices, len(ctrdevs))
+		for i, d := range ctrdevs {
+			mem := request.Memreq
+			if mem == 0 {
+				mem = totalmem[d.UUID] * request.MemPercentagereq / 100
+			}
+			// MIG instances are sized by their template.
+			if strings.Contains(d.UUID, "[") || mem == 0 || (mem == d.Usedmem && request.Coresreq == d.Usedcores) {
+				resized[idx][i] = d
+				continue
+			}
+			grownMem[d.UUID] += mem - d.Usedmem
+			grownCores[d.UUID] += request.Coresreq - d.Usedcores
+			d.Usedmem, d.Usedcores = mem, request.Coresreq
+			resized[idx][i] = d
+			changed = true
+		}
+	}
+	if !changed {
+		return podDev
+	}
+
+	nodes := []string{nodeID}
+	usage, _, err := s.getNodesUsage(&nodes, pod)
+	if err != nil || (*usage)[nodeID] == nil {
+		klog.ErrorS(err, "Failed to get the device usage of the node to resize pod", "pod", klog.KObj(pod), "node", nodeID)
+		return podDev
+	}
+	for _, d := range (*usage)[nodeID].Devices.DeviceLists {
+		id := d.Device.ID
+		if grownMem[id] > 0 && d.Device.Usedmem+grownMem[id] > d.Device.Totalmem {
+			s.rejectResize(pod, fmt.Sprintf("GPU %s has %dMiB of memory free, %dMiB more are requested", id, d.Device.Totalmem-d.Device.Usedmem, grownMem[id]))
+			return podDev
+		}
+		if grownCores[id] > 0 && d.Device.Usedcores+grownCores[id] > d.Device.Totalcore {
+			s.rejectResize(pod, fmt.Sprintf("GPU %s has %d cores free, %d more are requested", id, d.Device.Totalcore-d.Device.Usedcores, grownCores[id]))
+			return podDev
+		}
+	}
+
+	res := make(device.PodDevices, len(podDev))
+	for vendor, devs := range podDev {
+		res[vendor] = devs
+	}
+	res[nvidia.NvidiaGPUDevice] = resized
+	annotations := device.EncodePodDevices(map[string]string{nvidia.NvidiaGPUDevice: device.SupportDevices[nvidia.NvidiaGPUDevice]},
+		device.PodDevices{nvidia.NvidiaGPUDevice: resized})
+	annotations[util.GPUResizedAnnotationKey] = util.FormatHandshakeTime(s.clock.Now())
+	if err := s.patchResizedDevices(pod, annotations); err != nil {
+		klog.ErrorS(err, "Failed to record the resized GPUs of pod", "pod", klog.KObj(pod))
+		return podDev
+	}
+	klog.InfoS("Resized the GPUs of pod in place", "pod", klog.KObj(pod), "node", nodeID, "devices", annotations)
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(pod, corev1.EventTypeNormal, EventReasonGPUResized, "Resized the GPU memory and cores of the pod in place")
+	}
+	return res
+}
+
+// patchResizedDevices sets the annotations of the resized devices on the pod.
+func (s *Scheduler) patchResizedDevices(pod *corev1.Pod, annotations map[string]string) error {
+	return util.PatchAnnotationsWithRetry(context.Background(), s.kubeClient, util.PodRef(pod.Namespace, pod.Name), func(meta *metav1.ObjectMeta) error {
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, len(annotations))
+		}
+		maps.Copy(meta.Annotations, annotations)
+		return nil
+	})
+}
+
+// rejectResize marks the resize of the pod infeasible, once.
+func (s *Scheduler) rejectResize(pod *corev1.Pod, msg string) {
+	if pod.Status.Resize == corev1.PodResizeStatusInfeasible {
+		return
+	}
+	klog.InfoS("Rejecting GPU resize of pod", "pod", klog.KObj(pod), "reason", msg)
+	updated := pod.DeepCopy()
+	updated.Status.Resize = corev1.PodResizeStatusInfeasible
+	if _, err := s.kubeClient.CoreV1().Pods(pod.Namespace).UpdateStatus(context.Background(), updated, metav1.UpdateOptions{}); err != nil {
+		klog.ErrorS(err, "Failed to mark the GPU resize of pod infeasible", "pod", klog.KObj(pod))
+	}
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(pod, corev1.EventTypeWarning, EventReasonGPUResizeInfeasible, msg)
+	}
+}
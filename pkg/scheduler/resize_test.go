@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_resizeGPUs(t *testing.T) {
+	initTestDevices(t)
+
+	newPod := func(name string, mem, cores int64) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       k8stypes.UID(name),
+				Annotations: map[string]string{
+					util.AssignedNodeAnnotations: "node1",
+					util.DeviceBindPhase:         util.DeviceBindSuccess,
+				},
+			},
+			Spec: corev1.PodSpec{NodeName: "node1", Containers: []corev1.Container{{
+				Name: "cuda",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":      *resource.NewQuantity(1, resource.DecimalSI),
+					"hami.io/gpumem":   *resource.NewQuantity(mem, resource.DecimalSI),
+					"hami.io/gpucores": *resource.NewQuantity(cores, resource.DecimalSI),
+				}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	assigned := func(mem, cores int32) device.PodDevices {
+		return device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+			{UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: mem, Usedcores: cores},
+		}}}
+	}
+	train := newPod("train", 4000, 30)
+	other := newPod("other", 10000, 50)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = fake.NewSimpleClientset(train, other)
+	now := time.Now()
+	s.clock = testingclock.NewFakeClock(now)
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "GPU-0", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+		}},
+	})
+	s.podManager.AddPod(train, "node1", assigned(4000, 30))
+	s.podManager.AddPod(other, "node1", assigned(10000, 50))
+	getPod := func(pod *corev1.Pod) *corev1.Pod {
+		res, err := s.kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		return res
+	}
+
+	// Unchanged pods are left alone
+	assert.DeepEqual(t, s.resizeGPUs(train, "node1", assigned(4000, 30)), assigned(4000, 30))
+	_, ok := getPod(train).Annotations[util.GPUResizedAnnotationKey]
+	assert.Assert(t, !ok)
+
+	// Shrinks always succeed
+	shrunk := newPod("train", 2000, 20)
+	res := s.resizeGPUs(shrunk, "node1", assigned(4000, 30))
+	assert.DeepEqual(t, res, assigned(2000, 20))
+	updated := getPod(train)
+	assert.Equal(t, updated.Annotations[util.GPUResizedAnnotationKey], util.FormatHandshakeTime(now))
+	pd, err := device.DecodePodDevices(device.SupportDevices, updated.Annotations)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pd[nvidia.NvidiaGPUDevice], assigned(2000, 20)[nvidia.NvidiaGPUDevice])
+	s.podManager.AddPod(shrunk, "node1", res)
+
+	// Grows past the free memory of the device are infeasible
+	grown := newPod("train", 8000, 20)
+	assert.DeepEqual(t, s.resizeGPUs(grown, "node1", assigned(2000, 20)), assigned(2000, 20))
+	assert.Equal(t, getPod(train).Status.Resize, corev1.PodResizeStatusInfeasible)
+
+	// Grows fitting the device succeed
+	assert.DeepEqual(t, s.resizeGPUs(newPod("train", 6000, 50), "node1", assigned(2000, 20)), assigned(6000, 50))
+
+	// The number of GPUs can't be resized
+	more := newPod("other", 10000, 50)
+	more.Spec.Containers[0].Resources.Limits["hami.io/gpu"] = *resource.NewQuantity(2, resource.DecimalSI)
+	assert.DeepEqual(t, s.resizeGPUs(more, "node1", assigned(10000, 50)), assigned(10000, 50))
+	assert.Equal(t, getPod(other).Status.Resize, corev1.PodResizeStatusInfeasible)
+}
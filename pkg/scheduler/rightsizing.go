@@ -0,0 +1,401 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	// memoryUsageMetric is the device memory usage of containers in bytes exported by HAMi monitor, labeled by
+	// podnamespace, podname, ctrname and deviceuuid.
+	memoryUsageMetric = "vGPU_device_memory_usage_in_bytes"
+	// rightSizingHeadroom is the share of the peak usage added to it by recommendations.
+	rightSizingHeadroom = 0.2
+)
+
+// ContainerMemoryUsage is the measured device memory usage of a container on a device.
+type ContainerMemoryUsage struct {
+	Namespace  string
+	Pod        string
+	Container  string
+	DeviceUUID string
+	Bytes      float64
+}
+
+// MemoryUsageSource returns the measured device memory usage of containers.
+type MemoryUsageSource func(ctx context.Context) ([]ContainerMemoryUsage, error)
+
+// NewMemoryUsageSource returns a source running query against the Prometheus server at rawURL,
+// or reading the metrics endpoint of HAMi monitor at rawURL if query is empty.
+func NewMemoryUsageSource(client *http.Client, rawURL, query string) MemoryUsageSource {
+	if query == "" {
+		return monitorMemoryUsage(client, rawURL)
+	}
+	return prometheusMemoryUsage(client, rawURL, query)
+}
+
+func monitorMemoryUsage(client *http.Client, rawURL string) MemoryUsageSource {
+	return func(ctx context.Context) ([]ContainerMemoryUsage, error) {
+		resp, err := get(ctx, client, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metrics of %s: %v", rawURL, err)
+		}
+		res := make([]ContainerMemoryUsage, 0)
+		family, ok := families[memoryUsageMetric]
+		if !ok {
+			return res, nil
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if usage, ok := memoryUsageOf(labels, m.GetGauge().GetValue()); ok {
+				res = append(res, usage)
+			}
+		}
+		return res, nil
+	}
+}
+
+func prometheusMemoryUsage(client *http.Client, rawURL, query string) MemoryUsageSource {
+	queryURL := prometheusQueryURL(rawURL, query)
+	return func(ctx context.Context) ([]ContainerMemoryUsage, error) {
+		samples, err := prometheusVector(ctx, client, queryURL)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]ContainerMemoryUsage, 0, len(samples))
+		for _, sample := range samples {
+			if usage, ok := memoryUsageOf(sample.Metric, sample.Value); ok {
+				res = append(res, usage)
+			}
+		}
+		return res, nil
+	}
+}
+
+// memoryUsageOf returns the usage of a sample labeled like memoryUsageMetric, false if a label is missing.
+func memoryUsageOf(labels map[string]string, value float64) (ContainerMemoryUsage, bool) {
+	usage := ContainerMemoryUsage{
+		Namespace:  labels["podnamespace"],
+		Pod:        labels["podname"],
+		Container:  labels["ctrname"],
+		DeviceUUID: labels["deviceuuid"],
+		Bytes:      value,
+	}
+	if usage.Namespace == "" || usage.Pod == "" || usage.Container == "" || usage.DeviceUUID == "" {
+		return ContainerMemoryUsage{}, false
+	}
+	return usage, true
+}
+
+// workloadRef is a Deployment or StatefulSet recommended the device memory of its containers.
+type workloadRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+type containerRef struct {
+	workload  workloadRef
+	container string
+}
+
+// memorySample is the device memory usage of a container of a workload over its replicas at a scrape, in MB.
+type memorySample struct {
+	time time.Time
+	// used is the peak usage of a device by the container over the replicas.
+	used int32
+	// request is the device memory allocated per device to the container.
+	request int32
+	// devices is the number of devices allocated to the container over the replicas.
+	devices int
+}
+
+// rightSizing is the usage history of the containers of workloads. Only reclaimable is read outside of
+// RecommendRightSizing.
+type rightSizing struct {
+	samples map[containerRef][]memorySample
+	// observed is when the workloads were first observed, they are recommended once observed for the window.
+	observed map[workloadRef]time.Time
+	// replicaSets are the Deployments of ReplicaSets by UID, nil if not owned by one.
+	replicaSets map[k8stypes.UID]*workloadRef
+	// annotated is the recommendation last annotated on workloads, empty if none.
+	annotated map[workloadRef]string
+	// reclaimable is the device memory in MB the recommendations below the requests would reclaim.
+	reclaimable atomic.Int64
+}
+
+// RecommendRightSizing annotates the Deployments and StatefulSets whose peak device memory usage measured by source
+// over config.RightSizingWindow is far below or above their request with a recommendation, every interval until
+// the scheduler stops. The workloads themselves are left alone.
+func (s *Scheduler) RecommendRightSizing(source MemoryUsageSource, interval time.Duration) {
+	klog.InfoS("Entering RecommendRightSizing", "interval", interval, "window", config.RightSizingWindow)
+	defer klog.InfoS("Exiting RecommendRightSizing")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		usage, err := source(ctx)
+		if err != nil {
+			klog.ErrorS(err, "Failed to scrape device memory usage of containers")
+		} else {
+			s.syncRightSizing(ctx, s.clock.Now(), usage)
+		}
+		cancel()
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// ReclaimableGPUMemory returns the device memory in MB the current recommendations below the requests of
+// workloads would reclaim.
+func (s *Scheduler) ReclaimableGPUMemory() int64 {
+	return s.rightSizing.reclaimable.Load()
+}
+
+// syncRightSizing adds the usage scraped at now to the history, and annotates the workloads observed for the
+// window with their recommendation, or removes it if their requests are within the threshold of it.
+func (s *Scheduler) syncRightSizing(ctx context.Context, now time.Time, usage []ContainerMemoryUsage) {
+	r := &s.rightSizing
+	if r.samples == nil {
+		r.samples = make(map[containerRef][]memorySample)
+		r.observed = make(map[workloadRef]time.Time)
+		r.replicaSets = make(map[k8stypes.UID]*workloadRef)
+		r.annotated = make(map[workloadRef]string)
+	}
+	pods := make(map[k8stypes.NamespacedName]*device.PodInfo)
+	for _, pi := range s.podManager.ListPodsInfo() {
+		pods[k8stypes.NamespacedName{Namespace: pi.Namespace, Name: pi.Name}] = pi
+	}
+	current := make(map[containerRef]*memorySample)
+	seenReplicaSets := make(map[k8stypes.UID]bool)
+	for _, u := range usage {
+		pi, ok := pods[k8stypes.NamespacedName{Namespace: u.Namespace, Name: u.Pod}]
+		if !ok {
+			continue
+		}
+		if ref := metav1.GetControllerOf(pi.Pod); ref != nil && ref.Kind == "ReplicaSet" {
+			seenReplicaSets[ref.UID] = true
+		}
+		workload, ok := s.workloadOf(ctx, pi)
+		if !ok {
+			continue
+		}
+		request := containerMemoryRequest(pi, u.Container, u.DeviceUUID)
+		if request <= 0 {
+			continue
+		}
+		key := containerRef{workload: workload, container: u.Container}
+		sample, ok := current[key]
+		if !ok {
+			sample = &memorySample{time: now}
+			current[key] = sample
+		}
+		sample.used = max(sample.used, int32(math.Ceil(u.Bytes/(1024*1024))))
+		sample.request = max(sample.request, request)
+		sample.devices++
+	}
+	for uid := range r.replicaSets {
+		if !seenReplicaSets[uid] {
+			delete(r.replicaSets, uid)
+		}
+	}
+	for key, sample := range current {
+		r.samples[key] = append(r.samples[key], *sample)
+		if _, ok := r.observed[key.workload]; !ok {
+			r.observed[key.workload] = now
+		}
+	}
+
+	// Samples past the window are pruned, and so are the workloads without samples left.
+	since := now.Add(-config.RightSizingWindow)
+	active := make(map[workloadRef]bool)
+	for key, samples := range r.samples {
+		i := 0
+		for i < len(samples) && samples[i].time.Before(since) {
+			i++
+		}
+		if i == len(samples) {
+			delete(r.samples, key)
+			continue
+		}
+		r.samples[key] = samples[i:]
+		active[key.workload] = true
+	}
+	for workload := range r.observed {
+		if !active[workload] {
+			delete(r.observed, workload)
+			delete(r.annotated, workload)
+		}
+	}
+
+	recommendations := make(map[workloadRef]map[string]int32)
+	var reclaimable int64
+	for key, samples := range r.samples {
+		if r.observed[key.workload].After(since) {
+			continue
+		}
+		var peak int32
+		for _, sample := range samples {
+			peak = max(peak, sample.used)
+		}
+		latest := samples[len(samples)-1]
+		recommended := max(int32(math.Ceil(float64(peak)*(1+rightSizingHeadroom))), 1)
+		if math.Abs(float64(recommended-latest.request)) <= config.RightSizingThreshold*float64(latest.request) {
+			continue
+		}
+		if recommendations[key.workload] == nil {
+			recommendations[key.workload] = make(map[string]int32)
+		}
+		recommendations[key.workload][key.container] = recommended
+		if recommended < latest.request && latest.time.Equal(now) {
+			reclaimable += int64(latest.request-recommended) * int64(latest.devices)
+		}
+	}
+	r.reclaimable.Store(reclaimable)
+
+	for workload, observed := range r.observed {
+		if observed.After(since) {
+			continue
+		}
+		value := ""
+		if containers, ok := recommendations[workload]; ok {
+			data, err := json.Marshal(containers)
+			if err != nil {
+				continue
+			}
+			value = string(data)
+		}
+		if annotated, ok := r.annotated[workload]; ok && annotated == value {
+			continue
+		}
+		if err := s.annotateWorkload(ctx, workload, value); err != nil {
+			klog.ErrorS(err, "Failed to annotate the device memory recommendation of workload", "kind", workload.Kind,
+				"workload", klog.KRef(workload.Namespace, workload.Name))
+			continue
+		}
+		r.annotated[workload] = value
+	}
+}
+
+// workloadOf returns the StatefulSet or the Deployment of the ReplicaSet controlling the pod, false if controlled
+// by neither.
+func (s *Scheduler) workloadOf(ctx context.Context, pi *device.PodInfo) (workloadRef, bool) {
+	ref := metav1.GetControllerOf(pi.Pod)
+	if ref == nil {
+		return workloadRef{}, false
+	}
+	switch ref.Kind {
+	case "StatefulSet":
+		return workloadRef{Kind: ref.Kind, Namespace: pi.Namespace, Name: ref.Name}, true
+	case "ReplicaSet":
+		owner, ok := s.rightSizing.replicaSets[ref.UID]
+		if !ok {
+			rs, err := s.kubeClient.AppsV1().ReplicaSets(pi.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			if err != nil {
+				klog.V(4).ErrorS(err, "Failed to get the ReplicaSet of pod", "pod", klog.KObj(pi.Pod))
+				return workloadRef{}, false
+			}
+			if dref := metav1.GetControllerOf(rs); dref != nil && dref.Kind == "Deployment" {
+				owner = &workloadRef{Kind: dref.Kind, Namespace: pi.Namespace, Name: dref.Name}
+			}
+			s.rightSizing.replicaSets[ref.UID] = owner
+		}
+		if owner == nil {
+			return workloadRef{}, false
+		}
+		return *owner, true
+	}
+	return workloadRef{}, false
+}
+
+// containerMemoryRequest returns the device memory in MB allocated to the container of the pod on the device, 0 if
+// it's not allocated the device.
+func containerMemoryRequest(pi *device.PodInfo, container, uuid string) int32 {
+	idx := -1
+	for i, ctr := range pi.Spec.Containers {
+		if ctr.Name == container {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return 0
+	}
+	for _, podSingle := range pi.Devices {
+		if idx >= len(podSingle) {
+			continue
+		}
+		for _, cd := range podSingle[idx] {
+			if strings.Split(cd.UUID, "[")[0] == uuid {
+				return cd.Usedmem
+			}
+		}
+	}
+	return 0
+}
+
+// annotateWorkload sets the recommendation annotation of the workload to value, or removes it if value is empty.
+func (s *Scheduler) annotateWorkload(ctx context.Context, w workloadRef, value string) error {
+	changed := false
+	err := util.PatchAnnotationsWithRetry(ctx, s.kubeClient, util.ObjectRef{Kind: w.Kind, Namespace: w.Namespace, Name: w.Name}, func(meta *metav1.ObjectMeta) error {
+		changed = meta.Annotations[util.RecommendedGPUMemoryAnnotationKey] != value
+		if value == "" {
+			delete(meta.Annotations, util.RecommendedGPUMemoryAnnotationKey)
+			return nil
+		}
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, 1)
+		}
+		meta.Annotations[util.RecommendedGPUMemoryAnnotationKey] = value
+		return nil
+	})
+	if err != nil || !changed {
+		return err
+	}
+	klog.InfoS("Annotated the device memory recommendation of workload", "kind", w.Kind,
+		"workload", klog.KRef(w.Namespace, w.Name), "recommendation", value)
+	return nil
+}
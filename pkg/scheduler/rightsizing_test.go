@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_MemoryUsageSource(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metrics":
+			fmt.Fprint(w, `# HELP vGPU_device_memory_usage_in_bytes vGPU device usage
+# TYPE vGPU_device_memory_usage_in_bytes gauge
+vGPU_device_memory_usage_in_bytes{ctrname="infer",deviceuuid="GPU-0",podname="infer-a",podnamespace="default",vdeviceid="0"} 1.048576e+09
+vGPU_device_memory_usage_in_bytes{ctrname="infer",deviceuuid="GPU-1",podname="infer-b",vdeviceid="0"} 2e+09
+`)
+		case "/api/v1/query":
+			gotQuery = r.URL.Query().Get("query")
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"podnamespace":"default","podname":"train-0","ctrname":"train","deviceuuid":"GPU-2"},"value":[1700000000,"3e+09"]},
+				{"metric":{"deviceuuid":"GPU-3"},"value":[1700000000,"1e+09"]}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	got, err := NewMemoryUsageSource(server.Client(), server.URL+"/metrics", "")(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, []ContainerMemoryUsage{
+		{Namespace: "default", Pod: "infer-a", Container: "infer", DeviceUUID: "GPU-0", Bytes: 1048576000},
+	})
+
+	query := "max by (podnamespace, podname, ctrname, deviceuuid) (vGPU_device_memory_usage_in_bytes)"
+	got, err = NewMemoryUsageSource(server.Client(), server.URL, query)(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, gotQuery, query)
+	assert.DeepEqual(t, got, []ContainerMemoryUsage{
+		{Namespace: "default", Pod: "train-0", Container: "train", DeviceUUID: "GPU-2", Bytes: 3e9},
+	})
+}
+
+func Test_syncRightSizing(t *testing.T) {
+	restoreConfig(t)
+	config.RightSizingWindow = time.Hour
+	config.RightSizingThreshold = 0.5
+
+	deployment := func(name string, annotations map[string]string) (*appsv1.Deployment, *appsv1.ReplicaSet) {
+		d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name), Annotations: annotations}}
+		rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: name + "-7d9", Namespace: "default", UID: k8stypes.UID(name + "-7d9"),
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: name, UID: d.UID, Controller: ptr.To(true)}},
+		}}
+		return d, rs
+	}
+	infer, inferRS := deployment("infer", nil)
+	// Recommended before, the stale recommendation is removed.
+	batch, batchRS := deployment("batch", map[string]string{util.RecommendedGPUMemoryAnnotationKey: `{"batch":1000}`})
+	train := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "train"}}
+	kubeClient := fake.NewSimpleClientset(infer, inferRS, batch, batchRS, train)
+
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = kubeClient
+	addPod := func(name, container, kind string, owner *metav1.ObjectMeta, uuid string, usedmem int32) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name), OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: kind, Name: owner.Name, UID: owner.UID, Controller: ptr.To(true)},
+			}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "sidecar"}, {Name: container}}},
+		}
+		s.podManager.AddPod(pod, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: {{}, {{UUID: uuid, Type: nvidia.NvidiaGPUDevice, Usedmem: usedmem}}}})
+	}
+	addPod("infer-a", "infer", "ReplicaSet", &inferRS.ObjectMeta, "GPU-0", 8000)
+	addPod("infer-b", "infer", "ReplicaSet", &inferRS.ObjectMeta, "GPU-1", 8000)
+	addPod("batch-a", "batch", "ReplicaSet", &batchRS.ObjectMeta, "GPU-2", 4000)
+	addPod("train-0", "train", "StatefulSet", &train.ObjectMeta, "GPU-3", 2000)
+
+	usage := func(pod, container, uuid string, mb float64) ContainerMemoryUsage {
+		return ContainerMemoryUsage{Namespace: "default", Pod: pod, Container: container, DeviceUUID: uuid, Bytes: mb * 1024 * 1024}
+	}
+	recommendation := func(kind, name string) string {
+		var annotations map[string]string
+		if kind == "Deployment" {
+			d, err := kubeClient.AppsV1().Deployments("default").Get(context.Background(), name, metav1.GetOptions{})
+			assert.NilError(t, err)
+			annotations = d.Annotations
+		} else {
+			sts, err := kubeClient.AppsV1().StatefulSets("default").Get(context.Background(), name, metav1.GetOptions{})
+			assert.NilError(t, err)
+			annotations = sts.Annotations
+		}
+		return annotations[util.RecommendedGPUMemoryAnnotationKey]
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := [][]ContainerMemoryUsage{
+		{
+			usage("infer-a", "infer", "GPU-0", 1500), usage("infer-b", "infer", "GPU-1", 900),
+			usage("batch-a", "batch", "GPU-2", 2500), usage("train-0", "train", "GPU-3", 2800),
+			// Not scheduled by HAMi.
+			usage("unknown", "unknown", "GPU-4", 100),
+		},
+		{
+			usage("infer-a", "infer", "GPU-0", 1000), usage("infer-b", "infer", "GPU-1", 1100),
+			usage("batch-a", "batch", "GPU-2", 3000), usage("train-0", "train", "GPU-3", 3000),
+		},
+	}
+	s.syncRightSizing(context.Background(), now, history[0])
+	s.syncRightSizing(context.Background(), now.Add(30*time.Minute), history[1])
+	// Not observed for the window yet.
+	assert.Equal(t, recommendation("Deployment", "infer"), "")
+	assert.Equal(t, recommendation("Deployment", "batch"), `{"batch":1000}`)
+	assert.Equal(t, s.ReclaimableGPUMemory(), int64(0))
+
+	s.syncRightSizing(context.Background(), now.Add(time.Hour), history[1])
+	// The peaks of 1500, 3000 and 3000 MB with the headroom are 1800, 3600 and 3600 MB.
+	assert.Equal(t, recommendation("Deployment", "infer"), `{"infer":1800}`)
+	assert.Equal(t, recommendation("Deployment", "batch"), "")
+	assert.Equal(t, recommendation("StatefulSet", "train"), `{"train":3600}`)
+	assert.Equal(t, s.ReclaimableGPUMemory(), int64(2*(8000-1800)))
+
+	// The peaks past the window are forgotten.
+	s.syncRightSizing(context.Background(), now.Add(3*time.Hour), []ContainerMemoryUsage{
+		usage("infer-a", "infer", "GPU-0", 7000), usage("train-0", "train", "GPU-3", 2000),
+	})
+	assert.Equal(t, recommendation("Deployment", "infer"), "")
+	assert.Equal(t, recommendation("StatefulSet", "train"), "")
+	assert.Equal(t, s.ReclaimableGPUMemory(), int64(0))
+	assert.Equal(t, len(s.rightSizing.replicaSets), 1)
+}
@@ -22,15 +22,22 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
 
 	"github.com/Project-HAMi/HAMi/pkg/scheduler"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util/faultinject"
 )
 
+// drainingRetryAfter is the delay in seconds after which clients should retry requests rejected on shutdown.
+const drainingRetryAfter = 5
+
 func checkBody(w http.ResponseWriter, r *http.Request) {
 	if r.Body == nil {
 		http.Error(w, "Please send a request body", 400)
@@ -128,15 +135,108 @@ func bind(args extenderv1.ExtenderBindingArgs, bindFunc func(string, string, typ
 	}
 }
 
-func WebHookRoute() httprouter.Handle {
-	h, err := scheduler.NewWebHook()
+// jsonRoute decodes the request body into Req and writes the response of handle as json. It
+// always responds 200 since both kube-scheduler extenders and volcano expect errors in the body.
+func jsonRoute[Req any, Resp any](name string, handle func(Req) Resp, decodeErr func(error) Resp) httprouter.Handle {
+	klog.Infof("Initializing %s route", name)
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		klog.V(5).Infof("Entering %s handler", name)
+		checkBody(w, r)
+
+		var req Req
+		var resp Resp
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			klog.ErrorS(err, "Failed to decode request", "route", name)
+			resp = decodeErr(err)
+		} else {
+			resp = handle(req)
+		}
+		writeJSON(w, name, resp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, name string, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	if body, err := json.Marshal(resp); err != nil {
+		klog.ErrorS(err, "Failed to marshal response", "route", name)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+	} else {
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
+
+func RebalanceCandidatesRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		report, err := s.RebalanceCandidates(r.Context())
+		if err != nil {
+			klog.ErrorS(err, "Failed to compute rebalance candidates")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, "rebalance candidates", report)
+	}
+}
+
+func SummaryRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeJSON(w, "summary", s.Summary(r.URL.Query().Get("namespace")))
+	}
+}
+
+// DiagnosticsRoute serves the diagnostics of the scheduler, with the values of flags redacted.
+func DiagnosticsRoute(s *scheduler.Scheduler, flags *pflag.FlagSet) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeJSON(w, "diagnostics", s.Diagnostics(flags))
+	}
+}
+
+func SimulateRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("simulate", s.Simulate, func(err error) scheduler.SimulateResponse {
+		return scheduler.SimulateResponse{ErrorMessage: err.Error()}
+	})
+}
+
+func CapacityRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("capacity", s.Capacity, func(err error) scheduler.CapacityResponse {
+		return scheduler.CapacityResponse{ErrorMessage: err.Error()}
+	})
+}
+
+func VolcanoPredicateRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("volcano predicate", s.VolcanoPredicate, func(err error) scheduler.VolcanoPredicateResponse {
+		return scheduler.VolcanoPredicateResponse{ErrorMessage: err.Error()}
+	})
+}
+
+func VolcanoPrioritizeRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("volcano prioritize", s.VolcanoPrioritize, func(err error) scheduler.VolcanoPrioritizeResponse {
+		return scheduler.VolcanoPrioritizeResponse{ErrorMessage: err.Error()}
+	})
+}
+
+func VolcanoAllocateRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("volcano allocate", s.VolcanoAllocate, func(err error) scheduler.VolcanoEventResponse {
+		return scheduler.VolcanoEventResponse{ErrorMessage: err.Error()}
+	})
+}
+
+func VolcanoDeallocateRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("volcano deallocate", s.VolcanoDeallocate, func(err error) scheduler.VolcanoEventResponse {
+		return scheduler.VolcanoEventResponse{ErrorMessage: err.Error()}
+	})
+}
+
+func WebHookRoute(s *scheduler.Scheduler) (httprouter.Handle, error) {
+	h, err := scheduler.NewWebHook(s, s.LimitRangeLister(), s)
 	if err != nil {
-		klog.ErrorS(err, "Failed to create new webhook")
+		return nil, err
 	}
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		klog.Infof("Handling webhook request on %s", r.URL.Path)
 		h.ServeHTTP(w, r)
-	}
+	}, nil
 }
 
 func HealthzRoute() httprouter.Handle {
@@ -145,3 +245,63 @@ func HealthzRoute() httprouter.Handle {
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// ReloadRoute reloads the configuration from the device config file, responding 422 if it's rejected.
+func ReloadRoute() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if err := config.Reload(); err != nil {
+			klog.ErrorS(err, "Rejected configuration reload")
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// FaultInjectionRoute serves the faults injected by point.
+func FaultInjectionRoute() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeJSON(w, "fault injection", faultinject.List())
+	}
+}
+
+// SetFaultRoute injects the fault of the request body at the point of the path, responding 422 if it's rejected.
+func SetFaultRoute() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		var fault faultinject.Fault
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := faultinject.Set(faultinject.Point(ps.ByName("point")), fault); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ClearFaultRoute stops injecting faults at the point of the path, or at every point for "all".
+func ClearFaultRoute() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		point := faultinject.Point(ps.ByName("point"))
+		if point == "all" {
+			point = ""
+		}
+		faultinject.Clear(point)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// DrainingRoute serves h until the scheduler starts shutting down, then responds 503 so that requests are
+// retried once another replica or the restarted scheduler serves them.
+func DrainingRoute(s *scheduler.Scheduler, h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if s.ShuttingDown() {
+			w.Header().Set("Retry-After", strconv.Itoa(drainingRetryAfter))
+			http.Error(w, scheduler.ErrShuttingDown.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		h(w, r, ps)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler"
+	apiv1 "github.com/Project-HAMi/HAMi/pkg/scheduler/api/v1"
+)
+
+func V1FilterRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("v1 filter", func(args apiv1.FilterArgs) *apiv1.FilterResult {
+		result, err := s.Filter(args.ToExtender())
+		if err != nil {
+			klog.ErrorS(err, "Filter error for pod", "pod", klog.KObj(args.Pod))
+			return &apiv1.FilterResult{Error: err.Error()}
+		}
+		return apiv1.FilterResultFromExtender(result)
+	}, func(err error) *apiv1.FilterResult {
+		return &apiv1.FilterResult{Error: err.Error()}
+	})
+}
+
+func V1BindRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return jsonRoute("v1 bind", func(args apiv1.BindArgs) *apiv1.BindResult {
+		result, err := s.Bind(args.ToExtender())
+		if err != nil {
+			klog.ErrorS(err, "Bind error for pod", "pod", args.PodName, "namespace", args.PodNamespace)
+			return &apiv1.BindResult{Error: err.Error()}
+		}
+		return apiv1.BindResultFromExtender(result)
+	}, func(err error) *apiv1.BindResult {
+		return &apiv1.BindResult{Error: err.Error()}
+	})
+}
+
+func V1NodesRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeJSON(w, "v1 nodes", nodeListV1(*s.InspectAllNodesUsage()))
+	}
+}
+
+func V1NodeSerialsRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeJSON(w, "v1 node serials", deviceSerialListV1(s.DeviceSerials()))
+	}
+}
+
+func V1DecisionsRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeJSON(w, "v1 decisions", decisionListV1(s.ListDecisions()))
+	}
+}
+
+func OpenAPIRoute() httprouter.Handle {
+	doc := apiv1.OpenAPI()
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		writeJSON(w, "openapi", doc)
+	}
+}
+
+// DeprecatedRoute serves h on a deprecated path, telling clients the successor path.
+func DeprecatedRoute(h httprouter.Handle, path, successor string) httprouter.Handle {
+	var once sync.Once
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		once.Do(func() {
+			klog.Warningf("Deprecated endpoint %s is used, switch to %s", path, successor)
+		})
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		h(w, r, ps)
+	}
+}
+
+func nodeListV1(usage map[string]*scheduler.NodeUsage) *apiv1.NodeList {
+	list := &apiv1.NodeList{
+		TypeMeta: metav1.TypeMeta{APIVersion: apiv1.GroupVersion, Kind: "NodeList"},
+		Items:    make([]apiv1.Node, 0, len(usage)),
+	}
+	for name, n := range usage {
+		node := apiv1.Node{Name: name, Devices: make([]apiv1.Device, 0, len(n.Devices.DeviceLists))}
+		for _, d := range n.Devices.DeviceLists {
+			dev := apiv1.Device{
+				ID:          d.Device.ID,
+				Serial:      d.Device.Serial,
+				Index:       d.Device.Index,
+				Type:        d.Device.Type,
+				Mode:        d.Device.Mode,
+				Numa:        d.Device.Numa,
+				Health:      d.Device.Health,
+				Count:       d.Device.Count,
+				Used:        d.Device.Used,
+				TotalMemory: d.Device.Totalmem,
+				UsedMemory:  d.Device.Usedmem,
+				TotalCores:  d.Device.Totalcore,
+				UsedCores:   d.Device.Usedcores,
+			}
+			for _, p := range d.Device.PodInfos {
+				dev.Pods = append(dev.Pods, apiv1.PodReference{Namespace: p.Namespace, Name: p.Name, UID: p.UID})
+			}
+			node.Devices = append(node.Devices, dev)
+		}
+		sort.Slice(node.Devices, func(i, j int) bool { return node.Devices[i].ID < node.Devices[j].ID })
+		for _, o := range n.Orphaned {
+			node.Orphaned = append(node.Orphaned, apiv1.OrphanedDevice{
+				ID:         o.Device.UUID,
+				Pod:        apiv1.PodReference{Namespace: o.Pod.Namespace, Name: o.Pod.Name, UID: o.Pod.UID},
+				UsedMemory: o.Device.Usedmem,
+				UsedCores:  o.Device.Usedcores,
+			})
+		}
+		list.Items = append(list.Items, node)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list
+}
+
+func deviceSerialListV1(serials []scheduler.DeviceSerial) *apiv1.DeviceSerialList {
+	list := &apiv1.DeviceSerialList{
+		TypeMeta: metav1.TypeMeta{APIVersion: apiv1.GroupVersion, Kind: "DeviceSerialList"},
+		Items:    make([]apiv1.DeviceSerial, 0, len(serials)),
+	}
+	for _, d := range serials {
+		list.Items = append(list.Items, apiv1.DeviceSerial{Node: d.Node, Serial: d.Serial, UUID: d.UUID})
+	}
+	return list
+}
+
+func decisionListV1(decisions []scheduler.Decision) *apiv1.DecisionList {
+	list := &apiv1.DecisionList{
+		TypeMeta: metav1.TypeMeta{APIVersion: apiv1.GroupVersion, Kind: "DecisionList"},
+		Items:    make([]apiv1.Decision, 0, len(decisions)),
+	}
+	for _, d := range decisions {
+		list.Items = append(list.Items, apiv1.Decision{
+			Time:      metav1.NewTime(d.Time),
+			Pod:       apiv1.PodReference{Namespace: d.Pod.Namespace, Name: d.Pod.Name, UID: d.UID},
+			Stage:     apiv1.DecisionStage(d.Stage),
+			Succeeded: d.Succeeded,
+			Node:      d.Node,
+			Devices:   allocatedDevicesV1(d.Devices),
+			Message:   d.Message,
+		})
+	}
+	return list
+}
+
+func allocatedDevicesV1(devices device.PodDevices) []apiv1.AllocatedDevice {
+	var res []apiv1.AllocatedDevice
+	vendors := make([]string, 0, len(devices))
+	for vendor := range devices {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors)
+	for _, vendor := range vendors {
+		for ctr, ctrDevices := range devices[vendor] {
+			for _, d := range ctrDevices {
+				res = append(res, apiv1.AllocatedDevice{
+					Vendor:     vendor,
+					Container:  ctr,
+					UUID:       d.UUID,
+					Type:       d.Type,
+					UsedMemory: d.Usedmem,
+					UsedCores:  d.Usedcores,
+				})
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// filterContainerRuntime applies the container runtime affinity of the pod to the candidate nodes. With the
+// strict affinity, the nodes running another runtime are returned as failed, with the preferred one the nodes
+// running the runtime of the pod are returned as preferred.
+func (s *Scheduler) filterContainerRuntime(pod *corev1.Pod, nodeNames []string) ([]string, map[string]string, map[string]bool) {
+	runtime := config.ContainerRuntime
+	if pod.Annotations[util.ContainerRuntimeAnnotationKey] != "" {
+		runtime = pod.Annotations[util.ContainerRuntimeAnnotationKey]
+	}
+	if runtime == "" {
+		return nodeNames, nil, nil
+	}
+	affinity, _ := util.GetContainerRuntimeAffinity(pod, util.ContainerRuntimeAffinity(config.ContainerRuntimeAffinity))
+	candidates := make([]string, 0, len(nodeNames))
+	failed := make(map[string]string)
+	preferred := make(map[string]bool)
+	for _, nodeName := range nodeNames {
+		matched := false
+		// Unregistered nodes are failed when their usage is retrieved.
+		if node, err := s.GetNode(nodeName); err == nil && node.Node != nil {
+			matched = strings.EqualFold(util.NodeContainerRuntime(node.Node), runtime)
+		}
+		switch {
+		case matched:
+			candidates = append(candidates, nodeName)
+			preferred[nodeName] = true
+		case affinity == util.ContainerRuntimeAffinityStrict:
+			failed[nodeName] = common.NodeContainerRuntimeMismatch
+		default:
+			candidates = append(candidates, nodeName)
+		}
+	}
+	klog.V(4).InfoS("Applied container runtime affinity", "pod", klog.KObj(pod), "runtime", runtime, "affinity", affinity, "candidates", len(candidates), "matchedNodes", len(preferred))
+	return candidates, failed, preferred
+}
+
+// checkRuntimeClass returns why the runtime class of the pod requesting devices isn't one of
+// config.AllowedRuntimeClasses, nil if it is or any is allowed.
+func checkRuntimeClass(pod *corev1.Pod) error {
+	if len(config.AllowedRuntimeClasses) == 0 {
+		return nil
+	}
+	if pod.Spec.RuntimeClassName == nil {
+		return fmt.Errorf("pods requesting devices must set a runtime class, one of %s", strings.Join(config.AllowedRuntimeClasses, ", "))
+	}
+	if !slices.Contains(config.AllowedRuntimeClasses, *pod.Spec.RuntimeClassName) {
+		return fmt.Errorf("runtime class %q is not allowed for pods requesting devices, must be one of %s", *pod.Spec.RuntimeClassName, strings.Join(config.AllowedRuntimeClasses, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_filterContainerRuntime(t *testing.T) {
+	restoreConfig(t)
+	config.ContainerRuntime = ""
+	config.ContainerRuntimeAffinity = string(util.ContainerRuntimeAffinityStrict)
+
+	s := NewScheduler()
+	defer s.Stop()
+	nodes := map[string]*corev1.Node{
+		"node1": {
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{ContainerRuntimeVersion: "containerd://1.7.2"}},
+		},
+		// The label takes precedence over the status
+		"node2": {
+			ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{util.ContainerRuntimeAnnotationKey: "cri-o"}},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{ContainerRuntimeVersion: "containerd://1.7.2"}},
+		},
+		"node3": {
+			ObjectMeta: metav1.ObjectMeta{Name: "node3"},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{ContainerRuntimeVersion: "cri-o://1.30.0"}},
+		},
+	}
+	for name, node := range nodes {
+		s.addNode(name, &device.NodeInfo{
+			ID:   name,
+			Node: node,
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+				ID: name + "-GPU", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true,
+			}}},
+		})
+	}
+	newPod := func(annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", Annotations: annotations}}
+	}
+
+	tests := []struct {
+		name           string
+		runtime        string
+		pod            *corev1.Pod
+		wantCandidates []string
+		wantFailed     map[string]string
+		wantPreferred  map[string]bool
+	}{
+		{
+			name:           "no runtime",
+			pod:            newPod(nil),
+			wantCandidates: []string{"node1", "node2", "node3"},
+		},
+		{
+			name:           "strict runtime of the label",
+			pod:            newPod(map[string]string{util.ContainerRuntimeAnnotationKey: "CRI-O"}),
+			wantCandidates: []string{"node2", "node3"},
+			wantFailed:     map[string]string{"node1": common.NodeContainerRuntimeMismatch},
+			wantPreferred:  map[string]bool{"node2": true, "node3": true},
+		},
+		{
+			name:           "default runtime",
+			runtime:        "containerd",
+			pod:            newPod(nil),
+			wantCandidates: []string{"node1"},
+			wantFailed:     map[string]string{"node2": common.NodeContainerRuntimeMismatch, "node3": common.NodeContainerRuntimeMismatch},
+			wantPreferred:  map[string]bool{"node1": true},
+		},
+		{
+			name:    "preferred runtime",
+			runtime: "containerd",
+			pod: newPod(map[string]string{
+				util.ContainerRuntimeAffinityAnnotationKey: string(util.ContainerRuntimeAffinityPreferred),
+			}),
+			wantCandidates: []string{"node1", "node2", "node3"},
+			wantFailed:     map[string]string{},
+			wantPreferred:  map[string]bool{"node1": true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.ContainerRuntime = test.runtime
+			candidates, failed, preferred := s.filterContainerRuntime(test.pod, []string{"node1", "node2", "node3"})
+			assert.DeepEqual(t, candidates, test.wantCandidates)
+			assert.DeepEqual(t, failed, test.wantFailed)
+			assert.DeepEqual(t, preferred, test.wantPreferred)
+		})
+	}
+}
+
+func Test_checkRuntimeClass(t *testing.T) {
+	restoreConfig(t)
+	newPod := func(runtimeClass *string) *corev1.Pod {
+		return &corev1.Pod{Spec: corev1.PodSpec{RuntimeClassName: runtimeClass}}
+	}
+	nvidiaClass, runc := "nvidia", "runc"
+	tests := []struct {
+		name    string
+		allowed []string
+		pod     *corev1.Pod
+		wantErr string
+	}{
+		{name: "any runtime class", pod: newPod(&runc)},
+		{name: "allowed runtime class", allowed: []string{"nvidia", "nvidia-cdi"}, pod: newPod(&nvidiaClass)},
+		{name: "no runtime class", allowed: []string{"nvidia"}, pod: newPod(nil), wantErr: "pods requesting devices must set a runtime class, one of nvidia"},
+		{name: "runtime class not allowed", allowed: []string{"nvidia", "nvidia-cdi"}, pod: newPod(&runc), wantErr: `runtime class "runc" is not allowed for pods requesting devices, must be one of nvidia, nvidia-cdi`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.AllowedRuntimeClasses = test.allowed
+			err := checkRuntimeClass(test.pod)
+			if test.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, test.wantErr)
+		})
+	}
+}
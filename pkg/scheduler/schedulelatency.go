@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"maps"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ScheduleLatencyBuckets are the upper bounds in seconds of the buckets of the time to schedule pods.
+var ScheduleLatencyBuckets = []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+
+// ScheduleLatencyKey are the labels of the time to schedule pods.
+type ScheduleLatencyKey struct {
+	Namespace string
+	// Preempted is set if pods were preempted for the pod.
+	Preempted bool
+}
+
+// ScheduleLatency is the histogram of the time to schedule pods, from their creation to their bind.
+type ScheduleLatency struct {
+	Count uint64
+	Sum   float64
+	// Buckets are the cumulative counts by upper bound of ScheduleLatencyBuckets.
+	Buckets map[float64]uint64
+}
+
+type scheduleLatencies struct {
+	mutex      sync.Mutex
+	histograms map[ScheduleLatencyKey]*ScheduleLatency
+}
+
+// observeScheduleLatency records the time from the creation of the pod to its bind now. The kube-scheduler
+// nominates a node to pods it preempts other pods for.
+func (s *Scheduler) observeScheduleLatency(pod *corev1.Pod) {
+	if pod.CreationTimestamp.IsZero() {
+		return
+	}
+	seconds := s.clock.Since(pod.CreationTimestamp.Time).Seconds()
+	key := ScheduleLatencyKey{Namespace: pod.Namespace, Preempted: pod.Status.NominatedNodeName != ""}
+	s.scheduleLatencies.mutex.Lock()
+	defer s.scheduleLatencies.mutex.Unlock()
+	if s.scheduleLatencies.histograms == nil {
+		s.scheduleLatencies.histograms = make(map[ScheduleLatencyKey]*ScheduleLatency)
+	}
+	h, ok := s.scheduleLatencies.histograms[key]
+	if !ok {
+		h = &ScheduleLatency{Buckets: make(map[float64]uint64, len(ScheduleLatencyBuckets))}
+		for _, bound := range ScheduleLatencyBuckets {
+			h.Buckets[bound] = 0
+		}
+		s.scheduleLatencies.histograms[key] = h
+	}
+	h.Count++
+	h.Sum += seconds
+	for _, bound := range ScheduleLatencyBuckets {
+		if seconds <= bound {
+			h.Buckets[bound]++
+		}
+	}
+}
+
+// ScheduleLatencies returns the histograms of the time to schedule pods by labels.
+func (s *Scheduler) ScheduleLatencies() map[ScheduleLatencyKey]ScheduleLatency {
+	s.scheduleLatencies.mutex.Lock()
+	defer s.scheduleLatencies.mutex.Unlock()
+	res := make(map[ScheduleLatencyKey]ScheduleLatency, len(s.scheduleLatencies.histograms))
+	for key, h := range s.scheduleLatencies.histograms {
+		res[key] = ScheduleLatency{Count: h.Count, Sum: h.Sum, Buckets: maps.Clone(h.Buckets)}
+	}
+	return res
+}
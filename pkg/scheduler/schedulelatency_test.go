@@ -0,0 +1,78 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func Test_observeScheduleLatency(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewScheduler()
+	defer s.Stop()
+	s.clock = testingclock.NewFakeClock(now)
+
+	newPod := func(namespace string, age time.Duration, nominatedNode string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Status:     corev1.PodStatus{NominatedNodeName: nominatedNode},
+		}
+	}
+	for _, pod := range []*corev1.Pod{
+		newPod("default", 500*time.Millisecond, ""),
+		newPod("default", 4*time.Second, ""),
+		newPod("default", 45*time.Second, ""),
+		newPod("default", 2*time.Hour, ""),
+		newPod("default", 90*time.Second, "node1"),
+		newPod("team-a", 10*time.Second, ""),
+		// Pods without a creation time aren't observed.
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default"}},
+	} {
+		s.observeScheduleLatency(pod)
+	}
+
+	want := map[ScheduleLatencyKey]ScheduleLatency{
+		{Namespace: "default"}: {
+			Count: 4,
+			Sum:   0.5 + 4 + 45 + 7200,
+			Buckets: map[float64]uint64{
+				1: 1, 2.5: 1, 5: 2, 10: 2, 30: 2, 60: 3, 120: 3, 300: 3, 600: 3, 1800: 3, 3600: 3,
+			},
+		},
+		{Namespace: "default", Preempted: true}: {
+			Count: 1,
+			Sum:   90,
+			Buckets: map[float64]uint64{
+				1: 0, 2.5: 0, 5: 0, 10: 0, 30: 0, 60: 0, 120: 1, 300: 1, 600: 1, 1800: 1, 3600: 1,
+			},
+		},
+		{Namespace: "team-a"}: {
+			Count: 1,
+			Sum:   10,
+			Buckets: map[float64]uint64{
+				1: 0, 2.5: 0, 5: 0, 10: 1, 30: 1, 60: 1, 120: 1, 300: 1, 600: 1, 1800: 1, 3600: 1,
+			},
+		},
+	}
+	assert.DeepEqual(t, s.ScheduleLatencies(), want)
+}
@@ -18,6 +18,7 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"sort"
@@ -25,9 +26,13 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	listerscorev1 "k8s.io/client-go/listers/core/v1"
@@ -35,15 +40,25 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/utils/clock"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/faultinject"
 	nodelockutil "github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
+// errBindConflict is the cause of binds of pods already bound to another node.
+var errBindConflict = errors.New("pod already bound to another node")
+
+// errBindInProgress is the cause of binds of pods whose bind to the same node is still in flight.
+var errBindInProgress = errors.New("bind of pod already in progress")
+
 type Scheduler struct {
 	*nodeManager
 	podManager *device.PodManager
@@ -53,13 +68,54 @@ type Scheduler struct {
 	podLister   listerscorev1.PodLister
 	nodeLister  listerscorev1.NodeLister
 	quotaLister listerscorev1.ResourceQuotaLister
+	// PVCs and PVs of pods, to place them on the node of their node-local volumes
+	pvcLister listerscorev1.PersistentVolumeClaimLister
+	pvLister  listerscorev1.PersistentVolumeLister
+	// LimitRanges enforced on device resources by the webhook, nil if not enforced
+	limitRangeLister listerscorev1.LimitRangeLister
+	// Whether the informer caches are synced by resource, set on start
+	cachesSynced map[string]cache.InformerSynced
 	//Node status returned by filter
 	cachedstatus map[string]*NodeUsage
 	nodeNotify   chan struct{}
+	// Nodes changed since the last registration pass
+	nodeQueue *nodeQueue
+	// Latest filter and bind decisions
+	decisions decisionLog
 	//Node Overview
 	overviewstatus map[string]*NodeUsage
 	eventRecorder  record.EventRecorder
 	quotaManager   *device.QuotaManager
+	// Cluster capacity last written to config.KueueCapacityConfigMap
+	publishedCapacity string
+	// vGPU summary of the cluster served by the summary route
+	summary clusterSummary
+	// Devices reserved by filter for pods not bound yet
+	reservations reservations
+	orphans      orphans
+	clock        clock.PassiveClock
+	// Time to schedule pods exported as a metric
+	scheduleLatencies scheduleLatencies
+	// Binds in flight, drained on shutdown
+	inflight inflightBinds
+	// Nodes pods recently failed to bind to
+	bindBackoffs bindBackoffs
+	// Devices the device plugin recently found gone from their node
+	missingDevices missingDevices
+	// Identity of this replica in the allocation leases of nodes
+	identity string
+	// Pods kept pending until their schedule window opens
+	deferred deferredPods
+	// Bands of free device memory nodes are labeled with
+	freeCapacity freeCapacity
+	// Stale annotations removed from nodes and pods
+	cleaned cleanedAnnotations
+	// DeviceAllocation objects mirroring the allocation of nodes
+	allocationExport allocationExport
+	// Responses to kube-scheduler near the extender deadline exported as a metric
+	nearTimeouts nearTimeouts
+	// Device memory usage of workloads recommended their device memory by
+	rightSizing rightSizing
 }
 
 func NewScheduler() *Scheduler {
@@ -68,8 +124,14 @@ func NewScheduler() *Scheduler {
 		stopCh:       make(chan struct{}),
 		cachedstatus: make(map[string]*NodeUsage),
 		nodeNotify:   make(chan struct{}, 1),
+		summary:      clusterSummary{notify: make(chan struct{}, 1)},
+		reservations: reservations{reserved: make(map[k8stypes.UID]time.Time), avoided: make(map[k8stypes.UID]avoidedDevices)},
+		orphans:      orphans{since: make(map[k8stypes.UID]time.Time)},
+		clock:        clock.RealClock{},
+		identity:     newReplicaIdentity(),
 	}
 	s.nodeManager = newNodeManager()
+	s.nodeQueue = newNodeQueue(s.doNodeNotify)
 	s.podManager = device.NewPodManager()
 	s.quotaManager = device.NewQuotaManager()
 	klog.V(2).InfoS("Scheduler initialized successfully")
@@ -98,22 +160,38 @@ func (s *Scheduler) onAddPod(obj any) {
 		return
 	}
 	klog.V(5).InfoS("Pod added", "pod", pod.Name, "namespace", pod.Namespace)
+	defer s.notifySummary()
 	nodeID, ok := pod.Annotations[util.AssignedNodeAnnotations]
 	if !ok {
+		if util.IsPodInTerminatedState(pod) {
+			s.forgetPodFromRequests(pod)
+		}
 		return
 	}
 	if util.IsPodInTerminatedState(pod) {
-		pi, ok := s.podManager.GetPod(pod)
-		if ok {
-			s.quotaManager.RmUsage(pod, pi.Devices)
-		}
+		s.quotaManager.RmUsage(pod)
 		s.podManager.DelPod(pod)
+		s.settleReservation(pod)
+		return
+	}
+	if _, ok := pod.Annotations[util.DeviceMissingAnnotations]; ok {
+		s.handleMissingDevices(pod, nodeID)
+		return
+	}
+	if !s.trackReservation(pod) {
+		klog.V(4).InfoS("Ignoring devices of pod not bound in time", "pod", klog.KObj(pod), "ttl", config.ReservationTTL)
 		return
 	}
 	podDev, _ := device.DecodePodDevices(device.SupportDevices, pod.Annotations)
-	if s.podManager.AddPod(pod, nodeID, podDev) {
-		s.quotaManager.AddUsage(pod, podDev)
+	if config.InPlaceGPUResize {
+		podDev = s.resizeGPUs(pod, nodeID, podDev)
 	}
+	if util.IsShareGPUWithinPod(pod) {
+		podDev = device.DedupPodDevices(podDev)
+	}
+	// Updates replace the devices and usage attributed to the pod, e.g. when another controller patches it.
+	s.podManager.AddPod(pod, nodeID, podDev)
+	s.quotaManager.AddUsage(pod, podDev)
 }
 
 func (s *Scheduler) onUpdatePod(_, newObj any) {
@@ -126,15 +204,49 @@ func (s *Scheduler) onDelPod(obj any) {
 		klog.Errorf("unknown add object type")
 		return
 	}
+	defer s.notifySummary()
+	s.forgetDeferredPod(pod.UID)
 	_, ok = pod.Annotations[util.AssignedNodeAnnotations]
 	if !ok {
+		s.forgetPodFromRequests(pod)
 		return
 	}
-	pi, ok := s.podManager.GetPod(pod)
-	if ok {
-		s.quotaManager.RmUsage(pod, pi.Devices)
-		s.podManager.DelPod(pod)
+	s.settleReservation(pod)
+	s.clearBindBackoff(pod.UID)
+	s.quotaManager.RmUsage(pod)
+	s.podManager.DelPod(pod)
+}
+
+func (s *Scheduler) onAddNode(obj any) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		klog.Errorf("unknown add object type")
+		return
+	}
+	s.nodeQueue.add(node.Name)
+}
+
+// onUpdateNode queues the node if anything devices are registered from changed,
+// status heartbeats and informer resyncs are ignored.
+func (s *Scheduler) onUpdateNode(oldObj, newObj any) {
+	oldNode, ok := oldObj.(*corev1.Node)
+	if !ok {
+		klog.Errorf("unknown update object type")
+		return
+	}
+	newNode, ok := newObj.(*corev1.Node)
+	if !ok {
+		klog.Errorf("unknown update object type")
+		return
+	}
+	if maps.Equal(withoutAllocationLease(oldNode.Annotations), withoutAllocationLease(newNode.Annotations)) &&
+		maps.Equal(oldNode.Labels, newNode.Labels) &&
+		equality.Semantic.DeepEqual(oldNode.Spec, newNode.Spec) &&
+		equality.Semantic.DeepEqual(oldNode.Status.Capacity, newNode.Status.Capacity) &&
+		equality.Semantic.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable) {
+		return
 	}
+	s.nodeQueue.add(newNode.Name)
 }
 
 // onDelNode handles node delete events. It removes any in-memory per-node
@@ -148,10 +260,12 @@ func (s *Scheduler) onDelNode(obj any) {
 	case *corev1.Node:
 		klog.V(4).InfoS("Node deleted, cleaning up nodelock", "node", t.Name)
 		nodelockutil.CleanupNodeLock(t.Name)
+		s.nodeQueue.add(t.Name)
 	case cache.DeletedFinalStateUnknown:
 		if n, ok := t.Obj.(*corev1.Node); ok {
 			klog.V(4).InfoS("Node tombstone deleted, cleaning up nodelock", "node", n.Name)
 			nodelockutil.CleanupNodeLock(n.Name)
+			s.nodeQueue.add(n.Name)
 		} else {
 			klog.V(5).InfoS("Received tombstone for non-node object on delete")
 		}
@@ -190,6 +304,11 @@ func (s *Scheduler) Start() {
 	s.podLister = informerFactory.Core().V1().Pods().Lister()
 	s.nodeLister = informerFactory.Core().V1().Nodes().Lister()
 	s.quotaLister = informerFactory.Core().V1().ResourceQuotas().Lister()
+	s.pvcLister = informerFactory.Core().V1().PersistentVolumeClaims().Lister()
+	s.pvLister = informerFactory.Core().V1().PersistentVolumes().Lister()
+	if config.EnforceLimitRanges {
+		s.limitRangeLister = informerFactory.Core().V1().LimitRanges().Lister()
+	}
 
 	informerFactory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    s.onAddPod,
@@ -197,7 +316,8 @@ func (s *Scheduler) Start() {
 		DeleteFunc: s.onDelPod,
 	})
 	informerFactory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(_ any) { s.doNodeNotify() },
+		AddFunc:    s.onAddNode,
+		UpdateFunc: s.onUpdateNode,
 		DeleteFunc: s.onDelNode,
 	})
 	informerFactory.Core().V1().ResourceQuotas().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -205,15 +325,43 @@ func (s *Scheduler) Start() {
 		UpdateFunc: s.onUpdateQuota,
 		DeleteFunc: s.onDelQuota,
 	})
+	s.cachesSynced = map[string]cache.InformerSynced{
+		"pods":                   informerFactory.Core().V1().Pods().Informer().HasSynced,
+		"nodes":                  informerFactory.Core().V1().Nodes().Informer().HasSynced,
+		"resourcequotas":         informerFactory.Core().V1().ResourceQuotas().Informer().HasSynced,
+		"persistentvolumeclaims": informerFactory.Core().V1().PersistentVolumeClaims().Informer().HasSynced,
+		"persistentvolumes":      informerFactory.Core().V1().PersistentVolumes().Informer().HasSynced,
+	}
+	if config.EnforceLimitRanges {
+		s.cachesSynced["limitranges"] = informerFactory.Core().V1().LimitRanges().Informer().HasSynced
+	}
 	informerFactory.Start(s.stopCh)
 	informerFactory.WaitForCacheSync(s.stopCh)
 	s.addAllEventHandlers()
+	go s.summaryLoop()
+	go s.requeueDeferredPodsLoop()
+	if config.CleanupStaleAnnotations {
+		go s.cleanupStaleAnnotationsLoop()
+	}
+	if len(config.MaxDeviceResidency) > 0 {
+		go s.enforceDeviceResidencyLoop()
+	}
+	if config.Defragment {
+		go s.defragmentLoop()
+	}
+	if config.EnableDRA {
+		s.startDRAController(config.DRADriverName)
+	}
 }
 
 func (s *Scheduler) Stop() {
 	close(s.stopCh)
 }
 
+// RegisterFromNodeAnnotations keeps registered node devices in sync with node annotations.
+// Nodes are synced one by one as their events arrive, and all nodes are reconciled every
+// config.NodeResyncPeriod to catch anything missed, along with the quota usage. The devices allocated to the
+// running pods are replayed after the first resync. Node usage is refreshed every 15 seconds.
 func (s *Scheduler) RegisterFromNodeAnnotations() {
 	klog.InfoS("Entering RegisterFromNodeAnnotations")
 	defer klog.InfoS("Exiting RegisterFromNodeAnnotations")
@@ -221,80 +369,183 @@ func (s *Scheduler) RegisterFromNodeAnnotations() {
 	labelSelector := labels.Set(config.NodeLabelSelector).AsSelector()
 	klog.InfoS("Using label selector for list nodes", "selector", labelSelector.String())
 
+	resyncPeriod := config.NodeResyncPeriod
+	if resyncPeriod <= 0 {
+		resyncPeriod = time.Minute * 5
+	}
+	resyncTicker := time.NewTicker(resyncPeriod)
+	defer resyncTicker.Stop()
 	ticker := time.NewTicker(time.Second * 15)
 	defer ticker.Stop()
 	printedLog := map[string]bool{}
+	fullResync := true
+	warmedUp := false
 	for {
+		refreshUsage := false
 		select {
 		case <-s.nodeNotify:
 			klog.V(5).InfoS("Received node notification")
 		case <-ticker.C:
 			klog.V(5).InfoS("Ticker triggered")
+			refreshUsage = true
+		case <-resyncTicker.C:
+			klog.V(5).InfoS("Full node resync triggered")
+			fullResync = true
 		case <-s.stopCh:
 			klog.InfoS("Received stop signal, exiting RegisterFromNodeAnnotations")
 			return
 		}
-		rawNodes, err := s.nodeLister.List(labelSelector)
+		if fullResync {
+			if err := s.resyncNodes(labelSelector, printedLog); err != nil {
+				klog.ErrorS(err, "Failed to list nodes with selector", "selector", labelSelector.String())
+				continue
+			}
+			fullResync = false
+			refreshUsage = true
+			if !warmedUp {
+				s.warmUp()
+				warmedUp = true
+			}
+			s.reconcileUsage()
+		} else {
+			s.syncQueuedNodes(labelSelector, printedLog)
+		}
+		s.notifySummary()
+		if !refreshUsage {
+			continue
+		}
+		nodeNames := s.nodeIDs()
+		usage, _, err := s.getNodesUsage(&nodeNames, nil)
 		if err != nil {
-			klog.ErrorS(err, "Failed to list nodes with selector", "selector", labelSelector.String())
+			klog.ErrorS(err, "Failed to get node usage", "nodeNames", nodeNames)
+		} else {
+			s.handleOrphanedDevices(*usage)
+			s.syncFreeCapacity(*usage)
+		}
+		if err := s.publishCapacity(nodeNames); err != nil {
+			klog.ErrorS(err, "Failed to publish cluster device capacity", "configmap", config.KueueCapacityConfigMap)
+		}
+	}
+}
+
+// reconcileUsage checks the quota usage against a recompute from the usage of the pods cached, and corrects it.
+func (s *Scheduler) reconcileUsage() {
+	if drifted := s.quotaManager.Reconcile(func(uid k8stypes.UID) bool {
+		_, ok := s.podManager.GetPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: uid}})
+		return ok
+	}); drifted > 0 {
+		klog.InfoS("Corrected quota usage out of sync with cached pods", "resources", drifted)
+	}
+}
+
+// resyncNodes syncs the devices of all nodes matching labelSelector and removes any other node.
+func (s *Scheduler) resyncNodes(labelSelector labels.Selector, printedLog map[string]bool) error {
+	// Every node is synced below, changes queued so far are covered.
+	s.nodeQueue.drain()
+	rawNodes, err := s.nodeLister.List(labelSelector)
+	if err != nil {
+		return err
+	}
+	klog.V(5).InfoS("Listed nodes", "nodeCount", len(rawNodes))
+	listed := make(map[string]bool, len(rawNodes))
+	for _, val := range rawNodes {
+		listed[val.Name] = true
+		s.syncNode(val, printedLog)
+	}
+	for _, nodeID := range s.nodeIDs() {
+		if !listed[nodeID] {
+			s.removeNode(nodeID, printedLog)
+		}
+	}
+	return nil
+}
+
+// syncQueuedNodes syncs the devices of nodes changed since the last pass.
+func (s *Scheduler) syncQueuedNodes(labelSelector labels.Selector, printedLog map[string]bool) {
+	for _, nodeName := range s.nodeQueue.drain() {
+		node, err := s.nodeLister.Get(nodeName)
+		if err != nil || !labelSelector.Matches(labels.Set(node.Labels)) {
+			klog.V(5).InfoS("Node is gone or not selected", "nodeName", nodeName, "error", err)
+			s.removeNode(nodeName, printedLog)
 			continue
 		}
-		klog.V(5).InfoS("Listed nodes", "nodeCount", len(rawNodes))
-		var nodeNames []string
-		for _, val := range rawNodes {
-			nodeNames = append(nodeNames, val.Name)
-			klog.V(5).InfoS("Processing node", "nodeName", val.Name)
+		s.syncNode(node, printedLog)
+	}
+}
 
-			for devhandsk, devInstance := range device.GetDevices() {
-				klog.V(5).InfoS("Checking device health", "nodeName", val.Name, "deviceVendor", devhandsk)
+func (s *Scheduler) removeNode(nodeName string, printedLog map[string]bool) {
+	s.rmNode(nodeName)
+	s.nodeQueue.forget(nodeName)
+	delete(printedLog, nodeName)
+}
 
-				nodedevices, err := devInstance.GetNodeDevices(*val)
-				if err != nil {
-					klog.V(5).InfoS("Failed to get node devices", "nodeName", val.Name, "deviceVendor", devhandsk)
-					continue
-				}
+// syncNode checks the device health of a node and registers its devices from the node annotations.
+func (s *Scheduler) syncNode(val *corev1.Node, printedLog map[string]bool) {
+	klog.V(5).InfoS("Processing node", "nodeName", val.Name)
+	var recheck time.Duration
+	for devhandsk, devInstance := range device.GetDevices() {
+		klog.V(5).InfoS("Checking device health", "nodeName", val.Name, "deviceVendor", devhandsk)
 
-				health, needUpdate := devInstance.CheckHealth(devhandsk, val)
-				klog.V(5).InfoS("Device health check result", "nodeName", val.Name, "deviceVendor", devhandsk, "health", health, "needUpdate", needUpdate)
+		err := faultinject.Inject(faultinject.PointNodeAnnotationDecode)
+		var nodedevices []*device.DeviceInfo
+		if err == nil {
+			nodedevices, err = devInstance.GetNodeDevices(*val)
+		}
+		if err != nil {
+			klog.V(5).InfoS("Failed to get node devices", "nodeName", val.Name, "deviceVendor", devhandsk)
+			continue
+		}
 
-				if !health {
-					klog.Warning("Device is unhealthy, cleaning up node", "nodeName", val.Name, "deviceVendor", devhandsk)
-					err := devInstance.NodeCleanUp(val.Name)
-					if err != nil {
-						klog.ErrorS(err, "Node cleanup failed", "nodeName", val.Name, "deviceVendor", devhandsk)
-					}
+		health, needUpdate := devInstance.CheckHealth(devhandsk, val)
+		klog.V(5).InfoS("Device health check result", "nodeName", val.Name, "deviceVendor", devhandsk, "health", health, "needUpdate", needUpdate)
 
-					s.rmNodeDevices(val.Name, devhandsk)
-					continue
-				}
-				if !needUpdate {
-					klog.V(5).InfoS("No update needed for device", "nodeName", val.Name, "deviceVendor", devhandsk)
-					continue
-				}
-				nodeInfo := &device.NodeInfo{}
-				nodeInfo.ID = val.Name
-				nodeInfo.Node = val
-				klog.V(5).InfoS("Fetching node devices", "nodeName", val.Name, "deviceVendor", devhandsk)
-				nodeInfo.Devices = make(map[string][]device.DeviceInfo, 0)
-				for _, deviceinfo := range nodedevices {
-					nodeInfo.Devices[deviceinfo.DeviceVendor] = append(nodeInfo.Devices[deviceinfo.DeviceVendor], *deviceinfo)
-				}
-				s.addNode(val.Name, nodeInfo)
-				if s.nodes[val.Name] != nil && len(nodeInfo.Devices) > 0 {
-					if printedLog[val.Name] {
-						klog.V(5).InfoS("Node device updated", "nodeName", val.Name, "deviceVendor", devhandsk, "nodeInfo", nodeInfo, "totalDevices", s.nodes[val.Name].Devices)
-					} else {
-						klog.InfoS("Node device added", "nodeName", val.Name, "deviceVendor", devhandsk, "nodeInfo", nodeInfo, "totalDevices", s.nodes[val.Name].Devices)
-						printedLog[val.Name] = true
-					}
-				}
+		if !health {
+			klog.Warning("Device is unhealthy, cleaning up node", "nodeName", val.Name, "deviceVendor", devhandsk)
+			err := devInstance.NodeCleanUp(val.Name)
+			if err != nil {
+				klog.ErrorS(err, "Node cleanup failed", "nodeName", val.Name, "deviceVendor", devhandsk)
 			}
+
+			s.rmNodeDevices(val.Name, devhandsk)
+			continue
 		}
-		_, _, err = s.getNodesUsage(&nodeNames, nil)
-		if err != nil {
-			klog.ErrorS(err, "Failed to get node usage", "nodeNames", nodeNames)
+		// A handshake not answered makes the devices unhealthy without any node event,
+		// check the node again when it times out.
+		if deadline, ok := device.HandshakeDeadline(devhandsk, val); ok {
+			if d := time.Until(deadline) + time.Second; recheck == 0 || d < recheck {
+				recheck = d
+			}
+		}
+		if !needUpdate {
+			klog.V(5).InfoS("No update needed for device", "nodeName", val.Name, "deviceVendor", devhandsk)
+			continue
+		}
+		nodeInfo := &device.NodeInfo{}
+		nodeInfo.ID = val.Name
+		nodeInfo.Node = val
+		klog.V(5).InfoS("Fetching node devices", "nodeName", val.Name, "deviceVendor", devhandsk)
+		nodeInfo.Devices = make(map[string][]device.DeviceInfo, 0)
+		for _, deviceinfo := range nodedevices {
+			nodeInfo.Devices[deviceinfo.DeviceVendor] = append(nodeInfo.Devices[deviceinfo.DeviceVendor], *deviceinfo)
+		}
+		if err := faultinject.Inject(faultinject.PointDevicePluginRegistration); err != nil {
+			klog.ErrorS(err, "Failed to register node devices", "nodeName", val.Name, "deviceVendor", devhandsk)
+			continue
+		}
+		s.addNode(val.Name, nodeInfo)
+		if n, err := s.GetNode(val.Name); err == nil && len(nodeInfo.Devices) > 0 {
+			if printedLog[val.Name] {
+				klog.V(5).InfoS("Node device updated", "nodeName", val.Name, "deviceVendor", devhandsk, "nodeInfo", nodeInfo, "totalDevices", n.Devices)
+			} else {
+				klog.InfoS("Node device added", "nodeName", val.Name, "deviceVendor", devhandsk, "nodeInfo", nodeInfo, "totalDevices", n.Devices)
+				printedLog[val.Name] = true
+			}
 		}
 	}
+	s.syncNodeLabels(val)
+	if recheck > 0 {
+		s.nodeQueue.addAfter(val.Name, recheck)
+	}
 }
 
 // InspectAllNodesUsage is used by metrics monitor.
@@ -308,14 +559,17 @@ func (s *Scheduler) getNodesUsage(nodes *[]string, task *corev1.Pod) (*map[strin
 	overallnodeMap := make(map[string]*NodeUsage)
 	cachenodeMap := make(map[string]*NodeUsage)
 	failedNodes := make(map[string]string)
+	s.expireReservations()
 	allNodes, err := s.ListNodes()
 	if err != nil {
 		return &overallnodeMap, failedNodes, err
 	}
 
+	cfg := config.Current()
 	for _, node := range allNodes {
+		blacklisted := s.blacklistedDevices(node)
 		nodeInfo := &NodeUsage{}
-		userGPUPolicy := util.GetGPUSchedulerPolicyByPod(device.GPUSchedulerPolicy, task)
+		userGPUPolicy := gpuSchedulerPolicyOf(cfg, task, node)
 		nodeInfo.Node = node.Node
 		nodeInfo.Devices = policy.DeviceUsageList{
 			Policy:      userGPUPolicy,
@@ -325,26 +579,36 @@ func (s *Scheduler) getNodesUsage(nodes *[]string, task *corev1.Pod) (*map[strin
 			for _, d := range k {
 				nodeInfo.Devices.DeviceLists = append(nodeInfo.Devices.DeviceLists, &policy.DeviceListsScore{
 					Score: 0,
+					Cost:  cfg.DeviceCost(d.Type),
 					Device: &device.DeviceUsage{
-						ID:        d.ID,
-						Index:     d.Index,
-						Used:      0,
-						Count:     d.Count,
-						Usedmem:   0,
-						Totalmem:  d.Devmem,
-						Totalcore: d.Devcore,
-						Usedcores: 0,
+						ID:           d.ID,
+						Index:        d.Index,
+						Used:         0,
+						Count:        d.Count,
+						Usedmem:      0,
+						Totalmem:     d.Devmem,
+						Physicalmem:  d.PhysicalMem,
+						Totalcore:    d.Devcore,
+						Usedcores:    0,
+						Totalengines: d.Engines,
 						MigUsage: device.MigInUse{
 							Index:     0,
 							UsageList: make(device.MIGS, 0),
 						},
-						MigTemplate: d.MIGTemplate,
-						Mode:        d.Mode,
-						Type:        d.Type,
-						Numa:        d.Numa,
-						Health:      d.Health,
-						PodInfos:    make([]*device.PodInfo, 0),
-						CustomInfo:  maps.Clone(d.CustomInfo),
+						MigTemplate:     d.MIGTemplate,
+						Mode:            d.Mode,
+						Type:            d.Type,
+						Numa:            d.Numa,
+						Health:          d.Health && !s.isMissingDevice(node.ID, d.ID) && !blacklisted.Contains(d.ID),
+						Temperature:     d.Temperature,
+						ECCErrorTime:    d.ECCErrorTime,
+						DriverVersion:   d.DriverVersion,
+						Pool:            d.Pool,
+						PodInfos:        make([]*device.PodInfo, 0),
+						CustomInfo:      maps.Clone(d.CustomInfo),
+						RuntimeNotReady: d.RuntimeReady != nil && !*d.RuntimeReady,
+						Serial:          d.Serial,
+						ECCMode:         d.ECCMode,
 					},
 				})
 			}
@@ -363,15 +627,20 @@ func (s *Scheduler) getNodesUsage(nodes *[]string, task *corev1.Pod) (*map[strin
 		for _, podsingleds := range p.Devices {
 			for _, ctrdevs := range podsingleds {
 				for _, udevice := range ctrdevs {
+					found := false
 					for _, d := range node.Devices.DeviceLists {
 						deviceID := udevice.UUID
 						if strings.Contains(deviceID, "[") {
 							deviceID = strings.Split(deviceID, "[")[0]
 						}
 						if d.Device.ID == deviceID {
+							found = true
 							d.Device.Used++
 							d.Device.Usedmem += udevice.Usedmem
+							d.Device.Opportunisticmem += device.OpportunisticMem(p.Pod, udevice.Usedmem)
+							d.Device.Oversubscribedmem += udevice.Oversubscribedmem
 							d.Device.Usedcores += udevice.Usedcores
+							d.Device.Usedengines += udevice.Usedengines
 							d.Device.PodInfos = append(d.Device.PodInfos, p)
 
 							if strings.Contains(udevice.UUID, "[") {
@@ -389,6 +658,11 @@ func (s *Scheduler) getNodesUsage(nodes *[]string, task *corev1.Pod) (*map[strin
 							}
 						}
 					}
+					if !found {
+						klog.V(5).InfoS("pod assigned device not registered on node",
+							"pod", klog.KRef(p.Namespace, p.Name), "nodeID", p.NodeID, "deviceUUID", udevice.UUID)
+						node.Orphaned = append(node.Orphaned, OrphanedDevice{Pod: p, Device: udevice})
+					}
 				}
 			}
 		}
@@ -400,7 +674,7 @@ func (s *Scheduler) getNodesUsage(nodes *[]string, task *corev1.Pod) (*map[strin
 		if err != nil {
 			// The identified node does not have a gpu device, so the log here has no practical meaning,increase log priority.
 			klog.V(5).InfoS("node unregistered", "node", nodeID, "error", err)
-			failedNodes[nodeID] = "node unregistered"
+			failedNodes[nodeID] = s.unregisteredReason(nodeID)
 			continue
 		}
 		cachenodeMap[node.ID] = overallnodeMap[node.ID]
@@ -440,67 +714,248 @@ func (s *Scheduler) getPodUsage() (map[string]device.PodUseDeviceStat, error) {
 	return podUsageStat, nil
 }
 
-func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.ExtenderBindingResult, error) {
-	klog.InfoS("Attempting to bind pod to node", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+// bindInProgress returns whether a bind of the pod to the node is in flight: the node is locked by the pod within
+// the node lock timeout, and the pod is assigned to the node.
+func bindInProgress(node *corev1.Node, pod *corev1.Pod, args extenderv1.ExtenderBindingArgs) bool {
+	value, ok := node.Annotations[nodelockutil.NodeLockKey]
+	if !ok || pod.UID != args.PodUID || pod.Annotations[util.AssignedNodeAnnotations] != args.Node {
+		return false
+	}
+	lockTime, ns, name, err := nodelockutil.ParseNodeLock(value)
+	return err == nil && ns == pod.Namespace && name == pod.Name && time.Since(lockTime) <= nodelockutil.NodeLockTimeout
+}
+
+func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (result *extenderv1.ExtenderBindingResult, err error) {
+	klog.InfoS("Attempting to bind pod to node", "pod", args.PodName, "uid", args.PodUID, "namespace", args.PodNamespace, "node", args.Node)
+	defer s.observeExtenderResponse("bind", s.clock.Now())
+	defer func() { s.recordBindDecision(args, result, err) }()
+	inflight, ctx, err := s.beginBind()
+	if err != nil {
+		return &extenderv1.ExtenderBindingResult{Error: err.Error()}, err
+	}
+	defer s.endBind(inflight)
 	var res *extenderv1.ExtenderBindingResult
 
 	binding := &corev1.Binding{
 		ObjectMeta: metav1.ObjectMeta{Name: args.PodName, UID: args.PodUID},
 		Target:     corev1.ObjectReference{Kind: "Node", Name: args.Node},
 	}
-	current, err := s.kubeClient.CoreV1().Pods(args.PodNamespace).Get(context.Background(), args.PodName, metav1.GetOptions{})
+	current, err := s.kubeClient.CoreV1().Pods(args.PodNamespace).Get(ctx, args.PodName, metav1.GetOptions{})
 	if err != nil {
 		klog.ErrorS(err, "Failed to get pod", "pod", args.PodName, "namespace", args.PodNamespace)
 		return &extenderv1.ExtenderBindingResult{Error: err.Error()}, err
 	}
+	devices, _ := device.DecodePodDevices(device.SupportDevices, current.Annotations)
+	_, span := tracing.StartPodSpan(context.Background(), current, "scheduler.Bind", trace.WithAttributes(
+		attribute.String("hami.node", args.Node),
+		attribute.StringSlice("hami.device_uuids", deviceUUIDs(devices)),
+	))
+	defer func() {
+		spanErr := err
+		if spanErr == nil && result != nil && result.Error != "" {
+			spanErr = errors.New(result.Error)
+		}
+		tracing.EndSpan(span, spanErr)
+	}()
+	// kube-scheduler retries binds past its timeout even if they succeeded, the retries neither lock the node nor
+	// patch the pod again. A pod recreated with the same name isn't the pod the bind is for.
+	if bound := current.Spec.NodeName; bound != "" {
+		if current.UID != args.PodUID {
+			err = fmt.Errorf("%w: pod %s/%s bound to node %s has uid %s, not %s", errBindConflict, args.PodNamespace, args.PodName, bound, current.UID, args.PodUID)
+			klog.ErrorS(err, "Failed to bind pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+			return &extenderv1.ExtenderBindingResult{Error: err.Error()}, nil
+		}
+		if bound == args.Node {
+			klog.InfoS("Pod already bound to node", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+			return &extenderv1.ExtenderBindingResult{Error: ""}, nil
+		}
+		err = fmt.Errorf("%w: pod %s/%s is bound to node %s, not %s", errBindConflict, args.PodNamespace, args.PodName, bound, args.Node)
+		klog.ErrorS(err, "Failed to bind pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+		return &extenderv1.ExtenderBindingResult{Error: err.Error()}, nil
+	}
 	klog.InfoS("Trying to get the target node for pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
-	node, err := s.kubeClient.CoreV1().Nodes().Get(context.Background(), args.Node, metav1.GetOptions{})
+	node, err := s.kubeClient.CoreV1().Nodes().Get(ctx, args.Node, metav1.GetOptions{})
 	if err != nil {
 		klog.ErrorS(err, "Failed to get node", "node", args.Node)
+		s.recordBindFailure(args.PodUID, args.Node)
 		s.recordScheduleBindingResultEvent(current, EventReasonBindingFailed, []string{}, fmt.Errorf("failed to get node %s", args.Node))
 		res = &extenderv1.ExtenderBindingResult{Error: err.Error()}
 		return res, nil
 	}
+	// A retry arriving while the bind is still in flight finds the node locked by the pod, it fails without
+	// releasing the lock of the bind in flight and is retried once that bind is done.
+	if bindInProgress(node, current, args) {
+		err = fmt.Errorf("%w: pod %s/%s is being bound to node %s", errBindInProgress, args.PodNamespace, args.PodName, args.Node)
+		klog.InfoS("Pod bind already in progress", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+		return &extenderv1.ExtenderBindingResult{Error: err.Error()}, nil
+	}
+	inflight.setNode(current, node)
 
+	// The device plugin of nodes not managed by HAMi allocates their whole devices itself, it never releases
+	// node locks nor reports the bind phase.
+	managed := !config.AllowUnmanagedWholeDevices || s.isManagedNode(args.Node)
 	tmppatch := map[string]string{
 		util.DeviceBindPhase:     "allocating",
 		util.BindTimeAnnotations: strconv.FormatInt(time.Now().Unix(), 10),
 	}
 
-	for _, val := range device.GetDevices() {
-		err = val.LockNode(node, current)
+	if managed {
+		for _, val := range device.GetDevices() {
+			err = val.LockNode(node, current)
+			if err != nil {
+				klog.ErrorS(err, "Failed to lock node", "node", args.Node, "device", val)
+				goto ReleaseNodeLocks
+			}
+		}
+
+		inflight.setPatched(tmppatch)
+		if err = faultinject.Inject(faultinject.PointBindPatch); err == nil {
+			err = util.PatchPodAnnotations(current, tmppatch)
+		}
 		if err != nil {
-			klog.ErrorS(err, "Failed to lock node", "node", args.Node, "device", val)
+			klog.ErrorS(err, "Failed to patch pod annotations", "pod", klog.KObj(current))
 			goto ReleaseNodeLocks
 		}
 	}
 
-	err = util.PatchPodAnnotations(current, tmppatch)
-	if err != nil {
-		klog.ErrorS(err, "Failed to patch pod annotations", "pod", klog.KObj(current))
-		goto ReleaseNodeLocks
+	// kube-scheduler gave up on a bind past the extender deadline, the pod is retried from filter.
+	if err = context.Cause(ctx); err != nil {
+		klog.ErrorS(err, "Bind interrupted before binding pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+		if managed {
+			s.rollbackBind(inflight)
+		}
+		s.recordBindFailure(current.UID, args.Node)
+		s.recordScheduleBindingResultEvent(current, EventReasonBindingFailed, []string{}, err)
+		return &extenderv1.ExtenderBindingResult{Error: err.Error()}, nil
 	}
-
-	err = s.kubeClient.CoreV1().Pods(args.PodNamespace).Bind(context.Background(), binding, metav1.CreateOptions{})
+	// A drain rolling back the bind once the pod is being bound would leave the pod bound without its devices.
+	if err = inflight.commit(); err != nil {
+		klog.ErrorS(err, "Bind rolled back before binding pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+		s.recordBindFailure(current.UID, args.Node)
+		s.recordScheduleBindingResultEvent(current, EventReasonBindingFailed, []string{}, err)
+		return &extenderv1.ExtenderBindingResult{Error: err.Error()}, nil
+	}
+	err = s.kubeClient.CoreV1().Pods(args.PodNamespace).Bind(ctx, binding, metav1.CreateOptions{})
 	if err != nil {
 		klog.ErrorS(err, "Failed to bind pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
 		goto ReleaseNodeLocks
 	}
 
+	s.settleReservation(current)
+	s.clearBindBackoff(current.UID)
+	s.observeScheduleLatency(current)
 	s.recordScheduleBindingResultEvent(current, EventReasonBindingSucceed, []string{args.Node}, nil)
 	klog.InfoS("Successfully bound pod to node", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
 	return &extenderv1.ExtenderBindingResult{Error: ""}, nil
 
 ReleaseNodeLocks:
-	klog.InfoS("Release node locks", "node", args.Node)
-	for _, val := range device.GetDevices() {
-		val.ReleaseNodeLock(node, current)
+	if managed {
+		klog.InfoS("Release node locks", "node", args.Node)
+		for _, val := range device.GetDevices() {
+			val.ReleaseNodeLock(node, current)
+		}
 	}
+	s.recordBindFailure(current.UID, args.Node)
 	s.recordScheduleBindingResultEvent(current, EventReasonBindingFailed, []string{}, err)
 	return &extenderv1.ExtenderBindingResult{Error: err.Error()}, nil
 }
 
-func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error) {
+// podFit is the fit of a pod to candidate nodes.
+type podFit struct {
+	// Requests of the containers, with the utilization profile of the pod applied
+	resourceReqs   device.PodDeviceRequests
+	shareWithinPod bool
+	nodeScores     *policy.NodeScoreList
+	failedNodes    map[string]string
+	// Model of the GPU model fallback the nodes are scored for, empty without fallback
+	model string
+}
+
+// fitPod scores the candidate nodes the pod fits and records why the others don't, without allocating anything.
+func (s *Scheduler) fitPod(ctx context.Context, pod *corev1.Pod, resourceReqs device.PodDeviceRequests, candidates []string, tracer *podTracer) (*podFit, error) {
+	// The peaks of the utilization profile of the pod are allocated instead of its requests, which are used if the
+	// profile can't be read.
+	if profile, err := s.getUtilizationProfile(pod); err != nil {
+		klog.ErrorS(err, "Failed to get utilization profile, allocating the requests of the pod", "pod", klog.KObj(pod))
+		tracer.Trace("utilization profile ignored", "error", err)
+	} else if profile != nil {
+		resourceReqs = applyUtilizationProfile(resourceReqs, profile)
+		tracer.Trace("utilization profile applied", "profile", pod.Annotations[util.UtilizationProfileAnnotationKey], "requests", resourceReqs)
+	}
+	shareWithinPod := util.IsShareGPUWithinPod(pod)
+	fitReqs := resourceReqs
+	if shareWithinPod {
+		fitReqs = device.MergeContainerRequests(resourceReqs)
+	}
+	nodeNames, volumeFailedNodes, localNodes, err := s.filterVolumeLocality(pod, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(volumeFailedNodes) != 0 || len(localNodes) != 0 {
+		tracer.Trace("volume locality applied", "failedNodes", volumeFailedNodes, "localNodes", localNodes)
+	}
+	nodeNames, runtimeFailedNodes, runtimeNodes := s.filterContainerRuntime(pod, nodeNames)
+	if len(runtimeFailedNodes) != 0 || len(runtimeNodes) != 0 {
+		tracer.Trace("container runtime affinity applied", "failedNodes", runtimeFailedNodes, "runtimeNodes", runtimeNodes)
+	}
+	// The placement suggested by an external optimizer is honored if the pod fits it.
+	suggestion := suggestionOf(pod)
+	nodeNames, suggestionFailedNodes := suggestion.filterSuggestedNode(nodeNames)
+	if suggestion != nil {
+		tracer.Trace("placement suggestion applied", "suggestion", pod.Annotations[util.SuggestedPlacementAnnotationKey], "mode", suggestion.mode)
+	}
+	// With a GPU model fallback, the models are tried in order until one fits any node.
+	models := util.GetGPUModelFallback(pod)
+	if len(models) == 0 {
+		models = []string{""}
+	}
+	// Nodes the pod just failed to bind to are only selected if no other node fits.
+	backoffNodes := s.bindBackoffNodes(pod.UID)
+	if len(backoffNodes) != 0 {
+		tracer.Trace("bind failure cooldown applied", "nodes", backoffNodes)
+	}
+	res := &podFit{shareWithinPod: shareWithinPod}
+	for _, res.model = range models {
+		// The model is requested for the policies of the model to apply.
+		modelPod := podForGPUModel(pod, res.model)
+		// Fitting a pod updates the node usage, so it's rebuilt for every model.
+		var nodeUsage *map[string]*NodeUsage
+		nodeUsage, res.failedNodes, err = s.getNodesUsage(&nodeNames, modelPod)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(res.failedNodes, volumeFailedNodes)
+		maps.Copy(res.failedNodes, runtimeFailedNodes)
+		maps.Copy(res.failedNodes, suggestionFailedNodes)
+		if len(res.failedNodes) != 0 {
+			klog.V(5).InfoS("Nodes failed during usage retrieval",
+				"nodes", res.failedNodes)
+		}
+		_, scoreSpan := tracing.Tracer().Start(ctx, "scheduler.Score", trace.WithAttributes(attribute.String("hami.gpu_model", res.model)))
+		res.nodeScores, err = s.calcScore(ctx, nodeUsage, fitReqs, modelPod, res.failedNodes)
+		tracing.EndSpan(scoreSpan, err)
+		if err != nil {
+			return nil, fmt.Errorf("calcScore failed %v for pod %v", err, pod.Name)
+		}
+		suggestion.applyToScores(res.nodeScores, res.failedNodes)
+		for _, score := range res.nodeScores.NodeList {
+			score.Preferred = localNodes[score.NodeID]
+			score.PreferredRuntime = runtimeNodes[score.NodeID]
+			score.Deprioritized = backoffNodes[score.NodeID]
+		}
+		if len(res.nodeScores.NodeList) != 0 || ctx.Err() != nil {
+			break
+		}
+		if res.model != "" {
+			klog.V(4).InfoS("No available nodes for GPU model", "pod", pod.Name, "model", res.model)
+			tracer.Trace("no node fits GPU model", "model", res.model)
+		}
+	}
+	res.resourceReqs = resourceReqs
+	return res, nil
+}
+
+func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (result *extenderv1.ExtenderFilterResult, err error) {
 	klog.InfoS("Starting schedule filter process", "pod", args.Pod.Name, "uuid", args.Pod.UID, "namespace", args.Pod.Namespace)
 	resourceReqs := device.Resourcereqs(args.Pod)
 	resourceReqTotal := 0
@@ -519,33 +974,62 @@ func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 			Error:       "",
 		}, nil
 	}
-	s.podManager.DelPod(args.Pod)
-	nodeUsage, failedNodes, err := s.getNodesUsage(args.NodeNames, args.Pod)
+	defer s.observeExtenderResponse("filter", s.clock.Now())
+	defer func() { s.recordFilterDecision(args.Pod, result, err) }()
+	ctx, cancel := withExtenderDeadline(context.Background())
+	defer cancel()
+	ctx, span := tracing.StartPodSpan(ctx, args.Pod, "scheduler.Filter",
+		trace.WithAttributes(attribute.Int("hami.candidate_nodes", len(*args.NodeNames))))
+	defer func() { tracing.EndSpan(span, err) }()
+	tracer := newPodTracer(args.Pod)
+	tracer.Trace("filter started", "candidateNodes", len(*args.NodeNames), "requests", resourceReqs)
+	deferReason, err := s.filterScheduleWindow(args.Pod)
 	if err != nil {
 		s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringFailed, "", err)
 		return nil, err
 	}
-	if len(failedNodes) != 0 {
-		klog.V(5).InfoS("Nodes failed during usage retrieval",
-			"nodes", failedNodes)
+	if deferReason != "" {
+		tracer.Trace("schedule window closed", "reason", deferReason)
+		s.recordScheduleFilterResultEvent(args.Pod, EventReasonScheduleDeferred, deferReason, nil)
+		failedNodes := make(map[string]string, len(*args.NodeNames))
+		for _, nodeID := range *args.NodeNames {
+			failedNodes[nodeID] = deferReason
+		}
+		return &extenderv1.ExtenderFilterResult{FailedNodes: failedNodes}, nil
 	}
-	nodeScores, err := s.calcScore(nodeUsage, resourceReqs, args.Pod, failedNodes)
+	s.quotaManager.RmUsage(args.Pod)
+	s.podManager.DelPod(args.Pod)
+	s.settleReservation(args.Pod)
+	fit, err := s.fitPod(ctx, args.Pod, resourceReqs, *args.NodeNames, tracer)
 	if err != nil {
-		err := fmt.Errorf("calcScore failed %v for pod %v", err, args.Pod.Name)
 		s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringFailed, "", err)
 		return nil, err
 	}
+	resourceReqs, shareWithinPod, nodeScores, failedNodes, model := fit.resourceReqs, fit.shareWithinPod, fit.nodeScores, fit.failedNodes, fit.model
 	if len((*nodeScores).NodeList) == 0 {
+		if unmanaged := unmanagedWholeDeviceNodes(resourceReqs, failedNodes); len(unmanaged) != 0 {
+			klog.V(4).InfoS("Placing pod requesting whole devices on nodes not managed by HAMi", "pod", args.Pod.Name, "nodes", unmanaged)
+			tracer.Trace("no managed node fits, whole devices requested from unmanaged nodes", "nodes", unmanaged)
+			for _, nodeID := range unmanaged {
+				delete(failedNodes, nodeID)
+			}
+			return &extenderv1.ExtenderFilterResult{NodeNames: &unmanaged, FailedNodes: failedNodes}, nil
+		}
 		klog.V(4).InfoS("No available nodes meet the required scores",
 			"pod", args.Pod.Name)
+		tracer.Trace("no node fits", "failedNodes", failedNodes)
 		s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringFailed, "", fmt.Errorf("no available node, %d nodes do not meet", len(*args.NodeNames)))
 		return &extenderv1.ExtenderFilterResult{
 			FailedNodes: failedNodes,
 		}, nil
 	}
 	klog.V(4).Infoln("nodeScores_len=", len((*nodeScores).NodeList))
-	sort.Sort(nodeScores)
+	nodeScores.Sort()
 	m := (*nodeScores).NodeList[len((*nodeScores).NodeList)-1]
+	if tracer.Enabled() {
+		tracer.Trace("selected node", "node", m.NodeID, "score", m.Score, "fitNodes", len(nodeScores.NodeList), "failedNodes", failedNodes)
+		s.recordScheduleFilterResultEvent(args.Pod, EventReasonDebugTrace, genSuccessMsg(len(*args.NodeNames), m.NodeID, nodeScores.NodeList), nil)
+	}
 	klog.InfoS("Scheduling pod to node",
 		"podNamespace", args.Pod.Namespace,
 		"podName", args.Pod.Name,
@@ -554,26 +1038,79 @@ func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 	annotations := make(map[string]string)
 	annotations[util.AssignedNodeAnnotations] = m.NodeID
 	annotations[util.AssignedTimeAnnotations] = strconv.FormatInt(time.Now().Unix(), 10)
+	if model != "" {
+		annotations[util.GPUModelSelectedAnnotationKey] = model
+	}
 
+	// With devices shared within the pod, only the first container holds the usage.
+	podDevices := m.Devices
+	if shareWithinPod {
+		podDevices = device.ShareContainerDevices(resourceReqs, m.Devices)
+	}
 	for _, val := range device.GetDevices() {
-		val.PatchAnnotations(args.Pod, &annotations, m.Devices)
+		val.PatchAnnotations(args.Pod, &annotations, podDevices)
 	}
 
-	if s.podManager.AddPod(args.Pod, m.NodeID, m.Devices) {
-		s.quotaManager.AddUsage(args.Pod, m.Devices)
+	if err = s.acquireAllocationLease(ctx, m.NodeID, args.Pod); err != nil {
+		klog.ErrorS(err, "Failed to commit allocation to node", "pod", klog.KObj(args.Pod), "node", m.NodeID)
+		s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringFailed, "", err)
+		if errors.Is(err, errAllocationLeaseHeld) {
+			// The pod is retried, by then this replica has seen the allocation of the other replica.
+			return &extenderv1.ExtenderFilterResult{FailedNodes: map[string]string{m.NodeID: err.Error()}}, nil
+		}
+		return nil, err
 	}
+	s.podManager.AddPod(args.Pod, m.NodeID, m.Devices)
+	s.quotaManager.AddUsage(args.Pod, m.Devices)
+	s.reserve(args.Pod, s.clock.Now())
 	err = util.PatchPodAnnotations(args.Pod, annotations)
 	if err != nil {
 		s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringFailed, "", err)
+		s.quotaManager.RmUsage(args.Pod)
 		s.podManager.DelPod(args.Pod)
+		s.settleReservation(args.Pod)
+		if releaseErr := s.releaseAllocationLease(ctx, m.NodeID, args.Pod); releaseErr != nil {
+			klog.ErrorS(releaseErr, "Failed to release allocation lease of node", "pod", klog.KObj(args.Pod), "node", m.NodeID)
+		}
 		return nil, err
 	}
 	successMsg := genSuccessMsg(len(*args.NodeNames), m.NodeID, nodeScores.NodeList)
 	s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringSucceed, successMsg, nil)
+	span.SetAttributes(
+		attribute.String("hami.node", m.NodeID),
+		attribute.StringSlice("hami.device_uuids", deviceUUIDs(m.Devices)),
+	)
 	res := extenderv1.ExtenderFilterResult{NodeNames: &[]string{m.NodeID}}
 	return &res, nil
 }
 
+// deviceUUIDs returns the sorted UUIDs of the devices allocated to a pod, for span attributes.
+func deviceUUIDs(devices device.PodDevices) []string {
+	res := make([]string, 0)
+	for _, podSingleDevices := range devices {
+		for _, ctrDevices := range podSingleDevices {
+			for _, d := range ctrDevices {
+				res = append(res, d.UUID)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// podForGPUModel returns the pod restricted to GPUs of model, the pod itself if model is empty.
+func podForGPUModel(pod *corev1.Pod, model string) *corev1.Pod {
+	if model == "" {
+		return pod
+	}
+	res := pod.DeepCopy()
+	if res.Annotations == nil {
+		res.Annotations = make(map[string]string)
+	}
+	res.Annotations[nvidia.GPUInUse] = model
+	return res
+}
+
 func genSuccessMsg(totalNodes int, target string, nodes []*policy.NodeScore) string {
 	successMsg := "find fit node(%s), %d nodes not fit, %d nodes fit(%s)"
 	var scores []string
@@ -18,21 +18,31 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/klog/v2"
+	"k8s.io/client-go/tools/record"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
@@ -41,6 +51,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 	nodelockutil "github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 func Test_getNodesUsage(t *testing.T) {
@@ -257,21 +268,7 @@ func Test_Filter(t *testing.T) {
 	informerFactory.Start(s.stopCh)
 	informerFactory.WaitForCacheSync(s.stopCh)
 	s.addAllEventHandlers()
-	sConfig := &config.Config{
-		NvidiaConfig: nvidia.NvidiaConfig{
-			ResourceCountName:            "hami.io/gpu",
-			ResourceMemoryName:           "hami.io/gpumem",
-			ResourceMemoryPercentageName: "hami.io/gpumem-percentage",
-			ResourceCoreName:             "hami.io/gpucores",
-			DefaultMemory:                0,
-			DefaultCores:                 0,
-			DefaultGPUNum:                1,
-		},
-	}
-
-	if err := config.InitDevicesWithConfig(sConfig); err != nil {
-		klog.Fatalf("Failed to initialize devices with config: %v", err)
-	}
+	initTestDevices(t)
 	pod1 := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "pod1",
@@ -609,17 +606,19 @@ func Test_Filter(t *testing.T) {
 		},
 	}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			initNode()
-			client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Create(context.Background(), test.args.Pod, metav1.CreateOptions{})
-			got, gotErr := s.Filter(test.args)
-			assert.DeepEqual(t, test.wantErr, gotErr)
-			assert.DeepEqual(t, test.want, got)
-			getPod, _ := client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Get(context.Background(), test.args.Pod.Name, metav1.GetOptions{})
-			podDevices, _ := device.DecodePodDevices(device.SupportDevices, getPod.Annotations)
-			assert.DeepEqual(t, test.wantPodAnnotationDeviceID, podDevices["NVIDIA"][0][0].UUID)
-		})
+	for _, transport := range extenderTransports(t, s) {
+		for _, test := range tests {
+			t.Run(transport.name+"/"+test.name, func(t *testing.T) {
+				initNode()
+				client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Create(context.Background(), test.args.Pod, metav1.CreateOptions{})
+				got, gotErr := transport.filter(test.args)
+				assert.DeepEqual(t, test.wantErr, gotErr)
+				assert.DeepEqual(t, test.want, got)
+				getPod, _ := client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Get(context.Background(), test.args.Pod.Name, metav1.GetOptions{})
+				podDevices, _ := device.DecodePodDevices(device.SupportDevices, getPod.Annotations)
+				assert.DeepEqual(t, test.wantPodAnnotationDeviceID, podDevices["NVIDIA"][0][0].UUID)
+			})
+		}
 	}
 }
 
@@ -749,8 +748,8 @@ func Test_RegisterFromNodeAnnotations(t *testing.T) {
 					t.Errorf("missing annotation: hami.io/node-handshake-dcu")
 					return false
 				}
-				_, errHami := time.Parse(time.DateTime, strings.TrimPrefix(handshakeTimeStr, "Requesting_"))
-				_, errDcu := time.Parse(time.DateTime, strings.TrimPrefix(dcuTimeStr, "Requesting_"))
+				_, errHami := util.ParseHandshakeTime(strings.TrimPrefix(handshakeTimeStr, "Requesting_"))
+				_, errDcu := util.ParseHandshakeTime(strings.TrimPrefix(dcuTimeStr, "Requesting_"))
 				if errHami != nil {
 					t.Errorf("invalid time format in annotation 'hami.io/node-handshake': %v", errHami)
 					return false
@@ -862,8 +861,8 @@ func Test_RegisterFromNodeAnnotations_NIL(t *testing.T) {
 				}
 
 				// Verify time format in annotations if they exist
-				_, errHami := time.Parse(time.DateTime, strings.TrimPrefix(handshakeTimeStr, "Requesting_"))
-				_, errDcu := time.Parse(time.DateTime, strings.TrimPrefix(dcuTimeStr, "Requesting_"))
+				_, errHami := util.ParseHandshakeTime(strings.TrimPrefix(handshakeTimeStr, "Requesting_"))
+				_, errDcu := util.ParseHandshakeTime(strings.TrimPrefix(dcuTimeStr, "Requesting_"))
 
 				if errHami != nil {
 					t.Errorf("invalid time format in annotation 'hami.io/node-handshake': %v", errHami)
@@ -929,21 +928,7 @@ func Test_ResourceQuota(t *testing.T) {
 	informerFactory.Start(s.stopCh)
 	informerFactory.WaitForCacheSync(s.stopCh)
 	s.addAllEventHandlers()
-	sConfig := &config.Config{
-		NvidiaConfig: nvidia.NvidiaConfig{
-			ResourceCountName:            "hami.io/gpu",
-			ResourceMemoryName:           "hami.io/gpumem",
-			ResourceMemoryPercentageName: "hami.io/gpumem-percentage",
-			ResourceCoreName:             "hami.io/gpucores",
-			DefaultMemory:                0,
-			DefaultCores:                 0,
-			DefaultGPUNum:                1,
-		},
-	}
-
-	if err := config.InitDevicesWithConfig(sConfig); err != nil {
-		klog.Fatalf("Failed to initialize devices with config: %v", err)
-	}
+	initTestDevices(t)
 
 	initNode := func() {
 		nodes, _ := s.ListNodes()
@@ -1197,24 +1182,614 @@ func Test_ResourceQuota(t *testing.T) {
 		},
 	}
 
-	for _, test := range tests {
+	for _, transport := range extenderTransports(t, s) {
+		for _, test := range tests {
+			t.Run(transport.name+"/"+test.name, func(t *testing.T) {
+				s.onAddQuota(&test.quota)
+				initNode()
+				client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Create(context.Background(), test.args.Pod, metav1.CreateOptions{})
+				got, gotErr := transport.filter(test.args)
+				client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Delete(context.Background(), test.args.Pod.Name, metav1.DeleteOptions{})
+				// wait for pod deletion to be processed by the informer
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+					_, ok := s.podManager.GetPod(test.args.Pod)
+					return !ok, nil
+				})
+				require.NoError(t, err, "timed out waiting for pod to be deleted from pod manager")
+				s.onDelQuota(&test.quota)
+				assert.DeepEqual(t, test.wantErr, gotErr)
+				assert.DeepEqual(t, test.want, got)
+			})
+		}
+	}
+}
+
+func Test_Filter_ShareGPUWithinPod(t *testing.T) {
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	initTestDevices(t)
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {
+				{ID: "device1", Index: 0, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+				{ID: "device2", Index: 1, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			},
+		},
+	})
+	gpuContainer := func(name string, mem int64) corev1.Container {
+		return corev1.Container{
+			Name: name,
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+					"hami.io/gpumem": *resource.NewQuantity(mem, resource.BinarySI),
+				},
+			},
+		}
+	}
+	// Without sharing, the containers need 11000 MiB and can't be put on the same device.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shared",
+			Namespace:   "default",
+			UID:         "shared-uid",
+			Annotations: map[string]string{util.ShareGPUWithinPodAnnotationKey: "true"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{gpuContainer("train", 6000), gpuContainer("sidecar", 5000), {Name: "log"}},
+		},
+	}
+	client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+
+	got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &[]string{"node1"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, &extenderv1.ExtenderFilterResult{NodeNames: &[]string{"node1"}})
+
+	getPod, _ := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	podDevices, _ := device.DecodePodDevices(device.SupportDevices, getPod.Annotations)
+	ctrDevices := podDevices[nvidia.NvidiaGPUDevice]
+	assert.Assert(t, len(ctrDevices) >= 2)
+	assert.Equal(t, len(ctrDevices[0]), 1)
+	assert.Equal(t, ctrDevices[0][0].Usedmem, int32(6000))
+	assert.DeepEqual(t, ctrDevices[1], ctrDevices[0])
+
+	// The shared device is counted once.
+	nodeUsage, _, err := s.getNodesUsage(&[]string{"node1"}, pod)
+	assert.NilError(t, err)
+	used := map[string]int32{}
+	for _, d := range (*nodeUsage)["node1"].Devices.DeviceLists {
+		used[d.Device.ID] = d.Device.Usedmem
+	}
+	assert.Equal(t, used[ctrDevices[0][0].UUID], int32(6000))
+	assert.Equal(t, used["device1"]+used["device2"], int32(6000))
+}
+
+func Test_Filter_GPUModelFallback(t *testing.T) {
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	initTestDevices(t)
+	for _, n := range []struct{ name, uuid, model string }{
+		{name: "node1", uuid: "a100", model: "NVIDIA-NVIDIA A100-SXM4-40GB"},
+		{name: "node2", uuid: "v100", model: "NVIDIA-Tesla V100-PCIE-32GB"},
+		{name: "node3", uuid: "t4", model: "NVIDIA-Tesla T4"},
+	} {
+		s.addNode(n.name, &device.NodeInfo{
+			ID:   n.name,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: n.name}},
+			Devices: map[string][]device.DeviceInfo{
+				nvidia.NvidiaGPUDevice: {
+					{ID: n.uuid, Index: 0, Count: 10, Devmem: 8000, Devcore: 100, Type: n.model, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+				},
+			},
+		})
+	}
+	// The A100 is full.
+	s.podManager.AddPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "full", Namespace: "default", UID: "full-uid"}}, "node1", device.PodDevices{
+		nvidia.NvidiaGPUDevice: device.PodSingleDevice{
+			{{Idx: 0, UUID: "a100", Type: nvidia.NvidiaGPUDevice, Usedmem: 8000, Usedcores: 100}},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		fallback  string
+		wantNode  string
+		wantModel string
+	}{
+		{name: "falls back to the next model", fallback: "A100>V100>T4", wantNode: "node2", wantModel: "V100"},
+		{name: "follows the order", fallback: "A100>T4>V100", wantNode: "node3", wantModel: "T4"},
+		{name: "no model fits", fallback: "A100>H100", wantNode: ""},
+	}
+	for i, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			s.onAddQuota(&test.quota)
-			initNode()
-			client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Create(context.Background(), test.args.Pod, metav1.CreateOptions{})
-			got, gotErr := s.Filter(test.args)
-			client.KubeClient.CoreV1().Pods(test.args.Pod.Namespace).Delete(context.Background(), test.args.Pod.Name, metav1.DeleteOptions{})
-			// wait for pod deletion to be processed by the informer
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(ctx context.Context) (bool, error) {
-				_, ok := s.podManager.GetPod(test.args.Pod)
-				return !ok, nil
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        fmt.Sprintf("fallback-%d", i),
+					Namespace:   "default",
+					UID:         types.UID(fmt.Sprintf("fallback-uid-%d", i)),
+					Annotations: map[string]string{util.GPUModelFallbackAnnotationKey: test.fallback},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "gpu",
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+								"hami.io/gpumem": *resource.NewQuantity(4000, resource.BinarySI),
+							},
+						},
+					}},
+				},
+			}
+			client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+
+			got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &[]string{"node1", "node2", "node3"}})
+			assert.NilError(t, err)
+			getPod, _ := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+			if test.wantNode == "" {
+				assert.Assert(t, got.NodeNames == nil)
+				assert.Equal(t, len(got.FailedNodes), 3)
+				_, ok := getPod.Annotations[util.GPUModelSelectedAnnotationKey]
+				assert.Assert(t, !ok)
+				return
+			}
+			assert.DeepEqual(t, got, &extenderv1.ExtenderFilterResult{NodeNames: &[]string{test.wantNode}})
+			assert.Equal(t, getPod.Annotations[util.GPUModelSelectedAnnotationKey], test.wantModel)
+			assert.Equal(t, getPod.Annotations[util.AssignedNodeAnnotations], test.wantNode)
+			// The pod is not restricted to the model outside of the filter.
+			_, ok := getPod.Annotations[nvidia.GPUInUse]
+			assert.Assert(t, !ok)
+			s.podManager.DelPod(pod)
+		})
+	}
+}
+
+func Test_Filter_Tracing(t *testing.T) {
+	initTestDevices(t)
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {
+				{ID: "device1", Index: 0, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			},
+		},
+	})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "traced", Namespace: "default", UID: "traced-uid"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "train",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.BinarySI)},
+			},
+		}}},
+	}
+
+	// The webhook starts the trace and records it on the pod.
+	podBytes, err := json.Marshal(pod)
+	assert.NilError(t, err)
+	wh, err := NewWebHook(nil, nil, nil)
+	assert.NilError(t, err)
+	resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID: "req-uid", Namespace: pod.Namespace, Name: pod.Name, Object: runtime.RawExtension{Raw: podBytes},
+	}})
+	assert.Assert(t, resp.Allowed)
+	for _, patch := range resp.Patches {
+		if patch.Path == "/metadata/annotations" {
+			annotations := map[string]string{}
+			for k, v := range patch.Value.(map[string]any) {
+				annotations[k] = v.(string)
+			}
+			pod.Annotations = annotations
+		}
+	}
+	assert.Assert(t, pod.Annotations[tracing.TraceParentAnnotationKey] != "")
+
+	client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	_, err = s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &[]string{"node1"}})
+	assert.NilError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	webhook, filter, score := spans["webhook.Mutate"], spans["scheduler.Filter"], spans["scheduler.Score"]
+	assert.Assert(t, webhook != nil && filter != nil && score != nil)
+	assert.Assert(t, !webhook.Parent().IsValid())
+	// The filter runs in another request, it's a child of the webhook span by the pod annotation.
+	assert.Equal(t, filter.Parent().SpanID(), webhook.SpanContext().SpanID())
+	assert.Equal(t, filter.SpanContext().TraceID(), webhook.SpanContext().TraceID())
+	assert.Assert(t, filter.Parent().IsRemote())
+	assert.Equal(t, score.Parent().SpanID(), filter.SpanContext().SpanID())
+	assert.Equal(t, score.SpanContext().TraceID(), webhook.SpanContext().TraceID())
+}
+
+func Test_Filter_BurstableQoS(t *testing.T) {
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	initTestDevices(t)
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {
+				{ID: "device1", Index: 0, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			},
+		},
+	})
+	newPod := func(name string, mem int64, annos map[string]string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid"), Annotations: annos},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "ctr",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+						"hami.io/gpumem": *resource.NewQuantity(mem, resource.BinarySI),
+					},
+				},
+			}}},
+		}
+		client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		return pod
+	}
+	filter := func(pod *corev1.Pod) bool {
+		res, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &[]string{"node1"}})
+		assert.NilError(t, err)
+		return res.NodeNames != nil && len(*res.NodeNames) == 1
+	}
+	usage := func() *device.DeviceUsage {
+		nodeUsage, _, err := s.getNodesUsage(&[]string{"node1"}, nil)
+		assert.NilError(t, err)
+		return (*nodeUsage)["node1"].Devices.DeviceLists[0].Device
+	}
+	burstable := func(guaranteed string) map[string]string {
+		return map[string]string{util.QoSAnnotationKey: "burstable", util.GuaranteedMemoryAnnotationKey: guaranteed}
+	}
+
+	// The batch pod claims 6000 of which 4000 are opportunistic.
+	assert.Assert(t, filter(newPod("batch", 6000, burstable("2000"))))
+	dev := usage()
+	assert.Equal(t, dev.Usedmem, int32(6000))
+	assert.Equal(t, dev.Opportunisticmem, int32(4000))
+
+	// Burstable pods only claim memory no pod uses.
+	assert.Assert(t, !filter(newPod("batch2", 4000, burstable("1000"))))
+
+	// A guaranteed pod reclaims 3000 of the opportunistic memory.
+	assert.Assert(t, filter(newPod("serving", 5000, nil)))
+	dev = usage()
+	assert.Equal(t, dev.Usedmem, int32(11000))
+	assert.Equal(t, device.ReclaimedMem(dev), int32(3000))
+	assert.Equal(t, device.AvailableMem(dev, util.QoSGuaranteed), int32(1000))
+
+	// The guaranteed memory of the batch pod can't be reclaimed.
+	assert.Assert(t, !filter(newPod("serving2", 2000, nil)))
+	assert.Assert(t, filter(newPod("serving3", 1000, nil)))
+	assert.Equal(t, device.AvailableMem(usage(), util.QoSGuaranteed), int32(0))
+}
+
+func Test_Tracing_InMemoryExporter(t *testing.T) {
+	initTestDevices(t)
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(prev)
+
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	s.addAllEventHandlers()
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	client.KubeClient.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: node,
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {
+				{ID: "GPU-0", Index: 0, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+			},
+		},
+	})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "traced", Namespace: "default", UID: "traced-uid"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "train",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.BinarySI)},
+			},
+		}}},
+	}
+
+	podBytes, err := json.Marshal(pod)
+	assert.NilError(t, err)
+	wh, err := NewWebHook(nil, nil, nil)
+	assert.NilError(t, err)
+	resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID: "req-uid", Namespace: pod.Namespace, Name: pod.Name, Object: runtime.RawExtension{Raw: podBytes},
+	}})
+	assert.Assert(t, resp.Allowed)
+	for _, patch := range resp.Patches {
+		if patch.Path == "/metadata/annotations" {
+			pod.Annotations = map[string]string{}
+			for k, v := range patch.Value.(map[string]any) {
+				pod.Annotations[k] = v.(string)
+			}
+		}
+	}
+	client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	_, err = s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &[]string{"node1"}})
+	assert.NilError(t, err)
+	_, err = s.Bind(extenderv1.ExtenderBindingArgs{PodName: pod.Name, PodNamespace: pod.Namespace, PodUID: pod.UID, Node: "node1"})
+	assert.NilError(t, err)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	for _, name := range []string{"webhook.Mutate", "scheduler.Filter", "scheduler.Score", "scheduler.Bind"} {
+		span, ok := spans[name]
+		assert.Assert(t, ok, name)
+		assert.Equal(t, span.SpanContext.TraceID(), spans["webhook.Mutate"].SpanContext.TraceID(), name)
+		assert.Assert(t, !span.EndTime.Before(span.StartTime), name)
+	}
+	attributes := func(span tracetest.SpanStub) map[string]attribute.Value {
+		res := map[string]attribute.Value{}
+		for _, kv := range span.Attributes {
+			res[string(kv.Key)] = kv.Value
+		}
+		return res
+	}
+	for _, name := range []string{"scheduler.Filter", "scheduler.Bind"} {
+		attrs := attributes(spans[name])
+		assert.Equal(t, attrs["k8s.pod.uid"].AsString(), "traced-uid", name)
+		assert.Equal(t, attrs["hami.node"].AsString(), "node1", name)
+		assert.DeepEqual(t, attrs["hami.device_uuids"].AsStringSlice(), []string{"GPU-0"})
+	}
+}
+
+func Test_onUpdatePod_QuotaUsage(t *testing.T) {
+	initTestDevices(t)
+	s := NewScheduler()
+	defer s.Stop()
+
+	ns := "quota-update"
+	newPod := func(usedmem int32, annotations map[string]string) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: ns, UID: "train-uid", Annotations: map[string]string{
+			util.AssignedNodeAnnotations: "node1",
+		}}, Spec: corev1.PodSpec{NodeName: "node1"}}
+		for k, v := range device.EncodePodDevices(device.SupportDevices, device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+			{Idx: 0, UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: usedmem, Usedcores: 10},
+		}}}) {
+			pod.Annotations[k] = v
+		}
+		for k, v := range annotations {
+			pod.Annotations[k] = v
+		}
+		return pod
+	}
+	used := func() (int64, int64) {
+		dq := s.quotaManager.GetResourceQuota()[ns]
+		return (*dq)["hami.io/gpumem"].Used, (*dq)["hami.io/gpucores"].Used
+	}
+
+	// Another controller patching the annotations of the running pod doesn't count its usage again.
+	s.onAddPod(newPod(1000, nil))
+	s.onUpdatePod(nil, newPod(1000, map[string]string{"example.com/patched": "1"}))
+	s.onUpdatePod(nil, newPod(1000, map[string]string{"example.com/patched": "2"}))
+	mem, cores := used()
+	assert.Equal(t, mem, int64(1000))
+	assert.Equal(t, cores, int64(10))
+
+	// Changed devices replace the usage attributed to the pod.
+	s.onUpdatePod(nil, newPod(3000, nil))
+	mem, _ = used()
+	assert.Equal(t, mem, int64(3000))
+	assert.Equal(t, s.quotaManager.Reconcile(func(types.UID) bool { return true }), 0)
+
+	s.onDelPod(newPod(3000, nil))
+	mem, cores = used()
+	assert.Equal(t, mem, int64(0))
+	assert.Equal(t, cores, int64(0))
+}
+
+func Test_reconcileUsage(t *testing.T) {
+	initTestDevices(t)
+	s := NewScheduler()
+	defer s.Stop()
+
+	ns := "quota-reconcile"
+	devices := device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+		{Idx: 0, UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 1000, Usedcores: 10},
+	}}}
+	running := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: ns, UID: "running-uid"}}
+	s.podManager.AddPod(running, "node1", devices)
+	s.quotaManager.AddUsage(running, devices)
+	// Usage of a pod dropped from the cache without removing its usage.
+	gone := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gone", Namespace: ns, UID: "gone-uid"}}
+	s.quotaManager.AddUsage(gone, devices)
+
+	s.reconcileUsage()
+	dq := s.quotaManager.GetResourceQuota()[ns]
+	assert.Equal(t, (*dq)["hami.io/gpumem"].Used, int64(1000))
+	assert.Equal(t, (*dq)["hami.io/gpucores"].Used, int64(10))
+
+	// Usage changed behind the quota manager's back is recomputed.
+	s.quotaManager.Quotas[ns] = &device.DeviceQuota{"hami.io/gpumem": {Used: 5000, Limit: 8000}}
+	assert.Equal(t, s.quotaManager.Reconcile(func(types.UID) bool { return true }), 2)
+	dq = s.quotaManager.GetResourceQuota()[ns]
+	assert.Equal(t, (*dq)["hami.io/gpumem"].Used, int64(1000))
+	assert.Equal(t, (*dq)["hami.io/gpumem"].Limit, int64(8000))
+	assert.Equal(t, (*dq)["hami.io/gpucores"].Used, int64(10))
+}
+
+func Test_Filter_DeterministicTies(t *testing.T) {
+	initTestDevices(t)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "tie", Namespace: "default", UID: "tie-uid"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "gpu",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+						"hami.io/gpumem": *resource.NewQuantity(1000, resource.BinarySI),
+					},
+				},
+			}},
+		},
+	}
+	nodeNames := []string{"node3", "node1", "node4", "node2"}
+	// schedule filters the pod on identical nodes with identical devices.
+	schedule := func() (string, device.PodDevices) {
+		s := NewScheduler()
+		defer s.Stop()
+		client.KubeClient = fake.NewSimpleClientset(pod.DeepCopy())
+		s.kubeClient = client.KubeClient
+		for _, name := range nodeNames {
+			devices := make([]device.DeviceInfo, 0)
+			for _, uuid := range []string{"GPU-c", "GPU-a", "GPU-b"} {
+				devices = append(devices, device.DeviceInfo{
+					ID: name + "-" + uuid, Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+				})
+			}
+			s.addNode(name, &device.NodeInfo{
+				ID:      name,
+				Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+				Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices},
 			})
-			require.NoError(t, err, "timed out waiting for pod to be deleted from pod manager")
-			s.onDelQuota(&test.quota)
-			assert.DeepEqual(t, test.wantErr, gotErr)
-			assert.DeepEqual(t, test.want, got)
+		}
+		got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+		assert.NilError(t, err)
+		assert.Assert(t, got.NodeNames != nil && len(*got.NodeNames) == 1)
+		pi, ok := s.podManager.GetPod(pod)
+		assert.Assert(t, ok)
+		return (*got.NodeNames)[0], pi.Devices
+	}
+
+	for _, nodePolicy := range []string{util.NodeSchedulerPolicyBinpack.String(), util.NodeSchedulerPolicySpread.String()} {
+		t.Run(nodePolicy, func(t *testing.T) {
+			pod.Annotations = map[string]string{policy.NodeSchedulerPolicyAnnotationKey: nodePolicy}
+			node, devices := schedule()
+			// The last of the equal nodes and devices by name and UUID.
+			assert.Equal(t, node, "node4")
+			assert.Equal(t, devices[nvidia.NvidiaGPUDevice][0][0].UUID, "node4-GPU-c")
+			// Scheduling again decides the same.
+			againNode, againDevices := schedule()
+			assert.Equal(t, againNode, node)
+			assert.DeepEqual(t, againDevices, devices)
 		})
 	}
 }
+
+func Test_Bind_Duplicate(t *testing.T) {
+	initTestDevices(t)
+	nodelockutil.ResetNodeLocksForTest()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default", UID: "train"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "train",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.DecimalSI)}},
+		}}},
+	}
+	kubeClient := fake.NewSimpleClientset(pod,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}})
+	client.KubeClient = kubeClient
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = kubeClient
+	s.eventRecorder = record.NewFakeRecorder(10)
+	args := extenderv1.ExtenderBindingArgs{PodName: pod.Name, PodNamespace: pod.Namespace, PodUID: pod.UID, Node: "node1"}
+
+	res, err := s.Bind(args)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Error, "")
+	// The fake clientset doesn't bind pods, the pod is bound as by the API server.
+	bound, err := kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	bound.Spec.NodeName = "node1"
+	bound, err = kubeClient.CoreV1().Pods(pod.Namespace).Update(context.Background(), bound, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+	assertOnlyGetPod := func() {
+		t.Helper()
+		for _, action := range kubeClient.Actions() {
+			assert.Assert(t, action.GetVerb() == "get" && action.GetResource().Resource == "pods", "unexpected action %v", action)
+		}
+		current, err := kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, current.Annotations, bound.Annotations)
+		node, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node2", metav1.GetOptions{})
+		assert.NilError(t, err)
+		_, locked := node.Annotations[nodelockutil.NodeLockKey]
+		assert.Assert(t, !locked, "node locked: %v", node.Annotations)
+	}
+
+	// The retry of the bind succeeds without locking the node or patching the pod again.
+	kubeClient.ClearActions()
+	res, err = s.Bind(args)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Error, "")
+	assertOnlyGetPod()
+
+	// Binding the pod to another node conflicts.
+	kubeClient.ClearActions()
+	args.Node = "node2"
+	res, err = s.Bind(args)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(res.Error, errBindConflict.Error()), res.Error)
+	assert.Assert(t, strings.Contains(res.Error, "bound to node node1, not node2"), res.Error)
+	assertOnlyGetPod()
+
+	// The bind of a previous pod of the same name bound to the node conflicts too.
+	kubeClient.ClearActions()
+	res, err = s.Bind(extenderv1.ExtenderBindingArgs{PodName: pod.Name, PodNamespace: pod.Namespace, PodUID: "previous", Node: "node1"})
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(res.Error, errBindConflict.Error()), res.Error)
+	assert.Assert(t, strings.Contains(res.Error, "has uid train, not previous"), res.Error)
+	assertOnlyGetPod()
+
+	// A retry arriving while the bind is in flight, the node being locked by the pod assigned to it, fails
+	// without patching the pod or releasing the lock of the bind in flight.
+	infer := pod.DeepCopy()
+	infer.Name, infer.UID = "infer", "infer"
+	infer.Annotations = map[string]string{util.AssignedNodeAnnotations: "node1"}
+	_, err = kubeClient.CoreV1().Pods(infer.Namespace).Create(context.Background(), infer, metav1.CreateOptions{})
+	assert.NilError(t, err)
+	node1, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	lock := nodelockutil.GenerateNodeLockKeyByPod(infer)
+	node1.Annotations = map[string]string{nodelockutil.NodeLockKey: lock}
+	_, err = kubeClient.CoreV1().Nodes().Update(context.Background(), node1, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+	kubeClient.ClearActions()
+	res, err = s.Bind(extenderv1.ExtenderBindingArgs{PodName: infer.Name, PodNamespace: infer.Namespace, PodUID: infer.UID, Node: "node1"})
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(res.Error, errBindInProgress.Error()), res.Error)
+	for _, action := range kubeClient.Actions() {
+		assert.Assert(t, action.GetVerb() == "get", "unexpected action %v", action)
+	}
+	node1, err = kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, node1.Annotations[nodelockutil.NodeLockKey], lock)
+}
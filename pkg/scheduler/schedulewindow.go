@@ -0,0 +1,150 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// EventReasonScheduleDeferred indicates a pod is kept pending until its schedule window opens.
+const EventReasonScheduleDeferred = "ScheduleDeferred"
+
+// deferredPods tracks the pods kept pending by their schedule window, so they're retried once it opens instead of
+// waiting for kube-scheduler to retry unschedulable pods on its own.
+type deferredPods struct {
+	mutex sync.Mutex
+	// Pods by UID with the time their window opens
+	pods map[k8stypes.UID]deferredPod
+}
+
+type deferredPod struct {
+	namespace string
+	name      string
+	opensAt   time.Time
+}
+
+// scheduleOpensAt returns the time the pod may be scheduled from, set by util.ScheduleAfterAnnotationKey and
+// util.ScheduleWindowAnnotationKey, now if it may be scheduled now.
+func scheduleOpensAt(pod *corev1.Pod, now time.Time) (time.Time, error) {
+	after, err := util.GetScheduleAfter(pod)
+	if err != nil {
+		return now, err
+	}
+	window, err := util.GetScheduleWindow(pod)
+	if err != nil {
+		return now, err
+	}
+	res := now
+	if after.After(res) {
+		res = after
+	}
+	if window != nil {
+		res = window.NextOpen(res)
+	}
+	return res, nil
+}
+
+// deferPod records the pod is kept pending until opensAt.
+func (s *Scheduler) deferPod(pod *corev1.Pod, opensAt time.Time) {
+	s.deferred.mutex.Lock()
+	defer s.deferred.mutex.Unlock()
+	if s.deferred.pods == nil {
+		s.deferred.pods = make(map[k8stypes.UID]deferredPod)
+	}
+	s.deferred.pods[pod.UID] = deferredPod{namespace: pod.Namespace, name: pod.Name, opensAt: opensAt}
+}
+
+// forgetDeferredPod stops tracking the pod, once it's scheduled or deleted.
+func (s *Scheduler) forgetDeferredPod(uid k8stypes.UID) {
+	s.deferred.mutex.Lock()
+	defer s.deferred.mutex.Unlock()
+	delete(s.deferred.pods, uid)
+}
+
+// filterScheduleWindow returns the reason the pod is kept off all nodes if its schedule window isn't open, empty
+// if it may be scheduled now.
+func (s *Scheduler) filterScheduleWindow(pod *corev1.Pod) (string, error) {
+	now := s.clock.Now()
+	opensAt, err := scheduleOpensAt(pod, now)
+	if err != nil {
+		return "", err
+	}
+	if !opensAt.After(now) {
+		s.forgetDeferredPod(pod.UID)
+		return "", nil
+	}
+	s.deferPod(pod, opensAt)
+	return fmt.Sprintf("pod deferred by its schedule window until %s", opensAt.UTC().Format(time.RFC3339)), nil
+}
+
+// requeueDeferredPods updates the deferred pods whose schedule window opened, which makes kube-scheduler retry them.
+func (s *Scheduler) requeueDeferredPods() {
+	now := s.clock.Now()
+	due := make(map[k8stypes.UID]deferredPod)
+	s.deferred.mutex.Lock()
+	for uid, p := range s.deferred.pods {
+		if !p.opensAt.After(now) {
+			due[uid] = p
+		}
+	}
+	s.deferred.mutex.Unlock()
+	for uid, p := range due {
+		err := util.PatchAnnotationsWithRetry(context.Background(), s.kubeClient, util.PodRef(p.namespace, p.name), func(meta *metav1.ObjectMeta) error {
+			if meta.Annotations == nil {
+				meta.Annotations = make(map[string]string, 1)
+			}
+			meta.Annotations[util.ScheduleWindowOpenedAnnotationKey] = p.opensAt.UTC().Format(time.RFC3339)
+			return nil
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to requeue pod on its schedule window opening", "pod", klog.KRef(p.namespace, p.name))
+			continue
+		}
+		klog.V(4).InfoS("Requeued pod on its schedule window opening", "pod", klog.KRef(p.namespace, p.name), "opensAt", p.opensAt)
+		// The retry may have deferred the pod to its next window meanwhile, that one is kept.
+		s.deferred.mutex.Lock()
+		if current, ok := s.deferred.pods[uid]; ok && current.opensAt.Equal(p.opensAt) {
+			delete(s.deferred.pods, uid)
+		}
+		s.deferred.mutex.Unlock()
+	}
+}
+
+// requeueDeferredPodsLoop requeues the deferred pods whose schedule window opened every few seconds.
+func (s *Scheduler) requeueDeferredPodsLoop() {
+	ticker := time.NewTicker(time.Second * 5)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.requeueDeferredPods()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
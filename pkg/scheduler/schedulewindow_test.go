@@ -0,0 +1,181 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Filter_ScheduleWindow(t *testing.T) {
+	initTestDevices(t)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	client.KubeClient = fake.NewSimpleClientset(node)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	clock := testingclock.NewFakeClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	s.clock = clock
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: node,
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+			ID: "GPU-0", Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+		}}},
+	})
+	nodeNames := []string{"node1"}
+
+	newPod := func(name string, annotations map[string]string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name), Annotations: annotations},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "train",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(1, resource.DecimalSI),
+					"hami.io/gpumem": *resource.NewQuantity(1000, resource.DecimalSI),
+				}},
+			}}},
+		}
+		_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		return pod
+	}
+	nightly := newPod("nightly", map[string]string{util.ScheduleWindowAnnotationKey: "22:00-06:00"})
+	midday := newPod("midday", map[string]string{util.ScheduleWindowAnnotationKey: "11:00-13:00"})
+	later := newPod("later", map[string]string{
+		util.ScheduleAfterAnnotationKey:  "2025-01-16T08:00:00+02:00",
+		util.ScheduleWindowAnnotationKey: "22:00-06:00",
+	})
+
+	// In its window, the pod is scheduled normally.
+	got, err := s.Filter(extenderv1.ExtenderArgs{Pod: midday, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+
+	// Out of their window, the pods fail every node until it opens.
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: nightly, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0)
+	assert.Equal(t, got.FailedNodes["node1"], "pod deferred by its schedule window until 2025-01-15T22:00:00Z")
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: later, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.Equal(t, got.FailedNodes["node1"], "pod deferred by its schedule window until 2025-01-16T22:00:00Z")
+
+	opened := func(name string) string {
+		pod, err := client.KubeClient.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		return pod.Annotations[util.ScheduleWindowOpenedAnnotationKey]
+	}
+	s.requeueDeferredPods()
+	assert.Equal(t, opened("nightly"), "")
+
+	// Once the window opens, the pod is updated for kube-scheduler to retry it, and scheduled.
+	clock.SetTime(time.Date(2025, 1, 15, 22, 0, 0, 0, time.UTC))
+	s.requeueDeferredPods()
+	assert.Equal(t, opened("nightly"), "2025-01-15T22:00:00Z")
+	assert.Equal(t, opened("later"), "")
+	s.deferred.mutex.Lock()
+	_, deferred := s.deferred.pods[nightly.UID]
+	s.deferred.mutex.Unlock()
+	assert.Assert(t, !deferred)
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: nightly, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+
+	// The window is checked after the time set by the pod, it doesn't open before then.
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: later, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.Equal(t, got.FailedNodes["node1"], "pod deferred by its schedule window until 2025-01-16T22:00:00Z")
+}
+
+func Test_scheduleOpensAt(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Time
+		wantErr     bool
+	}{
+		{
+			name: "not set",
+			want: now,
+		},
+		{
+			name:        "after in the past",
+			annotations: map[string]string{util.ScheduleAfterAnnotationKey: "2025-01-15T12:00:00Z"},
+			want:        now,
+		},
+		{
+			name:        "after in the future",
+			annotations: map[string]string{util.ScheduleAfterAnnotationKey: "2025-01-15T15:00:00+01:00"},
+			want:        time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "in window",
+			annotations: map[string]string{util.ScheduleWindowAnnotationKey: "12:00-13:00"},
+			want:        now,
+		},
+		{
+			name:        "window later today",
+			annotations: map[string]string{util.ScheduleWindowAnnotationKey: "18:00-20:00"},
+			want:        time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "window tomorrow",
+			annotations: map[string]string{util.ScheduleWindowAnnotationKey: "01:00-02:00"},
+			want:        time.Date(2025, 1, 16, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "window open past midnight",
+			annotations: map[string]string{util.ScheduleWindowAnnotationKey: "12:00-06:00"},
+			want:        now,
+		},
+		{
+			name:        "invalid window",
+			annotations: map[string]string{util.ScheduleWindowAnnotationKey: "22:00"},
+			wantErr:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+			got, err := scheduleOpensAt(pod, now)
+			if test.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Assert(t, got.Equal(test.want), "got %v, want %v", got, test.want)
+		})
+	}
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -48,29 +49,83 @@ func getNodeResources(list NodeUsage, t string) []*device.DeviceUsage {
 	return l
 }
 
+// checkNodeCapacity returns why the devices registered by a node can never fit the request, whatever their usage,
+// so the node is rejected without fitting every device.
+func checkNodeCapacity(devices []*device.DeviceUsage, k device.ContainerDeviceRequest) string {
+	if int(k.Nums) > len(devices) {
+		return common.GenNodeInsufficientDeviceReason(int(k.Nums), len(devices))
+	}
+	if k.Memreq > 0 {
+		totalMem := int64(0)
+		for _, dev := range devices {
+			totalMem += int64(dev.Totalmem)
+		}
+		if requested := int64(k.Nums) * int64(k.Memreq); requested > totalMem {
+			return common.GenNodeInsufficientMemoryReason(requested, totalMem)
+		}
+	}
+	return ""
+}
+
+// withinMaxMemory returns the devices on which the request takes at most maxMem of memory, and the number of those
+// left out. Memory requested in percentage of a device is only known per device, e.g. a request of a whole GPU
+// exceeds the max on an 80GB GPU but not on a 24GB one of the same node.
+func withinMaxMemory(devices []*device.DeviceUsage, k device.ContainerDeviceRequest, maxMem int64) ([]*device.DeviceUsage, int) {
+	if maxMem == 0 {
+		return devices, 0
+	}
+	res := make([]*device.DeviceUsage, 0, len(devices))
+	for _, dev := range devices {
+		memreq := int64(k.Memreq)
+		if memreq == 0 && k.MemPercentagereq != 101 {
+			memreq = int64(dev.Totalmem) * int64(k.MemPercentagereq) / 100
+		}
+		if memreq > maxMem {
+			continue
+		}
+		res = append(res, dev)
+	}
+	return res, len(devices) - len(res)
+}
+
 func fitInDevices(node *NodeUsage, requests device.ContainerDeviceRequests, pod *corev1.Pod, nodeInfo *device.NodeInfo, devinput *device.PodDevices) (bool, string) {
 	//devmap := make(map[string]device.ContainerDevices)
 	devs := device.ContainerDevices{}
 	total, totalCore, totalMem := int32(0), int32(0), int32(0)
 	free, freeCore, freeMem := int32(0), int32(0), int32(0)
 	sums := 0
-	// computer all device score for one node
-	for index := range node.Devices.DeviceLists {
-		node.Devices.DeviceLists[index].ComputeScore(requests)
+	maxMem := config.Current().MaxMemoryPerAllocation(pod.Namespace)
+	// reject the node before scoring its devices if it can never fit the requests
+	for _, k := range requests {
+		if _, ok := device.GetDevices()[k.Type]; !ok {
+			continue
+		}
+		if reason := checkNodeCapacity(getNodeResources(*node, k.Type), k); reason != "" {
+			klog.V(5).InfoS(common.NodeInsufficientDevice, "pod", klog.KObj(pod), "reason", reason)
+			return false, reason
+		}
 	}
+	// computer all device score for one node
+	node.Devices.ComputeScore(requests)
 	//This loop is for requests for different devices
 	for _, k := range requests {
 		sums += int(k.Nums)
-		if int(k.Nums) > len(node.Devices.DeviceLists) {
-			klog.V(5).InfoS(common.NodeInsufficientDevice, "pod", klog.KObj(pod), "request devices nums", k.Nums, "node device nums", len(node.Devices.DeviceLists))
-			return false, common.NodeInsufficientDevice
-		}
-		sort.Sort(node.Devices)
 		_, ok := device.GetDevices()[k.Type]
 		if !ok {
 			return false, "Device type not found"
 		}
-		fit, tmpDevs, reason := device.GetDevices()[k.Type].Fit(getNodeResources(*node, k.Type), k, pod, nodeInfo, devinput)
+		node.Devices.Sort()
+		candidates, oversized := withinMaxMemory(getNodeResources(*node, k.Type), k, maxMem)
+		fit, tmpDevs, reason := device.GetDevices()[k.Type].Fit(candidates, k, pod, nodeInfo, devinput)
+		if !fit && oversized > 0 {
+			klog.V(5).InfoS(common.AllocationExceedsMaxMemory, "pod", klog.KObj(pod), "devices", oversized, "max", maxMem)
+			oversizedReason := common.GenReason(map[string]int{common.AllocationExceedsMaxMemory: oversized}, oversized+len(candidates))
+			if reason == "" {
+				reason = oversizedReason
+			} else {
+				reason = oversizedReason + ", " + reason
+			}
+		}
 		if fit {
 			for idx, val := range tmpDevs[k.Type] {
 				for nidx, v := range node.Devices.DeviceLists {
@@ -101,22 +156,40 @@ func fitInDevices(node *NodeUsage, requests device.ContainerDeviceRequests, pod
 	return true, ""
 }
 
-func (s *Scheduler) calcScore(nodes *map[string]*NodeUsage, resourceReqs device.PodDeviceRequests, task *corev1.Pod, failedNodes map[string]string) (*policy.NodeScoreList, error) {
-	userNodePolicy := config.NodeSchedulerPolicy
-	if task.GetAnnotations() != nil {
-		if value, ok := task.GetAnnotations()[policy.NodeSchedulerPolicyAnnotationKey]; ok {
-			userNodePolicy = value
+func (s *Scheduler) calcScore(ctx context.Context, nodes *map[string]*NodeUsage, resourceReqs device.PodDeviceRequests, task *corev1.Pod, failedNodes map[string]string) (*policy.NodeScoreList, error) {
+	res, failureReason, err := s.scoreNodes(ctx, nodes, resourceReqs, task, failedNodes)
+	// only pod scheduler failure will record failure event
+	if len(res.NodeList) == 0 {
+		for reasonType, failureNodes := range failureReason {
+			sort.Strings(failureNodes)
+			reason := fmt.Errorf("%d nodes %s(%s)", len(failureNodes), reasonType, strings.Join(failureNodes, ","))
+			s.recordScheduleFilterResultEvent(task, EventReasonFilteringFailed, "", reason)
 		}
 	}
+	return res, err
+}
+
+// scoreNodes fits the task on the nodes, returning the fit nodes with their scores and the unfit nodes by reason.
+// Fitting updates the device usage of the nodes. Once ctx is done, the nodes fit so far are returned and the nodes
+// still being fit are unfit by the cause of ctx.
+func (s *Scheduler) scoreNodes(ctx context.Context, nodes *map[string]*NodeUsage, resourceReqs device.PodDeviceRequests, task *corev1.Pod, failedNodes map[string]string) (*policy.NodeScoreList, map[string][]string, error) {
+	userNodePolicy := nodeSchedulerPolicyOf(config.Current(), task)
 	res := policy.NodeScoreList{
 		Policy:   userNodePolicy,
 		NodeList: make([]*policy.NodeScore, 0),
 	}
+	tracer := newPodTracer(task)
+	tracer.Trace("calculating node scores", "nodes", len(*nodes), "nodePolicy", userNodePolicy, "requests", resourceReqs)
+	spread := replicaSpreadOf(task)
+	avoided := s.devicesAvoidedBy(task)
+	suggestion := suggestionOf(task)
 
 	wg := sync.WaitGroup{}
 	fitNodesMutex := sync.Mutex{}
 	failedNodesMutex := sync.Mutex{}
 	failureReason := make(map[string][]string)
+	// Set once ctx is done, the nodes still being fit then no longer update the result.
+	expired := false
 	errCh := make(chan error, len(*nodes))
 	for nodeID, node := range *nodes {
 		wg.Add(1)
@@ -127,6 +200,11 @@ func (s *Scheduler) calcScore(nodes *map[string]*NodeUsage, resourceReqs device.
 			score := policy.NodeScore{NodeID: nodeID, Node: node.Node, Devices: make(device.PodDevices), Score: 0}
 			score.ComputeDefaultScore(node.Devices)
 			snapshot := score.SnapshotDevice(node.Devices)
+			spread.markDevices(node, task)
+			for _, ds := range node.Devices.DeviceLists {
+				ds.Avoided = avoided.Has(ds.Device.StableID())
+				ds.Suggested = suggestion.suggests(nodeID, ds.Device.ID)
+			}
 
 			nodeInfo, err := s.GetNode(nodeID)
 			if err != nil {
@@ -139,6 +217,9 @@ func (s *Scheduler) calcScore(nodes *map[string]*NodeUsage, resourceReqs device.
 			ctrfit := false
 			deviceType := ""
 			for ctrid, n := range resourceReqs {
+				if ctx.Err() != nil {
+					return
+				}
 				sums := 0
 				for _, k := range n {
 					sums += int(k.Nums)
@@ -162,42 +243,76 @@ func (s *Scheduler) calcScore(nodes *map[string]*NodeUsage, resourceReqs device.
 					}
 				}
 				ctrfit = fit
+				tracer.Trace("container fit result", "node", nodeID, "container", ctrid, "requests", n, "fit", fit, "reason", reason)
 				if !fit {
 					klog.V(4).InfoS(common.NodeUnfitPod, "pod", klog.KObj(task), "node", nodeID, "reason", reason)
 					failedNodesMutex.Lock()
+					if expired {
+						failedNodesMutex.Unlock()
+						break
+					}
 					failedNodes[nodeID] = common.NodeUnfitPod
-					for reasonType := range common.ParseReason(reason) {
+					reasons := common.ParseReason(reason)
+					for reasonType := range reasons {
 						failureReason[reasonType] = append(failureReason[reasonType], nodeID)
 					}
+					// Reasons of the whole node, such as its device capacity, aren't counted per device.
+					if len(reasons) == 0 && reason != "" {
+						failureReason[reason] = append(failureReason[reason], nodeID)
+					}
 					failedNodesMutex.Unlock()
 					break
 				}
 			}
 
 			if ctrfit {
+				score.Replicas = spread.nodeReplicas(node, &score, task)
+				score.OverrideScore(snapshot, userNodePolicy)
 				fitNodesMutex.Lock()
-				res.NodeList = append(res.NodeList, &score)
+				if !expired {
+					res.NodeList = append(res.NodeList, &score)
+				}
 				fitNodesMutex.Unlock()
-				score.OverrideScore(snapshot, userNodePolicy)
 				klog.V(4).InfoS(common.NodeFitPod, "pod", klog.KObj(task), "node", nodeID, "score", score.Score)
+				tracer.Trace("node score", "node", nodeID, "score", score.Score, "devices", score.Devices)
 			}
 		}(nodeID, node)
 	}
-	wg.Wait()
-	close(errCh)
-
-	// only pod scheduler failure will record failure event
-	if len(res.NodeList) == 0 {
-		for reasonType, failureNodes := range failureReason {
-			sort.Strings(failureNodes)
-			reason := fmt.Errorf("%d nodes %s(%s)", len(failureNodes), reasonType, strings.Join(failureNodes, ","))
-			s.recordScheduleFilterResultEvent(task, EventReasonFilteringFailed, "", reason)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		cause := context.Cause(ctx).Error()
+		fitNodesMutex.Lock()
+		failedNodesMutex.Lock()
+		expired = true
+		fit := make(map[string]bool, len(res.NodeList))
+		for _, score := range res.NodeList {
+			fit[score.NodeID] = true
+		}
+		var pending []string
+		for nodeID := range *nodes {
+			if _, ok := failedNodes[nodeID]; !ok && !fit[nodeID] {
+				failedNodes[nodeID] = cause
+				failureReason[cause] = append(failureReason[cause], nodeID)
+				pending = append(pending, nodeID)
+			}
 		}
+		failedNodesMutex.Unlock()
+		fitNodesMutex.Unlock()
+		klog.InfoS("Returning the nodes fit before the deadline", "pod", klog.KObj(task), "fitNodes", len(res.NodeList), "pendingNodes", len(pending))
+		tracer.Trace("nodes still being fit at the deadline", "nodes", pending, "cause", cause)
 	}
 
 	var errorsSlice []error
-	for e := range errCh {
-		errorsSlice = append(errorsSlice, e)
+	for len(errCh) != 0 {
+		errorsSlice = append(errorsSlice, <-errCh)
 	}
-	return &res, utilerrors.NewAggregate(errorsSlice)
+	return &res, failureReason, utilerrors.NewAggregate(errorsSlice)
 }
@@ -17,6 +17,9 @@ limitations under the License.
 package scheduler
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -25,9 +28,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/amd"
 	"github.com/Project-HAMi/HAMi/pkg/device/common"
 	"github.com/Project-HAMi/HAMi/pkg/device/hygon"
 	"github.com/Project-HAMi/HAMi/pkg/device/kunlun"
@@ -36,6 +43,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
 	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
 )
 
 func TestMain(m *testing.M) {
@@ -2634,7 +2642,7 @@ func Test_calcScore(t *testing.T) {
 				s.addNode(nodeName, &device.NodeInfo{ID: nodeName, Node: nodeUsage.Node, Devices: devices})
 			}
 			failedNodes := map[string]string{}
-			got, gotErr := s.calcScore(test.args.nodes, test.args.nums, test.args.task, failedNodes)
+			got, gotErr := s.calcScore(context.Background(), test.args.nodes, test.args.nums, test.args.task, failedNodes)
 			assert.DeepEqual(t, test.wants.err, gotErr)
 			wantMap := make(map[string]*policy.NodeScore)
 			for index, node := range (*(test.wants.want)).NodeList {
@@ -3362,7 +3370,40 @@ func Test_fitInDevices(t *testing.T) {
 				devinput: &device.PodDevices{},
 			},
 			want1: false,
-			want2: "NodeInsufficientDevice",
+			want2: "requested 2 devices, node has 1",
+		},
+		{
+			name: "request memory cannot exceed the total memory of the devices on the node",
+			args: struct {
+				node     NodeUsage
+				requests device.ContainerDeviceRequests
+				annos    map[string]string
+				pod      *corev1.Pod
+				devinput *device.PodDevices
+			}{
+				node: NodeUsage{
+					Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+					Devices: policy.DeviceUsageList{
+						DeviceLists: []*policy.DeviceListsScore{
+							{Device: makeDevice("test-1", 0, nvidia.NvidiaGPUDevice, 0, 4, 8192, 0, 0, 100)},
+							{Device: makeDevice("test-2", 0, nvidia.NvidiaGPUDevice, 0, 4, 8192, 0, 0, 100)},
+						},
+					},
+				},
+				requests: device.ContainerDeviceRequests{
+					"test-1": {
+						Nums:     int32(2),
+						Type:     nvidia.NvidiaGPUDevice,
+						Memreq:   int32(9000),
+						Coresreq: int32(1),
+					},
+				},
+				annos:    map[string]string{},
+				pod:      &corev1.Pod{},
+				devinput: &device.PodDevices{},
+			},
+			want1: false,
+			want2: "requested 18000MiB device memory, node has 16384MiB",
 		},
 		{
 			name: "device type the different from request type",
@@ -3571,3 +3612,143 @@ func Test_Nvidia_GPU_Topology(t *testing.T) {
 		})
 	}
 }
+
+func newCapacityTestNode(gpus int) *NodeUsage {
+	node := &NodeUsage{Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}}
+	for i := range gpus {
+		node.Devices.DeviceLists = append(node.Devices.DeviceLists, &policy.DeviceListsScore{
+			Device: makeDevice(fmt.Sprintf("GPU-%d", i), 0, nvidia.NvidiaGPUDevice, 0, 10, 81920, 0, 0, 100),
+		})
+	}
+	return node
+}
+
+func Test_scoreNodesInsufficientDevices(t *testing.T) {
+	s := NewScheduler()
+	defer s.Stop()
+	for _, name := range []string{"node-1", "node-2"} {
+		devices := make([]device.DeviceInfo, 8)
+		for i := range devices {
+			devices[i] = device.DeviceInfo{
+				ID: fmt.Sprintf("%s-GPU-%d", name, i), Count: 10, Devmem: 81920, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+			}
+		}
+		s.addNode(name, &device.NodeInfo{
+			ID:      name,
+			Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices},
+		})
+	}
+	nodes := map[string]*NodeUsage{"node-1": newCapacityTestNode(8), "node-2": newCapacityTestNode(8)}
+	requests := device.PodDeviceRequests{{nvidia.NvidiaGPUDevice: {Nums: 16, Type: nvidia.NvidiaGPUDevice, Memreq: 1000, Coresreq: 10}}}
+
+	res, failureReason, err := s.scoreNodes(context.Background(), &nodes, requests, &corev1.Pod{}, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(res.NodeList), 0)
+	failureNodes := failureReason["requested 16 devices, node has 8"]
+	sort.Strings(failureNodes)
+	assert.DeepEqual(t, failureNodes, []string{"node-1", "node-2"})
+}
+
+// BenchmarkFitInDevicesInsufficientDevices compares rejecting a node with 8 GPUs for a 16 GPU request on its
+// registered capacity with fitting each of its devices, done every cycle before.
+func BenchmarkFitInDevicesInsufficientDevices(b *testing.B) {
+	node := newCapacityTestNode(8)
+	request := device.ContainerDeviceRequest{Nums: 16, Type: nvidia.NvidiaGPUDevice, Memreq: 1000, Coresreq: 10}
+	pod := &corev1.Pod{}
+
+	b.Run("device-fit", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			node.Devices.ComputeScore(device.ContainerDeviceRequests{request.Type: request})
+			node.Devices.Sort()
+			fit, _, _ := device.GetDevices()[request.Type].Fit(getNodeResources(*node, request.Type), request, pod, nil, &device.PodDevices{})
+			if fit {
+				b.Fatal("16 GPUs fit on 8")
+			}
+		}
+	})
+	b.Run("capacity-check", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			fit, _ := fitInDevices(node, device.ContainerDeviceRequests{request.Type: request}, pod, nil, &device.PodDevices{})
+			if fit {
+				b.Fatal("16 GPUs fit on 8")
+			}
+		}
+	})
+}
+
+// Test_Filter_SlicedVendors fits slices of the memory and cores of a GPU the same way for every vendor slicing
+// its GPUs.
+func Test_Filter_SlicedVendors(t *testing.T) {
+	initTestDevices(t, func(c *config.Config) {
+		c.NvidiaConfig = nvidia.NvidiaConfig{
+			ResourceCountName:  "nvidia.com/gpu",
+			ResourceMemoryName: "nvidia.com/gpumem",
+			ResourceCoreName:   "nvidia.com/gpucores",
+			DefaultGPUNum:      1,
+		}
+		c.AMDGPUConfig = amd.AMDConfig{
+			ResourceCountName:  "amd.com/gpu",
+			ResourceMemoryName: "amd.com/gpumem",
+			ResourceCoreName:   "amd.com/gpucores",
+		}
+	})
+
+	vendors := []struct {
+		vendor     string
+		deviceType string
+		prefix     string
+	}{
+		{vendor: nvidia.NvidiaGPUDevice, deviceType: "NVIDIA-A100", prefix: "nvidia.com"},
+		{vendor: amd.AMDCommonWord, deviceType: "AMDGPU-Instinct MI210", prefix: "amd.com"},
+	}
+	for _, v := range vendors {
+		t.Run(v.vendor, func(t *testing.T) {
+			s := NewScheduler()
+			defer s.Stop()
+			client.KubeClient = fake.NewSimpleClientset()
+			s.kubeClient = client.KubeClient
+			s.addNode("node1", &device.NodeInfo{
+				ID:   "node1",
+				Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+				Devices: map[string][]device.DeviceInfo{v.vendor: {
+					{ID: "GPU-0", Count: 10, Devmem: 65520, Devcore: 100, Type: v.deviceType, Health: true, DeviceVendor: v.vendor},
+				}},
+			})
+			nodeNames := []string{"node1"}
+			filter := func(name string, mem, cores int64) (*extenderv1.ExtenderFilterResult, *corev1.Pod) {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name)},
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{
+						Name: "train",
+						Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+							corev1.ResourceName(v.prefix + "/gpu"):      *resource.NewQuantity(1, resource.DecimalSI),
+							corev1.ResourceName(v.prefix + "/gpumem"):   *resource.NewQuantity(mem, resource.DecimalSI),
+							corev1.ResourceName(v.prefix + "/gpucores"): *resource.NewQuantity(cores, resource.DecimalSI),
+						}},
+					}}},
+				}
+				_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+				assert.NilError(t, err)
+				got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+				assert.NilError(t, err)
+				return got, pod
+			}
+
+			got, pod := filter("slice-a", 40000, 30)
+			assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+			info, ok := s.podManager.GetPod(pod)
+			assert.Assert(t, ok)
+			assert.Equal(t, info.Devices[v.vendor][0][0].Usedmem, int32(40000))
+			assert.Equal(t, info.Devices[v.vendor][0][0].Usedcores, int32(30))
+
+			// The memory left doesn't fit another slice as large, but a smaller one.
+			got, _ = filter("slice-b", 40000, 30)
+			assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0, "placed on %v", got.NodeNames)
+			got, _ = filter("slice-c", 20000, 30)
+			assert.DeepEqual(t, got.NodeNames, &[]string{"node1"})
+		})
+	}
+}
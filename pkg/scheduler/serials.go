@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// DeviceSerial maps the serial number of a device registered on a node to its current UUID.
+type DeviceSerial struct {
+	Node   string
+	Serial string
+	UUID   string
+}
+
+// DeviceSerials returns the devices registered with a serial number, sorted by node and serial number.
+func (s *Scheduler) DeviceSerials() []DeviceSerial {
+	nodes, err := s.ListNodes()
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes")
+		return nil
+	}
+	res := make([]DeviceSerial, 0)
+	for nodeID, node := range nodes {
+		for _, devices := range node.Devices {
+			for _, d := range devices {
+				if d.Serial != "" {
+					res = append(res, DeviceSerial{Node: nodeID, Serial: d.Serial, UUID: d.ID})
+				}
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Node != res[j].Node {
+			return res[i].Node < res[j].Node
+		}
+		return res[i].Serial < res[j].Serial
+	})
+	return res
+}
+
+// stableDeviceIDs returns the serial numbers of the devices of the node by their UUIDs, the UUIDs of the devices
+// not registered with one, so the devices are tracked across resets changing their UUID.
+func (s *Scheduler) stableDeviceIDs(nodeID string, uuids []string) []string {
+	serials := make(map[string]string)
+	if node, err := s.GetNode(nodeID); err == nil {
+		for _, devices := range node.Devices {
+			for _, d := range devices {
+				if d.Serial != "" {
+					serials[d.ID] = d.Serial
+				}
+			}
+		}
+	}
+	res := make([]string, 0, len(uuids))
+	for _, uuid := range uuids {
+		if serial, ok := serials[strings.Split(uuid, "[")[0]]; ok {
+			res = append(res, serial)
+		} else {
+			res = append(res, uuid)
+		}
+	}
+	return res
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func newSerialsTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	s := NewScheduler()
+	t.Cleanup(s.Stop)
+	s.addNode("node-2", &device.NodeInfo{
+		ID:   "node-2",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "GPU-b", Serial: "1320221034568", DeviceVendor: nvidia.NvidiaGPUDevice},
+			// Registered by a device plugin not reporting serial numbers.
+			{ID: "GPU-c", DeviceVendor: nvidia.NvidiaGPUDevice},
+		}},
+	})
+	s.addNode("node-1", &device.NodeInfo{
+		ID:   "node-1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "GPU-a", Serial: "1320221034567", DeviceVendor: nvidia.NvidiaGPUDevice},
+		}},
+	})
+	return s
+}
+
+func Test_DeviceSerials(t *testing.T) {
+	s := newSerialsTestScheduler(t)
+	assert.DeepEqual(t, s.DeviceSerials(), []DeviceSerial{
+		{Node: "node-1", Serial: "1320221034567", UUID: "GPU-a"},
+		{Node: "node-2", Serial: "1320221034568", UUID: "GPU-b"},
+	})
+}
+
+func Test_stableDeviceIDs(t *testing.T) {
+	s := newSerialsTestScheduler(t)
+	assert.DeepEqual(t, s.stableDeviceIDs("node-2", []string{"GPU-b", "GPU-c", "GPU-b[1g.10gb-0]"}),
+		[]string{"1320221034568", "GPU-c", "1320221034568"})
+	// The devices of another node are not mapped.
+	assert.DeepEqual(t, s.stableDeviceIDs("node-1", []string{"GPU-b"}), []string{"GPU-b"})
+	assert.DeepEqual(t, s.stableDeviceIDs("node-3", []string{"GPU-a"}), []string{"GPU-a"})
+}
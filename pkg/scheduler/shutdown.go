@@ -0,0 +1,192 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+var (
+	// ErrShuttingDown is returned for requests received once the scheduler started draining.
+	ErrShuttingDown = errors.New("scheduler is shutting down")
+	// errBindRolledBack is returned by binds rolled back because they didn't complete within the shutdown grace.
+	errBindRolledBack = errors.New("bind rolled back on scheduler shutdown")
+)
+
+type inflightBinds struct {
+	mutex    sync.Mutex
+	draining bool
+	binds    map[*inflightBind]struct{}
+	// idle is closed once no bind is in flight while draining.
+	idle chan struct{}
+}
+
+// inflightBind is the state a bind changed so far, to roll it back if it can't complete before shutdown.
+type inflightBind struct {
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+	pod    *corev1.Pod
+	node   *corev1.Node
+	// patched are the pod annotations patched by the bind.
+	patched map[string]string
+	// committed is set once the pod is being bound, the API server may bind it whatever the outcome of the call.
+	committed  bool
+	rolledBack bool
+}
+
+// ShuttingDown reports whether the scheduler stopped accepting filter and bind requests.
+func (s *Scheduler) ShuttingDown() bool {
+	s.inflight.mutex.Lock()
+	defer s.inflight.mutex.Unlock()
+	return s.inflight.draining
+}
+
+// beginBind tracks a new bind until endBind, its context is cancelled if it's rolled back or past the extender
+// deadline. It fails once the scheduler is draining.
+func (s *Scheduler) beginBind() (*inflightBind, context.Context, error) {
+	s.inflight.mutex.Lock()
+	defer s.inflight.mutex.Unlock()
+	if s.inflight.draining {
+		return nil, nil, ErrShuttingDown
+	}
+	ctx, cancel := withExtenderDeadline(context.Background())
+	b := &inflightBind{cancel: cancel}
+	if s.inflight.binds == nil {
+		s.inflight.binds = make(map[*inflightBind]struct{})
+	}
+	s.inflight.binds[b] = struct{}{}
+	return b, ctx, nil
+}
+
+func (s *Scheduler) endBind(b *inflightBind) {
+	b.cancel()
+	s.inflight.mutex.Lock()
+	defer s.inflight.mutex.Unlock()
+	delete(s.inflight.binds, b)
+	if s.inflight.draining && len(s.inflight.binds) == 0 && s.inflight.idle != nil {
+		close(s.inflight.idle)
+		s.inflight.idle = nil
+	}
+}
+
+// Drain stops accepting filter and bind requests and waits up to grace for the binds in flight to finish.
+// Binds still in flight after grace have their node locks released and pod annotations restored, unless they're
+// already binding the pod.
+func (s *Scheduler) Drain(grace time.Duration) {
+	s.inflight.mutex.Lock()
+	s.inflight.draining = true
+	idle := make(chan struct{})
+	if len(s.inflight.binds) == 0 {
+		close(idle)
+	} else {
+		s.inflight.idle = idle
+	}
+	klog.InfoS("Draining in-flight binds", "binds", len(s.inflight.binds), "grace", grace)
+	s.inflight.mutex.Unlock()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		klog.InfoS("Drained in-flight binds")
+		return
+	case <-timer.C:
+	}
+
+	s.inflight.mutex.Lock()
+	binds := make([]*inflightBind, 0, len(s.inflight.binds))
+	for b := range s.inflight.binds {
+		binds = append(binds, b)
+	}
+	s.inflight.mutex.Unlock()
+	for _, b := range binds {
+		s.rollbackBind(b)
+	}
+}
+
+func (b *inflightBind) setNode(pod *corev1.Pod, node *corev1.Node) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pod, b.node = pod, node
+}
+
+func (b *inflightBind) setPatched(annotations map[string]string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.patched = annotations
+}
+
+// commit marks the bind as binding the pod, it's never rolled back from then on as the pod may be bound even if
+// the call fails. It fails if the bind was rolled back in the meantime.
+func (b *inflightBind) commit() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.rolledBack {
+		return errBindRolledBack
+	}
+	b.committed = true
+	return nil
+}
+
+// rollbackBind releases the node locks taken by the bind and restores the pod annotations it patched.
+func (s *Scheduler) rollbackBind(b *inflightBind) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.committed || b.rolledBack {
+		return
+	}
+	b.rolledBack = true
+	b.cancel()
+	if b.pod == nil || b.node == nil {
+		return
+	}
+	klog.InfoS("Rolling back interrupted bind", "pod", klog.KObj(b.pod), "node", b.node.Name)
+	for _, val := range device.GetDevices() {
+		if err := val.ReleaseNodeLock(b.node, b.pod); err != nil {
+			klog.ErrorS(err, "Failed to release node lock", "pod", klog.KObj(b.pod), "node", b.node.Name, "device", val.CommonWord())
+		}
+	}
+	if len(b.patched) == 0 {
+		return
+	}
+	err := util.PatchAnnotationsWithRetry(context.Background(), s.kubeClient, util.PodRef(b.pod.Namespace, b.pod.Name), func(meta *metav1.ObjectMeta) error {
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, len(b.patched))
+		}
+		for k := range b.patched {
+			if v, ok := b.pod.Annotations[k]; ok {
+				meta.Annotations[k] = v
+			} else {
+				delete(meta.Annotations, k)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to restore pod annotations", "pod", klog.KObj(b.pod))
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+)
+
+// slowBindClient blocks binds until released or their context is cancelled, as if the API server were slow.
+type slowBindClient struct {
+	kubernetes.Interface
+	entered chan struct{}
+	release chan struct{}
+}
+
+type slowBindCoreV1 struct {
+	typedcorev1.CoreV1Interface
+	entered chan struct{}
+	release chan struct{}
+}
+
+type slowBindPods struct {
+	typedcorev1.PodInterface
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c slowBindClient) CoreV1() typedcorev1.CoreV1Interface {
+	return slowBindCoreV1{CoreV1Interface: c.Interface.CoreV1(), entered: c.entered, release: c.release}
+}
+
+func (c slowBindCoreV1) Pods(namespace string) typedcorev1.PodInterface {
+	return slowBindPods{PodInterface: c.CoreV1Interface.Pods(namespace), entered: c.entered, release: c.release}
+}
+
+func (p slowBindPods) Bind(ctx context.Context, _ *corev1.Binding, _ metav1.CreateOptions) error {
+	close(p.entered)
+	select {
+	case <-p.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestDrain(t *testing.T) {
+	initTestDevices(t)
+
+	s := NewScheduler()
+	defer s.Stop()
+	kubeClient := fake.NewSimpleClientset()
+	client.KubeClient = kubeClient
+	entered, release := make(chan struct{}), make(chan struct{})
+	s.kubeClient = slowBindClient{Interface: kubeClient, entered: entered, release: release}
+	s.addAllEventHandlers()
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	_, err := kubeClient.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+	assert.NilError(t, err)
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: node,
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+			ID: "GPU-0", Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+		}}},
+	})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "slow", Namespace: "default", UID: "slow-uid"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "train",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.BinarySI)},
+			},
+		}}},
+	}
+	_, err = kubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	assert.NilError(t, err)
+	_, err = s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &[]string{"node1"}})
+	assert.NilError(t, err)
+	filtered, err := kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+
+	// A bind interrupted before binding the pod is rolled back: its node lock is released and the pod annotations
+	// it patched are restored, and it fails rather than bind the pod.
+	interrupted, _, err := s.beginBind()
+	assert.NilError(t, err)
+	for _, val := range device.GetDevices() {
+		assert.NilError(t, val.LockNode(node, filtered))
+	}
+	patch := map[string]string{util.DeviceBindPhase: "allocating"}
+	interrupted.setNode(filtered, node)
+	interrupted.setPatched(patch)
+	assert.NilError(t, util.PatchPodAnnotations(filtered, patch))
+	s.rollbackBind(interrupted)
+	s.endBind(interrupted)
+	unlocked, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, ok := unlocked.Annotations[nodelock.NodeLockKey]
+	assert.Assert(t, !ok)
+	restored, err := kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, restored.Annotations, filtered.Annotations)
+	assert.Equal(t, interrupted.commit(), errBindRolledBack)
+
+	args := extenderv1.ExtenderBindingArgs{PodName: pod.Name, PodNamespace: pod.Namespace, PodUID: pod.UID, Node: "node1"}
+	results := make(chan *extenderv1.ExtenderBindingResult, 1)
+	go func() {
+		result, _ := s.Bind(args)
+		results <- result
+	}()
+	<-entered
+	locked, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Assert(t, locked.Annotations[nodelock.NodeLockKey] != "")
+	allocating, err := kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, allocating.Annotations[util.DeviceBindPhase], "allocating")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+	assert.NilError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	<-ctx.Done()
+	s.Drain(100 * time.Millisecond)
+
+	// The bind didn't complete within the grace, but it's binding the pod, which may be bound whatever the outcome
+	// of the call, so it isn't rolled back.
+	stillLocked, err := kubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, stillLocked.Annotations[nodelock.NodeLockKey], locked.Annotations[nodelock.NodeLockKey])
+	stillAllocating, err := kubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stillAllocating.Annotations, allocating.Annotations)
+
+	// New binds are rejected.
+	assert.Assert(t, s.ShuttingDown())
+	_, err = s.Bind(args)
+	assert.Equal(t, err, ErrShuttingDown)
+
+	// The bind in flight succeeds.
+	close(release)
+	result := <-results
+	assert.Equal(t, result.Error, "")
+
+	// Draining without binds in flight returns right away.
+	idle := NewScheduler()
+	defer idle.Stop()
+	start := time.Now()
+	idle.Drain(time.Minute)
+	assert.Assert(t, time.Since(start) < time.Minute)
+}
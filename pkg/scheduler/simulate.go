@@ -0,0 +1,100 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+)
+
+// SimulateRequest is the request of the simulate endpoint.
+type SimulateRequest struct {
+	Pod *corev1.Pod `json:"pod"`
+	// NodeNames are the candidate nodes, all the nodes with devices registered if empty.
+	NodeNames []string `json:"nodeNames,omitempty"`
+}
+
+// SimulateResponse is the response of the simulate endpoint.
+type SimulateResponse struct {
+	// Node is the node filter would select for the pod, empty if it fits none.
+	Node string `json:"node,omitempty"`
+	// GPUModel is the model of the GPU model fallback of the pod the nodes fit.
+	GPUModel string `json:"gpuModel,omitempty"`
+	// Nodes are the results of the candidate nodes sorted by name.
+	Nodes        []SimulatedNode `json:"nodes"`
+	ErrorMessage string          `json:"errorMessage,omitempty"`
+}
+
+// SimulatedNode is the result of fitting the pod to a node.
+type SimulatedNode struct {
+	Name  string  `json:"name"`
+	Fits  bool    `json:"fits"`
+	Score float32 `json:"score,omitempty"`
+	// Reason is why the pod doesn't fit the node.
+	Reason string `json:"reason,omitempty"`
+	// Devices are the devices the pod would be allocated on the node.
+	Devices device.PodDevices `json:"devices,omitempty"`
+}
+
+// Simulate fits the pod to the nodes with the filter logic against the current usage, without allocating, binding
+// or recording anything. Devices already allocated to the pod are counted like those of any other pod.
+func (s *Scheduler) Simulate(req SimulateRequest) SimulateResponse {
+	if req.Pod == nil {
+		return SimulateResponse{ErrorMessage: "simulate request has no pod"}
+	}
+	if !podRequestsDevices(req.Pod) {
+		return SimulateResponse{ErrorMessage: "pod does not request any device"}
+	}
+	nodeNames := req.NodeNames
+	if len(nodeNames) == 0 {
+		nodeNames = s.nodeIDs()
+	}
+	fit, err := s.fitPod(context.Background(), req.Pod, device.Resourcereqs(req.Pod), nodeNames, newPodTracer(req.Pod))
+	if err != nil {
+		klog.ErrorS(err, "Failed to simulate pod", "pod", klog.KObj(req.Pod))
+		return SimulateResponse{ErrorMessage: err.Error()}
+	}
+	res := SimulateResponse{Nodes: make([]SimulatedNode, 0, len(nodeNames))}
+	if len(fit.nodeScores.NodeList) != 0 {
+		res.GPUModel = fit.model
+		fit.nodeScores.Sort()
+		res.Node = fit.nodeScores.NodeList[len(fit.nodeScores.NodeList)-1].NodeID
+	}
+	fits := make(map[string]bool, len(fit.nodeScores.NodeList))
+	for _, score := range fit.nodeScores.NodeList {
+		fits[score.NodeID] = true
+		res.Nodes = append(res.Nodes, SimulatedNode{Name: score.NodeID, Fits: true, Score: score.Score, Devices: score.Devices})
+	}
+	for _, name := range nodeNames {
+		if fits[name] {
+			continue
+		}
+		reason, ok := fit.failedNodes[name]
+		if !ok {
+			reason = common.NodeUnfitPod
+		}
+		res.Nodes = append(res.Nodes, SimulatedNode{Name: name, Reason: reason})
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool { return res.Nodes[i].Name < res.Nodes[j].Name })
+	return res
+}
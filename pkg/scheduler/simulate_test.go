@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Simulate(t *testing.T) {
+	initTestDevices(t)
+
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	node2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "infer", Namespace: "default", UID: k8stypes.UID("infer")},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "infer",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+				"hami.io/gpu":      *resource.NewQuantity(1, resource.DecimalSI),
+				"hami.io/gpumem":   *resource.NewQuantity(4000, resource.DecimalSI),
+				"hami.io/gpucores": *resource.NewQuantity(30, resource.DecimalSI),
+			}},
+		}}},
+	}
+	client.KubeClient = fake.NewSimpleClientset(node1, node2, pod)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	for _, n := range []struct {
+		node *corev1.Node
+		mem  int32
+	}{{node1, 8000}, {node2, 2000}} {
+		s.addNode(n.node.Name, &device.NodeInfo{
+			ID:   n.node.Name,
+			Node: n.node,
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+				ID: "GPU-" + n.node.Name, Count: 10, Devmem: n.mem, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+			}}},
+		})
+	}
+
+	res := s.Simulate(SimulateRequest{Pod: pod})
+	assert.Equal(t, res.ErrorMessage, "")
+	assert.Equal(t, res.Node, "node1")
+	assert.Equal(t, len(res.Nodes), 2)
+	assert.Equal(t, res.Nodes[0].Name, "node1")
+	assert.Assert(t, res.Nodes[0].Fits)
+	dev := res.Nodes[0].Devices[nvidia.NvidiaGPUDevice][0][0]
+	assert.Equal(t, dev.UUID, "GPU-node1")
+	assert.Equal(t, dev.Usedmem, int32(4000))
+	assert.Equal(t, dev.Usedcores, int32(30))
+	assert.DeepEqual(t, res.Nodes[1], SimulatedNode{Name: "node2", Reason: common.NodeUnfitPod})
+
+	// Nothing is allocated to the pod, nor recorded on it.
+	_, ok := s.podManager.GetPod(pod)
+	assert.Assert(t, !ok)
+	got, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(got.Annotations), 0)
+
+	// Candidates are restricted to the nodes requested.
+	res = s.Simulate(SimulateRequest{Pod: pod, NodeNames: []string{"node2"}})
+	assert.Equal(t, res.Node, "")
+	assert.DeepEqual(t, res.Nodes, []SimulatedNode{{Name: "node2", Reason: common.NodeUnfitPod}})
+
+	res = s.Simulate(SimulateRequest{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cpu", Namespace: "default"}}})
+	assert.Equal(t, res.ErrorMessage, "pod does not request any device")
+}
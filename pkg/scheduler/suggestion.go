@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// placementSuggestion is the placement suggested for a pod by an external optimizer.
+type placementSuggestion struct {
+	node string
+	// Any device of the node if empty
+	uuids sets.Set[string]
+	mode  util.SuggestedPlacementMode
+}
+
+// suggestionOf returns the placement suggested for the pod, nil if none. Suggestions that can't be parsed are
+// ignored, the optimizer may annotate the pod after its admission.
+func suggestionOf(pod *corev1.Pod) *placementSuggestion {
+	placement, err := util.GetSuggestedPlacement(pod)
+	if err != nil {
+		klog.V(4).InfoS("Ignoring placement suggestion", "pod", klog.KObj(pod), "err", err)
+		return nil
+	}
+	if placement == nil {
+		return nil
+	}
+	mode, _ := util.GetSuggestedPlacementMode(pod, util.SuggestedPlacementMode(config.SuggestedPlacementMode))
+	return &placementSuggestion{node: placement.Node, uuids: sets.New(placement.UUIDs...), mode: mode}
+}
+
+// strict reports whether the pod is only placed as suggested.
+func (p *placementSuggestion) strict() bool {
+	return p != nil && p.mode == util.SuggestedPlacementStrict
+}
+
+// suggests reports whether the device of the node is suggested.
+func (p *placementSuggestion) suggests(nodeID string, uuid string) bool {
+	if p == nil || p.node != nodeID {
+		return false
+	}
+	return p.uuids.Len() == 0 || p.uuids.Has(strings.Split(uuid, "[")[0])
+}
+
+// honoredBy reports whether the devices fit on the node are all suggested.
+func (p *placementSuggestion) honoredBy(nodeID string, devices device.PodDevices) bool {
+	if p == nil || p.node != nodeID {
+		return false
+	}
+	for _, podSingle := range devices {
+		for _, ctrDevices := range podSingle {
+			for _, d := range ctrDevices {
+				if !p.suggests(nodeID, d.UUID) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// filterSuggestedNode returns the candidate nodes but, with a strict suggestion, the nodes other than the suggested
+// one, returned as failed.
+func (p *placementSuggestion) filterSuggestedNode(nodeNames []string) ([]string, map[string]string) {
+	if !p.strict() {
+		return nodeNames, nil
+	}
+	candidates := make([]string, 0, 1)
+	failed := make(map[string]string)
+	for _, nodeName := range nodeNames {
+		if nodeName == p.node {
+			candidates = append(candidates, nodeName)
+		} else {
+			failed[nodeName] = common.NodeNotSuggested
+		}
+	}
+	return candidates, failed
+}
+
+// applyToScores marks the nodes honoring the suggestion, selected first. With a strict suggestion, the nodes not
+// honoring it are removed from the scores and returned as failed.
+func (p *placementSuggestion) applyToScores(scores *policy.NodeScoreList, failedNodes map[string]string) {
+	if p == nil {
+		return
+	}
+	res := scores.NodeList[:0]
+	for _, score := range scores.NodeList {
+		score.Suggested = p.honoredBy(score.NodeID, score.Devices)
+		if !score.Suggested && p.strict() {
+			failedNodes[score.NodeID] = common.SuggestedDevicesUnfit
+			continue
+		}
+		res = append(res, score)
+	}
+	scores.NodeList = res
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func Test_Filter_SuggestedPlacement(t *testing.T) {
+	initTestDevices(t)
+	config.SuggestedPlacementMode = string(util.SuggestedPlacementPreferred)
+
+	s := NewScheduler()
+	defer s.Stop()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	for _, node := range []struct {
+		name string
+		gpus map[string]int32
+	}{
+		{name: "node-a", gpus: map[string]int32{"a0": 24000, "a1": 24000}},
+		{name: "node-b", gpus: map[string]int32{"b0": 24000, "b1": 16000}},
+	} {
+		gpus := make([]device.DeviceInfo, 0, len(node.gpus))
+		for id, mem := range node.gpus {
+			gpus = append(gpus, device.DeviceInfo{
+				ID: id, Count: 10, Devmem: mem, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+			})
+		}
+		s.addNode(node.name, &device.NodeInfo{
+			ID:      node.name,
+			Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: node.name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: gpus},
+		})
+	}
+	// Binpacking places pods on the used GPU of node-a without a suggestion.
+	s.podManager.AddPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "used", Namespace: "default", UID: "used"}},
+		"node-a", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: "a0", Type: nvidia.NvidiaGPUDevice, Usedmem: 2000}}}})
+
+	nodeNames := []string{"node-a", "node-b"}
+	tests := []struct {
+		name        string
+		mem         int64
+		annotations map[string]string
+		wantNode    string
+		wantDevice  string
+		wantFailed  map[string]string
+	}{
+		{name: "no suggestion", mem: 4000, wantNode: "node-a", wantDevice: "a0"},
+		{
+			name:        "suggestion honored",
+			mem:         4000,
+			annotations: map[string]string{util.SuggestedPlacementAnnotationKey: "node-b:b1"},
+			wantNode:    "node-b",
+			wantDevice:  "b1",
+		},
+		{
+			name:        "node suggested without devices",
+			mem:         20000,
+			annotations: map[string]string{util.SuggestedPlacementAnnotationKey: "node-b"},
+			wantNode:    "node-b",
+			wantDevice:  "b0",
+		},
+		{
+			name:        "infeasible suggestion falls back",
+			mem:         20000,
+			annotations: map[string]string{util.SuggestedPlacementAnnotationKey: "node-b:b1"},
+			wantNode:    "node-a",
+			wantDevice:  "a0",
+		},
+		{
+			name: "infeasible strict suggestion",
+			mem:  20000,
+			annotations: map[string]string{
+				util.SuggestedPlacementAnnotationKey:     "node-b:b1",
+				util.SuggestedPlacementModeAnnotationKey: string(util.SuggestedPlacementStrict),
+			},
+			wantFailed: map[string]string{"node-a": common.NodeNotSuggested, "node-b": common.SuggestedDevicesUnfit},
+		},
+		{
+			name:        "unparsable suggestion ignored",
+			mem:         4000,
+			annotations: map[string]string{util.SuggestedPlacementAnnotationKey: ":b1"},
+			wantNode:    "node-a",
+			wantDevice:  "a0",
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			annotations := map[string]string{
+				util.NodeSchedulerPolicyAnnotationKey: "binpack",
+				util.GPUSchedulerPolicyAnnotationKey:  "binpack",
+			}
+			for k, v := range test.annotations {
+				annotations[k] = v
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("suggested-%d", i), Namespace: "default", UID: types.UID(fmt.Sprintf("suggested-%d", i)),
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "gpu",
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+						"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+						"hami.io/gpumem": *resource.NewQuantity(test.mem, resource.BinarySI),
+					}},
+				}}},
+			}
+			_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			assert.NilError(t, err)
+
+			got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+			assert.NilError(t, err)
+			if test.wantNode == "" {
+				assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0, "placed on %v", got.NodeNames)
+				assert.DeepEqual(t, map[string]string(got.FailedNodes), test.wantFailed)
+				return
+			}
+			assert.DeepEqual(t, got.NodeNames, &[]string{test.wantNode})
+			info, ok := s.podManager.GetPod(pod)
+			assert.Assert(t, ok)
+			assert.Equal(t, info.Devices[nvidia.NvidiaGPUDevice][0][0].UUID, test.wantDevice)
+			s.podManager.DelPod(pod)
+			s.quotaManager.RmUsage(pod)
+			s.settleReservation(pod)
+		})
+	}
+}
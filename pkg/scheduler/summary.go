@@ -0,0 +1,291 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// DeviceAmount is an amount of devices, memory in MB and cores. Devices counts physical devices for totals,
+// and device shares for allocations and demand.
+type DeviceAmount struct {
+	Devices int64 `json:"devices"`
+	Memory  int64 `json:"memory"`
+	Cores   int64 `json:"cores"`
+}
+
+func (a *DeviceAmount) add(devices, memory, cores int64) {
+	a.Devices += devices
+	a.Memory += memory
+	a.Cores += cores
+}
+
+// VendorSummary is the total and allocated amount of the devices of a vendor.
+type VendorSummary struct {
+	Vendor    string       `json:"vendor"`
+	Total     DeviceAmount `json:"total"`
+	Allocated DeviceAmount `json:"allocated"`
+}
+
+// DeviceTypeSummary is the total and allocated amount of the devices of a device type.
+type DeviceTypeSummary struct {
+	Vendor    string       `json:"vendor"`
+	Type      string       `json:"type"`
+	Total     DeviceAmount `json:"total"`
+	Allocated DeviceAmount `json:"allocated"`
+}
+
+// NamespaceSummary is the amount of devices allocated to the pods of a namespace, and the demand of its
+// pending pods, per vendor.
+type NamespaceSummary struct {
+	Namespace   string                  `json:"namespace"`
+	Pods        int                     `json:"pods"`
+	Allocated   map[string]DeviceAmount `json:"allocated"`
+	PendingPods int                     `json:"pendingPods"`
+	UnmetDemand map[string]DeviceAmount `json:"unmetDemand"`
+}
+
+// ClusterSummary is the vGPU summary of the cluster served by the summary route. PendingPods and UnmetDemand
+// are those of pods requesting devices not scheduled yet, memory requested in percentage is not counted.
+type ClusterSummary struct {
+	Vendors     []VendorSummary         `json:"vendors"`
+	DeviceTypes []DeviceTypeSummary     `json:"deviceTypes"`
+	Namespaces  []NamespaceSummary      `json:"namespaces"`
+	PendingPods int                     `json:"pendingPods"`
+	UnmetDemand map[string]DeviceAmount `json:"unmetDemand"`
+}
+
+// clusterSummary keeps the summary computed on the last cache update, so serving it is cheap.
+type clusterSummary struct {
+	mutex   sync.RWMutex
+	summary *ClusterSummary
+	notify  chan struct{}
+}
+
+// notifySummary requests the summary to be recomputed, bursts of cache updates are coalesced.
+func (s *Scheduler) notifySummary() {
+	select {
+	case s.summary.notify <- struct{}{}:
+	default:
+	}
+}
+
+// summaryLoop recomputes the summary, and the device allocations exported, when notified until the scheduler stops.
+func (s *Scheduler) summaryLoop() {
+	for {
+		select {
+		case <-s.summary.notify:
+			s.refreshSummary()
+			s.syncDeviceAllocations(context.Background())
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Summary returns the summary of the cluster, the namespaces and pending pods restricted to namespace if not empty.
+func (s *Scheduler) Summary(namespace string) *ClusterSummary {
+	s.summary.mutex.RLock()
+	summary := s.summary.summary
+	s.summary.mutex.RUnlock()
+	if summary == nil {
+		summary = &ClusterSummary{
+			Vendors:     []VendorSummary{},
+			DeviceTypes: []DeviceTypeSummary{},
+			Namespaces:  []NamespaceSummary{},
+			UnmetDemand: map[string]DeviceAmount{},
+		}
+	}
+	if namespace == "" {
+		return summary
+	}
+	res := *summary
+	res.Namespaces = []NamespaceSummary{}
+	res.PendingPods = 0
+	res.UnmetDemand = map[string]DeviceAmount{}
+	for _, ns := range summary.Namespaces {
+		if ns.Namespace == namespace {
+			res.Namespaces = append(res.Namespaces, ns)
+			res.PendingPods = ns.PendingPods
+			res.UnmetDemand = ns.UnmetDemand
+		}
+	}
+	return &res
+}
+
+// refreshSummary computes the summary from the registered devices, the devices allocated to pods and the
+// requests of pending pods.
+func (s *Scheduler) refreshSummary() {
+	vendors := make(map[string]*VendorSummary)
+	types := make(map[string]*DeviceTypeSummary)
+	namespaces := make(map[string]*NamespaceSummary)
+	res := &ClusterSummary{UnmetDemand: make(map[string]DeviceAmount)}
+
+	vendorOf := func(vendor string) *VendorSummary {
+		v, ok := vendors[vendor]
+		if !ok {
+			v = &VendorSummary{Vendor: vendor}
+			vendors[vendor] = v
+		}
+		return v
+	}
+	namespaceOf := func(namespace string) *NamespaceSummary {
+		ns, ok := namespaces[namespace]
+		if !ok {
+			ns = &NamespaceSummary{
+				Namespace:   namespace,
+				Allocated:   make(map[string]DeviceAmount),
+				UnmetDemand: make(map[string]DeviceAmount),
+			}
+			namespaces[namespace] = ns
+		}
+		return ns
+	}
+
+	// Device types of devices by node and device ID, to break down allocations by type.
+	deviceTypes := make(map[string]map[string]*DeviceTypeSummary)
+	nodes, err := s.ListNodes()
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes for summary")
+	}
+	for nodeID, node := range nodes {
+		deviceTypes[nodeID] = make(map[string]*DeviceTypeSummary)
+		for vendor, devices := range node.Devices {
+			v := vendorOf(vendor)
+			for _, d := range devices {
+				key := vendor + "/" + d.Type
+				t, ok := types[key]
+				if !ok {
+					t = &DeviceTypeSummary{Vendor: vendor, Type: d.Type}
+					types[key] = t
+				}
+				v.Total.add(1, int64(d.Devmem), int64(d.Devcore))
+				t.Total.add(1, int64(d.Devmem), int64(d.Devcore))
+				deviceTypes[nodeID][d.ID] = t
+			}
+		}
+	}
+
+	for _, p := range s.podManager.ListPodsInfo() {
+		ns := namespaceOf(p.Namespace)
+		ns.Pods++
+		for vendor, podSingleDevices := range p.Devices {
+			v := vendorOf(vendor)
+			allocated := ns.Allocated[vendor]
+			for _, ctrDevices := range podSingleDevices {
+				for _, d := range ctrDevices {
+					v.Allocated.add(1, int64(d.Usedmem), int64(d.Usedcores))
+					allocated.add(1, int64(d.Usedmem), int64(d.Usedcores))
+					// MIG instances are allocated as <device ID>[<template>-<instance>].
+					if t, ok := deviceTypes[p.NodeID][strings.Split(d.UUID, "[")[0]]; ok {
+						t.Allocated.add(1, int64(d.Usedmem), int64(d.Usedcores))
+					}
+				}
+			}
+			ns.Allocated[vendor] = allocated
+		}
+	}
+
+	for _, pod := range s.pendingPods() {
+		demands := make(map[string]DeviceAmount)
+		for _, ctrRequests := range device.Resourcereqs(pod) {
+			for vendor, req := range ctrRequests {
+				demand := demands[vendor]
+				demand.add(int64(req.Nums), int64(req.Nums)*int64(req.Memreq), int64(req.Nums)*int64(req.Coresreq))
+				demands[vendor] = demand
+			}
+		}
+		if len(demands) == 0 {
+			continue
+		}
+		ns := namespaceOf(pod.Namespace)
+		ns.PendingPods++
+		res.PendingPods++
+		for vendor, demand := range demands {
+			for _, unmet := range []map[string]DeviceAmount{ns.UnmetDemand, res.UnmetDemand} {
+				total := unmet[vendor]
+				total.add(demand.Devices, demand.Memory, demand.Cores)
+				unmet[vendor] = total
+			}
+		}
+	}
+
+	res.Vendors = make([]VendorSummary, 0, len(vendors))
+	for _, v := range vendors {
+		res.Vendors = append(res.Vendors, *v)
+	}
+	sort.Slice(res.Vendors, func(i, j int) bool { return res.Vendors[i].Vendor < res.Vendors[j].Vendor })
+	res.DeviceTypes = make([]DeviceTypeSummary, 0, len(types))
+	for _, t := range types {
+		res.DeviceTypes = append(res.DeviceTypes, *t)
+	}
+	sort.Slice(res.DeviceTypes, func(i, j int) bool {
+		if res.DeviceTypes[i].Vendor != res.DeviceTypes[j].Vendor {
+			return res.DeviceTypes[i].Vendor < res.DeviceTypes[j].Vendor
+		}
+		return res.DeviceTypes[i].Type < res.DeviceTypes[j].Type
+	})
+	res.Namespaces = make([]NamespaceSummary, 0, len(namespaces))
+	for _, ns := range namespaces {
+		res.Namespaces = append(res.Namespaces, *ns)
+	}
+	sort.Slice(res.Namespaces, func(i, j int) bool { return res.Namespaces[i].Namespace < res.Namespaces[j].Namespace })
+
+	s.summary.mutex.Lock()
+	s.summary.summary = res
+	s.summary.mutex.Unlock()
+	klog.V(5).InfoS("Refreshed cluster summary", "vendors", len(res.Vendors), "namespaces", len(res.Namespaces), "pendingPods", res.PendingPods)
+}
+
+// pendingPods returns the pods of the scheduler neither assigned devices nor bound to a node yet.
+func (s *Scheduler) pendingPods() []*corev1.Pod {
+	if s.podLister == nil {
+		return nil
+	}
+	pods, err := s.podLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods for summary")
+		return nil
+	}
+	res := make([]*corev1.Pod, 0)
+	schedulerName := config.Current().SchedulerName
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" || util.IsPodInTerminatedState(pod) {
+			continue
+		}
+		if _, ok := pod.Annotations[util.AssignedNodeAnnotations]; ok {
+			continue
+		}
+		if pod.Spec.SchedulerName != schedulerName &&
+			(config.VolcanoSchedulerName == "" || pod.Spec.SchedulerName != config.VolcanoSchedulerName) {
+			continue
+		}
+		res = append(res, pod)
+	}
+	return res
+}
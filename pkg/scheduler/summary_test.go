@@ -0,0 +1,236 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/cambricon"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_refreshSummary(t *testing.T) {
+	initTestDevices(t, func(c *config.Config) {
+		c.CambriconConfig = cambricon.CambriconConfig{
+			ResourceCountName:  "cambricon.com/vmlu",
+			ResourceMemoryName: "cambricon.com/mlu.smlu.vmemory",
+			ResourceCoreName:   "cambricon.com/mlu.smlu.vcore",
+		}
+	})
+	config.SchedulerName = "hami-scheduler"
+
+	newPod := func(namespace, name, schedulerName string, limits corev1.ResourceList) runtime.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: k8stypes.UID(name)},
+			Spec: corev1.PodSpec{
+				SchedulerName: schedulerName,
+				Containers:    []corev1.Container{{Name: "ctr", Resources: corev1.ResourceRequirements{Limits: limits}}},
+			},
+		}
+	}
+	gpus := corev1.ResourceList{"hami.io/gpu": resource.MustParse("2"), "hami.io/gpumem": resource.MustParse("20000")}
+	bound := newPod("team-a", "bound", "hami-scheduler", gpus).(*corev1.Pod)
+	bound.Spec.NodeName = "node1"
+	kubeClient := fake.NewSimpleClientset(
+		newPod("team-a", "pending-gpu", "hami-scheduler", gpus),
+		newPod("team-b", "pending-mlu", "hami-scheduler", corev1.ResourceList{
+			"cambricon.com/vmlu":             resource.MustParse("1"),
+			"cambricon.com/mlu.smlu.vmemory": resource.MustParse("4"),
+		}),
+		// Neither pending pods requesting no devices, pods of other schedulers nor bound pods are counted.
+		newPod("team-c", "pending-cpu", "hami-scheduler", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}),
+		newPod("team-c", "other-scheduler", "default-scheduler", gpus),
+		bound,
+	)
+
+	s := NewScheduler()
+	s.kubeClient = kubeClient
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, time.Hour*1)
+	s.podLister = informerFactory.Core().V1().Pods().Lister()
+	informerFactory.Core().V1().Pods().Informer()
+	informerFactory.Start(s.stopCh)
+	informerFactory.WaitForCacheSync(s.stopCh)
+	defer s.Stop()
+
+	// An empty summary is served until the first cache update.
+	assert.DeepEqual(t, s.Summary(""), &ClusterSummary{
+		Vendors:     []VendorSummary{},
+		DeviceTypes: []DeviceTypeSummary{},
+		Namespaces:  []NamespaceSummary{},
+		UnmetDemand: map[string]DeviceAmount{},
+	})
+
+	newDevice := func(id, devType, vendor string, devmem int32) device.DeviceInfo {
+		return device.DeviceInfo{ID: id, Count: 10, Devmem: devmem, Devcore: 100, Type: devType, Health: true, DeviceVendor: vendor}
+	}
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {
+				newDevice("GPU-0", "NVIDIA A100", nvidia.NvidiaGPUDevice, 40000),
+				newDevice("GPU-1", "NVIDIA A100", nvidia.NvidiaGPUDevice, 40000),
+			},
+			cambricon.CambriconMLUDevice: {newDevice("MLU-0", "MLU370", cambricon.CambriconMLUDevice, 24000)},
+		},
+	})
+	s.addNode("node2", &device.NodeInfo{
+		ID:   "node2",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {newDevice("GPU-2", "NVIDIA T4", nvidia.NvidiaGPUDevice, 16000)},
+		},
+	})
+	assigned := func(namespace, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: k8stypes.UID(name)}}
+	}
+	s.podManager.AddPod(assigned("team-a", "train"), "node1", device.PodDevices{
+		nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+			{UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 10000, Usedcores: 30},
+			{UUID: "GPU-1[1g.10gb-0]", Type: nvidia.NvidiaGPUDevice, Usedmem: 10000, Usedcores: 0},
+		}},
+		cambricon.CambriconMLUDevice: device.PodSingleDevice{{
+			{UUID: "MLU-0", Type: cambricon.CambriconMLUDevice, Usedmem: 4096, Usedcores: 50},
+		}},
+	})
+	s.podManager.AddPod(assigned("team-b", "infer"), "node2", device.PodDevices{
+		nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+			{UUID: "GPU-2", Type: nvidia.NvidiaGPUDevice, Usedmem: 8000, Usedcores: 20},
+		}},
+	})
+
+	s.refreshSummary()
+
+	teamA := NamespaceSummary{
+		Namespace: "team-a",
+		Pods:      1,
+		Allocated: map[string]DeviceAmount{
+			nvidia.NvidiaGPUDevice:       {Devices: 2, Memory: 20000, Cores: 30},
+			cambricon.CambriconMLUDevice: {Devices: 1, Memory: 4096, Cores: 50},
+		},
+		PendingPods: 1,
+		UnmetDemand: map[string]DeviceAmount{nvidia.NvidiaGPUDevice: {Devices: 2, Memory: 40000}},
+	}
+	teamB := NamespaceSummary{
+		Namespace:   "team-b",
+		Pods:        1,
+		Allocated:   map[string]DeviceAmount{nvidia.NvidiaGPUDevice: {Devices: 1, Memory: 8000, Cores: 20}},
+		PendingPods: 1,
+		UnmetDemand: map[string]DeviceAmount{cambricon.CambriconMLUDevice: {Devices: 1, Memory: 1024, Cores: 100}},
+	}
+	vendors := []VendorSummary{
+		{
+			Vendor:    cambricon.CambriconMLUDevice,
+			Total:     DeviceAmount{Devices: 1, Memory: 24000, Cores: 100},
+			Allocated: DeviceAmount{Devices: 1, Memory: 4096, Cores: 50},
+		},
+		{
+			Vendor:    nvidia.NvidiaGPUDevice,
+			Total:     DeviceAmount{Devices: 3, Memory: 96000, Cores: 300},
+			Allocated: DeviceAmount{Devices: 3, Memory: 28000, Cores: 50},
+		},
+	}
+	deviceTypes := []DeviceTypeSummary{
+		{
+			Vendor:    cambricon.CambriconMLUDevice,
+			Type:      "MLU370",
+			Total:     DeviceAmount{Devices: 1, Memory: 24000, Cores: 100},
+			Allocated: DeviceAmount{Devices: 1, Memory: 4096, Cores: 50},
+		},
+		{
+			Vendor:    nvidia.NvidiaGPUDevice,
+			Type:      "NVIDIA A100",
+			Total:     DeviceAmount{Devices: 2, Memory: 80000, Cores: 200},
+			Allocated: DeviceAmount{Devices: 2, Memory: 20000, Cores: 30},
+		},
+		{
+			Vendor:    nvidia.NvidiaGPUDevice,
+			Type:      "NVIDIA T4",
+			Total:     DeviceAmount{Devices: 1, Memory: 16000, Cores: 100},
+			Allocated: DeviceAmount{Devices: 1, Memory: 8000, Cores: 20},
+		},
+	}
+	assert.DeepEqual(t, s.Summary(""), &ClusterSummary{
+		Vendors:     vendors,
+		DeviceTypes: deviceTypes,
+		Namespaces:  []NamespaceSummary{teamA, teamB},
+		PendingPods: 2,
+		UnmetDemand: map[string]DeviceAmount{
+			nvidia.NvidiaGPUDevice:       {Devices: 2, Memory: 40000},
+			cambricon.CambriconMLUDevice: {Devices: 1, Memory: 1024, Cores: 100},
+		},
+	})
+
+	// Filtering by namespace keeps the cluster wide totals.
+	assert.DeepEqual(t, s.Summary("team-b"), &ClusterSummary{
+		Vendors:     vendors,
+		DeviceTypes: deviceTypes,
+		Namespaces:  []NamespaceSummary{teamB},
+		PendingPods: 1,
+		UnmetDemand: teamB.UnmetDemand,
+	})
+	assert.DeepEqual(t, s.Summary("team-c"), &ClusterSummary{
+		Vendors:     vendors,
+		DeviceTypes: deviceTypes,
+		Namespaces:  []NamespaceSummary{},
+		UnmetDemand: map[string]DeviceAmount{},
+	})
+}
+
+func Test_summaryRefreshedOnPodEvents(t *testing.T) {
+	s := NewScheduler()
+	go s.summaryLoop()
+	defer s.Stop()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "train",
+		Namespace:   "team-a",
+		UID:         "train",
+		Annotations: map[string]string{util.AssignedNodeAnnotations: "node1"},
+	}}
+	s.onAddPod(pod)
+	assert.Assert(t, pollSummary(s, func(summary *ClusterSummary) bool {
+		return len(summary.Namespaces) == 1 && summary.Namespaces[0].Pods == 1
+	}))
+	s.onDelPod(pod)
+	assert.Assert(t, pollSummary(s, func(summary *ClusterSummary) bool {
+		return len(summary.Namespaces) == 0
+	}))
+}
+
+func pollSummary(s *Scheduler, cond func(*ClusterSummary) bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond(s.Summary("")) {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
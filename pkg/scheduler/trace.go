@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// maxPodTraceLines bounds the trace logs emitted for one scheduling attempt of a pod.
+const maxPodTraceLines = 256
+
+// podTracer emits filter/score trace logs for a pod annotated with util.DebugAnnotationKey,
+// without raising the log level of the whole scheduler. It is a no-op for other pods.
+type podTracer struct {
+	pod     *corev1.Pod
+	enabled bool
+
+	mutex sync.Mutex
+	lines int
+}
+
+func newPodTracer(pod *corev1.Pod) *podTracer {
+	return &podTracer{
+		pod:     pod,
+		enabled: util.IsPodDebugEnabled(pod),
+	}
+}
+
+func (t *podTracer) Enabled() bool {
+	return t != nil && t.enabled
+}
+
+// Trace logs msg with the given key/value pairs, it is safe to be called from the per node goroutines.
+func (t *podTracer) Trace(msg string, keysAndValues ...any) {
+	if !t.Enabled() {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.lines++
+	if t.lines > maxPodTraceLines {
+		if t.lines == maxPodTraceLines+1 {
+			klog.InfoS("[trace] trace logs truncated", "pod", klog.KObj(t.pod), "uid", t.pod.UID, "limit", maxPodTraceLines)
+		}
+		return
+	}
+	klog.InfoS("[trace] "+msg, append([]any{"pod", klog.KObj(t.pod), "uid", t.pod.UID}, keysAndValues...)...)
+}
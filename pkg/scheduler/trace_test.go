@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func TestPodTracerOnlyForAnnotatedPod(t *testing.T) {
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	klog.LogToStderr(false)
+	defer klog.LogToStderr(true)
+
+	traced := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "traced-pod",
+		Namespace:   "default",
+		Annotations: map[string]string{util.DebugAnnotationKey: "true"},
+	}}
+	disabled := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "disabled-pod",
+		Namespace:   "default",
+		Annotations: map[string]string{util.DebugAnnotationKey: "false"},
+	}}
+	plain := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "plain-pod",
+		Namespace: "default",
+	}}
+
+	s := NewScheduler()
+	for _, pod := range []*corev1.Pod{traced, disabled, plain} {
+		_, err := s.calcScore(context.Background(), &map[string]*NodeUsage{}, device.PodDeviceRequests{}, pod, map[string]string{})
+		assert.NilError(t, err)
+	}
+	klog.Flush()
+
+	var traceLines []string
+	for line := range strings.SplitSeq(buf.String(), "\n") {
+		if strings.Contains(line, "[trace]") {
+			traceLines = append(traceLines, line)
+		}
+	}
+	assert.Assert(t, len(traceLines) > 0, "expected trace logs for annotated pod")
+	for _, line := range traceLines {
+		assert.Assert(t, strings.Contains(line, "default/traced-pod"), "unexpected trace line %s", line)
+	}
+}
+
+func TestPodTracerBounded(t *testing.T) {
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	klog.LogToStderr(false)
+	defer klog.LogToStderr(true)
+
+	tracer := newPodTracer(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "traced-pod",
+		Namespace:   "default",
+		Annotations: map[string]string{util.DebugAnnotationKey: "true"},
+	}})
+	for range maxPodTraceLines * 2 {
+		tracer.Trace("step")
+	}
+	klog.Flush()
+	assert.Equal(t, maxPodTraceLines, strings.Count(buf.String(), "[trace] step"))
+	assert.Equal(t, 1, strings.Count(buf.String(), "trace logs truncated"))
+}
+
+func TestPodTracerNil(t *testing.T) {
+	var tracer *podTracer
+	assert.Equal(t, false, tracer.Enabled())
+	tracer.Trace("nothing")
+}
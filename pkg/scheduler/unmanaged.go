@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+const (
+	// NodeUnregistered is the reason of filtering out nodes without devices registered by HAMi.
+	NodeUnregistered = "node unregistered"
+	// NodeNotHAMiManaged is the reason of filtering out nodes advertising device resources without devices
+	// registered by HAMi, e.g. running the upstream device plugin, which doesn't isolate sliced devices.
+	NodeNotHAMiManaged = "node not HAMi-managed"
+)
+
+// isManagedNode returns whether devices of the node are registered by HAMi. The registration annotations of the
+// device plugins of HAMi are authoritative, nodes only advertising device resources are not managed.
+func (s *Scheduler) isManagedNode(nodeID string) bool {
+	_, err := s.GetNode(nodeID)
+	return err == nil
+}
+
+// unregisteredReason returns why the node without devices registered by HAMi is filtered out, NodeNotHAMiManaged
+// if it advertises the resources of any device.
+func (s *Scheduler) unregisteredReason(nodeID string) string {
+	if s.nodeLister == nil {
+		return NodeUnregistered
+	}
+	node, err := s.nodeLister.Get(nodeID)
+	if err != nil {
+		return NodeUnregistered
+	}
+	for _, dev := range device.GetDevices() {
+		qty, ok := node.Status.Allocatable[corev1.ResourceName(dev.GetResourceNames().ResourceCountName)]
+		if ok && !qty.IsZero() {
+			return NodeNotHAMiManaged
+		}
+	}
+	return NodeUnregistered
+}
+
+// wholeDeviceRequests returns whether the containers only request a count of whole devices, without memory or
+// cores, which the device plugin of nodes not managed by HAMi can allocate as well.
+func wholeDeviceRequests(reqs device.PodDeviceRequests) bool {
+	for _, ctrReqs := range reqs {
+		for _, req := range ctrReqs {
+			if req.Memreq != 0 || req.MemPercentagereq != 100 || req.Coresreq != 0 && req.Coresreq != 100 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// unmanagedWholeDeviceNodes returns the nodes not managed by HAMi among the failed nodes the pod may still be
+// placed on with config.AllowUnmanagedWholeDevices, if it only requests whole devices, sorted by name.
+func unmanagedWholeDeviceNodes(reqs device.PodDeviceRequests, failedNodes map[string]string) []string {
+	if !config.AllowUnmanagedWholeDevices || !wholeDeviceRequests(reqs) {
+		return nil
+	}
+	res := make([]string, 0)
+	for nodeID, reason := range failedNodes {
+		if reason == NodeNotHAMiManaged {
+			res = append(res, nodeID)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
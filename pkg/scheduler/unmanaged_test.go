@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+)
+
+func Test_Filter_MixedCluster(t *testing.T) {
+	initTestDevices(t)
+
+	// hami-node is registered by the HAMi device plugin, upstream-node only advertises the GPUs of the upstream
+	// device plugin and cpu-node has no GPU.
+	upstream := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "upstream-node"},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			"hami.io/gpu": *resource.NewQuantity(2, resource.DecimalSI),
+		}},
+	}
+	cpu := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cpu-node"}}
+	managed := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "hami-node"}}
+	client.KubeClient = fake.NewSimpleClientset(upstream, cpu, managed)
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = client.KubeClient
+	informerFactory := informers.NewSharedInformerFactory(client.KubeClient, 0)
+	s.nodeLister = informerFactory.Core().V1().Nodes().Lister()
+	informerFactory.Start(s.stopCh)
+	informerFactory.WaitForCacheSync(s.stopCh)
+	s.addNode("hami-node", &device.NodeInfo{
+		ID:   "hami-node",
+		Node: managed,
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+			ID: "GPU-0", Count: 10, Devmem: 8000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice,
+		}}},
+	})
+	nodeNames := []string{"cpu-node", "hami-node", "upstream-node"}
+
+	newPod := func(name string, limits corev1.ResourceList) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name)},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "train",
+				Resources: corev1.ResourceRequirements{Limits: limits},
+			}}},
+		}
+		_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		return pod
+	}
+	slicedLimits := corev1.ResourceList{
+		"hami.io/gpu":    *resource.NewQuantity(1, resource.DecimalSI),
+		"hami.io/gpumem": *resource.NewQuantity(2000, resource.DecimalSI),
+	}
+	wholeLimits := corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(1, resource.DecimalSI)}
+
+	// The GPU of hami-node is free, both pods go there.
+	for _, pod := range []*corev1.Pod{newPod("sliced-free", slicedLimits), newPod("whole-free", wholeLimits)} {
+		got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got.NodeNames, &[]string{"hami-node"})
+		s.podManager.DelPod(pod)
+		s.quotaManager.RmUsage(pod)
+		s.settleReservation(pod)
+	}
+	sliced := newPod("sliced", slicedLimits)
+	whole := newPod("whole", wholeLimits)
+
+	// Once it's full, the pods are never placed on the unmanaged node without the flag.
+	busy := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "busy", Namespace: "default", UID: "busy"}}
+	s.podManager.AddPod(busy, "hami-node", device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{
+		{UUID: "GPU-0", Type: nvidia.NvidiaGPUDevice, Usedmem: 7000, Usedcores: 50},
+	}}})
+	for _, pod := range []*corev1.Pod{sliced, whole} {
+		got, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &nodeNames})
+		assert.NilError(t, err)
+		assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0, "pod %s placed on %v", pod.Name, got.NodeNames)
+		assert.Equal(t, got.FailedNodes["upstream-node"], NodeNotHAMiManaged)
+		assert.Equal(t, got.FailedNodes["cpu-node"], NodeUnregistered)
+	}
+
+	// With the flag, whole devices may be requested from the unmanaged node, sliced ones still can't.
+	config.AllowUnmanagedWholeDevices = true
+	got, err := s.Filter(extenderv1.ExtenderArgs{Pod: sliced, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.Assert(t, got.NodeNames == nil || len(*got.NodeNames) == 0)
+	assert.Equal(t, got.FailedNodes["upstream-node"], NodeNotHAMiManaged)
+	got, err = s.Filter(extenderv1.ExtenderArgs{Pod: whole, NodeNames: &nodeNames})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.NodeNames, &[]string{"upstream-node"})
+	_, failed := got.FailedNodes["upstream-node"]
+	assert.Assert(t, !failed)
+	current, err := client.KubeClient.CoreV1().Pods("default").Get(context.Background(), "whole", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, assigned := current.Annotations[util.AssignedNodeAnnotations]
+	assert.Assert(t, !assigned)
+
+	// Binding to the unmanaged node takes no node lock, its device plugin would never release it.
+	_, err = s.Bind(extenderv1.ExtenderBindingArgs{PodName: "whole", PodNamespace: "default", PodUID: whole.UID, Node: "upstream-node"})
+	assert.NilError(t, err)
+	node, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), "upstream-node", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, locked := node.Annotations[nodelock.NodeLockKey]
+	assert.Assert(t, !locked)
+	current, err = client.KubeClient.CoreV1().Pods("default").Get(context.Background(), "whole", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, phase := current.Annotations[util.DeviceBindPhase]
+	assert.Assert(t, !phase)
+}
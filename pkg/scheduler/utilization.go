@@ -0,0 +1,198 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
+)
+
+const (
+	// utilizationMetric is the device utilization in percent exported by HAMi monitor.
+	utilizationMetric = "HostCoreUtilization"
+	// utilizationUUIDLabel is the label of the device UUID in utilization metrics.
+	utilizationUUIDLabel = "deviceuuid"
+)
+
+// UtilizationSource returns the measured utilization of devices in percent, by device UUID.
+type UtilizationSource func(ctx context.Context) (map[string]float64, error)
+
+// NewUtilizationSource returns a source running query against the Prometheus server at rawURL,
+// or reading the metrics endpoint of HAMi monitor at rawURL if query is empty.
+func NewUtilizationSource(client *http.Client, rawURL, query string) UtilizationSource {
+	if query == "" {
+		return monitorUtilization(client, rawURL)
+	}
+	return prometheusUtilization(client, rawURL, query)
+}
+
+func monitorUtilization(client *http.Client, rawURL string) UtilizationSource {
+	return func(ctx context.Context) (map[string]float64, error) {
+		resp, err := get(ctx, client, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metrics of %s: %v", rawURL, err)
+		}
+		res := make(map[string]float64)
+		family, ok := families[utilizationMetric]
+		if !ok {
+			return res, nil
+		}
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == utilizationUUIDLabel {
+					res[l.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+		return res, nil
+	}
+}
+
+// prometheusQueryResponse is the response of the instant query API of Prometheus.
+type prometheusQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			// Value is [unix time, "value"].
+			Value []any `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func prometheusUtilization(client *http.Client, rawURL, query string) UtilizationSource {
+	queryURL := prometheusQueryURL(rawURL, query)
+	return func(ctx context.Context) (map[string]float64, error) {
+		samples, err := prometheusVector(ctx, client, queryURL)
+		if err != nil {
+			return nil, err
+		}
+		res := make(map[string]float64)
+		for _, sample := range samples {
+			if uuid, ok := sample.Metric[utilizationUUIDLabel]; ok {
+				res[uuid] = sample.Value
+			}
+		}
+		return res, nil
+	}
+}
+
+// prometheusSample is a sample of the vector returned by a Prometheus query.
+type prometheusSample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+func prometheusQueryURL(rawURL, query string) string {
+	return strings.TrimSuffix(rawURL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+}
+
+// prometheusVector returns the samples of the vector returned by the instant query of queryURL, skipping the
+// samples which aren't numbers.
+func prometheusVector(ctx context.Context, client *http.Client, queryURL string) ([]prometheusSample, error) {
+	resp, err := get(ctx, client, queryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var body prometheusQueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode query response: %v", err)
+	}
+	if body.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s", body.Error)
+	}
+	if body.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("query returned %s, expected vector", body.Data.ResultType)
+	}
+	res := make([]prometheusSample, 0, len(body.Data.Result))
+	for _, r := range body.Data.Result {
+		if len(r.Value) != 2 {
+			continue
+		}
+		s, _ := r.Value[1].(string)
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		res = append(res, prometheusSample{Metric: r.Metric, Value: value})
+	}
+	return res, nil
+}
+
+func get(ctx context.Context, client *http.Client, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("get %s: unexpected status %s", rawURL, resp.Status)
+	}
+	return resp, nil
+}
+
+// ScrapeUtilization adds the utilization measured by source to store every interval until the
+// scheduler stops. Devices not measured for a while are scored by allocation only.
+func (s *Scheduler) ScrapeUtilization(store *policy.UtilizationStore, source UtilizationSource, interval time.Duration) {
+	klog.InfoS("Entering ScrapeUtilization", "interval", interval)
+	defer klog.InfoS("Exiting ScrapeUtilization")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		utilization, err := source(ctx)
+		cancel()
+		if err != nil {
+			klog.ErrorS(err, "Failed to scrape device utilization")
+		}
+		for uuid, value := range utilization {
+			store.Observe(uuid, value/100)
+		}
+		store.Prune()
+		klog.V(5).InfoS("Scraped device utilization", "devices", len(utilization))
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/policy"
+)
+
+const monitorMetrics = `# HELP HostCoreUtilization GPU core utilization
+# TYPE HostCoreUtilization gauge
+HostCoreUtilization{deviceidx="0",devicetype="NVIDIA-Tesla T4",deviceuuid="GPU-0"} 35
+HostCoreUtilization{deviceidx="1",devicetype="NVIDIA-Tesla T4",deviceuuid="GPU-1"} 80
+# HELP HostGPUMemoryUsage GPU device memory usage
+# TYPE HostGPUMemoryUsage gauge
+HostGPUMemoryUsage{deviceidx="0",devicetype="NVIDIA-Tesla T4",deviceuuid="GPU-0"} 1.2e+09
+`
+
+func Test_UtilizationSource(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metrics":
+			fmt.Fprint(w, monitorMetrics)
+		case "/api/v1/query":
+			gotQuery = r.URL.Query().Get("query")
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"deviceuuid":"GPU-0"},"value":[1700000000,"12.5"]},
+				{"metric":{"deviceuuid":"GPU-1"},"value":[1700000000,"NaN"]},
+				{"metric":{"instance":"node1"},"value":[1700000000,"50"]}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	got, err := NewUtilizationSource(server.Client(), server.URL+"/metrics", "")(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, map[string]float64{"GPU-0": 35, "GPU-1": 80})
+
+	query := "avg by (deviceuuid) (avg_over_time(HostCoreUtilization[5m]))"
+	got, err = NewUtilizationSource(server.Client(), server.URL, query)(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, gotQuery, query)
+	assert.DeepEqual(t, got, map[string]float64{"GPU-0": 12.5})
+
+	_, err = NewUtilizationSource(server.Client(), server.URL+"/missing", "")(context.Background())
+	assert.ErrorContains(t, err, "unexpected status")
+}
+
+func Test_ScrapeUtilization(t *testing.T) {
+	s := NewScheduler()
+	store := policy.NewUtilizationStore(time.Minute)
+	scraped := make(chan struct{})
+	calls := 0
+	source := func(context.Context) (map[string]float64, error) {
+		calls++
+		if calls > 1 {
+			close(scraped)
+			return nil, fmt.Errorf("monitor unavailable")
+		}
+		return map[string]float64{"GPU-0": 40}, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		s.ScrapeUtilization(store, source, time.Millisecond)
+		close(done)
+	}()
+	<-scraped
+	s.Stop()
+	<-done
+
+	// Failed scrapes keep the previous measurements until they are stale.
+	got, ok := store.Get("GPU-0")
+	assert.Assert(t, ok)
+	assert.Equal(t, got, 0.4)
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// Annotations used by the volcano vgpu device plugin, written besides the HAMi ones so
+// nodes still running the volcano device plugin can be migrated one by one.
+const (
+	VolcanoAssignedNodeAnnotations = "volcano.sh/vgpu-node"
+	VolcanoAssignedTimeAnnotations = "volcano.sh/vgpu-time"
+	VolcanoAssignedIDsAnnotations  = "volcano.sh/vgpu-ids-new"
+	VolcanoInRequestDevices        = "volcano.sh/devices-to-allocate"
+)
+
+// Resources requested by pods using the volcano vgpu device plugin.
+const (
+	VolcanoVGPUNumber = "volcano.sh/vgpu-number"
+	VolcanoVGPUMemory = "volcano.sh/vgpu-memory"
+	VolcanoVGPUCores  = "volcano.sh/vgpu-cores"
+)
+
+// VolcanoTask is the subset of volcano api.TaskInfo used by HAMi.
+type VolcanoTask struct {
+	UID       string
+	Job       string
+	Name      string
+	Namespace string
+	NodeName  string
+	Pod       *corev1.Pod
+}
+
+// VolcanoNode is the subset of volcano api.NodeInfo used by HAMi.
+type VolcanoNode struct {
+	Name string
+	Node *corev1.Node
+}
+
+// VolcanoPredicateRequest is the request of volcano extender predicate verb.
+type VolcanoPredicateRequest struct {
+	Task *VolcanoTask `json:"task"`
+	Node *VolcanoNode `json:"node"`
+}
+
+// VolcanoPredicateResponse is the response of volcano extender predicate verb.
+type VolcanoPredicateResponse struct {
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// VolcanoPrioritizeRequest is the request of volcano extender prioritize verb.
+type VolcanoPrioritizeRequest struct {
+	Task  *VolcanoTask   `json:"task"`
+	Nodes []*VolcanoNode `json:"nodes"`
+}
+
+// VolcanoPrioritizeResponse is the response of volcano extender prioritize verb.
+type VolcanoPrioritizeResponse struct {
+	NodeScore    map[string]float64 `json:"nodeScore"`
+	ErrorMessage string             `json:"errorMessage"`
+}
+
+// VolcanoEventRequest is the request of volcano allocate and deallocate events.
+type VolcanoEventRequest struct {
+	Task *VolcanoTask `json:"task"`
+}
+
+// VolcanoEventResponse is the response of volcano allocate and deallocate events.
+type VolcanoEventResponse struct {
+	ErrorMessage string `json:"errorMessage"`
+}
+
+func isVolcanoPod(pod *corev1.Pod) bool {
+	return len(config.VolcanoSchedulerName) > 0 && pod.Spec.SchedulerName == config.VolcanoSchedulerName
+}
+
+// volcanoDeviceIntent translates the volcano vgpu resources requested by the containers of pod to the HAMi
+// resource limits of NVIDIA GPUs, for DeviceIntentAnnotationKey. It returns nil if no container requests vgpus.
+func volcanoDeviceIntent(pod *corev1.Pod) []corev1.ResourceList {
+	dev, ok := device.GetDevices()[nvidia.NvidiaGPUDevice]
+	if !ok {
+		return nil
+	}
+	names := dev.GetResourceNames()
+	translation := map[corev1.ResourceName]string{
+		VolcanoVGPUNumber: names.ResourceCountName,
+		VolcanoVGPUMemory: names.ResourceMemoryName,
+		VolcanoVGPUCores:  names.ResourceCoreName,
+	}
+	var intent []corev1.ResourceList
+	for i, ctr := range pod.Spec.Containers {
+		if _, ok := ctr.Resources.Limits[VolcanoVGPUNumber]; !ok {
+			continue
+		}
+		if intent == nil {
+			intent = make([]corev1.ResourceList, len(pod.Spec.Containers))
+		}
+		intent[i] = make(corev1.ResourceList)
+		for from, to := range translation {
+			if v, ok := ctr.Resources.Limits[from]; ok && to != "" {
+				intent[i][corev1.ResourceName(to)] = v
+			}
+		}
+	}
+	return intent
+}
+
+func volcanoTaskPod(task *VolcanoTask) (*corev1.Pod, error) {
+	if task == nil || task.Pod == nil {
+		return nil, fmt.Errorf("volcano task has no pod")
+	}
+	return task.Pod, nil
+}
+
+// volcanoFit runs HAMi filter and score for the pod on the given nodes without changing any state.
+// Devices of other tasks in the same job allocated earlier in the session are already accounted,
+// since they are tracked by podManager after the allocate event.
+func (s *Scheduler) volcanoFit(pod *corev1.Pod, nodeNames []string) (map[string]string, []*nodeFit, error) {
+	resourceReqs := device.Resourcereqs(pod)
+	nodeUsage, failedNodes, err := s.getNodesUsage(&nodeNames, pod)
+	if err != nil {
+		return failedNodes, nil, err
+	}
+	// The task may be reallocated, don't count the devices it holds.
+	s.releasePodUsage(*nodeUsage, pod)
+	if util.IsShareGPUWithinPod(pod) {
+		resourceReqs = device.MergeContainerRequests(resourceReqs)
+	}
+	nodeScores, err := s.calcScore(context.Background(), nodeUsage, resourceReqs, pod, failedNodes)
+	if err != nil {
+		return failedNodes, nil, err
+	}
+	fits := make([]*nodeFit, 0, len(nodeScores.NodeList))
+	for _, score := range nodeScores.NodeList {
+		fits = append(fits, &nodeFit{nodeID: score.NodeID, score: score.Score, devices: score.Devices})
+	}
+	return failedNodes, fits, nil
+}
+
+type nodeFit struct {
+	nodeID  string
+	score   float32
+	devices device.PodDevices
+}
+
+// releasePodUsage removes the devices held by pod from the usage snapshot.
+func (s *Scheduler) releasePodUsage(usage map[string]*NodeUsage, pod *corev1.Pod) {
+	pi, ok := s.podManager.GetPod(pod)
+	if !ok {
+		return
+	}
+	node, ok := usage[pi.NodeID]
+	if !ok {
+		return
+	}
+	for _, podSingle := range pi.Devices {
+		for _, ctrdevs := range podSingle {
+			for _, udevice := range ctrdevs {
+				for _, d := range node.Devices.DeviceLists {
+					if d.Device.ID != udevice.UUID {
+						continue
+					}
+					d.Device.Used--
+					d.Device.Usedmem -= udevice.Usedmem
+					d.Device.Usedcores -= udevice.Usedcores
+				}
+			}
+		}
+	}
+}
+
+// VolcanoPredicate checks whether the task fits the node.
+func (s *Scheduler) VolcanoPredicate(req VolcanoPredicateRequest) VolcanoPredicateResponse {
+	pod, err := volcanoTaskPod(req.Task)
+	if err != nil {
+		return VolcanoPredicateResponse{ErrorMessage: err.Error()}
+	}
+	if req.Node == nil {
+		return VolcanoPredicateResponse{ErrorMessage: "volcano predicate request has no node"}
+	}
+	if !podRequestsDevices(pod) {
+		return VolcanoPredicateResponse{}
+	}
+	failedNodes, fits, err := s.volcanoFit(pod, []string{req.Node.Name})
+	if err != nil {
+		return VolcanoPredicateResponse{ErrorMessage: err.Error()}
+	}
+	if len(fits) == 0 {
+		reason := failedNodes[req.Node.Name]
+		if reason == "" {
+			reason = "node unfit pod"
+		}
+		klog.V(4).InfoS("Volcano predicate failed", "pod", klog.KObj(pod), "job", req.Task.Job, "node", req.Node.Name, "reason", reason)
+		return VolcanoPredicateResponse{ErrorMessage: reason}
+	}
+	return VolcanoPredicateResponse{}
+}
+
+// VolcanoPrioritize returns HAMi node scores of the task for every fit node.
+func (s *Scheduler) VolcanoPrioritize(req VolcanoPrioritizeRequest) VolcanoPrioritizeResponse {
+	pod, err := volcanoTaskPod(req.Task)
+	if err != nil {
+		return VolcanoPrioritizeResponse{ErrorMessage: err.Error()}
+	}
+	res := VolcanoPrioritizeResponse{NodeScore: make(map[string]float64, len(req.Nodes))}
+	if !podRequestsDevices(pod) || len(req.Nodes) == 0 {
+		return res
+	}
+	nodeNames := make([]string, 0, len(req.Nodes))
+	for _, n := range req.Nodes {
+		if n != nil {
+			nodeNames = append(nodeNames, n.Name)
+		}
+	}
+	_, fits, err := s.volcanoFit(pod, nodeNames)
+	if err != nil {
+		return VolcanoPrioritizeResponse{ErrorMessage: err.Error()}
+	}
+	for _, fit := range fits {
+		res.NodeScore[fit.nodeID] = float64(fit.score)
+	}
+	return res
+}
+
+// VolcanoAllocate records the devices of the task on the node volcano allocated it to and
+// writes the assignment annotations, so the following tasks of the same job see this usage.
+func (s *Scheduler) VolcanoAllocate(req VolcanoEventRequest) VolcanoEventResponse {
+	pod, err := volcanoTaskPod(req.Task)
+	if err != nil {
+		return VolcanoEventResponse{ErrorMessage: err.Error()}
+	}
+	if !podRequestsDevices(pod) {
+		return VolcanoEventResponse{}
+	}
+	if req.Task.NodeName == "" {
+		return VolcanoEventResponse{ErrorMessage: "volcano task is not allocated to any node"}
+	}
+	_, fits, err := s.volcanoFit(pod, []string{req.Task.NodeName})
+	if err != nil {
+		return VolcanoEventResponse{ErrorMessage: err.Error()}
+	}
+	if len(fits) == 0 {
+		return VolcanoEventResponse{ErrorMessage: fmt.Sprintf("task %s does not fit node %s", req.Task.Name, req.Task.NodeName)}
+	}
+	fit := fits[0]
+	annotations := volcanoAnnotations(pod, fit.nodeID, fit.devices)
+	s.podManager.AddPod(pod, fit.nodeID, fit.devices)
+	s.quotaManager.AddUsage(pod, fit.devices)
+	if err := util.PatchPodAnnotations(pod, annotations); err != nil {
+		s.quotaManager.RmUsage(pod)
+		s.podManager.DelPod(pod)
+		return VolcanoEventResponse{ErrorMessage: err.Error()}
+	}
+	klog.InfoS("Volcano task allocated", "pod", klog.KObj(pod), "job", req.Task.Job, "node", fit.nodeID, "devices", fit.devices)
+	return VolcanoEventResponse{}
+}
+
+// VolcanoDeallocate drops the devices recorded for the task, e.g. when its job failed gang scheduling.
+func (s *Scheduler) VolcanoDeallocate(req VolcanoEventRequest) VolcanoEventResponse {
+	pod, err := volcanoTaskPod(req.Task)
+	if err != nil {
+		return VolcanoEventResponse{ErrorMessage: err.Error()}
+	}
+	if pi, ok := s.podManager.GetPod(pod); ok {
+		s.quotaManager.RmUsage(pod)
+		s.podManager.DelPod(pod)
+		klog.InfoS("Volcano task deallocated", "pod", klog.KObj(pod), "job", req.Task.Job, "node", pi.NodeID)
+	}
+	return VolcanoEventResponse{}
+}
+
+func podRequestsDevices(pod *corev1.Pod) bool {
+	for _, ctr := range device.Resourcereqs(pod) {
+		for _, req := range ctr {
+			if req.Nums > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// volcanoAnnotations returns HAMi assignment annotations together with the ones of volcano vgpu device plugin.
+func volcanoAnnotations(pod *corev1.Pod, nodeID string, pd device.PodDevices) map[string]string {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	annotations := map[string]string{
+		util.AssignedNodeAnnotations:   nodeID,
+		util.AssignedTimeAnnotations:   now,
+		VolcanoAssignedNodeAnnotations: nodeID,
+		VolcanoAssignedTimeAnnotations: now,
+	}
+	if util.IsShareGPUWithinPod(pod) {
+		pd = device.ShareContainerDevices(device.Resourcereqs(pod), pd)
+	}
+	for _, val := range device.GetDevices() {
+		val.PatchAnnotations(pod, &annotations, pd)
+	}
+	if devlist, ok := pd[nvidia.NvidiaGPUDevice]; ok && len(devlist) > 0 {
+		deviceStr := device.EncodePodSingleDevice(devlist)
+		annotations[VolcanoAssignedIDsAnnotations] = deviceStr
+		annotations[VolcanoInRequestDevices] = deviceStr
+	}
+	return annotations
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func loadVolcanoRequest(t *testing.T, name string, req any) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "volcano", name))
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(data, req))
+}
+
+func newVolcanoTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	s := NewScheduler()
+	client.KubeClient = fake.NewSimpleClientset()
+	s.kubeClient = client.KubeClient
+	initTestDevices(t)
+
+	for _, n := range []struct {
+		name    string
+		devices []string
+	}{
+		{name: "node1", devices: []string{"device1", "device2"}},
+		{name: "node2", devices: []string{"device3"}},
+	} {
+		devices := make([]device.DeviceInfo, 0, len(n.devices))
+		for i, id := range n.devices {
+			devices = append(devices, device.DeviceInfo{
+				ID:           id,
+				Index:        uint(i),
+				Count:        10,
+				Devmem:       8000,
+				Devcore:      100,
+				Type:         nvidia.NvidiaGPUDevice,
+				Health:       true,
+				DeviceVendor: nvidia.NvidiaGPUDevice,
+			})
+		}
+		s.addNode(n.name, &device.NodeInfo{
+			ID:      n.name,
+			Node:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: n.name}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: devices},
+		})
+	}
+	// device3 on node2 is almost full.
+	s.podManager.AddPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "busy", Namespace: "default", UID: "busy-uid"}}, "node2", device.PodDevices{
+		nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{Idx: 0, UUID: "device3", Type: nvidia.NvidiaGPUDevice, Usedmem: 6000, Usedcores: 75}}},
+	})
+	return s
+}
+
+func TestVolcanoPredicate(t *testing.T) {
+	s := newVolcanoTestScheduler(t)
+
+	var req VolcanoPredicateRequest
+	loadVolcanoRequest(t, "predicate.json", &req)
+	resp := s.VolcanoPredicate(req)
+	assert.Assert(t, resp.ErrorMessage != "", "task requesting 4000 memory should not fit node2")
+
+	req.Node.Name = "node1"
+	resp = s.VolcanoPredicate(req)
+	assert.Equal(t, resp.ErrorMessage, "")
+
+	req.Task.Pod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{}
+	req.Node.Name = "node2"
+	resp = s.VolcanoPredicate(req)
+	assert.Equal(t, resp.ErrorMessage, "", "task without devices should fit any node")
+
+	resp = s.VolcanoPredicate(VolcanoPredicateRequest{Node: req.Node})
+	assert.Assert(t, resp.ErrorMessage != "")
+}
+
+func TestVolcanoPrioritize(t *testing.T) {
+	s := newVolcanoTestScheduler(t)
+
+	var req VolcanoPrioritizeRequest
+	loadVolcanoRequest(t, "prioritize.json", &req)
+	resp := s.VolcanoPrioritize(req)
+	assert.Equal(t, resp.ErrorMessage, "")
+	assert.Equal(t, len(resp.NodeScore), 1)
+	_, ok := resp.NodeScore["node1"]
+	assert.Assert(t, ok)
+
+	req.Task.Pod.Spec.Containers[0].Resources.Limits["hami.io/gpumem"] = *resource.NewQuantity(1000, resource.BinarySI)
+	req.Task.Pod.Spec.Containers[0].Resources.Limits["hami.io/gpucores"] = *resource.NewQuantity(10, resource.BinarySI)
+	resp = s.VolcanoPrioritize(req)
+	assert.Equal(t, resp.ErrorMessage, "")
+	assert.Equal(t, len(resp.NodeScore), 2)
+}
+
+func TestVolcanoAllocateDeallocate(t *testing.T) {
+	s := newVolcanoTestScheduler(t)
+
+	var req VolcanoEventRequest
+	loadVolcanoRequest(t, "allocate.json", &req)
+	pod := req.Task.Pod
+	_, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	assert.NilError(t, err)
+
+	resp := s.VolcanoAllocate(req)
+	assert.Equal(t, resp.ErrorMessage, "")
+	pi, ok := s.podManager.GetPod(pod)
+	assert.Assert(t, ok)
+	assert.Equal(t, pi.NodeID, "node1")
+
+	patched, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, patched.Annotations[util.AssignedNodeAnnotations], "node1")
+	assert.Equal(t, patched.Annotations[VolcanoAssignedNodeAnnotations], "node1")
+	assert.Equal(t, patched.Annotations[VolcanoAssignedIDsAnnotations], patched.Annotations[device.InRequestDevices[nvidia.NvidiaGPUDevice]])
+	assert.Assert(t, patched.Annotations[VolcanoAssignedIDsAnnotations] != "")
+
+	// The next task of the same job sees the devices held by this one.
+	sibling := req.Task.Pod.DeepCopy()
+	sibling.Name, sibling.UID = "vcjob-worker-1", "volcano-task-uid-1"
+	sibling.Spec.Containers[0].Resources.Limits["hami.io/gpumem"] = *resource.NewQuantity(8000, resource.BinarySI)
+	predicate := s.VolcanoPredicate(VolcanoPredicateRequest{
+		Task: &VolcanoTask{UID: string(sibling.UID), Job: req.Task.Job, Name: sibling.Name, Namespace: sibling.Namespace, Pod: sibling},
+		Node: &VolcanoNode{Name: "node1"},
+	})
+	assert.Equal(t, predicate.ErrorMessage, "")
+	sibling.Spec.Containers[0].Resources.Limits[corev1.ResourceName("hami.io/gpu")] = *resource.NewQuantity(2, resource.BinarySI)
+	predicate = s.VolcanoPredicate(VolcanoPredicateRequest{
+		Task: &VolcanoTask{UID: string(sibling.UID), Job: req.Task.Job, Name: sibling.Name, Namespace: sibling.Namespace, Pod: sibling},
+		Node: &VolcanoNode{Name: "node1"},
+	})
+	assert.Assert(t, predicate.ErrorMessage != "")
+
+	resp = s.VolcanoDeallocate(req)
+	assert.Equal(t, resp.ErrorMessage, "")
+	_, ok = s.podManager.GetPod(pod)
+	assert.Assert(t, !ok)
+}
+
+func TestVolcanoDeviceIntent(t *testing.T) {
+	restoreConfig(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	config.VolcanoSchedulerName = "volcano"
+	s := newVolcanoTestScheduler(t)
+	h := &webhook{decoder: admission.NewDecoder(clientgoscheme.Scheme)}
+	handle := func(pod *corev1.Pod) admission.Response {
+		raw, err := json.Marshal(pod)
+		assert.NilError(t, err)
+		return h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "req-uid",
+			Operation: admissionv1.Create,
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "vcjob-worker-0", Namespace: "default", UID: "volcano-task-uid"},
+		Spec: corev1.PodSpec{
+			SchedulerName: "volcano",
+			Containers: []corev1.Container{
+				{Name: "sidecar"},
+				{
+					Name: "worker",
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+						VolcanoVGPUNumber: resource.MustParse("1"),
+						VolcanoVGPUMemory: resource.MustParse("4000"),
+						VolcanoVGPUCores:  resource.MustParse("50"),
+					}},
+				},
+			},
+		},
+	}
+	resp := handle(pod)
+	assert.Assert(t, resp.Allowed, "%v", resp.Result)
+	// Only the intent is recorded, the scheduler and containers are kept for volcano and its device plugin.
+	var intent string
+	for _, patch := range resp.Patches {
+		assert.Equal(t, patch.Path, "/metadata/annotations", "unexpected patch %v", patch)
+		intent = patch.Value.(map[string]any)[util.DeviceIntentAnnotationKey].(string)
+	}
+	pod.Annotations = map[string]string{util.DeviceIntentAnnotationKey: intent}
+	assert.DeepEqual(t, device.Resourcereqs(pod), device.PodDeviceRequests{
+		{},
+		{nvidia.NvidiaGPUDevice: {Nums: 1, Type: nvidia.NvidiaGPUDevice, Memreq: 4000, MemPercentagereq: 101, Coresreq: 50}},
+	})
+
+	// HAMi fits the devices of the translated requests.
+	task := &VolcanoTask{UID: string(pod.UID), Job: "default/vcjob", Name: pod.Name, Namespace: pod.Namespace, Pod: pod}
+	resp2 := s.VolcanoPredicate(VolcanoPredicateRequest{Task: task, Node: &VolcanoNode{Name: "node2"}})
+	assert.Assert(t, resp2.ErrorMessage != "", "task requesting 4000 memory should not fit node2")
+	resp2 = s.VolcanoPredicate(VolcanoPredicateRequest{Task: task, Node: &VolcanoNode{Name: "node1"}})
+	assert.Equal(t, resp2.ErrorMessage, "")
+
+	// The intent of other pods is never trusted.
+	forged := pod.DeepCopy()
+	forged.Spec.SchedulerName = ""
+	resp = handle(forged)
+	assert.Assert(t, resp.Allowed, "%v", resp.Result)
+	removed := false
+	for _, patch := range resp.Patches {
+		removed = removed || patch.Operation == "remove" && patch.Path == "/metadata/annotations"
+	}
+	assert.Assert(t, removed, "expected the intent to be removed, got patches %v", resp.Patches)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// filterVolumeLocality applies the volume locality of the pod to the candidate nodes. With the strict locality,
+// the nodes the node-local volumes of the pod aren't accessible from are returned as failed, with the preferred
+// one the nodes they're accessible from are returned as preferred.
+func (s *Scheduler) filterVolumeLocality(pod *corev1.Pod, nodeNames []string) ([]string, map[string]string, map[string]bool, error) {
+	locality, _ := util.GetVolumeLocality(pod, util.VolumeLocality(config.VolumeLocality))
+	if locality == util.VolumeLocalityNone {
+		return nodeNames, nil, nil, nil
+	}
+	selectors, err := s.volumeNodeSelectors(pod)
+	if err != nil || len(selectors) == 0 {
+		return nodeNames, nil, nil, err
+	}
+	candidates := make([]string, 0, len(nodeNames))
+	failed := make(map[string]string)
+	preferred := make(map[string]bool)
+	for _, nodeName := range nodeNames {
+		local := false
+		// Unregistered nodes are failed when their usage is retrieved.
+		if node, err := s.GetNode(nodeName); err == nil && node.Node != nil {
+			local = nodeMatchesSelectors(node.Node, selectors)
+		}
+		switch {
+		case local:
+			candidates = append(candidates, nodeName)
+			preferred[nodeName] = true
+		case locality == util.VolumeLocalityStrict:
+			failed[nodeName] = common.NodeVolumeMismatch
+		default:
+			candidates = append(candidates, nodeName)
+		}
+	}
+	klog.V(4).InfoS("Applied volume locality", "pod", klog.KObj(pod), "locality", locality, "candidates", len(candidates), "localNodes", len(preferred))
+	return candidates, failed, preferred, nil
+}
+
+// volumeNodeSelectors returns the node affinity of the PVs bound to the PVCs of the pod that are only accessible
+// from some nodes, e.g. local PVs. PVCs not bound yet, e.g. waiting for the first consumer, are skipped.
+func (s *Scheduler) volumeNodeSelectors(pod *corev1.Pod) ([]*corev1.NodeSelector, error) {
+	if s.pvcLister == nil || s.pvLister == nil {
+		return nil, nil
+	}
+	res := make([]*corev1.NodeSelector, 0)
+	for _, vol := range pod.Spec.Volumes {
+		var claimName string
+		switch {
+		case vol.PersistentVolumeClaim != nil:
+			claimName = vol.PersistentVolumeClaim.ClaimName
+		case vol.Ephemeral != nil:
+			claimName = pod.Name + "-" + vol.Name
+		default:
+			continue
+		}
+		pvc, err := s.pvcLister.PersistentVolumeClaims(pod.Namespace).Get(claimName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pvc %s/%s: %v", pod.Namespace, claimName, err)
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv, err := s.pvLister.Get(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pv %s of pvc %s/%s: %v", pvc.Spec.VolumeName, pod.Namespace, claimName, err)
+		}
+		if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+			res = append(res, pv.Spec.NodeAffinity.Required)
+		}
+	}
+	return res, nil
+}
+
+// nodeMatchesSelectors reports whether the node matches all the selectors.
+func nodeMatchesSelectors(node *corev1.Node, selectors []*corev1.NodeSelector) bool {
+	for _, selector := range selectors {
+		if !nodeMatchesSelector(node, selector) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeMatchesSelector reports whether the node matches any term of the selector. Terms without requirements
+// match no node, and metadata.name is the only field supported.
+func nodeMatchesSelector(node *corev1.Node, selector *corev1.NodeSelector) bool {
+	for _, term := range selector.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		labelSelector, err := nodeSelectorRequirementsAsSelector(term.MatchExpressions)
+		if err != nil {
+			klog.V(4).InfoS("Ignoring invalid node selector term", "term", term, "err", err)
+			continue
+		}
+		fieldSelector, err := nodeSelectorRequirementsAsSelector(term.MatchFields)
+		if err != nil {
+			klog.V(4).InfoS("Ignoring invalid node selector term", "term", term, "err", err)
+			continue
+		}
+		if labelSelector.Matches(labels.Set(node.Labels)) && fieldSelector.Matches(labels.Set{"metadata.name": node.Name}) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeSelectorRequirementsAsSelector(requirements []corev1.NodeSelectorRequirement) (labels.Selector, error) {
+	selector := labels.NewSelector()
+	for _, req := range requirements {
+		var op selection.Operator
+		switch req.Operator {
+		case corev1.NodeSelectorOpIn:
+			op = selection.In
+		case corev1.NodeSelectorOpNotIn:
+			op = selection.NotIn
+		case corev1.NodeSelectorOpExists:
+			op = selection.Exists
+		case corev1.NodeSelectorOpDoesNotExist:
+			op = selection.DoesNotExist
+		case corev1.NodeSelectorOpGt:
+			op = selection.GreaterThan
+		case corev1.NodeSelectorOpLt:
+			op = selection.LessThan
+		default:
+			return nil, fmt.Errorf("%q is not a valid node selector operator", req.Operator)
+		}
+		r, err := labels.NewRequirement(req.Key, op, req.Values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*r)
+	}
+	return selector, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_filterVolumeLocality(t *testing.T) {
+	restoreConfig(t)
+	config.VolumeLocality = string(util.VolumeLocalityNone)
+
+	localPV := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-pv"},
+		Spec: corev1.PersistentVolumeSpec{NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key: "kubernetes.io/hostname", Operator: corev1.NodeSelectorOpIn, Values: []string{"node1"},
+			}}}},
+		}}},
+	}
+	boundPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "local-pv"},
+	}
+	unboundPVC := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"}}
+
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = fake.NewSimpleClientset(localPV, boundPVC, unboundPVC)
+	informerFactory := informers.NewSharedInformerFactory(s.kubeClient, 0)
+	s.pvcLister = informerFactory.Core().V1().PersistentVolumeClaims().Lister()
+	s.pvLister = informerFactory.Core().V1().PersistentVolumes().Lister()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informerFactory.Start(stopCh)
+	informerFactory.WaitForCacheSync(stopCh)
+
+	for _, name := range []string{"node1", "node2"} {
+		s.addNode(name, &device.NodeInfo{
+			ID: name,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"kubernetes.io/hostname": name},
+			}},
+			Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {{
+				ID: name + "-GPU", Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true,
+			}}},
+		})
+	}
+
+	newPod := func(claimName string, locality util.VolumeLocality) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: claimName,
+				}},
+			}}},
+		}
+		if locality != "" {
+			pod.Annotations = map[string]string{util.VolumeLocalityAnnotationKey: string(locality)}
+		}
+		return pod
+	}
+
+	tests := []struct {
+		name           string
+		pod            *corev1.Pod
+		wantCandidates []string
+		wantFailed     map[string]string
+		wantPreferred  map[string]bool
+	}{
+		{
+			name:           "no locality",
+			pod:            newPod("data", ""),
+			wantCandidates: []string{"node1", "node2"},
+		},
+		{
+			name:           "strict locality",
+			pod:            newPod("data", util.VolumeLocalityStrict),
+			wantCandidates: []string{"node1"},
+			wantFailed:     map[string]string{"node2": common.NodeVolumeMismatch},
+			wantPreferred:  map[string]bool{"node1": true},
+		},
+		{
+			name:           "preferred locality",
+			pod:            newPod("data", util.VolumeLocalityPreferred),
+			wantCandidates: []string{"node1", "node2"},
+			wantFailed:     map[string]string{},
+			wantPreferred:  map[string]bool{"node1": true},
+		},
+		{
+			name:           "unbound pvc",
+			pod:            newPod("pending", util.VolumeLocalityStrict),
+			wantCandidates: []string{"node1", "node2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidates, failed, preferred, err := s.filterVolumeLocality(test.pod, []string{"node1", "node2"})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, candidates, test.wantCandidates)
+			assert.DeepEqual(t, failed, test.wantFailed)
+			assert.DeepEqual(t, preferred, test.wantPreferred)
+		})
+	}
+
+	// Missing pvcs fail the filter.
+	_, _, _, err := s.filterVolumeLocality(newPod("missing", util.VolumeLocalityStrict), []string{"node1"})
+	assert.ErrorContains(t, err, "failed to get pvc default/missing")
+}
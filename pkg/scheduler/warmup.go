@@ -0,0 +1,163 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// EventReasonInconsistentAllocation indicates the devices allocated to a running pod don't match its node or requests.
+const EventReasonInconsistentAllocation = "InconsistentAllocation"
+
+// warmUpResult counts the running pods replayed by the warm-up.
+type warmUpResult struct {
+	// Pods whose allocation annotations were replayed
+	Annotated int
+	// Pods without allocation annotations allocated devices from their requests
+	FromRequests int
+	// Pods flagged inconsistent
+	Inconsistent int
+}
+
+// warmUp replays the devices allocated to the running pods once nodes are registered. The allocation annotations
+// are authoritative, the pods having them are replayed by the pod informer and only verified here. Pods bound
+// without them, e.g. created before HAMi was installed, are allocated devices of their node from their requests
+// so their usage is counted. Pods whose allocation doesn't match their node or requests, or whose requests don't
+// fit their node, are flagged by a warning and an event.
+func (s *Scheduler) warmUp() warmUpResult {
+	res := warmUpResult{}
+	pods, err := s.podLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods to warm up the device allocation")
+		return res
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || util.IsPodInTerminatedState(pod) || !podRequestsDevices(pod) {
+			continue
+		}
+		nodeID, ok := pod.Annotations[util.AssignedNodeAnnotations]
+		if ok {
+			res.Annotated++
+			if msg := verifyAllocation(pod, nodeID); msg != "" {
+				s.flagInconsistentAllocation(pod, msg)
+				res.Inconsistent++
+			}
+			continue
+		}
+		if _, ok := s.podManager.GetPod(pod); ok {
+			continue
+		}
+		if _, err := s.GetNode(pod.Spec.NodeName); err != nil {
+			klog.V(4).InfoS("Ignoring pod without allocation annotations bound to a node without devices registered", "pod", klog.KObj(pod), "node", pod.Spec.NodeName)
+			continue
+		}
+		if msg := s.allocateFromRequests(pod); msg != "" {
+			s.flagInconsistentAllocation(pod, msg)
+			res.Inconsistent++
+			continue
+		}
+		res.FromRequests++
+	}
+	klog.InfoS("Warmed up the device allocation from running pods", "annotated", res.Annotated, "fromRequests", res.FromRequests, "inconsistent", res.Inconsistent)
+	return res
+}
+
+// verifyAllocation returns why the allocation annotations of the pod don't match its node or requests, empty if
+// they do.
+func verifyAllocation(pod *corev1.Pod, nodeID string) string {
+	if nodeID != pod.Spec.NodeName {
+		return fmt.Sprintf("pod is allocated devices of node %s but bound to node %s", nodeID, pod.Spec.NodeName)
+	}
+	podDev, err := device.DecodePodDevices(device.SupportDevices, pod.Annotations)
+	if err != nil {
+		return fmt.Sprintf("allocation annotations can't be decoded: %v", err)
+	}
+	// Containers sharing the GPUs of the pod are allocated them once.
+	if util.IsShareGPUWithinPod(pod) {
+		return ""
+	}
+	requested := make(map[string]int)
+	for _, ctrReqs := range device.Resourcereqs(pod) {
+		for vendor, req := range ctrReqs {
+			requested[vendor] += int(req.Nums)
+		}
+	}
+	for _, vendor := range slices.Sorted(maps.Keys(device.SupportDevices)) {
+		allocated := 0
+		for _, ctrdevs := range podDev[vendor] {
+			allocated += len(ctrdevs)
+		}
+		if allocated != requested[vendor] {
+			return fmt.Sprintf("pod requests %d %s devices but is allocated %d", requested[vendor], vendor, allocated)
+		}
+	}
+	return ""
+}
+
+// allocateFromRequests allocates the pod devices of its node fitting its requests, and returns why it can't if it
+// doesn't fit, empty otherwise.
+func (s *Scheduler) allocateFromRequests(pod *corev1.Pod) string {
+	nodeID := pod.Spec.NodeName
+	klog.Warningf("Pod %s/%s bound to node %s has no allocation annotations, allocating devices from its requests", pod.Namespace, pod.Name, nodeID)
+	nodes := []string{nodeID}
+	usage, _, err := s.getNodesUsage(&nodes, pod)
+	if err != nil || (*usage)[nodeID] == nil {
+		return fmt.Sprintf("the device usage of node %s is unknown", nodeID)
+	}
+	resourceReqs := device.Resourcereqs(pod)
+	if util.IsShareGPUWithinPod(pod) {
+		resourceReqs = device.MergeContainerRequests(resourceReqs)
+	}
+	candidates := map[string]*NodeUsage{nodeID: (*usage)[nodeID]}
+	scores, failureReason, err := s.scoreNodes(context.Background(), &candidates, resourceReqs, pod, make(map[string]string))
+	if err != nil {
+		return fmt.Sprintf("failed to fit the requests on node %s: %v", nodeID, err)
+	}
+	if len(scores.NodeList) == 0 {
+		return fmt.Sprintf("the requests don't fit the devices left on node %s: %s", nodeID, unfitReason(nodeID, failureReason))
+	}
+	podDev := scores.NodeList[0].Devices
+	s.podManager.AddPod(pod, nodeID, podDev)
+	s.quotaManager.AddUsage(pod, podDev)
+	return ""
+}
+
+func (s *Scheduler) flagInconsistentAllocation(pod *corev1.Pod, msg string) {
+	klog.Warningf("Inconsistent device allocation of pod %s/%s on node %s: %s", pod.Namespace, pod.Name, pod.Spec.NodeName, msg)
+	if s.eventRecorder != nil {
+		s.eventRecorder.Event(pod, corev1.EventTypeWarning, EventReasonInconsistentAllocation, msg)
+	}
+}
+
+// forgetPodFromRequests removes the devices allocated by the warm-up to the pod without allocation annotations.
+func (s *Scheduler) forgetPodFromRequests(pod *corev1.Pod) {
+	if _, ok := s.podManager.GetPod(pod); !ok {
+		return
+	}
+	s.quotaManager.RmUsage(pod)
+	s.podManager.DelPod(pod)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func Test_warmUp(t *testing.T) {
+	initTestDevices(t)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	newPod := func(name, nodeName string, mem int64, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID(name), Annotations: annotations},
+			Spec: corev1.PodSpec{NodeName: nodeName, Containers: []corev1.Container{{
+				Name: "cuda",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":      *resource.NewQuantity(1, resource.DecimalSI),
+					"hami.io/gpumem":   *resource.NewQuantity(mem, resource.DecimalSI),
+					"hami.io/gpucores": *resource.NewQuantity(30, resource.DecimalSI),
+				}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	assigned := func(nodeID string) map[string]string {
+		return map[string]string{
+			util.AssignedNodeAnnotations:                  nodeID,
+			util.DeviceBindPhase:                          util.DeviceBindSuccess,
+			device.SupportDevices[nvidia.NvidiaGPUDevice]: "GPU-0,NVIDIA,4000,30:;",
+		}
+	}
+	annotated := newPod("annotated", "node1", 4000, assigned("node1"))
+	// Assigned another node than the one it's bound to
+	moved := newPod("moved", "node1", 4000, assigned("node2"))
+	// Created before HAMi allocated devices by annotations
+	legacy := newPod("legacy", "node1", 8000, nil)
+	oversized := newPod("oversized", "node1", 32000, nil)
+	pending := newPod("pending", "", 4000, nil)
+	cpu := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "cpu", Namespace: "default", UID: "cpu"},
+		Spec:       corev1.PodSpec{NodeName: "node1", Containers: []corev1.Container{{Name: "cpu"}}},
+	}
+
+	s := NewScheduler()
+	defer s.Stop()
+	s.kubeClient = fake.NewClientset(node)
+	s.addNode("node1", &device.NodeInfo{ID: "node1", Node: node, Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+		{ID: "GPU-0", Index: 0, Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+		{ID: "GPU-1", Index: 1, Count: 10, Devmem: 16000, Devcore: 100, Type: nvidia.NvidiaGPUDevice, Health: true, DeviceVendor: nvidia.NvidiaGPUDevice},
+	}}})
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range []any{annotated, moved, legacy, oversized, pending, cpu} {
+		assert.NilError(t, pods.Add(obj))
+	}
+	s.podLister = listerscorev1.NewPodLister(pods)
+	// The pod informer replays the allocation annotations.
+	s.onAddPod(annotated)
+
+	res := s.warmUp()
+	assert.DeepEqual(t, res, warmUpResult{Annotated: 2, FromRequests: 1, Inconsistent: 2})
+	pi, ok := s.podManager.GetPod(legacy)
+	assert.Assert(t, ok)
+	assert.Equal(t, pi.NodeID, "node1")
+	ctrdevs := pi.Devices[nvidia.NvidiaGPUDevice][0]
+	assert.Equal(t, len(ctrdevs), 1)
+	assert.Equal(t, ctrdevs[0].Usedmem, int32(8000))
+	assert.Equal(t, ctrdevs[0].Usedcores, int32(30))
+	for _, pod := range []*corev1.Pod{oversized, pending, cpu} {
+		_, ok := s.podManager.GetPod(pod)
+		assert.Assert(t, !ok, pod.Name)
+	}
+
+	// Pods already allocated aren't allocated again.
+	res = s.warmUp()
+	assert.Equal(t, res.FromRequests, 0)
+
+	// The devices allocated from requests are released with the pod.
+	s.onDelPod(legacy)
+	_, ok = s.podManager.GetPod(legacy)
+	assert.Assert(t, !ok)
+	_, ok = s.podManager.GetPod(annotated)
+	assert.Assert(t, ok)
+}
+
+func Test_verifyAllocation(t *testing.T) {
+	initTestDevices(t)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default"},
+		Spec: corev1.PodSpec{NodeName: "node1", Containers: []corev1.Container{{
+			Name:      "cuda",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": *resource.NewQuantity(2, resource.DecimalSI)}},
+		}}},
+	}
+	tests := []struct {
+		name    string
+		nodeID  string
+		devices string
+		want    string
+	}{
+		{name: "consistent", nodeID: "node1", devices: "GPU-0,NVIDIA,4000,30:GPU-1,NVIDIA,4000,30:;"},
+		{name: "other node", nodeID: "node2", devices: "GPU-0,NVIDIA,4000,30:GPU-1,NVIDIA,4000,30:;", want: "pod is allocated devices of node node2 but bound to node node1"},
+		{name: "fewer devices", nodeID: "node1", devices: "GPU-0,NVIDIA,4000,30:;", want: "pod requests 2 NVIDIA devices but is allocated 1"},
+		{name: "no devices", nodeID: "node1", devices: ";", want: "pod requests 2 NVIDIA devices but is allocated 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := pod.DeepCopy()
+			p.Annotations = map[string]string{device.SupportDevices[nvidia.NvidiaGPUDevice]: tt.devices}
+			assert.Equal(t, verifyAllocation(p, tt.nodeID), tt.want)
+		})
+	}
+}
@@ -19,87 +19,445 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 const template = "Processing admission hook for pod %v/%v, UID: %v"
 
+// nearLimitPercent is the percentage of a LimitRange maximum or a namespace budget past which the pods admitted
+// are warned they're close to it.
+const nearLimitPercent = 90
+
+// podAnnotationValidators validate the annotations pods requesting devices set to tune their scheduling and
+// isolation, pods setting an invalid one are denied.
+var podAnnotationValidators = []struct {
+	annotation string
+	validate   func(pod *corev1.Pod) error
+}{
+	{util.ComputeModeAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetComputeMode(pod)
+		return err
+	}},
+	{util.IsolationAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetIsolationLevel(pod)
+		return err
+	}},
+	{util.OOMModeAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetOOMModes(pod)
+		return err
+	}},
+	{util.GPUHealthPolicyAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetGPUHealthPolicy(pod)
+		return err
+	}},
+	{util.QoSAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetQoSClass(pod)
+		return err
+	}},
+	{util.GuaranteedMemoryAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetGuaranteedMemory(pod)
+		return err
+	}},
+	{util.GPUMemoryEachAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetGPUMemoryEach(pod)
+		return err
+	}},
+	{util.SharedGPUAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetSharedGPU(pod)
+		return err
+	}},
+	{util.MinDriverVersionAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetMinDriverVersion(pod)
+		return err
+	}},
+	{util.RequireECCAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetRequiredECCMode(pod)
+		return err
+	}},
+	{util.DeviceAffinityAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetDeviceAffinity(pod)
+		return err
+	}},
+	{util.DeviceAntiAffinityAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetDeviceAntiAffinity(pod)
+		return err
+	}},
+	{util.VolumeLocalityAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetVolumeLocality(pod, util.VolumeLocalityNone)
+		return err
+	}},
+	{util.ContainerRuntimeAffinityAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetContainerRuntimeAffinity(pod, util.ContainerRuntimeAffinityStrict)
+		return err
+	}},
+	{util.SuggestedPlacementModeAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetSuggestedPlacementMode(pod, util.SuggestedPlacementPreferred)
+		return err
+	}},
+	{util.ReplicaSpreadAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetReplicaSpread(pod)
+		return err
+	}},
+	{util.ContiguousIndicesAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetContiguousIndices(pod)
+		return err
+	}},
+	{util.ScheduleAfterAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetScheduleAfter(pod)
+		return err
+	}},
+	{util.ScheduleWindowAnnotationKey, func(pod *corev1.Pod) error {
+		_, err := util.GetScheduleWindow(pod)
+		return err
+	}},
+}
+
 type webhook struct {
-	decoder admission.Decoder
+	decoder   admission.Decoder
+	decisions *admissionDecisionLogger
+	// Picks the vendor of vendor agnostic device requests, they're denied if nil
+	accelerators AcceleratorPicker
+	// LimitRanges enforced on device resources, not enforced if nil
+	limitRanges listerscorev1.LimitRangeLister
+	// Devices allocated to namespaces, their budgets are not enforced if nil
+	allocations AllocationState
 }
 
-func NewWebHook() (*admission.Webhook, error) {
+func NewWebHook(accelerators AcceleratorPicker, limitRanges listerscorev1.LimitRangeLister, allocations AllocationState) (*admission.Webhook, error) {
 	logf.SetLogger(klog.NewKlogr())
 	schema := runtime.NewScheme()
 	if err := clientgoscheme.AddToScheme(schema); err != nil {
 		return nil, err
 	}
 	decoder := admission.NewDecoder(schema)
-	wh := &admission.Webhook{Handler: &webhook{decoder: decoder}}
+	decisions, err := newAdmissionDecisionLogger(config.AdmissionDecisionLog)
+	if err != nil {
+		return nil, err
+	}
+	wh := &admission.Webhook{Handler: &webhook{decoder: decoder, decisions: decisions, accelerators: accelerators, limitRanges: limitRanges, allocations: allocations}}
 	return wh, nil
 }
 
-func (h *webhook) Handle(_ context.Context, req admission.Request) admission.Response {
+func (h *webhook) Handle(ctx context.Context, req admission.Request) (resp admission.Response) {
 	pod := &corev1.Pod{}
+	var resources []string
+	defer func() {
+		namespace, name, _ := admissionPodRef(req, pod)
+		h.decisions.log(req, namespace, name, resources, resp)
+	}()
 	err := h.decoder.Decode(req, pod)
 	if err != nil {
 		klog.Errorf("Failed to decode request: %v", err)
 		return admission.Errored(http.StatusBadRequest, err)
 	}
+	namespace, name, uid := admissionPodRef(req, pod)
+	// The trace of the pod starts here, later stages attach to it by the annotation injected below.
+	ctx, span := tracing.StartPodSpan(ctx, nil, "webhook.Mutate", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", namespace),
+		attribute.String("k8s.pod.name", name),
+	))
+	defer func() {
+		var err error
+		if !resp.Allowed && resp.Result != nil {
+			err = errors.New(resp.Result.Message)
+		}
+		tracing.EndSpan(span, err)
+	}()
 	if len(pod.Spec.Containers) == 0 {
-		klog.Warningf(template+" - Denying admission as pod has no containers", pod.Namespace, pod.Name, pod.UID)
+		klog.Warningf(template+" - Denying admission as pod has no containers", namespace, name, uid)
 		return admission.Denied("pod has no containers")
 	}
-	if pod.Spec.SchedulerName != "" &&
-		pod.Spec.SchedulerName != corev1.DefaultSchedulerName || !config.ForceOverwriteDefaultScheduler &&
-		(len(config.SchedulerName) == 0 || pod.Spec.SchedulerName != config.SchedulerName) {
-		klog.Infof(template+" - Pod already has different scheduler assigned", req.Namespace, req.Name, req.UID)
+	// The configuration may be reloaded meanwhile, the pod is handled with the one of its arrival.
+	cfg := config.Current()
+	volcanoPod := isVolcanoPod(pod)
+	if !volcanoPod && (pod.Spec.SchedulerName != "" &&
+		pod.Spec.SchedulerName != corev1.DefaultSchedulerName || !cfg.ForceOverwriteDefaultScheduler &&
+		(len(cfg.SchedulerName) == 0 || pod.Spec.SchedulerName != cfg.SchedulerName)) {
+		klog.Infof(template+" - Pod already has different scheduler assigned", namespace, name, uid)
 		return admission.Allowed("pod already has different scheduler assigned")
 	}
-	klog.Infof(template, pod.Namespace, pod.Name, pod.UID)
+	if kind, excluded := ownerKindExcluded(pod, config.WebhookIncludeOwnerKinds, config.WebhookExcludeOwnerKinds); excluded {
+		klog.Infof(template+" - Pod owned by %s is excluded", namespace, name, uid, kind)
+		return admission.Allowed(fmt.Sprintf("pods owned by %s are excluded", kind))
+	}
+	klog.Infof(template, namespace, name, uid)
+	if util.IsPodDebugEnabled(pod) {
+		klog.InfoS("Pod requested scheduler debug tracing", "pod", klog.KRef(namespace, name), "uid", uid, "annotation", util.DebugAnnotationKey)
+	}
+	// Vendors mutate a copy of the pod, the mutations only apply if every vendor succeeds.
+	mutated := pod.DeepCopy()
+	// Warnings are only returned with the mutated pod, the mutations they explain don't apply to denied pods.
+	warnings := &device.AdmissionWarnings{}
 	hasResource := false
-	for idx, ctr := range pod.Spec.Containers {
-		c := &pod.Spec.Containers[idx]
+	// The device intent and overridden envs are only recorded here, they're never trusted from the submitted pod.
+	delete(mutated.Annotations, util.DeviceIntentAnnotationKey)
+	delete(mutated.Annotations, util.OverriddenEnvsAnnotationKey)
+	if volcanoPod {
+		// Volcano vgpu resources are left to the volcano device plugin, HAMi only records them to fit the devices.
+		if intent := volcanoDeviceIntent(mutated); intent != nil {
+			data, err := json.Marshal(intent)
+			if err != nil {
+				klog.Errorf(template+" - Failed to marshal device intent, error: %v", namespace, name, uid, err)
+				return admission.Errored(http.StatusInternalServerError, err)
+			}
+			if mutated.Annotations == nil {
+				mutated.Annotations = make(map[string]string)
+			}
+			mutated.Annotations[util.DeviceIntentAnnotationKey] = string(data)
+			resources = append(resources, VolcanoVGPUNumber)
+			hasResource = true
+			klog.Infof(template+" - Recorded device intent of volcano vgpu resources: %s", namespace, name, uid, data)
+		}
+	}
+	vendor, err := h.requestAccelerators(mutated)
+	if errors.Is(err, device.ErrInvalidRequest) {
+		klog.Warningf(template+" - Denying admission as vendor agnostic devices can't be requested: %v", namespace, name, uid, err)
+		return admission.Denied(err.Error())
+	}
+	if err != nil {
+		klog.Errorf(template+" - Failed to pick the vendor of vendor agnostic devices: %v", namespace, name, uid, err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if vendor != "" {
+		klog.Infof(template+" - Requesting %s devices for %s", namespace, name, uid, vendor, device.AcceleratorMemoryResource)
+		warnings.Addf("%s is replaced by the resources of %s devices", device.AcceleratorMemoryResource, vendor)
+	}
+	if applyDefaultGPURequest(mutated) {
+		klog.Infof(template+" - Requesting a default GPU for pod labeled %v", namespace, name, uid, config.DefaultGPURequestLabels)
+		warnings.Addf("container %s requests a GPU by default, as the pod is labeled %v", mutated.Spec.Containers[0].Name, config.DefaultGPURequestLabels)
+	}
+	limitRanges, err := h.namespaceLimitRanges(namespace)
+	if err != nil {
+		klog.Errorf(template+" - Failed to list LimitRanges: %v", namespace, name, uid, err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	// Defaults apply before the vendors default the device resources themselves.
+	applyLimitRangeDefaults(mutated, limitRanges, warnings)
+	// Environment variables owned by the device plugin removed from containers, by container
+	overridden := make(map[string][]string)
+	for idx, ctr := range mutated.Spec.Containers {
+		c := &mutated.Spec.Containers[idx]
 		if ctr.SecurityContext != nil {
 			if ctr.SecurityContext.Privileged != nil && *ctr.SecurityContext.Privileged {
-				klog.Warningf(template+" - Denying admission as container %s is privileged", pod.Namespace, pod.Name, pod.UID, c.Name)
+				klog.Warningf(template+" - Denying admission as container %s is privileged", namespace, name, uid, c.Name)
 				continue
 			}
 		}
+		ctrHasResource := false
+		ctrDevices := make([]device.Devices, 0)
 		for _, val := range device.GetDevices() {
-			found, err := val.MutateAdmission(c, pod)
+			found, err := val.MutateAdmission(c, mutated, warnings)
+			if errors.Is(err, device.ErrInvalidRequest) {
+				klog.Warningf(template+" - Denying admission as container %s requests invalid devices: %v", namespace, name, uid, c.Name, err)
+				return admission.Denied(err.Error())
+			}
 			if err != nil {
-				klog.Errorf("validating pod failed:%s", err.Error())
+				klog.Errorf(template+" - Failed to validate pod: %v", namespace, name, uid, err)
 				return admission.Errored(http.StatusInternalServerError, err)
 			}
-			hasResource = hasResource || found
+			if found && !slices.Contains(resources, val.GetResourceNames().ResourceCountName) {
+				resources = append(resources, val.GetResourceNames().ResourceCountName)
+			}
+			if found {
+				ctrDevices = append(ctrDevices, val)
+			}
+			ctrHasResource = ctrHasResource || found
+		}
+		if ctrHasResource && !cfg.ImageAllowed(c.Image) {
+			klog.Warningf(template+" - Denying admission as image %s of container %s is not allowlisted", namespace, name, uid, c.Image, c.Name)
+			return admission.Denied(fmt.Sprintf("image %q of container %s is not allowed to use devices", c.Image, c.Name))
+		}
+		removed, err := applyOwnedEnvPolicy(c, ctrDevices, util.OwnedEnvPolicy(config.OwnedEnvPolicy))
+		if err != nil {
+			klog.Warningf(template+" - Denying admission: %v", namespace, name, uid, err)
+			return admission.Denied(err.Error())
+		}
+		if len(removed) != 0 {
+			klog.Infof(template+" - Overriding %v of container %s owned by the device plugin", namespace, name, uid, removed, c.Name)
+			warnings.Addf("environment variables %s of container %s are removed, they're set by the device plugin", strings.Join(removed, ", "), c.Name)
+			overridden[c.Name] = removed
+		}
+		hasResource = hasResource || ctrHasResource
+	}
+	if hasResource {
+		// The annotations are only validated for pods requesting devices, the others never use them.
+		for _, v := range podAnnotationValidators {
+			if err := v.validate(pod); err != nil {
+				klog.Warningf(template+" - Denying admission as annotation %s is invalid: %v", namespace, name, uid, v.annotation, err)
+				return admission.Denied(err.Error())
+			}
+		}
+		if err := checkRuntimeClass(mutated); err != nil {
+			klog.Warningf(template+" - Denying admission: %v", namespace, name, uid, err)
+			return admission.Denied(err.Error())
 		}
 	}
+	if err := checkLimitRanges(mutated, limitRanges, warnings); err != nil {
+		klog.Warningf(template+" - Denying admission: %v", namespace, name, uid, err)
+		return admission.Denied(err.Error())
+	}
+	if err := checkMaxMemoryPerAllocation(cfg, namespace, mutated); err != nil {
+		klog.Warningf(template+" - Denying admission: %v", namespace, name, uid, err)
+		return admission.Denied(err.Error())
+	}
+	if err := checkNamespaceBudget(cfg, h.allocations, namespace, mutated, warnings); err != nil {
+		klog.Warningf(template+" - Denying admission: %v", namespace, name, uid, err)
+		return admission.Denied(err.Error())
+	}
+	if len(overridden) != 0 {
+		data, err := json.Marshal(overridden)
+		if err != nil {
+			klog.Errorf(template+" - Failed to marshal overridden envs, error: %v", namespace, name, uid, err)
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if mutated.Annotations == nil {
+			mutated.Annotations = make(map[string]string)
+		}
+		mutated.Annotations[util.OverriddenEnvsAnnotationKey] = string(data)
+	}
+	pod = mutated
 
 	if !hasResource {
-		klog.Infof(template+" - Allowing admission for pod: no resource found", pod.Namespace, pod.Name, pod.UID)
+		klog.Infof(template+" - Allowing admission for pod: no resource found", namespace, name, uid)
 		//return admission.Allowed("no resource found")
-	} else if len(config.SchedulerName) > 0 {
-		pod.Spec.SchedulerName = config.SchedulerName
+	} else if volcanoPod {
+		klog.Infof(template+" - Keeping volcano scheduler", namespace, name, uid)
+	} else if len(cfg.SchedulerName) > 0 {
+		pod.Spec.SchedulerName = cfg.SchedulerName
 		if pod.Spec.NodeName != "" {
-			klog.Infof(template+" - Pod already has node assigned", pod.Namespace, pod.Name, pod.UID)
+			klog.Infof(template+" - Pod already has node assigned", namespace, name, uid)
 			return admission.Denied("pod has node assigned")
 		}
 	}
+	if hasResource && config.SetRequestsToLimits {
+		setRequestsToLimits(pod)
+	}
+	if hasResource {
+		tracing.InjectPod(ctx, pod)
+	}
 	marshaledPod, err := json.Marshal(pod)
 	if err != nil {
-		klog.Errorf(template+" - Failed to marshal pod, error: %v", pod.Namespace, pod.Name, pod.UID, err)
+		klog.Errorf(template+" - Failed to marshal pod, error: %v", namespace, name, uid, err)
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
-	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod).WithWarnings(warnings.List()...)
+}
+
+// requestAccelerators replaces the vendor agnostic device requests of the containers of the pod by requests of
+// the vendor picked for them, and returns that vendor. Containers of a pod all request the same vendor, since the
+// pod runs on one node.
+func (h *webhook) requestAccelerators(pod *corev1.Pod) (string, error) {
+	count, mem := int64(0), int64(0)
+	requesting := make([]int, 0)
+	for idx := range pod.Spec.Containers {
+		c, m, ok, err := device.AcceleratorRequest(&pod.Spec.Containers[idx])
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			requesting = append(requesting, idx)
+			count, mem = max(count, c), max(mem, m)
+		}
+	}
+	if len(requesting) == 0 {
+		return "", nil
+	}
+	if h.accelerators == nil {
+		return "", device.InvalidRequestErrorf("%s is not supported", device.AcceleratorMemoryResource)
+	}
+	vendor, err := h.accelerators.PickAcceleratorVendor(device.AcceleratorVendors(config.AcceleratorVendors), count, mem)
+	if err != nil {
+		return "", err
+	}
+	dev := device.GetDevices()[vendor].(device.AcceleratorDevices)
+	for _, idx := range requesting {
+		ctr := &pod.Spec.Containers[idx]
+		c, m, _, _ := device.AcceleratorRequest(ctr)
+		device.RemoveAcceleratorRequest(ctr)
+		dev.RequestAccelerator(ctr, c, m)
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[util.AcceleratorVendorAnnotationKey] = vendor
+	return vendor, nil
+}
+
+// applyOwnedEnvPolicy applies the policy to the environment variables the container sets which are owned by the
+// device plugin of devs, and returns the ones it removed. It fails with device.ErrInvalidRequest if the policy
+// denies them.
+func applyOwnedEnvPolicy(ctr *corev1.Container, devs []device.Devices, policy util.OwnedEnvPolicy) ([]string, error) {
+	owned := device.ContainerOwnedEnvs(ctr, devs)
+	if len(owned) == 0 {
+		return nil, nil
+	}
+	switch policy {
+	case util.OwnedEnvPolicyDeny:
+		return nil, device.InvalidRequestErrorf("environment variables %s of container %s are set by the device plugin, remove them",
+			strings.Join(owned, ", "), ctr.Name)
+	case util.OwnedEnvPolicyOverride:
+		ctr.Env = slices.DeleteFunc(ctr.Env, func(env corev1.EnvVar) bool { return slices.Contains(owned, env.Name) })
+		return owned, nil
+	}
+	return nil, nil
+}
+
+// admissionPodRef returns the namespace, name and UID identifying the pod of req in logs. Pods created with
+// generateName are only named by the API server after admission, they're identified by their generateName
+// prefix and the UID of the admission request instead, which the API server logs as well.
+func admissionPodRef(req admission.Request, pod *corev1.Pod) (string, string, types.UID) {
+	namespace, name, uid := pod.Namespace, pod.Name, pod.UID
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+	if name == "" {
+		name = req.Name
+	}
+	if name == "" {
+		name = pod.GenerateName
+	}
+	if uid == "" {
+		uid = req.UID
+	}
+	return namespace, name, uid
+}
+
+// setRequestsToLimits sets the cpu and memory requests of every container of the pod to their limits, so a pod
+// with limits set on all its containers is of the Guaranteed QoS class and its cpu isn't throttled below them.
+func setRequestsToLimits(pod *corev1.Pod) {
+	for _, ctrs := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for idx := range ctrs {
+			c := &ctrs[idx]
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				limit, ok := c.Resources.Limits[name]
+				if !ok {
+					continue
+				}
+				if c.Resources.Requests == nil {
+					c.Resources.Requests = make(corev1.ResourceList)
+				}
+				c.Resources.Requests[name] = limit
+			}
+		}
+	}
 }
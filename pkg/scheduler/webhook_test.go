@@ -17,7 +17,13 @@ limitations under the License.
 package scheduler
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
 	"testing"
 
 	admissionv1 "k8s.io/api/admission/v1"
@@ -26,11 +32,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
 )
 
 func TestHandle(t *testing.T) {
@@ -79,7 +88,7 @@ func TestHandle(t *testing.T) {
 	}
 
 	// create a WebHook object
-	wh, err := NewWebHook()
+	wh, err := NewWebHook(nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Error creating WebHook: %v", err)
 	}
@@ -93,23 +102,9 @@ func TestHandle(t *testing.T) {
 }
 
 func TestPodHasNodeName(t *testing.T) {
+	initTestDevices(t)
 	config.SchedulerName = "hami-scheduler"
 	config.ForceOverwriteDefaultScheduler = true
-	sConfig := &config.Config{
-		NvidiaConfig: nvidia.NvidiaConfig{
-			ResourceCountName:            "hami.io/gpu",
-			ResourceMemoryName:           "hami.io/gpumem",
-			ResourceMemoryPercentageName: "hami.io/gpumem-percentage",
-			ResourceCoreName:             "hami.io/gpucores",
-			DefaultMemory:                0,
-			DefaultCores:                 0,
-			DefaultGPUNum:                1,
-		},
-	}
-
-	if err := config.InitDevicesWithConfig(sConfig); err != nil {
-		klog.Fatalf("Failed to initialize devices with config: %v", err)
-	}
 	// create a Pod object
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -156,7 +151,7 @@ func TestPodHasNodeName(t *testing.T) {
 	}
 
 	// create a WebHook object
-	wh, err := NewWebHook()
+	wh, err := NewWebHook(nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Error creating WebHook: %v", err)
 	}
@@ -170,24 +165,9 @@ func TestPodHasNodeName(t *testing.T) {
 }
 
 func TestPodHasDifferentScheduler(t *testing.T) {
+	initTestDevices(t)
 	config.SchedulerName = "hami-scheduler"
 
-	sConfig := &config.Config{
-		NvidiaConfig: nvidia.NvidiaConfig{
-			ResourceCountName:            "hami.io/gpu",
-			ResourceMemoryName:           "hami.io/gpumem",
-			ResourceMemoryPercentageName: "hami.io/gpumem-percentage",
-			ResourceCoreName:             "hami.io/gpucores",
-			DefaultMemory:                0,
-			DefaultCores:                 0,
-			DefaultGPUNum:                1,
-		},
-	}
-
-	if err := config.InitDevicesWithConfig(sConfig); err != nil {
-		klog.Fatalf("Failed to initialize devices with config: %v", err)
-	}
-
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "test-pod",
@@ -229,7 +209,7 @@ func TestPodHasDifferentScheduler(t *testing.T) {
 			},
 		},
 	}
-	wh, err := NewWebHook()
+	wh, err := NewWebHook(nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Error creating WebHook: %v", err)
 	}
@@ -240,3 +220,724 @@ func TestPodHasDifferentScheduler(t *testing.T) {
 		t.Errorf("Expected allowed response for pod with different scheduler, but got: %v", resp)
 	}
 }
+
+func TestVolcanoPodKeepsScheduler(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.VolcanoSchedulerName = "volcano"
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			SchedulerName: "volcano",
+			Containers: []corev1.Container{
+				{
+					Name: "container1",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							"hami.io/gpu": resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+	podBytes, err := runtime.Encode(codec, pod)
+	if err != nil {
+		t.Fatalf("Error encoding pod: %v", err)
+	}
+
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Namespace: "default",
+			Name:      "test-pod",
+			Object: runtime.RawExtension{
+				Raw: podBytes,
+			},
+		},
+	}
+	wh, err := NewWebHook(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Error creating WebHook: %v", err)
+	}
+
+	resp := wh.Handle(context.Background(), req)
+
+	if !resp.Allowed {
+		t.Fatalf("Expected allowed response for volcano pod, but got: %v", resp)
+	}
+	if len(resp.Patches) == 0 {
+		t.Errorf("Expected volcano pod to be mutated, but got no patches")
+	}
+	for _, patch := range resp.Patches {
+		if patch.Path == "/spec/schedulerName" {
+			t.Errorf("Expected volcano pod to keep its scheduler, but got patch: %v", patch)
+		}
+	}
+}
+
+func TestInvalidComputeModeDenied(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pod",
+			Namespace:   "default",
+			Annotations: map[string]string{util.ComputeModeAnnotationKey: "shared"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "container1",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							"hami.io/gpu": resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+	podBytes, err := runtime.Encode(codec, pod)
+	if err != nil {
+		t.Fatalf("Error encoding pod: %v", err)
+	}
+
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Namespace: "default",
+			Name:      "test-pod",
+			Object: runtime.RawExtension{
+				Raw: podBytes,
+			},
+		},
+	}
+	wh, err := NewWebHook(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Error creating WebHook: %v", err)
+	}
+
+	resp := wh.Handle(context.Background(), req)
+
+	if resp.Allowed {
+		t.Errorf("Expected denied response for invalid compute mode, but got: %v", resp)
+	}
+}
+
+func TestInvalidAnnotationsOnlyDeniedWithDevices(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	tests := []struct {
+		name        string
+		annos       map[string]string
+		limits      corev1.ResourceList
+		wantAllowed bool
+	}{
+		{name: "invalid compute mode with devices", annos: map[string]string{util.ComputeModeAnnotationKey: "shared"}, limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+		{name: "invalid schedule after with devices", annos: map[string]string{util.ScheduleAfterAnnotationKey: "tomorrow"}, limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+		{name: "invalid compute mode without devices", annos: map[string]string{util.ComputeModeAnnotationKey: "shared"}, wantAllowed: true},
+		{name: "invalid schedule after without devices", annos: map[string]string{util.ScheduleAfterAnnotationKey: "tomorrow"}, wantAllowed: true},
+		{name: "invalid pod level GPU request", annos: map[string]string{util.PodGPUCountAnnotationKey: "0"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default", Annotations: test.annos},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:      "container1",
+					Resources: corev1.ResourceRequirements{Limits: test.limits},
+				}}},
+			}
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+			podBytes, err := runtime.Encode(codec, pod)
+			if err != nil {
+				t.Fatalf("Error encoding pod: %v", err)
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Namespace: "default",
+					Name:      "test-pod",
+					Object:    runtime.RawExtension{Raw: podBytes},
+				},
+			}
+			wh, err := NewWebHook(nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Error creating WebHook: %v", err)
+			}
+
+			resp := wh.Handle(context.Background(), req)
+			if resp.Allowed != test.wantAllowed {
+				t.Errorf("Expected allowed %v, but got: %v", test.wantAllowed, resp)
+			}
+		})
+	}
+}
+
+func TestInvalidDeviceAffinityDenied(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	tests := []struct {
+		name        string
+		annos       map[string]string
+		wantAllowed bool
+	}{
+		{name: "valid selectors", annos: map[string]string{util.DeviceAffinityAnnotationKey: "app=trainer", util.DeviceAntiAffinityAnnotationKey: "app in (miner)"}, wantAllowed: true},
+		{name: "invalid affinity", annos: map[string]string{util.DeviceAffinityAnnotationKey: "=miner"}},
+		{name: "invalid anti-affinity", annos: map[string]string{util.DeviceAntiAffinityAnnotationKey: "app in (miner"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default", Annotations: test.annos},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:      "container1",
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+				}}},
+			}
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+			podBytes, err := runtime.Encode(codec, pod)
+			if err != nil {
+				t.Fatalf("Error encoding pod: %v", err)
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Namespace: "default",
+					Name:      "test-pod",
+					Object:    runtime.RawExtension{Raw: podBytes},
+				},
+			}
+			wh, err := NewWebHook(nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Error creating WebHook: %v", err)
+			}
+
+			resp := wh.Handle(context.Background(), req)
+			if resp.Allowed != test.wantAllowed {
+				t.Errorf("Expected allowed %v, but got: %v", test.wantAllowed, resp)
+			}
+		})
+	}
+}
+
+func TestImageAllowlist(t *testing.T) {
+	digest := "sha256:6b0e7a9a4c8ea2b2b0f6a1f1d7e9c3a5b8d2f4e6a8c0b2d4f6e8a0c2b4d6f8e0"
+	initTestDevices(t, func(c *config.Config) {
+		c.ImageAllowlist = []string{"registry.example.com/ml/", digest}
+	})
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	tests := []struct {
+		name        string
+		image       string
+		gpu         bool
+		wantAllowed bool
+	}{
+		{name: "allowed by prefix", image: "registry.example.com/ml/pytorch:2.4", gpu: true, wantAllowed: true},
+		{name: "allowed by digest", image: "docker.io/library/cuda@" + digest, gpu: true, wantAllowed: true},
+		{name: "denied", image: "docker.io/library/cuda:12.4", gpu: true, wantAllowed: false},
+		{name: "no gpu requested", image: "docker.io/library/busybox", gpu: false, wantAllowed: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctr := corev1.Container{Name: "container1", Image: test.image}
+			if test.gpu {
+				ctr.Resources.Limits = corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{ctr}},
+			}
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+			podBytes, err := runtime.Encode(codec, pod)
+			if err != nil {
+				t.Fatalf("Error encoding pod: %v", err)
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Namespace: "default",
+					Name:      "test-pod",
+					Object:    runtime.RawExtension{Raw: podBytes},
+				},
+			}
+			wh, err := NewWebHook(nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Error creating WebHook: %v", err)
+			}
+
+			resp := wh.Handle(context.Background(), req)
+			if resp.Allowed != test.wantAllowed {
+				t.Fatalf("Expected allowed %v, but got: %v", test.wantAllowed, resp)
+			}
+			if !resp.Allowed && !strings.Contains(resp.Result.Message, test.image) {
+				t.Errorf("Expected denial message to name image %s, but got: %s", test.image, resp.Result.Message)
+			}
+		})
+	}
+}
+
+// errorDevices requests devices for every container and fails its admission with err.
+type errorDevices struct {
+	device.Devices
+	err error
+}
+
+func (d *errorDevices) MutateAdmission(ctr *corev1.Container, pod *corev1.Pod, warnings *device.AdmissionWarnings) (bool, error) {
+	return true, d.err
+}
+
+func TestMutateAdmissionErrors(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	t.Cleanup(func() { delete(device.DevicesMap, "error") })
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int32
+	}{
+		{name: "invalid request", err: device.InvalidRequestErrorf("memory %d is invalid", 3), wantCode: http.StatusForbidden},
+		{name: "internal error", err: device.InternalErrorf("failed to read config"), wantCode: http.StatusInternalServerError},
+		{name: "uncategorized error", err: errors.New("unexpected"), wantCode: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			device.DevicesMap["error"] = &errorDevices{err: test.err}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "container1"}}},
+			}
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+			podBytes, err := runtime.Encode(codec, pod)
+			if err != nil {
+				t.Fatalf("Error encoding pod: %v", err)
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Namespace: "default",
+					Name:      "test-pod",
+					Object:    runtime.RawExtension{Raw: podBytes},
+				},
+			}
+			wh, err := NewWebHook(nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Error creating WebHook: %v", err)
+			}
+
+			resp := wh.Handle(context.Background(), req)
+			if resp.Allowed {
+				t.Fatalf("Expected the pod to be rejected, but got: %v", resp)
+			}
+			if resp.Result.Code != test.wantCode {
+				t.Errorf("Expected code %d, but got: %d", test.wantCode, resp.Result.Code)
+			}
+			if resp.Result.Message != test.err.Error() {
+				t.Errorf("Expected message %q, but got: %q", test.err.Error(), resp.Result.Message)
+			}
+		})
+	}
+}
+
+func TestSetRequestsToLimits(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+	config.SetRequestsToLimits = true
+
+	tests := []struct {
+		name         string
+		gpu          bool
+		wantRequests bool
+	}{
+		{name: "gpu pod", gpu: true, wantRequests: true},
+		{name: "no gpu requested", gpu: false, wantRequests: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctr := corev1.Container{
+				Name: "container1",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("4"),
+						corev1.ResourceMemory: resource.MustParse("16Gi"),
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("500m"),
+					},
+				},
+			}
+			if test.gpu {
+				ctr.Resources.Limits["hami.io/gpu"] = resource.MustParse("1")
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{ctr}},
+			}
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+			podBytes, err := runtime.Encode(codec, pod)
+			if err != nil {
+				t.Fatalf("Error encoding pod: %v", err)
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Namespace: "default",
+					Name:      "test-pod",
+					Object:    runtime.RawExtension{Raw: podBytes},
+				},
+			}
+			wh, err := NewWebHook(nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Error creating WebHook: %v", err)
+			}
+
+			resp := wh.Handle(context.Background(), req)
+			if !resp.Allowed {
+				t.Fatalf("Expected allowed response, but got: %v", resp)
+			}
+			requests := map[string]any{}
+			for _, patch := range resp.Patches {
+				if path, ok := strings.CutPrefix(patch.Path, "/spec/containers/0/resources/requests/"); ok {
+					requests[path] = patch.Value
+				}
+			}
+			if !test.wantRequests {
+				if len(requests) != 0 {
+					t.Errorf("Expected requests of pod not requesting devices to be kept, but got patches: %v", requests)
+				}
+				return
+			}
+			if requests["cpu"] != "4" || requests["memory"] != "16Gi" {
+				t.Errorf("Expected cpu and memory requests to be set to limits, but got patches: %v", requests)
+			}
+		})
+	}
+}
+
+func Test_setRequestsToLimits(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{
+				Name: "init",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			}},
+			Containers: []corev1.Container{{
+				Name: "container1",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("8Gi"),
+						"hami.io/gpu":         resource.MustParse("1"),
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}},
+		},
+	}
+	setRequestsToLimits(pod)
+
+	if got := pod.Spec.InitContainers[0].Resources.Requests[corev1.ResourceCPU]; got.Cmp(resource.MustParse("1")) != 0 {
+		t.Errorf("Expected cpu request of init container 1, but got %s", got.String())
+	}
+	requests := pod.Spec.Containers[0].Resources.Requests
+	if got := requests[corev1.ResourceMemory]; got.Cmp(resource.MustParse("8Gi")) != 0 {
+		t.Errorf("Expected memory request 8Gi, but got %s", got.String())
+	}
+	// Requests without a limit are kept, and device resources are left to the device admission.
+	if got := requests[corev1.ResourceCPU]; got.Cmp(resource.MustParse("2")) != 0 {
+		t.Errorf("Expected cpu request without limit to be kept, but got %s", got.String())
+	}
+	if _, ok := requests["hami.io/gpu"]; ok {
+		t.Errorf("Expected device requests to be left alone, but got %v", requests)
+	}
+}
+
+func TestHandleMutatedPodIdempotent(t *testing.T) {
+	initTestDevices(t, func(c *config.Config) {
+		c.NvidiaConfig.ResourcePriority = "hami.io/priority"
+		c.NvidiaConfig.GPUCorePolicy = nvidia.ForceCorePolicy
+	})
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	// The pod as mutated by an earlier admission, e.g. of a dry-run, resubmitted by the client.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pod",
+			Namespace:   "default",
+			Annotations: map[string]string{util.ComputeModeAnnotationKey: string(util.ComputeModeExclusive)},
+		},
+		Spec: corev1.PodSpec{
+			SchedulerName: "hami-scheduler",
+			Containers: []corev1.Container{{
+				Name: "container1",
+				Env: []corev1.EnvVar{
+					{Name: "USER_ENV", Value: "kept"},
+					{Name: util.TaskPriority, Value: "1"},
+					{Name: util.CoreLimitSwitch, Value: string(nvidia.ForceCorePolicy)},
+					{Name: util.ComputeModeEnv, Value: string(util.ComputeModeExclusive)},
+				},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						"hami.io/gpu":      resource.MustParse("1"),
+						"hami.io/priority": resource.MustParse("1"),
+					},
+				},
+			}},
+		},
+	}
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+	podBytes, err := runtime.Encode(codec, pod)
+	if err != nil {
+		t.Fatalf("Error encoding pod: %v", err)
+	}
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Namespace: "default",
+			Name:      "test-pod",
+			Object:    runtime.RawExtension{Raw: podBytes},
+		},
+	}
+	wh, err := NewWebHook(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Error creating WebHook: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp := wh.Handle(context.Background(), req)
+		if !resp.Allowed {
+			t.Fatalf("Expected allowed response, but got: %v", resp)
+		}
+		for _, patch := range resp.Patches {
+			if strings.HasPrefix(patch.Path, "/spec/containers/0/env") {
+				t.Errorf("Expected env of the mutated pod to be kept, but got patch: %v", patch)
+			}
+		}
+	}
+}
+
+func TestHandleGenerateNamePod(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	var logs, decisions bytes.Buffer
+	klog.SetOutput(&logs)
+	klog.LogToStderr(false)
+	defer klog.LogToStderr(true)
+	h := &webhook{
+		decoder:   admission.NewDecoder(clientgoscheme.Scheme),
+		decisions: &admissionDecisionLogger{enc: json.NewEncoder(&decisions)},
+	}
+	// The pod of a controller, neither named nor in a namespace yet at admission.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "train-"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "ctr",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+		}}},
+	}
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("Error encoding pod: %v", err)
+	}
+	resp := h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       "req-uid",
+		Operation: admissionv1.Create,
+		Namespace: "default",
+		Object:    runtime.RawExtension{Raw: raw},
+	}})
+	klog.Flush()
+	if !resp.Allowed || len(resp.Patches) == 0 {
+		t.Fatalf("Expected mutated pod, but got: %v", resp)
+	}
+
+	if want := "Processing admission hook for pod default/train-, UID: req-uid"; !strings.Contains(logs.String(), want) {
+		t.Errorf("Expected logs to contain %q, but got: %s", want, logs.String())
+	}
+	var d AdmissionDecision
+	if err := json.NewDecoder(&decisions).Decode(&d); err != nil {
+		t.Fatalf("Error decoding admission decision: %v", err)
+	}
+	if d.UID != "req-uid" || d.Namespace != "default" || d.Pod != "train-" || d.Decision != AdmissionDecisionMutate {
+		t.Errorf("Expected decision on pod default/train- of request req-uid, but got: %+v", d)
+	}
+}
+
+func Test_applyOwnedEnvPolicy(t *testing.T) {
+	initTestDevices(t)
+	devs := []device.Devices{device.GetDevices()[nvidia.NvidiaGPUDevice]}
+	newContainer := func() *corev1.Container {
+		return &corev1.Container{Name: "container1", Env: []corev1.EnvVar{
+			{Name: "CUDA_DEVICE_MEMORY_LIMIT_0", Value: "80000m"},
+			{Name: "CUDA_DEVICE_MEMORY_LIMIT_FOO", Value: "kept, not a device index"},
+			{Name: "LD_PRELOAD", Value: "/opt/bypass.so"},
+			{Name: "CUDA_DISABLE_CONTROL", Value: "false"},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		policy      util.OwnedEnvPolicy
+		devs        []device.Devices
+		wantErr     bool
+		wantRemoved []string
+		wantEnvs    int
+	}{
+		{name: "allow", policy: util.OwnedEnvPolicyAllow, devs: devs, wantEnvs: 4},
+		{name: "default", policy: "", devs: devs, wantEnvs: 4},
+		{name: "deny", policy: util.OwnedEnvPolicyDeny, devs: devs, wantErr: true, wantEnvs: 4},
+		{name: "override", policy: util.OwnedEnvPolicyOverride, devs: devs,
+			wantRemoved: []string{"CUDA_DEVICE_MEMORY_LIMIT_0", "LD_PRELOAD"}, wantEnvs: 2},
+		{name: "no device requested", policy: util.OwnedEnvPolicyDeny, wantEnvs: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctr := newContainer()
+			removed, err := applyOwnedEnvPolicy(ctr, test.devs, test.policy)
+			if test.wantErr {
+				if !errors.Is(err, device.ErrInvalidRequest) {
+					t.Fatalf("Expected invalid request error, but got: %v", err)
+				}
+				if !strings.Contains(err.Error(), "CUDA_DEVICE_MEMORY_LIMIT_0") || !strings.Contains(err.Error(), "LD_PRELOAD") {
+					t.Errorf("Expected error to name the owned variables, but got: %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.Join(removed, ",") != strings.Join(test.wantRemoved, ",") {
+				t.Errorf("Expected removed %v, but got: %v", test.wantRemoved, removed)
+			}
+			if len(ctr.Env) != test.wantEnvs {
+				t.Errorf("Expected %d envs left, but got: %v", test.wantEnvs, ctr.Env)
+			}
+		})
+	}
+}
+
+func TestOwnedEnvPolicy(t *testing.T) {
+	initTestDevices(t)
+	config.SchedulerName = "hami-scheduler"
+	config.ForceOverwriteDefaultScheduler = true
+
+	coresDefaulted := "container container1: hami.io/gpucores is not set, defaulted to 100"
+	tests := []struct {
+		name           string
+		policy         util.OwnedEnvPolicy
+		wantAllowed    bool
+		wantAnnotation string
+		wantWarnings   []string
+	}{
+		{name: "allow", policy: util.OwnedEnvPolicyAllow, wantAllowed: true, wantWarnings: []string{coresDefaulted}},
+		{name: "deny", policy: util.OwnedEnvPolicyDeny, wantAllowed: false},
+		{name: "override", policy: util.OwnedEnvPolicyOverride, wantAllowed: true, wantAnnotation: `{"container1":["CUDA_DEVICE_MEMORY_LIMIT"]}`, wantWarnings: []string{
+			coresDefaulted,
+			"environment variables CUDA_DEVICE_MEMORY_LIMIT of container container1 are removed, they're set by the device plugin",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.OwnedEnvPolicy = string(test.policy)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{
+						Name:      "container1",
+						Env:       []corev1.EnvVar{{Name: "CUDA_DEVICE_MEMORY_LIMIT", Value: "80000m"}},
+						Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+					},
+					// Containers not requesting GPUs are left alone.
+					{Name: "container2", Env: []corev1.EnvVar{{Name: "LD_PRELOAD", Value: "/opt/profiler.so"}}},
+				}},
+			}
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+			podBytes, err := runtime.Encode(codec, pod)
+			if err != nil {
+				t.Fatalf("Error encoding pod: %v", err)
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Namespace: "default",
+					Name:      "test-pod",
+					Object:    runtime.RawExtension{Raw: podBytes},
+				},
+			}
+			wh, err := NewWebHook(nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Error creating WebHook: %v", err)
+			}
+
+			resp := wh.Handle(context.Background(), req)
+			if resp.Allowed != test.wantAllowed {
+				t.Fatalf("Expected allowed %v, but got: %v", test.wantAllowed, resp)
+			}
+			if !resp.Allowed {
+				if !strings.Contains(resp.Result.Message, "CUDA_DEVICE_MEMORY_LIMIT") {
+					t.Errorf("Expected denial message to name the variable, but got: %s", resp.Result.Message)
+				}
+				return
+			}
+			annotation := ""
+			for _, patch := range resp.Patches {
+				if patch.Path == "/metadata/annotations" {
+					annotations, _ := patch.Value.(map[string]any)
+					annotation, _ = annotations[util.OverriddenEnvsAnnotationKey].(string)
+				}
+				if strings.HasPrefix(patch.Path, "/spec/containers/1/env") {
+					t.Errorf("Expected env of the container not requesting GPUs to be kept, but got patch: %v", patch)
+				}
+			}
+			if annotation != test.wantAnnotation {
+				t.Errorf("Expected overridden envs annotation %q, but got %q", test.wantAnnotation, annotation)
+			}
+			if !slices.Equal(resp.Warnings, test.wantWarnings) {
+				t.Errorf("Expected warnings %q, but got %q", test.wantWarnings, resp.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookcert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// acquireLease takes the rotation Lease if it's free, expired or already held by this replica.
+// Concurrent replicas are told apart by the optimistic concurrency of the Lease.
+func (m *Manager) acquireLease(ctx context.Context) (bool, error) {
+	leases := m.client.CoordinationV1().Leases(m.config.Namespace)
+	now := metav1.NewMicroTime(m.now())
+	identity := m.config.Identity
+	duration := int32(leaseDuration.Seconds())
+	lease, err := leases.Get(ctx, m.config.SecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: m.config.SecretName, Namespace: m.config.Namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to create lease %s/%s: %v", m.config.Namespace, m.config.SecretName, err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease %s/%s: %v", m.config.Namespace, m.config.SecretName, err)
+	}
+	holder := leaseHolder(lease)
+	if holder != "" && holder != identity && m.leaseHeld(lease) {
+		return false, nil
+	}
+	if holder != identity {
+		lease.Spec.AcquireTime = &now
+	}
+	lease.Spec.HolderIdentity = &identity
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update lease %s/%s: %v", m.config.Namespace, m.config.SecretName, err)
+	}
+	return true, nil
+}
+
+// leaseHeld reports whether the Lease was renewed within its duration.
+func (m *Manager) leaseHeld(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return m.now().Before(expiry)
+}
+
+func leaseHolder(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+// releaseLease frees the rotation Lease held by this replica.
+func (m *Manager) releaseLease(ctx context.Context) {
+	leases := m.client.CoordinationV1().Leases(m.config.Namespace)
+	lease, err := leases.Get(ctx, m.config.SecretName, metav1.GetOptions{})
+	if err != nil || leaseHolder(lease) != m.config.Identity {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		klog.V(4).InfoS("Failed to release lease", "lease", klog.KObj(lease), "error", err)
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhookcert provisions the serving certificate of the scheduler webhook without Helm.
+// The CA and the serving certificate are kept in a Secret shared by the replicas, the replica
+// holding a Lease rotates them before they expire and writes the CA bundle into the
+// MutatingWebhookConfiguration, and every replica serves the certificate of the Secret.
+package webhookcert
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CACertKey is the key of the CA bundle in the Secret, the signing CA first.
+	CACertKey = "ca.crt"
+	// CAKeyKey is the key of the private key of the signing CA in the Secret.
+	CAKeyKey = "ca.key"
+
+	// caValidity is the validity of the generated CA.
+	caValidity = 10 * 365 * 24 * time.Hour
+	// leaseDuration is how long a replica holds the rotation Lease.
+	leaseDuration = time.Minute
+)
+
+// Config configures the Manager.
+type Config struct {
+	// Namespace is the namespace of the webhook Service, the Secret and the Lease.
+	Namespace string
+	// ServiceName is the name of the webhook Service the certificate is issued for.
+	ServiceName string
+	// SecretName is the name of the Secret keeping the certificates and of the rotation Lease.
+	SecretName string
+	// WebhookConfigName is the name of the MutatingWebhookConfiguration to write the CA bundle into.
+	WebhookConfigName string
+	// Validity is the validity of the serving certificate, it is rotated once a third of it is left.
+	Validity time.Duration
+	// Identity is the holder identity of the Lease, unique per replica.
+	Identity string
+}
+
+// Manager provisions and rotates the webhook serving certificate.
+type Manager struct {
+	config Config
+	client kubernetes.Interface
+	cert   atomic.Pointer[tls.Certificate]
+	now    func() time.Time
+}
+
+// NewManager returns a Manager, Reconcile must succeed before the certificate is served.
+func NewManager(client kubernetes.Interface, config Config) *Manager {
+	return &Manager{config: config, client: client, now: time.Now}
+}
+
+// GetCertificate returns the current serving certificate, for tls.Config.
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := m.cert.Load()
+	if cert == nil {
+		return nil, errors.New("webhook serving certificate is not provisioned yet")
+	}
+	return cert, nil
+}
+
+// Start reconciles until a serving certificate is loaded, then keeps reconciling every interval
+// until ctx is done.
+func (m *Manager) Start(ctx context.Context, interval time.Duration) error {
+	err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		if err := m.Reconcile(ctx); err != nil {
+			klog.ErrorS(err, "Failed to provision webhook serving certificate")
+		}
+		return m.cert.Load() != nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := m.Reconcile(ctx); err != nil {
+			klog.ErrorS(err, "Failed to reconcile webhook serving certificate")
+		}
+	}, interval)
+	return nil
+}
+
+// Reconcile loads the certificates of the Secret, rotates them if they expire soon and this replica
+// holds the Lease, and writes the CA bundle into the MutatingWebhookConfiguration.
+func (m *Manager) Reconcile(ctx context.Context) error {
+	secrets := m.client.CoreV1().Secrets(m.config.Namespace)
+	secret, err := secrets.Get(ctx, m.config.SecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get secret %s/%s: %v", m.config.Namespace, m.config.SecretName, err)
+	}
+	b := bundleFromSecret(secret)
+	if !m.valid(b) {
+		acquired, err := m.acquireLease(ctx)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			klog.V(4).InfoS("Webhook serving certificate is rotated by another replica", "secret", klog.KRef(m.config.Namespace, m.config.SecretName))
+			// Serve the current certificate, if any, until the holder writes the new one.
+			return m.load(b)
+		}
+		defer m.releaseLease(ctx)
+		if b, err = m.rotate(b); err != nil {
+			return err
+		}
+		if err := m.writeSecret(ctx, secret, b); err != nil {
+			return err
+		}
+		klog.InfoS("Rotated webhook serving certificate", "secret", klog.KRef(m.config.Namespace, m.config.SecretName), "notAfter", b.cert.NotAfter)
+	}
+	if err := m.load(b); err != nil {
+		return err
+	}
+	return m.patchWebhookConfig(ctx, b.caBundle)
+}
+
+// bundle is the content of the Secret.
+type bundle struct {
+	caBundle []byte
+	ca       *x509.Certificate
+	caKey    crypto.Signer
+	certPEM  []byte
+	keyPEM   []byte
+	cert     *x509.Certificate
+}
+
+func bundleFromSecret(secret *corev1.Secret) *bundle {
+	b := &bundle{}
+	if secret == nil {
+		return b
+	}
+	b.caBundle = secret.Data[CACertKey]
+	if block, _ := pem.Decode(b.caBundle); block != nil {
+		b.ca, _ = x509.ParseCertificate(block.Bytes)
+	}
+	if block, _ := pem.Decode(secret.Data[CAKeyKey]); block != nil {
+		if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+			b.caKey = key
+		}
+	}
+	b.certPEM = secret.Data[corev1.TLSCertKey]
+	b.keyPEM = secret.Data[corev1.TLSPrivateKeyKey]
+	if block, _ := pem.Decode(b.certPEM); block != nil {
+		b.cert, _ = x509.ParseCertificate(block.Bytes)
+	}
+	return b
+}
+
+func (m *Manager) renewBefore() time.Duration {
+	return m.config.Validity / 3
+}
+
+func (m *Manager) dnsNames() []string {
+	return []string{
+		m.config.ServiceName,
+		m.config.ServiceName + "." + m.config.Namespace,
+		m.config.ServiceName + "." + m.config.Namespace + ".svc",
+		m.config.ServiceName + "." + m.config.Namespace + ".svc.cluster.local",
+	}
+}
+
+// caValid reports whether the CA of b can sign certificates for a while.
+func (m *Manager) caValid(b *bundle) bool {
+	return b.ca != nil && b.caKey != nil && b.ca.NotAfter.Sub(m.now()) > m.renewBefore()
+}
+
+// valid reports whether b has a serving certificate signed by its CA, for the service, not expiring soon.
+func (m *Manager) valid(b *bundle) bool {
+	if !m.caValid(b) || b.cert == nil || b.cert.CheckSignatureFrom(b.ca) != nil {
+		return false
+	}
+	if _, err := tls.X509KeyPair(b.certPEM, b.keyPEM); err != nil {
+		return false
+	}
+	for _, name := range m.dnsNames() {
+		if !slices.Contains(b.cert.DNSNames, name) {
+			return false
+		}
+	}
+	return b.cert.NotAfter.Sub(m.now()) > m.renewBefore()
+}
+
+// rotate issues a new serving certificate, and a new CA if the CA of b expires soon.
+// The CA bundle keeps the previous CA until it expires, for clients not updated yet.
+func (m *Manager) rotate(b *bundle) (*bundle, error) {
+	res := &bundle{ca: b.ca, caKey: b.caKey, caBundle: b.caBundle}
+	if !m.caValid(b) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		ca, caPEM, err := m.sign(&x509.Certificate{
+			Subject:               pkix.Name{CommonName: m.config.ServiceName + "-ca"},
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, caValidity, key.Public(), nil, key)
+		if err != nil {
+			return nil, err
+		}
+		res.ca, res.caKey, res.caBundle = ca, key, caPEM
+		if b.ca != nil && b.ca.NotAfter.After(m.now()) {
+			res.caBundle = append(res.caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b.ca.Raw})...)
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	res.cert, res.certPEM, err = m.sign(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: m.dnsNames()[2]},
+		DNSNames:    m.dnsNames(),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, m.config.Validity, key.Public(), res.ca, res.caKey)
+	if err != nil {
+		return nil, err
+	}
+	if res.keyPEM, err = encodeKey(key); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// sign signs template by parent with key, self-signed if parent is nil.
+func (m *Manager) sign(template *x509.Certificate, validity time.Duration, pub crypto.PublicKey, parent *x509.Certificate, key crypto.Signer) (*x509.Certificate, []byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := m.now()
+	template.SerialNumber = serial
+	template.NotBefore = now.Add(-time.Minute)
+	template.NotAfter = now.Add(validity)
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign certificate %s: %v", template.Subject.CommonName, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key.(*ecdsa.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+func (m *Manager) writeSecret(ctx context.Context, secret *corev1.Secret, b *bundle) error {
+	caKeyPEM, err := encodeKey(b.caKey)
+	if err != nil {
+		return err
+	}
+	data := map[string][]byte{
+		CACertKey:               b.caBundle,
+		CAKeyKey:                caKeyPEM,
+		corev1.TLSCertKey:       b.certPEM,
+		corev1.TLSPrivateKeyKey: b.keyPEM,
+	}
+	secrets := m.client.CoreV1().Secrets(m.config.Namespace)
+	if secret == nil {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: m.config.SecretName, Namespace: m.config.Namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       data,
+		}
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	} else {
+		secret = secret.DeepCopy()
+		secret.Data = data
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write secret %s/%s: %v", m.config.Namespace, m.config.SecretName, err)
+	}
+	return nil
+}
+
+// load serves the serving certificate of b if it changed and has not expired.
+func (m *Manager) load(b *bundle) error {
+	if b.cert == nil || !b.cert.NotAfter.After(m.now()) {
+		return nil
+	}
+	if current := m.cert.Load(); current != nil && bytes.Equal(current.Certificate[0], b.cert.Raw) {
+		return nil
+	}
+	cert, err := tls.X509KeyPair(b.certPEM, b.keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook serving certificate: %v", err)
+	}
+	m.cert.Store(&cert)
+	klog.InfoS("Loaded webhook serving certificate", "notAfter", b.cert.NotAfter)
+	return nil
+}
+
+// patchWebhookConfig writes caBundle into every webhook of the MutatingWebhookConfiguration.
+func (m *Manager) patchWebhookConfig(ctx context.Context, caBundle []byte) error {
+	configs := m.client.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	config, err := configs.Get(ctx, m.config.WebhookConfigName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get mutating webhook configuration %s: %v", m.config.WebhookConfigName, err)
+	}
+	changed := false
+	for i := range config.Webhooks {
+		if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			config.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if _, err := configs.Update(ctx, config, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update mutating webhook configuration %s: %v", m.config.WebhookConfigName, err)
+	}
+	klog.InfoS("Updated CA bundle of mutating webhook configuration", "name", m.config.WebhookConfigName)
+	return nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookcert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+const validity = 365 * 24 * time.Hour
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestManager(client kubernetes.Interface, identity string, clock *fakeClock) *Manager {
+	m := NewManager(client, Config{
+		Namespace:         "kube-system",
+		ServiceName:       "hami-scheduler",
+		SecretName:        "hami-scheduler-tls",
+		WebhookConfigName: "hami-webhook",
+		Validity:          validity,
+		Identity:          identity,
+	})
+	m.now = clock.Now
+	return m
+}
+
+func newTestClient() kubernetes.Interface {
+	return fake.NewSimpleClientset(&admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "hami-webhook"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{Name: "vgpu.hami.io"},
+			{Name: "other.hami.io"},
+		},
+	})
+}
+
+func getSecret(t *testing.T, client kubernetes.Interface) *corev1.Secret {
+	t.Helper()
+	secret, err := client.CoreV1().Secrets("kube-system").Get(context.Background(), "hami-scheduler-tls", metav1.GetOptions{})
+	assert.NilError(t, err)
+	return secret
+}
+
+func servingCert(t *testing.T, m *Manager) *x509.Certificate {
+	t.Helper()
+	cert, err := m.GetCertificate(nil)
+	assert.NilError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NilError(t, err)
+	return leaf
+}
+
+func TestReconcile_Provisions(t *testing.T) {
+	client := newTestClient()
+	clock := &fakeClock{now: time.Now()}
+	m := newTestManager(client, "replica-1", clock)
+	_, err := m.GetCertificate(nil)
+	assert.ErrorContains(t, err, "not provisioned")
+
+	assert.NilError(t, m.Reconcile(context.Background()))
+
+	secret := getSecret(t, client)
+	assert.Equal(t, secret.Type, corev1.SecretTypeTLS)
+	config, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.Background(), "hami-webhook", metav1.GetOptions{})
+	assert.NilError(t, err)
+	for _, webhook := range config.Webhooks {
+		assert.DeepEqual(t, webhook.ClientConfig.CABundle, secret.Data[CACertKey])
+	}
+	cas, err := certutil.ParseCertsPEM(secret.Data[CACertKey])
+	assert.NilError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(cas[0])
+	leaf := servingCert(t, m)
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "hami-scheduler.kube-system.svc", Roots: roots, CurrentTime: clock.now})
+	assert.NilError(t, err)
+	assert.Assert(t, leaf.NotAfter.Equal(clock.now.Add(validity).Truncate(time.Second)))
+
+	// The lease is released once rotated.
+	lease, err := client.CoordinationV1().Leases("kube-system").Get(context.Background(), "hami-scheduler-tls", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Assert(t, lease.Spec.HolderIdentity == nil)
+
+	// Another replica serves the same certificate.
+	other := newTestManager(client, "replica-2", clock)
+	assert.NilError(t, other.Reconcile(context.Background()))
+	assert.DeepEqual(t, servingCert(t, other).Raw, leaf.Raw)
+	assert.DeepEqual(t, getSecret(t, client).Data, secret.Data)
+}
+
+func TestReconcile_RotatesBeforeExpiry(t *testing.T) {
+	client := newTestClient()
+	clock := &fakeClock{now: time.Now()}
+	m := newTestManager(client, "replica-1", clock)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	first := servingCert(t, m)
+	caBundle := getSecret(t, client).Data[CACertKey]
+
+	// More than a third of the validity is left.
+	clock.now = clock.now.Add(validity / 2)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	assert.DeepEqual(t, servingCert(t, m).Raw, first.Raw)
+
+	clock.now = first.NotAfter.Add(-validity/3 + time.Hour)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	rotated := servingCert(t, m)
+	assert.Assert(t, rotated.SerialNumber.Cmp(first.SerialNumber) != 0)
+	assert.Assert(t, rotated.NotAfter.After(first.NotAfter))
+	// The CA is kept.
+	assert.DeepEqual(t, getSecret(t, client).Data[CACertKey], caBundle)
+}
+
+func TestReconcile_LeaseHeldByAnotherReplica(t *testing.T) {
+	client := newTestClient()
+	clock := &fakeClock{now: time.Now()}
+	m := newTestManager(client, "replica-1", clock)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	first := servingCert(t, m)
+
+	clock.now = first.NotAfter.Add(-validity / 4)
+	holder, renew, duration := "replica-2", metav1.NewMicroTime(clock.now), int32(60)
+	leases := client.CoordinationV1().Leases("kube-system")
+	lease, err := leases.Get(context.Background(), "hami-scheduler-tls", metav1.GetOptions{})
+	assert.NilError(t, err)
+	lease.Spec = coordinationv1.LeaseSpec{HolderIdentity: &holder, RenewTime: &renew, LeaseDurationSeconds: &duration}
+	_, err = leases.Update(context.Background(), lease, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+
+	// The holder is rotating, the current certificate is still served.
+	assert.NilError(t, m.Reconcile(context.Background()))
+	assert.DeepEqual(t, servingCert(t, m).Raw, first.Raw)
+
+	// The holder is gone.
+	clock.now = clock.now.Add(2 * time.Minute)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	assert.Assert(t, servingCert(t, m).SerialNumber.Cmp(first.SerialNumber) != 0)
+}
+
+func TestReconcile_RotatesExpiringCA(t *testing.T) {
+	client := newTestClient()
+	clock := &fakeClock{now: time.Now()}
+	m := newTestManager(client, "replica-1", clock)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	oldCAs, err := certutil.ParseCertsPEM(getSecret(t, client).Data[CACertKey])
+	assert.NilError(t, err)
+
+	clock.now = oldCAs[0].NotAfter.Add(-validity / 4)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	cas, err := certutil.ParseCertsPEM(getSecret(t, client).Data[CACertKey])
+	assert.NilError(t, err)
+	// The new CA signs, the old one is trusted until it expires.
+	assert.Equal(t, len(cas), 2)
+	assert.DeepEqual(t, cas[1].Raw, oldCAs[0].Raw)
+	assert.NilError(t, servingCert(t, m).CheckSignatureFrom(cas[0]))
+	config, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.Background(), "hami-webhook", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, config.Webhooks[0].ClientConfig.CABundle, getSecret(t, client).Data[CACertKey])
+}
+
+func TestGetCertificate_ReloadsWithoutRestart(t *testing.T) {
+	client := newTestClient()
+	clock := &fakeClock{now: time.Now()}
+	m := newTestManager(client, "replica-1", clock)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	first := servingCert(t, m)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{GetCertificate: m.GetCertificate}
+	server.StartTLS()
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(getSecret(t, client).Data[CACertKey])
+	newClient := func() *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:    roots,
+			ServerName: "hami-scheduler.kube-system.svc",
+			Time:       clock.Now,
+		}}}
+	}
+	get := func(c *http.Client) *x509.Certificate {
+		resp, err := c.Get(server.URL)
+		assert.NilError(t, err)
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0]
+	}
+	kept := newClient()
+	assert.DeepEqual(t, get(kept).Raw, first.Raw)
+
+	clock.now = first.NotAfter.Add(-validity / 4)
+	assert.NilError(t, m.Reconcile(context.Background()))
+	rotated := servingCert(t, m)
+
+	// Open connections are kept, new ones get the rotated certificate.
+	assert.DeepEqual(t, get(kept).Raw, first.Raw)
+	assert.DeepEqual(t, get(newClient()).Raw, rotated.Raw)
+}
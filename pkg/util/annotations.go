@@ -0,0 +1,200 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// Kinds of the objects annotations are patched on by PatchAnnotationsWithRetry.
+const (
+	KindNode        = "Node"
+	KindPod         = "Pod"
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+)
+
+// AnnotationPatchBackoff bounds the attempts of PatchAnnotationsWithRetry on conflicts, jittered so the writers
+// conflicting on an object don't retry in lockstep.
+var AnnotationPatchBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 10 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.5,
+}
+
+// annotationPatchConflicts counts the conflicts PatchAnnotationsWithRetry encountered.
+var annotationPatchConflicts atomic.Int64
+
+// AnnotationPatchConflicts returns the number of conflicts encountered patching annotations, retried or not.
+func AnnotationPatchConflicts() int64 {
+	return annotationPatchConflicts.Load()
+}
+
+// NewAnnotationPatchConflictsCounter returns a counter of name reporting AnnotationPatchConflicts, for the components
+// patching annotations to export it.
+func NewAnnotationPatchConflictsCounter(name string) prometheus.CounterFunc {
+	return prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: name,
+		Help: "Number of conflicts encountered patching annotations of nodes and pods, retried up to a bound",
+	}, func() float64 { return float64(AnnotationPatchConflicts()) })
+}
+
+// ObjectRef refers to the object annotations are patched on.
+type ObjectRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// NodeRef refers to the node of name.
+func NodeRef(name string) ObjectRef {
+	return ObjectRef{Kind: KindNode, Name: name}
+}
+
+// PodRef refers to the pod of namespace and name.
+func PodRef(namespace, name string) ObjectRef {
+	return ObjectRef{Kind: KindPod, Namespace: namespace, Name: name}
+}
+
+func (r ObjectRef) String() string {
+	if r.Namespace == "" {
+		return r.Kind + "/" + r.Name
+	}
+	return r.Kind + "/" + r.Namespace + "/" + r.Name
+}
+
+// PatchAnnotationsWithRetry reads the object, lets mutate change the annotations and labels of a copy of its
+// metadata, and merge-patches the keys changed conditionally on the resourceVersion read, so a concurrent write
+// of the object conflicts instead of being overwritten or overwriting this one. On a conflict the object is read
+// and mutated again, up to the steps of AnnotationPatchBackoff. mutate may run several times and must derive its
+// changes from the metadata it's given; the error it returns is returned as is. Nothing is written if mutate
+// changes nothing.
+func PatchAnnotationsWithRetry(ctx context.Context, c kubernetes.Interface, ref ObjectRef, mutate func(meta *metav1.ObjectMeta) error) error {
+	return retry.RetryOnConflict(AnnotationPatchBackoff, func() error {
+		current, err := getObjectMeta(ctx, c, ref)
+		if err != nil {
+			return err
+		}
+		meta := current.DeepCopy()
+		if err := mutate(meta); err != nil {
+			return err
+		}
+		annotations := changedKeys(current.Annotations, meta.Annotations)
+		labels := changedKeys(current.Labels, meta.Labels)
+		if len(annotations) == 0 && len(labels) == 0 {
+			return nil
+		}
+		metadata := make(map[string]any)
+		if current.ResourceVersion != "" {
+			metadata["resourceVersion"] = current.ResourceVersion
+		}
+		if len(annotations) > 0 {
+			metadata["annotations"] = annotations
+		}
+		if len(labels) > 0 {
+			metadata["labels"] = labels
+		}
+		patch, err := json.Marshal(map[string]any{"metadata": metadata})
+		if err != nil {
+			return err
+		}
+		klog.V(5).InfoS("Patching annotations", "object", ref, "patch", string(patch))
+		err = patchObject(ctx, c, ref, patch)
+		if apierrors.IsConflict(err) {
+			annotationPatchConflicts.Add(1)
+			klog.V(4).InfoS("Conflict patching annotations, retrying", "object", ref)
+		}
+		return err
+	})
+}
+
+// changedKeys returns the keys of current changed in desired by their new value, the keys removed by nil.
+func changedKeys(current, desired map[string]string) map[string]*string {
+	res := make(map[string]*string)
+	for k, v := range desired {
+		if old, ok := current[k]; !ok || old != v {
+			res[k] = &v
+		}
+	}
+	for k := range current {
+		if _, ok := desired[k]; !ok {
+			res[k] = nil
+		}
+	}
+	return res
+}
+
+func getObjectMeta(ctx context.Context, c kubernetes.Interface, ref ObjectRef) (*metav1.ObjectMeta, error) {
+	switch ref.Kind {
+	case KindNode:
+		obj, err := c.CoreV1().Nodes().Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case KindPod:
+		obj, err := c.CoreV1().Pods(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case KindDeployment:
+		obj, err := c.AppsV1().Deployments(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case KindStatefulSet:
+		obj, err := c.AppsV1().StatefulSets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	}
+	return nil, fmt.Errorf("patching annotations of kind %s not supported", ref.Kind)
+}
+
+func patchObject(ctx context.Context, c kubernetes.Interface, ref ObjectRef, patch []byte) error {
+	var err error
+	switch ref.Kind {
+	case KindNode:
+		_, err = c.CoreV1().Nodes().Patch(ctx, ref.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	case KindPod:
+		_, err = c.CoreV1().Pods(ref.Namespace).Patch(ctx, ref.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	case KindDeployment:
+		_, err = c.AppsV1().Deployments(ref.Namespace).Patch(ctx, ref.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	case KindStatefulSet:
+		_, err = c.AppsV1().StatefulSets(ref.Namespace).Patch(ctx, ref.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	default:
+		err = fmt.Errorf("patching annotations of kind %s not supported", ref.Kind)
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// conflictingPodPatches makes patches of pods with a resourceVersion conflict if the pod changed since, as the API
+// server does, and the first injected patches conflict regardless. It returns the number of patches applied.
+func conflictingPodPatches(kubeClient *fake.Clientset, injected int) func() int {
+	var mutex sync.Mutex
+	applied := 0
+	kubeClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		patch := action.(k8stesting.PatchAction)
+		obj, err := kubeClient.Tracker().Get(corev1.SchemeGroupVersion.WithResource("pods"), patch.GetNamespace(), patch.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		pod := obj.(*corev1.Pod).DeepCopy()
+		var p struct {
+			Metadata struct {
+				ResourceVersion string             `json:"resourceVersion"`
+				Annotations     map[string]*string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(patch.GetPatch(), &p); err != nil {
+			return true, nil, err
+		}
+		if injected > 0 || (p.Metadata.ResourceVersion != "" && p.Metadata.ResourceVersion != pod.ResourceVersion) {
+			injected--
+			return true, nil, apierrors.NewConflict(corev1.Resource("pods"), pod.Name, errors.New("the object has been modified"))
+		}
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		for k, v := range p.Metadata.Annotations {
+			if v == nil {
+				delete(pod.Annotations, k)
+			} else {
+				pod.Annotations[k] = *v
+			}
+		}
+		rv, _ := strconv.Atoi(pod.ResourceVersion)
+		pod.ResourceVersion = strconv.Itoa(rv + 1)
+		applied++
+		return true, pod, kubeClient.Tracker().Update(corev1.SchemeGroupVersion.WithResource("pods"), pod, pod.Namespace)
+	})
+	return func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return applied
+	}
+}
+
+func TestPatchAnnotationsWithRetry(t *testing.T) {
+	defer func(backoff wait.Backoff) { AnnotationPatchBackoff = backoff }(AnnotationPatchBackoff)
+	// Enough attempts for every writer to get through the conflicts of the others.
+	AnnotationPatchBackoff = wait.Backoff{Steps: 100, Duration: time.Millisecond, Factor: 1, Jitter: 1}
+
+	kubeClient := fake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "train", Namespace: "default", ResourceVersion: "1", Annotations: map[string]string{"removed": "true"},
+	}})
+	applied := conflictingPodPatches(kubeClient, 3)
+	conflicts := AnnotationPatchConflicts()
+
+	// Concurrent writers incrementing a counter annotation by read-modify-write don't lose any increment.
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = PatchAnnotationsWithRetry(context.Background(), kubeClient, PodRef("default", "train"), func(meta *metav1.ObjectMeta) error {
+				count, _ := strconv.Atoi(meta.Annotations["count"])
+				meta.Annotations["count"] = strconv.Itoa(count + 1)
+				meta.Annotations["writer-"+strconv.Itoa(i)] = "done"
+				delete(meta.Annotations, "removed")
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NilError(t, err)
+	}
+	pod, err := kubeClient.CoreV1().Pods("default").Get(context.Background(), "train", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, pod.Annotations["count"], strconv.Itoa(writers))
+	for i := range writers {
+		assert.Equal(t, pod.Annotations["writer-"+strconv.Itoa(i)], "done")
+	}
+	_, removed := pod.Annotations["removed"]
+	assert.Assert(t, !removed, "annotation kept: %v", pod.Annotations)
+	assert.Equal(t, applied(), writers)
+	assert.Assert(t, AnnotationPatchConflicts()-conflicts >= 3)
+
+	// Nothing is written if nothing changes, the error of mutate is returned as is.
+	err = PatchAnnotationsWithRetry(context.Background(), kubeClient, PodRef("default", "train"), func(meta *metav1.ObjectMeta) error {
+		meta.Annotations["count"] = strconv.Itoa(writers)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, applied(), writers)
+	errHeld := errors.New("held")
+	err = PatchAnnotationsWithRetry(context.Background(), kubeClient, PodRef("default", "train"), func(meta *metav1.ObjectMeta) error {
+		return errHeld
+	})
+	assert.Assert(t, errors.Is(err, errHeld))
+
+	// The attempts are bounded.
+	AnnotationPatchBackoff.Steps = 2
+	conflictingPodPatches(kubeClient, 2)
+	err = PatchAnnotationsWithRetry(context.Background(), kubeClient, PodRef("default", "train"), func(meta *metav1.ObjectMeta) error {
+		meta.Annotations["bounded"] = "true"
+		return nil
+	})
+	assert.Assert(t, apierrors.IsConflict(err), err)
+}
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -85,6 +86,19 @@ func InitGlobalClient(opts ...Option) {
 	})
 }
 
+// NewDynamicClient creates a new dynamic Kubernetes client with the given options, e.g. for custom resources.
+func NewDynamicClient(opts ...Option) (dynamic.Interface, error) {
+	restConfig, err := loadKubeConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	WithDefaults()(restConfig)
+	for _, opt := range opts {
+		opt(restConfig)
+	}
+	return dynamic.NewForConfig(restConfig)
+}
+
 // loadKubeConfig loads Kubernetes configuration from the environment or in-cluster.
 func loadKubeConfig() (*rest.Config, error) {
 	kubeConfigPath := os.Getenv("KUBECONFIG")
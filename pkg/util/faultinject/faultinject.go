@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faultinject injects delays and errors at named points of the scheduler, so end-to-end tests can
+// exercise its failure handling, e.g. the rollback of node locks. Injection is off unless enabled, Inject is
+// then a no-op.
+package faultinject
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Point is a named point faults are injected at.
+type Point string
+
+const (
+	// PointNodeAnnotationDecode is the decoding of the devices of a node from its annotations.
+	PointNodeAnnotationDecode Point = "node-annotation-decode"
+	// PointDevicePluginRegistration is the registration of the decoded devices of a node by the scheduler.
+	PointDevicePluginRegistration Point = "device-plugin-registration"
+	// PointNodeLockAcquire is the acquisition of a node lock.
+	PointNodeLockAcquire Point = "node-lock-acquire"
+	// PointBindPatch is the patch of the pod annotations at bind, once the node locks are acquired.
+	PointBindPatch Point = "bind-patch"
+)
+
+// Points are the points faults can be injected at.
+var Points = []Point{PointNodeAnnotationDecode, PointDevicePluginRegistration, PointNodeLockAcquire, PointBindPatch}
+
+// ErrInjected is wrapped by the errors injected.
+var ErrInjected = errors.New("injected fault")
+
+// Fault is injected at a point: Delay is waited, then Error is returned if set. Count is the number of times
+// it's injected, until it's cleared if 0.
+type Fault struct {
+	Delay metav1.Duration `json:"delay"`
+	Error string          `json:"error,omitempty"`
+	Count int             `json:"count,omitempty"`
+}
+
+var (
+	enabled atomic.Bool
+	mu      sync.Mutex
+	faults  = make(map[Point]*Fault)
+)
+
+// Enable enables the injection of faults.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled reports whether the injection of faults is enabled.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Set injects fault at point from now on, replacing the fault injected there.
+func Set(point Point, fault Fault) error {
+	if !Enabled() {
+		return errors.New("fault injection is disabled")
+	}
+	if !slices.Contains(Points, point) {
+		return fmt.Errorf("unknown fault injection point %q", point)
+	}
+	if fault.Delay.Duration < 0 || fault.Count < 0 {
+		return fmt.Errorf("delay and count of the fault at %s must not be negative", point)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	faults[point] = &fault
+	klog.InfoS("Fault injection set", "point", point, "delay", fault.Delay.Duration, "error", fault.Error, "count", fault.Count)
+	return nil
+}
+
+// Clear stops injecting faults at point, or at every point if empty.
+func Clear(point Point) {
+	mu.Lock()
+	defer mu.Unlock()
+	if point == "" {
+		clear(faults)
+		return
+	}
+	delete(faults, point)
+}
+
+// List returns the faults injected by point.
+func List() map[Point]Fault {
+	mu.Lock()
+	defer mu.Unlock()
+	res := make(map[Point]Fault, len(faults))
+	for point, fault := range faults {
+		res[point] = *fault
+	}
+	return res
+}
+
+// Inject injects the fault set at point, if any: it waits its delay and returns its error. It returns nil
+// right away if injection is disabled.
+func Inject(point Point) error {
+	if !Enabled() {
+		return nil
+	}
+	mu.Lock()
+	fault, ok := faults[point]
+	if !ok {
+		mu.Unlock()
+		return nil
+	}
+	injected := *fault
+	if fault.Count > 0 {
+		if fault.Count--; fault.Count == 0 {
+			delete(faults, point)
+		}
+	}
+	mu.Unlock()
+
+	klog.InfoS("Injecting fault", "point", point, "delay", injected.Delay.Duration, "error", injected.Error)
+	time.Sleep(injected.Delay.Duration)
+	if injected.Error != "" {
+		return fmt.Errorf("%w at %s: %s", ErrInjected, point, injected.Error)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinject
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInject(t *testing.T) {
+	defer ResetForTest()
+
+	// Nothing is injected while disabled.
+	assert.ErrorContains(t, Set(PointBindPatch, Fault{Error: "conflict"}), "disabled")
+	assert.NilError(t, Inject(PointBindPatch))
+
+	Enable()
+	assert.ErrorContains(t, Set("unknown", Fault{Error: "conflict"}), "unknown")
+	assert.ErrorContains(t, Set(PointBindPatch, Fault{Count: -1}), "negative")
+
+	// A fault with a count is cleared once injected that many times.
+	assert.NilError(t, Set(PointBindPatch, Fault{Error: "conflict", Count: 2}))
+	assert.NilError(t, Inject(PointNodeLockAcquire))
+	for range 2 {
+		err := Inject(PointBindPatch)
+		assert.Assert(t, errors.Is(err, ErrInjected))
+		assert.ErrorContains(t, err, "at bind-patch: conflict")
+	}
+	assert.NilError(t, Inject(PointBindPatch))
+	assert.Equal(t, len(List()), 0)
+
+	// A fault without a count is injected until it's cleared, a delay alone doesn't fail.
+	assert.NilError(t, Set(PointNodeAnnotationDecode, Fault{Delay: metav1.Duration{Duration: 10 * time.Millisecond}}))
+	start := time.Now()
+	assert.NilError(t, Inject(PointNodeAnnotationDecode))
+	assert.Assert(t, time.Since(start) >= 10*time.Millisecond)
+	assert.DeepEqual(t, List(), map[Point]Fault{PointNodeAnnotationDecode: {Delay: metav1.Duration{Duration: 10 * time.Millisecond}}})
+	Clear("")
+	assert.Equal(t, len(List()), 0)
+}
@@ -18,26 +18,26 @@ package nodelock
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/faultinject"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 )
 
 const (
-	NodeLockKey = "hami.io/mutex.lock"
-	NodeLockSep = ","
+	NodeLockKey = util.NodeLockKey
+	NodeLockSep = util.NodeLockSep
 )
 
 var (
@@ -45,13 +45,6 @@ var (
 	nodeLocks = newNodeLockManager()
 	// NodeLockTimeout is the global timeout for node locks.
 	NodeLockTimeout time.Duration = time.Minute * 5
-
-	DefaultStrategy = wait.Backoff{
-		Steps:    5,
-		Duration: 100 * time.Millisecond,
-		Factor:   1.0,
-		Jitter:   0.1,
-	}
 )
 
 // nodeLockManager manages locks on a per-node basis to allow concurrent
@@ -123,33 +116,23 @@ func SetNodeLock(nodeName string, lockname string, pods *corev1.Pod) error {
 	nodeLock.Lock()
 	defer nodeLock.Unlock()
 
-	ctx := context.Background()
-	node, err := client.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
-	if _, ok := node.Annotations[NodeLockKey]; ok {
-		return fmt.Errorf("node %s is locked", nodeName)
-	}
-	err = retry.OnError(DefaultStrategy, func(err error) bool {
-		// Retry on any error
-		return true
-	}, func() error {
-		node, err = client.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
-		if err != nil {
-			klog.ErrorS(err, "Failed to get node when retry to patch", "node", nodeName)
-			return err
+	// The lock is checked again on every attempt, another scheduler locking the node in between conflicts.
+	errLocked := fmt.Errorf("node %s is locked", nodeName)
+	err := util.PatchAnnotationsWithRetry(context.Background(), client.GetClient(), util.NodeRef(nodeName), func(meta *metav1.ObjectMeta) error {
+		if _, ok := meta.Annotations[NodeLockKey]; ok {
+			return errLocked
 		}
-		patchData := fmt.Sprintf(`{"metadata":{"annotations":{"%s":"%s"},"resourceVersion":"%s"}}`, NodeLockKey, GenerateNodeLockKeyByPod(pods), node.ResourceVersion)
-		_, err = client.GetClient().CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, []byte(patchData), metav1.PatchOptions{})
-		if err != nil {
-			klog.ErrorS(err, "Failed to patch node when retry to patch", "node", nodeName)
-			return err
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string)
 		}
+		meta.Annotations[NodeLockKey] = GenerateNodeLockKeyByPod(pods)
 		return nil
 	})
+	if errors.Is(err, errLocked) {
+		return err
+	}
 	if err != nil {
-		return fmt.Errorf("failed to set node lock (node=%s, retry strategy=%+v): %w", nodeName, DefaultStrategy, err)
+		return fmt.Errorf("failed to set node lock (node=%s): %w", nodeName, err)
 	}
 
 	klog.InfoS("Node lock set", "node", nodeName, "podName", pods.Name)
@@ -165,47 +148,36 @@ func ReleaseNodeLock(nodeName string, lockname string, pod *corev1.Pod, skipNode
 	nodeLock.Lock()
 	defer nodeLock.Unlock()
 
-	ctx := context.Background()
-	node, err := client.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
-
-	lockStr, ok := node.Annotations[NodeLockKey]
-	if !ok {
-		return nil
-	}
-	if !skipNodeLockOwnerCheck && !strings.HasSuffix(lockStr, fmt.Sprintf("%s%s", NodeLockSep, GeneratePodNamespaceName(pod, NodeLockSep))) {
-		klog.InfoS("NodeLock is not set by this pod", NodeLockKey, lockStr, "podName", pod.Name, "podNamespace", pod.Namespace)
-		return nil
-	}
-
-	err = retry.OnError(DefaultStrategy, func(err error) bool {
-		// Retry on any error
-		return true
-	}, func() error {
-		node, err = client.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
-		if err != nil {
-			klog.ErrorS(err, "Failed to get node when retry to patch", "node", nodeName)
-			return err
+	// The owner is checked again on every attempt, so a lock another pod took in between isn't released.
+	released := false
+	err := util.PatchAnnotationsWithRetry(context.Background(), client.GetClient(), util.NodeRef(nodeName), func(meta *metav1.ObjectMeta) error {
+		released = false
+		lockStr, ok := meta.Annotations[NodeLockKey]
+		if !ok {
+			return nil
 		}
-		patchData := fmt.Sprintf(`{"metadata":{"annotations":{"%s":null},"resourceVersion":"%s"}}`, NodeLockKey, node.ResourceVersion)
-		_, err = client.GetClient().CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, []byte(patchData), metav1.PatchOptions{})
-		if err != nil {
-			klog.ErrorS(err, "Failed to patch node when retry to patch", "node", nodeName)
-			return err
+		if !skipNodeLockOwnerCheck && !strings.HasSuffix(lockStr, fmt.Sprintf("%s%s", NodeLockSep, GeneratePodNamespaceName(pod, NodeLockSep))) {
+			klog.InfoS("NodeLock is not set by this pod", NodeLockKey, lockStr, "podName", pod.Name, "podNamespace", pod.Namespace)
+			return nil
 		}
+		delete(meta.Annotations, NodeLockKey)
+		released = true
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to release node lock (node=%s, retry strategy=%+v): %w", nodeName, DefaultStrategy, err)
+		return fmt.Errorf("failed to release node lock (node=%s): %w", nodeName, err)
 	}
 
-	klog.InfoS("Node lock released", "node", nodeName, "podName", pod.Name)
+	if released {
+		klog.InfoS("Node lock released", "node", nodeName, "podName", pod.Name)
+	}
 	return nil
 }
 
 func LockNode(nodeName string, lockname string, pods *corev1.Pod) error {
+	if err := faultinject.Inject(faultinject.PointNodeLockAcquire); err != nil {
+		return err
+	}
 	ctx := context.Background()
 	node, err := client.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
@@ -248,18 +220,9 @@ func LockNode(nodeName string, lockname string, pods *corev1.Pod) error {
 	return fmt.Errorf("node %s has been locked within %v", nodeName, NodeLockTimeout)
 }
 
+// ParseNodeLock parses the value of the NodeLockKey annotation, see util.ParseNodeLock.
 func ParseNodeLock(value string) (lockTime time.Time, ns, name string, err error) {
-	if !strings.Contains(value, NodeLockSep) {
-		lockTime, err = time.Parse(time.RFC3339, value)
-		return lockTime, "", "", err
-	}
-	s := strings.Split(value, NodeLockSep)
-	if len(s) != 3 {
-		lockTime, err = time.Parse(time.RFC3339, value)
-		return lockTime, "", "", err
-	}
-	lockTime, err = time.Parse(time.RFC3339, s[0])
-	return lockTime, s[1], s[2], err
+	return util.ParseNodeLock(value)
 }
 
 func GenerateNodeLockKeyByPod(pod *corev1.Pod) string {
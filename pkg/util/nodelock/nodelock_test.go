@@ -18,14 +18,19 @@ package nodelock
 
 import (
 	"context"
+	"errors"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 )
@@ -335,6 +340,58 @@ func TestReleaseNodeLock(t *testing.T) {
 	}
 }
 
+// TestNodeLockConflict verifies a lock another scheduler takes or releases between reading the node and patching
+// it is neither overwritten nor released.
+func TestNodeLockConflict(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}})
+	client.KubeClient = kubeClient
+	hami := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "hami", Namespace: "hami-ns"}}
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "hami-ns"}}
+	// setLockOnNextPatch sets the lock to value as another scheduler would before the next patch, which conflicts.
+	setLockOnNextPatch := func(value string) {
+		done := false
+		kubeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			if done {
+				return false, nil, nil
+			}
+			done = true
+			// The tracker is used directly, the clientset is locked while reacting.
+			obj, err := kubeClient.Tracker().Get(corev1.SchemeGroupVersion.WithResource("nodes"), "", "node")
+			if err != nil {
+				return true, nil, err
+			}
+			node := obj.(*corev1.Node).DeepCopy()
+			node.Annotations = map[string]string{NodeLockKey: value}
+			if err := kubeClient.Tracker().Update(corev1.SchemeGroupVersion.WithResource("nodes"), node, ""); err != nil {
+				return true, nil, err
+			}
+			return true, nil, apierrors.NewConflict(corev1.Resource("nodes"), "node", errors.New("the object has been modified"))
+		})
+	}
+	lockOf := func() string {
+		t.Helper()
+		node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), "node", metav1.GetOptions{})
+		assert.NilError(t, err)
+		return node.Annotations[NodeLockKey]
+	}
+
+	otherLock := GenerateNodeLockKeyByPod(other)
+	setLockOnNextPatch(otherLock)
+	err := SetNodeLock("node", "", hami)
+	assert.ErrorContains(t, err, "node node is locked")
+	assert.Equal(t, lockOf(), otherLock)
+
+	// The lock of the pod is replaced by another pod's before it's released.
+	hamiLock := GenerateNodeLockKeyByPod(hami)
+	_, err = kubeClient.CoreV1().Nodes().Update(context.TODO(), &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name: "node", Annotations: map[string]string{NodeLockKey: hamiLock},
+	}}, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+	setLockOnNextPatch(otherLock)
+	assert.NilError(t, ReleaseNodeLock("node", "", hami, false))
+	assert.Equal(t, lockOf(), otherLock)
+}
+
 // TestConcurrentNodeLocks verifies that locks on different nodes can be acquired concurrently.
 func TestConcurrentNodeLocks(t *testing.T) {
 	client.KubeClient = fake.NewSimpleClientset()
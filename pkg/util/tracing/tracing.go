@@ -0,0 +1,130 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing exports OpenTelemetry traces of the scheduling pipeline of a pod. The webhook starts
+// the trace and records it in a pod annotation, the scheduler and device plugin attach their spans to it.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// TraceParentAnnotationKey keeps the W3C traceparent of the webhook span of a pod.
+	TraceParentAnnotationKey = "hami.io/traceparent"
+	// SamplingRatioEnv is the env of the ratio of pods traced, between 0 and 1. Tracing is off if unset or 0.
+	SamplingRatioEnv = "HAMI_TRACING_SAMPLING_RATIO"
+
+	tracerName        = "github.com/Project-HAMi/HAMi"
+	traceParentHeader = "traceparent"
+)
+
+var propagator = propagation.TraceContext{}
+
+// Init installs the global tracer provider of the component, exporting spans over OTLP configured by
+// the standard OTEL_EXPORTER_OTLP_* envs. The returned function flushes and stops the exporter.
+func Init(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	ratio, err := samplingRatio()
+	if err != nil {
+		return nil, err
+	}
+	if ratio == 0 {
+		klog.V(4).InfoS("Tracing disabled", "env", SamplingRatioEnv)
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP trace exporter error, %v", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		// Components other than the webhook follow the sampling decision recorded on the pod.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+	klog.InfoS("Tracing enabled", "service", serviceName, "samplingRatio", ratio)
+	return tp.Shutdown, nil
+}
+
+func samplingRatio() (float64, error) {
+	v := os.Getenv(SamplingRatioEnv)
+	if v == "" {
+		return 0, nil
+	}
+	ratio, err := strconv.ParseFloat(v, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return 0, fmt.Errorf("invalid %s %q, must be between 0 and 1", SamplingRatioEnv, v)
+	}
+	return ratio, nil
+}
+
+// Tracer returns the tracer of HAMi from the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartPodSpan starts a span of a stage of the pod, child of the trace recorded on the pod if any.
+func StartPodSpan(ctx context.Context, pod *corev1.Pod, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if pod != nil {
+		if parent := pod.Annotations[TraceParentAnnotationKey]; parent != "" {
+			ctx = propagator.Extract(ctx, propagation.MapCarrier{traceParentHeader: parent})
+		}
+		opts = append(opts, trace.WithAttributes(
+			attribute.String("k8s.namespace.name", pod.Namespace),
+			attribute.String("k8s.pod.name", pod.Name),
+			attribute.String("k8s.pod.uid", string(pod.UID)),
+		))
+	}
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// InjectPod records the span of ctx on the pod, so later stages are traced as its children.
+func InjectPod(ctx context.Context, pod *corev1.Pod) {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	parent := carrier.Get(traceParentHeader)
+	if parent == "" {
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[TraceParentAnnotationKey] = parent
+}
+
+// EndSpan ends the span, marking it failed if err is not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func withRecorder(t *testing.T, sampler sdktrace.Sampler) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler), sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestPodSpans(t *testing.T) {
+	recorder := withRecorder(t, sdktrace.ParentBased(sdktrace.AlwaysSample()))
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", UID: "uid"}}
+
+	ctx, webhook := StartPodSpan(context.Background(), nil, "webhook.Mutate")
+	InjectPod(ctx, pod)
+	webhook.End()
+	assert.Assert(t, pod.Annotations[TraceParentAnnotationKey] != "")
+
+	ctx, filter := StartPodSpan(context.Background(), pod, "scheduler.Filter")
+	_, score := Tracer().Start(ctx, "scheduler.Score")
+	score.End()
+	EndSpan(filter, nil)
+	_, allocate := StartPodSpan(context.Background(), pod, "deviceplugin.Allocate")
+	EndSpan(allocate, errors.New("device number not matched"))
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	assert.Equal(t, len(spans), 4)
+	root := spans["webhook.Mutate"].SpanContext()
+	assert.Assert(t, !spans["webhook.Mutate"].Parent().IsValid())
+	for _, name := range []string{"scheduler.Filter", "deviceplugin.Allocate"} {
+		assert.Equal(t, spans[name].Parent().SpanID(), root.SpanID(), name)
+		assert.Equal(t, spans[name].SpanContext().TraceID(), root.TraceID(), name)
+	}
+	assert.Equal(t, spans["scheduler.Score"].Parent().SpanID(), spans["scheduler.Filter"].SpanContext().SpanID())
+	assert.Equal(t, spans["scheduler.Filter"].Status().Code, codes.Unset)
+	assert.Equal(t, spans["deviceplugin.Allocate"].Status().Code, codes.Error)
+}
+
+func TestPodSpans_SamplingDecisionFollowsWebhook(t *testing.T) {
+	recorder := withRecorder(t, sdktrace.ParentBased(sdktrace.NeverSample()))
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+
+	ctx, webhook := StartPodSpan(context.Background(), nil, "webhook.Mutate")
+	InjectPod(ctx, pod)
+	webhook.End()
+	// Not sampled by the webhook, so the later stages aren't either.
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())), sdktrace.WithSpanProcessor(recorder)))
+	_, filter := StartPodSpan(context.Background(), pod, "scheduler.Filter")
+	filter.End()
+	assert.Equal(t, len(recorder.Ended()), 0)
+}
+
+func TestInjectPod_Disabled(t *testing.T) {
+	pod := &corev1.Pod{}
+	ctx, span := StartPodSpan(context.Background(), nil, "webhook.Mutate")
+	InjectPod(ctx, pod)
+	span.End()
+	assert.Assert(t, pod.Annotations == nil)
+}
+
+func TestSamplingRatio(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "0", want: 0},
+		{value: "0.25", want: 0.25},
+		{value: "1", want: 1},
+		{value: "1.5", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "all", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			t.Setenv(SamplingRatioEnv, test.value)
+			ratio, err := samplingRatio()
+			assert.Equal(t, err != nil, test.wantErr)
+			assert.Equal(t, ratio, test.want)
+		})
+	}
+}
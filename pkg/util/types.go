@@ -16,11 +16,26 @@ limitations under the License.
 
 package util
 
+import "time"
+
 const (
 	AssignedTimeAnnotations = "hami.io/vgpu-time"
 	AssignedNodeAnnotations = "hami.io/vgpu-node"
 	BindTimeAnnotations     = "hami.io/bind-time"
 	DeviceBindPhase         = "hami.io/bind-phase"
+	// DeviceMissingAnnotations lists the devices assigned to a pod the device plugin failed to allocate because
+	// they were gone from the node, e.g. fell off the bus, for the scheduler to reschedule the pod.
+	DeviceMissingAnnotations = "hami.io/device-missing"
+	// AllocationLeaseAnnotations records on a node the scheduler replica which last committed an allocation to it,
+	// for the pod and time, so other replicas only allocate to the node once they've seen that pod.
+	AllocationLeaseAnnotations = "hami.io/allocation-lease"
+	// BlacklistUUIDsAnnotations lists the devices of a node operators pulled out of allocation, e.g. flaky ones,
+	// pods already assigned them keep running.
+	BlacklistUUIDsAnnotations = "hami.io/blacklist-uuids"
+	// NodeLockKey is the node annotation the nodelock package locks nodes with while binding pods to them, its
+	// value is parsed by ParseNodeLock.
+	NodeLockKey = "hami.io/mutex.lock"
+	NodeLockSep = ","
 
 	DeviceBindAllocating = "allocating"
 	DeviceBindFailed     = "failed"
@@ -38,6 +53,10 @@ const (
 	NodeNameEnvName = "NODE_NAME"
 	TaskPriority    = "CUDA_TASK_PRIORITY"
 	CoreLimitSwitch = "GPU_CORE_UTILIZATION_POLICY"
+	// ComputeModeEnv tells the node agent which compute mode to configure the GPUs of a container with.
+	ComputeModeEnv = "GPU_COMPUTE_MODE"
+	// IsolationEnv tells the node library which isolation level to enforce the GPU memory of a container with.
+	IsolationEnv = "GPU_ISOLATION"
 )
 
 var (
@@ -59,6 +78,8 @@ const (
 	GPUSchedulerPolicySpread SchedulerPolicyName = "spread"
 	// GPUSchedulerPolicyTopology is GPU use topology scheduler.
 	GPUSchedulerPolicyTopology SchedulerPolicyName = "topology-aware"
+	// GPUSchedulerPolicyUtilization is GPU use spread scheduler weighing the measured utilization of GPUs.
+	GPUSchedulerPolicyUtilization SchedulerPolicyName = "utilization"
 )
 
 const (
@@ -66,8 +87,253 @@ const (
 	NodeSchedulerPolicyAnnotationKey = "hami.io/node-scheduler-policy"
 	// GPUSchedulerPolicyAnnotationKey is user set Pod annotation to change this default GPU policy.
 	GPUSchedulerPolicyAnnotationKey = "hami.io/gpu-scheduler-policy"
+	// DebugAnnotationKey is user set Pod annotation to enable scheduler trace logs for this pod only.
+	DebugAnnotationKey = "hami.io/debug"
+	// ComputeModeAnnotationKey is user set Pod annotation to choose how the GPUs of this pod are shared.
+	ComputeModeAnnotationKey = "hami.io/compute-mode"
+	// IsolationAnnotationKey is user set Pod annotation to choose how strictly the GPU memory of this pod is fenced.
+	IsolationAnnotationKey = "hami.io/isolation"
+	// OOMModeAnnotationKey is user set Pod annotation to choose how the GPU memory limit of the containers of this pod
+	// is enforced, for all containers or by container.
+	OOMModeAnnotationKey = "hami.io/oom-mode"
+	// ShareGPUWithinPodAnnotationKey is user set Pod annotation to let all containers of this pod share the same devices.
+	ShareGPUWithinPodAnnotationKey = "hami.io/share-gpu-within-pod"
+	// AllowMemoryOversubscriptionAnnotationKey is user set Pod annotation to let this pod use the device memory beyond
+	// the physical memory of the devices, on nodes whose device memory is scaled up.
+	AllowMemoryOversubscriptionAnnotationKey = "hami.io/allow-memory-oversubscription"
+	// RequireVerifiedAnnotationKey is user set Pod annotation to only allocate this pod the devices listed by
+	// VerifiedDevicesAnnotationKey on their node.
+	RequireVerifiedAnnotationKey = "hami.io/require-verified"
+	// VerifiedDevicesAnnotationKey is the Node annotation listing the UUIDs of the devices which passed the
+	// maintenance tests, e.g. a GPU burn-in, separated by ",". It's set by the maintenance tooling.
+	VerifiedDevicesAnnotationKey = "hami.io/verified-devices"
+	// GPUModelFallbackAnnotationKey is user set Pod annotation to list the accepted GPU models in order of preference, separated by ">".
+	GPUModelFallbackAnnotationKey = "hami.io/gpu-model-fallback"
+	// GPUModelSelectedAnnotationKey records the GPU model selected from GPUModelFallbackAnnotationKey.
+	GPUModelSelectedAnnotationKey = "hami.io/gpu-model-selected"
+	// GPUHealthPolicyAnnotationKey is user set Pod annotation to require stricter health of the GPUs of this pod.
+	GPUHealthPolicyAnnotationKey = "hami.io/gpu-health-policy"
+	// QoSAnnotationKey is user set Pod annotation to choose the QoS class of the GPU memory of this pod.
+	QoSAnnotationKey = "hami.io/qos"
+	// GuaranteedMemoryAnnotationKey is user set Pod annotation of the GPU memory in MB guaranteed to a burstable pod
+	// on each device, the rest of its memory request is opportunistic.
+	GuaranteedMemoryAnnotationKey = "hami.io/guaranteed-memory"
+	// GPUMemoryEachAnnotationKey is user set Pod annotation of comma-separated GPU memory in MB requested from each
+	// GPU of a container by index, e.g. "20480,10240" for the first GPU to have more memory than the second one.
+	GPUMemoryEachAnnotationKey = "hami.io/gpumem-each"
+	// SharedGPUAnnotationKey is user set Pod annotation of the memory and cores requested from the last GPU of each
+	// container requesting GPUs, e.g. "gpumem=4000,gpucores=30", the other GPUs of the container are allocated whole.
+	SharedGPUAnnotationKey = "hami.io/shared-gpu"
+	// DeviceIntentAnnotationKey records the device requests of the containers of a volcano pod requesting volcano vgpu
+	// resources, as the JSON list of the HAMi resource limits of each container. It's set by the webhook only.
+	DeviceIntentAnnotationKey = "hami.io/device-intent"
+	// AcceleratorVendorAnnotationKey records the vendor the webhook picked for the vendor agnostic device requests of a pod.
+	AcceleratorVendorAnnotationKey = "hami.io/accelerator-vendor"
+	// MinDriverVersionAnnotationKey is user set Pod annotation of the minimum driver version of the devices of this pod,
+	// e.g. "535.104.05".
+	MinDriverVersionAnnotationKey = "hami.io/min-driver-version"
+	// DevicePoolAnnotationKey is user set Pod annotation of the device pool the devices of this pod must be in, the
+	// pools are set by the device plugin configuration of each node.
+	DevicePoolAnnotationKey = "hami.io/device-pool"
+	// DeviceAffinityAnnotationKey is user set Pod label selector to only place this pod on devices hosting a matching pod.
+	DeviceAffinityAnnotationKey = "hami.io/device-affinity"
+	// DeviceAntiAffinityAnnotationKey is user set Pod label selector to keep this pod off devices hosting a matching pod.
+	DeviceAntiAffinityAnnotationKey = "hami.io/device-anti-affinity"
+	// WorkloadTypeLabelKey is user set Pod label of the type of the workload of this pod, e.g. "inference".
+	WorkloadTypeLabelKey = "hami.io/workload-type"
+	// IncompatibleTypesAnnotationKey is user set Pod annotation of comma-separated workload types, e.g. "training", this
+	// pod doesn't share devices with.
+	IncompatibleTypesAnnotationKey = "hami.io/incompatible-types"
+	// ReplicaSpreadAnnotationKey is user set Pod annotation to spread the replicas of this pod, the pods of the same
+	// controller, e.g. a ReplicaSet, across devices or nodes.
+	ReplicaSpreadAnnotationKey = "hami.io/replica-spread"
+	// VolumeLocalityAnnotationKey is user set Pod annotation to place this pod on the node its node-local volumes are on.
+	VolumeLocalityAnnotationKey = "hami.io/volume-locality"
+	// ContainerRuntimeAnnotationKey is user set Pod annotation of the container runtime, e.g. containerd, of the nodes
+	// to place this pod on. Nodes labeled with it run that runtime whatever their status reports.
+	ContainerRuntimeAnnotationKey = "hami.io/container-runtime"
+	// ContainerRuntimeAffinityAnnotationKey is user set Pod annotation of whether the container runtime of this pod
+	// is required or preferred.
+	ContainerRuntimeAffinityAnnotationKey = "hami.io/container-runtime-affinity"
+	// UtilizationProfileAnnotationKey is user set Pod annotation of the name of the utilization profile of this pod, whose
+	// peak usage is allocated instead of the device memory and cores requested by the pod.
+	UtilizationProfileAnnotationKey = "hami.io/utilization-profile"
+	// ScheduleAfterAnnotationKey is user set Pod annotation of the RFC3339 time before which this pod isn't scheduled.
+	ScheduleAfterAnnotationKey = "hami.io/schedule-after"
+	// ScheduleWindowAnnotationKey is user set Pod annotation of the daily window in UTC this pod is only scheduled in,
+	// e.g. "22:00-06:00" for off-peak hours, the window may wrap past midnight.
+	ScheduleWindowAnnotationKey = "hami.io/schedule-window"
+	// ScheduleWindowOpenedAnnotationKey records the time the schedule window of a deferred pod opened, updating the
+	// pod so the scheduler retries it. It's set by the scheduler only.
+	ScheduleWindowOpenedAnnotationKey = "hami.io/schedule-window-opened"
+	// OverriddenEnvsAnnotationKey records the environment variables owned by the device plugin the webhook removed
+	// from the containers of a pod, as the JSON map of their names by container. It's set by the webhook only.
+	OverriddenEnvsAnnotationKey = "hami.io/overridden-envs"
+	// PodGPUCountAnnotationKey, PodGPUMemoryAnnotationKey and PodGPUCoresAnnotationKey are user set Pod annotations of
+	// the GPUs requested by the pod as a whole instead of by its containers, e.g. for pods whose containers are injected.
+	// The request is attributed to the container set by PodGPUContainerAnnotationKey, the first container by default.
+	PodGPUCountAnnotationKey  = "hami.io/pod-gpu-count"
+	PodGPUMemoryAnnotationKey = "hami.io/pod-gpumem"
+	PodGPUCoresAnnotationKey  = "hami.io/pod-gpucores"
+	// PodGPUContainerAnnotationKey is user set Pod annotation of the name of the container requesting the pod level GPUs.
+	PodGPUContainerAnnotationKey = "hami.io/pod-gpu-container"
+	// PodGPUSidecarsAnnotationKey is user set Pod annotation of comma-separated names of containers seeing the pod level
+	// GPUs as well, without requesting any.
+	PodGPUSidecarsAnnotationKey = "hami.io/pod-gpu-sidecars"
+	// PodVisibleDevicesAnnotationKey records the UUIDs of the pod level GPUs, which the sidecars see by the downward API.
+	// It's set by the scheduler only.
+	PodVisibleDevicesAnnotationKey = "hami.io/pod-visible-devices"
+	// GPUResizedAnnotationKey records the time the GPUs of a running pod were last resized in place, for the monitor
+	// to apply the memory and cores of the allocation annotation to its containers. It's set by the scheduler only.
+	GPUResizedAnnotationKey = "hami.io/gpu-resized"
+	// DeviceResidencyDeadlineAnnotationKey records the RFC3339 time a pod approaching the max residency of its devices
+	// reaches it. It's set by the scheduler only.
+	DeviceResidencyDeadlineAnnotationKey = "hami.io/device-residency-deadline"
+	// RecommendedGPUMemoryAnnotationKey records the JSON map of the container names of a Deployment or StatefulSet to
+	// the device memory in MB per GPU recommended by their peak usage. It's set by the scheduler only.
+	RecommendedGPUMemoryAnnotationKey = "hami.io/recommended-gpumem"
+	// RequireECCAnnotationKey is user set Pod annotation of the ECC mode, "on" or "off", of the GPUs of this pod.
+	RequireECCAnnotationKey = "hami.io/require-ecc"
+	// ContiguousIndicesAnnotationKey is user set Pod annotation to allocate the GPUs of the pod with contiguous indices
+	// on the node, "true" or "preferred" to prefer them, "required" to only allocate them.
+	ContiguousIndicesAnnotationKey = "hami.io/contiguous-indices"
+	// SuggestedPlacementAnnotationKey is Pod annotation set by external placement optimizers to the node and the
+	// device UUIDs to place this pod on, e.g. node1:GPU-a,GPU-b, or only the node, e.g. node1.
+	SuggestedPlacementAnnotationKey = "hami.io/suggested-node-uuids"
+	// SuggestedPlacementModeAnnotationKey is user set Pod annotation of whether the placement suggested for this pod
+	// is preferred or strict.
+	SuggestedPlacementModeAnnotationKey = "hami.io/suggested-placement-mode"
+	// RebalanceLabelKey is user set Pod label to let the pod be reported for eviction when rescheduling it reduces device fragmentation.
+	RebalanceLabelKey = "hami.io/rebalance"
 )
 
+type ComputeMode string
+
+type IsolationLevel string
+
+type OOMMode string
+
+type GPUHealthPolicy string
+
+type QoSClass string
+
+type VolumeLocality string
+
+type ContainerRuntimeAffinity string
+
+type SuggestedPlacementMode string
+
+type OwnedEnvPolicy string
+
+type ReplicaSpread string
+
+type ContiguousIndices string
+
+type ECCMode string
+
+const (
+	// ComputeModeDefault shares GPUs with other pods by time slicing.
+	ComputeModeDefault ComputeMode = "default"
+	// ComputeModeMPS shares GPUs with other MPS pods by Multi-Process Service.
+	ComputeModeMPS ComputeMode = "mps"
+	// ComputeModeExclusive does not share GPUs with any other pod.
+	ComputeModeExclusive ComputeMode = "exclusive"
+
+	// IsolationStrict fences the GPU memory of the pod hard, allocations beyond its limit fail.
+	IsolationStrict IsolationLevel = "strict"
+	// IsolationShared enforces the GPU memory limit of the pod cooperatively, allocations beyond it succeed while
+	// the GPU has memory free.
+	IsolationShared IsolationLevel = "shared"
+
+	// OOMModeHard fails the GPU memory allocations of the container beyond its limit.
+	OOMModeHard OOMMode = "hard"
+	// OOMModeSoft lets the GPU memory allocations of the container beyond its limit succeed while the GPU has memory
+	// free, the monitor of the node flags the container exceeding its limit.
+	OOMModeSoft OOMMode = "soft"
+
+	// GPUHealthPolicyDefault excludes GPUs above the temperature threshold or with recent uncorrectable ECC errors.
+	GPUHealthPolicyDefault GPUHealthPolicy = "default"
+	// GPUHealthPolicyStrict excludes GPUs above the strict temperature threshold or with any uncorrectable ECC errors.
+	GPUHealthPolicyStrict GPUHealthPolicy = "strict"
+
+	// VolumeLocalityNone places the pod regardless of the nodes its volumes are on.
+	VolumeLocalityNone VolumeLocality = "none"
+	// VolumeLocalityPreferred prefers the nodes the node-local volumes of the pod are on.
+	VolumeLocalityPreferred VolumeLocality = "preferred"
+	// VolumeLocalityStrict only places the pod on the nodes the node-local volumes of the pod are on.
+	VolumeLocalityStrict VolumeLocality = "strict"
+
+	// ContainerRuntimeAffinityStrict only places the pod on the nodes running its container runtime.
+	ContainerRuntimeAffinityStrict ContainerRuntimeAffinity = "strict"
+	// ContainerRuntimeAffinityPreferred prefers the nodes running the container runtime of the pod.
+	ContainerRuntimeAffinityPreferred ContainerRuntimeAffinity = "preferred"
+
+	// SuggestedPlacementPreferred places the pod on the suggested node and devices if it fits them, as HAMi would
+	// otherwise.
+	SuggestedPlacementPreferred SuggestedPlacementMode = "preferred"
+	// SuggestedPlacementStrict only places the pod on the suggested node and devices.
+	SuggestedPlacementStrict SuggestedPlacementMode = "strict"
+
+	// OwnedEnvPolicyAllow admits pods setting environment variables owned by the device plugin unchanged.
+	OwnedEnvPolicyAllow OwnedEnvPolicy = "allow"
+	// OwnedEnvPolicyDeny denies pods setting environment variables owned by the device plugin.
+	OwnedEnvPolicyDeny OwnedEnvPolicy = "deny"
+	// OwnedEnvPolicyOverride removes environment variables owned by the device plugin from pods, so the values of
+	// the device plugin apply.
+	OwnedEnvPolicyOverride OwnedEnvPolicy = "override"
+
+	// ReplicaSpreadNone places the pod regardless of its replicas.
+	ReplicaSpreadNone ReplicaSpread = ""
+	// ReplicaSpreadDevice prefers the devices not used by the replicas of the pod.
+	ReplicaSpreadDevice ReplicaSpread = "device"
+	// ReplicaSpreadNode prefers the nodes with the fewest replicas of the pod.
+	ReplicaSpreadNode ReplicaSpread = "node"
+
+	// ContiguousIndicesNone allocates the GPUs of the pod regardless of their indices.
+	ContiguousIndicesNone ContiguousIndices = ""
+	// ContiguousIndicesPreferred prefers GPUs with contiguous indices, other GPUs are allocated if the node has none.
+	ContiguousIndicesPreferred ContiguousIndices = "preferred"
+	// ContiguousIndicesRequired only allocates GPUs with contiguous indices.
+	ContiguousIndicesRequired ContiguousIndices = "required"
+
+	// ECCModeOn is the mode of GPUs with ECC enabled.
+	ECCModeOn ECCMode = "on"
+	// ECCModeOff is the mode of GPUs with ECC disabled or not supporting it, whose memory is a little larger.
+	ECCModeOff ECCMode = "off"
+
+	// QoSGuaranteed reserves all the requested GPU memory of the pod.
+	QoSGuaranteed QoSClass = "guaranteed"
+	// QoSBurstable reserves the memory set by GuaranteedMemoryAnnotationKey only, the rest is opportunistic
+	// and can be reclaimed by guaranteed pods.
+	QoSBurstable QoSClass = "burstable"
+)
+
+// SharedGPU is the request of the shared GPU set by SharedGPUAnnotationKey. The memory is either in MB or in percent
+// of the memory of the GPU, the cores are in percent.
+type SharedGPU struct {
+	Mem           int32
+	MemPercentage int32
+	Cores         int32
+}
+
+// PodGPURequest is the pod level GPU request set by PodGPUCountAnnotationKey, PodGPUMemoryAnnotationKey and
+// PodGPUCoresAnnotationKey. Zero memory or cores leave them to the defaults of the container, Container is the
+// container the request is attributed to and Sidecars the containers seeing its GPUs.
+type PodGPURequest struct {
+	Count     int64
+	Mem       int64
+	Cores     int64
+	Container string
+	Sidecars  []string
+}
+
+// ScheduleWindow is the daily window set by ScheduleWindowAnnotationKey, as the offsets of its start and end from
+// midnight UTC. The window wraps past midnight if it starts after it ends.
+type ScheduleWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
 func (s SchedulerPolicyName) String() string {
 	return string(s)
 }
@@ -21,25 +21,32 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
-	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 )
 
 var (
 	HandshakeAnnos map[string]string
+	// RegisterAnnos are the node annotations the device plugins register devices in, by the vendors of
+	// HandshakeAnnos.
+	RegisterAnnos map[string]string
 )
 
 func init() {
 	HandshakeAnnos = make(map[string]string)
+	RegisterAnnos = make(map[string]string)
 }
 
 func GetNode(nodename string) (*corev1.Node, error) {
@@ -115,9 +122,9 @@ func GetAllocatePodByNode(ctx context.Context, nodeName string) (*corev1.Pod, er
 	if err != nil {
 		return nil, err
 	}
-	if value, ok := node.Annotations[nodelock.NodeLockKey]; ok {
-		klog.V(2).Infof("node annotation key is %s, value is %s ", nodelock.NodeLockKey, value)
-		_, ns, name, err := nodelock.ParseNodeLock(value)
+	if value, ok := node.Annotations[NodeLockKey]; ok {
+		klog.V(2).Infof("node annotation key is %s, value is %s ", NodeLockKey, value)
+		_, ns, name, err := ParseNodeLock(value)
 		if err != nil {
 			return nil, err
 		}
@@ -129,23 +136,31 @@ func GetAllocatePodByNode(ctx context.Context, nodeName string) (*corev1.Pod, er
 	return nil, nil
 }
 
-func PatchNodeAnnotations(node *corev1.Node, annotations map[string]string) error {
-	type patchMetadata struct {
-		Annotations map[string]string `json:"annotations,omitempty"`
+// ParseNodeLock parses the value of the NodeLockKey annotation into the time the node was locked and the namespace
+// and name of the pod locking it, empty for locks not set by a pod.
+func ParseNodeLock(value string) (lockTime time.Time, ns, name string, err error) {
+	if !strings.Contains(value, NodeLockSep) {
+		lockTime, err = time.Parse(time.RFC3339, value)
+		return lockTime, "", "", err
 	}
-	type patchNode struct {
-		Metadata patchMetadata `json:"metadata"`
+	s := strings.Split(value, NodeLockSep)
+	if len(s) != 3 {
+		lockTime, err = time.Parse(time.RFC3339, value)
+		return lockTime, "", "", err
 	}
+	lockTime, err = time.Parse(time.RFC3339, s[0])
+	return lockTime, s[1], s[2], err
+}
 
-	p := patchNode{}
-	p.Metadata.Annotations = annotations
-
-	bytes, err := json.Marshal(p)
-	if err != nil {
-		return err
-	}
-	_, err = client.GetClient().CoreV1().Nodes().
-		Patch(context.Background(), node.Name, k8stypes.MergePatchType, bytes, metav1.PatchOptions{})
+// PatchNodeAnnotations sets the annotations on the node by PatchAnnotationsWithRetry.
+func PatchNodeAnnotations(node *corev1.Node, annotations map[string]string) error {
+	err := PatchAnnotationsWithRetry(context.Background(), client.GetClient(), NodeRef(node.Name), func(meta *metav1.ObjectMeta) error {
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, len(annotations))
+		}
+		maps.Copy(meta.Annotations, annotations)
+		return nil
+	})
 	if err != nil {
 		klog.Infoln("annotations=", annotations)
 		klog.Infof("patch node %v failed, %v", node.Name, err)
@@ -153,30 +168,25 @@ func PatchNodeAnnotations(node *corev1.Node, annotations map[string]string) erro
 	return err
 }
 
+// PatchPodAnnotations sets the annotations on the pod by PatchAnnotationsWithRetry, and the label of
+// AssignedNodeAnnotations along its annotation. The pod is read before each patch to condition it on its
+// resourceVersion, so every call, e.g. each Bind of the scheduler and Allocate of the device plugins, costs a GET
+// of the pod on top of the patch, and a GET and a patch more per conflict retried.
 func PatchPodAnnotations(pod *corev1.Pod, annotations map[string]string) error {
-	type patchMetadata struct {
-		Annotations map[string]string `json:"annotations,omitempty"`
-		Labels      map[string]string `json:"labels,omitempty"`
-	}
-	type patchPod struct {
-		Metadata patchMetadata `json:"metadata"`
-	}
-
-	p := patchPod{}
-	p.Metadata.Annotations = annotations
-	label := make(map[string]string)
-	if v, ok := annotations[AssignedNodeAnnotations]; ok && v != "" {
-		label[AssignedNodeAnnotations] = v
-		p.Metadata.Labels = label
-	}
-
-	bytes, err := json.Marshal(p)
-	if err != nil {
-		return err
-	}
-	klog.V(5).Infof("patch pod %s/%s annotation content is %s", pod.Namespace, pod.Name, string(bytes))
-	_, err = client.GetClient().CoreV1().Pods(pod.Namespace).
-		Patch(context.Background(), pod.Name, k8stypes.MergePatchType, bytes, metav1.PatchOptions{})
+	klog.V(5).Infof("patch pod %s/%s annotations %v", pod.Namespace, pod.Name, annotations)
+	err := PatchAnnotationsWithRetry(context.Background(), client.GetClient(), PodRef(pod.Namespace, pod.Name), func(meta *metav1.ObjectMeta) error {
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string, len(annotations))
+		}
+		maps.Copy(meta.Annotations, annotations)
+		if v, ok := annotations[AssignedNodeAnnotations]; ok && v != "" {
+			if meta.Labels == nil {
+				meta.Labels = make(map[string]string, 1)
+			}
+			meta.Labels[AssignedNodeAnnotations] = v
+		}
+		return nil
+	})
 	if err != nil {
 		klog.Infof("patch pod %v failed, %v", pod.Name, err)
 	}
@@ -191,9 +201,23 @@ func InitKlogFlags() *flag.FlagSet {
 	return flagset
 }
 
+// FormatHandshakeTime formats the time of a handshake annotation as RFC3339 in UTC.
+func FormatHandshakeTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseHandshakeTime parses the time of a handshake annotation, RFC3339 or the legacy format without zone
+// written in the local time of the writer, which is assumed to share the zone of the reader.
+func ParseHandshakeTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateTime, value, time.Local)
+}
+
 func MarkAnnotationsToDelete(devType string, nn string) error {
 	tmppat := make(map[string]string)
-	tmppat[devType] = "Deleted_" + time.Now().Format(time.DateTime)
+	tmppat[devType] = "Deleted_" + FormatHandshakeTime(time.Now())
 	n, err := GetNode(nn)
 	if err != nil {
 		klog.Errorln("get node failed", err.Error())
@@ -212,6 +236,596 @@ func GetGPUSchedulerPolicyByPod(defaultPolicy string, task *corev1.Pod) string {
 	return userGPUPolicy
 }
 
+// IsPodDebugEnabled reports whether the pod asks for scheduler trace logs by DebugAnnotationKey.
+func IsPodDebugEnabled(pod *corev1.Pod) bool {
+	if pod == nil || pod.Annotations == nil {
+		return false
+	}
+	enabled, err := strconv.ParseBool(pod.Annotations[DebugAnnotationKey])
+	return err == nil && enabled
+}
+
+// IsShareGPUWithinPod reports whether the containers of the pod share devices by ShareGPUWithinPodAnnotationKey.
+func IsShareGPUWithinPod(pod *corev1.Pod) bool {
+	if pod == nil || pod.Annotations == nil {
+		return false
+	}
+	shared, err := strconv.ParseBool(pod.Annotations[ShareGPUWithinPodAnnotationKey])
+	return err == nil && shared
+}
+
+// AllowsMemoryOversubscription reports whether the pod may use oversubscribed device memory by
+// AllowMemoryOversubscriptionAnnotationKey.
+func AllowsMemoryOversubscription(pod *corev1.Pod) bool {
+	if pod == nil || pod.Annotations == nil {
+		return false
+	}
+	allowed, err := strconv.ParseBool(pod.Annotations[AllowMemoryOversubscriptionAnnotationKey])
+	return err == nil && allowed
+}
+
+// RequiresVerifiedDevices reports whether the pod may only be allocated verified devices by
+// RequireVerifiedAnnotationKey.
+func RequiresVerifiedDevices(pod *corev1.Pod) bool {
+	if pod == nil || pod.Annotations == nil {
+		return false
+	}
+	required, err := strconv.ParseBool(pod.Annotations[RequireVerifiedAnnotationKey])
+	return err == nil && required
+}
+
+// GetGPUModelFallback returns the GPU models set by GPUModelFallbackAnnotationKey in order of preference.
+func GetGPUModelFallback(pod *corev1.Pod) []string {
+	if pod == nil || pod.Annotations == nil {
+		return nil
+	}
+	var models []string
+	for _, model := range strings.Split(pod.Annotations[GPUModelFallbackAnnotationKey], ">") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// GetComputeMode returns the compute mode set by ComputeModeAnnotationKey, ComputeModeDefault if not set.
+func GetComputeMode(pod *corev1.Pod) (ComputeMode, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[ComputeModeAnnotationKey] == "" {
+		return ComputeModeDefault, nil
+	}
+	switch mode := ComputeMode(pod.Annotations[ComputeModeAnnotationKey]); mode {
+	case ComputeModeDefault, ComputeModeMPS, ComputeModeExclusive:
+		return mode, nil
+	default:
+		return ComputeModeDefault, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s, %s",
+			ComputeModeAnnotationKey, mode, ComputeModeDefault, ComputeModeMPS, ComputeModeExclusive)
+	}
+}
+
+// GetIsolationLevel returns the isolation level set by IsolationAnnotationKey, IsolationStrict if not set.
+func GetIsolationLevel(pod *corev1.Pod) (IsolationLevel, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[IsolationAnnotationKey] == "" {
+		return IsolationStrict, nil
+	}
+	switch level := IsolationLevel(pod.Annotations[IsolationAnnotationKey]); level {
+	case IsolationStrict, IsolationShared:
+		return level, nil
+	default:
+		return IsolationStrict, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s",
+			IsolationAnnotationKey, level, IsolationStrict, IsolationShared)
+	}
+}
+
+// GetOOMModes returns the OOM mode of each container of the pod set by OOMModeAnnotationKey, either a mode for all
+// containers or comma-separated <container>=<mode> entries. Containers not listed are OOMModeHard.
+func GetOOMModes(pod *corev1.Pod) (map[string]OOMMode, error) {
+	res := make(map[string]OOMMode)
+	if pod == nil {
+		return res, nil
+	}
+	for _, ctr := range pod.Spec.Containers {
+		res[ctr.Name] = OOMModeHard
+	}
+	value := strings.TrimSpace(pod.Annotations[OOMModeAnnotationKey])
+	if value == "" {
+		return res, nil
+	}
+	if !strings.Contains(value, "=") {
+		mode, err := parseOOMMode(value)
+		if err != nil {
+			return res, err
+		}
+		for name := range res {
+			res[name] = mode
+		}
+		return res, nil
+	}
+	for entry := range strings.SplitSeq(value, ",") {
+		name, modeValue, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return res, fmt.Errorf("invalid %s annotation entry %q, must be <container>=<mode>", OOMModeAnnotationKey, entry)
+		}
+		if _, ok := res[name]; !ok {
+			return res, fmt.Errorf("invalid %s annotation, the pod has no container %q", OOMModeAnnotationKey, name)
+		}
+		mode, err := parseOOMMode(modeValue)
+		if err != nil {
+			return res, err
+		}
+		res[name] = mode
+	}
+	return res, nil
+}
+
+func parseOOMMode(value string) (OOMMode, error) {
+	switch mode := OOMMode(value); mode {
+	case OOMModeHard, OOMModeSoft:
+		return mode, nil
+	default:
+		return OOMModeHard, fmt.Errorf("invalid %s annotation mode %q, must be one of %s, %s",
+			OOMModeAnnotationKey, mode, OOMModeHard, OOMModeSoft)
+	}
+}
+
+// GetGPUHealthPolicy returns the GPU health policy set by GPUHealthPolicyAnnotationKey, GPUHealthPolicyDefault if not set.
+func GetGPUHealthPolicy(pod *corev1.Pod) (GPUHealthPolicy, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[GPUHealthPolicyAnnotationKey] == "" {
+		return GPUHealthPolicyDefault, nil
+	}
+	switch policy := GPUHealthPolicy(pod.Annotations[GPUHealthPolicyAnnotationKey]); policy {
+	case GPUHealthPolicyDefault, GPUHealthPolicyStrict:
+		return policy, nil
+	default:
+		return GPUHealthPolicyDefault, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s",
+			GPUHealthPolicyAnnotationKey, policy, GPUHealthPolicyDefault, GPUHealthPolicyStrict)
+	}
+}
+
+// GetQoSClass returns the QoS class set by QoSAnnotationKey, QoSGuaranteed if not set.
+func GetQoSClass(pod *corev1.Pod) (QoSClass, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[QoSAnnotationKey] == "" {
+		return QoSGuaranteed, nil
+	}
+	switch qos := QoSClass(pod.Annotations[QoSAnnotationKey]); qos {
+	case QoSGuaranteed, QoSBurstable:
+		return qos, nil
+	default:
+		return QoSGuaranteed, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s",
+			QoSAnnotationKey, qos, QoSGuaranteed, QoSBurstable)
+	}
+}
+
+// GetVolumeLocality returns the volume locality set by VolumeLocalityAnnotationKey, defaultLocality if not set.
+func GetVolumeLocality(pod *corev1.Pod, defaultLocality VolumeLocality) (VolumeLocality, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[VolumeLocalityAnnotationKey] == "" {
+		return defaultLocality, nil
+	}
+	switch locality := VolumeLocality(pod.Annotations[VolumeLocalityAnnotationKey]); locality {
+	case VolumeLocalityNone, VolumeLocalityPreferred, VolumeLocalityStrict:
+		return locality, nil
+	default:
+		return defaultLocality, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s, %s",
+			VolumeLocalityAnnotationKey, locality, VolumeLocalityNone, VolumeLocalityPreferred, VolumeLocalityStrict)
+	}
+}
+
+// GetContainerRuntimeAffinity returns the container runtime affinity set by ContainerRuntimeAffinityAnnotationKey,
+// defaultAffinity if not set.
+func GetContainerRuntimeAffinity(pod *corev1.Pod, defaultAffinity ContainerRuntimeAffinity) (ContainerRuntimeAffinity, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[ContainerRuntimeAffinityAnnotationKey] == "" {
+		return defaultAffinity, nil
+	}
+	switch affinity := ContainerRuntimeAffinity(pod.Annotations[ContainerRuntimeAffinityAnnotationKey]); affinity {
+	case ContainerRuntimeAffinityStrict, ContainerRuntimeAffinityPreferred:
+		return affinity, nil
+	default:
+		return defaultAffinity, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s",
+			ContainerRuntimeAffinityAnnotationKey, affinity, ContainerRuntimeAffinityStrict, ContainerRuntimeAffinityPreferred)
+	}
+}
+
+// SuggestedPlacement is the node and devices suggested for a pod by SuggestedPlacementAnnotationKey, any device of
+// the node if UUIDs is empty.
+type SuggestedPlacement struct {
+	Node  string
+	UUIDs []string
+}
+
+// GetSuggestedPlacement returns the placement suggested by SuggestedPlacementAnnotationKey, nil if not set.
+func GetSuggestedPlacement(pod *corev1.Pod) (*SuggestedPlacement, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[SuggestedPlacementAnnotationKey] == "" {
+		return nil, nil
+	}
+	value := pod.Annotations[SuggestedPlacementAnnotationKey]
+	node, uuids, _ := strings.Cut(value, ":")
+	node = strings.TrimSpace(node)
+	if node == "" {
+		return nil, fmt.Errorf("invalid %s annotation %q, must be <node>[:<uuid>,...]", SuggestedPlacementAnnotationKey, value)
+	}
+	res := &SuggestedPlacement{Node: node}
+	for _, uuid := range strings.Split(uuids, ",") {
+		if uuid = strings.TrimSpace(uuid); uuid != "" {
+			res.UUIDs = append(res.UUIDs, uuid)
+		}
+	}
+	return res, nil
+}
+
+// GetSuggestedPlacementMode returns the mode set by SuggestedPlacementModeAnnotationKey, defaultMode if not set.
+func GetSuggestedPlacementMode(pod *corev1.Pod, defaultMode SuggestedPlacementMode) (SuggestedPlacementMode, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[SuggestedPlacementModeAnnotationKey] == "" {
+		return defaultMode, nil
+	}
+	switch mode := SuggestedPlacementMode(pod.Annotations[SuggestedPlacementModeAnnotationKey]); mode {
+	case SuggestedPlacementPreferred, SuggestedPlacementStrict:
+		return mode, nil
+	default:
+		return defaultMode, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s",
+			SuggestedPlacementModeAnnotationKey, mode, SuggestedPlacementPreferred, SuggestedPlacementStrict)
+	}
+}
+
+// NodeContainerRuntime returns the container runtime of the node, the ContainerRuntimeAnnotationKey label if set,
+// the runtime of its status otherwise, e.g. containerd for containerd://1.7.2.
+func NodeContainerRuntime(node *corev1.Node) string {
+	if runtime, ok := node.Labels[ContainerRuntimeAnnotationKey]; ok {
+		return runtime
+	}
+	runtime, _, _ := strings.Cut(node.Status.NodeInfo.ContainerRuntimeVersion, "://")
+	return runtime
+}
+
+// GetReplicaSpread returns the replica spread set by ReplicaSpreadAnnotationKey, ReplicaSpreadNone if not set.
+func GetReplicaSpread(pod *corev1.Pod) (ReplicaSpread, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[ReplicaSpreadAnnotationKey] == "" {
+		return ReplicaSpreadNone, nil
+	}
+	switch spread := ReplicaSpread(pod.Annotations[ReplicaSpreadAnnotationKey]); spread {
+	case ReplicaSpreadDevice, ReplicaSpreadNode:
+		return spread, nil
+	default:
+		return ReplicaSpreadNone, fmt.Errorf("invalid %s annotation %q, must be one of %s, %s",
+			ReplicaSpreadAnnotationKey, spread, ReplicaSpreadDevice, ReplicaSpreadNode)
+	}
+}
+
+// GetContiguousIndices returns the contiguous indices mode set by ContiguousIndicesAnnotationKey, ContiguousIndicesNone
+// if not set or "false", "true" is ContiguousIndicesPreferred.
+func GetContiguousIndices(pod *corev1.Pod) (ContiguousIndices, error) {
+	if pod == nil || pod.Annotations == nil {
+		return ContiguousIndicesNone, nil
+	}
+	switch v := pod.Annotations[ContiguousIndicesAnnotationKey]; v {
+	case "", "false":
+		return ContiguousIndicesNone, nil
+	case "true", string(ContiguousIndicesPreferred):
+		return ContiguousIndicesPreferred, nil
+	case string(ContiguousIndicesRequired):
+		return ContiguousIndicesRequired, nil
+	default:
+		return ContiguousIndicesNone, fmt.Errorf("invalid %s annotation %q, must be one of true, false, %s, %s",
+			ContiguousIndicesAnnotationKey, v, ContiguousIndicesPreferred, ContiguousIndicesRequired)
+	}
+}
+
+// GetRequiredECCMode returns the ECC mode set by RequireECCAnnotationKey, empty if not set.
+func GetRequiredECCMode(pod *corev1.Pod) (ECCMode, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[RequireECCAnnotationKey] == "" {
+		return "", nil
+	}
+	switch mode := ECCMode(pod.Annotations[RequireECCAnnotationKey]); mode {
+	case ECCModeOn, ECCModeOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation %q, must be one of %s, %s", RequireECCAnnotationKey, mode, ECCModeOn, ECCModeOff)
+	}
+}
+
+// GetScheduleAfter returns the time set by ScheduleAfterAnnotationKey, the zero time if not set.
+func GetScheduleAfter(pod *corev1.Pod) (time.Time, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[ScheduleAfterAnnotationKey] == "" {
+		return time.Time{}, nil
+	}
+	v := pod.Annotations[ScheduleAfterAnnotationKey]
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s annotation %q, must be an RFC3339 time", ScheduleAfterAnnotationKey, v)
+	}
+	return t, nil
+}
+
+// GetScheduleWindow returns the daily window set by ScheduleWindowAnnotationKey, nil if not set.
+func GetScheduleWindow(pod *corev1.Pod) (*ScheduleWindow, error) {
+	if pod == nil || pod.Annotations == nil || strings.TrimSpace(pod.Annotations[ScheduleWindowAnnotationKey]) == "" {
+		return nil, nil
+	}
+	v := pod.Annotations[ScheduleWindowAnnotationKey]
+	invalid := fmt.Errorf("invalid %s annotation %q, must be a daily window in UTC like 22:00-06:00", ScheduleWindowAnnotationKey, v)
+	start, end, ok := strings.Cut(strings.TrimSpace(v), "-")
+	if !ok {
+		return nil, invalid
+	}
+	offset := func(value string) (time.Duration, bool) {
+		t, err := time.Parse("15:04", strings.TrimSpace(value))
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+	}
+	res := &ScheduleWindow{}
+	if res.Start, ok = offset(start); !ok {
+		return nil, invalid
+	}
+	if res.End, ok = offset(end); !ok || res.Start == res.End {
+		return nil, invalid
+	}
+	return res, nil
+}
+
+// NextOpen returns the first time at or after t the window is open, t itself if it's open at t.
+func (w ScheduleWindow) NextOpen(t time.Time) time.Time {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := t.Sub(midnight)
+	if w.Start < w.End && offset >= w.Start && offset < w.End ||
+		w.Start > w.End && (offset >= w.Start || offset < w.End) {
+		return t
+	}
+	if offset < w.Start {
+		return midnight.Add(w.Start)
+	}
+	return midnight.AddDate(0, 0, 1).Add(w.Start)
+}
+
+// GetGuaranteedMemory returns the memory per device set by GuaranteedMemoryAnnotationKey, 0 if not set.
+func GetGuaranteedMemory(pod *corev1.Pod) (int32, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[GuaranteedMemoryAnnotationKey] == "" {
+		return 0, nil
+	}
+	v := pod.Annotations[GuaranteedMemoryAnnotationKey]
+	mem, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || mem < 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q, must be a non-negative memory in MB", GuaranteedMemoryAnnotationKey, v)
+	}
+	return int32(mem), nil
+}
+
+// GetGPUMemoryEach returns the memory of each GPU by index set by GPUMemoryEachAnnotationKey, nil if not set.
+func GetGPUMemoryEach(pod *corev1.Pod) ([]int32, error) {
+	if pod == nil || pod.Annotations == nil || strings.TrimSpace(pod.Annotations[GPUMemoryEachAnnotationKey]) == "" {
+		return nil, nil
+	}
+	v := pod.Annotations[GPUMemoryEachAnnotationKey]
+	items := strings.Split(v, ",")
+	res := make([]int32, 0, len(items))
+	for _, item := range items {
+		mem, err := strconv.ParseInt(strings.TrimSpace(item), 10, 32)
+		if err != nil || mem <= 0 {
+			return nil, fmt.Errorf("invalid %s annotation %q, must be comma-separated positive memory in MB", GPUMemoryEachAnnotationKey, v)
+		}
+		res = append(res, int32(mem))
+	}
+	return res, nil
+}
+
+// GetDeviceIntent returns the HAMi resource limits of each container recorded by DeviceIntentAnnotationKey, nil if
+// not set.
+func GetDeviceIntent(pod *corev1.Pod) ([]corev1.ResourceList, error) {
+	if pod == nil || pod.Annotations == nil || pod.Annotations[DeviceIntentAnnotationKey] == "" {
+		return nil, nil
+	}
+	var intent []corev1.ResourceList
+	if err := json.Unmarshal([]byte(pod.Annotations[DeviceIntentAnnotationKey]), &intent); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", DeviceIntentAnnotationKey, err)
+	}
+	return intent, nil
+}
+
+// GetSharedGPU returns the request of the shared GPU set by SharedGPUAnnotationKey, nil if not set.
+func GetSharedGPU(pod *corev1.Pod) (*SharedGPU, error) {
+	if pod == nil || pod.Annotations == nil || strings.TrimSpace(pod.Annotations[SharedGPUAnnotationKey]) == "" {
+		return nil, nil
+	}
+	v := pod.Annotations[SharedGPUAnnotationKey]
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid %s annotation %q, %s", SharedGPUAnnotationKey, v, reason)
+	}
+	res := &SharedGPU{}
+	for _, item := range strings.Split(v, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, invalid("must be comma-separated key=value pairs of gpumem, gpumem-percentage and gpucores")
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return nil, invalid(fmt.Sprintf("%s must be an integer", key))
+		}
+		switch strings.TrimSpace(key) {
+		case "gpumem":
+			if n <= 0 {
+				return nil, invalid("gpumem must be a positive memory in MB")
+			}
+			res.Mem = int32(n)
+		case "gpumem-percentage":
+			if n <= 0 || n > 100 {
+				return nil, invalid("gpumem-percentage must be between 1 and 100")
+			}
+			res.MemPercentage = int32(n)
+		case "gpucores":
+			if n < 0 || n > 100 {
+				return nil, invalid("gpucores must be between 0 and 100")
+			}
+			res.Cores = int32(n)
+		default:
+			return nil, invalid(fmt.Sprintf("unknown key %s", key))
+		}
+	}
+	if (res.Mem == 0) == (res.MemPercentage == 0) {
+		return nil, invalid("exactly one of gpumem and gpumem-percentage must be set")
+	}
+	return res, nil
+}
+
+// GetPodGPURequest returns the pod level GPU request set by PodGPUCountAnnotationKey, PodGPUMemoryAnnotationKey and
+// PodGPUCoresAnnotationKey, nil if none is set. The count defaults to 1.
+func GetPodGPURequest(pod *corev1.Pod) (*PodGPURequest, error) {
+	if pod == nil || pod.Annotations == nil {
+		return nil, nil
+	}
+	res := &PodGPURequest{Count: 1}
+	set := false
+	for _, item := range []struct {
+		key  string
+		min  int64
+		max  int64
+		dest *int64
+	}{
+		{key: PodGPUCountAnnotationKey, min: 1, max: math.MaxInt32, dest: &res.Count},
+		{key: PodGPUMemoryAnnotationKey, min: 1, max: math.MaxInt32, dest: &res.Mem},
+		{key: PodGPUCoresAnnotationKey, min: 0, max: 100, dest: &res.Cores},
+	} {
+		v, ok := pod.Annotations[item.key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil || n < item.min || n > item.max {
+			return nil, fmt.Errorf("invalid %s annotation %q, must be an integer from %d to %d", item.key, v, item.min, item.max)
+		}
+		*item.dest, set = n, true
+	}
+	if !set {
+		for _, key := range []string{PodGPUContainerAnnotationKey, PodGPUSidecarsAnnotationKey} {
+			if _, ok := pod.Annotations[key]; ok {
+				return nil, fmt.Errorf("%s is set without a pod level GPU request", key)
+			}
+		}
+		return nil, nil
+	}
+	containerExists := func(name string) bool {
+		return slices.ContainsFunc(pod.Spec.Containers, func(ctr corev1.Container) bool { return ctr.Name == name })
+	}
+	res.Container = strings.TrimSpace(pod.Annotations[PodGPUContainerAnnotationKey])
+	if res.Container == "" && len(pod.Spec.Containers) > 0 {
+		res.Container = pod.Spec.Containers[0].Name
+	}
+	if !containerExists(res.Container) {
+		return nil, fmt.Errorf("invalid %s annotation %q, no such container", PodGPUContainerAnnotationKey, res.Container)
+	}
+	for _, name := range strings.Split(pod.Annotations[PodGPUSidecarsAnnotationKey], ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if name == res.Container || !containerExists(name) {
+			return nil, fmt.Errorf("invalid %s annotation, %q must be another container of the pod", PodGPUSidecarsAnnotationKey, name)
+		}
+		res.Sidecars = append(res.Sidecars, name)
+	}
+	return res, nil
+}
+
+// GetMinDriverVersion returns the minimum driver version set by MinDriverVersionAnnotationKey, empty if not set.
+func GetMinDriverVersion(pod *corev1.Pod) (string, error) {
+	if pod == nil || pod.Annotations == nil || strings.TrimSpace(pod.Annotations[MinDriverVersionAnnotationKey]) == "" {
+		return "", nil
+	}
+	v := strings.TrimSpace(pod.Annotations[MinDriverVersionAnnotationKey])
+	if _, err := parseDriverVersion(v); err != nil {
+		return "", fmt.Errorf("invalid %s annotation %q, %v", MinDriverVersionAnnotationKey, v, err)
+	}
+	return v, nil
+}
+
+// CompareDriverVersions compares the driver versions a and b by their dot-separated numeric components, e.g.
+// "535.104.05" and "535.54.03", returning -1, 0 or 1 as a is older than, the same as or newer than b. Leading
+// zeros of the components are ignored, and missing components are 0, so "535" is the same as "535.0.0".
+func CompareDriverVersions(a, b string) (int, error) {
+	va, err := parseDriverVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseDriverVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range max(len(va), len(vb)) {
+		ca, cb := uint64(0), uint64(0)
+		if i < len(va) {
+			ca = va[i]
+		}
+		if i < len(vb) {
+			cb = vb[i]
+		}
+		if ca != cb {
+			if ca < cb {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseDriverVersion(v string) ([]uint64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, fmt.Errorf("empty driver version")
+	}
+	parts := strings.Split(v, ".")
+	res := make([]uint64, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("driver version %q must be dot-separated numbers", v)
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+// GetDeviceAffinity returns the label selector set by DeviceAffinityAnnotationKey, nil if not set.
+func GetDeviceAffinity(pod *corev1.Pod) (labels.Selector, error) {
+	return getDeviceSelector(pod, DeviceAffinityAnnotationKey)
+}
+
+// GetDeviceAntiAffinity returns the label selector set by DeviceAntiAffinityAnnotationKey, nil if not set.
+func GetDeviceAntiAffinity(pod *corev1.Pod) (labels.Selector, error) {
+	return getDeviceSelector(pod, DeviceAntiAffinityAnnotationKey)
+}
+
+// GetWorkloadType returns the workload type set by WorkloadTypeLabelKey, empty if not set.
+func GetWorkloadType(pod *corev1.Pod) string {
+	if pod == nil {
+		return ""
+	}
+	return strings.TrimSpace(pod.Labels[WorkloadTypeLabelKey])
+}
+
+// GetIncompatibleTypes returns the workload types set by IncompatibleTypesAnnotationKey.
+func GetIncompatibleTypes(pod *corev1.Pod) []string {
+	if pod == nil || pod.Annotations == nil {
+		return nil
+	}
+	var types []string
+	for _, t := range strings.Split(pod.Annotations[IncompatibleTypesAnnotationKey], ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func getDeviceSelector(pod *corev1.Pod, key string) (labels.Selector, error) {
+	if pod == nil || pod.Annotations == nil || strings.TrimSpace(pod.Annotations[key]) == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(pod.Annotations[key])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation %q, must be a label selector: %v", key, pod.Annotations[key], err)
+	}
+	return selector, nil
+}
+
 func IsPodInTerminatedState(pod *corev1.Pod) bool {
 	return pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded
 }
@@ -219,3 +833,15 @@ func IsPodInTerminatedState(pod *corev1.Pod) bool {
 func AllContainersCreated(pod *corev1.Pod) bool {
 	return len(pod.Status.ContainerStatuses) >= len(pod.Spec.Containers)
 }
+
+// SetContainerEnv sets the env var name of the container to value. Any entries of name are replaced, so
+// mutating a container again, e.g. when a mutated pod is resubmitted, doesn't duplicate them.
+func SetContainerEnv(ctr *corev1.Container, name, value string) {
+	env := ctr.Env[:0]
+	for _, e := range ctr.Env {
+		if e.Name != name {
+			env = append(env, e)
+		}
+	}
+	ctr.Env = append(env, corev1.EnvVar{Name: name, Value: value})
+}
@@ -19,18 +19,25 @@ package util
 import (
 	"context"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
-	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
 )
 
 var inRequestDevices map[string]string
 
+// nodeLockValue returns the value of the NodeLockKey annotation of a node locked by the pod now.
+func nodeLockValue(pod *corev1.Pod) string {
+	return time.Now().Format(time.RFC3339) + NodeLockSep + pod.Namespace + NodeLockSep + pod.Name
+}
+
 func init() {
 	inRequestDevices = make(map[string]string)
 	inRequestDevices["NVIDIA"] = "hami.io/vgpu-devices-to-allocate"
@@ -190,7 +197,7 @@ func TestGetPendingPod(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "test-node-1",
 			Annotations: map[string]string{
-				nodelock.NodeLockKey: nodelock.GenerateNodeLockKeyByPod(allocatedPod),
+				NodeLockKey: nodeLockValue(allocatedPod),
 			},
 		},
 	}
@@ -262,7 +269,7 @@ func TestGetAllocatePodByNode(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "test-node-1",
 			Annotations: map[string]string{
-				nodelock.NodeLockKey: nodelock.GenerateNodeLockKeyByPod(emptyPod),
+				NodeLockKey: nodeLockValue(emptyPod),
 			},
 		},
 	}
@@ -272,7 +279,7 @@ func TestGetAllocatePodByNode(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "test-node-2",
 			Annotations: map[string]string{
-				nodelock.NodeLockKey: nodelock.GenerateNodeLockKeyByPod(pod),
+				NodeLockKey: nodeLockValue(pod),
 			},
 		},
 	}
@@ -517,3 +524,617 @@ func Test_AllContainersCreated(t *testing.T) {
 		})
 	}
 }
+
+func TestIsPodDebugEnabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		annos map[string]string
+		want  bool
+	}{
+		{name: "no annotations", annos: nil, want: false},
+		{name: "enabled", annos: map[string]string{DebugAnnotationKey: "true"}, want: true},
+		{name: "disabled", annos: map[string]string{DebugAnnotationKey: "false"}, want: false},
+		{name: "invalid value", annos: map[string]string{DebugAnnotationKey: "yes please"}, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			assert.Equal(t, test.want, IsPodDebugEnabled(pod))
+		})
+	}
+	assert.Equal(t, false, IsPodDebugEnabled(nil))
+}
+
+func TestGetComputeMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    ComputeMode
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: ComputeModeDefault},
+		{name: "empty value", annos: map[string]string{ComputeModeAnnotationKey: ""}, want: ComputeModeDefault},
+		{name: "default", annos: map[string]string{ComputeModeAnnotationKey: "default"}, want: ComputeModeDefault},
+		{name: "mps", annos: map[string]string{ComputeModeAnnotationKey: "mps"}, want: ComputeModeMPS},
+		{name: "exclusive", annos: map[string]string{ComputeModeAnnotationKey: "exclusive"}, want: ComputeModeExclusive},
+		{name: "invalid value", annos: map[string]string{ComputeModeAnnotationKey: "MPS"}, want: ComputeModeDefault, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			mode, err := GetComputeMode(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, mode)
+		})
+	}
+	mode, err := GetComputeMode(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, ComputeModeDefault, mode)
+}
+
+func TestGetIsolationLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    IsolationLevel
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: IsolationStrict},
+		{name: "empty value", annos: map[string]string{IsolationAnnotationKey: ""}, want: IsolationStrict},
+		{name: "strict", annos: map[string]string{IsolationAnnotationKey: "strict"}, want: IsolationStrict},
+		{name: "shared", annos: map[string]string{IsolationAnnotationKey: "shared"}, want: IsolationShared},
+		{name: "invalid value", annos: map[string]string{IsolationAnnotationKey: "soft"}, want: IsolationStrict, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			level, err := GetIsolationLevel(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, level)
+		})
+	}
+}
+
+func TestGetOOMModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]OOMMode
+		wantErr bool
+	}{
+		{name: "not set", want: map[string]OOMMode{"train": OOMModeHard, "sidecar": OOMModeHard}},
+		{name: "all containers", value: "soft", want: map[string]OOMMode{"train": OOMModeSoft, "sidecar": OOMModeSoft}},
+		{name: "by container", value: "train=soft, sidecar=hard", want: map[string]OOMMode{"train": OOMModeSoft, "sidecar": OOMModeHard}},
+		{name: "containers not listed", value: "train=soft", want: map[string]OOMMode{"train": OOMModeSoft, "sidecar": OOMModeHard}},
+		{name: "invalid mode", value: "lenient", wantErr: true},
+		{name: "invalid container mode", value: "train=lenient", wantErr: true},
+		{name: "unknown container", value: "infer=soft", wantErr: true},
+		{name: "mode without container", value: "train=soft,hard", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{OOMModeAnnotationKey: test.value}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "train"}, {Name: "sidecar"}}},
+			}
+			modes, err := GetOOMModes(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			if !test.wantErr {
+				assert.DeepEqual(t, test.want, modes)
+			}
+		})
+	}
+}
+
+func TestGetGPUHealthPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    GPUHealthPolicy
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: GPUHealthPolicyDefault},
+		{name: "empty value", annos: map[string]string{GPUHealthPolicyAnnotationKey: ""}, want: GPUHealthPolicyDefault},
+		{name: "default", annos: map[string]string{GPUHealthPolicyAnnotationKey: "default"}, want: GPUHealthPolicyDefault},
+		{name: "strict", annos: map[string]string{GPUHealthPolicyAnnotationKey: "strict"}, want: GPUHealthPolicyStrict},
+		{name: "invalid value", annos: map[string]string{GPUHealthPolicyAnnotationKey: "Strict"}, want: GPUHealthPolicyDefault, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			policy, err := GetGPUHealthPolicy(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, policy)
+		})
+	}
+}
+
+func TestGetQoSClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    QoSClass
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: QoSGuaranteed},
+		{name: "guaranteed", annos: map[string]string{QoSAnnotationKey: "guaranteed"}, want: QoSGuaranteed},
+		{name: "burstable", annos: map[string]string{QoSAnnotationKey: "burstable"}, want: QoSBurstable},
+		{name: "invalid value", annos: map[string]string{QoSAnnotationKey: "besteffort"}, want: QoSGuaranteed, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			qos, err := GetQoSClass(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, qos)
+		})
+	}
+}
+
+func TestGetVolumeLocality(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    VolumeLocality
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: VolumeLocalityPreferred},
+		{name: "none", annos: map[string]string{VolumeLocalityAnnotationKey: "none"}, want: VolumeLocalityNone},
+		{name: "strict", annos: map[string]string{VolumeLocalityAnnotationKey: "strict"}, want: VolumeLocalityStrict},
+		{name: "invalid value", annos: map[string]string{VolumeLocalityAnnotationKey: "required"}, want: VolumeLocalityPreferred, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			locality, err := GetVolumeLocality(pod, VolumeLocalityPreferred)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, locality)
+		})
+	}
+}
+
+func TestGetSuggestedPlacement(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    *SuggestedPlacement
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: nil},
+		{name: "node and devices", annos: map[string]string{SuggestedPlacementAnnotationKey: "node1:GPU-a, GPU-b"}, want: &SuggestedPlacement{Node: "node1", UUIDs: []string{"GPU-a", "GPU-b"}}},
+		{name: "node only", annos: map[string]string{SuggestedPlacementAnnotationKey: "node1"}, want: &SuggestedPlacement{Node: "node1"}},
+		{name: "no node", annos: map[string]string{SuggestedPlacementAnnotationKey: ":GPU-a"}, want: nil, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			placement, err := GetSuggestedPlacement(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.DeepEqual(t, test.want, placement)
+		})
+	}
+}
+
+func TestGetSuggestedPlacementMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    SuggestedPlacementMode
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: SuggestedPlacementPreferred},
+		{name: "strict", annos: map[string]string{SuggestedPlacementModeAnnotationKey: "strict"}, want: SuggestedPlacementStrict},
+		{name: "invalid value", annos: map[string]string{SuggestedPlacementModeAnnotationKey: "required"}, want: SuggestedPlacementPreferred, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			mode, err := GetSuggestedPlacementMode(pod, SuggestedPlacementPreferred)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, mode)
+		})
+	}
+}
+
+func TestGetContiguousIndices(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    ContiguousIndices
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: ContiguousIndicesNone},
+		{name: "false", annos: map[string]string{ContiguousIndicesAnnotationKey: "false"}, want: ContiguousIndicesNone},
+		{name: "true", annos: map[string]string{ContiguousIndicesAnnotationKey: "true"}, want: ContiguousIndicesPreferred},
+		{name: "preferred", annos: map[string]string{ContiguousIndicesAnnotationKey: "preferred"}, want: ContiguousIndicesPreferred},
+		{name: "required", annos: map[string]string{ContiguousIndicesAnnotationKey: "required"}, want: ContiguousIndicesRequired},
+		{name: "invalid value", annos: map[string]string{ContiguousIndicesAnnotationKey: "strict"}, want: ContiguousIndicesNone, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			mode, err := GetContiguousIndices(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, mode)
+		})
+	}
+}
+
+func TestGetRequiredECCMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    ECCMode
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: ""},
+		{name: "on", annos: map[string]string{RequireECCAnnotationKey: "on"}, want: ECCModeOn},
+		{name: "off", annos: map[string]string{RequireECCAnnotationKey: "off"}, want: ECCModeOff},
+		{name: "invalid value", annos: map[string]string{RequireECCAnnotationKey: "true"}, want: "", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			mode, err := GetRequiredECCMode(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, mode)
+		})
+	}
+}
+
+func TestGetGuaranteedMemory(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    int32
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: 0},
+		{name: "memory", annos: map[string]string{GuaranteedMemoryAnnotationKey: "2048"}, want: 2048},
+		{name: "negative", annos: map[string]string{GuaranteedMemoryAnnotationKey: "-1"}, wantErr: true},
+		{name: "with unit", annos: map[string]string{GuaranteedMemoryAnnotationKey: "2Gi"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			mem, err := GetGuaranteedMemory(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, mem)
+		})
+	}
+}
+
+func TestGetGPUMemoryEach(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    []int32
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: nil},
+		{name: "memory of each gpu", annos: map[string]string{GPUMemoryEachAnnotationKey: "20480, 10240,10240"}, want: []int32{20480, 10240, 10240}},
+		{name: "zero", annos: map[string]string{GPUMemoryEachAnnotationKey: "20480,0"}, wantErr: true},
+		{name: "empty item", annos: map[string]string{GPUMemoryEachAnnotationKey: "20480,,10240"}, wantErr: true},
+		{name: "with unit", annos: map[string]string{GPUMemoryEachAnnotationKey: "20Gi,10Gi"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			mem, err := GetGPUMemoryEach(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.DeepEqual(t, test.want, mem)
+		})
+	}
+}
+
+func TestGetSharedGPU(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    *SharedGPU
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: nil},
+		{name: "memory and cores", annos: map[string]string{SharedGPUAnnotationKey: "gpumem=4000, gpucores=30"}, want: &SharedGPU{Mem: 4000, Cores: 30}},
+		{name: "memory percentage", annos: map[string]string{SharedGPUAnnotationKey: "gpumem-percentage=25"}, want: &SharedGPU{MemPercentage: 25}},
+		{name: "no memory", annos: map[string]string{SharedGPUAnnotationKey: "gpucores=30"}, wantErr: true},
+		{name: "both memories", annos: map[string]string{SharedGPUAnnotationKey: "gpumem=4000,gpumem-percentage=25"}, wantErr: true},
+		{name: "cores over 100", annos: map[string]string{SharedGPUAnnotationKey: "gpumem=4000,gpucores=120"}, wantErr: true},
+		{name: "unknown key", annos: map[string]string{SharedGPUAnnotationKey: "gpumem=4000,gpus=1"}, wantErr: true},
+		{name: "with unit", annos: map[string]string{SharedGPUAnnotationKey: "gpumem=4Gi"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			shared, err := GetSharedGPU(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.DeepEqual(t, test.want, shared)
+		})
+	}
+}
+
+func TestGetPodGPURequest(t *testing.T) {
+	containers := []corev1.Container{{Name: "app"}, {Name: "infer"}, {Name: "proxy"}}
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    *PodGPURequest
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: nil},
+		{name: "memory only", annos: map[string]string{PodGPUMemoryAnnotationKey: "4000"}, want: &PodGPURequest{Count: 1, Mem: 4000, Container: "app"}},
+		{
+			name: "container and sidecars",
+			annos: map[string]string{
+				PodGPUCountAnnotationKey: "2", PodGPUCoresAnnotationKey: "30",
+				PodGPUContainerAnnotationKey: "infer", PodGPUSidecarsAnnotationKey: "app, proxy",
+			},
+			want: &PodGPURequest{Count: 2, Cores: 30, Container: "infer", Sidecars: []string{"app", "proxy"}},
+		},
+		{name: "zero count", annos: map[string]string{PodGPUCountAnnotationKey: "0"}, wantErr: true},
+		{name: "cores over 100", annos: map[string]string{PodGPUCoresAnnotationKey: "120"}, wantErr: true},
+		{name: "unknown container", annos: map[string]string{PodGPUCountAnnotationKey: "1", PodGPUContainerAnnotationKey: "train"}, wantErr: true},
+		{name: "container is a sidecar", annos: map[string]string{PodGPUCountAnnotationKey: "1", PodGPUSidecarsAnnotationKey: "app"}, wantErr: true},
+		{name: "sidecars without request", annos: map[string]string{PodGPUSidecarsAnnotationKey: "proxy"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}, Spec: corev1.PodSpec{Containers: containers}}
+			req, err := GetPodGPURequest(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.DeepEqual(t, test.want, req)
+		})
+	}
+}
+
+func TestCompareDriverVersions(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "535.104.05", b: "535.104.05", want: 0},
+		{a: "535.104.05", b: "535.54.03", want: 1},
+		{a: "535.54.03", b: "535.104.05", want: -1},
+		{a: "535.104.05", b: "535.104.5", want: 0},
+		{a: "535.104.10", b: "535.104.9", want: 1},
+		{a: "550", b: "535.104.05", want: 1},
+		{a: "535", b: "535.0.0", want: 0},
+		{a: "535.104", b: "535.104.05", want: -1},
+		{a: " 470.82.01 ", b: "470.82.01", want: 0},
+		{a: "", b: "535", wantErr: true},
+		{a: "535.104.05-beta", b: "535", wantErr: true},
+		{a: "535..05", b: "535", wantErr: true},
+		{a: "r535", b: "535", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := CompareDriverVersions(test.a, test.b)
+		assert.Equal(t, test.wantErr, err != nil, "%q and %q", test.a, test.b)
+		assert.Equal(t, test.want, got, "%q and %q", test.a, test.b)
+	}
+}
+
+func TestGetMinDriverVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: ""},
+		{name: "version", annos: map[string]string{MinDriverVersionAnnotationKey: " 535.104.05"}, want: "535.104.05"},
+		{name: "major only", annos: map[string]string{MinDriverVersionAnnotationKey: "550"}, want: "550"},
+		{name: "not a version", annos: map[string]string{MinDriverVersionAnnotationKey: "latest"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			got, err := GetMinDriverVersion(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestGetDeviceAffinity(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		labels  map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil},
+		{name: "matching", annos: map[string]string{DeviceAffinityAnnotationKey: "app=miner"}, labels: map[string]string{"app": "miner"}, want: true},
+		{name: "set based", annos: map[string]string{DeviceAffinityAnnotationKey: "app in (miner, trainer),tier!=prod"}, labels: map[string]string{"app": "trainer"}, want: true},
+		{name: "not matching", annos: map[string]string{DeviceAffinityAnnotationKey: "app=miner"}, labels: map[string]string{"app": "infer"}},
+		{name: "invalid selector", annos: map[string]string{DeviceAffinityAnnotationKey: "=miner"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			selector, err := GetDeviceAffinity(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, selector != nil && selector.Matches(labels.Set(test.labels)))
+		})
+	}
+	selector, err := GetDeviceAntiAffinity(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{DeviceAntiAffinityAnnotationKey: "app in (miner"}}})
+	assert.Assert(t, err != nil)
+	assert.Assert(t, selector == nil)
+}
+
+func TestIsShareGPUWithinPod(t *testing.T) {
+	tests := []struct {
+		name  string
+		annos map[string]string
+		want  bool
+	}{
+		{name: "no annotations", annos: nil, want: false},
+		{name: "enabled", annos: map[string]string{ShareGPUWithinPodAnnotationKey: "true"}, want: true},
+		{name: "disabled", annos: map[string]string{ShareGPUWithinPodAnnotationKey: "false"}, want: false},
+		{name: "invalid value", annos: map[string]string{ShareGPUWithinPodAnnotationKey: "yes"}, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			assert.Equal(t, test.want, IsShareGPUWithinPod(pod))
+		})
+	}
+}
+
+func TestGetGPUModelFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		annos map[string]string
+		want  []string
+	}{
+		{name: "no annotations", annos: nil, want: nil},
+		{name: "empty", annos: map[string]string{GPUModelFallbackAnnotationKey: ""}, want: nil},
+		{name: "single model", annos: map[string]string{GPUModelFallbackAnnotationKey: "A100"}, want: []string{"A100"}},
+		{name: "ordered models", annos: map[string]string{GPUModelFallbackAnnotationKey: "A100 > V100>>T4 "}, want: []string{"A100", "V100", "T4"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			assert.DeepEqual(t, test.want, GetGPUModelFallback(pod))
+		})
+	}
+}
+
+func TestGetIncompatibleTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		annos map[string]string
+		want  []string
+	}{
+		{name: "no annotations", annos: nil, want: nil},
+		{name: "empty", annos: map[string]string{IncompatibleTypesAnnotationKey: ""}, want: nil},
+		{name: "single type", annos: map[string]string{IncompatibleTypesAnnotationKey: "training"}, want: []string{"training"}},
+		{name: "several types", annos: map[string]string{IncompatibleTypesAnnotationKey: "training, batch,,"}, want: []string{"training", "batch"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			assert.DeepEqual(t, test.want, GetIncompatibleTypes(pod))
+		})
+	}
+}
+
+func TestSetContainerEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []corev1.EnvVar
+		want []corev1.EnvVar
+	}{
+		{
+			name: "not set",
+			env:  []corev1.EnvVar{{Name: "USER_ENV", Value: "kept"}},
+			want: []corev1.EnvVar{{Name: "USER_ENV", Value: "kept"}, {Name: TaskPriority, Value: "1"}},
+		},
+		{
+			name: "already set",
+			env:  []corev1.EnvVar{{Name: TaskPriority, Value: "0"}, {Name: "USER_ENV", Value: "kept"}},
+			want: []corev1.EnvVar{{Name: "USER_ENV", Value: "kept"}, {Name: TaskPriority, Value: "1"}},
+		},
+		{
+			name: "duplicated",
+			env:  []corev1.EnvVar{{Name: TaskPriority, Value: "1"}, {Name: TaskPriority, Value: "1"}},
+			want: []corev1.EnvVar{{Name: TaskPriority, Value: "1"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctr := &corev1.Container{Env: test.env}
+			SetContainerEnv(ctr, TaskPriority, "1")
+			assert.DeepEqual(t, ctr.Env, test.want)
+		})
+	}
+}
+
+func TestGetDeviceIntent(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    []corev1.ResourceList
+		wantErr bool
+	}{
+		{name: "no annotations", annos: nil, want: nil},
+		{
+			name:  "containers",
+			annos: map[string]string{DeviceIntentAnnotationKey: `[null,{"hami.io/gpu":"1","hami.io/gpumem":"4k"}]`},
+			want:  []corev1.ResourceList{nil, {"hami.io/gpu": resource.MustParse("1"), "hami.io/gpumem": resource.MustParse("4k")}},
+		},
+		{name: "invalid", annos: map[string]string{DeviceIntentAnnotationKey: "hami.io/gpu=1"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annos}}
+			intent, err := GetDeviceIntent(pod)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, len(test.want), len(intent))
+			for i := range test.want {
+				assert.Equal(t, len(test.want[i]), len(intent[i]))
+				for name, q := range test.want[i] {
+					assert.Equal(t, q.Cmp(intent[i][name]), 0, "resource %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseHandshakeTime(t *testing.T) {
+	stamp := time.Date(2025, 6, 13, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, FormatHandshakeTime(stamp.In(time.FixedZone("UTC+8", 8*3600))), "2025-06-13T09:00:00Z")
+
+	parsed, err := ParseHandshakeTime(FormatHandshakeTime(stamp))
+	assert.NilError(t, err)
+	assert.Assert(t, parsed.Equal(stamp))
+	parsed, err = ParseHandshakeTime("2025-06-13T17:00:00+08:00")
+	assert.NilError(t, err)
+	assert.Assert(t, parsed.Equal(stamp))
+	// Legacy timestamps are in the local time of their writer.
+	parsed, err = ParseHandshakeTime(stamp.Local().Format(time.DateTime))
+	assert.NilError(t, err)
+	assert.Assert(t, parsed.Equal(stamp))
+
+	_, err = ParseHandshakeTime("yesterday")
+	assert.Assert(t, err != nil)
+}
+
+func TestGetScheduleWindow(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *ScheduleWindow
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "22:00-06:00", want: &ScheduleWindow{Start: 22 * time.Hour, End: 6 * time.Hour}},
+		{value: " 09:30 - 17:45 ", want: &ScheduleWindow{Start: 9*time.Hour + 30*time.Minute, End: 17*time.Hour + 45*time.Minute}},
+		{value: "22:00", wantErr: true},
+		{value: "22:00-24:00", wantErr: true},
+		{value: "08:00-08:00", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{ScheduleWindowAnnotationKey: test.value}}}
+			got, err := GetScheduleWindow(pod)
+			if test.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, test.want)
+		})
+	}
+
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	night := ScheduleWindow{Start: 22 * time.Hour, End: 6 * time.Hour}
+	assert.Equal(t, night.NextOpen(day.Add(23*time.Hour)), day.Add(23*time.Hour))
+	assert.Equal(t, night.NextOpen(day.Add(5*time.Hour)), day.Add(5*time.Hour))
+	assert.Equal(t, night.NextOpen(day.Add(6*time.Hour)), day.Add(22*time.Hour))
+	// Times are compared in UTC, whatever the zone they're in.
+	assert.Equal(t, night.NextOpen(day.Add(12*time.Hour).In(time.FixedZone("UTC+8", 8*3600))), day.Add(22*time.Hour))
+	office := ScheduleWindow{Start: 9 * time.Hour, End: 17 * time.Hour}
+	assert.Equal(t, office.NextOpen(day.Add(8*time.Hour)), day.Add(9*time.Hour))
+	assert.Equal(t, office.NextOpen(day.Add(17*time.Hour)), day.Add(33*time.Hour))
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/test/utils"
+)
+
+const (
+	MockDeviceResource       = "hami.io/mock-device"
+	MockMemoryResource       = "hami.io/mock-memory"
+	MockCoresResource        = "hami.io/mock-cores"
+	MockAllocatedAnnotation  = "hami.io/mock-devices-allocated"
+	MockPodMemory            = "1024"
+	MockPodCores             = "30"
+	MockNamespace            = "default"
+	MockPodImage             = "ubuntu:22.04"
+	MockPodDeleteTimeout     = 120 * time.Second
+	MockPodDeleteInterval    = 5 * time.Second
+	MockVisibleDevicesEnv    = "MOCK_VISIBLE_DEVICES"
+	MockDeviceMemoryLimitEnv = "MOCK_DEVICE_MEMORY_LIMIT_0"
+	MockDeviceCoreLimitEnv   = "MOCK_DEVICE_CORE_LIMIT"
+)
+
+// The mock devices are advertised by the mock device plugin, enabled by devices.mock.enabled of the chart, e.g. on
+// the kind cluster of hack/e2e-mock-kind.sh.
+var _ = ginkgo.Describe("[Mock] Mock device E2E Tests", ginkgo.Ordered, func() {
+	var (
+		clientSet = utils.GetClientSet()
+		nodeName  string
+	)
+
+	ginkgo.BeforeAll(func() {
+		nodes, err := utils.GetNodes(clientSet)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		for _, node := range nodes.Items {
+			if quantity, ok := node.Status.Allocatable[MockDeviceResource]; ok && !quantity.IsZero() {
+				nodeName = node.Name
+				break
+			}
+		}
+		if nodeName == "" {
+			ginkgo.Skip("No node advertises " + MockDeviceResource + ", the mock vendor isn't enabled")
+		}
+	})
+
+	ginkgo.It("schedules a pod on a mock device and injects its envs", func() {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mock-pod-" + utils.GetRandom(),
+				Namespace: MockNamespace,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:    "mock-container",
+					Image:   MockPodImage,
+					Command: []string{"/bin/sh"},
+					Args:    []string{"-c", "sleep 86400"},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							MockDeviceResource: resource.MustParse("1"),
+							MockMemoryResource: resource.MustParse(MockPodMemory),
+							MockCoresResource:  resource.MustParse(MockPodCores),
+						},
+					},
+				}},
+			},
+		}
+		ginkgo.DeferCleanup(func() {
+			ginkgo.By("DeferCleanup: Deleting pod " + pod.Name)
+			gomega.Expect(utils.DeletePod(clientSet, pod.Namespace, pod.Name)).To(gomega.Succeed())
+			gomega.Eventually(func() bool {
+				_, err := clientSet.CoreV1().Pods(pod.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+				return err != nil
+			}, MockPodDeleteTimeout, MockPodDeleteInterval).Should(gomega.BeTrue())
+		})
+
+		ginkgo.By("Creating pod " + pod.Name + " in namespace " + pod.Namespace)
+		_, err := utils.CreatePod(clientSet, pod, pod.Namespace)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ginkgo.By("Verifying pod " + pod.Name + " is in running status")
+		gomega.Expect(utils.WaitForPodRunning(clientSet, pod.Namespace, pod.Name)).To(gomega.Succeed())
+
+		ginkgo.By("Verifying the assignment annotation of pod " + pod.Name)
+		created, err := clientSet.CoreV1().Pods(pod.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(created.Spec.NodeName).NotTo(gomega.BeEmpty())
+		deviceID := gomega.MatchRegexp(fmt.Sprintf(`^MOCK-%s-\d+$`, created.Spec.NodeName))
+		gomega.Expect(created.Annotations).To(gomega.HaveKey(MockAllocatedAnnotation))
+		assigned := created.Annotations[MockAllocatedAnnotation]
+		gomega.Expect(assigned).To(gomega.MatchRegexp(
+			fmt.Sprintf(`^MOCK-%s-\d+,Mock,%s,%s:;$`, created.Spec.NodeName, MockPodMemory, MockPodCores)))
+
+		ginkgo.By("Verifying the envs injected in pod " + pod.Name)
+		output, err := utils.KubectlExecInPod(pod.Namespace, pod.Name, "env")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred(), "env command failed")
+		envs := parseEnvs(string(output))
+		gomega.Expect(envs).To(gomega.HaveKeyWithValue(MockVisibleDevicesEnv, deviceID))
+		gomega.Expect(assigned).To(gomega.HavePrefix(envs[MockVisibleDevicesEnv] + ","))
+		gomega.Expect(envs).To(gomega.HaveKeyWithValue(MockDeviceMemoryLimitEnv, MockPodMemory+"m"))
+		gomega.Expect(envs).To(gomega.HaveKeyWithValue(MockDeviceCoreLimitEnv, MockPodCores))
+	})
+})
+
+// parseEnvs parses the output of env into the values of the envs.
+func parseEnvs(output string) map[string]string {
+	envs := make(map[string]string)
+	for line := range strings.SplitSeq(output, "\n") {
+		if name, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			envs[name] = value
+		}
+	}
+	return envs
+}